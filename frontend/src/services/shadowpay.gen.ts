@@ -0,0 +1,2750 @@
+// Code generated by genclient from the proxy API handlers. DO NOT EDIT.
+// Regenerate with: go generate ./internal/api (in server/)
+
+const API_BASE = import.meta.env.VITE_API_URL || "http://localhost:8080/api";
+
+export type QueryValue = string | number | boolean | undefined;
+
+export interface ClientOptions {
+  baseUrl?: string;
+  headers?: Record<string, string>;
+  fetch?: typeof fetch;
+}
+
+export class ShadowPayError extends Error {
+  readonly status: number;
+  readonly body: ErrorDocument | undefined;
+
+  constructor(status: number, message: string, body?: ErrorDocument) {
+    super(message);
+    this.name = "ShadowPayError";
+    this.status = status;
+    this.body = body;
+  }
+}
+
+export class ShadowPayClient {
+  private readonly baseUrl: string;
+  private readonly headers: Record<string, string>;
+  private readonly fetchFn: typeof fetch;
+
+  constructor(options: ClientOptions = {}) {
+    this.baseUrl = options.baseUrl ?? API_BASE;
+    this.headers = options.headers ?? {};
+    this.fetchFn = options.fetch ?? fetch.bind(globalThis);
+  }
+
+  /** Sends a request and returns the response, throwing ShadowPayError unless it is 2xx. */
+  async raw(
+    method: string,
+    path: string,
+    body?: unknown,
+    query?: Record<string, QueryValue>
+  ): Promise<Response> {
+    let url = this.baseUrl + path;
+    if (query) {
+      const params = new URLSearchParams();
+      for (const [key, value] of Object.entries(query)) {
+        if (value !== undefined) {
+          params.set(key, String(value));
+        }
+      }
+      const qs = params.toString();
+      if (qs) {
+        url += "?" + qs;
+      }
+    }
+    const headers: Record<string, string> = { ...this.headers };
+    if (body !== undefined) {
+      headers["Content-Type"] = "application/json";
+    }
+    const response = await this.fetchFn(url, {
+      method,
+      headers,
+      body: body === undefined ? undefined : JSON.stringify(body),
+    });
+    if (!response.ok) {
+      const doc = (await response.json().catch(() => undefined)) as
+        | ErrorDocument
+        | undefined;
+      throw new ShadowPayError(
+        response.status,
+        doc?.error || response.statusText,
+        doc
+      );
+    }
+    return response;
+  }
+
+  private async request<T>(
+    method: string,
+    path: string,
+    body?: unknown,
+    query?: Record<string, QueryValue>
+  ): Promise<T> {
+    const response = await this.raw(method, path, body, query);
+    return (await response.json()) as T;
+  }
+
+  private async send(
+    method: string,
+    path: string,
+    body?: unknown,
+    query?: Record<string, QueryValue>
+  ): Promise<void> {
+    await this.raw(method, path, body, query);
+  }
+
+  /** GET /addressbook */
+  addressBookSearch(query?: {
+    kind?: QueryValue;
+    limit?: QueryValue;
+    q?: QueryValue;
+  }): Promise<{
+    entries: AddressbookEntry[];
+  }> {
+    return this.request("GET", "/addressbook", undefined, query);
+  }
+
+  /** POST /addressbook */
+  addressBookAdd(body: AddressbookAddRequest): Promise<AddressbookEntry> {
+    return this.request("POST", "/addressbook", body);
+  }
+
+  /** GET /addressbook/recent */
+  addressBookRecent(query?: {
+    kind?: QueryValue;
+  }): Promise<{
+    recent: Recent[];
+  }> {
+    return this.request("GET", "/addressbook/recent", undefined, query);
+  }
+
+  /** POST /addressbook/recent */
+  addressBookTouch(body: {
+    kind: string;
+    value: string;
+  }): Promise<void> {
+    return this.send("POST", "/addressbook/recent", body);
+  }
+
+  /** DELETE /addressbook/{id} */
+  addressBookRemove(id: string): Promise<void> {
+    return this.send("DELETE", `/addressbook/${encodeURIComponent(id)}`);
+  }
+
+  /** GET /addressbook/{id} */
+  addressBookGet(id: string): Promise<AddressbookEntry> {
+    return this.request("GET", `/addressbook/${encodeURIComponent(id)}`);
+  }
+
+  /** PATCH /addressbook/{id} */
+  addressBookUpdate(id: string, body: AddressbookUpdateRequest): Promise<AddressbookEntry> {
+    return this.request("PATCH", `/addressbook/${encodeURIComponent(id)}`, body);
+  }
+
+  /** GET /artifacts */
+  artifactManifest(): Promise<Manifest> {
+    return this.request("GET", "/artifacts");
+  }
+
+  /** GET /artifacts/{circuit}/{kind} */
+  artifactGet(circuit: string, kind: string): Promise<Response> {
+    return this.raw("GET", `/artifacts/${encodeURIComponent(circuit)}/${encodeURIComponent(kind)}`);
+  }
+
+  /** POST /authorization/authorize */
+  authorizationAuthorize(body: AuthorizeSpendingRequest): Promise<AuthorizeSpendingResponse> {
+    return this.request("POST", "/authorization/authorize", body);
+  }
+
+  /** POST /authorization/authorize-multisig */
+  authorizationMultisig(body: MultisigAuthorizeRequest): Promise<AuthorizeSpendingResponse> {
+    return this.request("POST", "/authorization/authorize-multisig", body);
+  }
+
+  /** GET /authorization/list/{wallet} */
+  authorizationList(wallet: string): Promise<ListAuthorizationsResponse> {
+    return this.request("GET", `/authorization/list/${encodeURIComponent(wallet)}`);
+  }
+
+  /** POST /authorization/revoke */
+  authorizationRevoke(body: RevokeAuthorizationRequest): Promise<RevokeAuthorizationResponse> {
+    return this.request("POST", "/authorization/revoke", body);
+  }
+
+  /** POST /authorization/update */
+  authorizationUpdate(body: UpdateAuthorizationRequest): Promise<UpdateAuthorizationResponse> {
+    return this.request("POST", "/authorization/update", body);
+  }
+
+  /** GET /authorization/usage/{wallet}/{service} */
+  authorizationUsage(wallet: string, service: string): Promise<UsageHistoryResponse> {
+    return this.request("GET", `/authorization/usage/${encodeURIComponent(wallet)}/${encodeURIComponent(service)}`);
+  }
+
+  /** POST /bots/link */
+  botLink(body: {
+    code: string;
+  }): Promise<BotsLink> {
+    return this.request("POST", "/bots/link", body);
+  }
+
+  /** POST /bots/payment-requests */
+  botPaymentRequestCreate(body: BotsCreateRequest): Promise<PaymentRequest> {
+    return this.request("POST", "/bots/payment-requests", body);
+  }
+
+  /** GET /bots/payment-requests/{id} */
+  botPaymentRequestGet(id: string): Promise<PaymentRequest> {
+    return this.request("GET", `/bots/payment-requests/${encodeURIComponent(id)}`);
+  }
+
+  /** GET /checkout/assets/{name} */
+  checkoutAsset(name: string): Promise<Response> {
+    return this.raw("GET", `/checkout/assets/${encodeURIComponent(name)}`);
+  }
+
+  /** GET /checkout/sessions */
+  checkoutList(): Promise<{
+    sessions: CheckoutSession[];
+  }> {
+    return this.request("GET", "/checkout/sessions");
+  }
+
+  /** POST /checkout/sessions */
+  checkoutCreate(body: CheckoutCreateRequest): Promise<CheckoutSession> {
+    return this.request("POST", "/checkout/sessions", body);
+  }
+
+  /** GET /checkout/sessions/{id} */
+  checkoutGet(id: string): Promise<CheckoutSession> {
+    return this.request("GET", `/checkout/sessions/${encodeURIComponent(id)}`);
+  }
+
+  /** POST /checkout/sessions/{id}/cancel */
+  checkoutCancel(id: string): Promise<CheckoutSession> {
+    return this.request("POST", `/checkout/sessions/${encodeURIComponent(id)}/cancel`);
+  }
+
+  /** POST /checkout/sessions/{id}/complete */
+  checkoutComplete(id: string): Promise<CheckoutSession> {
+    return this.request("POST", `/checkout/sessions/${encodeURIComponent(id)}/complete`);
+  }
+
+  /** POST /checkout/sessions/{id}/pay */
+  checkoutPay(id: string, body: PayRequest): Promise<PayResponse> {
+    return this.request("POST", `/checkout/sessions/${encodeURIComponent(id)}/pay`, body);
+  }
+
+  /** GET /checkout/{id} */
+  checkoutPage(id: string): Promise<Response> {
+    return this.raw("GET", `/checkout/${encodeURIComponent(id)}`);
+  }
+
+  /** GET /customers */
+  customerList(query?: {
+    alias?: QueryValue;
+  }): Promise<{
+    customers: Customer[];
+  }> {
+    return this.request("GET", "/customers", undefined, query);
+  }
+
+  /** GET /customers/stats */
+  customerStats(): Promise<CustomersStats> {
+    return this.request("GET", "/customers/stats");
+  }
+
+  /** DELETE /customers/{commitment} */
+  customerDelete(commitment: string): Promise<void> {
+    return this.send("DELETE", `/customers/${encodeURIComponent(commitment)}`);
+  }
+
+  /** GET /customers/{commitment} */
+  customerGet(commitment: string): Promise<Customer> {
+    return this.request("GET", `/customers/${encodeURIComponent(commitment)}`);
+  }
+
+  /** PUT /customers/{commitment} */
+  customerSetAlias(commitment: string, body: AliasRequest): Promise<Customer> {
+    return this.request("PUT", `/customers/${encodeURIComponent(commitment)}`, body);
+  }
+
+  /** POST /customers/{commitment}/purchases */
+  customerRecordPurchase(commitment: string, body: PurchaseRequest): Promise<Customer> {
+    return this.request("POST", `/customers/${encodeURIComponent(commitment)}/purchases`, body);
+  }
+
+  /** GET /escrow/balance/{wallet} */
+  escrowBalance(wallet: string): Promise<EscrowBalanceResponse> {
+    return this.request("GET", `/escrow/balance/${encodeURIComponent(wallet)}`);
+  }
+
+  /** GET /escrow/balances/{wallet} */
+  escrowBalances(wallet: string): Promise<AllBalancesResponse> {
+    return this.request("GET", `/escrow/balances/${encodeURIComponent(wallet)}`);
+  }
+
+  /** GET /events */
+  eventList(query?: {
+    cursor?: QueryValue;
+    from?: QueryValue;
+    limit?: QueryValue;
+    type?: QueryValue;
+  }): Promise<HistoryPage> {
+    return this.request("GET", "/events", undefined, query);
+  }
+
+  /** GET /events/stream */
+  eventStream(query?: {
+    types?: QueryValue;
+  }): Promise<Response> {
+    return this.raw("GET", "/events/stream", undefined, query);
+  }
+
+  /** GET /events/subscribers */
+  eventSubscribers(): Promise<{
+    subscribers: SubscriberStats[];
+  }> {
+    return this.request("GET", "/events/subscribers");
+  }
+
+  /** GET /events/{id} */
+  eventGet(id: string): Promise<Event> {
+    return this.request("GET", `/events/${encodeURIComponent(id)}`);
+  }
+
+  /** POST /events/{id}/redeliver */
+  eventRedeliver(id: string, query?: {
+    subscriber?: QueryValue;
+  }): Promise<{
+    id: string;
+    subscribers: string[];
+  }> {
+    return this.request("POST", `/events/${encodeURIComponent(id)}/redeliver`, undefined, query);
+  }
+
+  /** GET /fees */
+  feeList(query?: {
+    kind?: QueryValue;
+    wallet?: QueryValue;
+  }): Promise<{
+    fees: LedgerEntry[];
+  }> {
+    return this.request("GET", "/fees", undefined, query);
+  }
+
+  /** POST /fees */
+  feeRecord(body: LedgerRecordRequest): Promise<LedgerEntry> {
+    return this.request("POST", "/fees", body);
+  }
+
+  /** GET /fees/export */
+  feeExport(query?: {
+    format?: QueryValue;
+    kind?: QueryValue;
+    wallet?: QueryValue;
+  }): Promise<Response> {
+    return this.raw("GET", "/fees/export", undefined, query);
+  }
+
+  /** POST /fees/network */
+  feeRecordNetwork(body: {
+    wallet: string;
+    signature: string;
+  }): Promise<LedgerEntry> {
+    return this.request("POST", "/fees/network", body);
+  }
+
+  /** GET /fees/summary */
+  feeSummary(query?: {
+    kind?: QueryValue;
+    wallet?: QueryValue;
+  }): Promise<{
+    months: MonthlySummary[];
+  }> {
+    return this.request("GET", "/fees/summary", undefined, query);
+  }
+
+  /** GET /graphql */
+  graphQLGet(query?: {
+    operationName?: QueryValue;
+    query?: QueryValue;
+    variables?: QueryValue;
+  }): Promise<GraphqlResponse> {
+    return this.request("GET", "/graphql", undefined, query);
+  }
+
+  /** POST /graphql */
+  graphQLPost(body: GraphqlRequest, query?: {
+    operationName?: QueryValue;
+    query?: QueryValue;
+    variables?: QueryValue;
+  }): Promise<GraphqlResponse> {
+    return this.request("POST", "/graphql", body, query);
+  }
+
+  /** GET /holds */
+  holdList(query?: {
+    status?: QueryValue;
+  }): Promise<{
+    holds: Hold[];
+  }> {
+    return this.request("GET", "/holds", undefined, query);
+  }
+
+  /** GET /holds/{id} */
+  holdGet(id: string): Promise<Hold> {
+    return this.request("GET", `/holds/${encodeURIComponent(id)}`);
+  }
+
+  /** POST /holds/{id}/dispute */
+  holdDispute(id: string, body: {
+    reason: string;
+  }): Promise<Hold> {
+    return this.request("POST", `/holds/${encodeURIComponent(id)}/dispute`, body);
+  }
+
+  /** POST /holds/{id}/refund */
+  holdRefund(id: string, body: {
+    reason: string;
+  }): Promise<Hold> {
+    return this.request("POST", `/holds/${encodeURIComponent(id)}/refund`, body);
+  }
+
+  /** POST /holds/{id}/release */
+  holdRelease(id: string, body: {
+    reason: string;
+  }): Promise<Hold> {
+    return this.request("POST", `/holds/${encodeURIComponent(id)}/release`, body);
+  }
+
+  /** GET /invoices */
+  invoiceList(query?: {
+    status?: QueryValue;
+  }): Promise<{
+    invoices: Invoice[];
+  }> {
+    return this.request("GET", "/invoices", undefined, query);
+  }
+
+  /** POST /invoices */
+  invoiceCreate(body: InvoiceCreateRequest): Promise<Invoice> {
+    return this.request("POST", "/invoices", body);
+  }
+
+  /** POST /invoices/webhook */
+  invoiceWebhook(body: WebhookEvent): Promise<{
+    received: boolean;
+    invoice: Invoice;
+  }> {
+    return this.request("POST", "/invoices/webhook", body);
+  }
+
+  /** GET /invoices/{id} */
+  invoiceGet(id: string): Promise<Invoice> {
+    return this.request("GET", `/invoices/${encodeURIComponent(id)}`);
+  }
+
+  /** PATCH /invoices/{id} */
+  invoiceUpdate(id: string, body: InvoiceUpdateRequest): Promise<Invoice> {
+    return this.request("PATCH", `/invoices/${encodeURIComponent(id)}`, body);
+  }
+
+  /** GET /invoices/{id}/pdf */
+  invoicePDF(id: string): Promise<Response> {
+    return this.raw("GET", `/invoices/${encodeURIComponent(id)}/pdf`);
+  }
+
+  /** POST /invoices/{id}/refresh */
+  invoiceRefresh(id: string): Promise<Invoice> {
+    return this.request("POST", `/invoices/${encodeURIComponent(id)}/refresh`);
+  }
+
+  /** POST /invoices/{id}/void */
+  invoiceVoid(id: string): Promise<Invoice> {
+    return this.request("POST", `/invoices/${encodeURIComponent(id)}/void`);
+  }
+
+  /** GET /l/{code} */
+  paymentLinkResolve(code: string): Promise<Response> {
+    return this.raw("GET", `/l/${encodeURIComponent(code)}`);
+  }
+
+  /** GET /links */
+  paymentLinkList(): Promise<{
+    links: PaymentlinkLink[];
+  }> {
+    return this.request("GET", "/links");
+  }
+
+  /** POST /links */
+  paymentLinkCreate(body: PaymentlinkCreateRequest): Promise<PaymentlinkLink> {
+    return this.request("POST", "/links", body);
+  }
+
+  /** GET /links/{code} */
+  paymentLinkGet(code: string): Promise<PaymentlinkLink> {
+    return this.request("GET", `/links/${encodeURIComponent(code)}`);
+  }
+
+  /** POST /links/{code}/deactivate */
+  paymentLinkDeactivate(code: string): Promise<PaymentlinkLink> {
+    return this.request("POST", `/links/${encodeURIComponent(code)}/deactivate`);
+  }
+
+  /** GET /links/{code}/stats */
+  paymentLinkStats(code: string): Promise<PaymentlinkStats> {
+    return this.request("GET", `/links/${encodeURIComponent(code)}/stats`);
+  }
+
+  /** POST /merchant/analytics */
+  merchantAnalytics(body: AnalyticsRequest, query?: {
+    no_cache?: QueryValue;
+  }): Promise<AnalyticsResponse> {
+    return this.request("POST", "/merchant/analytics", body, query);
+  }
+
+  /** GET /merchant/earnings */
+  merchantEarnings(): Promise<EarningsResponse> {
+    return this.request("GET", "/merchant/earnings");
+  }
+
+  /** POST /merchant/withdraw */
+  merchantWithdraw(body: MerchantWithdrawRequest): Promise<MerchantWithdrawResponse> {
+    return this.request("POST", "/merchant/withdraw", body);
+  }
+
+  /** GET /messages */
+  messageList(query?: {
+    commitment?: QueryValue;
+    merchant_key?: QueryValue;
+  }): Promise<{
+    conversations: Conversation[];
+  }> {
+    return this.request("GET", "/messages", undefined, query);
+  }
+
+  /** POST /messages */
+  messageStart(body: StartRequest): Promise<Conversation> {
+    return this.request("POST", "/messages", body);
+  }
+
+  /** GET /messages/{id} */
+  messageGet(id: string, query?: {
+    after?: QueryValue;
+  }): Promise<Conversation> {
+    return this.request("GET", `/messages/${encodeURIComponent(id)}`, undefined, query);
+  }
+
+  /** POST /messages/{id} */
+  messagePost(id: string, body: PostRequest): Promise<Message> {
+    return this.request("POST", `/messages/${encodeURIComponent(id)}`, body);
+  }
+
+  /** DELETE /notifications/preferences/{wallet} */
+  notificationPreferenceDelete(wallet: string): Promise<void> {
+    return this.send("DELETE", `/notifications/preferences/${encodeURIComponent(wallet)}`);
+  }
+
+  /** GET /notifications/preferences/{wallet} */
+  notificationPreferenceGet(wallet: string): Promise<Preference> {
+    return this.request("GET", `/notifications/preferences/${encodeURIComponent(wallet)}`);
+  }
+
+  /** PUT /notifications/preferences/{wallet} */
+  notificationPreferenceSet(wallet: string, body: Preference): Promise<Preference> {
+    return this.request("PUT", `/notifications/preferences/${encodeURIComponent(wallet)}`, body);
+  }
+
+  /** GET /notifications/stats */
+  notificationStats(): Promise<{
+    channels: string[];
+    stats: Record<string, NotifyStats>;
+  }> {
+    return this.request("GET", "/notifications/stats");
+  }
+
+  /** GET /nullifiers */
+  nullifierExport(query?: {
+    cursor?: QueryValue;
+    limit?: QueryValue;
+  }): Promise<ExportPage> {
+    return this.request("GET", "/nullifiers", undefined, query);
+  }
+
+  /** POST /payment/authorize */
+  paymentAuthorize(body: AuthorizeRequest): Promise<AuthorizeResponse> {
+    return this.request("POST", "/payment/authorize", body);
+  }
+
+  /** POST /payment/deposit */
+  paymentDeposit(body: DepositRequest): Promise<DepositResponse> {
+    return this.request("POST", "/payment/deposit", body);
+  }
+
+  /** POST /payment/prepare */
+  paymentPrepare(body: {
+    receiver_commitment: string;
+    amount: number;
+    token_mint?: string;
+    generate_stealth?: boolean;
+    recipient_public_key?: string;
+  }): Promise<{
+    payment_hash: string;
+    commitment: string;
+    message: string;
+    transaction: string;
+    stealth_address: {
+      ephemeral_public_key: string;
+      ephemeral_private_key: string;
+      recipient_public_key: string;
+    };
+  } | PrepareResponse> {
+    return this.request("POST", "/payment/prepare", body);
+  }
+
+  /** POST /payment/refresh-access */
+  paymentRefreshAccess(body: {
+    access_token: string;
+  }): Promise<AuthorizeResponse> {
+    return this.request("POST", "/payment/refresh-access", body);
+  }
+
+  /** POST /payment/settle */
+  paymentSettle(body: {
+    token_mint?: string;
+    token_amount?: number;
+    customer_commitment?: string;
+    hold_seconds?: number;
+    payer_wallet?: string;
+    x402Version: number;
+    paymentHeader: string;
+    resource: string;
+    paymentRequirements: Requirements;
+    computeBudget?: ComputeBudget | null;
+    hold?: boolean;
+  }): Promise<{
+    hold?: Hold | null;
+    success: boolean;
+    tx_sig: string;
+    message: string;
+  } | {
+    swap?: SwapReceipt | null;
+    success: boolean;
+    tx_sig: string;
+    message: string;
+  }> {
+    return this.request("POST", "/payment/settle", body);
+  }
+
+  /** GET /payment/status/{paymentHash} */
+  paymentStatus(paymentHash: string): Promise<PaymentStatusResponse> {
+    return this.request("GET", `/payment/status/${encodeURIComponent(paymentHash)}`);
+  }
+
+  /** GET /payment/supported */
+  paymentSupported(): Promise<SupportedResponse> {
+    return this.request("GET", "/payment/supported");
+  }
+
+  /** POST /payment/verify-access */
+  paymentVerifyAccess(body: {
+    access_token: string;
+  }): Promise<VerifyAccessResponse> {
+    return this.request("POST", "/payment/verify-access", body);
+  }
+
+  /** POST /payment/withdraw */
+  paymentWithdraw(body: PaymentWithdrawRequest): Promise<PaymentWithdrawResponse> {
+    return this.request("POST", "/payment/withdraw", body);
+  }
+
+  /** GET /platform/earnings */
+  platformEarnings(): Promise<EarningsSummary> {
+    return this.request("GET", "/platform/earnings");
+  }
+
+  /** GET /platform/merchants */
+  platformList(): Promise<ListResponse> {
+    return this.request("GET", "/platform/merchants");
+  }
+
+  /** POST /platform/merchants */
+  platformCreate(body: PlatformCreateRequest): Promise<SubMerchant> {
+    return this.request("POST", "/platform/merchants", body);
+  }
+
+  /** GET /platform/merchants/{id} */
+  platformGet(id: string): Promise<SubMerchant> {
+    return this.request("GET", `/platform/merchants/${encodeURIComponent(id)}`);
+  }
+
+  /** PATCH /platform/merchants/{id} */
+  platformUpdate(id: string, body: PlatformUpdateRequest): Promise<SubMerchant> {
+    return this.request("PATCH", `/platform/merchants/${encodeURIComponent(id)}`, body);
+  }
+
+  /** GET /platform/merchants/{id}/earnings */
+  platformMerchantEarnings(id: string): Promise<Earnings> {
+    return this.request("GET", `/platform/merchants/${encodeURIComponent(id)}/earnings`);
+  }
+
+  /** POST /platform/merchants/{id}/keys */
+  platformCreateKey(id: string): Promise<KeysResponse> {
+    return this.request("POST", `/platform/merchants/${encodeURIComponent(id)}/keys`);
+  }
+
+  /** POST /platform/merchants/{id}/route */
+  platformRoute(id: string, body: Requirements): Promise<Requirements> {
+    return this.request("POST", `/platform/merchants/${encodeURIComponent(id)}/route`, body);
+  }
+
+  /** GET /pool/balance/{wallet} */
+  poolBalance(wallet: string): Promise<PoolBalanceResponse> {
+    return this.request("GET", `/pool/balance/${encodeURIComponent(wallet)}`);
+  }
+
+  /** POST /pool/deposit */
+  poolDeposit(body: {
+    wallet_address: string;
+    amount: number;
+    private_key?: string;
+    deposit_to_umbra?: boolean;
+    umbra_destination?: string;
+  }): Promise<Record<string, unknown>> {
+    return this.request("POST", "/pool/deposit", body);
+  }
+
+  /** GET /pool/deposit-address */
+  poolDepositAddress(): Promise<DepositAddressResponse> {
+    return this.request("GET", "/pool/deposit-address");
+  }
+
+  /** POST /pool/withdraw */
+  poolWithdraw(body: PoolWithdrawRequest): Promise<PoolWithdrawResponse> {
+    return this.request("POST", "/pool/withdraw", body);
+  }
+
+  /** GET /pool/withdraw/quote */
+  poolWithdrawQuote(query?: {
+    amount?: QueryValue;
+  }): Promise<WithdrawQuote> {
+    return this.request("GET", "/pool/withdraw/quote", undefined, query);
+  }
+
+  /** POST /privacy/decrypt */
+  privacyDecrypt(body: DecryptRequest): Promise<DecryptResponse> {
+    return this.request("POST", "/privacy/decrypt", body);
+  }
+
+  /** GET /receipts/{wallet} */
+  receiptsList(wallet: string): Promise<ListUserReceiptsResponse> {
+    return this.request("GET", `/receipts/${encodeURIComponent(wallet)}`);
+  }
+
+  /** POST /receipts/{wallet}/nft */
+  receiptNFTMint(wallet: string, body: {
+    commitment: string;
+  }): Promise<MintNFTResponse> {
+    return this.request("POST", `/receipts/${encodeURIComponent(wallet)}/nft`, body);
+  }
+
+  /** GET /reconciliation */
+  reconciliationReport(): Promise<ReconcileReport> {
+    return this.request("GET", "/reconciliation");
+  }
+
+  /** POST /reconciliation/run */
+  reconciliationRun(): Promise<ReconcileReport> {
+    return this.request("POST", "/reconciliation/run");
+  }
+
+  /** POST /registry */
+  registryRegister(body: RegistryRegisterRequest): Promise<RegistryRecord> {
+    return this.request("POST", "/registry", body);
+  }
+
+  /** GET /registry/{key} */
+  registryLookup(key: string): Promise<RegistryRecord> {
+    return this.request("GET", `/registry/${encodeURIComponent(key)}`);
+  }
+
+  /** POST /registry/{key}/unregister */
+  registryUnregister(key: string, body: UnregisterRequest): Promise<void> {
+    return this.send("POST", `/registry/${encodeURIComponent(key)}/unregister`, body);
+  }
+
+  /** GET /resolve/reverse/{address} */
+  resolveReverse(address: string): Promise<{
+    address: string;
+    name: string;
+  }> {
+    return this.request("GET", `/resolve/reverse/${encodeURIComponent(address)}`);
+  }
+
+  /** GET /resolve/{name} */
+  resolveName(name: string): Promise<{
+    name: string;
+    address: string;
+  }> {
+    return this.request("GET", `/resolve/${encodeURIComponent(name)}`);
+  }
+
+  /** POST /session/connect */
+  sessionConnect(body: {
+    wallet_address: string;
+    nonce: string;
+    signature: string;
+  }): Promise<SessionSession> {
+    return this.request("POST", "/session/connect", body);
+  }
+
+  /** GET /session/me */
+  sessionMe(): Promise<{
+    wallet_address: string;
+    expires_at: number;
+  }> {
+    return this.request("GET", "/session/me");
+  }
+
+  /** POST /session/nonce */
+  sessionNonce(body: {
+    wallet_address: string;
+  }): Promise<Challenge> {
+    return this.request("POST", "/session/nonce", body);
+  }
+
+  /** GET /settlements */
+  settlementList(query?: {
+    status?: QueryValue;
+  }): Promise<{
+    jobs: Job[];
+  }> {
+    return this.request("GET", "/settlements", undefined, query);
+  }
+
+  /** POST /settlements */
+  settlementEnqueue(body: {
+    token_mint?: string;
+    token_amount?: number;
+    customer_commitment?: string;
+    hold_seconds?: number;
+    payer_wallet?: string;
+    x402Version: number;
+    paymentHeader: string;
+    resource: string;
+    paymentRequirements: Requirements;
+    computeBudget?: ComputeBudget | null;
+    hold?: boolean;
+  }): Promise<Job> {
+    return this.request("POST", "/settlements", body);
+  }
+
+  /** GET /settlements/stats */
+  settlementStats(): Promise<SettlementStats> {
+    return this.request("GET", "/settlements/stats");
+  }
+
+  /** GET /settlements/{id} */
+  settlementGet(id: string): Promise<Job> {
+    return this.request("GET", `/settlements/${encodeURIComponent(id)}`);
+  }
+
+  /** POST /settlements/{id}/retry */
+  settlementRetry(id: string): Promise<Job> {
+    return this.request("POST", `/settlements/${encodeURIComponent(id)}/retry`);
+  }
+
+  /** POST /shadowid/auto-register */
+  shadowIDAutoRegister(body: AutoRegisterRequest): Promise<AutoRegisterResponse> {
+    return this.request("POST", "/shadowid/auto-register", body);
+  }
+
+  /** GET /shadowid/leaves */
+  shadowIDSync(query?: {
+    since?: QueryValue;
+  }): Promise<SyncTreeResponse> {
+    return this.request("GET", "/shadowid/leaves", undefined, query);
+  }
+
+  /** POST /shadowid/proof */
+  shadowIDProof(body: ProofRequest): Promise<ProofResponse> {
+    return this.request("POST", "/shadowid/proof", body);
+  }
+
+  /** POST /shadowid/register */
+  shadowIDRegister(body: ShadowidRegisterRequest): Promise<ShadowidRegisterResponse> {
+    return this.request("POST", "/shadowid/register", body);
+  }
+
+  /** POST /shadowid/register-batch */
+  shadowIDRegisterBatch(body: RegisterBatchRequest): Promise<RegisterBatchResponse> {
+    return this.request("POST", "/shadowid/register-batch", body);
+  }
+
+  /** GET /shadowid/root */
+  shadowIDRoot(): Promise<RootResponse> {
+    return this.request("GET", "/shadowid/root");
+  }
+
+  /** GET /shadowid/roots */
+  shadowIDRoots(): Promise<HistoricalRootsResponse> {
+    return this.request("GET", "/shadowid/roots");
+  }
+
+  /** GET /shadowid/status/{commitment} */
+  shadowIDStatus(commitment: string): Promise<ShadowidStatusResponse> {
+    return this.request("GET", `/shadowid/status/${encodeURIComponent(commitment)}`);
+  }
+
+  /** POST /shipping */
+  shippingCreate(body: ShippingCreateRequest): Promise<Order> {
+    return this.request("POST", "/shipping", body);
+  }
+
+  /** GET /shipping/{commitment} */
+  shippingGet(commitment: string): Promise<Order> {
+    return this.request("GET", `/shipping/${encodeURIComponent(commitment)}`);
+  }
+
+  /** POST /shipping/{commitment}/reveal/{wallet} */
+  shippingReveal(commitment: string, wallet: string): Promise<Order> {
+    return this.request("POST", `/shipping/${encodeURIComponent(commitment)}/reveal/${encodeURIComponent(wallet)}`);
+  }
+
+  /** GET /stealth/announcements */
+  stealthAnnouncements(query?: {
+    cursor?: QueryValue;
+    from_slot?: QueryValue;
+    limit?: QueryValue;
+  }): Promise<Page> {
+    return this.request("GET", "/stealth/announcements", undefined, query);
+  }
+
+  /** POST /swap/confirm */
+  swapConfirm(body: {
+    receipt_id: string;
+    swap_signature: string;
+  }): Promise<SwapReceipt> {
+    return this.request("POST", "/swap/confirm", body);
+  }
+
+  /** GET /swap/receipts */
+  swapReceipts(): Promise<{
+    receipts: SwapReceipt[];
+  }> {
+    return this.request("GET", "/swap/receipts");
+  }
+
+  /** GET /swap/receipts/{id} */
+  swapReceipt(id: string): Promise<SwapReceipt> {
+    return this.request("GET", `/swap/receipts/${encodeURIComponent(id)}`);
+  }
+
+  /** GET /tax/lots */
+  taxLots(query?: {
+    method?: QueryValue;
+    wallet?: QueryValue;
+  }): Promise<{
+    lots: Lot[];
+  }> {
+    return this.request("GET", "/tax/lots", undefined, query);
+  }
+
+  /** GET /tax/movements */
+  taxMovements(query?: {
+    wallet?: QueryValue;
+  }): Promise<{
+    movements: Movement[];
+  }> {
+    return this.request("GET", "/tax/movements", undefined, query);
+  }
+
+  /** POST /tax/movements */
+  taxRecord(body: TaxRecordRequest): Promise<Movement> {
+    return this.request("POST", "/tax/movements", body);
+  }
+
+  /** GET /tax/report */
+  taxReport(query?: {
+    method?: QueryValue;
+    wallet?: QueryValue;
+    year?: QueryValue;
+  }): Promise<TaxReport> {
+    return this.request("GET", "/tax/report", undefined, query);
+  }
+
+  /** GET /tax/report/export */
+  taxExport(query?: {
+    format?: QueryValue;
+    method?: QueryValue;
+    wallet?: QueryValue;
+    year?: QueryValue;
+  }): Promise<Response> {
+    return this.raw("GET", "/tax/report/export", undefined, query);
+  }
+
+  /** POST /token/add */
+  tokenAdd(body: TokenAddRequest): Promise<AddResponse> {
+    return this.request("POST", "/token/add", body);
+  }
+
+  /** GET /token/list */
+  tokenList(): Promise<ListSupportedResponse> {
+    return this.request("GET", "/token/list");
+  }
+
+  /** GET /token/list/detailed */
+  tokenListDetailed(): Promise<ListSupportedDetailedResponse> {
+    return this.request("GET", "/token/list/detailed");
+  }
+
+  /** POST /token/validate-payment */
+  tokenValidatePayment(body: {
+    mint: string;
+    amount: number;
+  }): Promise<PaymentValidation> {
+    return this.request("POST", "/token/validate-payment", body);
+  }
+
+  /** DELETE /token/{mint} */
+  tokenRemove(mint: string): Promise<RemoveResponse> {
+    return this.request("DELETE", `/token/${encodeURIComponent(mint)}`);
+  }
+
+  /** PUT /token/{mint} */
+  tokenUpdate(mint: string, body: TokenUpdateRequest): Promise<UpdateResponse> {
+    return this.request("PUT", `/token/${encodeURIComponent(mint)}`, body);
+  }
+
+  /** POST /umbra/balance */
+  umbraBalance(body: {
+    private_key: string;
+    mint?: string;
+  }): Promise<{
+    success: boolean;
+    data: {
+      balance: string;
+      balance_sol: number;
+      mint: string;
+      public_key: string;
+    };
+    message: string;
+  }> {
+    return this.request("POST", "/umbra/balance", body);
+  }
+
+  /** POST /umbra/deposit */
+  umbraDeposit(body: {
+    private_key: string;
+    amount: number;
+    destination_address?: string;
+  }): Promise<{
+    success: boolean;
+    data: {
+      signature: string;
+      amount: number;
+      amount_lamports: number;
+      destination_address: string;
+      public_key: string;
+      explorer_url: string;
+    };
+    message: string;
+  }> {
+    return this.request("POST", "/umbra/deposit", body);
+  }
+
+  /** POST /umbra/prepare-stealth-payment */
+  umbraPrepareStealthPayment(body: {
+    recipient_public_key: string;
+    amount: number;
+    token_mint?: string;
+    private_key: string;
+  }): Promise<{
+    success: boolean;
+    stealth_address: {
+      ephemeral_public_key: string;
+      ephemeral_private_key: string;
+      recipient_public_key: string;
+    };
+    deposit: {
+      signature: string;
+      amount: number;
+      amount_lamports: number;
+      destination_address: string;
+      explorer_url: string;
+    };
+    payment: {
+      payment_hash: string;
+      commitment: string;
+      message: string;
+    };
+    message: string;
+  }> {
+    return this.request("POST", "/umbra/prepare-stealth-payment", body);
+  }
+
+  /** POST /umbra/send */
+  umbraSend(body: {
+    private_key: string;
+    recipient_address: string;
+    amount: number;
+    mint?: string;
+  }): Promise<{
+    success: boolean;
+    data: {
+      signature: string;
+      amount: number;
+      amount_lamports: number;
+      recipient_address: string;
+      sender_public_key: string;
+      token_mint: string;
+      explorer_url: string;
+    };
+    message: string;
+  }> {
+    return this.request("POST", "/umbra/send", body);
+  }
+
+  /** POST /umbra/stealth-address */
+  umbraStealthAddress(body: {
+    recipient_public_key: string;
+  }): Promise<{
+    success: boolean;
+    data: {
+      ephemeral_public_key: string;
+      ephemeral_private_key: string;
+      recipient_public_key: string;
+    };
+    message: string;
+  }> {
+    return this.request("POST", "/umbra/stealth-address", body);
+  }
+
+  /** POST /umbra/withdraw */
+  umbraWithdraw(body: {
+    private_key: string;
+    commitment_index: number;
+    generation_index: number;
+    deposit_time: number;
+    relayer_public_key?: string;
+    mint?: string;
+  }): Promise<{
+    success: boolean;
+    data: {
+      signature: string;
+      destination_address: string;
+      token_mint: string;
+      claim_artifacts: Record<string, unknown>;
+      explorer_url: string;
+    };
+    message: string;
+    privacy: {
+      note: string;
+      zero_knowledge: string;
+    };
+  }> {
+    return this.request("POST", "/umbra/withdraw", body);
+  }
+
+  /** POST /warehouse/export */
+  warehouseExport(): Promise<{
+    exported: Counts;
+  }> {
+    return this.request("POST", "/warehouse/export");
+  }
+
+  /** GET /warehouse/status */
+  warehouseStatus(): Promise<Status> {
+    return this.request("GET", "/warehouse/status");
+  }
+
+  /** GET /webhook/config */
+  webhookConfig(): Promise<ConfigResponse> {
+    return this.request("GET", "/webhook/config");
+  }
+
+  /** POST /webhook/deactivate */
+  webhookDeactivate(body: DeactivateRequest): Promise<DeactivateResponse> {
+    return this.request("POST", "/webhook/deactivate", body);
+  }
+
+  /** GET /webhook/logs */
+  webhookLogs(query?: {
+    event?: QueryValue;
+    webhook_id?: QueryValue;
+  }): Promise<LogsResponse> {
+    return this.request("GET", "/webhook/logs", undefined, query);
+  }
+
+  /** POST /webhook/register */
+  webhookRegister(body: WebhookRegisterRequest): Promise<WebhookRegisterResponse> {
+    return this.request("POST", "/webhook/register", body);
+  }
+
+  /** GET /webhook/stats */
+  webhookStats(): Promise<StatsResponse> {
+    return this.request("GET", "/webhook/stats");
+  }
+
+  /** POST /webhook/test */
+  webhookTest(body: TestRequest): Promise<TestResponse> {
+    return this.request("POST", "/webhook/test", body);
+  }
+
+  /** POST /webhook/verify-signature */
+  webhookVerifySignature(body: VerifySignatureRequest): Promise<{
+    webhook_id: string;
+    valid: boolean;
+  }> {
+    return this.request("POST", "/webhook/verify-signature", body);
+  }
+}
+
+/** token.AddResponse */
+export interface AddResponse {
+  success: boolean;
+  message: string;
+}
+
+/** addressbook.AddRequest */
+export interface AddressbookAddRequest {
+  label: string;
+  kind: string;
+  value: string;
+  notes?: string;
+}
+
+/** addressbook.Entry */
+export interface AddressbookEntry {
+  id: string;
+  label: string;
+  kind: string;
+  value: string;
+  notes?: string;
+  uses: number;
+  created_at: number;
+  last_used_at?: number;
+}
+
+/** addressbook.UpdateRequest */
+export interface AddressbookUpdateRequest {
+  label?: string | null;
+  value?: string | null;
+  notes?: string | null;
+}
+
+/** customers.AliasRequest */
+export interface AliasRequest {
+  alias: string;
+  notes?: string;
+}
+
+/** escrow.AllBalancesResponse */
+export interface AllBalancesResponse {
+  wallet_address: string;
+  sol_balance: number;
+  tokens: TokenBalance[];
+}
+
+/** merchant.AnalyticsRequest */
+export interface AnalyticsRequest {
+  start_date?: string;
+  end_date?: string;
+  interval?: string;
+  cohorts?: boolean;
+  token_series?: boolean;
+  percentiles?: number[];
+}
+
+/** merchant.AnalyticsResponse */
+export interface AnalyticsResponse {
+  total_payments: number;
+  total_volume: number;
+  average_payment: number;
+  unique_customers: number;
+  time_series: PaymentStats[];
+  top_resources?: ResourceStat[];
+  success_rate: number;
+  pending_payments: number;
+  cohorts?: Cohort[];
+  token_volumes?: TokenVolumeSeries[];
+  payment_sizes?: PercentileStat[];
+  computed_by?: string;
+}
+
+/** announce.Announcement */
+export interface Announcement {
+  sequence: number;
+  ephemeral_public_key: string;
+  address: string;
+  signature: string;
+  slot: number;
+  block_time?: number;
+}
+
+/** artifacts.Artifact */
+export interface Artifact {
+  path: string;
+  sha256?: string;
+  size?: number;
+}
+
+/** authorization.Authorization */
+export interface Authorization {
+  id: number;
+  user_wallet: string;
+  authorized_service: string;
+  max_amount_per_tx: number;
+  max_daily_spend: number;
+  spent_today: number;
+  last_reset_date: string;
+  valid_until: number;
+  revoked: boolean;
+  created_at: number;
+}
+
+/** payment.AuthorizeRequest */
+export interface AuthorizeRequest {
+  commitment: string;
+  nullifier: string;
+  amount: number;
+  merchant: string;
+  scope?: string[];
+}
+
+/** payment.AuthorizeResponse */
+export interface AuthorizeResponse {
+  success: boolean;
+  access_token: string;
+  expires_in: number;
+  message?: string;
+}
+
+/** authorization.AuthorizeSpendingRequest */
+export interface AuthorizeSpendingRequest {
+  user_wallet: string;
+  authorized_service: string;
+  max_amount_per_tx: string;
+  max_daily_spend: string;
+  valid_until: number;
+  user_signature: string;
+}
+
+/** authorization.AuthorizeSpendingResponse */
+export interface AuthorizeSpendingResponse {
+  success: boolean;
+  message: string;
+  authorization_id: number;
+}
+
+/** shadowid.AutoRegisterRequest */
+export interface AutoRegisterRequest {
+  wallet_address: string;
+  signature: string;
+  message: string;
+}
+
+/** shadowid.AutoRegisterResponse */
+export interface AutoRegisterResponse {
+  success: boolean;
+  commitment: string;
+  leaf_index?: number;
+  message?: string;
+}
+
+/** bots.CreateRequest */
+export interface BotsCreateRequest {
+  wallet: string;
+  recipient: string;
+  amount: number;
+  description?: string;
+  metadata?: Record<string, string>;
+  expires_in?: number;
+}
+
+/** bots.Link */
+export interface BotsLink {
+  chat: Chat;
+  wallet: string;
+  linked_at: number;
+}
+
+/** session.Challenge */
+export interface Challenge {
+  wallet_address: string;
+  nonce: string;
+  message: string;
+  expires_at: number;
+}
+
+/** bots.Chat */
+export interface Chat {
+  platform: string;
+  id: string;
+}
+
+/** checkout.CreateRequest */
+export interface CheckoutCreateRequest {
+  amount: number;
+  recipient: string;
+  receiver_commitment: string;
+  reference?: string;
+  description?: string;
+  accepted_tokens?: string[];
+  prices?: Record<string, number>;
+  success_url: string;
+  cancel_url: string;
+  expires_in?: number;
+  metadata?: Record<string, string>;
+}
+
+/** checkout.Session */
+export interface CheckoutSession {
+  id: string;
+  intent_id: string;
+  amount: number;
+  recipient: string;
+  receiver_commitment: string;
+  reference?: string;
+  description?: string;
+  accepted_tokens?: string[];
+  prices?: Record<string, number>;
+  success_url: string;
+  cancel_url: string;
+  status: string;
+  url: string;
+  token_mint?: string;
+  payment_hash?: string;
+  tx_signature?: string;
+  metadata?: Record<string, string>;
+  created_at: number;
+  expires_at: number;
+  completed_at?: number;
+}
+
+/** artifacts.Circuit */
+export interface Circuit {
+  wasm: Artifact;
+  zkey: Artifact;
+  verification_key: Artifact;
+}
+
+/** merchant.Cohort */
+export interface Cohort {
+  month: string;
+  customers: number;
+  retention: number[];
+}
+
+/** types.ComputeBudget */
+export interface ComputeBudget {
+  compute_unit_price?: number;
+  compute_unit_limit?: number;
+  auto?: boolean;
+  auto_percentile?: number;
+}
+
+/** webhook.ConfigResponse */
+export interface ConfigResponse {
+  webhook_id: string;
+  url: string;
+  events: string[];
+  active: boolean;
+  secret?: string;
+  created_at: string;
+  updated_at?: string;
+}
+
+/** messages.Conversation */
+export interface Conversation {
+  id: string;
+  commitment: string;
+  merchant_key: string;
+  customer_key: string;
+  message_count: number;
+  created_at: number;
+  updated_at: number;
+  messages?: Message[];
+}
+
+/** warehouse.Counts */
+export interface Counts {
+  receipts: number;
+  analytics_days: number;
+  snapshots: number;
+}
+
+/** customers.Customer */
+export interface Customer {
+  commitment: string;
+  alias?: string;
+  notes?: string;
+  purchases: number;
+  total_spent: number;
+  first_seen_at?: number;
+  last_seen_at?: number;
+  history?: Purchase[];
+}
+
+/** customers.Stats */
+export interface CustomersStats {
+  customers: number;
+  returning_customers: number;
+  repeat_rate: number;
+  purchases: number;
+  total_spent: number;
+  average_order: number;
+}
+
+/** authorization.DailyUsage */
+export interface DailyUsage {
+  date: string;
+  spent: number;
+  transaction_count: number;
+  daily_limit: number;
+}
+
+/** webhook.DeactivateRequest */
+export interface DeactivateRequest {
+  webhook_id: string;
+}
+
+/** webhook.DeactivateResponse */
+export interface DeactivateResponse {
+  success: boolean;
+  webhook_id: string;
+  message?: string;
+}
+
+/** privacy.DecryptRequest */
+export interface DecryptRequest {
+  ciphertext: string;
+  private_key: string;
+  wallet_address?: string;
+}
+
+/** privacy.DecryptResponse */
+export interface DecryptResponse {
+  amount: number;
+}
+
+/** pool.DepositAddressResponse */
+export interface DepositAddressResponse {
+  deposit_address: string;
+  network: string;
+}
+
+/** payment.DepositRequest */
+export interface DepositRequest {
+  wallet_address: string;
+  amount: number;
+  compute_budget?: ComputeBudget | null;
+}
+
+/** payment.DepositResponse */
+export interface DepositResponse {
+  unsigned_tx_base64: string;
+  recent_blockhash: string;
+  last_valid_block_height: number;
+}
+
+/** token.DetailedToken */
+export interface DetailedToken {
+  metadata?: Metadata | null;
+  metadata_error?: string;
+  mint: string;
+  symbol: string;
+  decimals: number;
+  enabled: boolean;
+}
+
+/** tax.Disposal */
+export interface Disposal {
+  movement_id: string;
+  lot_id?: string;
+  token_mint?: string;
+  amount: number;
+  acquired?: number;
+  disposed: number;
+  proceeds: number;
+  cost_basis: number;
+  gain: number;
+  long_term: boolean;
+  unmatched?: boolean;
+}
+
+/** platform.Earnings */
+export interface Earnings {
+  sub_merchant_id: string;
+  name?: string;
+  platform_fees: number;
+  error?: string;
+  total_earnings: number;
+  total_usd_value?: string;
+  token_breakdown: TokenEarnings[];
+  withdrawable_sol: number;
+  pending_settlement: number;
+}
+
+/** merchant.EarningsResponse */
+export interface EarningsResponse {
+  total_earnings: number;
+  total_usd_value?: string;
+  token_breakdown: TokenEarnings[];
+  withdrawable_sol: number;
+  pending_settlement: number;
+}
+
+/** platform.EarningsSummary */
+export interface EarningsSummary {
+  sub_merchants: Earnings[];
+  total_earnings: number;
+  total_platform_fees: number;
+  pending_settlement: number;
+}
+
+/** api.ErrorDocument */
+export interface ErrorDocument {
+  error: string;
+  errors: ErrorObject[];
+}
+
+/** api.ErrorMeta */
+export interface ErrorMeta {
+  retryable: boolean;
+  correlation_id?: string;
+  upstream_status?: number;
+}
+
+/** api.ErrorObject */
+export interface ErrorObject {
+  status: string;
+  code: string;
+  title: string;
+  detail: string;
+  source?: ErrorSource | null;
+  meta: ErrorMeta;
+}
+
+/** api.ErrorSource */
+export interface ErrorSource {
+  pointer: string;
+}
+
+/** escrow.BalanceResponse */
+export interface EscrowBalanceResponse {
+  wallet_address: string;
+  balance: number;
+  mint?: string;
+}
+
+/** events.Event */
+export interface Event {
+  id: string;
+  event: string;
+  source: string;
+  timestamp: number;
+  data: unknown;
+}
+
+/** nullifier.ExportPage */
+export interface ExportPage {
+  entries: NullifierEntry[];
+  next_cursor?: string;
+  total: number;
+  digest: string;
+}
+
+/** reconcile.Finding */
+export interface Finding {
+  kind: string;
+  record: ReconcileRecord;
+  receipt?: ReceiptReceipt | null;
+  detail: string;
+}
+
+/** graphql.Error */
+export interface GraphqlError {
+  message: string;
+  path?: unknown[];
+}
+
+/** graphql.Request */
+export interface GraphqlRequest {
+  query: string;
+  variables?: Record<string, unknown>;
+  operationName?: string;
+}
+
+/** graphql.Response */
+export interface GraphqlResponse {
+  data: unknown;
+  errors?: GraphqlError[];
+}
+
+/** shadowid.HistoricalRoot */
+export interface HistoricalRoot {
+  root: string;
+  leaf_count: number;
+  created_at: number;
+}
+
+/** shadowid.HistoricalRootsResponse */
+export interface HistoricalRootsResponse {
+  roots: HistoricalRoot[];
+  tree_depth: number;
+}
+
+/** events.HistoryPage */
+export interface HistoryPage {
+  events: Event[];
+  next_cursor?: string;
+}
+
+/** hold.Hold */
+export interface Hold {
+  id: string;
+  tx_sig: string;
+  resource?: string;
+  customer_commitment?: string;
+  payer_wallet?: string;
+  amount?: string;
+  status: string;
+  release_at: number;
+  last_error?: string;
+  history?: Transition[];
+  created_at: number;
+  updated_at: number;
+}
+
+/** invoice.Invoice */
+export interface Invoice {
+  id: string;
+  number: string;
+  merchant: Party;
+  customer: Party;
+  currency: string;
+  line_items: LineItem[];
+  tax_rate_bps: number;
+  subtotal: number;
+  tax: number;
+  total: number;
+  notes?: string;
+  status: string;
+  intent_id: string;
+  tx_signature?: string;
+  issued_at: number;
+  due_date: number;
+  paid_at?: number;
+  voided_at?: number;
+}
+
+/** invoice.CreateRequest */
+export interface InvoiceCreateRequest {
+  merchant: Party;
+  customer: Party;
+  currency: string;
+  line_items: LineItem[];
+  tax_rate_bps?: number;
+  due_date: number;
+  notes?: string;
+}
+
+/** invoice.UpdateRequest */
+export interface InvoiceUpdateRequest {
+  customer?: Party | null;
+  line_items?: LineItem[];
+  tax_rate_bps?: number | null;
+  due_date?: number | null;
+  notes?: string | null;
+}
+
+/** settlement.Job */
+export interface Job {
+  id: string;
+  request: SettleRequest;
+  metadata?: Record<string, string>;
+  status: string;
+  attempts: number;
+  last_error?: string;
+  result?: SettleResponse | null;
+  next_attempt_at?: number;
+  created_at: number;
+  updated_at: number;
+}
+
+/** keys.Response */
+export interface KeysResponse {
+  api_key: string;
+  wallet_address: string;
+}
+
+/** ledger.Entry */
+export interface LedgerEntry {
+  id: string;
+  wallet: string;
+  kind: string;
+  amount: number;
+  token_mint?: string;
+  reference?: string;
+  note?: string;
+  at: number;
+}
+
+/** ledger.RecordRequest */
+export interface LedgerRecordRequest {
+  wallet: string;
+  kind: string;
+  amount: number;
+  token_mint?: string;
+  reference?: string;
+  note?: string;
+  at?: number;
+}
+
+/** invoice.LineItem */
+export interface LineItem {
+  description: string;
+  quantity: number;
+  unit_price: number;
+  amount: number;
+}
+
+/** authorization.ListAuthorizationsResponse */
+export interface ListAuthorizationsResponse {
+  authorizations: Authorization[];
+}
+
+/** platform.ListResponse */
+export interface ListResponse {
+  sub_merchants: SubMerchant[];
+}
+
+/** token.ListSupportedDetailedResponse */
+export interface ListSupportedDetailedResponse {
+  tokens: DetailedToken[];
+}
+
+/** token.ListSupportedResponse */
+export interface ListSupportedResponse {
+  tokens: Token[];
+}
+
+/** receipt.ListUserReceiptsResponse */
+export interface ListUserReceiptsResponse {
+  receipts: ReceiptReceipt[];
+  total_count: number;
+  limit: number;
+  offset: number;
+}
+
+/** webhook.LogEntry */
+export interface LogEntry {
+  id: string;
+  webhook_id: string;
+  event: string;
+  status_code: number;
+  response_time_ms: number;
+  success: boolean;
+  attempt: number;
+  timestamp: string;
+  error?: string;
+  payload_id?: string;
+}
+
+/** webhook.LogsRequest */
+export interface LogsRequest {
+  webhook_id?: string;
+  event?: string;
+  success?: boolean | null;
+  limit?: number;
+  offset?: number;
+}
+
+/** webhook.LogsResponse */
+export interface LogsResponse {
+  logs: LogEntry[];
+  total_count: number;
+  limit: number;
+  offset: number;
+}
+
+/** tax.Lot */
+export interface Lot {
+  movement_id: string;
+  account: string;
+  token_mint?: string;
+  acquired: number;
+  amount: number;
+  remaining: number;
+  cost: number;
+}
+
+/** artifacts.Manifest */
+export interface Manifest {
+  version: string;
+  base_url: string;
+  circuits: Record<string, Circuit>;
+}
+
+/** merchant.WithdrawRequest */
+export interface MerchantWithdrawRequest {
+  amount: number;
+  destination: string;
+  token_mint?: string;
+}
+
+/** merchant.WithdrawResponse */
+export interface MerchantWithdrawResponse {
+  success: boolean;
+  transaction: string;
+  withdrawal_id: string;
+  amount: number;
+  fee?: number;
+  net_amount: number;
+  message?: string;
+}
+
+/** messages.Message */
+export interface Message {
+  seq: number;
+  sender: string;
+  nonce: string;
+  ciphertext: string;
+  created_at: number;
+}
+
+/** token.Metadata */
+export interface Metadata {
+  name: string;
+  symbol: string;
+  uri?: string;
+  logo_uri?: string;
+}
+
+/** receipt.MintNFTResponse */
+export interface MintNFTResponse {
+  receipt_hash: string;
+  owner: string;
+  merkle_tree: string;
+  metadata_uri: string;
+  unsigned_tx_base64: string;
+  recent_blockhash: string;
+  last_valid_block_height: number;
+}
+
+/** ledger.MonthlySummary */
+export interface MonthlySummary {
+  month: string;
+  entries: number;
+  total: number;
+  by_kind: Record<string, number>;
+  tokens?: Record<string, number>;
+}
+
+/** tax.Movement */
+export interface Movement {
+  id: string;
+  wallet: string;
+  account: string;
+  direction: string;
+  amount: number;
+  token_mint?: string;
+  value: number;
+  reference?: string;
+  at: number;
+}
+
+/** authorization.MultisigAuthorizeRequest */
+export interface MultisigAuthorizeRequest {
+  treasury_wallet: string;
+  signers: string[];
+  threshold: number;
+  treasury_signature: string;
+  authorized_service: string;
+  max_amount_per_tx: string;
+  max_daily_spend: string;
+  valid_until: number;
+  nonce: string;
+  expires_at: number;
+  signatures: MultisigSignature[];
+}
+
+/** authorization.MultisigSignature */
+export interface MultisigSignature {
+  signer: string;
+  signature: string;
+}
+
+/** notify.Stats */
+export interface NotifyStats {
+  sent: number;
+  suppressed: number;
+  failed: number;
+}
+
+/** nullifier.Entry */
+export interface NullifierEntry {
+  hash: string;
+  receipt_id: string;
+  tx_sig?: string;
+  spent_at: number;
+}
+
+/** shipping.Order */
+export interface Order {
+  commitment: string;
+  merchant_key: string;
+  sealed_address?: string;
+  status: string;
+  created_at: number;
+  revealed_at?: number;
+}
+
+/** announce.Page */
+export interface Page {
+  announcements: Announcement[];
+  next_cursor?: string;
+  indexed_slot: number;
+}
+
+/** invoice.Party */
+export interface Party {
+  name: string;
+  email?: string;
+  wallet?: string;
+}
+
+/** checkout.PayRequest */
+export interface PayRequest {
+  wallet_address: string;
+  token_mint?: string;
+}
+
+/** checkout.PayResponse */
+export interface PayResponse {
+  deposit?: UnsignedTxResponse | null;
+  payment_hash?: string;
+  transaction?: string;
+}
+
+/** bots.PaymentRequest */
+export interface PaymentRequest {
+  id: string;
+  wallet: string;
+  recipient: string;
+  amount: number;
+  description?: string;
+  metadata?: Record<string, string>;
+  status: string;
+  messages: SentMessage[];
+  decided_by?: string;
+  created_at: number;
+  expires_at: number;
+  decided_at?: number;
+}
+
+/** merchant.PaymentStats */
+export interface PaymentStats {
+  timestamp: string;
+  payment_count: number;
+  total_amount: number;
+  unique_users?: number;
+}
+
+/** payment.StatusResponse */
+export interface PaymentStatusResponse {
+  payment_hash: string;
+  status: string;
+  tx_sig?: string;
+  receipt?: ReceiptReceipt | null;
+  message?: string;
+}
+
+/** token.PaymentValidation */
+export interface PaymentValidation {
+  allowed: boolean;
+  reason?: string;
+  quote?: Quote | null;
+}
+
+/** payment.WithdrawRequest */
+export interface PaymentWithdrawRequest {
+  wallet_address: string;
+  amount: number;
+  compute_budget?: ComputeBudget | null;
+}
+
+/** payment.WithdrawResponse */
+export interface PaymentWithdrawResponse {
+  unsigned_tx_base64: string;
+  recent_blockhash: string;
+  last_valid_block_height: number;
+  message?: string;
+}
+
+/** paymentlink.CreateRequest */
+export interface PaymentlinkCreateRequest {
+  amount: number;
+  recipient: string;
+  receiver_commitment: string;
+  description?: string;
+  accepted_tokens?: string[];
+  prices?: Record<string, number>;
+  success_url: string;
+  cancel_url: string;
+  max_uses?: number;
+  expires_at?: number;
+}
+
+/** paymentlink.Link */
+export interface PaymentlinkLink {
+  code: string;
+  url: string;
+  amount: number;
+  recipient: string;
+  receiver_commitment: string;
+  description?: string;
+  accepted_tokens?: string[];
+  prices?: Record<string, number>;
+  success_url: string;
+  cancel_url: string;
+  max_uses?: number;
+  expires_at?: number;
+  active: boolean;
+  created_at: number;
+  deactivated_at?: number;
+  usage: Usage;
+}
+
+/** paymentlink.Stats */
+export interface PaymentlinkStats {
+  code: string;
+  active: boolean;
+  usage: Usage;
+  conversion_rate: number;
+}
+
+/** merchant.PercentileStat */
+export interface PercentileStat {
+  percentile: number;
+  amount: number;
+}
+
+/** platform.CreateRequest */
+export interface PlatformCreateRequest {
+  name: string;
+  wallet_address: string;
+  fee_bps: number;
+  metadata?: Record<string, string>;
+}
+
+/** platform.UpdateRequest */
+export interface PlatformUpdateRequest {
+  name?: string | null;
+  fee_bps?: number | null;
+  status?: string | null;
+}
+
+/** pool.BalanceResponse */
+export interface PoolBalanceResponse {
+  wallet_address: string;
+  balance: number;
+  min_deposit: number;
+}
+
+/** pool.WithdrawRequest */
+export interface PoolWithdrawRequest {
+  wallet_address: string;
+  amount: number;
+}
+
+/** pool.WithdrawResponse */
+export interface PoolWithdrawResponse {
+  transaction: string;
+  net_amount: number;
+  fee: number;
+  message?: string;
+}
+
+/** messages.PostRequest */
+export interface PostRequest {
+  sender: string;
+  nonce: string;
+  ciphertext: string;
+}
+
+/** notify.Preference */
+export interface Preference {
+  merchant: string;
+  email?: string;
+  phone?: string;
+  events?: string[];
+  sms_events?: string[];
+  paused?: boolean;
+  updated_at: number;
+}
+
+/** payment.PrepareResponse */
+export interface PrepareResponse {
+  payment_hash: string;
+  transaction: string;
+  commitment: string;
+  message?: string;
+}
+
+/** shadowid.ProofRequest */
+export interface ProofRequest {
+  commitment: string;
+  root?: string;
+}
+
+/** shadowid.ProofResponse */
+export interface ProofResponse {
+  commitment: string;
+  leaf_index: number;
+  proof: string[];
+  root: string;
+}
+
+/** customers.Purchase */
+export interface Purchase {
+  amount: number;
+  token_mint?: string;
+  at: number;
+}
+
+/** customers.PurchaseRequest */
+export interface PurchaseRequest {
+  amount: number;
+  token_mint?: string;
+}
+
+/** jupiter.Quote */
+export interface Quote {
+  inputMint: string;
+  inAmount: string;
+  outputMint: string;
+  outAmount: string;
+  otherAmountThreshold: string;
+  swapMode: string;
+  slippageBps: number;
+  priceImpactPct: string;
+  routePlan: unknown[];
+  contextSlot?: number;
+  timeTaken?: number;
+}
+
+/** receipt.ReceiptBody */
+export interface ReceiptBody {
+  id: string;
+  amount_lamports: number;
+  timestamp: number;
+  merchant: string;
+  resource?: string;
+}
+
+/** receipt.Receipt */
+export interface ReceiptReceipt {
+  body: ReceiptBody;
+  sig: string;
+  pubkey: string;
+}
+
+/** addressbook.Recent */
+export interface Recent {
+  kind: string;
+  value: string;
+  label?: string;
+  at: number;
+}
+
+/** reconcile.Record */
+export interface ReconcileRecord {
+  kind: string;
+  id: string;
+  signature?: string;
+  wallet: string;
+  resource?: string;
+  lamports?: number;
+  succeeded: boolean;
+  at: number;
+}
+
+/** reconcile.Report */
+export interface ReconcileReport {
+  started_at: number;
+  finished_at: number;
+  records: number;
+  confirmed: number;
+  receipts: number;
+  findings: Finding[];
+  errors?: string[];
+}
+
+/** shadowid.RegisterBatchRequest */
+export interface RegisterBatchRequest {
+  commitments: string[];
+}
+
+/** shadowid.RegisterBatchResponse */
+export interface RegisterBatchResponse {
+  success: boolean;
+  registered: RegisteredLeaf[];
+  root: string;
+  message?: string;
+}
+
+/** shadowid.RegisteredLeaf */
+export interface RegisteredLeaf {
+  commitment: string;
+  leaf_index: number;
+}
+
+/** registry.Record */
+export interface RegistryRecord {
+  handle: string;
+  commitment?: string;
+  meta_address: string;
+  owner: string;
+  timestamp: number;
+  signature: string;
+  registered_at: number;
+  updated_at: number;
+}
+
+/** registry.RegisterRequest */
+export interface RegistryRegisterRequest {
+  handle: string;
+  commitment?: string;
+  meta_address: string;
+  owner: string;
+  timestamp: number;
+  signature: string;
+}
+
+/** token.RemoveResponse */
+export interface RemoveResponse {
+  success: boolean;
+  message?: string;
+}
+
+/** payment.Requirements */
+export interface Requirements {
+  scheme: string;
+  network: string;
+  maxAmountRequired: string;
+  resource: string;
+  description: string;
+  mimeType: string;
+  payTo: string;
+  maxTimeoutSeconds: number;
+  extra?: Record<string, unknown>;
+}
+
+/** merchant.ResourceStat */
+export interface ResourceStat {
+  resource: string;
+  payment_count: number;
+  total_amount: number;
+}
+
+/** authorization.RevokeAuthorizationRequest */
+export interface RevokeAuthorizationRequest {
+  user_wallet: string;
+  authorized_service: string;
+  user_signature: string;
+}
+
+/** authorization.RevokeAuthorizationResponse */
+export interface RevokeAuthorizationResponse {
+  success: boolean;
+  message: string;
+  authorization_id: number;
+}
+
+/** shadowid.RootResponse */
+export interface RootResponse {
+  root: string;
+  tree_depth: number;
+  leaf_count: number;
+}
+
+/** verify.Scheme */
+export interface Scheme {
+  scheme: string;
+  network: string;
+  description: string;
+}
+
+/** bots.SentMessage */
+export interface SentMessage {
+  chat: Chat;
+  message_id: string;
+}
+
+/** session.Session */
+export interface SessionSession {
+  token: string;
+  wallet_address: string;
+  expires_at: number;
+}
+
+/** payment.SettleRequest */
+export interface SettleRequest {
+  x402Version: number;
+  paymentHeader: string;
+  resource: string;
+  paymentRequirements: Requirements;
+  computeBudget?: ComputeBudget | null;
+  hold?: boolean;
+}
+
+/** payment.SettleResponse */
+export interface SettleResponse {
+  success: boolean;
+  tx_sig: string;
+  message: string;
+}
+
+/** settlement.Stats */
+export interface SettlementStats {
+  queued: number;
+  processing: number;
+  retrying: number;
+  succeeded: number;
+  failed: number;
+  attempts: number;
+  retries: number;
+  recovered: number;
+  avg_settle_seconds: number;
+  oldest_pending_age_seconds: number;
+}
+
+/** shadowid.RegisterRequest */
+export interface ShadowidRegisterRequest {
+  commitment: string;
+}
+
+/** shadowid.RegisterResponse */
+export interface ShadowidRegisterResponse {
+  success: boolean;
+  leaf_index: number;
+  tx_hash?: string;
+  message?: string;
+}
+
+/** shadowid.StatusResponse */
+export interface ShadowidStatusResponse {
+  commitment: string;
+  registered: boolean;
+  leaf_index?: number;
+}
+
+/** shipping.CreateRequest */
+export interface ShippingCreateRequest {
+  commitment: string;
+  merchant_key: string;
+  sealed_address: string;
+}
+
+/** messages.StartRequest */
+export interface StartRequest {
+  commitment: string;
+  merchant_key: string;
+  customer_key: string;
+}
+
+/** webhook.StatsResponse */
+export interface StatsResponse {
+  webhook_id: string;
+  total_deliveries: number;
+  successful_deliveries: number;
+  failed_deliveries: number;
+  success_rate: number;
+  average_response_time_ms: number;
+  last_delivery?: string;
+  last_success?: string;
+  last_failure?: string;
+}
+
+/** warehouse.Status */
+export interface Status {
+  schema: string;
+  wallets: number;
+  last_run?: number;
+  last_success?: number;
+  last_error?: string;
+  exported: Counts;
+  cursors?: Record<string, number>;
+}
+
+/** platform.SubMerchant */
+export interface SubMerchant {
+  id: string;
+  name: string;
+  wallet_address: string;
+  fee_bps: number;
+  status: string;
+  metadata?: Record<string, string>;
+  created_at: number;
+}
+
+/** events.SubscriberStats */
+export interface SubscriberStats {
+  name: string;
+  types?: string[];
+  pending: number;
+  delivered: number;
+  failures: number;
+  last_error?: string;
+}
+
+/** verify.SupportedResponse */
+export interface SupportedResponse {
+  x402Version: number;
+  schemes: Scheme[];
+}
+
+/** swap.Receipt */
+export interface SwapReceipt {
+  id: string;
+  settlement_tx?: string;
+  input_mint: string;
+  in_amount: number;
+  output_mint: string;
+  quoted_out: number;
+  min_out: number;
+  slippage_bps: number;
+  swap_transaction?: string;
+  swap_signature?: string;
+  status: string;
+  error?: string;
+  created_at: number;
+  completed_at?: number;
+}
+
+/** shadowid.SyncTreeResponse */
+export interface SyncTreeResponse {
+  from_leaf: number;
+  leaves: string[];
+  leaf_count: number;
+  root: string;
+  has_more: boolean;
+}
+
+/** tax.RecordRequest */
+export interface TaxRecordRequest {
+  wallet: string;
+  account: string;
+  direction: string;
+  amount: number;
+  token_mint?: string;
+  value?: number | null;
+  reference?: string;
+  at?: number;
+}
+
+/** tax.Report */
+export interface TaxReport {
+  wallet: string;
+  year: number;
+  method: string;
+  currency: string;
+  disposals: Disposal[];
+  proceeds: number;
+  cost_basis: number;
+  gain: number;
+  short_term_gain: number;
+  long_term_gain: number;
+  open_lots: Lot[];
+}
+
+/** webhook.TestRequest */
+export interface TestRequest {
+  webhook_id?: string;
+  event?: string;
+}
+
+/** webhook.TestResponse */
+export interface TestResponse {
+  success: boolean;
+  status_code: number;
+  response_time_ms: number;
+  message?: string;
+  error?: string;
+}
+
+/** token.Token */
+export interface Token {
+  mint: string;
+  symbol: string;
+  decimals: number;
+  enabled: boolean;
+}
+
+/** token.AddRequest */
+export interface TokenAddRequest {
+  mint: string;
+  symbol: string;
+  decimals: number;
+  enabled: boolean;
+}
+
+/** escrow.TokenBalance */
+export interface TokenBalance {
+  mint: string;
+  symbol: string;
+  decimals: number;
+  balance: number;
+  error?: string;
+}
+
+/** merchant.TokenEarnings */
+export interface TokenEarnings {
+  token_mint: string;
+  symbol?: string;
+  amount: number;
+  usd_value?: string;
+}
+
+/** token.UpdateRequest */
+export interface TokenUpdateRequest {
+  enabled?: boolean | null;
+  symbol?: string | null;
+  decimals?: number | null;
+}
+
+/** merchant.TokenVolumePoint */
+export interface TokenVolumePoint {
+  timestamp: string;
+  payment_count: number;
+  total_amount: number;
+}
+
+/** merchant.TokenVolumeSeries */
+export interface TokenVolumeSeries {
+  token_mint: string;
+  points: TokenVolumePoint[];
+}
+
+/** hold.Transition */
+export interface Transition {
+  from: string;
+  to: string;
+  reason?: string;
+  tx_sig?: string;
+  at: number;
+}
+
+/** registry.UnregisterRequest */
+export interface UnregisterRequest {
+  handle: string;
+  owner: string;
+  timestamp: number;
+  signature: string;
+}
+
+/** types.UnsignedTxResponse */
+export interface UnsignedTxResponse {
+  unsigned_tx_base64: string;
+  recent_blockhash: string;
+  last_valid_block_height: number;
+}
+
+/** authorization.UpdateAuthorizationRequest */
+export interface UpdateAuthorizationRequest {
+  user_wallet: string;
+  authorized_service: string;
+  max_amount_per_tx?: string | null;
+  max_daily_spend?: string | null;
+  valid_until?: number | null;
+  user_signature: string;
+}
+
+/** authorization.UpdateAuthorizationResponse */
+export interface UpdateAuthorizationResponse {
+  success: boolean;
+  message: string;
+  authorization: Authorization;
+}
+
+/** token.UpdateResponse */
+export interface UpdateResponse {
+  success: boolean;
+  message?: string;
+}
+
+/** paymentlink.Usage */
+export interface Usage {
+  visits: number;
+  completed: number;
+  total_collected: number;
+  last_used_at?: number;
+}
+
+/** authorization.UsageHistoryResponse */
+export interface UsageHistoryResponse {
+  user_wallet: string;
+  authorized_service: string;
+  days: DailyUsage[];
+}
+
+/** payment.VerifyAccessResponse */
+export interface VerifyAccessResponse {
+  valid: boolean;
+  commitment?: string;
+  merchant?: string;
+  amount?: number;
+  expires_at?: string;
+  message?: string;
+  scope?: string[];
+}
+
+/** webhook.VerifySignatureRequest */
+export interface VerifySignatureRequest {
+  webhook_id: string;
+  payload: string;
+  signature: string;
+}
+
+/** invoice.WebhookEvent */
+export interface WebhookEvent {
+  event: string;
+  data: {
+    intent_id: string;
+    reference: string;
+    tx_signature: string;
+    metadata?: Record<string, string>;
+  };
+}
+
+/** webhook.RegisterRequest */
+export interface WebhookRegisterRequest {
+  url: string;
+  events: string[];
+  secret?: string;
+}
+
+/** webhook.RegisterResponse */
+export interface WebhookRegisterResponse {
+  success: boolean;
+  webhook_id: string;
+  url: string;
+  events: string[];
+  created_at: string;
+  message?: string;
+}
+
+/** pool.WithdrawQuote */
+export interface WithdrawQuote {
+  amount: number;
+  fee_bps: number;
+  fee: number;
+  net_amount: number;
+  min_net_amount: number;
+  warnings?: string[];
+}
@@ -0,0 +1,299 @@
+// Package agent lets AI agents and other automated clients pay for x402
+// resources with ShadowPay in one call. Pay fetches a URL and, if it answers
+// 402 Payment Required, picks a payment option the API supports, checks the
+// price and the escrow balance, derives the wallet's ShadowID, prepares,
+// authorizes and settles the payment, and fetches the URL again with it.
+package agent
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	shadowpay "sol_privacy"
+	"sol_privacy/internal/amount"
+	"sol_privacy/internal/merkle"
+	"sol_privacy/internal/payment"
+	"sol_privacy/internal/shadowid"
+	"sol_privacy/internal/types"
+	"sol_privacy/internal/wallet"
+)
+
+// HeaderPayment carries the x402 payment when the resource is fetched again.
+const HeaderPayment = "X-PAYMENT"
+
+// maxChallengeSize caps the 402 body read for payment requirements.
+const maxChallengeSize = 1 << 20
+
+var (
+	// ErrNoSupportedScheme is returned when the resource accepts no payment
+	// scheme and network the API supports.
+	ErrNoSupportedScheme = errors.New("no supported payment option")
+	// ErrPriceTooHigh is returned when the resource costs more than maxPrice.
+	ErrPriceTooHigh = errors.New("price exceeds the maximum")
+	// ErrInsufficientBalance is returned when the escrow cannot cover the price.
+	ErrInsufficientBalance = errors.New("insufficient escrow balance")
+	// ErrPaymentRejected is returned when the resource still answers 402 after payment.
+	ErrPaymentRejected = errors.New("resource rejected the payment")
+)
+
+// Challenge is the body of a 402 Payment Required response.
+type Challenge struct {
+	X402Version int                    `json:"x402Version"`
+	Error       string                 `json:"error,omitempty"`
+	Accepts     []payment.Requirements `json:"accepts"`
+}
+
+// Result is the outcome of Pay. The caller closes Response.Body.
+type Result struct {
+	Response     *http.Response
+	Paid         bool                  // False if the resource was free
+	Amount       amount.Amount         // Lamports paid
+	Requirements *payment.Requirements // The payment option used
+	AccessToken  string
+	TxSig        string
+}
+
+// Config holds agent configuration.
+type Config struct {
+	SDK        *shadowpay.ShadowPay // Required
+	Signer     wallet.Signer        // Required, the paying wallet
+	HTTPClient *http.Client         // Fetches resources, defaults to http.DefaultClient
+}
+
+// Agent pays for x402 resources from one wallet. It is safe for concurrent use.
+type Agent struct {
+	config Config
+
+	mu       sync.Mutex
+	identity *shadowid.Identity // Derived on first payment
+}
+
+// New creates an agent.
+func New(config Config) (*Agent, error) {
+	if config.SDK == nil || config.Signer == nil {
+		return nil, fmt.Errorf("agent needs an SDK and a signer")
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	return &Agent{config: config}, nil
+}
+
+// Pay fetches url, paying up to maxPrice lamports if the resource requires
+// payment, and returns the resource's response. Nothing is paid when the
+// price exceeds maxPrice or the escrow balance.
+func (a *Agent) Pay(ctx context.Context, url string, maxPrice int64) (*Result, error) {
+	resp, err := a.get(ctx, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusPaymentRequired {
+		return &Result{Response: resp}, nil
+	}
+	result, headers, err := a.settle(ctx, resp, url, maxPrice)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err = a.get(ctx, url, headers)
+	if err != nil {
+		return result, fmt.Errorf("paid in %s but failed to fetch the resource: %w", result.TxSig, err)
+	}
+	result.Response = resp
+	if resp.StatusCode == http.StatusPaymentRequired {
+		resp.Body.Close()
+		result.Response = nil
+		return result, fmt.Errorf("%w after settling %s", ErrPaymentRejected, result.TxSig)
+	}
+	return result, nil
+}
+
+// settle pays for the resource at url that answered with the 402 response
+// resp, which it closes, and returns the headers to fetch it again with.
+func (a *Agent) settle(ctx context.Context, resp *http.Response, url string, maxPrice int64) (*Result, map[string]string, error) {
+	challenge, err := readChallenge(resp)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, price, err := a.choose(ctx, challenge, maxPrice)
+	if err != nil {
+		return nil, nil, err
+	}
+	if req.Resource == "" {
+		req.Resource = url
+	}
+
+	balance, err := a.config.SDK.Escrow.GetBalance(ctx, a.config.Signer.Address())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to check escrow balance: %w", err)
+	}
+	if balance.BalanceAmount() < price {
+		return nil, nil, fmt.Errorf("%w: %d lamports, price is %d", ErrInsufficientBalance, balance.Balance, price)
+	}
+
+	id, err := a.deriveIdentity()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, header, err := a.pay(ctx, challenge.X402Version, req, price, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, map[string]string{
+		HeaderPayment:   header,
+		"Authorization": "Bearer " + result.AccessToken,
+	}, nil
+}
+
+// choose picks the cheapest payment option whose scheme and network the API
+// supports, and returns its price in lamports.
+func (a *Agent) choose(ctx context.Context, challenge *Challenge, maxPrice int64) (*payment.Requirements, amount.Amount, error) {
+	caps, err := a.config.SDK.Capabilities(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to discover supported schemes: %w", err)
+	}
+
+	var best *payment.Requirements
+	var bestAmount amount.Amount
+	for i := range challenge.Accepts {
+		req := &challenge.Accepts[i]
+		if !caps.SupportsScheme(req.Scheme, req.Network) {
+			continue
+		}
+		price, err := amount.ParseSOL(req.MaxAmountRequired)
+		if err != nil {
+			continue
+		}
+		if best == nil || price < bestAmount {
+			best, bestAmount = req, price
+		}
+	}
+	if best == nil {
+		return nil, 0, ErrNoSupportedScheme
+	}
+	if bestAmount > amount.Lamports(maxPrice) {
+		return nil, 0, fmt.Errorf("%w: %d lamports, maximum is %d", ErrPriceTooHigh, bestAmount, maxPrice)
+	}
+	return best, bestAmount, nil
+}
+
+// pay prepares, authorizes and settles the payment, returning the result and
+// the payment header to present to the resource.
+func (a *Agent) pay(ctx context.Context, x402Version int, req *payment.Requirements, price amount.Amount, id *shadowid.Identity) (*Result, string, error) {
+	receiver, _ := req.Extra["receiverCommitment"].(string)
+	if receiver == "" {
+		receiver = req.PayTo
+	}
+	prepared, err := a.config.SDK.Payment.Prepare(ctx, payment.PrepareRequest{
+		ReceiverCommitment: types.Commitment(receiver),
+		Amount:             price.Int64(),
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to prepare payment: %w", err)
+	}
+
+	auth, err := a.config.SDK.Payment.Authorize(ctx, payment.AuthorizeRequest{
+		Commitment: types.Commitment(id.CommitmentHex()),
+		Nullifier:  merkle.FormatElement(id.Nullifier),
+		Amount:     price.Int64(),
+		Merchant:   types.Address(req.PayTo),
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to authorize payment: %w", err)
+	}
+	if !auth.Success {
+		return nil, "", fmt.Errorf("payment not authorized: %s", auth.Message)
+	}
+
+	header, err := paymentHeader(x402Version, req, prepared, auth.AccessToken)
+	if err != nil {
+		return nil, "", err
+	}
+	settled, err := a.config.SDK.Payment.Settle(ctx, payment.SettleRequest{
+		X402Version:         x402Version,
+		PaymentHeader:       header,
+		Resource:            req.Resource,
+		PaymentRequirements: *req,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to settle payment: %w", err)
+	}
+	if !settled.Success {
+		return nil, "", fmt.Errorf("payment not settled: %s", settled.Message)
+	}
+
+	return &Result{
+		Paid:         true,
+		Amount:       price,
+		Requirements: req,
+		AccessToken:  auth.AccessToken,
+		TxSig:        settled.TxSig,
+	}, header, nil
+}
+
+// paymentHeader encodes the payment as base64 JSON, the x402 header format.
+func paymentHeader(x402Version int, req *payment.Requirements, prepared *payment.PrepareResponse, accessToken string) (string, error) {
+	data, err := json.Marshal(map[string]any{
+		"x402Version": x402Version,
+		"scheme":      req.Scheme,
+		"network":     req.Network,
+		"payload": map[string]string{
+			"paymentHash": prepared.PaymentHash,
+			"commitment":  prepared.Commitment,
+			"accessToken": accessToken,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// deriveIdentity derives the wallet's ShadowID once; it needs a signature.
+func (a *Agent) deriveIdentity() (*shadowid.Identity, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.identity == nil {
+		id, err := shadowid.DeriveCommitment(a.config.Signer)
+		if err != nil {
+			return nil, err
+		}
+		a.identity = id
+	}
+	return a.identity, nil
+}
+
+func (a *Agent) get(ctx context.Context, url string, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return a.config.HTTPClient.Do(req)
+}
+
+// readChallenge parses and closes a 402 response.
+func readChallenge(resp *http.Response) (*Challenge, error) {
+	defer resp.Body.Close()
+	var c Challenge
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxChallengeSize)).Decode(&c); err != nil {
+		return nil, fmt.Errorf("invalid payment requirements: %w", err)
+	}
+	if len(c.Accepts) == 0 {
+		return nil, fmt.Errorf("payment required but no payment options offered: %s", c.Error)
+	}
+	if c.X402Version == 0 {
+		c.X402Version = types.DefaultX402Version
+	}
+	return &c, nil
+}
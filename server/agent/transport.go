@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Transport is an http.RoundTripper that pays for x402 resources with an
+// agent, so any http.Client fetches paid resources as if they were free:
+//
+//	client := &http.Client{Transport: &agent.Transport{Agent: a, MaxPrice: 1_000_000}}
+//	resp, err := client.Get("https://api.example.com/report")
+//
+// A request answered with 402 Payment Required is paid for and sent again.
+// Requests with a body are only paid for if the body can be replayed, i.e.
+// GetBody is set, as it is for requests built by http.NewRequest from
+// in-memory readers. Failed payments are returned as errors.
+type Transport struct {
+	Agent    *Agent            // Required
+	MaxPrice int64             // Most paid per request, in lamports
+	Base     http.RoundTripper // Sends the requests, defaults to http.DefaultTransport
+
+	// OnPayment, if set, is called with each settled payment before the
+	// resource is fetched again. Result.Response is nil.
+	OnPayment func(req *http.Request, result *Result)
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusPaymentRequired {
+		return resp, err
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil
+	}
+
+	result, headers, err := t.Agent.settle(req.Context(), resp, req.URL.String(), t.MaxPrice)
+	if err != nil {
+		return nil, err
+	}
+	if t.OnPayment != nil {
+		t.OnPayment(req, result)
+	}
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	for k, v := range headers {
+		retry.Header.Set(k, v)
+	}
+	resp, err = base.RoundTrip(retry)
+	if err != nil {
+		return nil, fmt.Errorf("paid in %s but failed to fetch the resource: %w", result.TxSig, err)
+	}
+	if resp.StatusCode == http.StatusPaymentRequired {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w after settling %s", ErrPaymentRejected, result.TxSig)
+	}
+	return resp, nil
+}
@@ -0,0 +1,151 @@
+package shadowpay
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"sol_privacy/internal/authorization"
+	"sol_privacy/internal/escrow"
+	"sol_privacy/internal/pool"
+	"sol_privacy/internal/shadowid"
+)
+
+// DefaultBatchWorkers is the number of calls a batch runs concurrently by default.
+const DefaultBatchWorkers = 8
+
+// Batch collects read calls and issues them concurrently with a bounded worker pool.
+// A Batch is not safe for concurrent use while calls are being added; Run may only be called once.
+type Batch struct {
+	sp      *ShadowPay
+	workers int
+	calls   []batchCall
+}
+
+type batchCall struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// BatchResult holds the outcome of a single batched call. It is populated when Run returns.
+type BatchResult[T any] struct {
+	Value *T
+	Err   error
+}
+
+// BatchError aggregates the failures of a batch, keyed by call name.
+type BatchError struct {
+	Errors map[string]error
+}
+
+func (e *BatchError) Error() string {
+	names := make([]string, 0, len(e.Errors))
+	for name := range e.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s: %v", name, e.Errors[name])
+	}
+	return fmt.Sprintf("shadowpay: %d batched call(s) failed: %s", len(names), strings.Join(parts, "; "))
+}
+
+// Batch starts a new batch of calls. workers bounds concurrency; zero uses DefaultBatchWorkers.
+func (s *ShadowPay) Batch(workers int) *Batch {
+	if workers <= 0 {
+		workers = DefaultBatchWorkers
+	}
+	return &Batch{sp: s, workers: workers}
+}
+
+// Queue adds a call to the batch and returns its result holder.
+// name identifies the call in a BatchError and should be unique within the batch.
+func Queue[T any](b *Batch, name string, call func(ctx context.Context) (*T, error)) *BatchResult[T] {
+	result := &BatchResult[T]{}
+	b.calls = append(b.calls, batchCall{
+		name: name,
+		run: func(ctx context.Context) error {
+			result.Value, result.Err = call(ctx)
+			return result.Err
+		},
+	})
+	return result
+}
+
+// EscrowBalance queues an escrow SOL balance lookup.
+func (b *Batch) EscrowBalance(wallet string) *BatchResult[escrow.BalanceResponse] {
+	return Queue(b, "escrow.balance:"+wallet, func(ctx context.Context) (*escrow.BalanceResponse, error) {
+		return b.sp.Escrow.GetBalance(ctx, wallet)
+	})
+}
+
+// EscrowTokenBalance queues an escrow SPL token balance lookup.
+func (b *Batch) EscrowTokenBalance(wallet, mint string) *BatchResult[escrow.BalanceResponse] {
+	return Queue(b, "escrow.token_balance:"+wallet+":"+mint, func(ctx context.Context) (*escrow.BalanceResponse, error) {
+		return b.sp.Escrow.GetTokenBalance(ctx, wallet, mint)
+	})
+}
+
+// PoolBalance queues a privacy pool balance lookup.
+func (b *Batch) PoolBalance(wallet string) *BatchResult[pool.BalanceResponse] {
+	return Queue(b, "pool.balance:"+wallet, func(ctx context.Context) (*pool.BalanceResponse, error) {
+		return b.sp.Pool.GetBalance(ctx, wallet)
+	})
+}
+
+// ShadowIDStatus queues a ShadowID commitment status lookup.
+func (b *Batch) ShadowIDStatus(commitment string) *BatchResult[shadowid.StatusResponse] {
+	return Queue(b, "shadowid.status:"+commitment, func(ctx context.Context) (*shadowid.StatusResponse, error) {
+		return b.sp.ShadowID.GetStatus(ctx, commitment)
+	})
+}
+
+// Authorizations queues a lookup of a wallet's spending authorizations and limits.
+func (b *Batch) Authorizations(wallet string) *BatchResult[authorization.ListAuthorizationsResponse] {
+	return Queue(b, "authorization.list:"+wallet, func(ctx context.Context) (*authorization.ListAuthorizationsResponse, error) {
+		return b.sp.Authorization.ListAuthorizations(ctx, wallet)
+	})
+}
+
+// Len returns the number of queued calls.
+func (b *Batch) Len() int {
+	return len(b.calls)
+}
+
+// Run issues all queued calls and waits for them to finish.
+// It returns a *BatchError if any call failed; successful results are still populated.
+func (b *Batch) Run(ctx context.Context) error {
+	jobs := make(chan batchCall)
+	var mu sync.Mutex
+	failed := make(map[string]error)
+
+	var wg sync.WaitGroup
+	for i := 0; i < b.workers && i < len(b.calls); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for call := range jobs {
+				if err := call.run(ctx); err != nil {
+					mu.Lock()
+					failed[call.name] = err
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, call := range b.calls {
+		jobs <- call
+	}
+	close(jobs)
+	wg.Wait()
+
+	if len(failed) > 0 {
+		return &BatchError{Errors: failed}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+// Command artifacts manages the cached circuit artifacts client-side proofs
+// need:
+//
+//	artifacts sync -pin    # First sync: download and pin, then commit the manifest
+//	artifacts sync         # Download what is missing, checked against the pins
+//	artifacts verify       # Check the cache against the pins
+//	artifacts list         # Show the manifest's circuits and cached versions
+//	artifacts prune        # Remove versions other than the manifest's
+//
+// The cache is -dir, $SHADOWPAY_ARTIFACTS_DIR or ~/.shadowpay/artifacts. The
+// manifest is -manifest, $SHADOWPAY_ARTIFACTS_MANIFEST or manifest.json in
+// the cache; without one, the Umbra circuits are used, unpinned.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"sol_privacy/internal/artifacts"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	godotenv.Load()
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+	cmd := os.Args[1]
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	dir := fs.String("dir", artifacts.DefaultDir(), "Cache directory")
+	manifestPath := fs.String("manifest", os.Getenv("SHADOWPAY_ARTIFACTS_MANIFEST"), "Manifest file (default: manifest.json in the cache)")
+	pin := fs.Bool("pin", false, "sync: pin unpinned files to their download")
+	circuits := fs.String("circuits", "", "sync: comma-separated circuits (default: all)")
+	fs.Parse(os.Args[2:])
+
+	if *dir == "" {
+		log.Fatal("no cache directory; pass -dir")
+	}
+	if *manifestPath == "" {
+		*manifestPath = filepath.Join(*dir, "manifest.json")
+	}
+	manifest, err := artifacts.LoadManifest(*manifestPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cache := artifacts.NewCache(*dir, manifest)
+
+	switch cmd {
+	case "sync":
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		var names []string
+		for _, name := range strings.Split(*circuits, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		err := cache.Sync(ctx, artifacts.SyncOptions{
+			Circuits: names,
+			Pin:      *pin,
+			Progress: func(circuit, kind string, f artifacts.Artifact, downloaded bool) {
+				status := "cached"
+				if downloaded {
+					status = fmt.Sprintf("downloaded %d bytes", f.Size)
+				}
+				fmt.Printf("%-34s %-5s %s  %s\n", circuit, kind, f.SHA256[:12], status)
+			},
+		})
+		// Keep the pins of the files downloaded before any failure
+		if *pin {
+			os.MkdirAll(filepath.Dir(*manifestPath), 0o755)
+			if serr := manifest.Save(*manifestPath); serr != nil {
+				log.Fatal(serr)
+			}
+			fmt.Printf("Pins saved to %s\n", *manifestPath)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "verify":
+		if err := cache.Verify(); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("All %d circuits of version %s match their pins\n", len(manifest.Circuits), manifest.Version)
+	case "list":
+		fmt.Printf("Manifest %s, version %s, from %s\n", *manifestPath, manifest.Version, manifest.BaseURL)
+		for _, name := range manifest.CircuitNames() {
+			if _, err := cache.Open(name); err != nil {
+				fmt.Printf("  %v\n", err) // Names the circuit
+			} else {
+				fmt.Printf("  %-34s ok\n", name)
+			}
+		}
+		versions, err := cache.Versions()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Cached versions: %s\n", strings.Join(versions, ", "))
+	case "prune":
+		removed, err := cache.Prune()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Removed versions: %s\n", strings.Join(removed, ", "))
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: artifacts sync|verify|list|prune [flags]")
+	os.Exit(2)
+}
@@ -0,0 +1,95 @@
+// Command demo-buyer fetches demo-merchant's paywalled endpoint with a plain
+// http.Client whose transport pays for x402 resources, settling the payment
+// through the API:
+//
+//	SHADOWPAY_API_KEY=... demo-buyer -url http://localhost:4021/premium
+//
+// It pays from -wallet, or the default named wallet, out of that wallet's
+// escrow. On devnet, fund it with cmd/faucet and deposit to escrow first.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	shadowpay "sol_privacy"
+	"sol_privacy/agent"
+	"sol_privacy/internal/amount"
+	"sol_privacy/internal/client"
+	"sol_privacy/internal/wallets"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	godotenv.Load()
+
+	url := flag.String("url", "http://localhost:4021/premium", "Paywalled resource to fetch")
+	walletName := flag.String("wallet", "", "Named wallet that pays (default: the default named wallet)")
+	maxPrice := flag.String("max-price", "0.01", "Most to pay, in SOL")
+	timeout := flag.Duration("timeout", 2*time.Minute, "Give up after this long")
+	flag.Parse()
+
+	ws, err := wallets.Open(wallets.DefaultPath())
+	if err != nil {
+		log.Fatal(err)
+	}
+	signer, err := ws.Signer(*walletName)
+	if err != nil {
+		log.Fatalf("no wallet to pay with: %v", err)
+	}
+	limit, err := amount.ParseSOL(*maxPrice)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var opts []client.Option
+	if baseURL := os.Getenv("SHADOWPAY_BASE_URL"); baseURL != "" {
+		opts = append(opts, client.WithBaseURL(baseURL))
+	}
+	sdk := shadowpay.New(os.Getenv("SHADOWPAY_API_KEY"), opts...)
+	a, err := agent.New(agent.Config{SDK: sdk, Signer: signer})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	if balance, err := sdk.Escrow.GetBalance(ctx, signer.Address()); err == nil {
+		log.Printf("paying from %s, escrow balance %s SOL", signer.Address(), balance.BalanceAmount().SOL())
+	}
+
+	httpClient := &http.Client{Transport: &agent.Transport{
+		Agent:    a,
+		MaxPrice: limit.Int64(),
+		OnPayment: func(req *http.Request, result *agent.Result) {
+			log.Printf("paid %s SOL for %s, settled in %s", result.Amount.SOL(), req.URL, result.TxSig)
+		},
+	}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *url, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("%s answered %s", *url, resp.Status)
+	fmt.Println(string(body))
+	if resp.StatusCode != http.StatusOK {
+		os.Exit(1)
+	}
+}
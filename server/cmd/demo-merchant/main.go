@@ -0,0 +1,145 @@
+// Command demo-merchant serves a paywalled endpoint with the x402 middleware,
+// priced in devnet SOL. Pair it with demo-buyer:
+//
+//	SHADOWPAY_API_KEY=... demo-merchant -price 0.001
+//	SHADOWPAY_API_KEY=... demo-buyer -url http://localhost:4021/premium
+//
+// GET / is free and describes the offer. GET /premium answers 402 Payment
+// Required with the payment requirements until it is fetched with an access
+// token, which the middleware verifies with the API. Payments go to -pay-to,
+// or the default named wallet.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	shadowpay "sol_privacy"
+	"sol_privacy/internal/amount"
+	"sol_privacy/internal/client"
+	"sol_privacy/internal/payment"
+	"sol_privacy/internal/wallets"
+	"sol_privacy/internal/x402"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	godotenv.Load()
+
+	addr := flag.String("addr", ":4021", "Address to listen on")
+	publicURL := flag.String("public-url", "", "URL buyers reach the server at (default: http://localhost and the port of -addr)")
+	price := flag.String("price", "0.001", "Price of /premium in SOL")
+	network := flag.String("network", "solana-devnet", "x402 network the payment settles on")
+	payTo := flag.String("pay-to", os.Getenv("DEMO_MERCHANT_WALLET"), "Wallet receiving payments (default: the default named wallet)")
+	flag.Parse()
+
+	if *payTo == "" {
+		ws, err := wallets.Open(wallets.DefaultPath())
+		if err != nil {
+			log.Fatal(err)
+		}
+		if *payTo, err = ws.Resolve(""); err != nil {
+			log.Fatalf("no -pay-to wallet: %v", err)
+		}
+	}
+	if *publicURL == "" {
+		*publicURL = "http://localhost" + (*addr)[strings.LastIndex(*addr, ":"):]
+	}
+	lamports, err := amount.ParseSOL(*price)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var opts []client.Option
+	if baseURL := os.Getenv("SHADOWPAY_BASE_URL"); baseURL != "" {
+		opts = append(opts, client.WithBaseURL(baseURL))
+	}
+	sdk := shadowpay.New(os.Getenv("SHADOWPAY_API_KEY"), opts...)
+
+	offer, err := x402.NewRequirements().
+		Network(*network).
+		Price(lamports.Int64()).
+		Resource(*publicURL + "/premium").
+		Description("Premium market report").
+		PayTo(*payTo).
+		Build()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"endpoint": offer.Resource,
+			"price":    offer.MaxAmountRequired + " SOL",
+			"network":  offer.Network,
+			"pay_to":   offer.PayTo,
+		})
+	})
+	paywall := sdk.Payment.RequireAccess(payment.MiddlewareOptions{Accepts: []payment.Requirements{offer}})
+	mux.Handle("GET /premium", paywall(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		access := payment.AccessFromContext(r.Context())
+		log.Printf("served /premium to commitment %s (%s SOL)", access.Commitment, amount.Lamports(access.Amount).SOL())
+		writeJSON(w, map[string]any{
+			"report":     "SOL volatility is expected to stay elevated this week.",
+			"paid_by":    access.Commitment,
+			"amount":     amount.Lamports(access.Amount).SOL(),
+			"expires_at": access.ExpiresAt(),
+		})
+	})))
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           logRequests(mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		log.Printf("demo-merchant selling %s for %s SOL on %s, paid to %s",
+			offer.Resource, offer.MaxAmountRequired, offer.Network, offer.PayTo)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+	<-ctx.Done()
+
+	shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	srv.Shutdown(shutdown)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// statusRecorder captures the status a handler wrote, for logging.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		log.Printf("%s %s -> %d", r.Method, r.URL.Path, rec.status)
+	})
+}
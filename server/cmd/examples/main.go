@@ -0,0 +1,119 @@
+// Command examples runs the SDK example scenarios as an integration smoke
+// test:
+//
+//	examples -list                       # Show the scenarios
+//	examples                             # Run all of them
+//	examples -run escrow,stealth -json   # Run some, JSON results on stdout
+//	examples -dry-run                    # Local steps only; list the API calls
+//
+// The exit status is 1 if a scenario failed. Configuration comes from the
+// environment (see gallery.ConfigFromEnv).
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"sol_privacy/internal/gallery"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	godotenv.Load()
+
+	list := flag.Bool("list", false, "List the scenarios and exit")
+	names := flag.String("run", "", "Comma-separated scenarios to run (default: all)")
+	dryRun := flag.Bool("dry-run", false, "Skip steps that need the network, printing their requests")
+	asJSON := flag.Bool("json", false, "Print results as JSON")
+	timeout := flag.Duration("timeout", 5*time.Minute, "Give up after this long")
+	flag.Parse()
+
+	if *list {
+		for _, s := range gallery.Scenarios() {
+			fmt.Printf("%-18s %s\n", s.Name, s.Description)
+		}
+		return
+	}
+
+	var selected []string
+	if *names != "" {
+		selected = strings.Split(*names, ",")
+	}
+	scenarios, err := gallery.Select(selected)
+	if err != nil {
+		log.Fatal(err)
+	}
+	config, err := gallery.ConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	config.DryRun = *dryRun
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	results := gallery.Run(ctx, config, scenarios)
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(results)
+	} else {
+		printResults(results)
+	}
+	for _, r := range results {
+		if !r.OK {
+			os.Exit(1)
+		}
+	}
+}
+
+// printResults prints one line per step, with its detail abbreviated.
+func printResults(results []gallery.Result) {
+	marks := map[string]string{
+		gallery.StatusOK:      "✓",
+		gallery.StatusFailed:  "✗",
+		gallery.StatusSkipped: "-",
+	}
+	for _, r := range results {
+		status := "ok"
+		if !r.OK {
+			status = "FAILED"
+		}
+		fmt.Printf("%s: %s (%.2fs)\n", r.Scenario, status, r.Seconds)
+		for _, step := range r.Steps {
+			line := fmt.Sprintf("  %s %s", marks[step.Status], step.Name)
+			switch {
+			case step.Error != "":
+				line += ": " + step.Error
+			case step.Detail != nil:
+				line += ": " + abbreviate(step.Detail, 100)
+			}
+			fmt.Println(line)
+		}
+		if !r.OK && len(r.Steps) == 0 {
+			fmt.Printf("  %s\n", r.Error)
+		}
+	}
+}
+
+func abbreviate(v any, n int) string {
+	s, ok := v.(string)
+	if !ok {
+		data, _ := json.Marshal(v)
+		s = string(data)
+	}
+	if len(s) > n {
+		s = s[:n] + "..."
+	}
+	return s
+}
@@ -0,0 +1,82 @@
+// Command export writes a wallet's receipts as a file accounting software can
+// import, valued in a fiat currency at the time each receipt settled:
+//
+//	SHADOWPAY_API_KEY=... export -wallet <address> -format ofx -out receipts.ofx
+//	SHADOWPAY_API_KEY=... export -wallet shop -format qif -currency eur
+//	SHADOWPAY_API_KEY=... export -wallet shop -rate 150   # A fixed price per SOL, offline
+//	SHADOWPAY_API_KEY=... export -wallet shop -currency ""  # Amounts in SOL
+//
+// Formats are csv, qif and ofx. Prices come from CoinGecko's history, at
+// $PRICING_URL if set, with $COINGECKO_API_KEY if set. The file is written
+// to stdout unless -out is given.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	shadowpay "sol_privacy"
+	"sol_privacy/internal/client"
+	"sol_privacy/internal/pricing"
+	"sol_privacy/internal/wallets"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	godotenv.Load()
+
+	walletFlag := flag.String("wallet", "", "Wallet name or address (default: the default named wallet)")
+	format := flag.String("format", "csv", "csv, qif or ofx")
+	currency := flag.String("currency", "usd", "Fiat currency to value receipts in; empty for SOL")
+	rate := flag.Float64("rate", 0, "Value receipts at this fixed price per SOL instead of historical prices")
+	out := flag.String("out", "", "File to write (default: stdout)")
+	timeout := flag.Duration("timeout", 5*time.Minute, "How long the export may take")
+	flag.Parse()
+
+	apiKey := os.Getenv("SHADOWPAY_API_KEY")
+	if apiKey == "" {
+		log.Fatal("SHADOWPAY_API_KEY is required")
+	}
+	ws, err := wallets.Open(wallets.DefaultPath())
+	if err != nil {
+		log.Fatal(err)
+	}
+	address, err := ws.Resolve(*walletFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var opts []client.Option
+	if baseURL := os.Getenv("SHADOWPAY_BASE_URL"); baseURL != "" {
+		opts = append(opts, client.WithBaseURL(baseURL))
+	}
+	sdk := shadowpay.New(apiKey, opts...)
+	switch {
+	case *currency == "":
+	case *rate > 0:
+		sdk.Receipt.SetPricing(pricing.Fixed(*rate), *currency)
+	default:
+		sdk.Receipt.SetPricing(pricing.NewCoinGecko(pricing.Config{
+			BaseURL: os.Getenv("PRICING_URL"),
+			APIKey:  os.Getenv("COINGECKO_API_KEY"),
+		}), *currency)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	data, err := sdk.Receipt.Export(ctx, address, *format)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *out == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
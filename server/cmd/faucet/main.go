@@ -0,0 +1,78 @@
+// Command faucet airdrops devnet SOL to a wallet and waits until it lands, so
+// the CLI wizard and the examples can be tried without funding wallets by hand:
+//
+//	faucet                          # 1 SOL to the default named wallet
+//	faucet -wallet savings -amount 2
+//	faucet -wallet <address> -min 0.5   # Only when the balance is below 0.5 SOL
+//
+// The cluster is $SOLANA_RPC_URL, or devnet. The result is printed as JSON.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"sol_privacy/internal/amount"
+	"sol_privacy/internal/faucet"
+	"sol_privacy/internal/solana"
+	"sol_privacy/internal/wallets"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	godotenv.Load()
+	rpcURL := os.Getenv("SOLANA_RPC_URL")
+	if rpcURL == "" {
+		rpcURL = solana.DevnetRPCURL
+	}
+
+	walletFlag := flag.String("wallet", "", "Wallet name or address (default: the default named wallet)")
+	amountFlag := flag.String("amount", "1", "SOL to airdrop")
+	minFlag := flag.String("min", "", "Only airdrop when the balance is below this many SOL")
+	rpcFlag := flag.String("rpc", rpcURL, "Solana RPC URL")
+	timeout := flag.Duration("timeout", 2*time.Minute, "How long to wait for the airdrop to land")
+	flag.Parse()
+
+	ws, err := wallets.Open(wallets.DefaultPath())
+	if err != nil {
+		log.Fatal(err)
+	}
+	address, err := ws.Resolve(*walletFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	lamports, err := amount.ParseSOL(*amountFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	rpc := solana.NewClient(solana.Config{URL: *rpcFlag})
+
+	var result *faucet.Result
+	if *minFlag != "" {
+		min, err := amount.ParseSOL(*minFlag)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if result, err = faucet.EnsureBalance(ctx, rpc, address, min); err != nil {
+			log.Fatal(err)
+		}
+		if result == nil {
+			fmt.Fprintf(os.Stderr, "%s already has at least %s SOL\n", address, min.SOL())
+			return
+		}
+	} else if result, err = faucet.Airdrop(ctx, rpc, address, lamports); err != nil {
+		log.Fatal(err)
+	}
+
+	out, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Println(string(out))
+}
@@ -0,0 +1,48 @@
+// Command genclient generates the frontend's TypeScript client from the
+// proxy API handlers:
+//
+//	genclient -pkg internal/api -out ../frontend/src/services/shadowpay.gen.ts
+//	genclient -routes   # List the routes the client covers
+//
+// It runs from go generate in internal/api; regenerate after changing a
+// route or a request or response type, and commit the result.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"sol_privacy/internal/genclient"
+)
+
+func main() {
+	pkg := flag.String("pkg", ".", "Directory of the api package")
+	out := flag.String("out", "", "Write the client to this file instead of stdout")
+	routes := flag.Bool("routes", false, "List the routes instead of generating the client")
+	flag.Parse()
+
+	if *routes {
+		list, err := genclient.Routes(*pkg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, r := range list {
+			fmt.Printf("%-7s %-50s %s\n", r.Method, r.Path, r.Handler)
+		}
+		return
+	}
+
+	data, err := genclient.Generate(*pkg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *out == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
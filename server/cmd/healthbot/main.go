@@ -0,0 +1,77 @@
+// Command healthbot continuously exercises ShadowPay deployments and serves
+// the results as Prometheus metrics and JSON status:
+//
+//	healthbot -config healthbot.json
+//	healthbot -once   # One round, JSON status on stdout, exit 1 on failure
+//
+// Without -config it checks one environment configured from the
+// environment (see healthbot.LoadConfig).
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"sol_privacy/internal/healthbot"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	godotenv.Load()
+
+	configPath := flag.String("config", os.Getenv("HEALTHBOT_CONFIG"), "JSON config file")
+	listen := flag.String("listen", os.Getenv("HEALTHBOT_LISTEN"), "Address serving /metrics, /status and /healthz (overrides the config)")
+	once := flag.Bool("once", false, "Run every check once, print the status and exit non-zero if any failed")
+	flag.Parse()
+
+	config, err := healthbot.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *listen != "" {
+		config.Listen = *listen
+	}
+	bot := healthbot.New(*config)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *once {
+		ok := bot.RunOnce(ctx)
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(bot.Status())
+		if !ok {
+			os.Exit(1)
+		}
+		return
+	}
+
+	srv := &http.Server{
+		Addr:              config.Listen,
+		Handler:           bot.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		log.Printf("healthbot serving metrics on %s, checking %d environments every %s",
+			config.Listen, len(config.Environments), time.Duration(config.Interval))
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	bot.Run(ctx)
+
+	shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	srv.Shutdown(shutdown)
+}
@@ -6,6 +6,8 @@ import (
 	"os"
 
 	"sol_privacy/internal/cli"
+	"sol_privacy/internal/config"
+	"sol_privacy/internal/secrets"
 	"sol_privacy/internal/server"
 
 	"github.com/joho/godotenv"
@@ -19,11 +21,17 @@ func main() {
 
 	// Define flags
 	serverMode := flag.Bool("server", false, "Run as HTTP API server instead of CLI")
-	port := flag.String("port", "8080", "Port to run server on (only used with --server)")
+	port := flag.String("port", "", "Port to run server on (only used with --server; default 8080)")
+	configPath := flag.String("config", os.Getenv("SHADOWPAY_CONFIG"), "YAML config file (only used with --server)")
+	sealPath := flag.String("seal-secrets", "", "Encrypt KEY=VALUE lines from stdin into this file with the master key, then exit")
 	flag.Parse()
 
+	if *sealPath != "" {
+		sealSecrets(*sealPath)
+		return
+	}
 	if *serverMode {
-		runServer(*port)
+		runServer(*configPath, *port)
 	} else {
 		runCLI()
 	}
@@ -35,16 +43,39 @@ func runCLI() {
 	}
 }
 
-func runServer(port string) {
-	apiKey := os.Getenv("SHADOWPAY_API_KEY")
-	if apiKey == "" {
-		log.Fatal("SHADOWPAY_API_KEY environment variable is required")
+// sealSecrets writes the secrets read from stdin to path, encrypted with the
+// master key, for the server's secrets.file setting.
+func sealSecrets(path string) {
+	values, err := secrets.ParseEnv(os.Stdin)
+	if err != nil {
+		log.Fatal(err)
+	}
+	key, err := secrets.MasterKey()
+	if err != nil {
+		log.Fatal(err)
+	}
+	data, err := secrets.Seal(values, key)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("sealed %d secrets into %s", len(values), path)
+}
+
+func runServer(configPath, port string) {
+	// The flag overrides the file like the environment does, including on reload
+	if port != "" {
+		os.Setenv("PORT", port)
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	if err := server.Run(server.Config{
-		APIKey: apiKey,
-		Port:   port,
-	}); err != nil {
+	if err := server.Run(cfg); err != nil {
 		log.Fatal(err)
 	}
 }
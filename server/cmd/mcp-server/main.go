@@ -0,0 +1,56 @@
+// Command mcp-server exposes ShadowPay payment operations as Model Context
+// Protocol tools over stdio, so agent frameworks such as LangChain (through
+// its MCP adapters) and MCP clients can check pool balances, authorize
+// spending, and prepare and settle payments:
+//
+//	SHADOWPAY_API_KEY=... mcp-server
+//
+// Every payment is checked against a spending policy configured by
+// MCP_MAX_AMOUNT, MCP_DAILY_LIMIT, MCP_ALLOWED_RECIPIENTS and
+// MCP_MAX_AUTH_TTL (see mcp.PolicyFromEnv). Logs go to stderr; stdout carries
+// only protocol messages.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	shadowpay "sol_privacy"
+	"sol_privacy/internal/client"
+	"sol_privacy/internal/mcp"
+
+	"github.com/joho/godotenv"
+)
+
+const version = "0.1.0"
+
+func main() {
+	godotenv.Load()
+	log.SetOutput(os.Stderr)
+
+	apiKey := os.Getenv("SHADOWPAY_API_KEY")
+	if apiKey == "" {
+		log.Fatal("SHADOWPAY_API_KEY is required")
+	}
+	var opts []client.Option
+	if baseURL := os.Getenv("SHADOWPAY_BASE_URL"); baseURL != "" {
+		opts = append(opts, client.WithBaseURL(baseURL))
+	}
+	sdk := shadowpay.New(apiKey, opts...)
+
+	policy, err := mcp.PolicyFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := mcp.NewPaymentServer(sdk, policy, version)
+	if err := server.Serve(ctx, os.Stdin, os.Stdout); err != nil && ctx.Err() == nil {
+		log.Fatal(err)
+	}
+}
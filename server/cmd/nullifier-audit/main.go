@@ -0,0 +1,114 @@
+// Command nullifier-audit exports the consumed-nullifier set and cross-checks
+// receipts against it, so auditors can verify independently that no payment
+// was spent twice:
+//
+//	SHADOWPAY_API_KEY=... nullifier-audit -receipts receipts.json
+//	nullifier-audit -set nullifiers.json -receipts receipts.json
+//	SHADOWPAY_API_KEY=... nullifier-audit -save nullifiers.json
+//
+// Receipts are a JSON array of signed receipts, or a receipt listing as the
+// API returns it. The report is printed as JSON; the exit status is 1 if it
+// found a problem.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	shadowpay "sol_privacy"
+	"sol_privacy/internal/client"
+	"sol_privacy/internal/nullifier"
+	"sol_privacy/internal/receipt"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	setFile := flag.String("set", "", "Audit a previously saved set instead of exporting it")
+	save := flag.String("save", "", "Save the exported set to this file")
+	receiptsFile := flag.String("receipts", "", "Receipts to cross-check against the set")
+	flag.Parse()
+	godotenv.Load()
+
+	set, err := loadSet(*setFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *save != "" {
+		data, err := json.MarshalIndent(set, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(*save, data, 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var receipts []receipt.Receipt
+	if *receiptsFile != "" {
+		if receipts, err = loadReceipts(*receiptsFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	report := nullifier.Audit(set, receipts)
+	out, _ := json.MarshalIndent(struct {
+		Digest string `json:"digest"`
+		*nullifier.Report
+	}{set.Digest, report}, "", "  ")
+	fmt.Println(string(out))
+	if !report.OK() {
+		os.Exit(1)
+	}
+}
+
+// loadSet reads a saved set, checking its digest, or exports it from the API.
+func loadSet(path string) (*nullifier.Set, error) {
+	if path == "" {
+		apiKey := os.Getenv("SHADOWPAY_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("SHADOWPAY_API_KEY is required to export the set")
+		}
+		var opts []client.Option
+		if baseURL := os.Getenv("SHADOWPAY_BASE_URL"); baseURL != "" {
+			opts = append(opts, client.WithBaseURL(baseURL))
+		}
+		return shadowpay.New(apiKey, opts...).Nullifiers.ExportAll(context.Background())
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set nullifier.Set
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	digest, err := nullifier.Digest(set.Entries)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if digest != set.Digest {
+		return nil, fmt.Errorf("%s: %w", path, nullifier.ErrDigestMismatch)
+	}
+	return &set, nil
+}
+
+func loadReceipts(path string) ([]receipt.Receipt, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var receipts []receipt.Receipt
+	if err := json.Unmarshal(data, &receipts); err == nil {
+		return receipts, nil
+	}
+	var listing receipt.ListUserReceiptsResponse
+	if err := json.Unmarshal(data, &listing); err != nil {
+		return nil, fmt.Errorf("%s: expected a receipt array or listing: %w", path, err)
+	}
+	return listing.Receipts, nil
+}
@@ -0,0 +1,115 @@
+// Command prover serves Groth16 proving to zkproof.Remote clients, e.g. on a
+// GPU host running rapidsnark:
+//
+//	prover -listen :8790 -rapidsnark /opt/rapidsnark/prover
+//
+// Clients seal witnesses to the service's X25519 key, kept in -key and
+// created on first start. The prover URI to give clients is printed on
+// start. Proving uses the synced, pinned artifacts of the cache, as in
+// cmd/artifacts; clients must use the same manifest.
+package main
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"sol_privacy/internal/artifacts"
+	"sol_privacy/internal/base58"
+	"sol_privacy/internal/zkproof"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	godotenv.Load()
+
+	listen := flag.String("listen", ":8790", "Address serving /prove")
+	keyPath := flag.String("key", os.Getenv("PROVER_KEY_FILE"), "X25519 key file (default: prover.key in the cache)")
+	rapidsnark := flag.String("rapidsnark", os.Getenv("RAPIDSNARK"), "Rapidsnark prover binary (default: prove with snarkjs)")
+	workers := flag.Int("workers", 1, "Proofs generated at once")
+	dir := flag.String("dir", artifacts.DefaultDir(), "Artifact cache directory")
+	manifestPath := flag.String("manifest", os.Getenv("SHADOWPAY_ARTIFACTS_MANIFEST"), "Artifact manifest (default: manifest.json in the cache)")
+	flag.Parse()
+
+	if *manifestPath == "" {
+		*manifestPath = filepath.Join(*dir, "manifest.json")
+	}
+	manifest, err := artifacts.LoadManifest(*manifestPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cache := artifacts.NewCache(*dir, manifest)
+	if err := cache.Verify(); err != nil {
+		log.Fatalf("artifacts are not ready; run artifacts sync: %v", err)
+	}
+
+	if *keyPath == "" {
+		*keyPath = filepath.Join(*dir, "prover.key")
+	}
+	key, err := loadKey(*keyPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var prover zkproof.WitnessProver = &zkproof.SnarkJS{Artifacts: cache}
+	if *rapidsnark != "" {
+		prover = &zkproof.Rapidsnark{Artifacts: cache, Command: *rapidsnark}
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/prove", zkproof.NewRemoteHandler(zkproof.RemoteConfig{
+		Key:      key,
+		Manifest: manifest,
+		Prover:   prover,
+		Workers:  *workers,
+	}))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	srv := &http.Server{Addr: *listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		log.Printf("prover serving on %s; clients use https://<host>/prove#%s", *listen, base58.Encode(key.PublicKey().Bytes()))
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+	<-ctx.Done()
+
+	shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	srv.Shutdown(shutdown)
+}
+
+// loadKey reads the hex X25519 private key at path, creating it if missing.
+func loadKey(path string) (*ecdh.PrivateKey, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		key, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return nil, err
+		}
+		return key, os.WriteFile(path, []byte(hex.EncodeToString(key.Bytes())+"\n"), 0o600)
+	}
+	if err != nil {
+		return nil, err
+	}
+	raw, err := hex.DecodeString(strings.TrimSpace(string(b)))
+	if err != nil {
+		return nil, err
+	}
+	return ecdh.X25519().NewPrivateKey(raw)
+}
@@ -0,0 +1,59 @@
+// Command testvectors checks crypto test vectors against the Go
+// implementations, or regenerates the golden file:
+//
+//	testvectors                       # Check the golden vectors
+//	testvectors -check vectors.json   # Check a file, e.g. one written by another implementation
+//	testvectors -write vectors.json   # Regenerate the golden file
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"sol_privacy/internal/testvectors"
+)
+
+func main() {
+	check := flag.String("check", "", "Vectors file to check instead of the golden vectors")
+	write := flag.String("write", "", "Regenerate the vectors into this file")
+	flag.Parse()
+
+	if *write != "" {
+		v, err := testvectors.Generate()
+		if err != nil {
+			log.Fatal(err)
+		}
+		data, err := testvectors.Marshal(v)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(*write, data, 0o644); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	v, err := testvectors.Load()
+	if *check != "" {
+		data, readErr := os.ReadFile(*check)
+		if readErr != nil {
+			log.Fatal(readErr)
+		}
+		v, err = testvectors.Parse(data)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	errs := append(testvectors.Verify(v), testvectors.VerifyReferences(v)...)
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	if len(errs) > 0 {
+		os.Exit(1)
+	}
+	fmt.Printf("ok: %d poseidon, %d merkle, %d shadowid, %d elgamal, %d stealth vectors\n",
+		len(v.Poseidon), len(v.Merkle), len(v.ShadowID), len(v.ElGamal), len(v.Stealth))
+}
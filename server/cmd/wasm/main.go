@@ -0,0 +1,44 @@
+//go:build js && wasm
+
+// Command wasm builds the crypto primitives for browsers. It registers a
+// shadowpayCrypto global whose call(name, argsJSON) runs a wasmcrypto
+// function and returns its JSON result, or an Error; shadowpay-crypto.js
+// wraps it. Build with
+//
+//	GOOS=js GOARCH=wasm go build -o shadowpay-crypto.wasm ./cmd/wasm
+package main
+
+import (
+	"syscall/js"
+
+	"sol_privacy/internal/wasmcrypto"
+)
+
+func main() {
+	names := wasmcrypto.Names()
+	list := make([]any, len(names))
+	for i, name := range names {
+		list[i] = name
+	}
+
+	js.Global().Set("shadowpayCrypto", js.ValueOf(map[string]any{
+		"names": list,
+		"call": js.FuncOf(func(_ js.Value, args []js.Value) any {
+			if len(args) < 1 || args[0].Type() != js.TypeString {
+				return js.Global().Get("Error").New("call(name, argsJSON): name must be a string")
+			}
+			var in []byte
+			if len(args) > 1 && args[1].Type() == js.TypeString {
+				in = []byte(args[1].String())
+			}
+			out, err := wasmcrypto.Call(args[0].String(), in)
+			if err != nil {
+				return js.Global().Get("Error").New(err.Error())
+			}
+			return string(out)
+		}),
+	}))
+
+	// Keep the functions callable for the life of the page
+	select {}
+}
@@ -0,0 +1,50 @@
+// Loads the crypto primitives built from cmd/wasm, so the browser runs the
+// same Poseidon, ShadowID, Merkle, ElGamal and stealth code as the backend.
+// Load wasm_exec.js from the Go distribution first; it defines Go.
+//
+//   import { loadShadowPayCrypto } from "./shadowpay-crypto.js";
+//
+//   const crypto = await loadShadowPayCrypto("/shadowpay-crypto.wasm");
+//   const message = crypto.shadowid.message({ wallet_address }).message;
+//   const id = crypto.shadowid.derive({ wallet_address, signature });
+//   const { amount } = crypto.elgamal.decrypt({ private_key, ciphertext });
+//
+// Functions are synchronous once loaded and throw on invalid arguments.
+
+let loading;
+
+export function loadShadowPayCrypto(url = "/shadowpay-crypto.wasm") {
+  loading ??= instantiate(url).catch((err) => {
+    loading = undefined;
+    throw err;
+  });
+  return loading;
+}
+
+async function instantiate(url) {
+  if (typeof Go === "undefined") {
+    throw new Error("load wasm_exec.js before shadowpay-crypto.js");
+  }
+  const go = new Go();
+  const { instance } = await WebAssembly.instantiateStreaming(
+    fetch(url),
+    go.importObject
+  );
+  // The module registers shadowpayCrypto and then blocks for the life of the page
+  go.run(instance);
+
+  const module = globalThis.shadowpayCrypto;
+  const api = {};
+  for (const name of module.names) {
+    const [group, fn] = name.split(".");
+    api[group] ??= {};
+    api[group][fn] = (args = {}) => {
+      const out = module.call(name, JSON.stringify(args));
+      if (out instanceof Error) {
+        throw out;
+      }
+      return JSON.parse(out);
+    };
+  }
+  return api;
+}
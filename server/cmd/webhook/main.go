@@ -0,0 +1,299 @@
+// Command webhook helps develop webhook endpoints locally. Its listen
+// subcommand runs an echo server, registers it as the merchant's webhook,
+// prints each delivery with its signature checked, and deactivates the
+// webhook on exit:
+//
+//	SHADOWPAY_API_KEY=... webhook listen -tunnel ngrok
+//	SHADOWPAY_API_KEY=... webhook listen -url https://my-tunnel.example -forward http://localhost:3000/hooks
+//	webhook listen -tls -secret whsec_...   # Local only, e.g. behind the proxy
+//
+// ShadowPay must reach the server, so deliveries need a public HTTPS URL:
+// -url for a tunnel that is already up, or -tunnel ngrok to start one with
+// the ngrok agent. Without either, nothing is registered.
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	shadowpay "sol_privacy"
+	"sol_privacy/internal/client"
+	"sol_privacy/internal/webhook"
+
+	"github.com/joho/godotenv"
+)
+
+// ngrokAPI is the ngrok agent's local API, which lists its tunnels.
+const ngrokAPI = "http://127.0.0.1:4040/api/tunnels"
+
+func main() {
+	godotenv.Load()
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 || os.Args[1] != "listen" {
+		fmt.Fprintln(os.Stderr, "usage: webhook listen [flags]")
+		os.Exit(2)
+	}
+	fs := flag.NewFlagSet("listen", flag.ExitOnError)
+	port := fs.Int("port", 4242, "Local port to listen on")
+	useTLS := fs.Bool("tls", false, "Serve HTTPS with a self-signed certificate")
+	publicURL := fs.String("url", "", "Public HTTPS URL forwarding to the local server")
+	tunnel := fs.String("tunnel", "", `Start a tunnel with this agent ("ngrok") instead of -url`)
+	events := fs.String("events", "payment.received,payment.settled,payment.failed", "Comma-separated events to subscribe to")
+	secret := fs.String("secret", "", "Signing secret (default: a new random one)")
+	header := fs.String("header", webhook.SignatureHeader, "Header carrying the signature")
+	forward := fs.String("forward", "", "Also forward deliveries to this URL, e.g. your app's handler")
+	fs.Parse(os.Args[2:])
+
+	if *secret == "" {
+		*secret = newSecret()
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	printer := &printer{forward: *forward, header: *header}
+	addr := fmt.Sprintf("127.0.0.1:%d", *port)
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           webhook.Listener(*secret, *header, printer.print),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	scheme := "http"
+	if *useTLS {
+		cert, err := selfSignedCert()
+		if err != nil {
+			log.Fatal(err)
+		}
+		srv.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+		scheme = "https"
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go func() {
+		var err error
+		if *useTLS {
+			err = srv.ServeTLS(ln, "", "")
+		} else {
+			err = srv.Serve(ln)
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+	local := scheme + "://" + addr
+	log.Printf("Listening on %s", local)
+
+	switch *tunnel {
+	case "":
+	case "ngrok":
+		url, err := startNgrok(ctx, local)
+		if err != nil {
+			log.Fatal(err)
+		}
+		*publicURL = url
+	default:
+		log.Fatalf("unknown tunnel %q; use ngrok, or -url with any other", *tunnel)
+	}
+
+	var sdk *shadowpay.ShadowPay
+	var webhookID string
+	if *publicURL != "" {
+		apiKey := os.Getenv("SHADOWPAY_API_KEY")
+		if apiKey == "" {
+			log.Fatal("SHADOWPAY_API_KEY is required to register the webhook")
+		}
+		var opts []client.Option
+		if baseURL := os.Getenv("SHADOWPAY_BASE_URL"); baseURL != "" {
+			opts = append(opts, client.WithBaseURL(baseURL))
+		}
+		sdk = shadowpay.New(apiKey, opts...)
+		resp, err := sdk.Webhook.Register(ctx, webhook.RegisterRequest{
+			URL:    *publicURL,
+			Events: splitList(*events),
+			Secret: *secret,
+		})
+		if err != nil {
+			log.Fatalf("registering %s failed: %v", *publicURL, err)
+		}
+		webhookID = resp.WebhookID
+		log.Printf("Registered %s as webhook %s for %s", *publicURL, webhookID, *events)
+	} else {
+		log.Printf("Not registered: pass -url or -tunnel ngrok to receive ShadowPay deliveries")
+	}
+	log.Printf("Signing secret: %s", *secret)
+	log.Printf("Ready. Press Ctrl+C to stop.\n")
+
+	<-ctx.Done()
+	log.Printf("\nStopping")
+	shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if webhookID != "" {
+		if _, err := sdk.Webhook.Deactivate(shutdown, webhook.DeactivateRequest{WebhookID: webhookID}); err != nil {
+			log.Printf("deactivating webhook %s failed: %v", webhookID, err)
+		} else {
+			log.Printf("Deactivated webhook %s", webhookID)
+		}
+	}
+	srv.Shutdown(shutdown)
+}
+
+// printer pretty-prints deliveries and forwards them.
+type printer struct {
+	forward string
+	header  string
+}
+
+func (p *printer) print(d webhook.Delivery) {
+	check := "signature verified"
+	if !d.Verified {
+		check = "SIGNATURE INVALID"
+		if d.Signature == "" {
+			check = "NO SIGNATURE"
+		}
+	}
+	event := d.Event
+	if event == "" {
+		event = "(unknown event)"
+	}
+	fmt.Printf("%s  %s  [%s]\n", d.Received.Format("15:04:05"), event, check)
+
+	var pretty bytes.Buffer
+	if json.Indent(&pretty, d.Payload, "  ", "  ") == nil {
+		fmt.Printf("  %s\n", pretty.String())
+	} else {
+		fmt.Printf("  %s\n", d.Payload)
+	}
+
+	if p.forward != "" {
+		fmt.Printf("  -> %s\n", p.send(d))
+	}
+}
+
+// send forwards a delivery with its signature and returns the outcome.
+func (p *printer) send(d webhook.Delivery) string {
+	req, err := http.NewRequest(http.MethodPost, p.forward, bytes.NewReader(d.Payload))
+	if err != nil {
+		return err.Error()
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(p.header, d.Signature)
+	resp, err := (&http.Client{Timeout: 10 * time.Second}).Do(req)
+	if err != nil {
+		return err.Error()
+	}
+	resp.Body.Close()
+	return fmt.Sprintf("%s %s", p.forward, resp.Status)
+}
+
+// startNgrok starts the ngrok agent forwarding to local and returns its
+// public HTTPS URL. The agent stops with ctx.
+func startNgrok(ctx context.Context, local string) (string, error) {
+	cmd := exec.CommandContext(ctx, "ngrok", "http", local, "--log", "false")
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("starting ngrok failed (is it installed and authenticated?): %w", err)
+	}
+	go cmd.Wait()
+
+	deadline := time.Now().Add(15 * time.Second)
+	for time.Now().Before(deadline) {
+		if url, err := ngrokURL(ctx); err == nil && url != "" {
+			return url, nil
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+	return "", errors.New("ngrok did not report a tunnel in time")
+}
+
+// ngrokURL returns the public HTTPS URL of the agent's tunnel, if any.
+func ngrokURL(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ngrokAPI, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var list struct {
+		Tunnels []struct {
+			PublicURL string `json:"public_url"`
+		} `json:"tunnels"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return "", err
+	}
+	for _, t := range list.Tunnels {
+		if strings.HasPrefix(t.PublicURL, "https://") {
+			return t.PublicURL, nil
+		}
+	}
+	return "", nil
+}
+
+// selfSignedCert creates a certificate for localhost, valid for a day.
+func selfSignedCert() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 62))
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+func newSecret() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return "whsec_" + hex.EncodeToString(b)
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
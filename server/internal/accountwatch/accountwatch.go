@@ -0,0 +1,206 @@
+// Package accountwatch follows the lamport balances of the pool and escrow
+// accounts through Solana WebSocket subscriptions, so deposits are noticed
+// when the cluster pushes them instead of by polling the ShadowPay API.
+//
+// Balances are read over RPC whenever the subscription (re)connects, so a
+// change made while disconnected is reported once the watcher is back.
+package accountwatch
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/solana"
+)
+
+// Account kinds.
+const (
+	KindPool   = "pool"
+	KindEscrow = "escrow"
+)
+
+// Reconnect backoff defaults.
+const (
+	DefaultBaseBackoff = time.Second
+	DefaultMaxBackoff  = time.Minute
+)
+
+// Account is an account to watch.
+type Account struct {
+	Address string `json:"address"`
+	Kind    string `json:"kind"` // pool or escrow
+}
+
+// Change is a balance change of a watched account.
+type Change struct {
+	Address  string `json:"address"`
+	Kind     string `json:"kind"`
+	Slot     uint64 `json:"slot,omitempty"` // Zero when caught up over RPC after a reconnect
+	Lamports uint64 `json:"lamports"`
+	Previous uint64 `json:"previous"`
+	Delta    int64  `json:"delta"` // Positive for deposits
+}
+
+// Config configures a Watcher.
+type Config struct {
+	RPC        *solana.Client // Reads balances when the subscription connects
+	URL        string         // WebSocket endpoint; derived from the RPC URL if empty
+	Commitment string         // Defaults to "confirmed"
+	Accounts   []Account
+
+	// PoolAddress looks up the pool PDA, watched along with Accounts, when
+	// Run starts. May be nil.
+	PoolAddress func(ctx context.Context) (string, error)
+
+	// OnChange is called for every balance change, in order per account.
+	OnChange func(ctx context.Context, c Change)
+
+	BaseBackoff time.Duration // First reconnect delay, defaults to DefaultBaseBackoff
+	MaxBackoff  time.Duration // Defaults to DefaultMaxBackoff
+}
+
+// Watcher subscribes to account changes and reports balance changes.
+type Watcher struct {
+	config Config
+
+	mu       sync.Mutex
+	balances map[string]uint64 // Last known, by address
+}
+
+// New creates a watcher. Call Run to start watching.
+func New(config Config) *Watcher {
+	if config.URL == "" {
+		config.URL = solana.WebSocketURL(config.RPC.URL())
+	}
+	if config.BaseBackoff == 0 {
+		config.BaseBackoff = DefaultBaseBackoff
+	}
+	if config.MaxBackoff == 0 {
+		config.MaxBackoff = DefaultMaxBackoff
+	}
+	return &Watcher{config: config, balances: make(map[string]uint64)}
+}
+
+// Run watches the accounts until ctx is done, reconnecting with backoff when
+// the subscription drops.
+func (w *Watcher) Run(ctx context.Context) {
+	accounts := w.config.Accounts
+	if w.config.PoolAddress != nil {
+		pool, ok := w.poolAddress(ctx)
+		if !ok {
+			return
+		}
+		accounts = append([]Account{{Address: pool, Kind: KindPool}}, accounts...)
+	}
+	kinds := make(map[string]string, len(accounts))
+	addresses := make([]string, len(accounts))
+	for i, a := range accounts {
+		kinds[a.Address] = a.Kind
+		addresses[i] = a.Address
+	}
+
+	backoff := w.config.BaseBackoff
+	for ctx.Err() == nil {
+		connected := false
+		opts := solana.WatchOptions{
+			Commitment: w.config.Commitment,
+			// Read once subscribed, so no change falls in between
+			OnSubscribed: func() {
+				connected = true
+				w.catchUp(ctx, addresses, kinds)
+			},
+		}
+		updates := make(chan solana.AccountUpdate)
+		done := make(chan error, 1)
+		go func() {
+			done <- solana.WatchAccounts(ctx, w.config.URL, addresses, opts, updates)
+		}()
+
+		var err error
+	loop:
+		for {
+			select {
+			case u := <-updates:
+				w.record(ctx, kinds[u.Address], u.Address, u.Lamports, u.Slot)
+			case err = <-done:
+				break loop
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			backoff = w.config.BaseBackoff
+		}
+		log.Printf("accountwatch: %v; reconnecting in %s", err, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, w.config.MaxBackoff)
+	}
+}
+
+// poolAddress looks up the pool PDA, retrying with backoff until ctx is done.
+func (w *Watcher) poolAddress(ctx context.Context) (string, bool) {
+	backoff := w.config.BaseBackoff
+	for {
+		address, err := w.config.PoolAddress(ctx)
+		if err == nil && address != "" {
+			return address, true
+		}
+		if err == nil {
+			err = errors.New("none returned")
+		}
+		if ctx.Err() != nil {
+			return "", false
+		}
+		log.Printf("accountwatch: pool address: %v; retrying in %s", err, backoff)
+		select {
+		case <-ctx.Done():
+			return "", false
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, w.config.MaxBackoff)
+	}
+}
+
+// catchUp reads the balances over RPC, reporting those that changed since
+// they were last seen.
+func (w *Watcher) catchUp(ctx context.Context, addresses []string, kinds map[string]string) {
+	for _, address := range addresses {
+		lamports, err := w.config.RPC.GetBalance(ctx, address)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("accountwatch: balance of %s: %v", address, err)
+			}
+			continue
+		}
+		w.record(ctx, kinds[address], address, lamports, 0)
+	}
+}
+
+// record stores a balance and reports it if it changed. The first balance
+// seen for an account is its baseline.
+func (w *Watcher) record(ctx context.Context, kind, address string, lamports, slot uint64) {
+	w.mu.Lock()
+	previous, seen := w.balances[address]
+	w.balances[address] = lamports
+	w.mu.Unlock()
+
+	if !seen || previous == lamports || w.config.OnChange == nil {
+		return
+	}
+	w.config.OnChange(ctx, Change{
+		Address:  address,
+		Kind:     kind,
+		Slot:     slot,
+		Lamports: lamports,
+		Previous: previous,
+		Delta:    int64(lamports) - int64(previous),
+	})
+}
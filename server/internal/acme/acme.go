@@ -0,0 +1,535 @@
+// Package acme obtains and renews TLS certificates from an ACME certificate
+// authority such as Let's Encrypt (RFC 8555), answering http-01 challenges.
+//
+// Use Manager.GetCertificate in a tls.Config and serve Manager.HTTPHandler on
+// port 80 so the authority can reach the challenge responses.
+package acme
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LetsEncrypt is the production directory of Let's Encrypt.
+const LetsEncrypt = "https://acme-v02.api.letsencrypt.org/directory"
+
+// renewBefore is how long before expiry a certificate is renewed.
+const renewBefore = 30 * 24 * time.Hour
+
+// ErrUnknownHost is returned for TLS handshakes naming a host not managed.
+var ErrUnknownHost = errors.New("acme: host not configured")
+
+// Manager holds a certificate for Domains, obtaining it on first use and
+// renewing it before it expires.
+type Manager struct {
+	Directory  string   // ACME directory URL; LetsEncrypt if empty
+	Email      string   // Contact for expiry notices, optional
+	Domains    []string // Names on the certificate
+	CacheDir   string   // Where the account key and certificate are kept
+	HTTPClient *http.Client
+
+	mu     sync.Mutex // Serializes issuance
+	cert   *tls.Certificate
+	tokens sync.Map // Challenge token -> key authorization
+}
+
+// GetCertificate returns the certificate for a TLS handshake, obtaining or
+// renewing it if needed.
+func (m *Manager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if hello.ServerName != "" && !slices.Contains(m.Domains, strings.ToLower(hello.ServerName)) {
+		return nil, ErrUnknownHost
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	return m.certificate(ctx)
+}
+
+// HTTPHandler answers http-01 challenges and redirects other requests to
+// HTTPS, or passes them to fallback if it is not nil.
+func (m *Manager) HTTPHandler(fallback http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token, ok := strings.CutPrefix(r.URL.Path, "/.well-known/acme-challenge/"); ok {
+			if keyAuth, ok := m.tokens.Load(token); ok {
+				w.Header().Set("Content-Type", "text/plain")
+				io.WriteString(w, keyAuth.(string))
+				return
+			}
+			http.NotFound(w, r)
+			return
+		}
+		if fallback != nil {
+			fallback.ServeHTTP(w, r)
+			return
+		}
+		host := strings.Split(r.Host, ":")[0]
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
+
+// Renew keeps the certificate fresh until ctx is done, checking twice a day.
+func (m *Manager) Renew(ctx context.Context) {
+	ticker := time.NewTicker(12 * time.Hour)
+	defer ticker.Stop()
+	for {
+		if _, err := m.certificate(ctx); err != nil {
+			log.Printf("acme: renewal failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// certificate returns a valid certificate from memory, the cache or the CA.
+func (m *Manager) certificate(ctx context.Context) (*tls.Certificate, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if fresh(m.cert) {
+		return m.cert, nil
+	}
+	if cert, err := m.loadCert(); err == nil && fresh(cert) {
+		m.cert = cert
+		return cert, nil
+	}
+	cert, err := m.obtain(ctx)
+	if err != nil {
+		// Keep serving a certificate that has not expired yet
+		if m.cert != nil && time.Now().Before(m.cert.Leaf.NotAfter) {
+			log.Printf("acme: %v; serving the current certificate", err)
+			return m.cert, nil
+		}
+		return nil, err
+	}
+	m.cert = cert
+	return cert, nil
+}
+
+func fresh(cert *tls.Certificate) bool {
+	return cert != nil && cert.Leaf != nil && time.Until(cert.Leaf.NotAfter) > renewBefore
+}
+
+// obtain orders a certificate for m.Domains.
+func (m *Manager) obtain(ctx context.Context) (*tls.Certificate, error) {
+	accountKey, err := m.accountKey()
+	if err != nil {
+		return nil, err
+	}
+	c := &client{http: m.HTTPClient, key: accountKey}
+	if c.http == nil {
+		c.http = &http.Client{Timeout: 30 * time.Second}
+	}
+	dir := m.Directory
+	if dir == "" {
+		dir = LetsEncrypt
+	}
+	if err := c.discover(ctx, dir); err != nil {
+		return nil, err
+	}
+	if err := c.register(ctx, m.Email); err != nil {
+		return nil, err
+	}
+
+	// Order, then prove control of every domain
+	ids := make([]identifier, len(m.Domains))
+	for i, d := range m.Domains {
+		ids[i] = identifier{Type: "dns", Value: d}
+	}
+	var o order
+	orderURL, err := c.post(ctx, c.dir.NewOrder, map[string]interface{}{"identifiers": ids}, &o)
+	if err != nil {
+		return nil, fmt.Errorf("acme: new order: %w", err)
+	}
+	for _, authzURL := range o.Authorizations {
+		if err := m.authorize(ctx, c, authzURL); err != nil {
+			return nil, err
+		}
+	}
+
+	// Finalize with a CSR for a fresh key
+	certKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: m.Domains[0]},
+		DNSNames: m.Domains,
+	}, certKey)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.post(ctx, o.Finalize, map[string]string{"csr": b64(csr)}, &o); err != nil {
+		return nil, fmt.Errorf("acme: finalize: %w", err)
+	}
+	for o.Status != "valid" {
+		if o.Status == "invalid" {
+			return nil, errors.New("acme: order became invalid")
+		}
+		if err := sleep(ctx, time.Second); err != nil {
+			return nil, err
+		}
+		if _, err := c.post(ctx, orderURL, nil, &o); err != nil {
+			return nil, fmt.Errorf("acme: order status: %w", err)
+		}
+	}
+
+	var chain bytes.Buffer
+	if _, err := c.post(ctx, o.Certificate, nil, &chain); err != nil {
+		return nil, fmt.Errorf("acme: download certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(certKey)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	cert, err := tls.X509KeyPair(chain.Bytes(), keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("acme: %w", err)
+	}
+	if err := m.writeCache(m.certFile(), append(chain.Bytes(), keyPEM...)); err != nil {
+		log.Printf("acme: certificate not cached: %v", err)
+	}
+	log.Printf("acme: obtained certificate for %s, valid until %s", strings.Join(m.Domains, ", "), cert.Leaf.NotAfter.Format(time.RFC3339))
+	return &cert, nil
+}
+
+// authorize answers the http-01 challenge of one authorization.
+func (m *Manager) authorize(ctx context.Context, c *client, authzURL string) error {
+	var a authorization
+	if _, err := c.post(ctx, authzURL, nil, &a); err != nil {
+		return fmt.Errorf("acme: authorization: %w", err)
+	}
+	if a.Status == "valid" {
+		return nil
+	}
+	var ch *challenge
+	for i := range a.Challenges {
+		if a.Challenges[i].Type == "http-01" {
+			ch = &a.Challenges[i]
+		}
+	}
+	if ch == nil {
+		return fmt.Errorf("acme: %s offers no http-01 challenge", a.Identifier.Value)
+	}
+
+	m.tokens.Store(ch.Token, ch.Token+"."+c.thumbprint())
+	defer m.tokens.Delete(ch.Token)
+	if _, err := c.post(ctx, ch.URL, struct{}{}, nil); err != nil {
+		return fmt.Errorf("acme: accept challenge: %w", err)
+	}
+	for a.Status != "valid" {
+		if a.Status == "invalid" {
+			detail := "challenge failed"
+			for _, ch := range a.Challenges {
+				if ch.Error != nil {
+					detail = ch.Error.Detail
+				}
+			}
+			return fmt.Errorf("acme: %s: %s", a.Identifier.Value, detail)
+		}
+		if err := sleep(ctx, time.Second); err != nil {
+			return err
+		}
+		if _, err := c.post(ctx, authzURL, nil, &a); err != nil {
+			return fmt.Errorf("acme: authorization status: %w", err)
+		}
+	}
+	return nil
+}
+
+// accountKey loads the account key from the cache, creating it on first use.
+func (m *Manager) accountKey() (*ecdsa.PrivateKey, error) {
+	path := filepath.Join(m.CacheDir, "account.key")
+	if data, err := os.ReadFile(path); err == nil {
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("acme: %s is not PEM", path)
+		}
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.writeCache(path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})); err != nil {
+		return nil, fmt.Errorf("acme: %w", err)
+	}
+	return key, nil
+}
+
+func (m *Manager) certFile() string {
+	return filepath.Join(m.CacheDir, strings.ReplaceAll(m.Domains[0], "*", "_")+".pem")
+}
+
+// loadCert reads the cached certificate and key.
+func (m *Manager) loadCert() (*tls.Certificate, error) {
+	data, err := os.ReadFile(m.certFile())
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.X509KeyPair(data, data)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range m.Domains {
+		if cert.Leaf.VerifyHostname(d) != nil {
+			return nil, errors.New("acme: cached certificate does not cover " + d)
+		}
+	}
+	return &cert, nil
+}
+
+func (m *Manager) writeCache(path string, data []byte) error {
+	if err := os.MkdirAll(m.CacheDir, 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// client speaks the ACME protocol with JWS-signed requests.
+type client struct {
+	http  *http.Client
+	key   *ecdsa.PrivateKey
+	dir   directory
+	kid   string // Account URL, once registered
+	nonce string
+}
+
+type directory struct {
+	NewNonce   string `json:"newNonce"`
+	NewAccount string `json:"newAccount"`
+	NewOrder   string `json:"newOrder"`
+}
+
+type identifier struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type order struct {
+	Status         string   `json:"status"`
+	Authorizations []string `json:"authorizations"`
+	Finalize       string   `json:"finalize"`
+	Certificate    string   `json:"certificate"`
+}
+
+type authorization struct {
+	Status     string      `json:"status"`
+	Identifier identifier  `json:"identifier"`
+	Challenges []challenge `json:"challenges"`
+}
+
+type challenge struct {
+	Type  string   `json:"type"`
+	URL   string   `json:"url"`
+	Token string   `json:"token"`
+	Error *problem `json:"error,omitempty"`
+}
+
+// problem is an ACME error document (RFC 7807).
+type problem struct {
+	Type   string `json:"type"`
+	Detail string `json:"detail"`
+}
+
+func (p *problem) Error() string {
+	return fmt.Sprintf("%s (%s)", p.Detail, strings.TrimPrefix(p.Type, "urn:ietf:params:acme:error:"))
+}
+
+func (c *client) discover(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("acme: directory: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("acme: directory: status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(&c.dir)
+}
+
+// register creates the account, or finds the existing one for the key.
+func (c *client) register(ctx context.Context, email string) error {
+	payload := map[string]interface{}{"termsOfServiceAgreed": true}
+	if email != "" {
+		payload["contact"] = []string{"mailto:" + email}
+	}
+	kid, err := c.post(ctx, c.dir.NewAccount, payload, nil)
+	if err != nil {
+		return fmt.Errorf("acme: register account: %w", err)
+	}
+	c.kid = kid
+	return nil
+}
+
+// post sends a signed request (POST-as-GET when payload is nil), decodes the
+// response into out (a *bytes.Buffer receives it raw) and returns its Location.
+func (c *client) post(ctx context.Context, url string, payload, out interface{}) (string, error) {
+	for attempt := 0; ; attempt++ {
+		body, err := c.sign(ctx, url, payload)
+		if err != nil {
+			return "", err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Content-Type", "application/jose+json")
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return "", err
+		}
+		data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		resp.Body.Close()
+		if err != nil {
+			return "", err
+		}
+		if n := resp.Header.Get("Replay-Nonce"); n != "" {
+			c.nonce = n
+		}
+
+		if resp.StatusCode >= 400 {
+			p := &problem{Detail: fmt.Sprintf("status %d", resp.StatusCode)}
+			json.Unmarshal(data, p)
+			// A stale nonce is retried once with the fresh one just received
+			if strings.HasSuffix(p.Type, ":badNonce") && attempt == 0 {
+				continue
+			}
+			return "", p
+		}
+		switch out := out.(type) {
+		case nil:
+		case *bytes.Buffer:
+			out.Write(data)
+		default:
+			if err := json.Unmarshal(data, out); err != nil {
+				return "", err
+			}
+		}
+		return resp.Header.Get("Location"), nil
+	}
+}
+
+// sign wraps payload in a flattened JWS signed with ES256.
+func (c *client) sign(ctx context.Context, url string, payload interface{}) ([]byte, error) {
+	if c.nonce == "" {
+		if err := c.fetchNonce(ctx); err != nil {
+			return nil, err
+		}
+	}
+	protected := map[string]interface{}{"alg": "ES256", "nonce": c.nonce, "url": url}
+	if c.kid != "" {
+		protected["kid"] = c.kid
+	} else {
+		protected["jwk"] = c.jwk()
+	}
+	c.nonce = ""
+
+	header, err := json.Marshal(protected)
+	if err != nil {
+		return nil, err
+	}
+	var body string
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		body = b64(data)
+	}
+	signingInput := b64(header) + "." + body
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, c.key, digest[:])
+	if err != nil {
+		return nil, err
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return json.Marshal(map[string]string{
+		"protected": b64(header),
+		"payload":   body,
+		"signature": b64(sig),
+	})
+}
+
+func (c *client) fetchNonce(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.dir.NewNonce, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("acme: nonce: %w", err)
+	}
+	resp.Body.Close()
+	c.nonce = resp.Header.Get("Replay-Nonce")
+	if c.nonce == "" {
+		return errors.New("acme: no nonce returned")
+	}
+	return nil
+}
+
+// jwk is the account public key as a JSON Web Key, members in the order the
+// thumbprint requires (RFC 7638).
+func (c *client) jwk() json.RawMessage {
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	c.key.X.FillBytes(x)
+	c.key.Y.FillBytes(y)
+	return json.RawMessage(fmt.Sprintf(`{"crv":"P-256","kty":"EC","x":%q,"y":%q}`, b64(x), b64(y)))
+}
+
+func (c *client) thumbprint() string {
+	sum := crypto.SHA256.New()
+	sum.Write(c.jwk())
+	return b64(sum.Sum(nil))
+}
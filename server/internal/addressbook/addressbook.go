@@ -0,0 +1,523 @@
+// Package addressbook keeps labeled merchant wallets, recipient commitments and
+// stealth meta-addresses, plus recently used recipients, in a local store that
+// is encrypted at rest.
+package addressbook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/jsonfile"
+	"sol_privacy/internal/validate"
+)
+
+// Kind is the kind of value an entry holds.
+type Kind string
+
+// Entry kinds.
+const (
+	KindWallet     Kind = "wallet"     // Merchant or recipient wallet address
+	KindCommitment Kind = "commitment" // Recipient ShadowID commitment
+	KindStealth    Kind = "stealth"    // Stealth meta-address
+)
+
+// Kinds lists every entry kind.
+var Kinds = []Kind{KindWallet, KindCommitment, KindStealth}
+
+// Limits of entry fields.
+const (
+	MaxLabelLength   = 64
+	MaxNotesLength   = 256
+	MaxStealthLength = 256
+	MaxRecent        = 20 // Recently used values kept per book
+)
+
+var (
+	// ErrEntryNotFound is returned for unknown entry IDs or labels.
+	ErrEntryNotFound = errors.New("address book entry not found")
+	// ErrLabelExists is returned when a label is already in use.
+	ErrLabelExists = errors.New("label already in use")
+)
+
+// Entry is a labeled address.
+type Entry struct {
+	ID         string `json:"id"`
+	Label      string `json:"label"`
+	Kind       Kind   `json:"kind"`
+	Value      string `json:"value"`
+	Notes      string `json:"notes,omitempty"`
+	Uses       int    `json:"uses"`
+	CreatedAt  int64  `json:"created_at"`
+	LastUsedAt int64  `json:"last_used_at,omitempty"`
+}
+
+// Recent is a recently used value, labeled or not.
+type Recent struct {
+	Kind  Kind   `json:"kind"`
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"` // Set if the value is in the book
+	At    int64  `json:"at"`
+}
+
+// AddRequest adds an entry.
+type AddRequest struct {
+	Label string `json:"label"`
+	Kind  Kind   `json:"kind"`
+	Value string `json:"value"`
+	Notes string `json:"notes,omitempty"`
+}
+
+// Validate checks the request fields.
+func (r AddRequest) Validate() error {
+	v := validate.New()
+	checkLabel(v, r.Label)
+	checkValue(v, r.Kind, r.Value)
+	if len(r.Notes) > MaxNotesLength {
+		v.Add("notes", fmt.Errorf("must be at most %d characters", MaxNotesLength))
+	}
+	return v.Err()
+}
+
+// UpdateRequest changes an entry. Nil fields are left unchanged; the kind of an
+// entry is fixed.
+type UpdateRequest struct {
+	Label *string `json:"label,omitempty"`
+	Value *string `json:"value,omitempty"`
+	Notes *string `json:"notes,omitempty"`
+}
+
+func checkLabel(v *validate.Validator, label string) {
+	v.Required("label", label)
+	if len(label) > MaxLabelLength {
+		v.Add("label", fmt.Errorf("must be at most %d characters", MaxLabelLength))
+	}
+}
+
+func checkValue(v *validate.Validator, kind Kind, value string) {
+	switch kind {
+	case KindWallet:
+		v.Address("value", value)
+	case KindCommitment:
+		v.Commitment("value", value)
+	case KindStealth:
+		switch {
+		case value == "":
+			v.Add("value", fmt.Errorf("is required"))
+		case len(value) > MaxStealthLength:
+			v.Add("value", fmt.Errorf("must be at most %d characters", MaxStealthLength))
+		case strings.ContainsAny(value, " \t\r\n"):
+			v.Add("value", fmt.Errorf("must not contain whitespace"))
+		}
+	default:
+		v.Add("kind", fmt.Errorf("must be one of %v", Kinds))
+	}
+}
+
+// Store persists entries and recently used values.
+type Store interface {
+	Put(e *Entry) error
+	Get(id string) (*Entry, error) // Returns ErrEntryNotFound for unknown IDs
+	Delete(id string) error
+	List() ([]*Entry, error)
+	PutRecent(recent []Recent) error
+	Recent() ([]Recent, error) // Most recent first
+}
+
+// NewMemoryStore creates an empty in-memory Store.
+func NewMemoryStore() Store {
+	return newMemoryStore()
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{entries: jsonfile.NewMemoryStore(func(e *Entry) string { return e.ID }, nil)}
+}
+
+// memoryStore keeps entries in a jsonfile.Store and recently used values in
+// a slice.
+type memoryStore struct {
+	entries *jsonfile.Store[Entry]
+	mu      sync.RWMutex // Guards recent
+	recent  []Recent
+}
+
+func (m *memoryStore) Put(e *Entry) error {
+	return m.entries.Put(e)
+}
+
+func (m *memoryStore) Get(id string) (*Entry, error) {
+	e, ok := m.entries.Get(id)
+	if !ok {
+		return nil, ErrEntryNotFound
+	}
+	return e, nil
+}
+
+func (m *memoryStore) Delete(id string) error {
+	e, _ := m.entries.Delete(id)
+	if e == nil {
+		return ErrEntryNotFound
+	}
+	return nil
+}
+
+// List returns all entries ordered by label.
+func (m *memoryStore) List() ([]*Entry, error) {
+	return m.entries.List(func(a, b *Entry) bool { return a.Label < b.Label }), nil
+}
+
+// PutRecent replaces the recently used values.
+func (m *memoryStore) PutRecent(recent []Recent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.recent = append([]Recent(nil), recent...)
+	return nil
+}
+
+// Recent returns a copy of the recently used values.
+func (m *memoryStore) Recent() ([]Recent, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]Recent(nil), m.recent...), nil
+}
+
+// DefaultPath returns $SHADOWPAY_ADDRESSBOOK_FILE, or ~/.shadowpay/addressbook.enc.
+func DefaultPath() string {
+	if path := os.Getenv("SHADOWPAY_ADDRESSBOOK_FILE"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".shadowpay", "addressbook.enc")
+}
+
+// EncryptedFileStore is an in-memory Store persisted to a file encrypted with a
+// passphrase (AES-256-GCM, PBKDF2-SHA256 key) after every write.
+type EncryptedFileStore struct {
+	*memoryStore
+	path   string
+	sealer sealer
+	mu     sync.Mutex // Serializes file writes
+}
+
+// snapshot is the plaintext content of an encrypted file.
+type snapshot struct {
+	Entries []Entry  `json:"entries"`
+	Recent  []Recent `json:"recent,omitempty"`
+}
+
+// NewEncryptedFileStore opens the store at path, creating it on first write.
+// It returns ErrWrongPassphrase if the file was sealed with another passphrase.
+func NewEncryptedFileStore(path, passphrase string) (*EncryptedFileStore, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("address book: passphrase is required")
+	}
+	fs := &EncryptedFileStore{
+		memoryStore: newMemoryStore(),
+		path:        path,
+		sealer:      sealer{passphrase: passphrase},
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var snap snapshot
+	if err := fs.sealer.open(data, &snap); err != nil {
+		return nil, err
+	}
+	for _, e := range snap.Entries {
+		fs.entries.Put(&e)
+	}
+	fs.recent = snap.Recent
+	return fs, nil
+}
+
+// Put saves the entry and rewrites the file.
+func (f *EncryptedFileStore) Put(e *Entry) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.memoryStore.Put(e)
+	return f.save()
+}
+
+// Delete removes the entry and rewrites the file.
+func (f *EncryptedFileStore) Delete(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.memoryStore.Delete(id); err != nil {
+		return err
+	}
+	return f.save()
+}
+
+// PutRecent replaces the recently used values and rewrites the file.
+func (f *EncryptedFileStore) PutRecent(recent []Recent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.memoryStore.PutRecent(recent)
+	return f.save()
+}
+
+func (f *EncryptedFileStore) save() error {
+	entries, _ := f.memoryStore.List()
+	recent, _ := f.memoryStore.Recent()
+	snap := snapshot{Entries: make([]Entry, len(entries)), Recent: recent}
+	for i, e := range entries {
+		snap.Entries[i] = *e
+	}
+	data, err := f.sealer.seal(snap)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt address book: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(f.path), err)
+	}
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", f.path, err)
+	}
+	return os.Rename(tmp, f.path)
+}
+
+// Book manages address book entries.
+type Book struct {
+	store Store
+	mu    sync.Mutex // Serializes label checks and recent updates with writes
+}
+
+// NewBook creates an address book. A nil store selects an in-memory store.
+func NewBook(store Store) *Book {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	return &Book{store: store}
+}
+
+// Add stores a new entry. Labels are unique, ignoring case.
+func (b *Book) Add(ctx context.Context, req AddRequest) (*Entry, error) {
+	req.Label = strings.TrimSpace(req.Label)
+	req.Value = strings.TrimSpace(req.Value)
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.checkLabelFree(req.Label, ""); err != nil {
+		return nil, err
+	}
+	e := &Entry{
+		ID:        newID(),
+		Label:     req.Label,
+		Kind:      req.Kind,
+		Value:     req.Value,
+		Notes:     req.Notes,
+		CreatedAt: time.Now().Unix(),
+	}
+	if err := b.store.Put(e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Update changes an entry's label, value or notes.
+func (b *Book) Update(ctx context.Context, id string, req UpdateRequest) (*Entry, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e, err := b.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Label != nil {
+		e.Label = strings.TrimSpace(*req.Label)
+	}
+	if req.Value != nil {
+		e.Value = strings.TrimSpace(*req.Value)
+	}
+	if req.Notes != nil {
+		e.Notes = *req.Notes
+	}
+	if err := (AddRequest{Label: e.Label, Kind: e.Kind, Value: e.Value, Notes: e.Notes}).Validate(); err != nil {
+		return nil, err
+	}
+	if err := b.checkLabelFree(e.Label, e.ID); err != nil {
+		return nil, err
+	}
+	if err := b.store.Put(e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Remove deletes an entry.
+func (b *Book) Remove(ctx context.Context, id string) error {
+	return b.store.Delete(id)
+}
+
+// Get returns an entry by ID.
+func (b *Book) Get(ctx context.Context, id string) (*Entry, error) {
+	return b.store.Get(id)
+}
+
+// Lookup returns the entry of the given kind with the label, ignoring case.
+// An empty kind matches any kind.
+func (b *Book) Lookup(ctx context.Context, kind Kind, label string) (*Entry, error) {
+	all, err := b.store.List()
+	if err != nil {
+		return nil, err
+	}
+	label = strings.TrimSpace(label)
+	for _, e := range all {
+		if (kind == "" || e.Kind == kind) && strings.EqualFold(e.Label, label) {
+			return e, nil
+		}
+	}
+	return nil, ErrEntryNotFound
+}
+
+// Search returns up to limit entries of the given kind (any if empty) whose
+// label, value or notes contain query, ignoring case. Label prefix matches rank
+// first, then label matches, then the most recently used. An empty query lists
+// every entry; limit <= 0 means no limit.
+func (b *Book) Search(ctx context.Context, query string, kind Kind, limit int) ([]*Entry, error) {
+	all, err := b.store.List()
+	if err != nil {
+		return nil, err
+	}
+	q := strings.ToLower(strings.TrimSpace(query))
+	type match struct {
+		e     *Entry
+		score int
+	}
+	var matches []match
+	for _, e := range all {
+		if kind != "" && e.Kind != kind {
+			continue
+		}
+		label := strings.ToLower(e.Label)
+		score := 0
+		switch {
+		case q == "":
+		case strings.HasPrefix(label, q):
+			score = 3
+		case strings.Contains(label, q):
+			score = 2
+		case strings.Contains(strings.ToLower(e.Value), q), strings.Contains(strings.ToLower(e.Notes), q):
+			score = 1
+		default:
+			continue
+		}
+		matches = append(matches, match{e, score})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].e.LastUsedAt > matches[j].e.LastUsedAt
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	out := make([]*Entry, len(matches))
+	for i, m := range matches {
+		out[i] = m.e
+	}
+	return out, nil
+}
+
+// Touch records that value was just used as a recipient: it bumps the usage of
+// a matching entry and moves the value to the front of the recent list.
+// Values that are not labeled are remembered too.
+func (b *Book) Touch(ctx context.Context, kind Kind, value string) error {
+	value = strings.TrimSpace(value)
+	v := validate.New()
+	checkValue(v, kind, value)
+	if err := v.Err(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now().Unix()
+	r := Recent{Kind: kind, Value: value, At: now}
+	all, err := b.store.List()
+	if err != nil {
+		return err
+	}
+	for _, e := range all {
+		if e.Kind == kind && e.Value == value {
+			e.Uses++
+			e.LastUsedAt = now
+			if err := b.store.Put(e); err != nil {
+				return err
+			}
+			r.Label = e.Label
+			break
+		}
+	}
+
+	recent, err := b.store.Recent()
+	if err != nil {
+		return err
+	}
+	out := []Recent{r}
+	for _, old := range recent {
+		if old.Kind != kind || old.Value != value {
+			out = append(out, old)
+		}
+	}
+	if len(out) > MaxRecent {
+		out = out[:MaxRecent]
+	}
+	return b.store.PutRecent(out)
+}
+
+// Recent returns recently used values of the given kind (any if empty), most
+// recent first.
+func (b *Book) Recent(ctx context.Context, kind Kind) ([]Recent, error) {
+	recent, err := b.store.Recent()
+	if err != nil || kind == "" {
+		return recent, err
+	}
+	out := recent[:0]
+	for _, r := range recent {
+		if r.Kind == kind {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+// checkLabelFree reports ErrLabelExists if another entry than id uses label.
+func (b *Book) checkLabelFree(label, id string) error {
+	all, err := b.store.List()
+	if err != nil {
+		return err
+	}
+	for _, e := range all {
+		if e.ID != id && strings.EqualFold(e.Label, label) {
+			return ErrLabelExists
+		}
+	}
+	return nil
+}
+
+func newID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "ab_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,105 @@
+package addressbook
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Key derivation parameters of sealed files.
+const (
+	kdfName       = "pbkdf2-sha256"
+	kdfIterations = 600_000
+	saltSize      = 16
+)
+
+// ErrWrongPassphrase is returned when a sealed file cannot be decrypted.
+var ErrWrongPassphrase = errors.New("address book: wrong passphrase or corrupted file")
+
+// envelope is the on-disk format of an encrypted file.
+type envelope struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// sealer encrypts with AES-256-GCM under a key derived from a passphrase. The
+// key is derived once per salt, so repeated saves stay cheap.
+type sealer struct {
+	passphrase string
+	salt       []byte
+	iterations int
+	aead       cipher.AEAD
+}
+
+func (s *sealer) init(salt []byte, iterations int) error {
+	key, err := pbkdf2.Key(sha256.New, s.passphrase, salt, iterations, 32)
+	if err != nil {
+		return err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	s.salt, s.iterations, s.aead = salt, iterations, aead
+	return nil
+}
+
+// seal encrypts v as JSON, generating a salt on first use.
+func (s *sealer) seal(v interface{}) ([]byte, error) {
+	if s.aead == nil {
+		salt := make([]byte, saltSize)
+		rand.Read(salt)
+		if err := s.init(salt, kdfIterations); err != nil {
+			return nil, err
+		}
+	}
+	plaintext, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	rand.Read(nonce)
+	return json.MarshalIndent(envelope{
+		Version:    1,
+		KDF:        kdfName,
+		Iterations: s.iterations,
+		Salt:       s.salt,
+		Nonce:      nonce,
+		Ciphertext: s.aead.Seal(nil, nonce, plaintext, nil),
+	}, "", "  ")
+}
+
+// open decrypts data into v and keeps the derived key for later seals.
+func (s *sealer) open(data []byte, v interface{}) error {
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return fmt.Errorf("address book: %w", err)
+	}
+	if env.Version != 1 || env.KDF != kdfName || env.Iterations <= 0 {
+		return fmt.Errorf("address book: unsupported file format")
+	}
+	if err := s.init(env.Salt, env.Iterations); err != nil {
+		return err
+	}
+	if len(env.Nonce) != s.aead.NonceSize() {
+		return ErrWrongPassphrase
+	}
+	plaintext, err := s.aead.Open(nil, env.Nonce, env.Ciphertext, nil)
+	if err != nil {
+		return ErrWrongPassphrase
+	}
+	return json.Unmarshal(plaintext, v)
+}
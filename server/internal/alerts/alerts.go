@@ -0,0 +1,434 @@
+// Package alerts posts operational alerts to Slack and Discord incoming
+// webhooks: settlements that failed for good, webhook deliveries failing
+// repeatedly, and spending authorizations reaching their daily limit.
+//
+// Alerts travel as events on the bus, so each channel is a durable
+// subscriber and a Slack or Discord outage delays alerts rather than losing
+// them. The Alerter's Run loop raises the alerts no other subsystem
+// publishes, by watching the bus's delivery stats and the authorizations of
+// configured wallets.
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"sol_privacy/internal/authorization"
+	"sol_privacy/internal/events"
+)
+
+// Kinds of alert a channel may post.
+const (
+	SettlementFailed   = "settlement_failed"   // A queued settlement ran out of attempts
+	WebhookFailures    = "webhook_failures"    // A webhook subscriber keeps failing
+	AuthorizationLimit = "authorization_limit" // An authorization spent its share of the daily limit
+)
+
+// Kinds lists every kind of alert.
+var Kinds = []string{SettlementFailed, WebhookFailures, AuthorizationLimit}
+
+// eventTypes maps each kind to the bus event that raises it.
+var eventTypes = map[string]string{
+	SettlementFailed:   events.SettlementFailed,
+	WebhookFailures:    events.DeliveryFailing,
+	AuthorizationLimit: events.AuthorizationSpendAlert,
+}
+
+// Defaults.
+const (
+	DefaultInterval                = time.Minute
+	DefaultWebhookFailureThreshold = 5
+)
+
+// Channel is an incoming webhook alerts are posted to.
+type Channel struct {
+	Name   string // Identifies the channel's deliveries; defaults to Format
+	Format Format
+	URL    string   // Required, the incoming webhook URL
+	Kinds  []string // Kinds of alert posted; all if empty
+
+	// Templates override DefaultTemplates by kind.
+	Templates map[string]Template
+}
+
+// Config holds alerting configuration.
+type Config struct {
+	Channels []Channel
+	Bus      *events.Bus // Required; alerts are published and delivered through it
+
+	// A webhook subscriber (one named "webhook.*") with this many failed
+	// delivery attempts in one Interval raises WebhookFailures. It alerts
+	// again only after an interval without failures. Defaults to
+	// DefaultWebhookFailureThreshold.
+	WebhookFailureThreshold int
+
+	// Authorizations of Wallets are checked every Interval and raise
+	// AuthorizationLimit once a day when their spend reaches
+	// AuthorizationUsage (0-1] of the daily limit, 1 by default.
+	Authorizations     *authorization.Service
+	Wallets            []string
+	AuthorizationUsage float64
+
+	Interval   time.Duration // Defaults to DefaultInterval
+	HTTPClient *http.Client  // Defaults to a client with a 10s timeout
+}
+
+// Template renders an alert with text/template. The data is TemplateData.
+type Template struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+// TemplateData is what templates render.
+type TemplateData struct {
+	Kind string         // Kind of alert
+	ID   string         // Event ID
+	Time time.Time      // Event time, UTC
+	Data map[string]any // The event payload, as in webhooks
+}
+
+// DeliveryFailure is the payload of events.DeliveryFailing.
+type DeliveryFailure struct {
+	Subscriber string `json:"subscriber"`
+	Failures   int64  `json:"failures"` // Failed attempts within Window
+	Window     string `json:"window"`
+	Pending    int    `json:"pending"`
+	LastError  string `json:"last_error,omitempty"`
+}
+
+// DefaultTemplates are used for kinds a channel has no template for.
+var DefaultTemplates = map[string]Template{
+	SettlementFailed: {
+		Title: "Settlement failed: {{.Data.request.paymentRequirements.maxAmountRequired}} SOL",
+		Text: "Settlement {{.Data.id}} to {{short .Data.request.paymentRequirements.payTo}} failed after {{.Data.attempts}} attempts." +
+			"{{with .Data.last_error}}\nError: {{.}}{{end}}",
+	},
+	WebhookFailures: {
+		Title: "Webhook deliveries failing: {{.Data.subscriber}}",
+		Text: "{{.Data.failures}} delivery attempts failed in the last {{.Data.window}}; {{.Data.pending}} events are waiting." +
+			"{{with .Data.last_error}}\nLast error: {{.}}{{end}}",
+	},
+	AuthorizationLimit: {
+		Title: "Authorization limit reached: {{.Data.authorized_service}}",
+		Text: "{{.Data.authorized_service}} has spent {{sol .Data.spent_today}} of its {{sol .Data.max_daily_spend}} SOL daily limit " +
+			"for {{short .Data.user_wallet}} ({{percent .Data.usage_ratio}}) on {{.Data.date}}.",
+	},
+}
+
+var templateFuncs = template.FuncMap{
+	// sol formats a lamport amount from a payload as SOL
+	"sol": func(v any) string {
+		if n, ok := v.(float64); ok {
+			return strconv.FormatFloat(n/1e9, 'f', -1, 64)
+		}
+		return fmt.Sprint(v)
+	},
+	// percent formats a ratio such as 0.85 as "85%"
+	"percent": func(v any) string {
+		if n, ok := v.(float64); ok {
+			return strconv.FormatFloat(n*100, 'f', 0, 64) + "%"
+		}
+		return fmt.Sprint(v)
+	},
+	// short abbreviates an address
+	"short": func(v any) string {
+		s, _ := v.(string)
+		if len(s) <= 12 {
+			return s
+		}
+		return s[:4] + "..." + s[len(s)-4:]
+	},
+}
+
+type compiled struct {
+	title, text *template.Template
+}
+
+type channel struct {
+	Channel
+	templates map[string]*compiled
+}
+
+// Alerter posts alerts to its channels and raises the ones derived from
+// delivery stats and authorization spend.
+type Alerter struct {
+	config   Config
+	channels map[string]*channel
+	names    []string
+
+	mu           sync.Mutex
+	lastFailures map[string]int64 // By subscriber, at the previous check
+	failing      map[string]bool  // Subscribers alerted and not yet recovered
+}
+
+// New creates an alerter, compiling its channels' templates. If
+// Authorizations is set, its spend alert handler is replaced.
+func New(config Config) (*Alerter, error) {
+	if config.Bus == nil {
+		return nil, fmt.Errorf("alerts need an event bus")
+	}
+	if len(config.Channels) == 0 {
+		return nil, fmt.Errorf("no alert channels configured")
+	}
+	if config.WebhookFailureThreshold <= 0 {
+		config.WebhookFailureThreshold = DefaultWebhookFailureThreshold
+	}
+	if config.AuthorizationUsage <= 0 {
+		config.AuthorizationUsage = 1
+	}
+	if config.Interval <= 0 {
+		config.Interval = DefaultInterval
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	a := &Alerter{
+		config:       config,
+		channels:     make(map[string]*channel),
+		lastFailures: make(map[string]int64),
+		failing:      make(map[string]bool),
+	}
+	for _, c := range config.Channels {
+		if c.Name == "" {
+			c.Name = string(c.Format)
+		}
+		if _, dup := a.channels[c.Name]; dup {
+			return nil, fmt.Errorf("duplicate alert channel %q", c.Name)
+		}
+		if c.Format != FormatSlack && c.Format != FormatDiscord {
+			return nil, fmt.Errorf("alert channel %s: unknown format %q", c.Name, c.Format)
+		}
+		if c.URL == "" {
+			return nil, fmt.Errorf("alert channel %s: webhook URL is required", c.Name)
+		}
+		if len(c.Kinds) == 0 {
+			c.Kinds = Kinds
+		}
+		for _, k := range c.Kinds {
+			if _, ok := eventTypes[k]; !ok {
+				return nil, fmt.Errorf("alert channel %s: unknown alert %q", c.Name, k)
+			}
+		}
+		ch := &channel{Channel: c, templates: make(map[string]*compiled)}
+		for _, k := range c.Kinds {
+			t, ok := c.Templates[k]
+			if !ok {
+				t = DefaultTemplates[k]
+			}
+			tc, err := t.compile(k)
+			if err != nil {
+				return nil, fmt.Errorf("alert channel %s: %w", c.Name, err)
+			}
+			ch.templates[k] = tc
+		}
+		a.channels[c.Name] = ch
+		a.names = append(a.names, c.Name)
+	}
+
+	if config.Authorizations != nil && len(config.Wallets) > 0 {
+		err := config.Authorizations.SetSpendAlert(config.AuthorizationUsage, func(ctx context.Context, alert authorization.SpendAlert) {
+			config.Bus.Emit(ctx, events.AuthorizationSpendAlert, "alerts", alert)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+func (t Template) compile(kind string) (*compiled, error) {
+	var c compiled
+	var err error
+	parse := func(part, text string) *template.Template {
+		if err != nil {
+			return nil
+		}
+		var tmpl *template.Template
+		tmpl, err = template.New(kind + "." + part).Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			err = fmt.Errorf("template %s: %w", kind, err)
+		}
+		return tmpl
+	}
+	c.title = parse("title", t.Title)
+	c.text = parse("text", t.Text)
+	return &c, err
+}
+
+// Channels returns the names of the configured channels.
+func (a *Alerter) Channels() []string {
+	return a.names
+}
+
+// EventTypes returns the bus event types a channel posts alerts for.
+func (a *Alerter) EventTypes(name string) []string {
+	c := a.channels[name]
+	if c == nil {
+		return nil
+	}
+	out := make([]string, len(c.Kinds))
+	for i, k := range c.Kinds {
+		out[i] = eventTypes[k]
+	}
+	return out
+}
+
+// Handler returns the durable subscriber that posts a channel's alerts.
+// Failed posts are retried by the bus, except those the webhook rejects
+// outright, which would fail again.
+func (a *Alerter) Handler(name string) events.Handler {
+	return func(ctx context.Context, e events.Event) error {
+		c := a.channels[name]
+		if c == nil {
+			return nil
+		}
+		kind := kindOf(e.Type)
+		msg, err := c.render(kind, e)
+		if err != nil {
+			// A broken template won't fix itself on retry
+			log.Printf("alerts: %s: %v", name, err)
+			return nil
+		}
+		err = a.post(ctx, c, msg)
+		var rejected *rejectedError
+		if errors.As(err, &rejected) {
+			log.Printf("alerts: %s: %v", name, err)
+			return nil
+		}
+		return err
+	}
+}
+
+func kindOf(eventType string) string {
+	for k, t := range eventTypes {
+		if t == eventType {
+			return k
+		}
+	}
+	return ""
+}
+
+// Message is a rendered alert.
+type Message struct {
+	Kind  string
+	Title string
+	Text  string
+	Time  time.Time
+}
+
+// Render renders an event with a channel's template for its kind of alert.
+func (a *Alerter) Render(name string, e events.Event) (Message, error) {
+	c := a.channels[name]
+	if c == nil {
+		return Message{}, fmt.Errorf("unknown alert channel %q", name)
+	}
+	return c.render(kindOf(e.Type), e)
+}
+
+func (c *channel) render(kind string, e events.Event) (Message, error) {
+	tc, ok := c.templates[kind]
+	if !ok {
+		return Message{}, fmt.Errorf("channel does not post %s events", e.Type)
+	}
+	data := &TemplateData{Kind: kind, ID: e.ID, Time: time.Unix(e.Timestamp, 0).UTC()}
+	if len(e.Data) > 0 {
+		if err := json.Unmarshal(e.Data, &data.Data); err != nil {
+			return Message{}, err
+		}
+	}
+	title, err := execute(tc.title, data)
+	if err != nil {
+		return Message{}, err
+	}
+	text, err := execute(tc.text, data)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{Kind: kind, Title: strings.Join(strings.Fields(title), " "), Text: strings.TrimSpace(text), Time: data.Time}, nil
+}
+
+func execute(t *template.Template, data *TemplateData) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(buf.String(), "<no value>", ""), nil
+}
+
+// Run raises webhook failure and authorization limit alerts every Interval
+// until ctx is done.
+func (a *Alerter) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.CheckDeliveries(ctx)
+			a.CheckAuthorizations(ctx)
+		}
+	}
+}
+
+// CheckDeliveries raises WebhookFailures for webhook subscribers whose
+// failed attempts since the previous check reach the threshold.
+func (a *Alerter) CheckDeliveries(ctx context.Context) {
+	stats, err := a.config.Bus.Stats()
+	if err != nil {
+		log.Printf("alerts: %v", err)
+		return
+	}
+
+	var raised []DeliveryFailure
+	a.mu.Lock()
+	for _, s := range stats {
+		if !strings.HasPrefix(s.Name, "webhook.") {
+			continue
+		}
+		failures := s.Failures - a.lastFailures[s.Name]
+		a.lastFailures[s.Name] = s.Failures
+		switch {
+		case failures == 0:
+			a.failing[s.Name] = false
+		case failures >= int64(a.config.WebhookFailureThreshold) && !a.failing[s.Name]:
+			a.failing[s.Name] = true
+			raised = append(raised, DeliveryFailure{
+				Subscriber: s.Name,
+				Failures:   failures,
+				Window:     a.config.Interval.String(),
+				Pending:    s.Pending,
+				LastError:  s.LastError,
+			})
+		}
+	}
+	a.mu.Unlock()
+
+	for _, f := range raised {
+		a.config.Bus.Emit(ctx, events.DeliveryFailing, "alerts", f)
+	}
+}
+
+// CheckAuthorizations checks the configured wallets' authorizations against
+// their daily limits.
+func (a *Alerter) CheckAuthorizations(ctx context.Context) {
+	if a.config.Authorizations == nil {
+		return
+	}
+	for _, wallet := range a.config.Wallets {
+		if _, err := a.config.Authorizations.CheckSpendAlerts(ctx, wallet); err != nil {
+			log.Printf("alerts: checking authorizations of %s: %v", wallet, err)
+		}
+	}
+}
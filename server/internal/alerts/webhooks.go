@@ -0,0 +1,107 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Format is the payload an incoming webhook accepts.
+type Format string
+
+// Formats of incoming webhook.
+const (
+	FormatSlack   Format = "slack"
+	FormatDiscord Format = "discord"
+)
+
+// Colors of each kind of alert, as RGB.
+var colors = map[string]int{
+	SettlementFailed:   0xd6453d,
+	WebhookFailures:    0xe8912d,
+	AuthorizationLimit: 0xe8c42d,
+}
+
+// Discord embed limits.
+const (
+	maxDiscordTitle = 256
+	maxDiscordText  = 4096
+)
+
+// Payload returns the JSON body posting msg to a webhook of format f.
+func (f Format) Payload(msg Message) ([]byte, error) {
+	color := colors[msg.Kind]
+	switch f {
+	case FormatSlack:
+		// The top-level text shows in notifications; the attachment in the channel
+		return json.Marshal(map[string]any{
+			"text": msg.Title,
+			"attachments": []map[string]any{{
+				"color":  fmt.Sprintf("#%06x", color),
+				"title":  msg.Title,
+				"text":   msg.Text,
+				"footer": "ShadowPay",
+				"ts":     msg.Time.Unix(),
+			}},
+		})
+	case FormatDiscord:
+		return json.Marshal(map[string]any{
+			"username": "ShadowPay",
+			"embeds": []map[string]any{{
+				"title":       truncate(msg.Title, maxDiscordTitle),
+				"description": truncate(msg.Text, maxDiscordText),
+				"color":       color,
+				"timestamp":   msg.Time.Format(time.RFC3339),
+			}},
+		})
+	}
+	return nil, fmt.Errorf("unknown alert format %q", f)
+}
+
+// rejectedError is a webhook refusing a payload, which retrying won't change.
+type rejectedError struct {
+	status int
+	body   string
+}
+
+func (e *rejectedError) Error() string {
+	return fmt.Sprintf("webhook rejected alert: status %d: %s", e.status, e.body)
+}
+
+func (a *Alerter) post(ctx context.Context, c *channel, msg Message) error {
+	body, err := c.Format.Payload(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := a.config.HTTPClient.Do(req)
+	if err != nil {
+		// The URL is the webhook's secret; keep it out of logs
+		return fmt.Errorf("posting alert to %s failed", c.Name)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return &rejectedError{status: resp.StatusCode, body: string(bytes.TrimSpace(msg))}
+		}
+		return fmt.Errorf("posting alert to %s: status %d", c.Name, resp.StatusCode)
+	}
+	return nil
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
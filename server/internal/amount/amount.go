@@ -0,0 +1,161 @@
+// Package amount represents token amounts in base units, so lamports, SOL
+// and SPL token quantities are converted explicitly and exactly instead of
+// through float multiplications by 1e9.
+package amount
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// SOL denominations.
+const (
+	SOLDecimals    = 9
+	LamportsPerSOL = 1_000_000_000
+)
+
+// MaxDecimals is the most decimal places a token may have; 10^18 is the
+// largest power of ten an int64 holds.
+const MaxDecimals = 18
+
+// Amount is a quantity in a token's base units: lamports for SOL, or the
+// smallest unit of an SPL token. It marshals to JSON as an integer, as the
+// API's amount fields always have, and also accepts integers sent as strings.
+type Amount int64
+
+// Lamports returns an amount of n lamports, or n base units of a token.
+func Lamports(n int64) Amount {
+	return Amount(n)
+}
+
+// SOL converts an amount in SOL to lamports, rounding to the nearest
+// lamport. It is meant for constants such as prices; parse user input with
+// ParseSOL.
+func SOL(sol float64) Amount {
+	return Amount(math.Round(sol * LamportsPerSOL))
+}
+
+// ParseSOL parses a decimal SOL amount such as "0.001".
+func ParseSOL(s string) (Amount, error) {
+	return Parse(s, SOLDecimals)
+}
+
+// Parse parses a non-negative decimal amount of a token with the given
+// decimals, such as "1.5" USDC with 6 decimals, into base units. It rejects
+// digits below the token's smallest unit rather than rounding them.
+func Parse(s string, decimals int) (Amount, error) {
+	if decimals < 0 || decimals > MaxDecimals {
+		return 0, fmt.Errorf("invalid token decimals %d", decimals)
+	}
+	s = strings.TrimSpace(s)
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" || !digits(whole) || !digits(frac) {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if len(frac) > decimals {
+		if strings.TrimRight(frac[decimals:], "0") != "" {
+			return 0, fmt.Errorf("amount %q has more than %d decimals", s, decimals)
+		}
+		frac = frac[:decimals] // Trailing zeros, e.g. "1.50" of a 1-decimal token
+	}
+	unit := pow10(decimals)
+	var n int64
+	if whole != "" {
+		w, err := strconv.ParseInt(whole, 10, 64)
+		if err != nil || w > math.MaxInt64/unit {
+			return 0, fmt.Errorf("amount %q out of range", s)
+		}
+		n = w * unit
+	}
+	if frac != "" {
+		f, _ := strconv.ParseInt(frac+strings.Repeat("0", decimals-len(frac)), 10, 64)
+		if n > math.MaxInt64-f {
+			return 0, fmt.Errorf("amount %q out of range", s)
+		}
+		n += f
+	}
+	return Amount(n), nil
+}
+
+// Int64 returns the amount in base units.
+func (a Amount) Int64() int64 {
+	return int64(a)
+}
+
+// SOL formats the amount, in lamports, as SOL, e.g. "0.001".
+func (a Amount) SOL() string {
+	return a.Format(SOLDecimals)
+}
+
+// Format formats the amount as a decimal of a token with the given decimals,
+// exactly and without trailing zeros.
+func (a Amount) Format(decimals int) string {
+	decimals = min(max(decimals, 0), MaxDecimals)
+	sign := ""
+	u := uint64(a)
+	if a < 0 {
+		sign, u = "-", -u
+	}
+	unit := uint64(pow10(decimals))
+	whole, frac := u/unit, u%unit
+	if frac == 0 {
+		return sign + strconv.FormatUint(whole, 10)
+	}
+	f := strings.TrimRight(fmt.Sprintf("%0*d", decimals, frac), "0")
+	return sign + strconv.FormatUint(whole, 10) + "." + f
+}
+
+// Float returns the amount as a float of a token with the given decimals, for
+// display and charts only.
+func (a Amount) Float(decimals int) float64 {
+	return float64(a) / float64(pow10(min(max(decimals, 0), MaxDecimals)))
+}
+
+// MulBps returns the given basis points of the amount, rounded down, without
+// overflowing for any amount.
+func (a Amount) MulBps(bps int) Amount {
+	return a/10000*Amount(bps) + a%10000*Amount(bps)/10000
+}
+
+// UnmarshalJSON accepts an integer or an integer string.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	var n int64
+	var err error
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err = json.Unmarshal(data, &s); err == nil {
+			n, err = strconv.ParseInt(s, 10, 64)
+		}
+	} else {
+		err = json.Unmarshal(data, &n)
+	}
+	if err != nil {
+		return fmt.Errorf("amount must be an integer number of base units, got %s", data)
+	}
+	*a = Amount(n)
+	return nil
+}
+
+func digits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func pow10(n int) int64 {
+	p := int64(1)
+	for range n {
+		p *= 10
+	}
+	return p
+}
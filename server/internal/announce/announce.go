@@ -0,0 +1,286 @@
+// Package announce indexes the ephemeral key announcements of stealth
+// payments, so recipients can scan for their payments without rescanning
+// the chain.
+//
+// Senders announce each payment from the Umbra program as an Anchor event:
+// a "Program data:" log holding the discriminator AnnouncementDiscriminator,
+// the 32-byte X25519 ephemeral public key and the 32-byte stealth address
+// paid. An Indexer follows the program's transactions into a Store, the
+// proxy serves the store in pages, and Client.Scan recovers each announced
+// address with the recipient's scan key locally; the scan key never leaves
+// the recipient.
+package announce
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"sol_privacy/internal/base58"
+	"sol_privacy/internal/jsonfile"
+)
+
+// Page sizes.
+const (
+	DefaultPage = 500
+	MaxPage     = 5000
+)
+
+// AnnouncementDiscriminator prefixes announcement events: the first 8 bytes
+// of SHA-256("event:Announcement"), as Anchor derives them.
+var AnnouncementDiscriminator = func() [8]byte {
+	h := sha256.Sum256([]byte("event:Announcement"))
+	var d [8]byte
+	copy(d[:], h[:8])
+	return d
+}()
+
+// ErrInvalidCursor is returned for cursors not returned by List.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Announcement is an announced stealth payment.
+type Announcement struct {
+	Sequence           uint64 `json:"sequence"`             // Position in the index, from 1
+	EphemeralPublicKey string `json:"ephemeral_public_key"` // Base58 X25519 public key
+	Address            string `json:"address"`              // Stealth address paid
+	Signature          string `json:"signature"`            // Announcing transaction
+	Slot               uint64 `json:"slot"`
+	BlockTime          int64  `json:"block_time,omitempty"` // Unix timestamp, if known
+}
+
+// Checkpoint is the newest transaction the index covers.
+type Checkpoint struct {
+	Signature string `json:"signature,omitempty"`
+	Slot      uint64 `json:"slot"`
+}
+
+// Query selects a page of announcements.
+type Query struct {
+	FromSlot uint64 // Earlier announcements are skipped
+	Cursor   string // NextCursor of the previous page
+	Limit    int    // Defaults to DefaultPage
+}
+
+// Page is a page of announcements in chain order.
+type Page struct {
+	Announcements []Announcement `json:"announcements"`
+	NextCursor    string         `json:"next_cursor,omitempty"` // Empty on the last page
+	IndexedSlot   uint64         `json:"indexed_slot"`          // Slot the index has caught up to
+}
+
+// Store persists the index.
+type Store interface {
+	// Append adds announcements, in chain order, and advances the checkpoint.
+	// Announcements already indexed are skipped.
+	Append(anns []Announcement, cp Checkpoint) error
+	List(q Query) (*Page, error)
+	Checkpoint() (Checkpoint, error)
+}
+
+// ParseLogs extracts the announcements from a transaction's log messages.
+// Signature, Slot and BlockTime are left for the caller.
+func ParseLogs(logs []string) []Announcement {
+	var anns []Announcement
+	for _, line := range logs {
+		data, ok := strings.CutPrefix(line, "Program data: ")
+		if !ok {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(data)
+		if err != nil || len(raw) < 8+64 || [8]byte(raw[:8]) != AnnouncementDiscriminator {
+			continue
+		}
+		anns = append(anns, Announcement{
+			EphemeralPublicKey: base58.Encode(raw[8:40]),
+			Address:            base58.Encode(raw[40:72]),
+		})
+	}
+	return anns
+}
+
+// MemoryStore is an in-process Store.
+type MemoryStore struct {
+	mu         sync.RWMutex
+	anns       []Announcement // Sequence i is at i-1
+	seen       map[string]bool
+	checkpoint Checkpoint
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{seen: make(map[string]bool)}
+}
+
+func key(a Announcement) string {
+	return a.Signature + "/" + a.EphemeralPublicKey
+}
+
+// Append adds announcements and advances the checkpoint.
+func (m *MemoryStore) Append(anns []Announcement, cp Checkpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.append(anns)
+	m.checkpoint = cp
+	return nil
+}
+
+// append numbers and adds the announcements not seen yet.
+func (m *MemoryStore) append(anns []Announcement) {
+	for _, a := range anns {
+		if m.seen[key(a)] {
+			continue
+		}
+		m.seen[key(a)] = true
+		a.Sequence = uint64(len(m.anns)) + 1
+		m.anns = append(m.anns, a)
+	}
+}
+
+// List returns the announcements matching q after its cursor.
+func (m *MemoryStore) List(q Query) (*Page, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultPage
+	}
+	if limit > MaxPage {
+		limit = MaxPage
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	// Announcements are appended in chain order, so slots never decrease
+	start := sort.Search(len(m.anns), func(i int) bool { return m.anns[i].Slot >= q.FromSlot })
+	if q.Cursor != "" {
+		seq, err := strconv.ParseUint(q.Cursor, 10, 64)
+		if err != nil || seq == 0 || seq > uint64(len(m.anns)) {
+			return nil, ErrInvalidCursor
+		}
+		start = max(start, int(seq))
+	}
+	end := min(start+limit, len(m.anns))
+	page := &Page{
+		Announcements: append([]Announcement{}, m.anns[start:end]...),
+		IndexedSlot:   m.checkpoint.Slot,
+	}
+	if end < len(m.anns) {
+		page.NextCursor = strconv.FormatUint(m.anns[end-1].Sequence, 10)
+	}
+	return page, nil
+}
+
+// Checkpoint returns the newest transaction indexed.
+func (m *MemoryStore) Checkpoint() (Checkpoint, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.checkpoint, nil
+}
+
+// FileStore is a MemoryStore persisted to a JSON-lines file of
+// announcements, appended as they are indexed, and a checkpoint file next
+// to it.
+type FileStore struct {
+	*MemoryStore
+	path string
+	mu   sync.Mutex // Serializes file writes
+}
+
+// NewFileStore opens the index at path, creating it on first write.
+func NewFileStore(path string) (*FileStore, error) {
+	fs := &FileStore{MemoryStore: NewMemoryStore(), path: path}
+	if err := jsonfile.Load(fs.checkpointPath(), &fs.checkpoint); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer f.Close()
+	dec := json.NewDecoder(bufio.NewReader(f))
+	var anns []Announcement
+	for {
+		var a Announcement
+		if err := dec.Decode(&a); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		anns = append(anns, a)
+	}
+	fs.MemoryStore.append(anns)
+	return fs, nil
+}
+
+func (f *FileStore) checkpointPath() string {
+	return f.path + ".checkpoint"
+}
+
+// Append adds announcements to the file, then saves the checkpoint. After a
+// crash in between, the transactions are indexed again and the duplicates
+// skipped.
+func (f *FileStore) Append(anns []Announcement, cp Checkpoint) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Number the new announcements as the memory store will, but only add
+	// them once they are on disk
+	f.MemoryStore.mu.RLock()
+	next := uint64(len(f.anns)) + 1
+	var fresh []Announcement
+	batch := make(map[string]bool)
+	for _, a := range anns {
+		if f.seen[key(a)] || batch[key(a)] {
+			continue
+		}
+		batch[key(a)] = true
+		a.Sequence = next
+		next++
+		fresh = append(fresh, a)
+	}
+	f.MemoryStore.mu.RUnlock()
+
+	if len(fresh) > 0 {
+		file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return err
+		}
+		w := bufio.NewWriter(file)
+		enc := json.NewEncoder(w)
+		for _, a := range fresh {
+			if err = enc.Encode(a); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			err = w.Flush()
+		}
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.path, err)
+		}
+	}
+	f.MemoryStore.mu.Lock()
+	f.MemoryStore.append(fresh)
+	f.MemoryStore.mu.Unlock()
+
+	if err := jsonfile.Save(f.checkpointPath(), cp); err != nil {
+		return err
+	}
+	f.MemoryStore.mu.Lock()
+	f.checkpoint = cp
+	f.MemoryStore.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,85 @@
+package announce
+
+import (
+	"context"
+	"crypto/ecdh"
+	"net/url"
+	"strconv"
+
+	"sol_privacy/internal/stealth"
+	"sol_privacy/internal/wallet"
+)
+
+// Client reads the announcement index hosted by a proxy, e.g. through
+// proxyclient.Client.Do.
+type Client struct {
+	doRequest func(ctx context.Context, method, path string, body, result interface{}) error
+}
+
+// NewClient creates an announcement client.
+func NewClient(doRequest func(ctx context.Context, method, path string, body, result interface{}) error) *Client {
+	return &Client{doRequest: doRequest}
+}
+
+// List returns a page of announcements.
+func (c *Client) List(ctx context.Context, q Query) (*Page, error) {
+	params := url.Values{}
+	if q.FromSlot > 0 {
+		params.Set("from_slot", strconv.FormatUint(q.FromSlot, 10))
+	}
+	if q.Cursor != "" {
+		params.Set("cursor", q.Cursor)
+	}
+	if q.Limit > 0 {
+		params.Set("limit", strconv.Itoa(q.Limit))
+	}
+	path := "/stealth/announcements"
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+	var page Page
+	if err := c.doRequest(ctx, "GET", path, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Match is an announced payment to the scanning recipient.
+type Match struct {
+	Announcement
+	Keypair *wallet.Keypair // Spends the stealth address
+}
+
+// ScanResult lists the payments found by Scan.
+type ScanResult struct {
+	Matches  []Match
+	NextSlot uint64 // FromSlot for the next scan
+}
+
+// Scan pages through the announcements from fromSlot and returns those paying
+// the recipient of scan. The scan key stays local: each announcement is
+// recovered here and kept if it derives the announced address.
+func (c *Client) Scan(ctx context.Context, scan *ecdh.PrivateKey, fromSlot uint64) (*ScanResult, error) {
+	result := &ScanResult{NextSlot: fromSlot}
+	q := Query{FromSlot: fromSlot, Limit: MaxPage}
+	for {
+		page, err := c.List(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range page.Announcements {
+			kp, err := stealth.Recover(scan, a.EphemeralPublicKey)
+			if err != nil || kp.Address() != a.Address {
+				continue
+			}
+			result.Matches = append(result.Matches, Match{Announcement: a, Keypair: kp})
+		}
+		if page.IndexedSlot >= result.NextSlot {
+			result.NextSlot = page.IndexedSlot + 1
+		}
+		if page.NextCursor == "" {
+			return result, nil
+		}
+		q.Cursor = page.NextCursor
+	}
+}
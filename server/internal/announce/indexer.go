@@ -0,0 +1,123 @@
+package announce
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"sol_privacy/internal/solana"
+)
+
+// Indexer defaults.
+const (
+	DefaultPollInterval = 10 * time.Second
+	signaturesPage      = 1000
+	checkpointEvery     = 100 // Transactions
+)
+
+// RPC is the part of the Solana client the indexer uses.
+type RPC interface {
+	GetSignaturesForAddress(ctx context.Context, address string, opts solana.SignaturesOptions) ([]solana.SignatureInfo, error)
+	GetTransactionMeta(ctx context.Context, signature string) (*solana.TransactionMeta, error)
+}
+
+// IndexerConfig configures an Indexer.
+type IndexerConfig struct {
+	RPC          RPC
+	Program      string // Program whose transactions carry the announcements
+	Store        Store
+	PollInterval time.Duration // Defaults to DefaultPollInterval
+}
+
+// Indexer follows the program's transactions into the store. The first run
+// indexes the program's whole history; later runs resume from the store's
+// checkpoint.
+type Indexer struct {
+	config IndexerConfig
+}
+
+// NewIndexer creates an indexer. Call Run to start indexing.
+func NewIndexer(config IndexerConfig) *Indexer {
+	if config.PollInterval == 0 {
+		config.PollInterval = DefaultPollInterval
+	}
+	return &Indexer{config: config}
+}
+
+// Store returns the index.
+func (ix *Indexer) Store() Store {
+	return ix.config.Store
+}
+
+// Run indexes new transactions every PollInterval until ctx is done.
+func (ix *Indexer) Run(ctx context.Context) {
+	for {
+		if _, err := ix.Poll(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("announce: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(ix.config.PollInterval):
+		}
+	}
+}
+
+// Poll indexes the transactions since the checkpoint and returns the number
+// of announcements found. Progress is saved every batch of transactions, so
+// an error leaves the index consistent and the next poll resumes from it.
+func (ix *Indexer) Poll(ctx context.Context) (int, error) {
+	cp, err := ix.config.Store.Checkpoint()
+	if err != nil {
+		return 0, err
+	}
+
+	// Signatures come newest first; collect back to the checkpoint
+	var pending []solana.SignatureInfo
+	before := ""
+	for {
+		page, err := ix.config.RPC.GetSignaturesForAddress(ctx, ix.config.Program, solana.SignaturesOptions{
+			Before: before,
+			Until:  cp.Signature,
+			Limit:  signaturesPage,
+		})
+		if err != nil {
+			return 0, err
+		}
+		pending = append(pending, page...)
+		if len(page) < signaturesPage {
+			break
+		}
+		before = page[len(page)-1].Signature
+	}
+
+	found := 0
+	var batch []Announcement
+	for i := len(pending) - 1; i >= 0; i-- {
+		sig := pending[i]
+		if !sig.Failed() {
+			meta, err := ix.config.RPC.GetTransactionMeta(ctx, sig.Signature)
+			if err != nil {
+				return found, err
+			}
+			if meta != nil {
+				for _, a := range ParseLogs(meta.LogMessages) {
+					a.Signature = sig.Signature
+					a.Slot = sig.Slot
+					if sig.BlockTime != nil {
+						a.BlockTime = *sig.BlockTime
+					}
+					batch = append(batch, a)
+				}
+			}
+		}
+		if i%checkpointEvery == 0 {
+			if err := ix.config.Store.Append(batch, Checkpoint{Signature: sig.Signature, Slot: sig.Slot}); err != nil {
+				return found, err
+			}
+			found += len(batch)
+			batch = nil
+		}
+	}
+	return found, nil
+}
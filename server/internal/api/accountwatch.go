@@ -0,0 +1,47 @@
+package api
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"sol_privacy/internal/accountwatch"
+	"sol_privacy/internal/events"
+	"sol_privacy/internal/solana"
+)
+
+// newAccountWatcher watches the pool PDA when ACCOUNT_WATCH_POOL is set and
+// the escrow accounts listed in ACCOUNT_WATCH_ESCROWS, publishing their
+// balance changes on the bus. It returns nil when neither is set.
+func newAccountWatcher(h *Handler) *accountwatch.Watcher {
+	pool, _ := strconv.ParseBool(h.env("ACCOUNT_WATCH_POOL"))
+	var escrows []accountwatch.Account
+	for _, address := range strings.Split(h.env("ACCOUNT_WATCH_ESCROWS"), ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			escrows = append(escrows, accountwatch.Account{Address: address, Kind: accountwatch.KindEscrow})
+		}
+	}
+	if !pool && len(escrows) == 0 {
+		return nil
+	}
+
+	config := accountwatch.Config{
+		RPC:        solana.NewClient(solana.Config{URL: h.env("SOLANA_RPC_URL")}),
+		URL:        h.env("SOLANA_WS_URL"),
+		Commitment: h.env("ACCOUNT_WATCH_COMMITMENT"),
+		Accounts:   escrows,
+		OnChange: func(ctx context.Context, c accountwatch.Change) {
+			h.events.Emit(ctx, events.AccountBalanceChanged, "accountwatch", c)
+		},
+	}
+	if pool {
+		config.PoolAddress = func(ctx context.Context) (string, error) {
+			resp, err := h.client.Pool.GetDepositAddress(ctx)
+			if err != nil {
+				return "", err
+			}
+			return resp.DepositAddress, nil
+		}
+	}
+	return accountwatch.New(config)
+}
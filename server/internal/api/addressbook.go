@@ -0,0 +1,140 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"sol_privacy/internal/addressbook"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func newAddressBook(h *Handler) *addressbook.Book {
+	var store addressbook.Store
+	path := h.env("ADDRESS_BOOK_DB")
+	// Entries are only persisted encrypted, so the data directory needs a key
+	if path == "" && h.env("ADDRESS_BOOK_KEY") != "" {
+		path = h.storePath("ADDRESS_BOOK_DB", "addressbook.enc")
+	}
+	if path != "" {
+		fs, err := addressbook.NewEncryptedFileStore(path, h.env("ADDRESS_BOOK_KEY"))
+		if err != nil {
+			log.Printf("address book not persisted: %v", err)
+		} else {
+			store = fs
+		}
+	}
+	return addressbook.NewBook(store)
+}
+
+// AddressBookSearch handles listing or searching address book entries
+func (h *Handler) AddressBookSearch(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	entries, err := h.addressBook.Search(r.Context(), q.Get("q"), addressbook.Kind(q.Get("kind")), limit)
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"entries": entries,
+	})
+}
+
+// AddressBookAdd handles labeling a new address
+func (h *Handler) AddressBookAdd(w http.ResponseWriter, r *http.Request) {
+	var req addressbook.AddRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	e, err := h.addressBook.Add(r.Context(), req)
+	if err != nil {
+		respondAddressBookError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, e)
+}
+
+// AddressBookRecent handles listing recently used recipients
+func (h *Handler) AddressBookRecent(w http.ResponseWriter, r *http.Request) {
+	recent, err := h.addressBook.Recent(r.Context(), addressbook.Kind(r.URL.Query().Get("kind")))
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"recent": recent,
+	})
+}
+
+// AddressBookTouch handles recording a recipient as recently used
+func (h *Handler) AddressBookTouch(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Kind  addressbook.Kind `json:"kind"`
+		Value string           `json:"value"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.addressBook.Touch(r.Context(), req.Kind, req.Value); err != nil {
+		respondAddressBookError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddressBookGet handles fetching an address book entry
+func (h *Handler) AddressBookGet(w http.ResponseWriter, r *http.Request) {
+	e, err := h.addressBook.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondAddressBookError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, e)
+}
+
+// AddressBookUpdate handles relabeling an address book entry
+func (h *Handler) AddressBookUpdate(w http.ResponseWriter, r *http.Request) {
+	var req addressbook.UpdateRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	e, err := h.addressBook.Update(r.Context(), chi.URLParam(r, "id"), req)
+	if err != nil {
+		respondAddressBookError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, e)
+}
+
+// AddressBookRemove handles deleting an address book entry
+func (h *Handler) AddressBookRemove(w http.ResponseWriter, r *http.Request) {
+	if err := h.addressBook.Remove(r.Context(), chi.URLParam(r, "id")); err != nil {
+		respondAddressBookError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func respondAddressBookError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, addressbook.ErrEntryNotFound):
+		respondError(w, r, http.StatusNotFound, err.Error())
+	case errors.Is(err, addressbook.ErrLabelExists):
+		respondError(w, r, http.StatusConflict, err.Error())
+	default:
+		respondUpstreamError(w, r, err)
+	}
+}
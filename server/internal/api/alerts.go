@@ -0,0 +1,23 @@
+package api
+
+import (
+	"log"
+
+	"sol_privacy/internal/alerts"
+)
+
+// newAlerter enables the alert channels in config, or returns nil if it has
+// none.
+func newAlerter(h *Handler, config alerts.Config) *alerts.Alerter {
+	if len(config.Channels) == 0 {
+		return nil
+	}
+	config.Bus = h.events
+	config.Authorizations = h.client.Authorization
+	a, err := alerts.New(config)
+	if err != nil {
+		log.Printf("alerts disabled: %v", err)
+		return nil
+	}
+	return a
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"sol_privacy/internal/cache"
+	"sol_privacy/internal/merchant"
+)
+
+// Analytics cache defaults, overridden by ANALYTICS_CACHE_TTL and ANALYTICS_CACHE_STALE
+const (
+	defaultAnalyticsTTL      = time.Minute
+	defaultAnalyticsStaleTTL = 5 * time.Minute
+)
+
+func newAnalyticsCache(h *Handler) *cache.Cache[*merchant.AnalyticsResponse] {
+	return cache.New[*merchant.AnalyticsResponse](cache.Config{
+		TTL:      h.envDuration("ANALYTICS_CACHE_TTL", defaultAnalyticsTTL),
+		StaleTTL: h.envDuration("ANALYTICS_CACHE_STALE", defaultAnalyticsStaleTTL),
+	})
+}
+
+// envDuration reads a duration such as "30s" from the environment
+func (h *Handler) envDuration(name string, def time.Duration) time.Duration {
+	s := h.env(name)
+	if s == "" {
+		return def
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		log.Printf("invalid %s %q, using %s: %v", name, s, def, err)
+		return def
+	}
+	return d
+}
+
+// getAnalytics serves merchant analytics through the cache, keyed by the full request
+func (h *Handler) getAnalytics(ctx context.Context, req merchant.AnalyticsRequest, bypass bool) (*merchant.AnalyticsResponse, cache.Status, error) {
+	key, err := json.Marshal(req)
+	if err != nil {
+		return nil, cache.Bypass, err
+	}
+	return h.analytics.Get(ctx, string(key), bypass, func(ctx context.Context) (*merchant.AnalyticsResponse, error) {
+		return h.client.Merchant.GetAnalytics(ctx, req)
+	})
+}
+
+// bypassCache reports whether the caller asked for fresh data with
+// ?no_cache=true or Cache-Control: no-cache
+func bypassCache(r *http.Request) bool {
+	if noCache, err := strconv.ParseBool(r.URL.Query().Get("no_cache")); err == nil && noCache {
+		return true
+	}
+	cc := strings.ToLower(r.Header.Get("Cache-Control"))
+	return strings.Contains(cc, "no-cache") || strings.Contains(cc, "no-store")
+}
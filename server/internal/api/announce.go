@@ -0,0 +1,72 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"sol_privacy/internal/announce"
+	"sol_privacy/internal/solana"
+	"sol_privacy/internal/validate"
+)
+
+// newAnnouncementIndexer indexes the stealth payment announcements of the
+// Umbra program at UMBRA_PROGRAM_ID into ANNOUNCEMENTS_DB. It returns nil
+// when no program is set.
+func newAnnouncementIndexer(h *Handler) *announce.Indexer {
+	program := h.env("UMBRA_PROGRAM_ID")
+	if program == "" {
+		return nil
+	}
+	var store announce.Store = announce.NewMemoryStore()
+	if path := h.storePath("ANNOUNCEMENTS_DB", "announcements.jsonl"); path != "" {
+		fs, err := announce.NewFileStore(path)
+		if err != nil {
+			log.Printf("stealth announcements not persisted: %v", err)
+		} else {
+			store = fs
+		}
+	}
+	return announce.NewIndexer(announce.IndexerConfig{
+		RPC:          solana.NewClient(solana.Config{URL: h.env("SOLANA_RPC_URL")}),
+		Program:      program,
+		Store:        store,
+		PollInterval: h.envDuration("ANNOUNCEMENTS_POLL_INTERVAL", announce.DefaultPollInterval),
+	})
+}
+
+// StealthAnnouncements handles paging through indexed stealth payment
+// announcements, which recipients scan with their scan key locally
+func (h *Handler) StealthAnnouncements(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := announce.Query{Cursor: q.Get("cursor")}
+	if from := q.Get("from_slot"); from != "" {
+		slot, err := strconv.ParseUint(from, 10, 64)
+		if err != nil {
+			respondValidationError(w, r, validate.Errors{{Field: "from_slot", Message: "must be a slot number"}})
+			return
+		}
+		query.FromSlot = slot
+	}
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > announce.MaxPage {
+			respondError(w, r, http.StatusBadRequest, "limit must be between 1 and 5000")
+			return
+		}
+		query.Limit = n
+	}
+
+	page, err := h.announcements.Store().List(query)
+	if errors.Is(err, announce.ErrInvalidCursor) {
+		respondValidationError(w, r, validate.Errors{{Field: "cursor", Message: "is not a cursor returned by a previous page"}})
+		return
+	}
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, page)
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"sol_privacy/internal/artifacts"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// newArtifactCache serves the circuit artifacts synced into ARTIFACTS_DIR,
+// pinned by ARTIFACTS_MANIFEST (manifest.json in the directory by default).
+// It returns nil when no directory is set.
+func newArtifactCache(h *Handler) *artifacts.Cache {
+	dir := h.env("ARTIFACTS_DIR")
+	if dir == "" {
+		return nil
+	}
+	path := h.env("ARTIFACTS_MANIFEST")
+	if path == "" {
+		path = filepath.Join(dir, "manifest.json")
+	}
+	manifest, err := artifacts.LoadManifest(path)
+	if err != nil {
+		log.Printf("circuit artifacts not served: %v", err)
+		return nil
+	}
+	return artifacts.NewCache(dir, manifest)
+}
+
+// ArtifactManifest handles listing the served circuit artifacts and their pins
+func (h *Handler) ArtifactManifest(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, h.artifacts.Manifest())
+}
+
+// ArtifactGet handles serving a circuit artifact to client-side provers,
+// checked against its pin on every request
+func (h *Handler) ArtifactGet(w http.ResponseWriter, r *http.Request) {
+	circuit, kind := chi.URLParam(r, "circuit"), chi.URLParam(r, "kind")
+	path, err := h.artifacts.File(circuit, kind)
+	switch {
+	case errors.Is(err, artifacts.ErrUnknownCircuit), errors.Is(err, artifacts.ErrNotCached):
+		respondError(w, r, http.StatusNotFound, "Artifact not found")
+		return
+	case errors.Is(err, artifacts.ErrUnpinned), errors.Is(err, artifacts.ErrIntegrity):
+		log.Printf("artifacts: %v", err)
+		respondError(w, r, http.StatusServiceUnavailable, "Artifact failed its integrity check")
+		return
+	case err != nil:
+		respondError(w, r, http.StatusNotFound, "Artifact not found")
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		respondError(w, r, http.StatusNotFound, "Artifact not found")
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	pin := h.artifacts.Manifest().Circuits[circuit].Artifacts()[kind].SHA256
+	w.Header().Set("ETag", `"`+pin+`"`)
+	w.Header().Set("X-Artifact-SHA256", pin)
+	http.ServeContent(w, r, filepath.Base(path), info.ModTime(), f)
+}
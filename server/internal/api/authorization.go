@@ -1,25 +1,38 @@
 package api
 
 import (
-	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 
 	"sol_privacy/internal/authorization"
+	"sol_privacy/internal/validate"
 
 	"github.com/go-chi/chi/v5"
 )
 
+func newMultisigNonces(h *Handler) *authorization.UsedNonces {
+	if path := h.storePath("MULTISIG_NONCES_DB", "multisig-nonces.json"); path != "" {
+		nonces, err := authorization.NewUsedNonces(path)
+		if err == nil {
+			return nonces
+		}
+		log.Printf("multisig nonces not persisted: %v", err)
+	}
+	nonces, _ := authorization.NewUsedNonces("")
+	return nonces
+}
+
 // AuthorizationAuthorize handles bot spending authorization
 func (h *Handler) AuthorizationAuthorize(w http.ResponseWriter, r *http.Request) {
 	var req authorization.AuthorizeSpendingRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	resp, err := h.client.Authorization.AuthorizeSpending(r.Context(), req)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
@@ -29,14 +42,84 @@ func (h *Handler) AuthorizationAuthorize(w http.ResponseWriter, r *http.Request)
 // AuthorizationList handles listing authorizations
 func (h *Handler) AuthorizationList(w http.ResponseWriter, r *http.Request) {
 	wallet := chi.URLParam(r, "wallet")
-	if wallet == "" {
-		respondError(w, http.StatusBadRequest, "wallet address required")
+	if err := validate.Address(wallet); err != nil {
+		respondValidationError(w, r, validate.Errors{{Field: "wallet", Message: err.Error()}})
 		return
 	}
 
 	resp, err := h.client.Authorization.ListAuthorizations(r.Context(), wallet)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// AuthorizationUsage handles getting per-day spend for an authorization
+func (h *Handler) AuthorizationUsage(w http.ResponseWriter, r *http.Request) {
+	wallet := chi.URLParam(r, "wallet")
+	service := chi.URLParam(r, "service")
+	if wallet == "" || service == "" {
+		respondError(w, r, http.StatusBadRequest, "wallet address and service required")
+		return
+	}
+
+	resp, err := h.client.Authorization.GetUsageHistory(r.Context(), wallet, service)
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// AuthorizationUpdate handles changing an authorization's limits in place
+func (h *Handler) AuthorizationUpdate(w http.ResponseWriter, r *http.Request) {
+	var req authorization.UpdateAuthorizationRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.UserWallet == "" || req.AuthorizedService == "" || req.UserSignature == "" {
+		respondError(w, r, http.StatusBadRequest, "Missing required fields: user_wallet, authorized_service, user_signature")
+		return
+	}
+
+	resp, err := h.client.Authorization.UpdateAuthorization(r.Context(), req)
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// AuthorizationMultisig handles M-of-N authorizations, rejecting requests
+// whose signer set the treasury wallet did not sign, without enough valid
+// signatures, expired or already submitted, before they reach the upstream API
+func (h *Handler) AuthorizationMultisig(w http.ResponseWriter, r *http.Request) {
+	var req authorization.MultisigAuthorizeRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := authorization.VerifyMultisig(req); err != nil {
+		respondError(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if err := h.multisigNonces.Use(req); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, authorization.ErrNonceUsed) {
+			status = http.StatusConflict
+		}
+		respondError(w, r, status, err.Error())
+		return
+	}
+
+	resp, err := h.client.Authorization.AuthorizeMultisig(r.Context(), req)
+	if err != nil {
+		respondUpstreamError(w, r, err)
 		return
 	}
 
@@ -46,14 +129,13 @@ func (h *Handler) AuthorizationList(w http.ResponseWriter, r *http.Request) {
 // AuthorizationRevoke handles revoking an authorization
 func (h *Handler) AuthorizationRevoke(w http.ResponseWriter, r *http.Request) {
 	var req authorization.RevokeAuthorizationRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	resp, err := h.client.Authorization.RevokeAuthorization(r.Context(), req)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
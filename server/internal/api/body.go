@@ -0,0 +1,171 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	shadowpay "sol_privacy"
+	"sol_privacy/internal/openapi"
+	"sol_privacy/internal/validate"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// defaultMaxBodyBytes caps request bodies, overridden by MAX_BODY_BYTES
+const defaultMaxBodyBytes = 1 << 20
+
+// newBodySchemas reads the request body schemas of the embedded OpenAPI spec.
+func newBodySchemas() *openapi.Spec {
+	spec, err := openapi.Parse(shadowpay.OpenAPI)
+	if err != nil {
+		log.Printf("request body schemas disabled: %v", err)
+		return nil
+	}
+	return spec
+}
+
+// maxBodyBytes reads MAX_BODY_BYTES, falling back to defaultMaxBodyBytes.
+func (h *Handler) maxBodyBytes() int64 {
+	s := h.env("MAX_BODY_BYTES")
+	if s == "" {
+		return defaultMaxBodyBytes
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n <= 0 {
+		log.Printf("invalid MAX_BODY_BYTES %q, using %d", s, defaultMaxBodyBytes)
+		return defaultMaxBodyBytes
+	}
+	return n
+}
+
+// checkBody rejects bodies over the size limit with 413, and JSON bodies
+// that do not match the route's request schema in the OpenAPI spec with 422.
+// The body is buffered, so handlers and signature checks still read it.
+func (h *Handler) checkBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body []byte
+		if r.Body != nil && r.Body != http.NoBody {
+			var err error
+			if body, err = io.ReadAll(http.MaxBytesReader(w, r.Body, h.maxBody)); err != nil {
+				respondBodyError(w, r, err)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		var op *openapi.Operation
+		if h.bodySchemas != nil {
+			op = h.bodySchemas.Find(r.Method, routePath(r))
+		}
+		if op == nil || op.Body == nil || !isJSON(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(bytes.TrimSpace(body)) == 0 {
+			if op.BodyRequired {
+				respondError(w, r, http.StatusBadRequest, "Request body is required")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var v any
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		if err := dec.Decode(&v); err != nil {
+			respondError(w, r, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if errs := op.Body.Validate(v); len(errs) > 0 {
+			respondFieldErrors(w, r, http.StatusUnprocessableEntity, errs)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// routePath is the request path relative to where the API is mounted, as
+// paths are written in the spec.
+func routePath(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePath != "" {
+		return rctx.RoutePath
+	}
+	return r.URL.Path
+}
+
+// isJSON reports whether a request body is JSON. Requests without a
+// Content-Type are taken to be, as the handlers decode them anyway.
+func isJSON(r *http.Request) bool {
+	ct := r.Header.Get("Content-Type")
+	if ct == "" {
+		return true
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	return err == nil && (mt == "application/json" || strings.HasSuffix(mt, "+json"))
+}
+
+// decodeJSON decodes a request body into v, rejecting fields v does not
+// have. It reports a bad body itself and returns false.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		respondBodyError(w, r, err)
+		return false
+	}
+	return true
+}
+
+// respondBodyError reports a body that could not be read or decoded: 413 when
+// it is too large, 422 for unknown or mistyped fields and 400 otherwise.
+func respondBodyError(w http.ResponseWriter, r *http.Request, err error) {
+	var tooLarge *http.MaxBytesError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &tooLarge):
+		respondError(w, r, http.StatusRequestEntityTooLarge, "Request body too large")
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		respondFieldErrors(w, r, http.StatusUnprocessableEntity, validate.Errors{{
+			Field:   typeErr.Field,
+			Message: "must be " + jsonTypeOf(typeErr.Type),
+		}})
+	case strings.HasPrefix(err.Error(), `json: unknown field "`):
+		field := strings.TrimSuffix(strings.TrimPrefix(err.Error(), `json: unknown field "`), `"`)
+		respondFieldErrors(w, r, http.StatusUnprocessableEntity, validate.Errors{{
+			Field:   field,
+			Message: "is not a known field",
+		}})
+	default:
+		respondError(w, r, http.StatusBadRequest, "Invalid request body")
+	}
+}
+
+// jsonTypeOf names the JSON type a Go value decodes from, with its article.
+func jsonTypeOf(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	}
+	return "an object"
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/bots"
+	"sol_privacy/internal/events"
+	"sol_privacy/internal/session"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// newBots enables the Telegram and Discord bots whose tokens are set, or
+// returns nil if neither is.
+func newBots(h *Handler) *bots.Bot {
+	if h.env("TELEGRAM_BOT_TOKEN") == "" && h.env("DISCORD_BOT_TOKEN") == "" {
+		return nil
+	}
+	var store bots.Store
+	if path := h.storePath("BOTS_DB", "bots.json"); path != "" {
+		fs, err := bots.NewFileStore(path)
+		if err != nil {
+			log.Printf("bot links not persisted: %v", err)
+		} else {
+			store = fs
+		}
+	}
+	bot, err := bots.New(bots.Config{
+		Pool:     h.client.Pool,
+		Store:    store,
+		LinkHint: h.env("BOTS_LINK_HINT"),
+		// EVENTS_WEBHOOK_URL delivers decisions to the requester
+		OnDecision: func(ctx context.Context, r *bots.PaymentRequest) {
+			eventType := events.PaymentRequestDeclined
+			if r.Status == bots.RequestApproved {
+				eventType = events.PaymentRequestApproved
+			}
+			h.events.Emit(ctx, eventType, "bots", r)
+		},
+	})
+	if err != nil {
+		log.Printf("bots disabled: %v", err)
+		return nil
+	}
+
+	// Webhook and command registration talk to the platforms; don't hold up startup
+	var setup sync.WaitGroup
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+
+	if token := h.env("TELEGRAM_BOT_TOKEN"); token != "" {
+		tg, err := bots.NewTelegram(bots.TelegramConfig{Token: token, WebhookSecret: h.env("TELEGRAM_WEBHOOK_SECRET")})
+		if err != nil {
+			log.Printf("telegram bot disabled: %v", err)
+		} else {
+			bot.AddPlatform(tg)
+			h.telegram = tg
+			if url := h.env("TELEGRAM_WEBHOOK_URL"); url != "" {
+				setup.Go(func() {
+					if err := tg.SetWebhook(ctx, url); err != nil {
+						log.Printf("telegram webhook not set: %v", err)
+					}
+				})
+			}
+		}
+	}
+	if token := h.env("DISCORD_BOT_TOKEN"); token != "" {
+		dc, err := bots.NewDiscord(bots.DiscordConfig{
+			Token:         token,
+			PublicKey:     h.env("DISCORD_PUBLIC_KEY"),
+			ApplicationID: h.env("DISCORD_APPLICATION_ID"),
+		})
+		if err != nil {
+			log.Printf("discord bot disabled: %v", err)
+		} else {
+			bot.AddPlatform(dc)
+			h.discord = dc
+			if h.env("DISCORD_APPLICATION_ID") != "" {
+				setup.Go(func() {
+					if err := dc.RegisterCommands(ctx); err != nil {
+						log.Printf("discord commands not registered: %v", err)
+					}
+				})
+			}
+		}
+	}
+	go func() {
+		setup.Wait()
+		cancel()
+	}()
+	return bot
+}
+
+// BotLink handles linking the chat a /link code was issued to with the
+// session's wallet
+func (h *Handler) BotLink(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Code string `json:"code"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Code == "" {
+		respondError(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	claims, _ := session.FromContext(r.Context())
+
+	link, err := h.bots.Redeem(r.Context(), req.Code, claims.Wallet)
+	if err != nil {
+		respondBotError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, link)
+}
+
+// BotPaymentRequestCreate handles asking a wallet's owner to approve a payment in chat
+func (h *Handler) BotPaymentRequestCreate(w http.ResponseWriter, r *http.Request) {
+	var req bots.CreateRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	pr, err := h.bots.RequestPayment(r.Context(), req)
+	if err != nil {
+		respondBotError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, pr)
+}
+
+// BotPaymentRequestGet handles fetching a payment request and its decision
+func (h *Handler) BotPaymentRequestGet(w http.ResponseWriter, r *http.Request) {
+	pr, err := h.bots.PaymentRequest(chi.URLParam(r, "id"))
+	if err != nil {
+		respondBotError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, pr)
+}
+
+func respondBotError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, bots.ErrRequestNotFound), errors.Is(err, bots.ErrCodeNotFound):
+		respondError(w, r, http.StatusNotFound, err.Error())
+	case errors.Is(err, bots.ErrNotLinked):
+		respondError(w, r, http.StatusConflict, err.Error())
+	default:
+		respondUpstreamError(w, r, err)
+	}
+}
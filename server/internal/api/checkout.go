@@ -0,0 +1,189 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"sol_privacy/internal/checkout"
+	"sol_privacy/internal/confirm"
+	"sol_privacy/internal/solana"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func newCheckoutManager(h *Handler) *checkout.Manager {
+	baseURL := strings.TrimSuffix(h.env("CHECKOUT_BASE_URL"), "/")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080/api/checkout"
+	}
+
+	// Payment links and CHECKOUT_WEBHOOK_URL consume these from the bus
+	onEvent := func(ctx context.Context, event checkout.Event) {
+		h.events.Emit(ctx, event.Type, "checkout", event.Session)
+	}
+
+	return checkout.NewManager(checkout.Config{
+		Intents:  h.client.Intent,
+		Escrow:   h.client.Escrow,
+		Payments: h.client.Payment,
+		Tracker:  confirm.NewTracker(solana.NewClient(solana.Config{URL: h.env("SOLANA_RPC_URL")}), 0),
+		BaseURL:  baseURL,
+		OnEvent:  onEvent,
+	})
+}
+
+// checkoutAssets are the scripts the hosted page loads from
+// CHECKOUT_ASSETS_DIR, so no third-party CDN serves code to payers.
+var checkoutAssets = map[string]bool{
+	checkout.Web3Asset: true,
+}
+
+// CheckoutCreate handles creating a checkout session
+func (h *Handler) CheckoutCreate(w http.ResponseWriter, r *http.Request) {
+	var req checkout.CreateRequest
+	if !decodeJSON(w, r, &req) || !h.resolveRecipient(w, r, "recipient", &req.Recipient) {
+		return
+	}
+
+	session, err := h.checkout.Create(r.Context(), req)
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, session)
+}
+
+// CheckoutList handles listing checkout sessions
+func (h *Handler) CheckoutList(w http.ResponseWriter, r *http.Request) {
+	sessions, err := h.checkout.List(r.Context())
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"sessions": sessions,
+	})
+}
+
+// CheckoutGet handles fetching a checkout session
+func (h *Handler) CheckoutGet(w http.ResponseWriter, r *http.Request) {
+	session, err := h.checkout.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondCheckoutError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, session)
+}
+
+// CheckoutPay handles preparing a payer's escrow payment of a session
+func (h *Handler) CheckoutPay(w http.ResponseWriter, r *http.Request) {
+	var req checkout.PayRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	// Reject SPL-token payments that violate the mint's guardrail
+	if req.TokenMint != "" {
+		session, err := h.checkout.Get(r.Context(), chi.URLParam(r, "id"))
+		if err != nil {
+			respondCheckoutError(w, r, err)
+			return
+		}
+		validation, err := h.client.Token.ValidatePayment(r.Context(), req.TokenMint, session.Price(req.TokenMint))
+		if err != nil {
+			respondError(w, r, http.StatusBadGateway, "Failed to validate token payment: "+err.Error())
+			return
+		}
+		if !validation.Allowed {
+			respondError(w, r, http.StatusUnprocessableEntity, "Token payment rejected: "+validation.Reason)
+			return
+		}
+	}
+
+	resp, err := h.checkout.Pay(r.Context(), chi.URLParam(r, "id"), req)
+	if err != nil {
+		respondCheckoutError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// CheckoutComplete handles completing a paid checkout session. The
+// settlement is looked up and confirmed on-chain, not taken from the caller.
+func (h *Handler) CheckoutComplete(w http.ResponseWriter, r *http.Request) {
+	session, err := h.checkout.Complete(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondCheckoutError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, session)
+}
+
+// CheckoutCancel handles canceling an open checkout session
+func (h *Handler) CheckoutCancel(w http.ResponseWriter, r *http.Request) {
+	session, err := h.checkout.Cancel(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondCheckoutError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, session)
+}
+
+// CheckoutAsset handles serving the hosted page's scripts
+func (h *Handler) CheckoutAsset(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	dir := h.env("CHECKOUT_ASSETS_DIR")
+	if !checkoutAssets[name] || dir == "" {
+		respondError(w, r, http.StatusNotFound, "asset not found")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	http.ServeFile(w, r, filepath.Join(dir, name))
+}
+
+// CheckoutPage handles serving the hosted payment page
+func (h *Handler) CheckoutPage(w http.ResponseWriter, r *http.Request) {
+	session, err := h.checkout.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondCheckoutError(w, r, err)
+		return
+	}
+
+	rpcURL := h.env("SOLANA_RPC_URL")
+	if rpcURL == "" {
+		rpcURL = solana.MainnetRPCURL
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := checkout.RenderPage(w, session, strings.TrimSuffix(session.URL, "/"+session.ID), rpcURL); err != nil {
+		log.Printf("failed to render checkout page: %v", err)
+	}
+}
+
+func respondCheckoutError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, checkout.ErrSessionNotFound):
+		respondError(w, r, http.StatusNotFound, err.Error())
+	case errors.Is(err, checkout.ErrSessionClosed):
+		respondError(w, r, http.StatusConflict, err.Error())
+	case errors.Is(err, checkout.ErrNotPaid):
+		respondError(w, r, http.StatusPaymentRequired, err.Error())
+	case errors.Is(err, checkout.ErrPaymentPending):
+		respondError(w, r, http.StatusConflict, err.Error())
+	case errors.Is(err, checkout.ErrTokenNotAccepted):
+		respondError(w, r, http.StatusUnprocessableEntity, err.Error())
+	default:
+		respondUpstreamError(w, r, err)
+	}
+}
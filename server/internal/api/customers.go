@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"sol_privacy/internal/customers"
+	"sol_privacy/internal/x402"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func newCustomerService(h *Handler) *customers.Service {
+	var store customers.Store
+	if path := h.storePath("CUSTOMERS_DB", "customers.json"); path != "" {
+		fs, err := customers.NewFileStore(path)
+		if err != nil {
+			log.Printf("customers not persisted: %v", err)
+		} else {
+			store = fs
+		}
+	}
+	return customers.NewService(store)
+}
+
+// CustomerList handles listing customers, optionally filtered by alias
+func (h *Handler) CustomerList(w http.ResponseWriter, r *http.Request) {
+	list, err := h.customers.List(r.Context(), r.URL.Query().Get("alias"))
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"customers": list,
+	})
+}
+
+// CustomerStats handles summarizing repeat-purchase stats
+func (h *Handler) CustomerStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.customers.Stats(r.Context())
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, stats)
+}
+
+// CustomerGet handles fetching a customer by commitment
+func (h *Handler) CustomerGet(w http.ResponseWriter, r *http.Request) {
+	c, err := h.customers.Get(r.Context(), chi.URLParam(r, "commitment"))
+	if err != nil {
+		respondCustomerError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, c)
+}
+
+// CustomerSetAlias handles attaching an alias to a commitment
+func (h *Handler) CustomerSetAlias(w http.ResponseWriter, r *http.Request) {
+	var req customers.AliasRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	c, err := h.customers.SetAlias(r.Context(), chi.URLParam(r, "commitment"), req)
+	if err != nil {
+		respondCustomerError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, c)
+}
+
+// CustomerRecordPurchase handles counting a purchase paid with a commitment
+func (h *Handler) CustomerRecordPurchase(w http.ResponseWriter, r *http.Request) {
+	var req customers.PurchaseRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	c, err := h.customers.RecordPurchase(r.Context(), chi.URLParam(r, "commitment"), req)
+	if err != nil {
+		respondCustomerError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, c)
+}
+
+// CustomerDelete handles forgetting a customer
+func (h *Handler) CustomerDelete(w http.ResponseWriter, r *http.Request) {
+	if err := h.customers.Delete(r.Context(), chi.URLParam(r, "commitment")); err != nil {
+		respondCustomerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// recordSettledPurchase counts a settled payment against the payer's commitment.
+// SPL settlements use the token amount; SOL ones the x402 requirement in SOL.
+func (h *Handler) recordSettledPurchase(ctx context.Context, commitment, maxAmount, tokenMint string, tokenAmount int64) {
+	req := customers.PurchaseRequest{TokenMint: tokenMint, Amount: tokenAmount}
+	if tokenMint == "" {
+		req.Amount, _ = x402.ParseSOL(maxAmount)
+	}
+	if _, err := h.customers.RecordPurchase(ctx, commitment, req); err != nil {
+		log.Printf("failed to record customer purchase: %v", err)
+	}
+}
+
+func respondCustomerError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, customers.ErrCustomerNotFound) {
+		respondError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	respondUpstreamError(w, r, err)
+}
@@ -0,0 +1,223 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"sol_privacy/internal/client"
+	sperrors "sol_privacy/internal/errors"
+	"sol_privacy/internal/i18n"
+	"sol_privacy/internal/validate"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Error codes returned in ErrorObject.Code.
+const (
+	CodeInvalidRequest      = "invalid_request"
+	CodeValidationFailed    = "validation_failed"
+	CodeUnauthorized        = "unauthorized"
+	CodeForbidden           = "forbidden"
+	CodeNotFound            = "not_found"
+	CodeConflict            = "conflict"
+	CodePayloadTooLarge     = "payload_too_large"
+	CodeUnprocessable       = "unprocessable"
+	CodeRateLimited         = "rate_limited"
+	CodeUpstreamRejected    = "upstream_rejected"
+	CodeUpstreamError       = "upstream_error"
+	CodeUpstreamUnavailable = "upstream_unavailable"
+	CodeUpstreamTimeout     = "upstream_timeout"
+	CodeUnsupported         = "unsupported"
+	CodeInternal            = "internal_error"
+)
+
+// ErrorDocument is the body of every error response. Error repeats the first
+// error's detail so clients reading the legacy {"error": "..."} shape keep working.
+type ErrorDocument struct {
+	Error  string        `json:"error"`
+	Errors []ErrorObject `json:"errors"`
+}
+
+// ErrorObject is a JSON:API-style error.
+type ErrorObject struct {
+	Status string       `json:"status"` // HTTP status as a string
+	Code   string       `json:"code"`
+	Title  string       `json:"title"`
+	Detail string       `json:"detail"`
+	Source *ErrorSource `json:"source,omitempty"`
+	Meta   ErrorMeta    `json:"meta"`
+}
+
+// ErrorSource points at the request field that caused the error.
+type ErrorSource struct {
+	Pointer string `json:"pointer"`
+}
+
+// ErrorMeta carries retry and tracing information.
+type ErrorMeta struct {
+	Retryable      bool   `json:"retryable"`
+	CorrelationID  string `json:"correlation_id,omitempty"`
+	UpstreamStatus int    `json:"upstream_status,omitempty"`
+}
+
+// respondError writes a single error with a code derived from status
+func respondError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	writeErrors(w, r, status, []ErrorObject{{
+		Code:   codeForStatus(status),
+		Detail: i18n.T(i18n.FromContext(r.Context()), message),
+		Meta:   ErrorMeta{Retryable: retryableStatus(status)},
+	}})
+}
+
+// respondValidationError reports field-level validation failures as 400
+func respondValidationError(w http.ResponseWriter, r *http.Request, errs validate.Errors) {
+	respondFieldErrors(w, r, http.StatusBadRequest, errs)
+}
+
+// respondFieldErrors writes one error per invalid field
+func respondFieldErrors(w http.ResponseWriter, r *http.Request, status int, errs validate.Errors) {
+	locale := i18n.FromContext(r.Context())
+	objs := make([]ErrorObject, len(errs))
+	for i, fe := range errs {
+		objs[i] = ErrorObject{
+			Code:   CodeValidationFailed,
+			Detail: fe.Field + ": " + i18n.T(locale, fe.Message),
+			Source: &ErrorSource{Pointer: fieldPointer(fe.Field)},
+		}
+	}
+	writeErrors(w, r, status, objs)
+}
+
+// fieldPointer turns a field path such as "line_items[0].unit_price" into a
+// JSON pointer, "/line_items/0/unit_price"
+func fieldPointer(field string) string {
+	field = strings.NewReplacer("[", ".", "]", "").Replace(field)
+	return "/" + strings.ReplaceAll(field, ".", "/")
+}
+
+// respondUpstreamError maps a failed service call to a structured error
+func respondUpstreamError(w http.ResponseWriter, r *http.Request, err error) {
+	var verrs validate.Errors
+	if errors.As(err, &verrs) {
+		respondValidationError(w, r, verrs)
+		return
+	}
+
+	status, obj := classifyError(err)
+	writeErrors(w, r, status, []ErrorObject{obj})
+}
+
+// classifyError decides the proxy status, code and retryability of a service error.
+func classifyError(err error) (int, ErrorObject) {
+	obj := ErrorObject{Detail: err.Error()}
+
+	var apiErr *sperrors.ErrorResponse
+	var netErr net.Error
+	switch {
+	case errors.As(err, &apiErr):
+		obj.Meta.UpstreamStatus = apiErr.StatusCode
+		switch {
+		case apiErr.StatusCode == http.StatusTooManyRequests:
+			obj.Code, obj.Meta.Retryable = CodeRateLimited, true
+			return http.StatusTooManyRequests, obj
+		case apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden:
+			// The proxy's own API key was rejected; callers cannot fix that
+			obj.Code = CodeUpstreamRejected
+			return http.StatusBadGateway, obj
+		case apiErr.StatusCode >= 400 && apiErr.StatusCode < 500:
+			obj.Code = CodeUpstreamRejected
+			return apiErr.StatusCode, obj
+		default:
+			obj.Code = CodeUpstreamError
+			obj.Meta.Retryable = retryableStatus(apiErr.StatusCode)
+			return http.StatusBadGateway, obj
+		}
+	case errors.Is(err, client.ErrSandboxMainnet):
+		obj.Code = CodeForbidden
+		return http.StatusForbidden, obj
+	case errors.Is(err, errors.ErrUnsupported):
+		// The upstream API has not advertised the endpoint
+		obj.Code = CodeUnsupported
+		return http.StatusNotImplemented, obj
+	case errors.Is(err, context.DeadlineExceeded):
+		obj.Code, obj.Meta.Retryable = CodeUpstreamTimeout, true
+		return http.StatusGatewayTimeout, obj
+	case errors.As(err, &netErr):
+		obj.Code, obj.Meta.Retryable = CodeUpstreamUnavailable, true
+		if netErr.Timeout() {
+			obj.Code = CodeUpstreamTimeout
+			return http.StatusGatewayTimeout, obj
+		}
+		return http.StatusBadGateway, obj
+	}
+
+	obj.Code = CodeInternal
+	return http.StatusInternalServerError, obj
+}
+
+func writeErrors(w http.ResponseWriter, r *http.Request, status int, objs []ErrorObject) {
+	correlationID := middleware.GetReqID(r.Context())
+	if correlationID != "" {
+		w.Header().Set("X-Correlation-ID", correlationID)
+	}
+
+	doc := ErrorDocument{Errors: objs}
+	title := i18n.T(i18n.FromContext(r.Context()), http.StatusText(status))
+	for i := range doc.Errors {
+		doc.Errors[i].Status = strconv.Itoa(status)
+		doc.Errors[i].Title = title
+		doc.Errors[i].Meta.CorrelationID = correlationID
+	}
+	if len(objs) > 0 {
+		doc.Error = objs[0].Detail
+	}
+	respondJSON(w, status, doc)
+}
+
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusUnprocessableEntity:
+		return CodeUnprocessable
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusBadGateway:
+		return CodeUpstreamError
+	case http.StatusServiceUnavailable:
+		return CodeUpstreamUnavailable
+	case http.StatusGatewayTimeout:
+		return CodeUpstreamTimeout
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeInvalidRequest
+}
+
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// RespondError writes an error document for middleware outside this package
+func RespondError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	respondError(w, r, status, message)
+}
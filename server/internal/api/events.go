@@ -0,0 +1,308 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"sol_privacy/internal/checkout"
+	"sol_privacy/internal/events"
+	"sol_privacy/internal/eventsink"
+	"sol_privacy/internal/settlement"
+	"sol_privacy/internal/validate"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// streamHeartbeat keeps idle event streams open through proxies.
+const streamHeartbeat = 25 * time.Second
+
+func newEventBus(h *Handler) *events.Bus {
+	var store events.Store
+	if path := h.storePath("EVENTS_DB", "events.json"); path != "" {
+		fs, err := events.NewFileStore(path)
+		if err != nil {
+			log.Printf("event deliveries not persisted: %v", err)
+		} else {
+			store = fs
+		}
+	}
+	var history events.History
+	if path := h.storePath("EVENTS_HISTORY_DB", "events-history.jsonl"); path != "" {
+		fh, err := events.NewFileHistory(path)
+		if err != nil {
+			log.Printf("event history not persisted: %v", err)
+		} else {
+			history = fh
+		}
+	}
+	return events.NewBus(events.Config{
+		Store:            store,
+		History:          history,
+		HistoryRetention: h.envDuration("EVENTS_HISTORY_RETENTION", 0),
+	})
+}
+
+// subscribeEvents registers the durable consumers of the bus. Call it once
+// every service they use exists.
+func (h *Handler) subscribeEvents() {
+	if h.paymentLinks != nil {
+		h.events.SubscribeDurable("paymentlinks", []string{events.CheckoutCompleted}, func(ctx context.Context, e events.Event) error {
+			var session checkout.Session
+			if err := e.Decode(&session); err != nil {
+				return err
+			}
+			h.paymentLinks.HandleCheckoutEvent(ctx, checkout.Event{Type: e.Type, Timestamp: e.Timestamp, Session: session})
+			return nil
+		})
+	}
+
+	// Follow-ups of queued settlements, as PaymentSettle does inline
+	h.events.SubscribeDurable("customers", []string{events.SettlementSucceeded}, func(ctx context.Context, e events.Event) error {
+		var j settlement.Job
+		if err := e.Decode(&j); err != nil {
+			return err
+		}
+		if commitment := j.Metadata[settlementCustomer]; commitment != "" {
+			tokenAmount, _ := strconv.ParseInt(j.Metadata[settlementTokenAmount], 10, 64)
+			h.recordSettledPurchase(ctx, commitment, j.Metadata[settlementMaxAmount], j.Metadata[settlementTokenMint], tokenAmount)
+		}
+		return nil
+	})
+	h.events.SubscribeDurable("holds", []string{events.SettlementSucceeded}, func(ctx context.Context, e events.Event) error {
+		var j settlement.Job
+		if err := e.Decode(&j); err != nil {
+			return err
+		}
+		if j.Request.Hold && j.Result != nil {
+			h.recordHold(ctx, j.Result.TxSig, j.Request.Resource, j.Metadata[settlementCustomer], j.Metadata[settlementPayer],
+				j.Request.PaymentRequirements.MaxAmountRequired, holdSeconds(j.Metadata[settlementHoldSeconds]))
+		}
+		return nil
+	})
+	if h.swaps != nil {
+		h.events.SubscribeDurable("autoswap", []string{events.SettlementSucceeded}, func(ctx context.Context, e events.Event) error {
+			var j settlement.Job
+			if err := e.Decode(&j); err != nil {
+				return err
+			}
+			if mint := j.Metadata[settlementTokenMint]; mint != "" && j.Result != nil && !j.Request.Hold {
+				tokenAmount, _ := strconv.ParseInt(j.Metadata[settlementTokenAmount], 10, 64)
+				// A failed conversion is recorded on its receipt, not retried
+				h.swaps.ConvertOnSettlement(ctx, j.Result.TxSig, mint, tokenAmount)
+			}
+			return nil
+		})
+	}
+
+	// Webhook fan-out, retried until the receiver accepts each event
+	if url := h.env("CHECKOUT_WEBHOOK_URL"); url != "" {
+		h.events.SubscribeDurable("webhook.checkout", []string{"checkout."}, events.WebhookHandler(url, nil))
+	}
+	if url := h.env("AUTO_SWAP_WEBHOOK_URL"); url != "" {
+		h.events.SubscribeDurable("webhook.swap", []string{"swap."}, events.WebhookHandler(url, nil))
+	}
+	if url := h.env("SHIPPING_WEBHOOK_URL"); url != "" {
+		h.events.SubscribeDurable("webhook.shipping", []string{"shipping."}, events.WebhookHandler(url, nil))
+	}
+	if url := h.env("EVENTS_WEBHOOK_URL"); url != "" {
+		h.events.SubscribeDurable("webhook.events", eventTypes(h.env("EVENTS_WEBHOOK_TYPES")), events.WebhookHandler(url, nil))
+	}
+
+	// Chat notifications to the chats linked to the paid wallet
+	if h.bots != nil {
+		h.events.SubscribeDurable("bots", []string{events.SettlementSucceeded, events.SettlementFailed}, h.bots.HandleEvent)
+	}
+
+	// Email and SMS to merchants, each channel retried on its own
+	if h.notifier != nil {
+		for _, channel := range h.notifier.Channels() {
+			h.events.SubscribeDurable("notify."+channel, []string{"settlement.", "checkout."}, h.notifier.Handler(channel))
+		}
+	}
+
+	// Operational alerts to Slack and Discord, each channel retried on its own
+	if h.alerts != nil {
+		for _, channel := range h.alerts.Channels() {
+			h.events.SubscribeDurable("alerts."+channel, h.alerts.EventTypes(channel), h.alerts.Handler(channel))
+		}
+	}
+
+	if path := h.env("EVENTS_AUDIT_LOG"); path != "" {
+		h.events.SubscribeDurable("audit", nil, events.AppendHandler(path))
+	}
+
+	// Kafka or NATS, for merchants' data pipelines
+	if url := h.env("EVENT_SINK_URL"); url != "" {
+		sink, err := eventsink.Open(url, eventsink.Config{
+			Topic:  h.env("EVENT_SINK_TOPIC"),
+			Format: eventsink.Format(strings.ToLower(h.env("EVENT_SINK_FORMAT"))),
+		})
+		if err != nil {
+			log.Printf("event sink disabled: %v", err)
+		} else {
+			h.events.SubscribeDurable("sink", eventTypes(h.env("EVENT_SINK_TYPES")), sink.Handle)
+		}
+	}
+}
+
+// eventTypes splits a comma-separated list of event type patterns.
+func eventTypes(list string) []string {
+	var out []string
+	for _, t := range strings.Split(list, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// EventStream handles streaming bus events as server-sent events, filtered by
+// ?types=checkout.,swap.completed. The stream ends just before the request
+// timeout; EventSource clients reconnect on their own.
+func (h *Handler) EventStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondError(w, r, http.StatusInternalServerError, "Streaming unsupported")
+		return
+	}
+
+	stream, cancel := h.events.Subscribe(eventTypes(r.URL.Query().Get("types"))...)
+	defer cancel()
+
+	ctx := r.Context()
+	if deadline, ok := ctx.Deadline(); ok {
+		var stop context.CancelFunc
+		ctx, stop = context.WithDeadline(ctx, deadline.Add(-time.Second))
+		defer stop()
+	}
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 1000\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		case e, ok := <-stream:
+			if !ok {
+				return
+			}
+			data, _ := json.Marshal(e)
+			fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+		}
+		flusher.Flush()
+	}
+}
+
+// EventSubscribers handles reporting the delivery backlog of durable subscribers
+func (h *Handler) EventSubscribers(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.events.Stats()
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"subscribers": stats,
+	})
+}
+
+// EventList handles paging through past bus events, oldest first, filtered by
+// ?from= (RFC 3339 or Unix seconds) and ?type=checkout.,swap.completed
+func (h *Handler) EventList(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := events.HistoryQuery{
+		Types:  eventTypes(q.Get("type")),
+		Cursor: q.Get("cursor"),
+	}
+	if from := q.Get("from"); from != "" {
+		t, err := parseEventTime(from)
+		if err != nil {
+			respondValidationError(w, r, validate.Errors{{Field: "from", Message: "must be an RFC 3339 time or Unix timestamp"}})
+			return
+		}
+		query.From = t
+	}
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > events.MaxHistoryPage {
+			respondError(w, r, http.StatusBadRequest, "limit must be between 1 and 1000")
+			return
+		}
+		query.Limit = n
+	}
+
+	page, err := h.events.Events(query)
+	if err != nil {
+		respondEventError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, page)
+}
+
+// EventGet handles fetching a past bus event
+func (h *Handler) EventGet(w http.ResponseWriter, r *http.Request) {
+	e, err := h.events.Event(chi.URLParam(r, "id"))
+	if err != nil {
+		respondEventError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, e)
+}
+
+// EventRedeliver handles queueing a past event for its durable subscribers
+// again, or only for ?subscriber=, so they can rebuild state after an outage
+func (h *Handler) EventRedeliver(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	subscribers, err := h.events.Redeliver(r.Context(), id, r.URL.Query().Get("subscriber"))
+	if err != nil {
+		respondEventError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, map[string]interface{}{
+		"id":          id,
+		"subscribers": subscribers,
+	})
+}
+
+// parseEventTime reads an RFC 3339 time or Unix timestamp.
+func parseEventTime(s string) (int64, error) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Unix(), nil
+}
+
+func respondEventError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, events.ErrEventNotFound), errors.Is(err, events.ErrUnknownSubscriber):
+		respondError(w, r, http.StatusNotFound, err.Error())
+	case errors.Is(err, events.ErrInvalidCursor):
+		respondValidationError(w, r, validate.Errors{{Field: "cursor", Message: "is not a cursor returned by a previous page"}})
+	default:
+		respondUpstreamError(w, r, err)
+	}
+}
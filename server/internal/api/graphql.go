@@ -0,0 +1,154 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"sol_privacy/internal/graphql"
+	"sol_privacy/internal/merchant"
+	"sol_privacy/internal/receipt"
+	"sol_privacy/internal/validate"
+	"sol_privacy/internal/webhook"
+)
+
+// GraphQL handles merchant analytics queries
+func (h *Handler) GraphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphql.Request
+	if r.Method == http.MethodGet {
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if vars := r.URL.Query().Get("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				respondError(w, r, http.StatusBadRequest, "Invalid variables")
+				return
+			}
+		}
+	} else if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.Query == "" {
+		respondError(w, r, http.StatusBadRequest, "Missing required field: query")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, h.graphql.Execute(r.Context(), req))
+}
+
+// newGraphQLSchema exposes merchant earnings, analytics, receipts and webhook logs
+func (h *Handler) newGraphQLSchema() *graphql.Schema {
+	s := graphql.NewSchema()
+
+	s.Field("earnings", func(ctx context.Context, args graphql.Args) (interface{}, error) {
+		return h.client.Merchant.GetEarnings(ctx)
+	})
+
+	s.Field("analytics", func(ctx context.Context, args graphql.Args) (interface{}, error) {
+		req, err := analyticsRequest(args)
+		if err != nil {
+			return nil, err
+		}
+		resp, _, err := h.getAnalytics(ctx, req, noCache(args))
+		return resp, err
+	})
+
+	s.Field("analyticsTimeSeries", func(ctx context.Context, args graphql.Args) (interface{}, error) {
+		first, offset, err := graphql.Page(args)
+		if err != nil {
+			return nil, err
+		}
+		req, err := analyticsRequest(args)
+		if err != nil {
+			return nil, err
+		}
+		resp, _, err := h.getAnalytics(ctx, req, noCache(args))
+		if err != nil {
+			return nil, err
+		}
+		series := resp.TimeSeries
+		total := len(series)
+		if offset > total {
+			offset = total
+		}
+		end := offset + first
+		if end > total {
+			end = total
+		}
+		return graphql.NewConnection(series[offset:end], offset, total), nil
+	})
+
+	s.Field("receipts", func(ctx context.Context, args graphql.Args) (interface{}, error) {
+		wallet := args.String("wallet")
+		if err := validate.Address(wallet); err != nil {
+			return nil, fmt.Errorf("argument wallet %v", err)
+		}
+		first, offset, err := graphql.Page(args)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := h.client.Receipt.ListUserReceipts(ctx, wallet, receipt.ListUserReceiptsRequest{
+			Limit:  first,
+			Offset: offset,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return graphql.NewConnection(resp.Receipts, offset, resp.TotalCount), nil
+	})
+
+	s.Field("webhookLogs", func(ctx context.Context, args graphql.Args) (interface{}, error) {
+		first, offset, err := graphql.Page(args)
+		if err != nil {
+			return nil, err
+		}
+		success, err := args.Bool("success")
+		if err != nil {
+			return nil, err
+		}
+		resp, err := h.client.Webhook.GetLogs(ctx, webhook.LogsRequest{
+			WebhookID: args.String("webhookId"),
+			Event:     args.String("event"),
+			Success:   success,
+			Limit:     first,
+			Offset:    offset,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return graphql.NewConnection(resp.Logs, offset, resp.TotalCount), nil
+	})
+
+	s.Field("webhookStats", func(ctx context.Context, args graphql.Args) (interface{}, error) {
+		return h.client.Webhook.GetStats(ctx)
+	})
+
+	return s
+}
+
+// noCache reads the noCache argument of analytics fields
+func noCache(args graphql.Args) bool {
+	b, _ := args.Bool("noCache")
+	return b != nil && *b
+}
+
+func analyticsRequest(args graphql.Args) (merchant.AnalyticsRequest, error) {
+	req := merchant.AnalyticsRequest{
+		StartDate: args.String("startDate"),
+		EndDate:   args.String("endDate"),
+		Interval:  args.String("interval"),
+	}
+	cohorts, err := args.Bool("cohorts")
+	if err != nil {
+		return req, err
+	}
+	tokenSeries, err := args.Bool("tokenSeries")
+	if err != nil {
+		return req, err
+	}
+	req.Cohorts = cohorts != nil && *cohorts
+	req.TokenSeries = tokenSeries != nil && *tokenSeries
+	req.Percentiles, err = args.Floats("percentiles")
+	return req, err
+}
@@ -1,12 +1,56 @@
 package api
 
+//go:generate go run sol_privacy/cmd/genclient -out ../../../frontend/src/services/shadowpay.gen.ts
+
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"log"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	shadowpay "sol_privacy"
+	"sol_privacy/internal/accountwatch"
+	"sol_privacy/internal/addressbook"
+	"sol_privacy/internal/alerts"
+	"sol_privacy/internal/announce"
+	"sol_privacy/internal/artifacts"
+	"sol_privacy/internal/authorization"
+	"sol_privacy/internal/bots"
+	"sol_privacy/internal/cache"
+	"sol_privacy/internal/chaos"
+	"sol_privacy/internal/checkout"
+	"sol_privacy/internal/client"
+	"sol_privacy/internal/customers"
+	"sol_privacy/internal/events"
+	"sol_privacy/internal/graphql"
+	"sol_privacy/internal/hold"
+	"sol_privacy/internal/invoice"
+	"sol_privacy/internal/jupiter"
+	"sol_privacy/internal/ledger"
+	"sol_privacy/internal/messages"
+	"sol_privacy/internal/merchant"
+	"sol_privacy/internal/notify"
+	"sol_privacy/internal/openapi"
+	"sol_privacy/internal/paymentlink"
+	"sol_privacy/internal/reconcile"
+	"sol_privacy/internal/registry"
+	"sol_privacy/internal/reqsign"
+	"sol_privacy/internal/resolve"
+	"sol_privacy/internal/session"
+	"sol_privacy/internal/settlement"
+	"sol_privacy/internal/shipping"
+	"sol_privacy/internal/swap"
+	"sol_privacy/internal/tax"
+	"sol_privacy/internal/types"
 	"sol_privacy/internal/umbra"
+	"sol_privacy/internal/warehouse"
+	"sol_privacy/internal/webhook"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -16,51 +60,373 @@ type Handler struct {
 	client      *shadowpay.ShadowPay
 	umbraClient *umbra.Client
 	umbraEnabled bool
+	sessions    *session.Manager
+	swaps       *swap.Service
+	graphql     *graphql.Schema
+	checkout    *checkout.Manager
+	paymentLinks *paymentlink.Service
+	invoices    *invoice.Service
+	customers   *customers.Service
+	messages    *messages.Service
+	shipping    *shipping.Service
+	analytics   *cache.Cache[*merchant.AnalyticsResponse]
+	fees        *ledger.Service
+	tax         *tax.Service
+	webhookSecrets webhook.SecretStore
+	addressBook *addressbook.Book
+	registry    *registry.Service
+	resolver    *resolve.Resolver
+	dataDir     string
+	tenant      string            // Empty for the main merchant
+	tenantEnv   map[string]string // The tenant's own settings
+	signatures  *reqsign.Verifier // Nil when request signing is off
+	signingRequired bool
+	payerSignatures *reqsign.Verifier // Checks payers' hold disputes, even when signing is off
+	merchantWallets []string          // MERCHANT_WALLETS, whose sessions act for the merchant
+	multisigNonces *authorization.UsedNonces
+	sandbox     bool              // A sandbox upstream is configured
+	secrets     map[string]string
+	settlements *settlement.Queue
+	reconciler  *reconcile.Reconciler
+	holds       *hold.Manager
+	events      *events.Bus
+	warehouse   *warehouse.Exporter   // Nil unless WAREHOUSE_URL is set
+	accounts    *accountwatch.Watcher // Nil unless pool or escrow accounts are watched
+	announcements *announce.Indexer   // Nil unless UMBRA_PROGRAM_ID is set
+	artifacts   *artifacts.Cache      // Nil unless ARTIFACTS_DIR is set
+	bots        *bots.Bot             // Nil unless a bot token is set
+	telegram    *bots.Telegram
+	discord     *bots.Discord
+	notifier    *notify.Notifier      // Nil unless SMTP or Twilio is configured
+	alerts      *alerts.Alerter       // Nil unless an alert channel is configured
+	rootWatch   time.Duration         // Zero unless ShadowID root changes are published
+	bodySchemas *openapi.Spec         // Nil if the spec could not be read
+	maxBody     int64
+}
+
+// Options configures a Handler beyond its API key
+type Options struct {
+	UmbraURL string // Enables the Umbra integration when set
+	DataDir  string // Default directory of stores whose *_DB variable is unset
+
+	// RequestSigning is SigningOff (default), SigningOptional to verify wallet
+	// signatures when present, or SigningRequire to demand them on sensitive
+	// routes. Signatures older than SignatureMaxAge are rejected.
+	RequestSigning  string
+	SignatureMaxAge time.Duration
+
+	// Secrets holds values decrypted from a sealed secrets file, by
+	// environment variable name. The environment takes precedence.
+	Secrets map[string]string
+
+	// Alerts posts operational alerts to the channels it lists; the bus and
+	// authorization service are filled in by the handler.
+	Alerts alerts.Config
+
+	// Tenant names another merchant served by the same proxy. Its stores
+	// live in their own directory under DataDir, and settings that belong to
+	// one merchant (see tenantScoped) come from TenantEnv only, so its data,
+	// events and bots are kept apart from the main merchant's.
+	Tenant    string
+	TenantEnv map[string]string
 }
 
+// defaultUpstreamCacheTTL is how long cached upstream reads are served before
+// they are revalidated, overridden by UPSTREAM_CACHE_TTL
+const defaultUpstreamCacheTTL = 15 * time.Second
+
 // NewHandler creates a new API handler
-func NewHandler(apiKey string) *Handler {
+func NewHandler(apiKey string, opts Options) *Handler {
 	h := &Handler{
-		client: shadowpay.New(apiKey),
+		dataDir:   opts.DataDir,
+		secrets:   opts.Secrets,
+		tenant:    opts.Tenant,
+		tenantEnv: opts.TenantEnv,
+	}
+	if h.tenant != "" && h.dataDir != "" {
+		h.dataDir = filepath.Join(h.dataDir, "tenants", h.tenant)
+	}
+	// Upstream reads that rarely change are cached and revalidated by ETag
+	upstream := []client.Option{client.WithResponseCache(client.CacheConfig{
+		TTL: h.envDuration("UPSTREAM_CACHE_TTL", defaultUpstreamCacheTTL),
+	})}
+	// Comma-separated regional base URLs, tried by health and latency
+	if urls := h.env("SHADOWPAY_BASE_URLS"); urls != "" {
+		upstream = append(upstream, client.WithBaseURLs(strings.Split(urls, ",")...))
+	}
+	// Injected upstream faults, for testing integrations against this proxy
+	if spec := h.env("SHADOWPAY_CHAOS"); spec != "" {
+		if inj, err := newChaosInjector(spec); err != nil {
+			log.Printf("chaos injection disabled: %v", err)
+		} else {
+			log.Printf("WARNING: injecting upstream faults (SHADOWPAY_CHAOS=%s); never enable this in production", spec)
+			upstream = append(upstream, client.WithMiddleware(inj.Middleware()))
+		}
+	}
+	// A sandbox upstream for test traffic, or for all of it
+	if opt, ok := h.sandboxOption(); ok {
+		h.sandbox = true
+		upstream = append(upstream, opt)
+	}
+	h.client = shadowpay.New(apiKey, upstream...)
+	// How typed addresses and commitments print in logs
+	switch h.env("LOG_REDACT") {
+	case "short":
+		types.SetRedaction(types.RedactShort)
+	case "all":
+		types.SetRedaction(types.RedactAll)
+	}
+	h.sessions = session.NewManager(session.Config{
+		Secret: []byte(h.env("SESSION_SECRET")),
+	})
+
+	if opts.RequestSigning == SigningOptional || opts.RequestSigning == SigningRequire {
+		h.signatures = reqsign.NewVerifier(opts.SignatureMaxAge)
+		h.signingRequired = opts.RequestSigning == SigningRequire
+	}
+	h.payerSignatures = h.signatures
+	if h.payerSignatures == nil {
+		h.payerSignatures = reqsign.NewVerifier(opts.SignatureMaxAge)
+	}
+	for _, wallet := range strings.Split(h.env("MERCHANT_WALLETS"), ",") {
+		if wallet = strings.TrimSpace(wallet); wallet != "" {
+			h.merchantWallets = append(h.merchantWallets, wallet)
+		}
 	}
 
 	// Initialize Umbra client if URL is configured
-	if umbraURL := os.Getenv("UMBRA_API_URL"); umbraURL != "" {
+	if opts.UmbraURL != "" {
 		h.umbraClient = umbra.NewClient(umbra.Config{
-			BaseURL: umbraURL,
+			BaseURL: opts.UmbraURL,
 		})
 		h.umbraEnabled = true
 	}
 
+	h.maxBody = h.maxBodyBytes()
+	h.bodySchemas = newBodySchemas()
+	h.events = newEventBus(h)
+	h.analytics = newAnalyticsCache(h)
+	h.graphql = h.newGraphQLSchema()
+	h.checkout = newCheckoutManager(h)
+	h.paymentLinks = newPaymentLinkService(h)
+	h.invoices = newInvoiceService(h)
+	h.customers = newCustomerService(h)
+	h.messages = newMessageService(h)
+	h.shipping = newShippingService(h)
+	h.fees = newFeeLedger(h)
+	h.tax = newTaxLedger(h)
+	h.webhookSecrets = newWebhookSecrets(h)
+	h.addressBook = newAddressBook(h)
+	h.registry = newRegistry(h)
+	h.resolver = newResolver(h)
+	h.settlements = newSettlementQueue(h)
+	h.holds = newHoldManager(h)
+	h.multisigNonces = newMultisigNonces(h)
+	h.reconciler = newReconciler(h)
+	h.warehouse = newWarehouseExporter(h)
+	h.accounts = newAccountWatcher(h)
+	h.announcements = newAnnouncementIndexer(h)
+	h.artifacts = newArtifactCache(h)
+	h.bots = newBots(h)
+	h.notifier = newNotifier(h)
+	h.alerts = newAlerter(h, opts.Alerts)
+	if h.rootWatch = h.envDuration("SHADOWID_ROOT_WATCH", 0); h.rootWatch > 0 {
+		h.client.ShadowID.SetRootPollInterval(h.rootWatch)
+	}
+	// Cohort, token series and percentile analytics fall back to recorded purchases
+	h.client.Merchant.SetRecordSource(h.customers)
+
+	// Initialize auto-swap on settlement if a target asset is configured
+	if target := h.env("AUTO_SWAP_TARGET"); target != "" {
+		h.swaps = newSwapService(h, target)
+	}
+	h.subscribeEvents()
+
 	return h
 }
 
-// Routes returns all API routes
+// Run settles queued payments, releases timed-out holds, delivers events,
+// reconciles settlements, exports to the warehouse, watches pool and escrow
+// balances, indexes stealth announcements, checks for alerts and watches
+// the ShadowID root until ctx is done.
+func (h *Handler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		h.events.Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		h.reconciler.Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		h.settlements.Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		h.holds.Run(ctx)
+	}()
+	if h.warehouse != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.warehouse.Run(ctx)
+		}()
+	}
+	if h.accounts != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.accounts.Run(ctx)
+		}()
+	}
+	if h.announcements != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.announcements.Run(ctx)
+		}()
+	}
+	if h.alerts != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.alerts.Run(ctx)
+		}()
+	}
+	if h.rootWatch > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.publishRootChanges(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// env returns the environment variable name, or its sealed secret if unset.
+// A tenant's own settings come first, and it does not inherit settings that
+// belong to one merchant.
+func (h *Handler) env(name string) string {
+	if h.tenant != "" {
+		if v := h.tenantEnv[name]; v != "" || tenantScoped(name) {
+			return v
+		}
+	}
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return h.secrets[name]
+}
+
+// tenantScoped reports whether a setting belongs to one merchant: where its
+// data is kept, where its events and bot messages go, the keys that sign or
+// encrypt them and the wallet it swaps into.
+func tenantScoped(name string) bool {
+	for _, prefix := range []string{"ACCOUNT_WATCH_", "AUTO_SWAP_", "DISCORD_", "EVENT_SINK_", "EVENTS_", "MERCHANT_", "TELEGRAM_", "WAREHOUSE_"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	for _, suffix := range []string{"_DB", "_KEY", "_SECRET", "_WEBHOOK_URL"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// storePath returns the file a store persists to: the path in env if set,
+// otherwise name inside the data directory, or "" to keep it in memory.
+func (h *Handler) storePath(env, name string) string {
+	if path := h.env(env); path != "" {
+		return path
+	}
+	if h.dataDir == "" {
+		return ""
+	}
+	if err := os.MkdirAll(h.dataDir, 0o700); err != nil {
+		log.Printf("data directory unavailable: %v", err)
+		return ""
+	}
+	return filepath.Join(h.dataDir, name)
+}
+
+func newChaosInjector(spec string) (*chaos.Injector, error) {
+	config, err := chaos.Parse(spec)
+	if err != nil {
+		return nil, err
+	}
+	return chaos.New(config)
+}
+
+func newSwapService(h *Handler, target string) *swap.Service {
+	config := swap.Config{
+		MerchantWallet: h.env("AUTO_SWAP_MERCHANT_WALLET"),
+	}
+	switch target {
+	case "USDC":
+		config.TargetMint = jupiter.USDCMint
+	case "SOL":
+		config.TargetMint = jupiter.SOLMint
+	default:
+		config.TargetMint = target
+	}
+	if bps, err := strconv.Atoi(h.env("AUTO_SWAP_MAX_SLIPPAGE_BPS")); err == nil {
+		config.MaxSlippageBps = bps
+	}
+
+	// AUTO_SWAP_WEBHOOK_URL consumes these from the bus
+	onEvent := func(ctx context.Context, event swap.Event) {
+		h.events.Emit(ctx, event.Type, "swap", event.Receipt)
+	}
+
+	svc, err := swap.NewService(nil, config, onEvent)
+	if err != nil {
+		log.Printf("auto-swap disabled: %v", err)
+		return nil
+	}
+	return svc
+}
+
+// Routes returns all API routes. Routes that move funds, decrypt data or
+// change spending authorizations verify wallet request signatures when
+// signing is enabled. Slowly changing reads carry ETags for conditional GETs.
 func (h *Handler) Routes() chi.Router {
 	r := chi.NewRouter()
+	r.Use(h.checkBody)
+	r.Use(h.sandboxHeader)
 
 	// Payment routes
 	r.Route("/payment", func(r chi.Router) {
-		r.Post("/deposit", h.PaymentDeposit)
-		r.Post("/withdraw", h.PaymentWithdraw)
+		r.With(h.requireSignature(bodyField("wallet_address"))).Post("/deposit", h.PaymentDeposit)
+		r.With(h.requireSignature(bodyField("wallet_address"))).Post("/withdraw", h.PaymentWithdraw)
 		r.Post("/prepare", h.PaymentPrepare)
 		r.Post("/authorize", h.PaymentAuthorize)
 		r.Post("/verify-access", h.PaymentVerifyAccess)
+		r.Post("/refresh-access", h.PaymentRefreshAccess)
 		r.Post("/settle", h.PaymentSettle)
+		r.Get("/status/{paymentHash}", h.PaymentStatus)
+		r.With(conditional).Get("/supported", h.PaymentSupported)
 	})
 
 	// Pool routes
 	r.Route("/pool", func(r chi.Router) {
 		r.Get("/balance/{wallet}", h.PoolBalance)
-		r.Post("/deposit", h.PoolDeposit)
-		r.Post("/withdraw", h.PoolWithdraw)
-		r.Get("/deposit-address", h.PoolDepositAddress)
+		r.With(h.requireSignature(bodyField("wallet_address"))).Post("/deposit", h.PoolDeposit)
+		r.With(h.requireSignature(bodyField("wallet_address"))).Post("/withdraw", h.PoolWithdraw)
+		r.Get("/withdraw/quote", h.PoolWithdrawQuote)
+		r.With(conditional).Get("/deposit-address", h.PoolDepositAddress)
 	})
 
 	// Token routes
 	r.Route("/token", func(r chi.Router) {
-		r.Get("/list", h.TokenList)
+		r.With(conditional).Get("/list", h.TokenList)
+		r.With(conditional).Get("/list/detailed", h.TokenListDetailed)
 		r.Post("/add", h.TokenAdd)
+		r.Post("/validate-payment", h.TokenValidatePayment)
 		r.Put("/{mint}", h.TokenUpdate)
 		r.Delete("/{mint}", h.TokenRemove)
 	})
@@ -69,12 +435,24 @@ func (h *Handler) Routes() chi.Router {
 	r.Route("/merchant", func(r chi.Router) {
 		r.Get("/earnings", h.MerchantEarnings)
 		r.Post("/analytics", h.MerchantAnalytics)
-		r.Post("/withdraw", h.MerchantWithdraw)
+		r.With(h.requireSignature(bodyField("destination"))).Post("/withdraw", h.MerchantWithdraw)
+	})
+
+	// Marketplace sub-merchants
+	r.Route("/platform", func(r chi.Router) {
+		r.Post("/merchants", h.PlatformCreate)
+		r.Get("/merchants", h.PlatformList)
+		r.Get("/merchants/{id}", h.PlatformGet)
+		r.Patch("/merchants/{id}", h.PlatformUpdate)
+		r.Post("/merchants/{id}/keys", h.PlatformCreateKey)
+		r.Get("/merchants/{id}/earnings", h.PlatformMerchantEarnings)
+		r.Post("/merchants/{id}/route", h.PlatformRoute)
+		r.Get("/earnings", h.PlatformEarnings)
 	})
 
 	// Privacy routes
 	r.Route("/privacy", func(r chi.Router) {
-		r.Post("/decrypt", h.PrivacyDecrypt)
+		r.With(h.requireSignature(bodyField("wallet_address"))).Post("/decrypt", h.PrivacyDecrypt)
 	})
 
 	// Webhook routes
@@ -85,36 +463,258 @@ func (h *Handler) Routes() chi.Router {
 		r.Get("/logs", h.WebhookLogs)
 		r.Get("/stats", h.WebhookStats)
 		r.Post("/deactivate", h.WebhookDeactivate)
+		r.Post("/verify-signature", h.WebhookVerifySignature)
 	})
 
 	// ShadowID routes
 	r.Route("/shadowid", func(r chi.Router) {
 		r.Post("/auto-register", h.ShadowIDAutoRegister)
 		r.Post("/register", h.ShadowIDRegister)
+		r.Post("/register-batch", h.ShadowIDRegisterBatch)
+		r.Get("/leaves", h.ShadowIDSync)
 		r.Post("/proof", h.ShadowIDProof)
-		r.Get("/root", h.ShadowIDRoot)
+		r.With(conditional).Get("/root", h.ShadowIDRoot)
+		r.Get("/roots", h.ShadowIDRoots)
 		r.Get("/status/{commitment}", h.ShadowIDStatus)
 	})
 
+	// Consumed-nullifier set, for auditors
+	r.Get("/nullifiers", h.NullifierExport)
+
 	// Authorization routes
 	r.Route("/authorization", func(r chi.Router) {
-		r.Post("/authorize", h.AuthorizationAuthorize)
+		r.With(h.requireSignature(bodyField("user_wallet"))).Post("/authorize", h.AuthorizationAuthorize)
+		r.With(h.requireSignature(bodyField("treasury_wallet"))).Post("/authorize-multisig", h.AuthorizationMultisig)
 		r.Get("/list/{wallet}", h.AuthorizationList)
-		r.Post("/revoke", h.AuthorizationRevoke)
+		r.Get("/usage/{wallet}/{service}", h.AuthorizationUsage)
+		r.With(h.requireSignature(bodyField("user_wallet"))).Post("/update", h.AuthorizationUpdate)
+		r.With(h.requireSignature(bodyField("user_wallet"))).Post("/revoke", h.AuthorizationRevoke)
+	})
+
+	// Checkout sessions and hosted payment pages
+	r.Route("/checkout", func(r chi.Router) {
+		r.Post("/sessions", h.CheckoutCreate)
+		r.Get("/sessions", h.CheckoutList)
+		r.Get("/sessions/{id}", h.CheckoutGet)
+		r.Post("/sessions/{id}/pay", h.CheckoutPay)
+		r.Post("/sessions/{id}/complete", h.CheckoutComplete)
+		r.Post("/sessions/{id}/cancel", h.CheckoutCancel)
+		r.Get("/assets/{name}", h.CheckoutAsset)
+		r.Get("/{id}", h.CheckoutPage)
+	})
+
+	// Payment links
+	r.Route("/links", func(r chi.Router) {
+		r.Post("/", h.PaymentLinkCreate)
+		r.Get("/", h.PaymentLinkList)
+		r.Get("/{code}", h.PaymentLinkGet)
+		r.Get("/{code}/stats", h.PaymentLinkStats)
+		r.Post("/{code}/deactivate", h.PaymentLinkDeactivate)
+	})
+	r.Get("/l/{code}", h.PaymentLinkResolve)
+
+	// Invoices
+	r.Route("/invoices", func(r chi.Router) {
+		r.Post("/", h.InvoiceCreate)
+		r.Get("/", h.InvoiceList)
+		r.Post("/webhook", h.InvoiceWebhook)
+		r.Get("/{id}", h.InvoiceGet)
+		r.Patch("/{id}", h.InvoiceUpdate)
+		r.Get("/{id}/pdf", h.InvoicePDF)
+		r.Post("/{id}/void", h.InvoiceVoid)
+		r.Post("/{id}/refresh", h.InvoiceRefresh)
+	})
+
+	// Customer aliases keyed by ShadowID commitment
+	r.Route("/customers", func(r chi.Router) {
+		r.Get("/", h.CustomerList)
+		r.Get("/stats", h.CustomerStats)
+		r.Get("/{commitment}", h.CustomerGet)
+		r.Put("/{commitment}", h.CustomerSetAlias)
+		r.Delete("/{commitment}", h.CustomerDelete)
+		r.Post("/{commitment}/purchases", h.CustomerRecordPurchase)
+	})
+
+	// End-to-end encrypted merchant-customer messages
+	r.Route("/messages", func(r chi.Router) {
+		r.Get("/", h.MessageList)
+		r.Post("/", h.MessageStart)
+		r.Get("/{id}", h.MessageGet)
+		r.Post("/{id}", h.MessagePost)
+	})
+
+	// Shipping addresses held until settlement
+	r.Route("/shipping", func(r chi.Router) {
+		r.Post("/", h.ShippingCreate)
+		r.Get("/{commitment}", h.ShippingGet)
+	})
+
+	// Event bus streams, history and delivery backlog
+	r.Route("/events", func(r chi.Router) {
+		r.Get("/", h.EventList)
+		r.Get("/stream", h.EventStream)
+		r.Get("/subscribers", h.EventSubscribers)
+		r.Get("/{id}", h.EventGet)
+		r.Post("/{id}/redeliver", h.EventRedeliver)
+	})
+
+	// Durable settlement queue
+	r.Route("/settlements", func(r chi.Router) {
+		r.Post("/", h.SettlementEnqueue)
+		r.Get("/", h.SettlementList)
+		r.Get("/stats", h.SettlementStats)
+		r.Get("/{id}", h.SettlementGet)
+		r.Post("/{id}/retry", h.SettlementRetry)
+	})
+
+	// Conditional payments held until delivery is confirmed. The payer may
+	// dispute with a request signed by the paying wallet; everything else
+	// takes a session of one of the merchant's wallets.
+	r.Route("/holds", func(r chi.Router) {
+		r.With(h.requireMerchant).Get("/", h.HoldList)
+		r.With(h.requireMerchant).Get("/{id}", h.HoldGet)
+		r.With(h.requireMerchant).Post("/{id}/release", h.HoldRelease)
+		r.With(h.requireMerchant).Post("/{id}/refund", h.HoldRefund)
+		r.Post("/{id}/dispute", h.HoldDispute)
+	})
+
+	// Settlements and withdrawals checked against the cluster and receipts
+	r.Route("/reconciliation", func(r chi.Router) {
+		r.Get("/", h.ReconciliationReport)
+		r.Post("/run", h.ReconciliationRun)
+	})
+
+	// Postgres analytics warehouse export
+	r.Route("/warehouse", func(r chi.Router) {
+		r.Get("/status", h.WarehouseStatus)
+		r.Post("/export", h.WarehouseExport)
+	})
+
+	// Fee ledger
+	r.Route("/fees", func(r chi.Router) {
+		r.Post("/", h.FeeRecord)
+		r.Get("/", h.FeeList)
+		r.Post("/network", h.FeeRecordNetwork)
+		r.Get("/summary", h.FeeSummary)
+		r.Get("/export", h.FeeExport)
+	})
+
+	// Cost basis and realized gains of pool and escrow movements
+	r.Route("/tax", func(r chi.Router) {
+		r.Post("/movements", h.TaxRecord)
+		r.Get("/movements", h.TaxMovements)
+		r.Get("/lots", h.TaxLots)
+		r.Get("/report", h.TaxReport)
+		r.Get("/report/export", h.TaxExport)
 	})
 
+	// Labeled wallets, commitments and stealth meta-addresses
+	r.Route("/addressbook", func(r chi.Router) {
+		r.Get("/", h.AddressBookSearch)
+		r.Post("/", h.AddressBookAdd)
+		r.Get("/recent", h.AddressBookRecent)
+		r.Post("/recent", h.AddressBookTouch)
+		r.Get("/{id}", h.AddressBookGet)
+		r.Patch("/{id}", h.AddressBookUpdate)
+		r.Delete("/{id}", h.AddressBookRemove)
+	})
+
+	// Stealth meta-addresses published under handles
+	r.Route("/registry", func(r chi.Router) {
+		r.Post("/", h.RegistryRegister)
+		r.Get("/{key}", h.RegistryLookup)
+		r.Post("/{key}/unregister", h.RegistryUnregister)
+	})
+
+	// Solana Name Service (.sol) lookups
+	r.Route("/resolve", func(r chi.Router) {
+		r.Get("/{name}", h.ResolveName)
+		r.Get("/reverse/{address}", h.ResolveReverse)
+	})
+
+	// GraphQL merchant analytics
+	r.Get("/graphql", h.GraphQL)
+	r.Post("/graphql", h.GraphQL)
+
+	// Wallet connect routes
+	r.Route("/session", func(r chi.Router) {
+		r.Post("/nonce", h.SessionNonce)
+		r.Post("/connect", h.SessionConnect)
+		r.With(h.requireSession).Get("/me", h.SessionMe)
+	})
+
+	// Wallet-scoped routes (require a session bound to the wallet)
+	r.Group(func(r chi.Router) {
+		r.Use(h.requireSession)
+		r.With(requireWalletOwner).Get("/escrow/balance/{wallet}", h.EscrowBalance)
+		r.With(requireWalletOwner).Get("/escrow/balances/{wallet}", h.EscrowBalances)
+		r.With(requireWalletOwner).Get("/receipts/{wallet}", h.ReceiptsList)
+		r.With(requireWalletOwner).Post("/receipts/{wallet}/nft", h.ReceiptNFTMint)
+		r.With(requireWalletOwner).Post("/shipping/{commitment}/reveal/{wallet}", h.ShippingReveal)
+	})
+
+	// Auto-swap routes (only if auto-swap is enabled)
+	if h.swaps != nil {
+		r.Route("/swap", func(r chi.Router) {
+			r.Get("/receipts", h.SwapReceipts)
+			r.Get("/receipts/{id}", h.SwapReceipt)
+			r.Post("/confirm", h.SwapConfirm)
+		})
+	}
+
+	// Chat bot routes (only if a bot token is set)
+	if h.bots != nil {
+		r.Route("/bots", func(r chi.Router) {
+			if h.telegram != nil {
+				r.Method(http.MethodPost, "/telegram", h.telegram.Handler(h.bots))
+			}
+			if h.discord != nil {
+				r.Method(http.MethodPost, "/discord", h.discord.Handler(h.bots))
+			}
+			r.With(h.requireSession).Post("/link", h.BotLink)
+			r.Post("/payment-requests", h.BotPaymentRequestCreate)
+			r.Get("/payment-requests/{id}", h.BotPaymentRequestGet)
+		})
+	}
+
+	// Email and SMS notification routes (only if a channel is configured)
+	if h.notifier != nil {
+		r.Route("/notifications", func(r chi.Router) {
+			r.Get("/stats", h.NotificationStats)
+			r.Group(func(r chi.Router) {
+				r.Use(h.requireSession)
+				r.With(requireWalletOwner).Get("/preferences/{wallet}", h.NotificationPreferenceGet)
+				r.With(requireWalletOwner).Put("/preferences/{wallet}", h.NotificationPreferenceSet)
+				r.With(requireWalletOwner).Delete("/preferences/{wallet}", h.NotificationPreferenceDelete)
+			})
+		})
+	}
+
 	// Umbra integration routes (only if Umbra is enabled)
 	if h.umbraEnabled {
 		r.Route("/umbra", func(r chi.Router) {
 			r.Post("/stealth-address", h.UmbraStealthAddress)
 			r.Post("/deposit", h.UmbraDeposit)
-			r.Post("/send", h.UmbraSend)
-			r.Post("/withdraw", h.UmbraWithdraw)
+			r.With(h.requireSignature(bodySecretKey("private_key"))).Post("/send", h.UmbraSend)
+			r.With(h.requireSignature(bodySecretKey("private_key"))).Post("/withdraw", h.UmbraWithdraw)
 			r.Post("/balance", h.UmbraBalance)
 			r.Post("/prepare-stealth-payment", h.UmbraPrepareStealthPayment)
 		})
 	}
 
+	// Indexed stealth payment announcements (only if an Umbra program is set)
+	if h.announcements != nil {
+		r.Get("/stealth/announcements", h.StealthAnnouncements)
+	}
+
+	// Pinned circuit artifacts for client-side provers (only if synced)
+	if h.artifacts != nil {
+		r.Route("/artifacts", func(r chi.Router) {
+			r.Get("/", h.ArtifactManifest)
+			r.Get("/{circuit}/{kind}", h.ArtifactGet)
+		})
+	}
+
 	return r
 }
 
@@ -124,7 +724,3 @@ func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(data)
 }
-
-func respondError(w http.ResponseWriter, status int, message string) {
-	respondJSON(w, status, map[string]string{"error": message})
-}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"sol_privacy/internal/hold"
+	"sol_privacy/internal/reqsign"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func newHoldManager(h *Handler) *hold.Manager {
+	// EVENTS_WEBHOOK_URL and other bus subscribers consume these
+	config := hold.Config{
+		Release:       h.client.Payment.Release,
+		Refund:        h.client.Payment.Refund,
+		Timeout:       h.envDuration("HOLD_TIMEOUT", hold.DefaultTimeout),
+		CheckInterval: h.envDuration("HOLD_CHECK_INTERVAL", hold.DefaultCheckInterval),
+		OnEvent: func(ctx context.Context, event hold.Event) {
+			h.events.Emit(ctx, event.Type, "hold", event.Hold)
+		},
+	}
+	if path := h.storePath("HOLDS_DB", "holds.json"); path != "" {
+		fs, err := hold.NewFileStore(path)
+		if err != nil {
+			log.Printf("holds not persisted: %v", err)
+		} else {
+			config.Store = fs
+		}
+	}
+	return hold.NewManager(config)
+}
+
+// recordHold tracks a payment settled with hold set, releasing it after
+// holdSeconds (or HOLD_TIMEOUT) unless it is confirmed, refunded or disputed
+// first. payer is the wallet that may dispute it, if known. The funds stay
+// locked upstream either way, so failures are logged for the operator to
+// release or refund by hand.
+func (h *Handler) recordHold(ctx context.Context, txSig, resource, commitment, payer, amount string, holdSeconds int64) *hold.Hold {
+	held, err := h.holds.Create(ctx, hold.CreateRequest{
+		TxSig:    txSig,
+		Resource: resource,
+		Customer: commitment,
+		Payer:    payer,
+		Amount:   amount,
+		Timeout:  time.Duration(holdSeconds) * time.Second,
+	})
+	if err != nil {
+		log.Printf("hold for settlement %s not recorded: %v", txSig, err)
+		return nil
+	}
+	return held
+}
+
+// HoldList handles listing held payments, optionally by status
+func (h *Handler) HoldList(w http.ResponseWriter, r *http.Request) {
+	holds, err := h.holds.List(r.Context(), hold.Status(r.URL.Query().Get("status")))
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"holds": holds,
+	})
+}
+
+// HoldGet handles fetching a held payment and its transitions
+func (h *Handler) HoldGet(w http.ResponseWriter, r *http.Request) {
+	held, err := h.holds.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondHoldError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, held)
+}
+
+// HoldRelease handles releasing held funds to the merchant, as when the
+// customer confirms delivery
+func (h *Handler) HoldRelease(w http.ResponseWriter, r *http.Request) {
+	h.holdTransition(w, r, h.holds.Release)
+}
+
+// HoldRefund handles returning held funds to the payer
+func (h *Handler) HoldRefund(w http.ResponseWriter, r *http.Request) {
+	h.holdTransition(w, r, h.holds.Refund)
+}
+
+// HoldDispute handles freezing a held payment until it is resolved, for the
+// merchant or the paying wallet
+func (h *Handler) HoldDispute(w http.ResponseWriter, r *http.Request) {
+	if !h.isMerchantSession(r) && !h.signedByPayer(w, r) {
+		return
+	}
+	h.holdTransition(w, r, h.holds.Dispute)
+}
+
+// signedByPayer reports whether the request is signed by the wallet that
+// paid the hold, responding with the reason if not.
+func (h *Handler) signedByPayer(w http.ResponseWriter, r *http.Request) bool {
+	held, err := h.holds.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondHoldError(w, r, err)
+		return false
+	}
+	if held.Payer == "" {
+		respondError(w, r, http.StatusForbidden, "only the merchant can dispute a hold with no payer wallet")
+		return false
+	}
+	if !reqsign.Signed(r) {
+		respondError(w, r, http.StatusUnauthorized, "dispute must be signed by the paying wallet")
+		return false
+	}
+	signer, err := h.payerSignatures.Verify(r)
+	if err != nil {
+		respondError(w, r, http.StatusUnauthorized, err.Error())
+		return false
+	}
+	if signer != held.Payer {
+		respondError(w, r, http.StatusForbidden, "request is not signed by the paying wallet")
+		return false
+	}
+	return true
+}
+
+func (h *Handler) holdTransition(w http.ResponseWriter, r *http.Request, move func(ctx context.Context, id, reason string) (*hold.Hold, error)) {
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if r.ContentLength != 0 {
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+	}
+
+	held, err := move(r.Context(), chi.URLParam(r, "id"), req.Reason)
+	if err != nil {
+		respondHoldError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, held)
+}
+
+func respondHoldError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, hold.ErrHoldNotFound):
+		respondError(w, r, http.StatusNotFound, err.Error())
+	case errors.Is(err, hold.ErrInvalidTransition):
+		respondError(w, r, http.StatusConflict, err.Error())
+	default:
+		respondUpstreamError(w, r, err)
+	}
+}
+
+// holdSeconds parses the hold timeout carried in settlement job metadata.
+func holdSeconds(s string) int64 {
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"sol_privacy/internal/events"
+	"sol_privacy/internal/invoice"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func newInvoiceService(h *Handler) *invoice.Service {
+	var store invoice.Store
+	if path := h.storePath("INVOICES_DB", "invoices.json"); path != "" {
+		fs, err := invoice.NewFileStore(path)
+		if err != nil {
+			log.Printf("invoices not persisted: %v", err)
+		} else {
+			store = fs
+		}
+	}
+	return invoice.NewService(h.client.Intent, store)
+}
+
+// InvoiceCreate handles issuing an invoice
+func (h *Handler) InvoiceCreate(w http.ResponseWriter, r *http.Request) {
+	var req invoice.CreateRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	inv, err := h.invoices.Create(r.Context(), req)
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, inv)
+}
+
+// InvoiceList handles listing invoices, optionally filtered by status
+func (h *Handler) InvoiceList(w http.ResponseWriter, r *http.Request) {
+	invoices, err := h.invoices.List(r.Context(), r.URL.Query().Get("status"))
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"invoices": invoices,
+	})
+}
+
+// InvoiceGet handles fetching an invoice
+func (h *Handler) InvoiceGet(w http.ResponseWriter, r *http.Request) {
+	inv, err := h.invoices.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondInvoiceError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, inv)
+}
+
+// InvoicePDF handles rendering an invoice as PDF
+func (h *Handler) InvoicePDF(w http.ResponseWriter, r *http.Request) {
+	inv, err := h.invoices.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondInvoiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `inline; filename="`+inv.Number+`.pdf"`)
+	if err := invoice.WritePDF(w, inv); err != nil {
+		log.Printf("failed to render invoice %s: %v", inv.ID, err)
+	}
+}
+
+// InvoiceUpdate handles changing an open invoice
+func (h *Handler) InvoiceUpdate(w http.ResponseWriter, r *http.Request) {
+	var req invoice.UpdateRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	inv, err := h.invoices.Update(r.Context(), chi.URLParam(r, "id"), req)
+	if err != nil {
+		respondInvoiceError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, inv)
+}
+
+// InvoiceVoid handles voiding an unpaid invoice
+func (h *Handler) InvoiceVoid(w http.ResponseWriter, r *http.Request) {
+	inv, err := h.invoices.Void(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondInvoiceError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, inv)
+}
+
+// InvoiceRefresh handles checking whether an invoice has been paid
+func (h *Handler) InvoiceRefresh(w http.ResponseWriter, r *http.Request) {
+	inv, err := h.invoices.Refresh(r.Context(), chi.URLParam(r, "id"), "")
+	if err != nil {
+		respondInvoiceError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, inv)
+}
+
+// InvoiceWebhook handles ShadowPay payment webhooks for invoice intents
+func (h *Handler) InvoiceWebhook(w http.ResponseWriter, r *http.Request) {
+	var event invoice.WebhookEvent
+	// Not decoded strictly: ShadowPay may add fields to its events
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		respondError(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	h.events.Emit(r.Context(), events.WebhookReceived, "webhook", event)
+
+	inv, err := h.invoices.HandleWebhook(r.Context(), event)
+	if err != nil && !errors.Is(err, invoice.ErrInvoiceNotFound) {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	// Acknowledge events for other payments so the sender does not retry them
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"received": true,
+		"invoice":  inv,
+	})
+}
+
+func respondInvoiceError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, invoice.ErrInvoiceNotFound):
+		respondError(w, r, http.StatusNotFound, err.Error())
+	case errors.Is(err, invoice.ErrInvoiceClosed):
+		respondError(w, r, http.StatusConflict, err.Error())
+	default:
+		respondUpstreamError(w, r, err)
+	}
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"sol_privacy/internal/ledger"
+	"sol_privacy/internal/solana"
+	"sol_privacy/internal/validate"
+)
+
+func newFeeLedger(h *Handler) *ledger.Service {
+	var store ledger.Store
+	if path := h.storePath("FEE_LEDGER_DB", "fees.json"); path != "" {
+		fs, err := ledger.NewFileStore(path)
+		if err != nil {
+			log.Printf("fee ledger not persisted: %v", err)
+		} else {
+			store = fs
+		}
+	}
+	rpc := solana.NewClient(solana.Config{URL: h.env("SOLANA_RPC_URL")})
+	return ledger.NewService(store, rpc)
+}
+
+// FeeRecord handles recording a fee paid by a wallet
+func (h *Handler) FeeRecord(w http.ResponseWriter, r *http.Request) {
+	var req ledger.RecordRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	e, err := h.fees.Record(r.Context(), req)
+	if err != nil {
+		respondLedgerError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, e)
+}
+
+// FeeRecordNetwork handles recording the network fee of a confirmed transaction
+func (h *Handler) FeeRecordNetwork(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Wallet    string `json:"wallet"`
+		Signature string `json:"signature"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	e, err := h.fees.RecordNetworkFee(r.Context(), req.Wallet, req.Signature)
+	if err != nil {
+		respondLedgerError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, e)
+}
+
+// FeeList handles listing recorded fees
+func (h *Handler) FeeList(w http.ResponseWriter, r *http.Request) {
+	f, err := parseFeeFilter(r)
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	entries, err := h.fees.List(r.Context(), f)
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"fees": entries,
+	})
+}
+
+// FeeSummary handles totaling recorded fees per month
+func (h *Handler) FeeSummary(w http.ResponseWriter, r *http.Request) {
+	f, err := parseFeeFilter(r)
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	months, err := h.fees.Summary(r.Context(), f)
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"months": months,
+	})
+}
+
+// FeeExport handles downloading recorded fees as CSV or JSON
+func (h *Handler) FeeExport(w http.ResponseWriter, r *http.Request) {
+	f, err := parseFeeFilter(r)
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = ledger.FormatCSV
+	}
+	var buf bytes.Buffer
+	if err := h.fees.Export(r.Context(), &buf, format, f); err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	contentType := "text/csv"
+	if format == ledger.FormatJSON {
+		contentType = "application/json"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="fees.`+format+`"`)
+	w.Write(buf.Bytes())
+}
+
+// recordFee adds an entry to the fee ledger, logging instead of failing the request.
+func (h *Handler) recordFee(r *http.Request, req ledger.RecordRequest) {
+	if req.Amount <= 0 {
+		return
+	}
+	if _, err := h.fees.Record(r.Context(), req); err != nil {
+		log.Printf("failed to record %s fee: %v", req.Kind, err)
+	}
+}
+
+// parseFeeFilter reads the wallet, kind, from and to query parameters.
+func parseFeeFilter(r *http.Request) (ledger.Filter, error) {
+	q := r.URL.Query()
+	f := ledger.Filter{Wallet: q.Get("wallet"), Kind: ledger.Kind(q.Get("kind"))}
+	v := validate.New().OptionalAddress("wallet", f.Wallet)
+	for _, p := range []struct {
+		name string
+		dst  *int64
+	}{{"from", &f.From}, {"to", &f.To}} {
+		if s := q.Get(p.name); s != "" {
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil || n < 0 {
+				v.Add(p.name, errors.New("must be a unix timestamp"))
+				continue
+			}
+			*p.dst = n
+		}
+	}
+	return f, v.Err()
+}
+
+func respondLedgerError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ledger.ErrTransactionNotFound):
+		respondError(w, r, http.StatusNotFound, err.Error())
+	case errors.Is(err, ledger.ErrDuplicate):
+		respondError(w, r, http.StatusConflict, err.Error())
+	default:
+		respondUpstreamError(w, r, err)
+	}
+}
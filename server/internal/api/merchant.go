@@ -1,9 +1,9 @@
 package api
 
 import (
-	"encoding/json"
 	"net/http"
 
+	"sol_privacy/internal/ledger"
 	"sol_privacy/internal/merchant"
 )
 
@@ -11,7 +11,7 @@ import (
 func (h *Handler) MerchantEarnings(w http.ResponseWriter, r *http.Request) {
 	resp, err := h.client.Merchant.GetEarnings(r.Context())
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
@@ -21,33 +21,40 @@ func (h *Handler) MerchantEarnings(w http.ResponseWriter, r *http.Request) {
 // MerchantAnalytics handles getting merchant analytics
 func (h *Handler) MerchantAnalytics(w http.ResponseWriter, r *http.Request) {
 	var req merchant.AnalyticsRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
-	resp, err := h.client.Merchant.GetAnalytics(r.Context(), req)
+	resp, status, err := h.getAnalytics(r.Context(), req, bypassCache(r))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
+	w.Header().Set("X-Cache", string(status))
+
 	respondJSON(w, http.StatusOK, resp)
 }
 
 // MerchantWithdraw handles merchant earnings withdrawal
 func (h *Handler) MerchantWithdraw(w http.ResponseWriter, r *http.Request) {
 	var req merchant.WithdrawRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	resp, err := h.client.Merchant.Withdraw(r.Context(), req)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
+	h.recordFee(r, ledger.RecordRequest{
+		Wallet:    req.Destination,
+		Kind:      ledger.KindMerchantWithdrawal,
+		Amount:    resp.Fee,
+		TokenMint: req.TokenMint,
+		Reference: resp.WithdrawalID,
+	})
 
 	respondJSON(w, http.StatusOK, resp)
 }
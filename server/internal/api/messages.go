@@ -0,0 +1,97 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"sol_privacy/internal/messages"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func newMessageService(h *Handler) *messages.Service {
+	var store messages.Store
+	if path := h.storePath("MESSAGES_DB", "messages.json"); path != "" {
+		fs, err := messages.NewFileStore(path)
+		if err != nil {
+			log.Printf("messages not persisted: %v", err)
+		} else {
+			store = fs
+		}
+	}
+	return messages.NewService(store)
+}
+
+// MessageList handles listing conversations by commitment or merchant key
+func (h *Handler) MessageList(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	list, err := h.messages.List(r.Context(), messages.Filter{
+		Commitment:  q.Get("commitment"),
+		MerchantKey: q.Get("merchant_key"),
+	})
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"conversations": list,
+	})
+}
+
+// MessageStart handles opening a conversation between a commitment and a merchant
+func (h *Handler) MessageStart(w http.ResponseWriter, r *http.Request) {
+	var req messages.StartRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	c, err := h.messages.Start(r.Context(), req)
+	if err != nil {
+		respondMessageError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, c)
+}
+
+// MessageGet handles fetching a conversation and its sealed messages
+func (h *Handler) MessageGet(w http.ResponseWriter, r *http.Request) {
+	after, _ := strconv.Atoi(r.URL.Query().Get("after"))
+	c, err := h.messages.Get(r.Context(), chi.URLParam(r, "id"), after)
+	if err != nil {
+		respondMessageError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, c)
+}
+
+// MessagePost handles relaying a sealed message
+func (h *Handler) MessagePost(w http.ResponseWriter, r *http.Request) {
+	var req messages.PostRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	m, err := h.messages.Post(r.Context(), chi.URLParam(r, "id"), req)
+	if err != nil {
+		respondMessageError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, m)
+}
+
+func respondMessageError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, messages.ErrConversationNotFound):
+		respondError(w, r, http.StatusNotFound, err.Error())
+	case errors.Is(err, messages.ErrConversationFull):
+		respondError(w, r, http.StatusConflict, err.Error())
+	default:
+		respondUpstreamError(w, r, err)
+	}
+}
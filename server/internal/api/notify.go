@@ -0,0 +1,146 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"sol_privacy/internal/notify"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// newNotifier enables email and SMS notifications for the channels
+// configured, or returns nil if neither is.
+func newNotifier(h *Handler) *notify.Notifier {
+	config := notify.Config{
+		EmailPerHour: notify.Limit(h.envInt("NOTIFY_EMAIL_PER_HOUR")),
+		SMSPerHour:   notify.Limit(h.envInt("NOTIFY_SMS_PER_HOUR")),
+	}
+	if host := h.env("SMTP_HOST"); host != "" {
+		sender, err := notify.NewSMTP(notify.SMTPConfig{
+			Host:     host,
+			Port:     h.env("SMTP_PORT"),
+			Username: h.env("SMTP_USERNAME"),
+			Password: h.env("SMTP_PASSWORD"),
+			From:     h.env("SMTP_FROM"),
+		})
+		if err != nil {
+			log.Printf("email notifications disabled: %v", err)
+		} else {
+			config.Email = sender
+		}
+	}
+	if sid := h.env("TWILIO_ACCOUNT_SID"); sid != "" {
+		sender, err := notify.NewTwilio(notify.TwilioConfig{
+			AccountSID: sid,
+			AuthToken:  h.env("TWILIO_AUTH_TOKEN"),
+			From:       h.env("TWILIO_FROM"),
+		})
+		if err != nil {
+			log.Printf("SMS notifications disabled: %v", err)
+		} else {
+			config.SMS = sender
+		}
+	}
+	if config.Email == nil && config.SMS == nil {
+		return nil
+	}
+
+	// JSON object of event type to {"subject", "body", "sms"} templates
+	if path := h.env("NOTIFY_TEMPLATES"); path != "" {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			err = json.Unmarshal(data, &config.Templates)
+		}
+		if err != nil {
+			log.Printf("notification templates not loaded: %v", err)
+		}
+	}
+	if path := h.storePath("NOTIFY_DB", "notifications.json"); path != "" {
+		fs, err := notify.NewFileStore(path)
+		if err != nil {
+			log.Printf("notification preferences not persisted: %v", err)
+		} else {
+			config.Store = fs
+		}
+	}
+
+	n, err := notify.New(config)
+	if err != nil {
+		log.Printf("notifications disabled: %v", err)
+		return nil
+	}
+	return n
+}
+
+// envInt returns an integer variable, or 0 if it is unset or invalid.
+func (h *Handler) envInt(name string) int {
+	s := h.env(name)
+	if s == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		log.Printf("invalid %s %q, using the default: %v", name, s, err)
+		return 0
+	}
+	return n
+}
+
+// NotificationPreferenceGet handles fetching a merchant's notification preferences
+func (h *Handler) NotificationPreferenceGet(w http.ResponseWriter, r *http.Request) {
+	p, err := h.notifier.Preference(chi.URLParam(r, "wallet"))
+	if err != nil {
+		respondNotifyError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, p)
+}
+
+// NotificationPreferenceSet handles saving a merchant's email and SMS notification preferences
+func (h *Handler) NotificationPreferenceSet(w http.ResponseWriter, r *http.Request) {
+	var req notify.Preference
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	req.Merchant = chi.URLParam(r, "wallet")
+
+	p, err := h.notifier.SetPreference(req)
+	if err != nil {
+		respondNotifyError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, p)
+}
+
+// NotificationPreferenceDelete handles stopping all notifications to a merchant
+func (h *Handler) NotificationPreferenceDelete(w http.ResponseWriter, r *http.Request) {
+	if err := h.notifier.DeletePreference(chi.URLParam(r, "wallet")); err != nil {
+		respondNotifyError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// NotificationStats handles reporting sent, rate-limited and failed notifications per channel
+func (h *Handler) NotificationStats(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"channels": h.notifier.Channels(),
+		"stats":    h.notifier.Stats(),
+	})
+}
+
+func respondNotifyError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, notify.ErrPreferenceNotFound) {
+		respondError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	respondUpstreamError(w, r, err)
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"sol_privacy/internal/nullifier"
+)
+
+// NullifierExport handles exporting a page of the consumed-nullifier set for auditors
+func (h *Handler) NullifierExport(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	req := nullifier.ExportRequest{Cursor: q.Get("cursor")}
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > nullifier.MaxPageSize {
+			respondError(w, r, http.StatusBadRequest, "limit must be between 1 and 1000")
+			return
+		}
+		req.Limit = n
+	}
+
+	resp, err := h.client.Nullifiers.Export(r.Context(), req)
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
@@ -1,23 +1,26 @@
 package api
 
 import (
-	"encoding/json"
 	"net/http"
 
+	"sol_privacy/internal/hold"
 	"sol_privacy/internal/payment"
+	"sol_privacy/internal/swap"
+	"sol_privacy/internal/types"
+
+	"github.com/go-chi/chi/v5"
 )
 
 // PaymentDeposit handles deposit to payment account
 func (h *Handler) PaymentDeposit(w http.ResponseWriter, r *http.Request) {
 	var req payment.DepositRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	resp, err := h.client.Payment.Deposit(r.Context(), req)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
@@ -27,14 +30,13 @@ func (h *Handler) PaymentDeposit(w http.ResponseWriter, r *http.Request) {
 // PaymentWithdraw handles withdrawal from payment account
 func (h *Handler) PaymentWithdraw(w http.ResponseWriter, r *http.Request) {
 	var req payment.WithdrawRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	resp, err := h.client.Payment.Withdraw(r.Context(), req)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
@@ -49,21 +51,33 @@ func (h *Handler) PaymentPrepare(w http.ResponseWriter, r *http.Request) {
 		Amount             int64  `json:"amount"`
 		TokenMint          string `json:"token_mint,omitempty"`
 		GenerateStealth    bool   `json:"generate_stealth,omitempty"`
-		RecipientPublicKey string `json:"recipient_public_key,omitempty"`
+		RecipientPublicKey string `json:"recipient_public_key,omitempty"` // Wallet or .sol name
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) || !h.resolveRecipient(w, r, "recipient_public_key", &req.RecipientPublicKey) {
 		return
 	}
 
+	// Reject SPL-token payments that violate the mint's guardrail
+	if req.TokenMint != "" {
+		validation, err := h.client.Token.ValidatePayment(r.Context(), req.TokenMint, req.Amount)
+		if err != nil {
+			respondError(w, r, http.StatusBadGateway, "Failed to validate token payment: "+err.Error())
+			return
+		}
+		if !validation.Allowed {
+			respondError(w, r, http.StatusUnprocessableEntity, "Token payment rejected: "+validation.Reason)
+			return
+		}
+	}
+
 	receiverCommitment := req.ReceiverCommitment
 
 	// If stealth address generation is requested and Umbra is enabled
 	if req.GenerateStealth && h.umbraEnabled && req.RecipientPublicKey != "" {
 		stealthResp, err := h.umbraClient.GenerateStealthAddress(r.Context(), req.RecipientPublicKey)
 		if err != nil {
-			respondError(w, http.StatusInternalServerError, "Failed to generate stealth address: "+err.Error())
+			respondError(w, r, http.StatusInternalServerError, "Failed to generate stealth address: "+err.Error())
 			return
 		}
 		receiverCommitment = stealthResp.Data.EphemeralPublicKey
@@ -71,12 +85,12 @@ func (h *Handler) PaymentPrepare(w http.ResponseWriter, r *http.Request) {
 
 	// Prepare payment with ShadowPay
 	prepareResp, err := h.client.Payment.Prepare(r.Context(), payment.PrepareRequest{
-		ReceiverCommitment: receiverCommitment,
+		ReceiverCommitment: types.Commitment(receiverCommitment),
 		Amount:             req.Amount,
 		TokenMint:          req.TokenMint,
 	})
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
@@ -103,14 +117,13 @@ func (h *Handler) PaymentPrepare(w http.ResponseWriter, r *http.Request) {
 // PaymentAuthorize handles payment authorization
 func (h *Handler) PaymentAuthorize(w http.ResponseWriter, r *http.Request) {
 	var req payment.AuthorizeRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	resp, err := h.client.Payment.Authorize(r.Context(), req)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
@@ -122,14 +135,53 @@ func (h *Handler) PaymentVerifyAccess(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		AccessToken string `json:"access_token"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	resp, err := h.client.Payment.VerifyAccess(r.Context(), req.AccessToken)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// PaymentRefreshAccess handles access token refresh
+func (h *Handler) PaymentRefreshAccess(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		AccessToken string `json:"access_token"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	resp, err := h.client.Payment.RefreshAccess(r.Context(), req.AccessToken)
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// PaymentStatus handles payment settlement status lookup
+func (h *Handler) PaymentStatus(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.client.Payment.GetStatus(r.Context(), chi.URLParam(r, "paymentHash"))
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// PaymentSupported handles listing the supported x402 payment schemes
+func (h *Handler) PaymentSupported(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.client.Verify.GetSupported(r.Context())
+	if err != nil {
+		respondUpstreamError(w, r, err)
 		return
 	}
 
@@ -138,17 +190,47 @@ func (h *Handler) PaymentVerifyAccess(w http.ResponseWriter, r *http.Request) {
 
 // PaymentSettle handles payment settlement
 func (h *Handler) PaymentSettle(w http.ResponseWriter, r *http.Request) {
-	var req payment.SettleRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	var req struct {
+		payment.SettleRequest
+		TokenMint   string `json:"token_mint,omitempty"`   // Mint the payment settled in, for auto-swap
+		TokenAmount int64  `json:"token_amount,omitempty"` // Settled amount in smallest units
+
+		CustomerCommitment string `json:"customer_commitment,omitempty"` // Payer's ShadowID commitment, for repeat-customer stats
+		HoldSeconds        int64  `json:"hold_seconds,omitempty"`        // With hold, release after this unless resolved first
+		PayerWallet        string `json:"payer_wallet,omitempty"`        // With hold, the wallet that may dispute it
+	}
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
-	resp, err := h.client.Payment.Settle(r.Context(), req)
+	resp, err := h.client.Payment.Settle(r.Context(), req.SettleRequest)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
-	respondJSON(w, http.StatusOK, resp)
+	if resp.Success && req.CustomerCommitment != "" {
+		h.recordSettledPurchase(r.Context(), req.CustomerCommitment, req.PaymentRequirements.MaxAmountRequired, req.TokenMint, req.TokenAmount)
+	}
+
+	var held *hold.Hold
+	if resp.Success && req.Hold {
+		held = h.recordHold(r.Context(), resp.TxSig, req.Resource, req.CustomerCommitment, req.PayerWallet, req.PaymentRequirements.MaxAmountRequired, req.HoldSeconds)
+	}
+
+	// Held funds are locked, so there is nothing to convert yet
+	if h.swaps == nil || !resp.Success || req.TokenMint == "" || req.Hold {
+		respondJSON(w, http.StatusOK, struct {
+			*payment.SettleResponse
+			Hold *hold.Hold `json:"hold,omitempty"`
+		}{resp, held})
+		return
+	}
+
+	// A failed conversion does not fail the settlement; the receipt records the error
+	receipt, _ := h.swaps.ConvertOnSettlement(r.Context(), resp.TxSig, req.TokenMint, req.TokenAmount)
+	respondJSON(w, http.StatusOK, struct {
+		*payment.SettleResponse
+		Swap *swap.Receipt `json:"swap,omitempty"`
+	}{resp, receipt})
 }
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"sol_privacy/internal/paymentlink"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func newPaymentLinkService(h *Handler) *paymentlink.Service {
+	baseURL := strings.TrimSuffix(h.env("PAYMENT_LINK_BASE_URL"), "/")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080/api/l"
+	}
+
+	var store paymentlink.Store
+	if path := h.storePath("PAYMENT_LINKS_DB", "payment_links.json"); path != "" {
+		fs, err := paymentlink.NewFileStore(path)
+		if err != nil {
+			log.Printf("payment links not persisted: %v", err)
+		} else {
+			store = fs
+		}
+	}
+
+	return paymentlink.NewService(paymentlink.Config{
+		Checkout: h.checkout,
+		Store:    store,
+		BaseURL:  baseURL,
+	})
+}
+
+// PaymentLinkCreate handles creating a payment link
+func (h *Handler) PaymentLinkCreate(w http.ResponseWriter, r *http.Request) {
+	var req paymentlink.CreateRequest
+	if !decodeJSON(w, r, &req) || !h.resolveRecipient(w, r, "recipient", &req.Recipient) {
+		return
+	}
+
+	link, err := h.paymentLinks.Create(r.Context(), req)
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, link)
+}
+
+// PaymentLinkList handles listing payment links
+func (h *Handler) PaymentLinkList(w http.ResponseWriter, r *http.Request) {
+	links, err := h.paymentLinks.List(r.Context())
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"links": links,
+	})
+}
+
+// PaymentLinkGet handles fetching a payment link
+func (h *Handler) PaymentLinkGet(w http.ResponseWriter, r *http.Request) {
+	link, err := h.paymentLinks.Get(r.Context(), chi.URLParam(r, "code"))
+	if err != nil {
+		respondPaymentLinkError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, link)
+}
+
+// PaymentLinkStats handles fetching payment link usage statistics
+func (h *Handler) PaymentLinkStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.paymentLinks.Stats(r.Context(), chi.URLParam(r, "code"))
+	if err != nil {
+		respondPaymentLinkError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, stats)
+}
+
+// PaymentLinkDeactivate handles deactivating a payment link
+func (h *Handler) PaymentLinkDeactivate(w http.ResponseWriter, r *http.Request) {
+	link, err := h.paymentLinks.Deactivate(r.Context(), chi.URLParam(r, "code"))
+	if err != nil {
+		respondPaymentLinkError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, link)
+}
+
+// PaymentLinkResolve handles a visit to a short link by redirecting to a new checkout session
+func (h *Handler) PaymentLinkResolve(w http.ResponseWriter, r *http.Request) {
+	session, err := h.paymentLinks.Resolve(r.Context(), chi.URLParam(r, "code"))
+	if err != nil {
+		respondPaymentLinkError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, session.URL, http.StatusSeeOther)
+}
+
+func respondPaymentLinkError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, paymentlink.ErrLinkNotFound):
+		respondError(w, r, http.StatusNotFound, err.Error())
+	case errors.Is(err, paymentlink.ErrLinkInactive):
+		respondError(w, r, http.StatusGone, err.Error())
+	default:
+		respondUpstreamError(w, r, err)
+	}
+}
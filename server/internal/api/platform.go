@@ -0,0 +1,118 @@
+package api
+
+import (
+	"net/http"
+
+	"sol_privacy/internal/payment"
+	"sol_privacy/internal/platform"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// PlatformCreate handles creating a sub-merchant
+func (h *Handler) PlatformCreate(w http.ResponseWriter, r *http.Request) {
+	var req platform.CreateRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	resp, err := h.client.Platform.Create(r.Context(), req)
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, resp)
+}
+
+// PlatformList handles listing sub-merchants
+func (h *Handler) PlatformList(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.client.Platform.List(r.Context())
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// PlatformGet handles getting a sub-merchant
+func (h *Handler) PlatformGet(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.client.Platform.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// PlatformUpdate handles changing a sub-merchant's name, fee or status
+func (h *Handler) PlatformUpdate(w http.ResponseWriter, r *http.Request) {
+	var req platform.UpdateRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	resp, err := h.client.Platform.Update(r.Context(), chi.URLParam(r, "id"), req)
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// PlatformCreateKey handles issuing a sub-merchant API key
+func (h *Handler) PlatformCreateKey(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.client.Platform.CreateAPIKey(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, resp)
+}
+
+// PlatformMerchantEarnings handles getting a sub-merchant's earnings
+func (h *Handler) PlatformMerchantEarnings(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.client.Platform.GetEarnings(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// PlatformEarnings handles getting the earnings of every sub-merchant
+func (h *Handler) PlatformEarnings(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.client.Platform.GetAllEarnings(r.Context())
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// PlatformRoute handles routing payment requirements to a sub-merchant
+func (h *Handler) PlatformRoute(w http.ResponseWriter, r *http.Request) {
+	var req payment.Requirements
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	sub, err := h.client.Platform.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+	routed, err := platform.Route(sub, req)
+	if err != nil {
+		respondError(w, r, http.StatusConflict, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, routed)
+}
@@ -1,11 +1,14 @@
 package api
 
 import (
-	"encoding/json"
 	"net/http"
+	"strconv"
 
+	"sol_privacy/internal/ledger"
 	"sol_privacy/internal/pool"
+	"sol_privacy/internal/types"
 	"sol_privacy/internal/umbra"
+	"sol_privacy/internal/validate"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -13,14 +16,14 @@ import (
 // PoolBalance handles pool balance check
 func (h *Handler) PoolBalance(w http.ResponseWriter, r *http.Request) {
 	wallet := chi.URLParam(r, "wallet")
-	if wallet == "" {
-		respondError(w, http.StatusBadRequest, "wallet address required")
+	if err := validate.Address(wallet); err != nil {
+		respondValidationError(w, r, validate.Errors{{Field: "wallet", Message: err.Error()}})
 		return
 	}
 
 	resp, err := h.client.Pool.GetBalance(r.Context(), wallet)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
@@ -38,18 +41,17 @@ func (h *Handler) PoolDeposit(w http.ResponseWriter, r *http.Request) {
 		UmbraDestination   string  `json:"umbra_destination,omitempty"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	// Deposit to ShadowPay pool
 	poolResp, err := h.client.Pool.Deposit(r.Context(), pool.DepositRequest{
-		WalletAddress: req.WalletAddress,
+		WalletAddress: types.Address(req.WalletAddress),
 		Amount:        req.Amount,
 	})
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
@@ -90,14 +92,35 @@ func (h *Handler) PoolDeposit(w http.ResponseWriter, r *http.Request) {
 // PoolWithdraw handles pool withdrawal
 func (h *Handler) PoolWithdraw(w http.ResponseWriter, r *http.Request) {
 	var req pool.WithdrawRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	resp, err := h.client.Pool.Withdraw(r.Context(), req)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
+		return
+	}
+	h.recordFee(r, ledger.RecordRequest{
+		Wallet: string(req.WalletAddress),
+		Kind:   ledger.KindPoolWithdrawal,
+		Amount: resp.Fee,
+	})
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// PoolWithdrawQuote handles previewing the fee and net amount of a pool withdrawal
+func (h *Handler) PoolWithdrawQuote(w http.ResponseWriter, r *http.Request) {
+	lamports, err := strconv.ParseInt(r.URL.Query().Get("amount"), 10, 64)
+	if err != nil {
+		respondValidationError(w, r, validate.Errors{{Field: "amount", Message: "must be an integer number of lamports"}})
+		return
+	}
+
+	resp, err := h.client.Pool.QuoteWithdraw(r.Context(), lamports)
+	if err != nil {
+		respondUpstreamError(w, r, err)
 		return
 	}
 
@@ -108,7 +131,7 @@ func (h *Handler) PoolWithdraw(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) PoolDepositAddress(w http.ResponseWriter, r *http.Request) {
 	resp, err := h.client.Pool.GetDepositAddress(r.Context())
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
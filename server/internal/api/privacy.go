@@ -1,7 +1,6 @@
 package api
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"sol_privacy/internal/privacy"
@@ -10,14 +9,13 @@ import (
 // PrivacyDecrypt handles decrypting an amount
 func (h *Handler) PrivacyDecrypt(w http.ResponseWriter, r *http.Request) {
 	var req privacy.DecryptRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	resp, err := h.client.Privacy.Decrypt(r.Context(), req)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"sol_privacy/internal/events"
+	"sol_privacy/internal/ledger"
+	"sol_privacy/internal/reconcile"
+	"sol_privacy/internal/settlement"
+	"sol_privacy/internal/solana"
+	"sol_privacy/internal/x402"
+)
+
+// newReconciler checks queued settlements and recorded withdrawals against
+// the cluster at SOLANA_RPC_URL and the merchants' receipts, every
+// RECONCILE_INTERVAL if set, publishing each mismatch once on the bus.
+func newReconciler(h *Handler) *reconcile.Reconciler {
+	rpc := solana.NewClient(solana.Config{URL: h.env("SOLANA_RPC_URL")})
+	return reconcile.New(reconcile.Config{
+		Records:  h.reconcileRecords,
+		Statuses: rpc.GetSignatureStatuses,
+		Receipts: h.client.Receipt.ListUserReceipts,
+		Window:   h.envDuration("RECONCILE_WINDOW", reconcile.DefaultWindow),
+		Grace:    h.envDuration("RECONCILE_GRACE", reconcile.DefaultGrace),
+		Interval: h.envDuration("RECONCILE_INTERVAL", 0),
+		OnFinding: func(ctx context.Context, f reconcile.Finding) {
+			h.events.Emit(ctx, events.ReconciliationMismatch, "reconcile", f)
+		},
+	})
+}
+
+// reconcileRecords lists finished settlement jobs and withdrawal fees.
+// Settlements of tokens are checked on-chain only, as receipts are in
+// lamports.
+func (h *Handler) reconcileRecords(ctx context.Context) ([]reconcile.Record, error) {
+	jobs, err := h.settlements.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	var records []reconcile.Record
+	for _, j := range jobs {
+		if !j.Done() {
+			continue
+		}
+		rec := reconcile.Record{
+			Kind:      reconcile.KindSettlement,
+			ID:        j.ID,
+			Wallet:    j.Request.PaymentRequirements.PayTo,
+			Resource:  j.Request.Resource,
+			Succeeded: j.Status == settlement.StatusSucceeded,
+			At:        j.UpdatedAt,
+		}
+		if j.Result != nil {
+			rec.Signature = j.Result.TxSig
+		}
+		if j.Metadata[settlementTokenMint] == "" {
+			rec.Lamports, _ = x402.ParseSOL(j.Request.PaymentRequirements.MaxAmountRequired)
+		} else {
+			rec.Wallet = ""
+		}
+		records = append(records, rec)
+	}
+
+	for _, kind := range []ledger.Kind{ledger.KindPoolWithdrawal, ledger.KindMerchantWithdrawal} {
+		entries, err := h.fees.List(ctx, ledger.Filter{Kind: kind})
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			records = append(records, reconcile.Record{
+				Kind:      reconcile.KindWithdrawal,
+				ID:        e.ID,
+				Signature: e.Reference,
+				Wallet:    e.Wallet,
+				Succeeded: true,
+				At:        e.At,
+			})
+		}
+	}
+	return records, nil
+}
+
+// ReconciliationReport handles returning the latest reconciliation report
+func (h *Handler) ReconciliationReport(w http.ResponseWriter, r *http.Request) {
+	report := h.reconciler.Last()
+	if report == nil {
+		respondError(w, r, http.StatusNotFound, "No reconciliation has run yet")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, report)
+}
+
+// ReconciliationRun handles running a reconciliation now instead of waiting
+// for the next interval
+func (h *Handler) ReconciliationRun(w http.ResponseWriter, r *http.Request) {
+	report, err := h.reconciler.Reconcile(r.Context())
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"sol_privacy/internal/registry"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func newRegistry(h *Handler) *registry.Service {
+	var store registry.Store
+	if path := h.storePath("REGISTRY_DB", "registry.json"); path != "" {
+		fs, err := registry.NewFileStore(path)
+		if err != nil {
+			log.Printf("stealth registry not persisted: %v", err)
+		} else {
+			store = fs
+		}
+	}
+	return registry.NewService(store)
+}
+
+// RegistryRegister handles publishing or updating a handle's stealth meta-address
+func (h *Handler) RegistryRegister(w http.ResponseWriter, r *http.Request) {
+	var req registry.RegisterRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	record, err := h.registry.Register(r.Context(), req)
+	if err != nil {
+		respondRegistryError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, record)
+}
+
+// RegistryLookup handles resolving a handle or ShadowID commitment to its record
+func (h *Handler) RegistryLookup(w http.ResponseWriter, r *http.Request) {
+	record, err := h.registry.Lookup(r.Context(), chi.URLParam(r, "key"))
+	if err != nil {
+		respondRegistryError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, record)
+}
+
+// RegistryUnregister handles removing a handle, signed by its owner
+func (h *Handler) RegistryUnregister(w http.ResponseWriter, r *http.Request) {
+	var req registry.UnregisterRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	req.Handle = chi.URLParam(r, "key")
+
+	if err := h.registry.Unregister(r.Context(), req); err != nil {
+		respondRegistryError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func respondRegistryError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, registry.ErrNotFound):
+		respondError(w, r, http.StatusNotFound, err.Error())
+	case errors.Is(err, registry.ErrHandleTaken), errors.Is(err, registry.ErrCommitmentTaken):
+		respondError(w, r, http.StatusConflict, err.Error())
+	case errors.Is(err, registry.ErrStale):
+		respondError(w, r, http.StatusUnauthorized, err.Error())
+	default:
+		respondUpstreamError(w, r, err)
+	}
+}
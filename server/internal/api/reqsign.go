@@ -0,0 +1,126 @@
+package api
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"sol_privacy/internal/base58"
+	"sol_privacy/internal/reqsign"
+)
+
+// Request signing modes set by Options.RequestSigning.
+const (
+	SigningOff      = "off"
+	SigningOptional = "optional"
+	SigningRequire  = "require"
+)
+
+// actingWallet returns the wallet a JSON request body acts for, or "" if
+// the body names none.
+type actingWallet func(body []byte) string
+
+// requireSignature verifies wallet signatures on a sensitive route whose
+// body acts for the wallet actor returns. Unsigned requests pass unless
+// signing is required; a signed request must be valid and signed by that
+// wallet, and is refused when the wallet cannot be told.
+func (h *Handler) requireSignature(actor actingWallet) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if h.signatures == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !reqsign.Signed(r) && !h.signingRequired {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			signer, err := h.signatures.Verify(r)
+			if err != nil {
+				respondError(w, r, http.StatusUnauthorized, err.Error())
+				return
+			}
+			wallet := actor(readBody(r))
+			if wallet == "" {
+				respondError(w, r, http.StatusBadRequest, "request does not name exactly one wallet it acts for")
+				return
+			}
+			if wallet != signer {
+				respondError(w, r, http.StatusForbidden, "request is not signed by this wallet")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// readBody returns the request body, leaving it readable.
+func readBody(r *http.Request) []byte {
+	if r.Body == nil {
+		return nil
+	}
+	body, err := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return nil
+	}
+	return body
+}
+
+// bodyField acts for the wallet in a string field of the body. Handlers
+// decode bodies with encoding/json, which matches keys case-insensitively
+// and keeps the last duplicate, so the field is matched the same way and a
+// body that names it more than once acts for no wallet.
+func bodyField(name string) actingWallet {
+	return func(body []byte) string {
+		dec := json.NewDecoder(bytes.NewReader(body))
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+			return ""
+		}
+		var value json.RawMessage
+		found := false
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return ""
+			}
+			key, _ := tok.(string)
+			var v json.RawMessage
+			if err := dec.Decode(&v); err != nil {
+				return ""
+			}
+			if !strings.EqualFold(key, name) {
+				continue
+			}
+			if found {
+				return ""
+			}
+			value, found = v, true
+		}
+		var wallet string
+		if !found || json.Unmarshal(value, &wallet) != nil {
+			return ""
+		}
+		return wallet
+	}
+}
+
+// bodySecretKey acts for the wallet of the base58 Solana secret key in a
+// field of the body, as Umbra requests carry. The address is derived from
+// the seed half, as that is the key that signs, whatever the public half
+// claims.
+func bodySecretKey(name string) actingWallet {
+	field := bodyField(name)
+	return func(body []byte) string {
+		secret, err := base58.Decode(field(body))
+		if err != nil || len(secret) != ed25519.PrivateKeySize {
+			return ""
+		}
+		key := ed25519.NewKeyFromSeed(secret[:ed25519.SeedSize])
+		return base58.Encode(key.Public().(ed25519.PublicKey))
+	}
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"sol_privacy/internal/resolve"
+	"sol_privacy/internal/solana"
+	"sol_privacy/internal/validate"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// newResolver resolves .sol names on SNS_RPC_URL, falling back to
+// SOLANA_RPC_URL, caching lookups for SNS_CACHE_TTL.
+func newResolver(h *Handler) *resolve.Resolver {
+	url := h.env("SNS_RPC_URL")
+	if url == "" {
+		url = h.env("SOLANA_RPC_URL")
+	}
+	return resolve.NewResolver(solana.NewClient(solana.Config{URL: url}), h.envDuration("SNS_CACHE_TTL", resolve.DefaultTTL))
+}
+
+// resolveRecipient replaces a .sol name in *value with the wallet owning it.
+// It responds and returns false if the name does not resolve.
+func (h *Handler) resolveRecipient(w http.ResponseWriter, r *http.Request, field string, value *string) bool {
+	if !resolve.IsName(*value) {
+		return true
+	}
+	address, err := h.resolver.Address(r.Context(), *value)
+	switch {
+	case errors.Is(err, resolve.ErrNotFound), errors.Is(err, resolve.ErrInvalidName):
+		respondValidationError(w, r, validate.Errors{{Field: field, Message: err.Error()}})
+		return false
+	case err != nil:
+		respondError(w, r, http.StatusBadGateway, "Failed to resolve name: "+err.Error())
+		return false
+	}
+	*value = address
+	return true
+}
+
+// ResolveName handles resolving a .sol domain to the wallet that owns it
+func (h *Handler) ResolveName(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	address, err := h.resolver.Address(r.Context(), name)
+	if err != nil {
+		respondResolveError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{
+		"name":    name,
+		"address": address,
+	})
+}
+
+// ResolveReverse handles looking up the primary .sol domain of a wallet
+func (h *Handler) ResolveReverse(w http.ResponseWriter, r *http.Request) {
+	address := chi.URLParam(r, "address")
+	if err := validate.Address(address); err != nil {
+		respondValidationError(w, r, validate.Errors{{Field: "address", Message: err.Error()}})
+		return
+	}
+	name, err := h.resolver.Reverse(r.Context(), address)
+	if err != nil {
+		respondResolveError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{
+		"address": address,
+		"name":    name,
+	})
+}
+
+func respondResolveError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, resolve.ErrNotFound):
+		respondError(w, r, http.StatusNotFound, "Name not found")
+	case errors.Is(err, resolve.ErrInvalidName):
+		respondError(w, r, http.StatusBadRequest, "Invalid .sol name")
+	default:
+		respondError(w, r, http.StatusBadGateway, "Failed to resolve name: "+err.Error())
+	}
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"sol_privacy/internal/client"
+)
+
+// sandboxOption configures the upstream sandbox from SHADOWPAY_SANDBOX_URL
+// and SHADOWPAY_SANDBOX_API_KEY. With SHADOWPAY_SANDBOX=true every upstream
+// call goes to it; otherwise only those of requests sent with the sandbox
+// environment header do.
+func (h *Handler) sandboxOption() (client.Option, bool) {
+	url := h.env("SHADOWPAY_SANDBOX_URL")
+	if url == "" {
+		return nil, false
+	}
+	config := client.SandboxConfig{
+		URL:    url,
+		APIKey: h.env("SHADOWPAY_SANDBOX_API_KEY"),
+		Always: strings.EqualFold(h.env("SHADOWPAY_SANDBOX"), "true"),
+	}
+	return client.WithSandbox(config), true
+}
+
+// sandboxHeader sends the upstream calls of requests with
+// X-ShadowPay-Environment: sandbox to the sandbox, so clients can try
+// changes against production deployments without touching live data.
+func (h *Handler) sandboxHeader(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		env := r.Header.Get(client.HeaderEnvironment)
+		if env == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !strings.EqualFold(env, client.EnvironmentSandbox) {
+			respondError(w, r, http.StatusBadRequest, "Unknown environment")
+			return
+		}
+		if !h.sandbox {
+			respondError(w, r, http.StatusBadRequest, "Sandbox is not configured")
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(client.Sandboxed(r.Context())))
+	})
+}
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"sol_privacy/internal/receipt"
+	"sol_privacy/internal/session"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SessionNonce handles issuing a wallet connect challenge
+func (h *Handler) SessionNonce(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		WalletAddress string `json:"wallet_address"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.WalletAddress == "" {
+		respondError(w, r, http.StatusBadRequest, "Missing required field: wallet_address")
+		return
+	}
+
+	challenge, err := h.sessions.IssueNonce(req.WalletAddress)
+	if errors.Is(err, session.ErrTooManyNonces) {
+		w.Header().Set("Retry-After", "60")
+		respondError(w, r, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, challenge)
+}
+
+// SessionConnect handles exchanging a signed challenge for a session token
+func (h *Handler) SessionConnect(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		WalletAddress string `json:"wallet_address"`
+		Nonce         string `json:"nonce"`
+		Signature     string `json:"signature"` // Base58 encoded signature of the challenge message
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.WalletAddress == "" || req.Nonce == "" || req.Signature == "" {
+		respondError(w, r, http.StatusBadRequest, "Missing required fields: wallet_address, nonce, signature")
+		return
+	}
+
+	sess, err := h.sessions.Connect(req.WalletAddress, req.Nonce, req.Signature)
+	if err != nil {
+		respondError(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, sess)
+}
+
+// SessionMe returns the wallet bound to the current session
+func (h *Handler) SessionMe(w http.ResponseWriter, r *http.Request) {
+	claims, _ := session.FromContext(r.Context())
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"wallet_address": claims.Wallet,
+		"expires_at":     claims.ExpiresAt,
+	})
+}
+
+// EscrowBalance handles the session wallet's escrow balance check
+func (h *Handler) EscrowBalance(w http.ResponseWriter, r *http.Request) {
+	wallet := chi.URLParam(r, "wallet")
+
+	resp, err := h.client.Escrow.GetBalance(r.Context(), wallet)
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// EscrowBalances handles the session wallet's SOL and SPL token escrow balance overview
+func (h *Handler) EscrowBalances(w http.ResponseWriter, r *http.Request) {
+	wallet := chi.URLParam(r, "wallet")
+
+	resp, err := h.client.Escrow.GetAllBalances(r.Context(), wallet)
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// ReceiptsList handles listing receipts for the session wallet
+func (h *Handler) ReceiptsList(w http.ResponseWriter, r *http.Request) {
+	wallet := chi.URLParam(r, "wallet")
+
+	resp, err := h.client.Receipt.ListUserReceipts(r.Context(), wallet, receipt.ListUserReceiptsRequest{})
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// ReceiptNFTMint handles building the unsigned mint of a compressed NFT
+// receipt for one of the session wallet's payments
+func (h *Handler) ReceiptNFTMint(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Commitment string `json:"commitment"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	resp, err := h.client.Receipt.MintNFT(r.Context(), req.Commitment)
+	if errors.Is(err, receipt.ErrUnverified) {
+		respondError(w, r, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+	// Only the merchant paid can sign the mint
+	if resp.Owner != chi.URLParam(r, "wallet") {
+		respondError(w, r, http.StatusForbidden, "receipt belongs to another merchant")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// requireSession rejects requests without a valid session token
+func (h *Handler) requireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" {
+			respondError(w, r, http.StatusUnauthorized, "session token required")
+			return
+		}
+
+		claims, err := h.sessions.Validate(token)
+		if err != nil {
+			respondError(w, r, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(session.WithClaims(r.Context(), claims)))
+	})
+}
+
+// requireWalletOwner rejects requests whose {wallet} URL param differs from the session wallet.
+// Must be mounted after requireSession.
+func requireWalletOwner(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := session.FromContext(r.Context())
+		if !ok || claims.Wallet != chi.URLParam(r, "wallet") {
+			respondError(w, r, http.StatusForbidden, "session is not authorized for this wallet")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireMerchant rejects requests without a session of one of the
+// merchant's wallets (MERCHANT_WALLETS). With none configured, every request
+// is refused.
+func (h *Handler) requireMerchant(next http.Handler) http.Handler {
+	return h.requireSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := session.FromContext(r.Context())
+		if !ok || !slices.Contains(h.merchantWallets, claims.Wallet) {
+			respondError(w, r, http.StatusForbidden, "session is not authorized for the merchant")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}))
+}
+
+// isMerchantSession reports whether the request carries a valid session of
+// one of the merchant's wallets.
+func (h *Handler) isMerchantSession(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		return false
+	}
+	claims, err := h.sessions.Validate(token)
+	return err == nil && slices.Contains(h.merchantWallets, claims.Wallet)
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"sol_privacy/internal/events"
+	"sol_privacy/internal/payment"
+	"sol_privacy/internal/settlement"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Settlement job metadata keys
+const (
+	settlementTokenMint   = "token_mint"
+	settlementTokenAmount = "token_amount"
+	settlementMaxAmount   = "max_amount"
+	settlementCustomer    = "customer_commitment"
+	settlementHoldSeconds = "hold_seconds"
+	settlementPayer       = "payer_wallet"
+)
+
+func newSettlementQueue(h *Handler) *settlement.Queue {
+	config := settlement.Config{
+		Settle: h.client.Payment.Settle,
+		// Everything but a rejection by the relayer is worth another attempt
+		Retryable: func(err error) bool {
+			_, obj := classifyError(err)
+			return obj.Code != CodeUpstreamRejected
+		},
+		OnDone: h.settlementDone,
+	}
+	if path := h.storePath("SETTLEMENTS_DB", "settlements.json"); path != "" {
+		fs, err := settlement.NewFileStore(path)
+		if err != nil {
+			log.Printf("settlements not persisted: %v", err)
+		} else {
+			config.Store = fs
+		}
+	}
+	if n, err := strconv.Atoi(h.env("SETTLEMENT_WORKERS")); err == nil {
+		config.Workers = n
+	}
+	if n, err := strconv.Atoi(h.env("SETTLEMENT_MAX_ATTEMPTS")); err == nil {
+		config.MaxAttempts = n
+	}
+	return settlement.NewQueue(config)
+}
+
+// settlementDone publishes the outcome of a queued settlement. Its
+// follow-ups, run inline by PaymentSettle, subscribe to the event.
+func (h *Handler) settlementDone(ctx context.Context, j *settlement.Job) {
+	if j.Status != settlement.StatusSucceeded {
+		log.Printf("settlement %s failed after %d attempts: %s", j.ID, j.Attempts, j.LastError)
+		h.events.Emit(ctx, events.SettlementFailed, "settlement", j)
+		return
+	}
+	h.events.Emit(ctx, events.SettlementSucceeded, "settlement", j)
+}
+
+// SettlementEnqueue handles queueing a payment settlement. The job is
+// persisted before the response, so it is settled even if the proxy restarts.
+func (h *Handler) SettlementEnqueue(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		payment.SettleRequest
+		TokenMint          string `json:"token_mint,omitempty"`
+		TokenAmount        int64  `json:"token_amount,omitempty"`
+		CustomerCommitment string `json:"customer_commitment,omitempty"`
+		HoldSeconds        int64  `json:"hold_seconds,omitempty"`
+		PayerWallet        string `json:"payer_wallet,omitempty"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.PaymentHeader == "" || req.Resource == "" {
+		respondError(w, r, http.StatusBadRequest, "Missing required fields: paymentHeader, resource")
+		return
+	}
+
+	metadata := map[string]string{}
+	if req.TokenMint != "" {
+		metadata[settlementTokenMint] = req.TokenMint
+		metadata[settlementTokenAmount] = strconv.FormatInt(req.TokenAmount, 10)
+	}
+	if req.CustomerCommitment != "" {
+		metadata[settlementCustomer] = req.CustomerCommitment
+		metadata[settlementMaxAmount] = req.PaymentRequirements.MaxAmountRequired
+	}
+	if req.Hold && req.HoldSeconds > 0 {
+		metadata[settlementHoldSeconds] = strconv.FormatInt(req.HoldSeconds, 10)
+	}
+	if req.Hold && req.PayerWallet != "" {
+		metadata[settlementPayer] = req.PayerWallet
+	}
+
+	job, err := h.settlements.Enqueue(r.Context(), req.SettleRequest, metadata)
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, job)
+}
+
+// SettlementList handles listing queued settlements, optionally by status
+func (h *Handler) SettlementList(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.settlements.List(r.Context(), settlement.Status(r.URL.Query().Get("status")))
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"jobs": jobs,
+	})
+}
+
+// SettlementGet handles fetching a queued settlement
+func (h *Handler) SettlementGet(w http.ResponseWriter, r *http.Request) {
+	job, err := h.settlements.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondSettlementError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, job)
+}
+
+// SettlementRetry handles requeueing a failed settlement
+func (h *Handler) SettlementRetry(w http.ResponseWriter, r *http.Request) {
+	job, err := h.settlements.Retry(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondSettlementError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, job)
+}
+
+// SettlementStats handles settlement queue metrics
+func (h *Handler) SettlementStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.settlements.Stats(r.Context())
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, stats)
+}
+
+func respondSettlementError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, settlement.ErrJobNotFound):
+		respondError(w, r, http.StatusNotFound, err.Error())
+	case errors.Is(err, settlement.ErrNotRetryable):
+		respondError(w, r, http.StatusConflict, err.Error())
+	default:
+		respondUpstreamError(w, r, err)
+	}
+}
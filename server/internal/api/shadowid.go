@@ -1,9 +1,11 @@
 package api
 
 import (
-	"encoding/json"
+	"context"
 	"net/http"
+	"strconv"
 
+	"sol_privacy/internal/events"
 	"sol_privacy/internal/shadowid"
 
 	"github.com/go-chi/chi/v5"
@@ -12,14 +14,13 @@ import (
 // ShadowIDAutoRegister handles auto-registration via signature
 func (h *Handler) ShadowIDAutoRegister(w http.ResponseWriter, r *http.Request) {
 	var req shadowid.AutoRegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	resp, err := h.client.ShadowID.AutoRegister(r.Context(), req)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
@@ -29,31 +30,78 @@ func (h *Handler) ShadowIDAutoRegister(w http.ResponseWriter, r *http.Request) {
 // ShadowIDRegister handles commitment registration
 func (h *Handler) ShadowIDRegister(w http.ResponseWriter, r *http.Request) {
 	var req shadowid.RegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	resp, err := h.client.ShadowID.Register(r.Context(), req)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
 	respondJSON(w, http.StatusOK, resp)
 }
 
-// ShadowIDProof handles getting a Merkle proof
+// ShadowIDRegisterBatch handles registering several commitments at once
+func (h *Handler) ShadowIDRegisterBatch(w http.ResponseWriter, r *http.Request) {
+	var req shadowid.RegisterBatchRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if len(req.Commitments) == 0 {
+		respondError(w, r, http.StatusBadRequest, "Missing required field: commitments")
+		return
+	}
+
+	resp, err := h.client.ShadowID.RegisterBatch(r.Context(), req.Commitments)
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// ShadowIDSync handles fetching leaves inserted since a given index
+func (h *Handler) ShadowIDSync(w http.ResponseWriter, r *http.Request) {
+	sinceLeaf := 0
+	if since := r.URL.Query().Get("since"); since != "" {
+		n, err := strconv.Atoi(since)
+		if err != nil || n < 0 {
+			respondError(w, r, http.StatusBadRequest, "since must be a non-negative integer")
+			return
+		}
+		sinceLeaf = n
+	}
+
+	resp, err := h.client.ShadowID.SyncTree(r.Context(), sinceLeaf)
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// ShadowIDProof handles getting a Merkle proof, against a historical root if
+// one is given
 func (h *Handler) ShadowIDProof(w http.ResponseWriter, r *http.Request) {
 	var req shadowid.ProofRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
-	resp, err := h.client.ShadowID.GetProof(r.Context(), req.Commitment)
+	var resp *shadowid.ProofResponse
+	var err error
+	if req.Root != "" {
+		resp, err = h.client.ShadowID.GetProofAtRoot(r.Context(), req.Commitment, req.Root)
+	} else {
+		resp, err = h.client.ShadowID.GetProof(r.Context(), req.Commitment)
+	}
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
@@ -64,7 +112,18 @@ func (h *Handler) ShadowIDProof(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) ShadowIDRoot(w http.ResponseWriter, r *http.Request) {
 	resp, err := h.client.ShadowID.GetRoot(r.Context())
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// ShadowIDRoots handles listing the archive of past Merkle tree roots
+func (h *Handler) ShadowIDRoots(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.client.ShadowID.ListHistoricalRoots(r.Context())
+	if err != nil {
+		respondUpstreamError(w, r, err)
 		return
 	}
 
@@ -75,15 +134,23 @@ func (h *Handler) ShadowIDRoot(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) ShadowIDStatus(w http.ResponseWriter, r *http.Request) {
 	commitment := chi.URLParam(r, "commitment")
 	if commitment == "" {
-		respondError(w, http.StatusBadRequest, "commitment required")
+		respondError(w, r, http.StatusBadRequest, "commitment required")
 		return
 	}
 
 	resp, err := h.client.ShadowID.GetStatus(r.Context(), commitment)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
 	respondJSON(w, http.StatusOK, resp)
 }
+
+// publishRootChanges publishes the ShadowID root on the event bus whenever it
+// changes, and once at startup, for verifiers streaming shadowid. events.
+func (h *Handler) publishRootChanges(ctx context.Context) {
+	for root := range h.client.ShadowID.WatchRoot(ctx) {
+		h.events.Emit(ctx, events.ShadowIDRootChanged, "shadowid", root)
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"sol_privacy/internal/shipping"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func newShippingService(h *Handler) *shipping.Service {
+	// SHIPPING_WEBHOOK_URL and EVENTS_WEBHOOK_URL consume these from the bus
+	config := shipping.Config{
+		Receipts: h.client.Receipt,
+		OnEvent: func(ctx context.Context, event shipping.Event) {
+			h.events.Emit(ctx, event.Type, "shipping", event.Order)
+		},
+	}
+	if path := h.storePath("SHIPPING_DB", "shipping.json"); path != "" {
+		fs, err := shipping.NewFileStore(path)
+		if err != nil {
+			log.Printf("shipping addresses not persisted: %v", err)
+		} else {
+			config.Store = fs
+		}
+	}
+	return shipping.NewService(config)
+}
+
+// ShippingCreate handles holding a sealed shipping address until settlement
+func (h *Handler) ShippingCreate(w http.ResponseWriter, r *http.Request) {
+	var req shipping.CreateRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	o, err := h.shipping.Create(r.Context(), req)
+	if err != nil {
+		respondShippingError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, o)
+}
+
+// ShippingGet handles fetching the status of a shipping address
+func (h *Handler) ShippingGet(w http.ResponseWriter, r *http.Request) {
+	o, err := h.shipping.Get(r.Context(), chi.URLParam(r, "commitment"))
+	if err != nil {
+		respondShippingError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, o)
+}
+
+// ShippingReveal handles releasing a sealed shipping address to the session
+// wallet once the payment to it has settled
+func (h *Handler) ShippingReveal(w http.ResponseWriter, r *http.Request) {
+	o, err := h.shipping.Reveal(r.Context(), chi.URLParam(r, "commitment"), chi.URLParam(r, "wallet"))
+	if err != nil {
+		respondShippingError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, o)
+}
+
+func respondShippingError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, shipping.ErrOrderNotFound):
+		respondError(w, r, http.StatusNotFound, err.Error())
+	case errors.Is(err, shipping.ErrOrderExists), errors.Is(err, shipping.ErrNotSettled):
+		respondError(w, r, http.StatusConflict, err.Error())
+	case errors.Is(err, shipping.ErrWrongMerchant):
+		respondError(w, r, http.StatusForbidden, err.Error())
+	default:
+		respondUpstreamError(w, r, err)
+	}
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SwapReceipts handles listing auto-swap receipts
+func (h *Handler) SwapReceipts(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"receipts": h.swaps.List(),
+	})
+}
+
+// SwapReceipt handles fetching a single auto-swap receipt
+func (h *Handler) SwapReceipt(w http.ResponseWriter, r *http.Request) {
+	receipt, ok := h.swaps.Get(chi.URLParam(r, "id"))
+	if !ok {
+		respondError(w, r, http.StatusNotFound, "Swap receipt not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, receipt)
+}
+
+// SwapConfirm handles recording the signature of a submitted swap transaction
+func (h *Handler) SwapConfirm(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ReceiptID     string `json:"receipt_id"`
+		SwapSignature string `json:"swap_signature"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.ReceiptID == "" || req.SwapSignature == "" {
+		respondError(w, r, http.StatusBadRequest, "Missing required fields: receipt_id, swap_signature")
+		return
+	}
+
+	receipt, err := h.swaps.Confirm(r.Context(), req.ReceiptID, req.SwapSignature)
+	if err != nil {
+		respondError(w, r, http.StatusConflict, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, receipt)
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"sol_privacy/internal/pricing"
+	"sol_privacy/internal/tax"
+	"sol_privacy/internal/validate"
+)
+
+func newTaxLedger(h *Handler) *tax.Service {
+	var store tax.Store
+	if path := h.storePath("TAX_DB", "tax.json"); path != "" {
+		fs, err := tax.NewFileStore(path)
+		if err != nil {
+			log.Printf("tax lots not persisted: %v", err)
+		} else {
+			store = fs
+		}
+	}
+	prices := pricing.NewCoinGecko(pricing.Config{
+		BaseURL: h.env("PRICING_URL"),
+		APIKey:  h.env("COINGECKO_API_KEY"),
+	})
+	return tax.NewService(store, prices, strings.ToLower(h.env("TAX_CURRENCY")))
+}
+
+// TaxRecord handles recording a deposit to or withdrawal from the pool or escrow
+func (h *Handler) TaxRecord(w http.ResponseWriter, r *http.Request) {
+	var req tax.RecordRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	m, err := h.tax.Record(r.Context(), req)
+	if err != nil {
+		respondTaxError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, m)
+}
+
+// TaxMovements handles listing a wallet's recorded movements
+func (h *Handler) TaxMovements(w http.ResponseWriter, r *http.Request) {
+	wallet := r.URL.Query().Get("wallet")
+	if err := validate.New().Address("wallet", wallet).Err(); err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	movements, err := h.tax.List(r.Context(), wallet)
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"movements": movements,
+	})
+}
+
+// TaxLots handles listing the lots a wallet holds
+func (h *Handler) TaxLots(w http.ResponseWriter, r *http.Request) {
+	lots, err := h.tax.Lots(r.Context(), r.URL.Query().Get("wallet"), h.taxMethod(r))
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"lots": lots,
+	})
+}
+
+// TaxReport handles a wallet's realized gains for a year
+func (h *Handler) TaxReport(w http.ResponseWriter, r *http.Request) {
+	year, err := parseTaxYear(r)
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	report, err := h.tax.Report(r.Context(), r.URL.Query().Get("wallet"), year, h.taxMethod(r))
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, report)
+}
+
+// TaxExport handles downloading a wallet's annual report as CSV or JSON
+func (h *Handler) TaxExport(w http.ResponseWriter, r *http.Request) {
+	year, err := parseTaxYear(r)
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = tax.FormatCSV
+	}
+	var buf bytes.Buffer
+	if err := h.tax.Export(r.Context(), &buf, format, q.Get("wallet"), year, h.taxMethod(r)); err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	contentType := "text/csv"
+	if format == tax.FormatJSON {
+		contentType = "application/json"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="tax-`+strconv.Itoa(year)+`.`+format+`"`)
+	w.Write(buf.Bytes())
+}
+
+// taxMethod is the method query parameter, or TAX_LOT_METHOD
+func (h *Handler) taxMethod(r *http.Request) string {
+	if method := r.URL.Query().Get("method"); method != "" {
+		return method
+	}
+	return strings.ToLower(h.env("TAX_LOT_METHOD"))
+}
+
+// parseTaxYear reads the year query parameter, defaulting to the current year.
+func parseTaxYear(r *http.Request) (int, error) {
+	s := r.URL.Query().Get("year")
+	if s == "" {
+		return time.Now().UTC().Year(), nil
+	}
+	year, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, validate.Errors{{Field: "year", Message: "must be a calendar year"}}
+	}
+	return year, nil
+}
+
+func respondTaxError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, tax.ErrDuplicate):
+		respondError(w, r, http.StatusConflict, err.Error())
+	case errors.Is(err, tax.ErrUnpriced):
+		respondValidationError(w, r, validate.Errors{{Field: "value", Message: err.Error()}})
+	default:
+		respondUpstreamError(w, r, err)
+	}
+}
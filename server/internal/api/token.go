@@ -1,7 +1,6 @@
 package api
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"sol_privacy/internal/token"
@@ -13,7 +12,42 @@ import (
 func (h *Handler) TokenList(w http.ResponseWriter, r *http.Request) {
 	resp, err := h.client.Token.ListSupported(r.Context())
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// TokenListDetailed handles listing supported tokens with on-chain metadata
+func (h *Handler) TokenListDetailed(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.client.Token.ListSupportedDetailed(r.Context())
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// TokenValidatePayment handles checking a payment against the token's guardrail
+func (h *Handler) TokenValidatePayment(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Mint   string `json:"mint"`
+		Amount int64  `json:"amount"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.Mint == "" {
+		respondError(w, r, http.StatusBadRequest, "Missing required field: mint")
+		return
+	}
+
+	resp, err := h.client.Token.ValidatePayment(r.Context(), req.Mint, req.Amount)
+	if err != nil {
+		respondUpstreamError(w, r, err)
 		return
 	}
 
@@ -23,14 +57,13 @@ func (h *Handler) TokenList(w http.ResponseWriter, r *http.Request) {
 // TokenAdd handles adding a new token
 func (h *Handler) TokenAdd(w http.ResponseWriter, r *http.Request) {
 	var req token.AddRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	resp, err := h.client.Token.Add(r.Context(), req)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
@@ -41,19 +74,18 @@ func (h *Handler) TokenAdd(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) TokenUpdate(w http.ResponseWriter, r *http.Request) {
 	mint := chi.URLParam(r, "mint")
 	if mint == "" {
-		respondError(w, http.StatusBadRequest, "mint address required")
+		respondError(w, r, http.StatusBadRequest, "mint address required")
 		return
 	}
 
 	var req token.UpdateRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	resp, err := h.client.Token.Update(r.Context(), mint, req)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
@@ -64,13 +96,13 @@ func (h *Handler) TokenUpdate(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) TokenRemove(w http.ResponseWriter, r *http.Request) {
 	mint := chi.URLParam(r, "mint")
 	if mint == "" {
-		respondError(w, http.StatusBadRequest, "mint address required")
+		respondError(w, r, http.StatusBadRequest, "mint address required")
 		return
 	}
 
 	resp, err := h.client.Token.Remove(r.Context(), mint)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
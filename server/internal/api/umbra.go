@@ -1,17 +1,18 @@
 package api
 
 import (
-	"encoding/json"
 	"net/http"
 
+	"sol_privacy/internal/amount"
 	"sol_privacy/internal/payment"
+	"sol_privacy/internal/types"
 	"sol_privacy/internal/umbra"
 )
 
 // UmbraStealthAddress generates a stealth address for anonymous payments.
 func (h *Handler) UmbraStealthAddress(w http.ResponseWriter, r *http.Request) {
 	if !h.umbraEnabled {
-		respondError(w, http.StatusNotImplemented, "Umbra integration is not enabled")
+		respondError(w, r, http.StatusNotImplemented, "Umbra integration is not enabled")
 		return
 	}
 
@@ -19,13 +20,12 @@ func (h *Handler) UmbraStealthAddress(w http.ResponseWriter, r *http.Request) {
 		RecipientPublicKey string `json:"recipient_public_key"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	if req.RecipientPublicKey == "" {
-		respondError(w, http.StatusBadRequest, "Missing required field: recipient_public_key")
+		respondError(w, r, http.StatusBadRequest, "Missing required field: recipient_public_key")
 		return
 	}
 
@@ -36,7 +36,7 @@ func (h *Handler) UmbraStealthAddress(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := h.umbraClient.GenerateStealthAddress(r.Context(), stealthReq.RecipientPublicKey)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
@@ -54,7 +54,7 @@ func (h *Handler) UmbraStealthAddress(w http.ResponseWriter, r *http.Request) {
 // UmbraDeposit deposits SOL into the Umbra privacy pool.
 func (h *Handler) UmbraDeposit(w http.ResponseWriter, r *http.Request) {
 	if !h.umbraEnabled {
-		respondError(w, http.StatusNotImplemented, "Umbra integration is not enabled")
+		respondError(w, r, http.StatusNotImplemented, "Umbra integration is not enabled")
 		return
 	}
 
@@ -64,13 +64,12 @@ func (h *Handler) UmbraDeposit(w http.ResponseWriter, r *http.Request) {
 		DestinationAddress string  `json:"destination_address,omitempty"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	if req.PrivateKey == "" || req.Amount <= 0 {
-		respondError(w, http.StatusBadRequest, "Missing required fields: private_key, amount")
+		respondError(w, r, http.StatusBadRequest, "Missing required fields: private_key, amount")
 		return
 	}
 
@@ -83,7 +82,7 @@ func (h *Handler) UmbraDeposit(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := h.umbraClient.Deposit(r.Context(), depositReq)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
@@ -104,7 +103,7 @@ func (h *Handler) UmbraDeposit(w http.ResponseWriter, r *http.Request) {
 // UmbraSend performs an anonymous/confidential transfer.
 func (h *Handler) UmbraSend(w http.ResponseWriter, r *http.Request) {
 	if !h.umbraEnabled {
-		respondError(w, http.StatusNotImplemented, "Umbra integration is not enabled")
+		respondError(w, r, http.StatusNotImplemented, "Umbra integration is not enabled")
 		return
 	}
 
@@ -115,13 +114,12 @@ func (h *Handler) UmbraSend(w http.ResponseWriter, r *http.Request) {
 		Mint             string  `json:"mint,omitempty"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	if req.PrivateKey == "" || req.RecipientAddress == "" || req.Amount <= 0 {
-		respondError(w, http.StatusBadRequest, "Missing required fields: private_key, recipient_address, amount")
+		respondError(w, r, http.StatusBadRequest, "Missing required fields: private_key, recipient_address, amount")
 		return
 	}
 
@@ -135,7 +133,7 @@ func (h *Handler) UmbraSend(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := h.umbraClient.Send(r.Context(), sendReq)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
@@ -157,7 +155,7 @@ func (h *Handler) UmbraSend(w http.ResponseWriter, r *http.Request) {
 // UmbraWithdraw withdraws funds from the Umbra privacy pool.
 func (h *Handler) UmbraWithdraw(w http.ResponseWriter, r *http.Request) {
 	if !h.umbraEnabled {
-		respondError(w, http.StatusNotImplemented, "Umbra integration is not enabled")
+		respondError(w, r, http.StatusNotImplemented, "Umbra integration is not enabled")
 		return
 	}
 
@@ -170,13 +168,12 @@ func (h *Handler) UmbraWithdraw(w http.ResponseWriter, r *http.Request) {
 		Mint                string `json:"mint,omitempty"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	if req.PrivateKey == "" || req.CommitmentIndex < 0 || req.GenerationIndex < 0 || req.DepositTime <= 0 {
-		respondError(w, http.StatusBadRequest, "Missing required fields: private_key, commitment_index, generation_index, deposit_time")
+		respondError(w, r, http.StatusBadRequest, "Missing required fields: private_key, commitment_index, generation_index, deposit_time")
 		return
 	}
 
@@ -192,7 +189,7 @@ func (h *Handler) UmbraWithdraw(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := h.umbraClient.Withdraw(r.Context(), withdrawReq)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
@@ -216,7 +213,7 @@ func (h *Handler) UmbraWithdraw(w http.ResponseWriter, r *http.Request) {
 // UmbraBalance retrieves the encrypted balance for a token.
 func (h *Handler) UmbraBalance(w http.ResponseWriter, r *http.Request) {
 	if !h.umbraEnabled {
-		respondError(w, http.StatusNotImplemented, "Umbra integration is not enabled")
+		respondError(w, r, http.StatusNotImplemented, "Umbra integration is not enabled")
 		return
 	}
 
@@ -225,13 +222,12 @@ func (h *Handler) UmbraBalance(w http.ResponseWriter, r *http.Request) {
 		Mint       string `json:"mint,omitempty"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	if req.PrivateKey == "" {
-		respondError(w, http.StatusBadRequest, "Missing required field: private_key")
+		respondError(w, r, http.StatusBadRequest, "Missing required field: private_key")
 		return
 	}
 
@@ -243,7 +239,7 @@ func (h *Handler) UmbraBalance(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := h.umbraClient.GetBalance(r.Context(), balanceReq)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
@@ -263,7 +259,7 @@ func (h *Handler) UmbraBalance(w http.ResponseWriter, r *http.Request) {
 // Generates a stealth address and prepares a ZK payment in one call.
 func (h *Handler) UmbraPrepareStealthPayment(w http.ResponseWriter, r *http.Request) {
 	if !h.umbraEnabled {
-		respondError(w, http.StatusNotImplemented, "Umbra integration is not enabled")
+		respondError(w, r, http.StatusNotImplemented, "Umbra integration is not enabled")
 		return
 	}
 
@@ -274,20 +270,19 @@ func (h *Handler) UmbraPrepareStealthPayment(w http.ResponseWriter, r *http.Requ
 		PrivateKey         string  `json:"private_key"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	if req.RecipientPublicKey == "" || req.Amount <= 0 || req.PrivateKey == "" {
-		respondError(w, http.StatusBadRequest, "Missing required fields: recipient_public_key, amount, private_key")
+		respondError(w, r, http.StatusBadRequest, "Missing required fields: recipient_public_key, amount, private_key")
 		return
 	}
 
 	// Step 1: Generate stealth address via Umbra
 	stealthResp, err := h.umbraClient.GenerateStealthAddress(r.Context(), req.RecipientPublicKey)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to generate stealth address: "+err.Error())
+		respondError(w, r, http.StatusInternalServerError, "Failed to generate stealth address: "+err.Error())
 		return
 	}
 
@@ -298,18 +293,18 @@ func (h *Handler) UmbraPrepareStealthPayment(w http.ResponseWriter, r *http.Requ
 		DestinationAddress: stealthResp.Data.EphemeralPublicKey,
 	})
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to deposit to Umbra pool: "+err.Error())
+		respondError(w, r, http.StatusInternalServerError, "Failed to deposit to Umbra pool: "+err.Error())
 		return
 	}
 
 	// Step 3: Prepare ShadowPay payment with the stealth address as commitment
 	prepareResp, err := h.client.Payment.Prepare(r.Context(), payment.PrepareRequest{
-		ReceiverCommitment: stealthResp.Data.EphemeralPublicKey,
-		Amount:             int64(req.Amount * 1e9), // Convert to lamports
+		ReceiverCommitment: types.Commitment(stealthResp.Data.EphemeralPublicKey),
+		Amount:             amount.SOL(req.Amount).Int64(),
 		TokenMint:          req.TokenMint,
 	})
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to prepare payment: "+err.Error())
+		respondError(w, r, http.StatusInternalServerError, "Failed to prepare payment: "+err.Error())
 		return
 	}
 
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"sol_privacy/internal/warehouse"
+)
+
+// newWarehouseExporter configures the Postgres export, or returns nil when
+// WAREHOUSE_URL is unset.
+func newWarehouseExporter(h *Handler) *warehouse.Exporter {
+	url := h.env("WAREHOUSE_URL")
+	if url == "" {
+		return nil
+	}
+	config := warehouse.Config{
+		URL:      url,
+		Schema:   h.env("WAREHOUSE_SCHEMA"),
+		Interval: h.envDuration("WAREHOUSE_INTERVAL", warehouse.DefaultInterval),
+		Backfill: h.envDuration("WAREHOUSE_BACKFILL", warehouse.DefaultBackfill),
+		Receipts: h.client.Receipt,
+		Merchant: h.client.Merchant,
+	}
+	for _, wallet := range strings.Split(h.env("WAREHOUSE_WALLETS"), ",") {
+		if wallet = strings.TrimSpace(wallet); wallet != "" {
+			config.Wallets = append(config.Wallets, wallet)
+		}
+	}
+	if n, err := strconv.Atoi(h.env("WAREHOUSE_PAGE_SIZE")); err == nil {
+		config.PageSize = n
+	}
+	exporter, err := warehouse.New(config)
+	if err != nil {
+		log.Printf("warehouse export disabled: %v", err)
+		return nil
+	}
+	return exporter
+}
+
+// WarehouseStatus handles reporting the progress of the Postgres export
+func (h *Handler) WarehouseStatus(w http.ResponseWriter, r *http.Request) {
+	if h.warehouse == nil {
+		respondError(w, r, http.StatusNotImplemented, "Warehouse export is not enabled")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, h.warehouse.Status())
+}
+
+// WarehouseExport handles running an export now instead of waiting for the
+// next interval
+func (h *Handler) WarehouseExport(w http.ResponseWriter, r *http.Request) {
+	if h.warehouse == nil {
+		respondError(w, r, http.StatusNotImplemented, "Warehouse export is not enabled")
+		return
+	}
+
+	counts, err := h.warehouse.Export(r.Context())
+	if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"exported": counts,
+	})
+}
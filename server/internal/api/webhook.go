@@ -2,25 +2,44 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 
 	"sol_privacy/internal/webhook"
 )
 
+func newWebhookSecrets(h *Handler) webhook.SecretStore {
+	if path := h.storePath("WEBHOOK_SECRETS_DB", "webhook-secrets.json"); path != "" {
+		fs, err := webhook.NewFileSecretStore(path)
+		if err == nil {
+			return fs
+		}
+		log.Printf("webhook secrets not persisted: %v", err)
+	}
+	return webhook.NewMemorySecretStore()
+}
+
 // WebhookRegister handles webhook registration
 func (h *Handler) WebhookRegister(w http.ResponseWriter, r *http.Request) {
 	var req webhook.RegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	resp, err := h.client.Webhook.Register(r.Context(), req)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
+	// Kept for signature verification; the API only returns it masked
+	if req.Secret != "" && resp.WebhookID != "" {
+		if err := h.webhookSecrets.PutSecret(resp.WebhookID, req.Secret); err != nil {
+			log.Printf("webhook secret not saved: %v", err)
+		}
+	}
+
 	respondJSON(w, http.StatusOK, resp)
 }
 
@@ -28,7 +47,7 @@ func (h *Handler) WebhookRegister(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) WebhookConfig(w http.ResponseWriter, r *http.Request) {
 	resp, err := h.client.Webhook.GetConfig(r.Context())
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
@@ -38,14 +57,13 @@ func (h *Handler) WebhookConfig(w http.ResponseWriter, r *http.Request) {
 // WebhookTest handles testing a webhook
 func (h *Handler) WebhookTest(w http.ResponseWriter, r *http.Request) {
 	var req webhook.TestRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	resp, err := h.client.Webhook.Test(r.Context(), req)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
@@ -63,7 +81,7 @@ func (h *Handler) WebhookLogs(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := h.client.Webhook.GetLogs(r.Context(), req)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
@@ -74,7 +92,7 @@ func (h *Handler) WebhookLogs(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) WebhookStats(w http.ResponseWriter, r *http.Request) {
 	resp, err := h.client.Webhook.GetStats(r.Context())
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
@@ -84,16 +102,46 @@ func (h *Handler) WebhookStats(w http.ResponseWriter, r *http.Request) {
 // WebhookDeactivate handles deactivating a webhook
 func (h *Handler) WebhookDeactivate(w http.ResponseWriter, r *http.Request) {
 	var req webhook.DeactivateRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	resp, err := h.client.Webhook.Deactivate(r.Context(), req)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondUpstreamError(w, r, err)
 		return
 	}
 
 	respondJSON(w, http.StatusOK, resp)
 }
+
+// WebhookVerifySignature handles checking a webhook delivery's HMAC signature,
+// for merchant backends that would rather not implement it. Secrets are those
+// of webhooks registered through the proxy, or WEBHOOK_SECRET.
+func (h *Handler) WebhookVerifySignature(w http.ResponseWriter, r *http.Request) {
+	var req webhook.VerifySignatureRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+
+	secret, err := h.webhookSecrets.Secret(req.WebhookID)
+	if errors.Is(err, webhook.ErrUnknownWebhook) {
+		secret = h.env("WEBHOOK_SECRET")
+	} else if err != nil {
+		respondUpstreamError(w, r, err)
+		return
+	}
+	if secret == "" {
+		respondError(w, r, http.StatusNotFound, "Unknown webhook")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"webhook_id": req.WebhookID,
+		"valid":      webhook.VerifySignature([]byte(req.Payload), req.Signature, secret),
+	})
+}
@@ -0,0 +1,380 @@
+// Package artifacts downloads, verifies and caches the circuit artifacts
+// client-side proofs need: each circuit's WASM witness generator, Groth16
+// proving key (zkey) and verification key.
+//
+// A Manifest pins every file by SHA-256. Sync downloads the files of the
+// manifest's version into their own cache directory and rejects any that do
+// not match their pin, so several versions can be cached side by side. Open
+// checks the files again before they are handed to a prover. Files without a
+// pin are refused unless Sync is asked to pin them, which trusts the first
+// download the way go.sum does; commit the pinned manifest.
+package artifacts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"sol_privacy/internal/jsonfile"
+)
+
+// Default artifact set: the Umbra circuits, from the Umbra SDK.
+const (
+	DefaultBaseURL = "https://umbra-zk-public.s3.eu-central-1.amazonaws.com/uploads"
+	DefaultVersion = "0000" // Trusted setup contribution of the zkeys
+)
+
+// Artifact kinds.
+const (
+	KindWASM            = "wasm"
+	KindZKey            = "zkey"
+	KindVerificationKey = "vkey"
+)
+
+var (
+	// ErrUnknownCircuit is returned for circuits not in the manifest.
+	ErrUnknownCircuit = errors.New("unknown circuit")
+	// ErrUnpinned is returned for files the manifest has no hash for.
+	ErrUnpinned = errors.New("artifact is not pinned")
+	// ErrNotCached is returned by Open for files Sync has not downloaded.
+	ErrNotCached = errors.New("artifact is not cached; run artifacts sync")
+	// ErrIntegrity is returned for files that do not match their pin.
+	ErrIntegrity = errors.New("artifact does not match its pinned hash")
+)
+
+// Artifact is a file of a circuit.
+type Artifact struct {
+	Path   string `json:"path"`             // Relative to the manifest's base URL
+	SHA256 string `json:"sha256,omitempty"` // Hex; empty until pinned
+	Size   int64  `json:"size,omitempty"`
+}
+
+// Circuit holds the artifacts of a circuit.
+type Circuit struct {
+	WASM            Artifact `json:"wasm"`
+	ZKey            Artifact `json:"zkey"`
+	VerificationKey Artifact `json:"verification_key"`
+}
+
+// Artifacts returns the circuit's files by kind.
+func (c *Circuit) Artifacts() map[string]*Artifact {
+	return map[string]*Artifact{
+		KindWASM:            &c.WASM,
+		KindZKey:            &c.ZKey,
+		KindVerificationKey: &c.VerificationKey,
+	}
+}
+
+// Manifest lists the artifacts of a version and their pins.
+type Manifest struct {
+	Version  string              `json:"version"` // Names the cache directory
+	BaseURL  string              `json:"base_url"`
+	Circuits map[string]*Circuit `json:"circuits"`
+}
+
+// DefaultManifest returns the Umbra circuits, unpinned.
+func DefaultManifest() *Manifest {
+	circuit := func(name string) *Circuit {
+		return &Circuit{
+			WASM:            Artifact{Path: "/" + name + ".wasm"},
+			ZKey:            Artifact{Path: "/" + name + "_" + DefaultVersion + ".zkey"},
+			VerificationKey: Artifact{Path: "/" + name + "_verification_key.json"},
+		}
+	}
+	return &Manifest{
+		Version: DefaultVersion,
+		BaseURL: DefaultBaseURL,
+		Circuits: map[string]*Circuit{
+			"masterViewingKeyRegistration":     circuit("master_viewing_key_registration"),
+			"createSplDepositWithHiddenAmount": circuit("create_spl_deposit_with_hidden_amount"),
+			"createSplDepositWithPublicAmount": circuit("create_spl_deposit_with_public_amount"),
+			"claimSplDepositWithHiddenAmount":  circuit("claim_spl_deposit_with_hidden_amount"),
+			"claimSplDeposit":                  circuit("claim_spl_deposit_with_public_amount"),
+		},
+	}
+}
+
+// LoadManifest reads the manifest at path, or returns DefaultManifest if
+// there is none.
+func LoadManifest(path string) (*Manifest, error) {
+	m := DefaultManifest()
+	if err := jsonfile.Load(path, m); err != nil {
+		return nil, err
+	}
+	if m.Version == "" || strings.ContainsAny(m.Version, `/\`) || m.Version == ".." {
+		return nil, fmt.Errorf("%s: invalid version %q", path, m.Version)
+	}
+	return m, nil
+}
+
+// Save writes the manifest to path.
+func (m *Manifest) Save(path string) error {
+	return jsonfile.Save(path, m)
+}
+
+// CircuitNames returns the manifest's circuits, sorted.
+func (m *Manifest) CircuitNames() []string {
+	names := make([]string, 0, len(m.Circuits))
+	for name := range m.Circuits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultDir returns $SHADOWPAY_ARTIFACTS_DIR, or ~/.shadowpay/artifacts.
+func DefaultDir() string {
+	if dir := os.Getenv("SHADOWPAY_ARTIFACTS_DIR"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".shadowpay", "artifacts")
+}
+
+// Cache keeps the artifacts of a manifest under dir/<version>.
+type Cache struct {
+	dir        string
+	manifest   *Manifest
+	httpClient *http.Client
+}
+
+// NewCache creates a cache in dir for the manifest.
+func NewCache(dir string, manifest *Manifest) *Cache {
+	return &Cache{
+		dir:        dir,
+		manifest:   manifest,
+		httpClient: &http.Client{Timeout: 10 * time.Minute}, // zkeys run to tens of MB
+	}
+}
+
+// Manifest returns the cache's manifest, with any pins added by Sync.
+func (c *Cache) Manifest() *Manifest {
+	return c.manifest
+}
+
+// Paths are the local files of a circuit, checked against their pins.
+type Paths struct {
+	WASM            string
+	ZKey            string
+	VerificationKey string
+}
+
+// Open returns the cached files of a circuit after checking each against its
+// pin. Call it right before proving, so a file changed on disk since Sync is
+// never used.
+func (c *Cache) Open(circuit string) (*Paths, error) {
+	cc, ok := c.manifest.Circuits[circuit]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCircuit, circuit)
+	}
+	for kind, f := range cc.Artifacts() {
+		if err := c.check(f); err != nil {
+			return nil, fmt.Errorf("%s %s: %w", circuit, kind, err)
+		}
+	}
+	return &Paths{
+		WASM:            c.path(&cc.WASM),
+		ZKey:            c.path(&cc.ZKey),
+		VerificationKey: c.path(&cc.VerificationKey),
+	}, nil
+}
+
+// File returns the cached file of a circuit's artifact after checking it
+// against its pin.
+func (c *Cache) File(circuit, kind string) (string, error) {
+	cc, ok := c.manifest.Circuits[circuit]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownCircuit, circuit)
+	}
+	f, ok := cc.Artifacts()[kind]
+	if !ok {
+		return "", fmt.Errorf("unknown artifact kind %q", kind)
+	}
+	if err := c.check(f); err != nil {
+		return "", fmt.Errorf("%s %s: %w", circuit, kind, err)
+	}
+	return c.path(f), nil
+}
+
+// Verify checks every cached file of the manifest against its pin.
+func (c *Cache) Verify() error {
+	var errs []error
+	for _, name := range c.manifest.CircuitNames() {
+		if _, err := c.Open(name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SyncOptions configures Sync.
+type SyncOptions struct {
+	Circuits []string // Defaults to every circuit in the manifest
+	Pin      bool     // Pin unpinned files to their download; see the package doc
+
+	// Progress is called after each file. May be nil.
+	Progress func(circuit, kind string, f Artifact, downloaded bool)
+}
+
+// Sync downloads the files missing from the cache, or not matching their
+// pin, and checks each download against its pin. With Pin set, unpinned
+// files are pinned in the manifest; save it afterwards.
+func (c *Cache) Sync(ctx context.Context, opts SyncOptions) error {
+	names := opts.Circuits
+	if len(names) == 0 {
+		names = c.manifest.CircuitNames()
+	}
+	for _, name := range names {
+		cc, ok := c.manifest.Circuits[name]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownCircuit, name)
+		}
+		for _, kind := range []string{KindWASM, KindZKey, KindVerificationKey} {
+			f := cc.Artifacts()[kind]
+			downloaded := false
+			if err := c.check(f); err != nil {
+				if errors.Is(err, ErrUnpinned) && !opts.Pin {
+					return fmt.Errorf("%s %s: %w; sync with pinning to trust the download", name, kind, err)
+				}
+				if err := c.download(ctx, f); err != nil {
+					return fmt.Errorf("%s %s: %w", name, kind, err)
+				}
+				downloaded = true
+			}
+			if opts.Progress != nil {
+				opts.Progress(name, kind, *f, downloaded)
+			}
+		}
+	}
+	return nil
+}
+
+// Versions returns the versions in the cache.
+func (c *Cache) Versions() ([]string, error) {
+	entries, err := os.ReadDir(c.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, e := range entries {
+		if e.IsDir() {
+			versions = append(versions, e.Name())
+		}
+	}
+	return versions, nil
+}
+
+// Prune removes the cached versions other than the manifest's.
+func (c *Cache) Prune() ([]string, error) {
+	versions, err := c.Versions()
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, v := range versions {
+		if v == c.manifest.Version {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(c.dir, v)); err != nil {
+			return removed, err
+		}
+		removed = append(removed, v)
+	}
+	return removed, nil
+}
+
+func (c *Cache) path(f *Artifact) string {
+	return filepath.Join(c.dir, c.manifest.Version, path.Base(f.Path))
+}
+
+// check compares the cached file with its pin.
+func (c *Cache) check(f *Artifact) error {
+	if f.SHA256 == "" {
+		return ErrUnpinned
+	}
+	sum, size, err := hashFile(c.path(f))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotCached
+	}
+	if err != nil {
+		return err
+	}
+	if sum != strings.ToLower(f.SHA256) || (f.Size > 0 && size != f.Size) {
+		return ErrIntegrity
+	}
+	return nil
+}
+
+// download fetches a file into the cache if it matches its pin, pinning it
+// first if it has none.
+func (c *Cache) download(ctx context.Context, f *Artifact) error {
+	url := strings.TrimSuffix(c.manifest.BaseURL, "/") + "/" + strings.TrimPrefix(f.Path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: status %d", url, resp.StatusCode)
+	}
+
+	dst := c.path(f)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dst), filepath.Base(dst)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), resp.Body)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", url, err)
+	}
+
+	sum := hex.EncodeToString(h.Sum(nil))
+	if f.SHA256 == "" {
+		f.SHA256, f.Size = sum, size
+	} else if sum != strings.ToLower(f.SHA256) || (f.Size > 0 && size != f.Size) {
+		return fmt.Errorf("%w: %s has sha256 %s", ErrIntegrity, url, sum)
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+func hashFile(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, file)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
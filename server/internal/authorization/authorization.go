@@ -1,13 +1,29 @@
 package authorization
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/types"
+	"sol_privacy/internal/validate"
 )
 
 // Service handles automated payment authorization for bots and services.
 type Service struct {
 	doRequest func(ctx context.Context, method, path string, body, result interface{}) error
+
+	alertMu        sync.Mutex
+	alertThreshold float64
+	alertHandler   AlertHandler
+	alertsFired    map[string]bool // "<authorization id>/<reset date>" already alerted
+
+	caps types.Capabilities
 }
 
 // NewService creates a new authorization service.
@@ -17,6 +33,12 @@ func NewService(doRequest func(ctx context.Context, method, path string, body, r
 	}
 }
 
+// SetCapabilities enables multisig authorizations if the API advertises
+// them. Call it before using the service.
+func (s *Service) SetCapabilities(c types.Capabilities) {
+	s.caps = c
+}
+
 // AuthorizeSpendingRequest represents a request to authorize bot/service spending.
 type AuthorizeSpendingRequest struct {
 	UserWallet        string `json:"user_wallet"`
@@ -27,6 +49,17 @@ type AuthorizeSpendingRequest struct {
 	UserSignature     string `json:"user_signature"`       // Base58 encoded signature
 }
 
+// Validate checks the request fields.
+func (r AuthorizeSpendingRequest) Validate() error {
+	return validate.New().
+		Address("user_wallet", r.UserWallet).
+		Required("authorized_service", r.AuthorizedService).
+		Required("max_amount_per_tx", r.MaxAmountPerTx).
+		Required("max_daily_spend", r.MaxDailySpend).
+		Required("user_signature", r.UserSignature).
+		Err()
+}
+
 // AuthorizeSpendingResponse contains the authorization confirmation.
 type AuthorizeSpendingResponse struct {
 	Success         bool   `json:"success"`
@@ -48,6 +81,24 @@ type RevokeAuthorizationResponse struct {
 	AuthorizationID int    `json:"authorization_id"`
 }
 
+// UpdateAuthorizationRequest represents a request to change an authorization's limits in place.
+// Unset fields keep their current value. SpentToday is preserved.
+type UpdateAuthorizationRequest struct {
+	UserWallet        string  `json:"user_wallet"`
+	AuthorizedService string  `json:"authorized_service"`
+	MaxAmountPerTx    *string `json:"max_amount_per_tx,omitempty"` // In SOL (string format)
+	MaxDailySpend     *string `json:"max_daily_spend,omitempty"`   // In SOL (string format)
+	ValidUntil        *int64  `json:"valid_until,omitempty"`       // Unix timestamp
+	UserSignature     string  `json:"user_signature"`              // Base58 encoded signature
+}
+
+// UpdateAuthorizationResponse contains the updated authorization.
+type UpdateAuthorizationResponse struct {
+	Success       bool          `json:"success"`
+	Message       string        `json:"message"`
+	Authorization Authorization `json:"authorization"`
+}
+
 // Authorization represents a spending authorization for a bot/service.
 type Authorization struct {
 	ID                int    `json:"id"`
@@ -67,10 +118,164 @@ type ListAuthorizationsResponse struct {
 	Authorizations []Authorization `json:"authorizations"`
 }
 
+// DailyUsage contains the spend of one authorization on a single day.
+type DailyUsage struct {
+	Date             string `json:"date"`              // YYYY-MM-DD (UTC)
+	Spent            int64  `json:"spent"`             // In lamports
+	TransactionCount int    `json:"transaction_count"`
+	DailyLimit       int64  `json:"daily_limit"`       // In lamports, limit in effect that day
+}
+
+// UsageHistoryResponse contains per-day spend for a bot/service authorization.
+type UsageHistoryResponse struct {
+	UserWallet        string       `json:"user_wallet"`
+	AuthorizedService string       `json:"authorized_service"`
+	Days              []DailyUsage `json:"days"`
+}
+
+// SpendAlert is raised when an authorization crosses the configured share of its daily limit.
+type SpendAlert struct {
+	AuthorizationID   int     `json:"authorization_id"`
+	UserWallet        string  `json:"user_wallet"`
+	AuthorizedService string  `json:"authorized_service"`
+	SpentToday        int64   `json:"spent_today"`     // In lamports
+	MaxDailySpend     int64   `json:"max_daily_spend"` // In lamports
+	UsageRatio        float64 `json:"usage_ratio"`     // SpentToday / MaxDailySpend
+	Threshold         float64 `json:"threshold"`
+	Date              string  `json:"date"`
+}
+
+// AlertHandler is called once per authorization per day when its spend crosses the threshold.
+type AlertHandler func(ctx context.Context, alert SpendAlert)
+
+// GetUsageHistory retrieves per-day spend for a bot/service authorization.
+func (s *Service) GetUsageHistory(ctx context.Context, walletAddress, service string) (*UsageHistoryResponse, error) {
+	var resp UsageHistoryResponse
+	path := fmt.Sprintf("/shadowpay/api/authorization-usage/%s/%s", url.PathEscape(walletAddress), url.PathEscape(service))
+	if err := s.doRequest(ctx, "GET", path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SetSpendAlert configures a handler fired when an authorization's SpentToday reaches
+// threshold (0-1] of its MaxDailySpend, e.g. 0.8 for 80%. A nil handler disables alerts.
+func (s *Service) SetSpendAlert(threshold float64, handler AlertHandler) error {
+	if handler != nil && (threshold <= 0 || threshold > 1) {
+		return fmt.Errorf("alert threshold must be in (0, 1], got %v", threshold)
+	}
+
+	s.alertMu.Lock()
+	defer s.alertMu.Unlock()
+	s.alertThreshold = threshold
+	s.alertHandler = handler
+	s.alertsFired = make(map[string]bool)
+	return nil
+}
+
+// CheckSpendAlerts lists the wallet's authorizations and fires the configured alert
+// handler for each one over the threshold. Each authorization alerts at most once per day.
+// It returns the alerts raised by this call.
+func (s *Service) CheckSpendAlerts(ctx context.Context, walletAddress string) ([]SpendAlert, error) {
+	s.alertMu.Lock()
+	handler, threshold := s.alertHandler, s.alertThreshold
+	s.alertMu.Unlock()
+	if handler == nil {
+		return nil, nil
+	}
+
+	resp, err := s.ListAuthorizations(ctx, walletAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	var raised []SpendAlert
+	for _, auth := range resp.Authorizations {
+		if auth.Revoked || auth.MaxDailySpend <= 0 {
+			continue
+		}
+		ratio := float64(auth.SpentToday) / float64(auth.MaxDailySpend)
+		if ratio < threshold {
+			continue
+		}
+
+		date := auth.LastResetDate
+		if date == "" {
+			date = time.Now().UTC().Format("2006-01-02")
+		}
+		key := fmt.Sprintf("%d/%s", auth.ID, date)
+
+		s.alertMu.Lock()
+		fired := s.alertsFired[key]
+		s.alertsFired[key] = true
+		s.alertMu.Unlock()
+		if fired {
+			continue
+		}
+
+		alert := SpendAlert{
+			AuthorizationID:   auth.ID,
+			UserWallet:        auth.UserWallet,
+			AuthorizedService: auth.AuthorizedService,
+			SpentToday:        auth.SpentToday,
+			MaxDailySpend:     auth.MaxDailySpend,
+			UsageRatio:        ratio,
+			Threshold:         threshold,
+			Date:              date,
+		}
+		handler(ctx, alert)
+		raised = append(raised, alert)
+	}
+	return raised, nil
+}
+
+// WebhookAlertHandler returns an AlertHandler that POSTs each alert as JSON to url.
+// Delivery errors are reported to onError if it is non-nil.
+func WebhookAlertHandler(url string, httpClient *http.Client, onError func(error)) AlertHandler {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return func(ctx context.Context, alert SpendAlert) {
+		if err := postAlert(ctx, httpClient, url, alert); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
+
+func postAlert(ctx context.Context, httpClient *http.Client, url string, alert SpendAlert) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"event": "authorization.spend_alert",
+		"data":  alert,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create alert request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("alert delivery failed: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("alert webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
 // AuthorizeSpending registers a bot/service to spend from user's escrow automatically.
 // Includes per-transaction and daily limits with expiration.
 // User must sign the authorization message to prove ownership.
 func (s *Service) AuthorizeSpending(ctx context.Context, req AuthorizeSpendingRequest) (*AuthorizeSpendingResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
 	var resp AuthorizeSpendingResponse
 	if err := s.doRequest(ctx, "POST", "/shadowpay/api/authorize-spending", req, &resp); err != nil {
 		return nil, err
@@ -88,11 +293,26 @@ func (s *Service) RevokeAuthorization(ctx context.Context, req RevokeAuthorizati
 	return &resp, nil
 }
 
+// UpdateAuthorization changes the per-transaction limit, daily limit and/or expiry of an
+// existing authorization without revoking it, so the day's spend counter is kept.
+// User must sign the update message to prove ownership.
+func (s *Service) UpdateAuthorization(ctx context.Context, req UpdateAuthorizationRequest) (*UpdateAuthorizationResponse, error) {
+	if req.MaxAmountPerTx == nil && req.MaxDailySpend == nil && req.ValidUntil == nil {
+		return nil, fmt.Errorf("update must change at least one of max_amount_per_tx, max_daily_spend, valid_until")
+	}
+
+	var resp UpdateAuthorizationResponse
+	if err := s.doRequest(ctx, "POST", "/shadowpay/api/update-authorization", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
 // ListAuthorizations retrieves all active spending authorizations for a user wallet.
 // Shows per-transaction limits, daily spending caps, current usage, and expiration.
 func (s *Service) ListAuthorizations(ctx context.Context, walletAddress string) (*ListAuthorizationsResponse, error) {
 	var resp ListAuthorizationsResponse
-	path := fmt.Sprintf("/shadowpay/api/my-authorizations/%s", walletAddress)
+	path := fmt.Sprintf("/shadowpay/api/my-authorizations/%s", url.PathEscape(walletAddress))
 	if err := s.doRequest(ctx, "GET", path, nil, &resp); err != nil {
 		return nil, err
 	}
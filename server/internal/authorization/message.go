@@ -0,0 +1,97 @@
+package authorization
+
+import (
+	"fmt"
+	"strings"
+
+	"sol_privacy/internal/base58"
+	"sol_privacy/internal/wallet"
+)
+
+// BuildAuthorizationMessage returns the canonical message the user signs to authorize spending.
+func BuildAuthorizationMessage(req AuthorizeSpendingRequest) string {
+	return fmt.Sprintf("ShadowPay Spending Authorization\nWallet: %s\nService: %s\nMax per transaction: %s SOL\nMax daily spend: %s SOL\nValid until: %d",
+		req.UserWallet, req.AuthorizedService, req.MaxAmountPerTx, req.MaxDailySpend, req.ValidUntil)
+}
+
+// BuildRevocationMessage returns the canonical message the user signs to revoke an authorization.
+func BuildRevocationMessage(req RevokeAuthorizationRequest) string {
+	return fmt.Sprintf("ShadowPay Revoke Authorization\nWallet: %s\nService: %s",
+		req.UserWallet, req.AuthorizedService)
+}
+
+// BuildUpdateMessage returns the canonical message the user signs to change an authorization.
+// Only the fields being changed are included.
+func BuildUpdateMessage(req UpdateAuthorizationRequest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "ShadowPay Update Authorization\nWallet: %s\nService: %s", req.UserWallet, req.AuthorizedService)
+	if req.MaxAmountPerTx != nil {
+		fmt.Fprintf(&b, "\nMax per transaction: %s SOL", *req.MaxAmountPerTx)
+	}
+	if req.MaxDailySpend != nil {
+		fmt.Fprintf(&b, "\nMax daily spend: %s SOL", *req.MaxDailySpend)
+	}
+	if req.ValidUntil != nil {
+		fmt.Fprintf(&b, "\nValid until: %d", *req.ValidUntil)
+	}
+	return b.String()
+}
+
+// SignAuthorization signs the authorization message with the user's wallet and sets
+// req.UserSignature. If req.UserWallet is empty it is filled from the signer.
+func SignAuthorization(signer wallet.Signer, req *AuthorizeSpendingRequest) error {
+	if err := bindWallet(signer, &req.UserWallet); err != nil {
+		return err
+	}
+	sig, err := sign(signer, BuildAuthorizationMessage(*req))
+	if err != nil {
+		return err
+	}
+	req.UserSignature = sig
+	return nil
+}
+
+// SignRevocation signs the revocation message and sets req.UserSignature.
+func SignRevocation(signer wallet.Signer, req *RevokeAuthorizationRequest) error {
+	if err := bindWallet(signer, &req.UserWallet); err != nil {
+		return err
+	}
+	sig, err := sign(signer, BuildRevocationMessage(*req))
+	if err != nil {
+		return err
+	}
+	req.UserSignature = sig
+	return nil
+}
+
+// SignUpdate signs the update message and sets req.UserSignature.
+func SignUpdate(signer wallet.Signer, req *UpdateAuthorizationRequest) error {
+	if err := bindWallet(signer, &req.UserWallet); err != nil {
+		return err
+	}
+	sig, err := sign(signer, BuildUpdateMessage(*req))
+	if err != nil {
+		return err
+	}
+	req.UserSignature = sig
+	return nil
+}
+
+func bindWallet(signer wallet.Signer, userWallet *string) error {
+	if *userWallet == "" {
+		*userWallet = signer.Address()
+		return nil
+	}
+	if *userWallet != signer.Address() {
+		return fmt.Errorf("signer %s does not match user wallet %s", signer.Address(), *userWallet)
+	}
+	return nil
+}
+
+func sign(signer wallet.Signer, message string) (string, error) {
+	sig, err := signer.SignMessage([]byte(message))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign authorization message: %w", err)
+	}
+	return base58.Encode(sig), nil
+}
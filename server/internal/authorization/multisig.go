@@ -0,0 +1,298 @@
+package authorization
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/base58"
+	"sol_privacy/internal/jsonfile"
+	"sol_privacy/internal/types"
+	"sol_privacy/internal/wallet"
+)
+
+const (
+	// DefaultMultisigTTL is how long signers have to sign a new multisig
+	// authorization.
+	DefaultMultisigTTL = 24 * time.Hour
+	// MaxMultisigTTL is the furthest ahead a multisig authorization's
+	// signatures may expire.
+	MaxMultisigTTL = 7 * 24 * time.Hour
+)
+
+// ErrNonceUsed is returned for a multisig authorization already submitted.
+var ErrNonceUsed = errors.New("multisig authorization nonce already used")
+
+// MultisigSignature is one signer's approval of a multisig authorization.
+type MultisigSignature struct {
+	Signer    string `json:"signer"`    // Base58 wallet address
+	Signature string `json:"signature"` // Base58 encoded signature of the canonical message
+}
+
+// MultisigAuthorizeRequest represents an authorization that requires M-of-N signatures,
+// e.g. a DAO treasury authorizing an agent to spend from its escrow. The signer set
+// and threshold count only when the treasury wallet itself signed them, so whoever
+// submits the request cannot name signers of their own. The treasury and the
+// signers also sign its nonce and expiry, so the signatures authorize it once
+// and only until then.
+type MultisigAuthorizeRequest struct {
+	TreasuryWallet    string              `json:"treasury_wallet"`    // Escrow the service spends from
+	Signers           []string            `json:"signers"`            // N eligible signer wallets
+	Threshold         int                 `json:"threshold"`          // M signatures required
+	TreasurySignature string              `json:"treasury_signature"` // Base58 treasury signature of the signer set message
+	AuthorizedService string              `json:"authorized_service"`
+	MaxAmountPerTx    string              `json:"max_amount_per_tx"` // In SOL (string format)
+	MaxDailySpend     string              `json:"max_daily_spend"`   // In SOL (string format)
+	ValidUntil        int64               `json:"valid_until"`       // Unix timestamp
+	Nonce             string              `json:"nonce"`             // Random hex, unique per authorization
+	ExpiresAt         int64               `json:"expires_at"`        // Unix timestamp after which the signatures are void
+	Signatures        []MultisigSignature `json:"signatures"`
+}
+
+// NewMultisigAuthorization creates an unsigned M-of-N authorization request
+// with a fresh nonce, expiring after DefaultMultisigTTL. Signers are sorted so
+// every participant builds the same canonical message.
+func NewMultisigAuthorization(treasury string, signers []string, threshold int, service, maxPerTx, maxDaily string, validUntil int64) (*MultisigAuthorizeRequest, error) {
+	req := &MultisigAuthorizeRequest{
+		TreasuryWallet:    treasury,
+		Signers:           append([]string(nil), signers...),
+		Threshold:         threshold,
+		AuthorizedService: service,
+		MaxAmountPerTx:    maxPerTx,
+		MaxDailySpend:     maxDaily,
+		ValidUntil:        validUntil,
+		Nonce:             newNonce(),
+		ExpiresAt:         time.Now().Add(DefaultMultisigTTL).Unix(),
+	}
+	sort.Strings(req.Signers)
+	if err := req.validateSignerSet(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// BuildMultisigAuthorizationMessage returns the canonical message each signer signs.
+// Signatures are excluded so partial collection does not change the message.
+func BuildMultisigAuthorizationMessage(req MultisigAuthorizeRequest) string {
+	signers := append([]string(nil), req.Signers...)
+	sort.Strings(signers)
+	return fmt.Sprintf("ShadowPay Multisig Spending Authorization\nTreasury: %s\nSigners: %s\nThreshold: %d\nService: %s\nMax per transaction: %s SOL\nMax daily spend: %s SOL\nValid until: %d\nNonce: %s\nExpires at: %d",
+		req.TreasuryWallet, strings.Join(signers, ","), req.Threshold, req.AuthorizedService,
+		req.MaxAmountPerTx, req.MaxDailySpend, req.ValidUntil, req.Nonce, req.ExpiresAt)
+}
+
+// BuildMultisigSignerSetMessage returns the message the treasury wallet signs to
+// name its signers and threshold for the authorization with the request's nonce
+// and expiry.
+func BuildMultisigSignerSetMessage(req MultisigAuthorizeRequest) string {
+	signers := append([]string(nil), req.Signers...)
+	sort.Strings(signers)
+	return fmt.Sprintf("ShadowPay Multisig Signer Set\nTreasury: %s\nSigners: %s\nThreshold: %d\nNonce: %s\nExpires at: %d",
+		req.TreasuryWallet, strings.Join(signers, ","), req.Threshold, req.Nonce, req.ExpiresAt)
+}
+
+// SignSignerSet records the treasury wallet's signature of the request's signer set.
+func (r *MultisigAuthorizeRequest) SignSignerSet(treasury wallet.Signer) error {
+	if treasury.Address() != r.TreasuryWallet {
+		return fmt.Errorf("%s is not the treasury wallet %s", treasury.Address(), r.TreasuryWallet)
+	}
+	sig, err := treasury.SignMessage([]byte(BuildMultisigSignerSetMessage(*r)))
+	if err != nil {
+		return fmt.Errorf("failed to sign multisig signer set: %w", err)
+	}
+	r.TreasurySignature = base58.Encode(sig)
+	return nil
+}
+
+// AddSignature signs the request with one of its eligible signers and records the signature.
+func (r *MultisigAuthorizeRequest) AddSignature(signer wallet.Signer) error {
+	sig, err := signer.SignMessage([]byte(BuildMultisigAuthorizationMessage(*r)))
+	if err != nil {
+		return fmt.Errorf("failed to sign multisig authorization: %w", err)
+	}
+	return r.AddPartialSignature(MultisigSignature{
+		Signer:    signer.Address(),
+		Signature: base58.Encode(sig),
+	})
+}
+
+// AddPartialSignature verifies and records a signature collected out of band.
+// A later signature from the same signer replaces the earlier one.
+func (r *MultisigAuthorizeRequest) AddPartialSignature(sig MultisigSignature) error {
+	if !r.isSigner(sig.Signer) {
+		return fmt.Errorf("%s is not an eligible signer", sig.Signer)
+	}
+	if err := wallet.VerifySignature(sig.Signer, []byte(BuildMultisigAuthorizationMessage(*r)), sig.Signature); err != nil {
+		return err
+	}
+
+	for i, existing := range r.Signatures {
+		if existing.Signer == sig.Signer {
+			r.Signatures[i] = sig
+			return nil
+		}
+	}
+	r.Signatures = append(r.Signatures, sig)
+	return nil
+}
+
+// Missing returns the eligible signers that have not signed yet.
+func (r *MultisigAuthorizeRequest) Missing() []string {
+	signed := make(map[string]bool, len(r.Signatures))
+	for _, s := range r.Signatures {
+		signed[s.Signer] = true
+	}
+	var missing []string
+	for _, s := range r.Signers {
+		if !signed[s] {
+			missing = append(missing, s)
+		}
+	}
+	return missing
+}
+
+// IsComplete reports whether at least Threshold valid signatures have been collected.
+func (r *MultisigAuthorizeRequest) IsComplete() bool {
+	return VerifyMultisig(*r) == nil
+}
+
+// VerifyMultisig checks that the request has a nonce and has not expired, that
+// the treasury wallet signed the signer set and threshold, and that the request
+// carries at least Threshold valid signatures from distinct eligible signers.
+func VerifyMultisig(req MultisigAuthorizeRequest) error {
+	if err := req.validateSignerSet(); err != nil {
+		return err
+	}
+	if req.Nonce == "" {
+		return fmt.Errorf("multisig authorization requires a nonce")
+	}
+	now := time.Now()
+	if req.ExpiresAt <= now.Unix() {
+		return fmt.Errorf("multisig authorization signatures expired")
+	}
+	if req.ExpiresAt > now.Add(MaxMultisigTTL).Unix() {
+		return fmt.Errorf("multisig authorization may expire at most %s ahead", MaxMultisigTTL)
+	}
+	signerSet := []byte(BuildMultisigSignerSetMessage(req))
+	if req.TreasurySignature == "" {
+		return fmt.Errorf("multisig signer set is not signed by the treasury wallet")
+	}
+	if err := wallet.VerifySignature(req.TreasuryWallet, signerSet, req.TreasurySignature); err != nil {
+		return fmt.Errorf("multisig signer set is not signed by the treasury wallet: %w", err)
+	}
+
+	message := []byte(BuildMultisigAuthorizationMessage(req))
+	valid := make(map[string]bool)
+	for _, sig := range req.Signatures {
+		if !req.isSigner(sig.Signer) || valid[sig.Signer] {
+			continue
+		}
+		if wallet.VerifySignature(sig.Signer, message, sig.Signature) == nil {
+			valid[sig.Signer] = true
+		}
+	}
+
+	if len(valid) < req.Threshold {
+		return fmt.Errorf("multisig authorization has %d of %d required signatures", len(valid), req.Threshold)
+	}
+	return nil
+}
+
+// AuthorizeMultisig registers an M-of-N authorization once enough signatures are collected.
+// Signatures are verified locally before the request is sent.
+func (s *Service) AuthorizeMultisig(ctx context.Context, req MultisigAuthorizeRequest) (*AuthorizeSpendingResponse, error) {
+	if err := VerifyMultisig(req); err != nil {
+		return nil, err
+	}
+	if err := types.Require(ctx, s.caps, types.FeatureMultisig); err != nil {
+		return nil, err
+	}
+
+	var resp AuthorizeSpendingResponse
+	if err := s.doRequest(ctx, "POST", "/shadowpay/api/authorize-spending-multisig", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UsedNonces records the nonces of submitted multisig authorizations until
+// they expire, so the same signatures cannot authorize twice.
+type UsedNonces struct {
+	nonces *jsonfile.Store[usedNonce]
+	mu     sync.Mutex // Serializes checks with writes
+}
+
+type usedNonce struct {
+	Nonce     string `json:"nonce"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// NewUsedNonces opens the nonces recorded at path, creating the file on first
+// write, or keeps them in memory if path is empty.
+func NewUsedNonces(path string) (*UsedNonces, error) {
+	nonces, err := jsonfile.NewStore(path, func(n *usedNonce) string { return n.Nonce }, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &UsedNonces{nonces: nonces}, nil
+}
+
+// Use records the request's nonce, returning ErrNonceUsed if it was recorded
+// before. Expired nonces are dropped, since VerifyMultisig rejects their
+// requests anyway.
+func (u *UsedNonces) Use(req MultisigAuthorizeRequest) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	now := time.Now().Unix()
+	if err := u.nonces.DeleteFunc(func(n *usedNonce) bool { return n.ExpiresAt <= now }); err != nil {
+		return err
+	}
+	if _, ok := u.nonces.Get(req.Nonce); ok {
+		return ErrNonceUsed
+	}
+	return u.nonces.Put(&usedNonce{Nonce: req.Nonce, ExpiresAt: req.ExpiresAt})
+}
+
+func newNonce() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (r *MultisigAuthorizeRequest) validateSignerSet() error {
+	if _, err := wallet.PublicKey(r.TreasuryWallet); err != nil {
+		return fmt.Errorf("invalid treasury wallet: %w", err)
+	}
+	if len(r.Signers) == 0 {
+		return fmt.Errorf("multisig authorization requires at least one signer")
+	}
+	if r.Threshold < 1 || r.Threshold > len(r.Signers) {
+		return fmt.Errorf("threshold must be between 1 and %d, got %d", len(r.Signers), r.Threshold)
+	}
+	seen := make(map[string]bool, len(r.Signers))
+	for _, s := range r.Signers {
+		if _, err := wallet.PublicKey(s); err != nil {
+			return err
+		}
+		if seen[s] {
+			return fmt.Errorf("duplicate signer %s", s)
+		}
+		seen[s] = true
+	}
+	return nil
+}
+
+func (r *MultisigAuthorizeRequest) isSigner(address string) bool {
+	for _, s := range r.Signers {
+		if s == address {
+			return true
+		}
+	}
+	return false
+}
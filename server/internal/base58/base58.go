@@ -0,0 +1,76 @@
+// Package base58 implements the Bitcoin base58 alphabet used by Solana for
+// public keys and signatures.
+package base58
+
+import (
+	"fmt"
+	"math/big"
+)
+
+const alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var decodeMap [256]int
+
+func init() {
+	for i := range decodeMap {
+		decodeMap[i] = -1
+	}
+	for i := 0; i < len(alphabet); i++ {
+		decodeMap[alphabet[i]] = i
+	}
+}
+
+// Encode returns the base58 encoding of b.
+func Encode(b []byte) string {
+	zeros := 0
+	for zeros < len(b) && b[zeros] == 0 {
+		zeros++
+	}
+
+	n := new(big.Int).SetBytes(b)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, alphabet[mod.Int64()])
+	}
+	for i := 0; i < zeros; i++ {
+		out = append(out, alphabet[0])
+	}
+
+	// Reverse in place
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+// Decode decodes a base58 string into bytes.
+func Decode(s string) ([]byte, error) {
+	if s == "" {
+		return nil, fmt.Errorf("base58: empty input")
+	}
+
+	zeros := 0
+	for zeros < len(s) && s[zeros] == alphabet[0] {
+		zeros++
+	}
+
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for i := 0; i < len(s); i++ {
+		v := decodeMap[s[i]]
+		if v < 0 {
+			return nil, fmt.Errorf("base58: invalid character %q at position %d", s[i], i)
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(v)))
+	}
+
+	decoded := n.Bytes()
+	out := make([]byte, zeros+len(decoded))
+	copy(out[zeros:], decoded)
+	return out, nil
+}
@@ -0,0 +1,510 @@
+// Package bots lets users reach the proxy from Telegram and Discord. A user
+// links a chat to their wallet with a one-time code redeemed from a wallet
+// session, then queries the wallet's pool balance, approves or declines
+// payment requests with inline buttons, and is notified when payments to the
+// wallet settle. The commands and flows are shared; Telegram and Discord
+// only translate messages and buttons.
+package bots
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/amount"
+	"sol_privacy/internal/events"
+	"sol_privacy/internal/pool"
+	"sol_privacy/internal/settlement"
+	"sol_privacy/internal/validate"
+)
+
+// Platform names.
+const (
+	PlatformTelegram = "telegram"
+	PlatformDiscord  = "discord"
+)
+
+// Payment request statuses.
+const (
+	RequestPending  = "pending"
+	RequestApproved = "approved"
+	RequestDeclined = "declined"
+	RequestExpired  = "expired"
+)
+
+// Defaults.
+const (
+	DefaultLinkCodeTTL      = 10 * time.Minute
+	DefaultRequestExpiresIn = 15 * time.Minute
+	MaxRequestExpiresIn     = 7 * 24 * time.Hour
+)
+
+// Button data prefixes of payment request decisions.
+const (
+	actionApprove = "approve:"
+	actionDecline = "decline:"
+)
+
+var (
+	// ErrNotLinked is returned for chats not linked to a wallet, and when a
+	// wallet has no linked chats to ask.
+	ErrNotLinked = errors.New("chat not linked to a wallet")
+	// ErrCodeNotFound is returned for unknown or expired link codes.
+	ErrCodeNotFound = errors.New("link code not found or expired")
+	// ErrRequestNotFound is returned for unknown payment requests.
+	ErrRequestNotFound = errors.New("payment request not found")
+	// ErrRequestDecided is returned when a request was already decided or expired.
+	ErrRequestDecided = errors.New("payment request already decided")
+)
+
+// Chat is a conversation on a platform: a Telegram chat or a Discord channel.
+type Chat struct {
+	Platform string `json:"platform"`
+	ID       string `json:"id"`
+}
+
+func (c Chat) String() string {
+	return c.Platform + ":" + c.ID
+}
+
+// Link ties a chat to the wallet it acts for.
+type Link struct {
+	Chat     Chat   `json:"chat"`
+	Wallet   string `json:"wallet"`
+	LinkedAt int64  `json:"linked_at"`
+}
+
+// LinkCode is a one-time code a chat was given to prove wallet ownership.
+type LinkCode struct {
+	Code      string `json:"code"`
+	Chat      Chat   `json:"chat"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// SentMessage is a copy of a payment request posted to a chat.
+type SentMessage struct {
+	Chat      Chat   `json:"chat"`
+	MessageID string `json:"message_id"`
+}
+
+// PaymentRequest asks a wallet's owner to approve a payment.
+type PaymentRequest struct {
+	ID          string            `json:"id"`
+	Wallet      string            `json:"wallet"`
+	Recipient   string            `json:"recipient"`
+	Amount      int64             `json:"amount"` // Lamports
+	Description string            `json:"description,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	Status      string            `json:"status"`
+	Messages    []SentMessage     `json:"messages"`
+	DecidedBy   string            `json:"decided_by,omitempty"` // Chat that decided
+	CreatedAt   int64             `json:"created_at"`
+	ExpiresAt   int64             `json:"expires_at"`
+	DecidedAt   int64             `json:"decided_at,omitempty"`
+}
+
+// CreateRequest represents a request to ask a wallet's owner for approval.
+type CreateRequest struct {
+	Wallet      string            `json:"wallet"`
+	Recipient   string            `json:"recipient"`
+	Amount      int64             `json:"amount"` // Lamports
+	Description string            `json:"description,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	ExpiresIn   int64             `json:"expires_in,omitempty"` // Seconds, default 15 minutes
+}
+
+// Validate checks the request fields.
+func (r CreateRequest) Validate() error {
+	v := validate.New().
+		Address("wallet", r.Wallet).
+		Required("recipient", r.Recipient).
+		Amount("amount", r.Amount, 1, validate.MaxLamports).
+		Metadata("metadata", r.Metadata)
+	if r.ExpiresIn < 0 || time.Duration(r.ExpiresIn)*time.Second > MaxRequestExpiresIn {
+		v.Add("expires_in", fmt.Errorf("must be between 0 and %d seconds", int64(MaxRequestExpiresIn/time.Second)))
+	}
+	return v.Err()
+}
+
+// ButtonStyle hints how a platform should color a button.
+type ButtonStyle int
+
+// Button styles.
+const (
+	ButtonDefault ButtonStyle = iota
+	ButtonConfirm
+	ButtonCancel
+)
+
+// Button is an inline button; pressing it sends Data back to the bot.
+type Button struct {
+	Label string
+	Data  string
+	Style ButtonStyle
+}
+
+// Message is a platform-neutral chat message.
+type Message struct {
+	Text    string
+	Buttons []Button
+}
+
+// Platform sends and edits messages on a chat service.
+type Platform interface {
+	Name() string
+	Send(ctx context.Context, chatID string, msg Message) (messageID string, err error)
+	Edit(ctx context.Context, chatID, messageID string, msg Message) error
+}
+
+// Config holds bot configuration.
+type Config struct {
+	Pool  *pool.Service // Required, answers balance queries
+	Store Store         // Defaults to an in-memory store
+
+	// LinkHint tells users where to enter their link code, e.g. a wallet app URL.
+	LinkHint    string
+	LinkCodeTTL time.Duration // Defaults to DefaultLinkCodeTTL
+
+	// OnDecision is called after a payment request is approved or declined.
+	OnDecision func(ctx context.Context, r *PaymentRequest)
+}
+
+// Bot implements the commands and flows shared by every platform.
+type Bot struct {
+	config    Config
+	platforms map[string]Platform
+
+	mu sync.Mutex // Serializes payment request decisions
+}
+
+// New creates a bot. Add platforms before serving their webhooks.
+func New(config Config) (*Bot, error) {
+	if config.Pool == nil {
+		return nil, fmt.Errorf("bot needs a pool service")
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryStore()
+	}
+	if config.LinkCodeTTL <= 0 {
+		config.LinkCodeTTL = DefaultLinkCodeTTL
+	}
+	return &Bot{config: config, platforms: make(map[string]Platform)}, nil
+}
+
+// AddPlatform registers a platform to send messages through.
+func (b *Bot) AddPlatform(p Platform) {
+	b.platforms[p.Name()] = p
+}
+
+// HandleCommand answers a command such as "/balance" sent in a chat.
+func (b *Bot) HandleCommand(ctx context.Context, chat Chat, text string) Message {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return helpMessage()
+	}
+	// Telegram addresses commands in groups as /balance@botname
+	command, _, _ := strings.Cut(strings.TrimPrefix(fields[0], "/"), "@")
+
+	switch strings.ToLower(command) {
+	case "start", "help":
+		return helpMessage()
+	case "link":
+		return b.startLink(chat)
+	case "unlink":
+		if err := b.config.Store.Unlink(chat); err != nil {
+			log.Printf("bots: failed to unlink %s: %v", chat, err)
+			return Message{Text: "Failed to unlink this chat, try again later."}
+		}
+		return Message{Text: "This chat is no longer linked to a wallet."}
+	case "balance":
+		return b.balance(ctx, chat)
+	default:
+		return Message{Text: "Unknown command. Send /help for the commands."}
+	}
+}
+
+func helpMessage() Message {
+	return Message{Text: strings.Join([]string{
+		"ShadowPay commands:",
+		"/link - link this chat to your wallet",
+		"/balance - your wallet's privacy pool balance",
+		"/unlink - stop acting for your wallet",
+		"",
+		"Once linked, payment requests for your wallet arrive here to approve or decline, and you are told when payments to it settle.",
+	}, "\n")}
+}
+
+func (b *Bot) startLink(chat Chat) Message {
+	code := newCode()
+	c := &LinkCode{Code: code, Chat: chat, ExpiresAt: time.Now().Add(b.config.LinkCodeTTL).Unix()}
+	if err := b.config.Store.PutCode(c); err != nil {
+		log.Printf("bots: failed to save link code for %s: %v", chat, err)
+		return Message{Text: "Failed to start linking, try again later."}
+	}
+	text := fmt.Sprintf("To link this chat to your wallet, enter code %s in your wallet app within %d minutes.",
+		code, int(b.config.LinkCodeTTL.Minutes()))
+	if b.config.LinkHint != "" {
+		text += "\n" + b.config.LinkHint
+	}
+	return Message{Text: text}
+}
+
+func (b *Bot) balance(ctx context.Context, chat Chat) Message {
+	link, err := b.config.Store.LinkOf(chat)
+	if errors.Is(err, ErrNotLinked) {
+		return Message{Text: "This chat is not linked to a wallet. Send /link first."}
+	}
+	if err != nil {
+		log.Printf("bots: failed to load link of %s: %v", chat, err)
+		return Message{Text: "Failed to look up your wallet, try again later."}
+	}
+	resp, err := b.config.Pool.GetBalance(ctx, link.Wallet)
+	if err != nil {
+		return Message{Text: "Failed to fetch the balance, try again later."}
+	}
+	return Message{Text: fmt.Sprintf("Pool balance of %s: %s SOL", shortAddress(link.Wallet), resp.BalanceAmount().SOL())}
+}
+
+// Redeem links the chat a code was issued to with wallet, whose ownership
+// the caller has verified, and confirms it in the chat.
+func (b *Bot) Redeem(ctx context.Context, code, wallet string) (*Link, error) {
+	c, err := b.config.Store.TakeCode(strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil {
+		return nil, err
+	}
+	link := &Link{Chat: c.Chat, Wallet: wallet, LinkedAt: time.Now().Unix()}
+	if err := b.config.Store.Link(link); err != nil {
+		return nil, err
+	}
+	b.send(ctx, c.Chat, Message{Text: fmt.Sprintf("This chat is now linked to wallet %s.", shortAddress(wallet))})
+	return link, nil
+}
+
+// RequestPayment posts a payment request with approve and decline buttons to
+// every chat linked to the wallet. It returns ErrNotLinked if there is none
+// or no message could be sent.
+func (b *Bot) RequestPayment(ctx context.Context, req CreateRequest) (*PaymentRequest, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	chats, err := b.config.Store.Chats(req.Wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresIn := DefaultRequestExpiresIn
+	if req.ExpiresIn > 0 {
+		expiresIn = time.Duration(req.ExpiresIn) * time.Second
+	}
+	now := time.Now()
+	pr := &PaymentRequest{
+		ID:          newID("preq_"),
+		Wallet:      req.Wallet,
+		Recipient:   req.Recipient,
+		Amount:      req.Amount,
+		Description: req.Description,
+		Metadata:    req.Metadata,
+		Status:      RequestPending,
+		CreatedAt:   now.Unix(),
+		ExpiresAt:   now.Add(expiresIn).Unix(),
+	}
+
+	msg := requestMessage(pr)
+	for _, chat := range chats {
+		if id, ok := b.send(ctx, chat, msg); ok {
+			pr.Messages = append(pr.Messages, SentMessage{Chat: chat, MessageID: id})
+		}
+	}
+	if len(pr.Messages) == 0 {
+		return nil, fmt.Errorf("%w: no chat could be reached for %s", ErrNotLinked, req.Wallet)
+	}
+	if err := b.config.Store.PutRequest(pr); err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
+
+// PaymentRequest returns a payment request, marking it expired if its time ran out.
+func (b *Bot) PaymentRequest(id string) (*PaymentRequest, error) {
+	pr, err := b.config.Store.GetRequest(id)
+	if err != nil {
+		return nil, err
+	}
+	if pr.Status == RequestPending && time.Now().Unix() >= pr.ExpiresAt {
+		pr.Status = RequestExpired
+	}
+	return pr, nil
+}
+
+// HandleButton handles a button pressed in a chat and returns what the
+// message should now say. Only chats linked to the request's wallet may
+// decide it; the request's copies in other chats are updated too.
+func (b *Bot) HandleButton(ctx context.Context, chat Chat, data string) (Message, error) {
+	var status, id string
+	switch {
+	case strings.HasPrefix(data, actionApprove):
+		status, id = RequestApproved, strings.TrimPrefix(data, actionApprove)
+	case strings.HasPrefix(data, actionDecline):
+		status, id = RequestDeclined, strings.TrimPrefix(data, actionDecline)
+	default:
+		return Message{}, fmt.Errorf("unknown action")
+	}
+
+	pr, err := b.decide(chat, id, status)
+	if err != nil {
+		return Message{}, err
+	}
+	msg := requestMessage(pr)
+	for _, m := range pr.Messages {
+		if m.Chat != chat {
+			b.edit(ctx, m, msg)
+		}
+	}
+	if b.config.OnDecision != nil {
+		b.config.OnDecision(ctx, pr)
+	}
+	return msg, nil
+}
+
+func (b *Bot) decide(chat Chat, id, status string) (*PaymentRequest, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	pr, err := b.PaymentRequest(id)
+	if err != nil {
+		return nil, err
+	}
+	link, err := b.config.Store.LinkOf(chat)
+	if err != nil || link.Wallet != pr.Wallet {
+		return nil, fmt.Errorf("this chat may not decide payments for %s", shortAddress(pr.Wallet))
+	}
+	if pr.Status != RequestPending {
+		return nil, fmt.Errorf("%w: %s", ErrRequestDecided, pr.Status)
+	}
+	pr.Status = status
+	pr.DecidedBy = chat.String()
+	pr.DecidedAt = time.Now().Unix()
+	if err := b.config.Store.PutRequest(pr); err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
+
+func requestMessage(pr *PaymentRequest) Message {
+	lines := []string{
+		fmt.Sprintf("Payment request: %s SOL to %s", formatSOL(pr.Amount), shortAddress(pr.Recipient)),
+	}
+	if pr.Description != "" {
+		lines = append(lines, pr.Description)
+	}
+	switch pr.Status {
+	case RequestPending:
+		lines = append(lines, fmt.Sprintf("Expires %s", time.Unix(pr.ExpiresAt, 0).UTC().Format("Jan 2 15:04 MST")))
+		return Message{Text: strings.Join(lines, "\n"), Buttons: []Button{
+			{Label: "Approve", Data: actionApprove + pr.ID, Style: ButtonConfirm},
+			{Label: "Decline", Data: actionDecline + pr.ID, Style: ButtonCancel},
+		}}
+	default:
+		lines = append(lines, "Status: "+pr.Status)
+		return Message{Text: strings.Join(lines, "\n")}
+	}
+}
+
+// HandleEvent notifies the chats linked to a payment's recipient wallet when
+// its settlement succeeds or fails. Subscribe it to the settlement events.
+func (b *Bot) HandleEvent(ctx context.Context, e events.Event) error {
+	if e.Type != events.SettlementSucceeded && e.Type != events.SettlementFailed {
+		return nil
+	}
+	var j settlement.Job
+	if err := e.Decode(&j); err != nil {
+		return err
+	}
+	wallet := j.Request.PaymentRequirements.PayTo
+	if wallet == "" {
+		return nil
+	}
+	chats, err := b.config.Store.Chats(wallet)
+	if err != nil {
+		return err
+	}
+
+	amount := j.Request.PaymentRequirements.MaxAmountRequired
+	var text string
+	if e.Type == events.SettlementSucceeded {
+		text = fmt.Sprintf("Payment of %s SOL to %s settled.", amount, shortAddress(wallet))
+		if j.Result != nil && j.Result.TxSig != "" {
+			text += "\nTransaction: " + j.Result.TxSig
+		}
+	} else {
+		text = fmt.Sprintf("Payment of %s SOL to %s failed to settle: %s", amount, shortAddress(wallet), j.LastError)
+	}
+
+	// Retrying after a partial failure would notify the other chats twice
+	sent := 0
+	for _, chat := range chats {
+		if _, ok := b.send(ctx, chat, Message{Text: text}); ok {
+			sent++
+		}
+	}
+	if len(chats) > 0 && sent == 0 {
+		return fmt.Errorf("no chat linked to %s could be notified", wallet)
+	}
+	return nil
+}
+
+func (b *Bot) send(ctx context.Context, chat Chat, msg Message) (string, bool) {
+	p, ok := b.platforms[chat.Platform]
+	if !ok {
+		return "", false
+	}
+	id, err := p.Send(ctx, chat.ID, msg)
+	if err != nil {
+		log.Printf("bots: failed to message %s: %v", chat, err)
+		return "", false
+	}
+	return id, true
+}
+
+func (b *Bot) edit(ctx context.Context, m SentMessage, msg Message) {
+	p, ok := b.platforms[m.Chat.Platform]
+	if !ok {
+		return
+	}
+	if err := p.Edit(ctx, m.Chat.ID, m.MessageID, msg); err != nil {
+		log.Printf("bots: failed to update message in %s: %v", m.Chat, err)
+	}
+}
+
+// newCode returns a link code that is easy to type, e.g. "7KQ2-M9XD".
+func newCode() string {
+	const alphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ" // No 0/O or 1/I
+	b := make([]byte, 8)
+	rand.Read(b)
+	for i := range b {
+		b[i] = alphabet[int(b[i])%len(alphabet)]
+	}
+	return string(b[:4]) + "-" + string(b[4:])
+}
+
+func newID(prefix string) string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return prefix + hex.EncodeToString(b)
+}
+
+func shortAddress(addr string) string {
+	if len(addr) <= 12 {
+		return addr
+	}
+	return addr[:4] + "..." + addr[len(addr)-4:]
+}
+
+func formatSOL(lamports int64) string {
+	return amount.Lamports(lamports).SOL()
+}
@@ -0,0 +1,237 @@
+package bots
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultDiscordAPIURL is the Discord REST API.
+const DefaultDiscordAPIURL = "https://discord.com/api/v10"
+
+// Discord interaction and response types.
+const (
+	discordPing             = 1
+	discordCommand          = 2
+	discordButtonPress      = 3
+	discordPong             = 1
+	discordChannelMessage   = 4
+	discordUpdateMessage    = 7
+	discordEphemeral        = 1 << 6
+	discordActionRow        = 1
+	discordButton           = 2
+	discordStylePrimary     = 1
+	discordStyleSuccess     = 3
+	discordStyleDanger      = 4
+	discordChatInputCommand = 1
+)
+
+// DiscordConfig holds Discord bot configuration.
+type DiscordConfig struct {
+	Token         string       // Required, the bot token
+	PublicKey     string       // Required, hex application public key that signs interactions
+	ApplicationID string       // Needed by RegisterCommands
+	APIURL        string       // Defaults to DefaultDiscordAPIURL
+	HTTPClient    *http.Client // Defaults to a client with a 10s timeout
+}
+
+// Discord sends messages through the Discord REST API and serves its
+// interactions endpoint, where slash commands and button presses arrive.
+type Discord struct {
+	config    DiscordConfig
+	publicKey ed25519.PublicKey
+}
+
+// NewDiscord creates a Discord platform.
+func NewDiscord(config DiscordConfig) (*Discord, error) {
+	if config.Token == "" {
+		return nil, fmt.Errorf("discord bot token is required")
+	}
+	key, err := hex.DecodeString(config.PublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("discord public key must be %d hex-encoded bytes", ed25519.PublicKeySize)
+	}
+	if config.APIURL == "" {
+		config.APIURL = DefaultDiscordAPIURL
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	config.APIURL = strings.TrimRight(config.APIURL, "/")
+	return &Discord{config: config, publicKey: ed25519.PublicKey(key)}, nil
+}
+
+// Name returns PlatformDiscord.
+func (d *Discord) Name() string {
+	return PlatformDiscord
+}
+
+type discordComponent struct {
+	Type       int                `json:"type"`
+	Style      int                `json:"style,omitempty"`
+	Label      string             `json:"label,omitempty"`
+	CustomID   string             `json:"custom_id,omitempty"`
+	Components []discordComponent `json:"components,omitempty"`
+}
+
+type discordMessage struct {
+	Content    string             `json:"content"`
+	Components []discordComponent `json:"components"` // Empty removes the buttons of an edited message
+	Flags      int                `json:"flags,omitempty"`
+}
+
+func toDiscord(msg Message) discordMessage {
+	out := discordMessage{Content: msg.Text, Components: []discordComponent{}}
+	if len(msg.Buttons) == 0 {
+		return out
+	}
+	row := discordComponent{Type: discordActionRow}
+	for _, b := range msg.Buttons {
+		style := discordStylePrimary
+		switch b.Style {
+		case ButtonConfirm:
+			style = discordStyleSuccess
+		case ButtonCancel:
+			style = discordStyleDanger
+		}
+		row.Components = append(row.Components, discordComponent{Type: discordButton, Style: style, Label: b.Label, CustomID: b.Data})
+	}
+	out.Components = append(out.Components, row)
+	return out
+}
+
+// Send posts a message to a channel.
+func (d *Discord) Send(ctx context.Context, channelID string, msg Message) (string, error) {
+	var result struct {
+		ID string `json:"id"`
+	}
+	if err := d.call(ctx, http.MethodPost, "/channels/"+channelID+"/messages", toDiscord(msg), &result); err != nil {
+		return "", err
+	}
+	return result.ID, nil
+}
+
+// Edit replaces a message's text and buttons.
+func (d *Discord) Edit(ctx context.Context, channelID, messageID string, msg Message) error {
+	return d.call(ctx, http.MethodPatch, "/channels/"+channelID+"/messages/"+messageID, toDiscord(msg), nil)
+}
+
+// RegisterCommands creates the bot's slash commands, replacing any others
+// the application has.
+func (d *Discord) RegisterCommands(ctx context.Context) error {
+	if d.config.ApplicationID == "" {
+		return fmt.Errorf("discord application ID is required to register commands")
+	}
+	commands := []map[string]any{
+		{"name": "link", "type": discordChatInputCommand, "description": "Link this channel to your wallet"},
+		{"name": "balance", "type": discordChatInputCommand, "description": "Your wallet's privacy pool balance"},
+		{"name": "unlink", "type": discordChatInputCommand, "description": "Stop acting for your wallet"},
+		{"name": "help", "type": discordChatInputCommand, "description": "List the ShadowPay commands"},
+	}
+	return d.call(ctx, http.MethodPut, "/applications/"+d.config.ApplicationID+"/commands", commands, nil)
+}
+
+func (d *Discord) call(ctx context.Context, method, path string, body, result any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, d.config.APIURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bot "+d.config.Token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := d.config.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("discord %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("discord %s %s: status %d: %s", method, path, resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	if result != nil {
+		return json.NewDecoder(resp.Body).Decode(result)
+	}
+	return nil
+}
+
+type discordInteraction struct {
+	Type      int    `json:"type"`
+	ChannelID string `json:"channel_id"`
+	Data      struct {
+		Name     string `json:"name"`      // Slash commands
+		CustomID string `json:"custom_id"` // Buttons
+	} `json:"data"`
+}
+
+// Handler returns the interactions endpoint to configure in the Discord
+// developer portal. Requests not signed with the application's key are
+// rejected, as Discord requires.
+func (d *Discord) Handler(b *Bot) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxUpdateSize))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if !d.verify(r.Header.Get("X-Signature-Timestamp"), r.Header.Get("X-Signature-Ed25519"), body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var in discordInteraction
+		if err := json.Unmarshal(body, &in); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+		chat := Chat{Platform: PlatformDiscord, ID: in.ChannelID}
+		switch in.Type {
+		case discordPing:
+			respondDiscord(w, discordPong, nil)
+		case discordCommand:
+			msg := toDiscord(b.HandleCommand(ctx, chat, "/"+in.Data.Name))
+			respondDiscord(w, discordChannelMessage, &msg)
+		case discordButtonPress:
+			reply, err := b.HandleButton(ctx, chat, in.Data.CustomID)
+			if err != nil {
+				// Only the presser sees why, and the buttons stay
+				respondDiscord(w, discordChannelMessage, &discordMessage{Content: err.Error(), Flags: discordEphemeral})
+				return
+			}
+			msg := toDiscord(reply)
+			respondDiscord(w, discordUpdateMessage, &msg)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+}
+
+func (d *Discord) verify(timestamp, signature string, body []byte) bool {
+	sig, err := hex.DecodeString(signature)
+	if err != nil || len(sig) != ed25519.SignatureSize || timestamp == "" {
+		return false
+	}
+	return ed25519.Verify(d.publicKey, append([]byte(timestamp), body...), sig)
+}
+
+func respondDiscord(w http.ResponseWriter, kind int, data *discordMessage) {
+	resp := map[string]any{"type": kind}
+	if data != nil {
+		resp["data"] = data
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("bots: failed to answer discord interaction: %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package bots
+
+import (
+	"time"
+
+	"sol_privacy/internal/jsonfile"
+)
+
+// Store persists wallet links, pending link codes and payment requests.
+type Store interface {
+	Link(l *Link) error
+	Unlink(chat Chat) error
+	LinkOf(chat Chat) (*Link, error)     // Returns ErrNotLinked for unlinked chats
+	Chats(wallet string) ([]Chat, error) // Chats linked to a wallet, oldest link first
+
+	PutCode(c *LinkCode) error
+	TakeCode(code string) (*LinkCode, error) // Removes the code; returns ErrCodeNotFound if unknown or expired
+
+	PutRequest(r *PaymentRequest) error
+	GetRequest(id string) (*PaymentRequest, error) // Returns ErrRequestNotFound for unknown IDs
+}
+
+// NewMemoryStore creates an empty in-memory Store.
+func NewMemoryStore() Store {
+	return store{
+		links:    jsonfile.NewMemoryStore(linkChat, nil),
+		codes:    jsonfile.NewMemoryStore(codeKey, nil),
+		requests: jsonfile.NewMemoryStore(requestID, nil),
+	}
+}
+
+// NewFileStore opens a Store persisted to JSON files after every write, so
+// links and pending requests survive restarts. Links are kept at path, link
+// codes at path.codes and payment requests at path.requests; all are
+// created on first write.
+func NewFileStore(path string) (Store, error) {
+	links, err := jsonfile.NewStore(path, linkChat, nil)
+	if err != nil {
+		return nil, err
+	}
+	codes, err := jsonfile.NewStore(path+".codes", codeKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	requests, err := jsonfile.NewStore(path+".requests", requestID, nil)
+	if err != nil {
+		return nil, err
+	}
+	return store{links: links, codes: codes, requests: requests}, nil
+}
+
+func linkChat(l *Link) string { return l.Chat.String() }
+
+func codeKey(c *LinkCode) string { return c.Code }
+
+func requestID(r *PaymentRequest) string { return r.ID }
+
+// store keeps links, codes and requests in jsonfile.Stores.
+type store struct {
+	links    *jsonfile.Store[Link]
+	codes    *jsonfile.Store[LinkCode]
+	requests *jsonfile.Store[PaymentRequest]
+}
+
+func (s store) Link(l *Link) error {
+	return s.links.Put(l)
+}
+
+func (s store) Unlink(chat Chat) error {
+	_, err := s.links.Delete(chat.String())
+	return err
+}
+
+func (s store) LinkOf(chat Chat) (*Link, error) {
+	l, ok := s.links.Get(chat.String())
+	if !ok {
+		return nil, ErrNotLinked
+	}
+	return l, nil
+}
+
+// Chats returns the chats linked to a wallet, oldest link first.
+func (s store) Chats(wallet string) ([]Chat, error) {
+	links := s.links.List(func(a, b *Link) bool {
+		if a.LinkedAt != b.LinkedAt {
+			return a.LinkedAt < b.LinkedAt
+		}
+		return a.Chat.String() < b.Chat.String()
+	})
+	var chats []Chat
+	for _, l := range links {
+		if l.Wallet == wallet {
+			chats = append(chats, l.Chat)
+		}
+	}
+	return chats, nil
+}
+
+// PutCode saves the code and drops expired ones.
+func (s store) PutCode(c *LinkCode) error {
+	now := time.Now().Unix()
+	if err := s.codes.DeleteFunc(func(old *LinkCode) bool { return old.ExpiresAt <= now }); err != nil {
+		return err
+	}
+	return s.codes.Put(c)
+}
+
+// TakeCode removes and returns an unexpired code.
+func (s store) TakeCode(code string) (*LinkCode, error) {
+	c, err := s.codes.Delete(code)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil || c.ExpiresAt <= time.Now().Unix() {
+		return nil, ErrCodeNotFound
+	}
+	return c, nil
+}
+
+func (s store) PutRequest(r *PaymentRequest) error {
+	return s.requests.Put(r)
+}
+
+func (s store) GetRequest(id string) (*PaymentRequest, error) {
+	r, ok := s.requests.Get(id)
+	if !ok {
+		return nil, ErrRequestNotFound
+	}
+	return r, nil
+}
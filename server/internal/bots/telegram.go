@@ -0,0 +1,217 @@
+package bots
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTelegramAPIURL is the Telegram Bot API.
+const DefaultTelegramAPIURL = "https://api.telegram.org"
+
+// headerTelegramSecret carries the secret token given to setWebhook.
+const headerTelegramSecret = "X-Telegram-Bot-Api-Secret-Token"
+
+// maxUpdateSize caps a webhook body from either platform.
+const maxUpdateSize = 1 << 20
+
+// TelegramConfig holds Telegram bot configuration.
+type TelegramConfig struct {
+	Token         string       // Required, from @BotFather
+	WebhookSecret string       // Checked on every update when set
+	APIURL        string       // Defaults to DefaultTelegramAPIURL
+	HTTPClient    *http.Client // Defaults to a client with a 10s timeout
+}
+
+// Telegram sends messages through the Telegram Bot API and serves its webhook.
+type Telegram struct {
+	config TelegramConfig
+}
+
+// NewTelegram creates a Telegram platform.
+func NewTelegram(config TelegramConfig) (*Telegram, error) {
+	if config.Token == "" {
+		return nil, fmt.Errorf("telegram bot token is required")
+	}
+	if config.APIURL == "" {
+		config.APIURL = DefaultTelegramAPIURL
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	config.APIURL = strings.TrimRight(config.APIURL, "/")
+	return &Telegram{config: config}, nil
+}
+
+// Name returns PlatformTelegram.
+func (t *Telegram) Name() string {
+	return PlatformTelegram
+}
+
+type telegramButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data"`
+}
+
+type telegramMarkup struct {
+	InlineKeyboard [][]telegramButton `json:"inline_keyboard"`
+}
+
+func telegramKeyboard(buttons []Button) *telegramMarkup {
+	row := make([]telegramButton, len(buttons))
+	for i, b := range buttons {
+		row[i] = telegramButton{Text: b.Label, CallbackData: b.Data}
+	}
+	// An empty keyboard removes the buttons of an edited message
+	markup := &telegramMarkup{InlineKeyboard: [][]telegramButton{}}
+	if len(row) > 0 {
+		markup.InlineKeyboard = append(markup.InlineKeyboard, row)
+	}
+	return markup
+}
+
+// Send posts a message to a chat.
+func (t *Telegram) Send(ctx context.Context, chatID string, msg Message) (string, error) {
+	body := map[string]any{"chat_id": chatID, "text": msg.Text}
+	if len(msg.Buttons) > 0 {
+		body["reply_markup"] = telegramKeyboard(msg.Buttons)
+	}
+	var result struct {
+		MessageID int64 `json:"message_id"`
+	}
+	if err := t.call(ctx, "sendMessage", body, &result); err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(result.MessageID, 10), nil
+}
+
+// Edit replaces a message's text and buttons.
+func (t *Telegram) Edit(ctx context.Context, chatID, messageID string, msg Message) error {
+	id, err := strconv.ParseInt(messageID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid telegram message id %q", messageID)
+	}
+	return t.call(ctx, "editMessageText", map[string]any{
+		"chat_id":      chatID,
+		"message_id":   id,
+		"text":         msg.Text,
+		"reply_markup": telegramKeyboard(msg.Buttons),
+	}, nil)
+}
+
+// SetWebhook tells Telegram to post updates to url.
+func (t *Telegram) SetWebhook(ctx context.Context, url string) error {
+	body := map[string]any{
+		"url":             url,
+		"allowed_updates": []string{"message", "callback_query"},
+	}
+	if t.config.WebhookSecret != "" {
+		body["secret_token"] = t.config.WebhookSecret
+	}
+	return t.call(ctx, "setWebhook", body, nil)
+}
+
+func (t *Telegram) call(ctx context.Context, method string, body, result any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	url := t.config.APIURL + "/bot" + t.config.Token + "/" + method
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := t.config.HTTPClient.Do(req)
+	if err != nil {
+		// The URL holds the token; keep it out of logs
+		return fmt.Errorf("telegram %s: request failed", method)
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		OK          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return fmt.Errorf("telegram %s: invalid response (status %d)", method, resp.StatusCode)
+	}
+	if !out.OK {
+		return fmt.Errorf("telegram %s: %s", method, out.Description)
+	}
+	if result != nil {
+		return json.Unmarshal(out.Result, result)
+	}
+	return nil
+}
+
+type telegramUpdate struct {
+	Message *struct {
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+		Text string `json:"text"`
+	} `json:"message"`
+	CallbackQuery *struct {
+		ID      string `json:"id"`
+		Data    string `json:"data"`
+		Message *struct {
+			MessageID int64 `json:"message_id"`
+			Chat      struct {
+				ID int64 `json:"id"`
+			} `json:"chat"`
+		} `json:"message"`
+	} `json:"callback_query"`
+}
+
+// Handler returns the webhook Telegram posts updates to. Commands are
+// answered in the chat; button presses update the message and show a short
+// notice, or the reason the press was refused.
+func (t *Telegram) Handler(b *Bot) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if t.config.WebhookSecret != "" &&
+			subtle.ConstantTimeCompare([]byte(r.Header.Get(headerTelegramSecret)), []byte(t.config.WebhookSecret)) != 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var u telegramUpdate
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxUpdateSize)).Decode(&u); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		// Telegram redelivers updates until they are acknowledged, so failures
+		// below are logged rather than returned
+		w.WriteHeader(http.StatusOK)
+
+		ctx := r.Context()
+		switch {
+		case u.Message != nil && strings.HasPrefix(u.Message.Text, "/"):
+			chatID := strconv.FormatInt(u.Message.Chat.ID, 10)
+			reply := b.HandleCommand(ctx, Chat{Platform: PlatformTelegram, ID: chatID}, u.Message.Text)
+			if _, err := t.Send(ctx, chatID, reply); err != nil {
+				log.Printf("bots: %v", err)
+			}
+		case u.CallbackQuery != nil && u.CallbackQuery.Message != nil:
+			q := u.CallbackQuery
+			chatID := strconv.FormatInt(q.Message.Chat.ID, 10)
+			msg, err := b.HandleButton(ctx, Chat{Platform: PlatformTelegram, ID: chatID}, q.Data)
+			notice := "Done"
+			if err != nil {
+				notice = err.Error()
+			} else if err := t.Edit(ctx, chatID, strconv.FormatInt(q.Message.MessageID, 10), msg); err != nil {
+				log.Printf("bots: %v", err)
+			}
+			if err := t.call(ctx, "answerCallbackQuery", map[string]any{"callback_query_id": q.ID, "text": notice}, nil); err != nil {
+				log.Printf("bots: %v", err)
+			}
+		}
+	})
+}
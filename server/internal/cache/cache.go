@@ -0,0 +1,173 @@
+// Package cache is an in-memory read-through cache with stale-while-revalidate:
+// entries are fresh for TTL, then served stale for up to StaleTTL while a single
+// background fetch refreshes them.
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Status describes how a value was served.
+type Status string
+
+const (
+	Hit    Status = "HIT"    // Fresh cached value
+	Stale  Status = "STALE"  // Stale value, refresh started in the background
+	Miss   Status = "MISS"   // Fetched because nothing usable was cached
+	Bypass Status = "BYPASS" // Fetched because the caller skipped the cache
+)
+
+// FetchFunc loads the value for a key.
+type FetchFunc[V any] func(ctx context.Context) (V, error)
+
+// Config holds cache configuration.
+type Config struct {
+	TTL          time.Duration // How long values are fresh; zero disables caching
+	StaleTTL     time.Duration // How long after TTL stale values may still be served
+	RefreshAfter time.Duration // Timeout for background refreshes, defaults to 30s
+	MaxEntries   int           // Oldest entries are evicted beyond this, defaults to 1000
+}
+
+type entry[V any] struct {
+	value      V
+	fetchedAt  time.Time
+	refreshing bool
+}
+
+// call is an in-flight foreground fetch shared by concurrent callers of a key.
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// Cache caches values of type V by string key.
+type Cache[V any] struct {
+	config Config
+
+	mu       sync.Mutex
+	entries  map[string]*entry[V]
+	inflight map[string]*call[V]
+}
+
+// New creates a cache.
+func New[V any](config Config) *Cache[V] {
+	if config.RefreshAfter == 0 {
+		config.RefreshAfter = 30 * time.Second
+	}
+	if config.MaxEntries == 0 {
+		config.MaxEntries = 1000
+	}
+	return &Cache[V]{
+		config:   config,
+		entries:  make(map[string]*entry[V]),
+		inflight: make(map[string]*call[V]),
+	}
+}
+
+// Get returns the value for key, calling fetch on a miss. With bypass set the
+// cache is not read, but the fetched value is stored for later callers.
+func (c *Cache[V]) Get(ctx context.Context, key string, bypass bool, fetch FetchFunc[V]) (V, Status, error) {
+	if c.config.TTL <= 0 {
+		v, err := fetch(ctx)
+		return v, Bypass, err
+	}
+
+	if !bypass {
+		c.mu.Lock()
+		if e, ok := c.entries[key]; ok {
+			age := time.Since(e.fetchedAt)
+			if age < c.config.TTL {
+				c.mu.Unlock()
+				return e.value, Hit, nil
+			}
+			if age < c.config.TTL+c.config.StaleTTL {
+				if !e.refreshing {
+					e.refreshing = true
+					go c.refresh(key, fetch)
+				}
+				c.mu.Unlock()
+				return e.value, Stale, nil
+			}
+		}
+		c.mu.Unlock()
+	}
+
+	v, err := c.fetch(ctx, key, fetch)
+	status := Miss
+	if bypass {
+		status = Bypass
+	}
+	return v, status, err
+}
+
+// Invalidate drops key from the cache.
+func (c *Cache[V]) Invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// fetch loads key in the foreground, sharing the fetch with concurrent callers.
+func (c *Cache[V]) fetch(ctx context.Context, key string, fetch FetchFunc[V]) (V, error) {
+	c.mu.Lock()
+	if cl, ok := c.inflight[key]; ok {
+		c.mu.Unlock()
+		select {
+		case <-cl.done:
+			return cl.value, cl.err
+		case <-ctx.Done():
+			var zero V
+			return zero, ctx.Err()
+		}
+	}
+	cl := &call[V]{done: make(chan struct{})}
+	c.inflight[key] = cl
+	c.mu.Unlock()
+
+	cl.value, cl.err = fetch(ctx)
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	if cl.err == nil {
+		c.store(key, cl.value)
+	}
+	c.mu.Unlock()
+	close(cl.done)
+	return cl.value, cl.err
+}
+
+// refresh reloads a stale entry detached from the request that noticed it.
+func (c *Cache[V]) refresh(key string, fetch FetchFunc[V]) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.RefreshAfter)
+	defer cancel()
+	v, err := fetch(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err == nil {
+		c.store(key, v)
+		return
+	}
+	// Keep serving the stale value until it ages out; the next request retries
+	if e, ok := c.entries[key]; ok {
+		e.refreshing = false
+	}
+}
+
+// store saves a value, evicting the oldest entry when full. Callers hold c.mu.
+func (c *Cache[V]) store(key string, v V) {
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.config.MaxEntries {
+		var oldest string
+		var oldestAt time.Time
+		for k, e := range c.entries {
+			if oldest == "" || e.fetchedAt.Before(oldestAt) {
+				oldest, oldestAt = k, e.fetchedAt
+			}
+		}
+		delete(c.entries, oldest)
+	}
+	c.entries[key] = &entry[V]{value: v, fetchedAt: time.Now()}
+}
@@ -0,0 +1,193 @@
+// Package capabilities discovers what the hosted ShadowPay API supports: its
+// version, x402 protocol version, payment schemes and optional features. The
+// SDK consults it so that calls adapt to the deployment they talk to instead
+// of failing when the API evolves.
+//
+// APIs that predate discovery have no capabilities endpoint. For them the
+// x402 version and schemes come from the supported-schemes endpoint, and the
+// features are assumed to be Baseline.
+package capabilities
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	sperrors "sol_privacy/internal/errors"
+	"sol_privacy/internal/types"
+	"sol_privacy/internal/verify"
+)
+
+// DefaultTTL is how long discovered capabilities are reused.
+const DefaultTTL = 10 * time.Minute
+
+// retryAfter is how long a failed discovery falls back to Baseline before
+// it is tried again.
+const retryAfter = 30 * time.Second
+
+// Baseline are the features of the hosted API before it advertised them,
+// which this SDK has always relied on.
+var Baseline = []string{
+	types.FeatureShadowIDSync,
+	types.FeatureSPLTokens,
+}
+
+// Capabilities is what an API deployment supports.
+type Capabilities struct {
+	APIVersion  string          `json:"api_version,omitempty"`
+	X402Version int             `json:"x402Version"`
+	Schemes     []verify.Scheme `json:"schemes"`
+	Features    []string        `json:"features"`
+
+	// Advertised is false when the API has no capabilities endpoint and
+	// Features are Baseline.
+	Advertised bool      `json:"advertised"`
+	FetchedAt  time.Time `json:"fetched_at"`
+}
+
+// Supports reports whether the API offers a feature.
+func (c *Capabilities) Supports(feature string) bool {
+	return slices.Contains(c.Features, feature)
+}
+
+// SupportsScheme reports whether the API accepts x402 payments in a scheme on
+// a network, e.g. "zkproof" on "solana-mainnet". An empty network matches any.
+func (c *Capabilities) SupportsScheme(scheme, network string) bool {
+	for _, s := range c.Schemes {
+		if s.Scheme == scheme && (network == "" || s.Network == network) {
+			return true
+		}
+	}
+	return false
+}
+
+// Discoverer fetches and caches capabilities. It implements
+// types.Capabilities and is safe for concurrent use.
+type Discoverer struct {
+	doRequest func(ctx context.Context, method, path string, body, result interface{}) error
+	ttl       time.Duration
+
+	mu       sync.Mutex
+	cached   *Capabilities
+	failedAt time.Time
+}
+
+// New creates a discoverer. A ttl of zero uses DefaultTTL.
+func New(doRequest func(ctx context.Context, method, path string, body, result interface{}) error, ttl time.Duration) *Discoverer {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Discoverer{doRequest: doRequest, ttl: ttl}
+}
+
+// Get returns the capabilities, fetching them if the cached ones expired.
+func (d *Discoverer) Get(ctx context.Context) (*Capabilities, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cached != nil && time.Since(d.cached.FetchedAt) < d.ttl {
+		return d.cached, nil
+	}
+	return d.refreshLocked(ctx)
+}
+
+// Refresh fetches the capabilities now, e.g. after a deployment.
+func (d *Discoverer) Refresh(ctx context.Context) (*Capabilities, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.refreshLocked(ctx)
+}
+
+func (d *Discoverer) refreshLocked(ctx context.Context) (*Capabilities, error) {
+	c, err := d.fetch(ctx)
+	if err != nil {
+		d.failedAt = time.Now()
+		return nil, err
+	}
+	d.cached = c
+	return c, nil
+}
+
+func (d *Discoverer) fetch(ctx context.Context) (*Capabilities, error) {
+	var c Capabilities
+	err := d.doRequest(ctx, "GET", "/shadowpay/capabilities", nil, &c)
+	if err == nil {
+		c.Advertised = true
+		if c.X402Version == 0 {
+			c.X402Version = types.DefaultX402Version
+		}
+		c.FetchedAt = time.Now()
+		return &c, nil
+	}
+	if !missing(err) {
+		return nil, err
+	}
+
+	var supported verify.SupportedResponse
+	if err := d.doRequest(ctx, "GET", "/shadowpay/supported", nil, &supported); err != nil {
+		return nil, err
+	}
+	c = Capabilities{
+		X402Version: supported.X402Version,
+		Schemes:     supported.Schemes,
+		Features:    slices.Clone(Baseline),
+		FetchedAt:   time.Now(),
+	}
+	if c.X402Version == 0 {
+		c.X402Version = types.DefaultX402Version
+	}
+	return &c, nil
+}
+
+// missing reports whether err means the API has no such endpoint.
+func missing(err error) bool {
+	var apiErr *sperrors.ErrorResponse
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+// current returns the capabilities to gate on: the cached ones, freshly
+// fetched ones, or nil while discovery fails. A failed discovery is retried
+// after a short delay rather than on every call.
+func (d *Discoverer) current(ctx context.Context) *Capabilities {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cached != nil && time.Since(d.cached.FetchedAt) < d.ttl {
+		return d.cached
+	}
+	if time.Since(d.failedAt) < retryAfter {
+		return d.cached
+	}
+	if c, err := d.refreshLocked(ctx); err == nil {
+		return c
+	}
+	// Keep gating on stale capabilities rather than none
+	return d.cached
+}
+
+// Supports reports whether the API offers a feature. Until capabilities can
+// be discovered it assumes Baseline, so an unreachable endpoint never
+// disables a feature the API always had.
+func (d *Discoverer) Supports(ctx context.Context, feature string) bool {
+	if c := d.current(ctx); c != nil {
+		return c.Supports(feature)
+	}
+	return slices.Contains(Baseline, feature)
+}
+
+// X402Version returns the x402 version the API speaks, or
+// types.DefaultX402Version until it can be discovered.
+func (d *Discoverer) X402Version(ctx context.Context) int {
+	if c := d.current(ctx); c != nil {
+		return c.X402Version
+	}
+	return types.DefaultX402Version
+}
@@ -0,0 +1,382 @@
+// Package channel implements streaming micro-payments over x402: a client
+// authorizes a budget once, the channel middleware charges each request and
+// each second it runs against that budget, and the accrued total is claimed
+// from the authorization in periodic batches rather than settled per request.
+package channel
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/payment"
+)
+
+// Defaults.
+const (
+	DefaultSettleInterval = time.Minute
+)
+
+// Headers describing the channel a request was charged to.
+const (
+	HeaderChannelID = "X-Channel-Id"
+	HeaderRemaining = "X-Channel-Remaining" // Lamports left after the per-request charge
+)
+
+var (
+	// ErrChannelNotFound is returned for unknown channels and tokens.
+	ErrChannelNotFound = errors.New("payment channel not found")
+	// ErrBudgetExhausted is returned when a charge exceeds the budget left.
+	ErrBudgetExhausted = errors.New("payment channel budget exhausted")
+)
+
+// Rate prices usage in lamports. Either may be zero.
+type Rate struct {
+	PerRequest int64 // Charged when a request starts
+	PerSecond  int64 // Charged while a request runs, prorated to the millisecond
+}
+
+// Validate checks the rate charges something and has no negative prices.
+func (r Rate) Validate() error {
+	if r.PerRequest < 0 || r.PerSecond < 0 {
+		return fmt.Errorf("channel rates must not be negative")
+	}
+	if r.PerRequest == 0 && r.PerSecond == 0 {
+		return fmt.Errorf("channel rate needs a per-request or per-second price")
+	}
+	return nil
+}
+
+// Channel is a budget authorized by one access grant, the token issued by
+// Authorize and every token refreshed from it.
+type Channel struct {
+	ID         string `json:"id"`    // SHA-256 of the first token
+	Token      string `json:"token"` // Latest token, used to claim
+	Commitment string `json:"commitment"`
+	Merchant   string `json:"merchant"`
+	Budget     int64  `json:"budget"`  // Authorized lamports
+	Spent      int64  `json:"spent"`   // Charged so far
+	Claimed    int64  `json:"claimed"` // Settled to the merchant so far
+	Requests   int64  `json:"requests"`
+	LastTxSig  string `json:"last_tx_sig,omitempty"`
+	LastError  string `json:"last_error,omitempty"`
+	OpenedAt   int64  `json:"opened_at"`
+	UpdatedAt  int64  `json:"updated_at"`
+	SettledAt  int64  `json:"settled_at,omitempty"`
+}
+
+// Remaining returns the budget left to charge.
+func (c *Channel) Remaining() int64 {
+	return max(c.Budget-c.Spent, 0)
+}
+
+// Unsettled returns the charges not claimed yet.
+func (c *Channel) Unsettled() int64 {
+	return c.Spent - c.Claimed
+}
+
+// Config holds channel configuration.
+type Config struct {
+	Payments *payment.Service // Required
+	Rate     Rate
+	Store    Store // Defaults to an in-memory store
+
+	SettleInterval time.Duration // How often Run claims accrued charges, defaults to DefaultSettleInterval
+	MinSettlement  int64         // Charges below this wait for the next batch, unless the budget is spent
+
+	// OnSettle is called after each claim attempt; LastError is set if it failed.
+	OnSettle func(ctx context.Context, c *Channel)
+}
+
+// Manager charges requests to channels and settles them in batches.
+type Manager struct {
+	config Config
+
+	mu sync.Mutex // Serializes read-modify-write of channels
+}
+
+// New creates a channel manager. Call Run to settle charges. Tokens
+// refreshed through Payments keep charging the channel they replace.
+func New(config Config) (*Manager, error) {
+	if config.Payments == nil {
+		return nil, fmt.Errorf("channel manager needs a payment service")
+	}
+	if err := config.Rate.Validate(); err != nil {
+		return nil, err
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryStore()
+	}
+	if config.SettleInterval <= 0 {
+		config.SettleInterval = DefaultSettleInterval
+	}
+	m := &Manager{config: config}
+	config.Payments.OnRefresh(m.link)
+	return m, nil
+}
+
+// Get returns a channel.
+func (m *Manager) Get(id string) (*Channel, error) {
+	return m.config.Store.Get(id)
+}
+
+// List returns all channels, newest first.
+func (m *Manager) List() ([]*Channel, error) {
+	return m.config.Store.List()
+}
+
+// Middleware returns the x402 middleware of Payments.RequireAccess with
+// channel charging: each request is charged Rate.PerRequest when it starts
+// and Rate.PerSecond while it runs. Requests the budget cannot cover get 402
+// Payment Required; a request that spends the rest of the budget while it
+// runs has its context canceled.
+func (m *Manager) Middleware(opts payment.MiddlewareOptions) func(http.Handler) http.Handler {
+	requireAccess := m.config.Payments.RequireAccess(opts)
+	return func(next http.Handler) http.Handler {
+		return requireAccess(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c, err := m.open(payment.AccessFromContext(r.Context()))
+			if errors.Is(err, ErrBudgetExhausted) {
+				w.Header().Set(HeaderChannelID, c.ID)
+				w.Header().Set(HeaderRemaining, strconv.FormatInt(c.Remaining(), 10))
+				payment.PaymentRequired(w, "Channel budget exhausted")
+				return
+			}
+			if err != nil {
+				log.Printf("channel: failed to open channel: %v", err)
+				payment.WriteError(w, http.StatusInternalServerError, "Failed to charge payment channel")
+				return
+			}
+			w.Header().Set(HeaderChannelID, c.ID)
+			w.Header().Set(HeaderRemaining, strconv.FormatInt(c.Remaining(), 10))
+			if m.config.Rate.PerSecond == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithCancel(r.Context())
+			defer cancel()
+			meter := m.meterTime(ctx, cancel, c.ID)
+			defer meter()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}))
+	}
+}
+
+// open finds or opens the access's channel and charges the per-request price.
+func (m *Manager) open(a *payment.Access) (*Channel, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := tokenID(a.Token())
+	cid, err := m.config.Store.Resolve(id)
+	if errors.Is(err, ErrChannelNotFound) {
+		cid = id
+		if err := m.config.Store.Alias(id, cid); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	c, err := m.config.Store.Get(cid)
+	if errors.Is(err, ErrChannelNotFound) {
+		c = &Channel{ID: cid, Commitment: a.Commitment, Merchant: a.Merchant, Budget: a.Amount, OpenedAt: now}
+	} else if err != nil {
+		return nil, err
+	}
+
+	price := m.config.Rate.PerRequest
+	if c.Remaining() == 0 || c.Remaining() < price {
+		return c, ErrBudgetExhausted
+	}
+	c.Token = a.Token()
+	c.Spent += price
+	c.Requests++
+	c.UpdatedAt = now
+	if err := m.config.Store.Put(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// meterTime charges the per-second price every second until the returned
+// function is called, which charges the final partial second. It cancels
+// the request once the budget is spent.
+func (m *Manager) meterTime(ctx context.Context, cancel context.CancelFunc, id string) func() {
+	start := time.Now()
+	var (
+		mu      sync.Mutex
+		charged int64
+	)
+	chargeUntil := func(t time.Time) error {
+		mu.Lock()
+		defer mu.Unlock()
+		due := m.config.Rate.PerSecond * t.Sub(start).Milliseconds() / 1000
+		if due <= charged {
+			return nil
+		}
+		err := m.charge(id, due-charged)
+		charged = due
+		return err
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case t := <-ticker.C:
+				if err := chargeUntil(t); err != nil {
+					if !errors.Is(err, ErrBudgetExhausted) {
+						log.Printf("channel: failed to charge channel %s: %v", id, err)
+					}
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+		if err := chargeUntil(time.Now()); err != nil && !errors.Is(err, ErrBudgetExhausted) {
+			log.Printf("channel: failed to charge channel %s: %v", id, err)
+		}
+	}
+}
+
+// charge adds to a channel's spend, capped at its budget. It returns
+// ErrBudgetExhausted once the budget is spent.
+func (m *Manager) charge(id string, amount int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, err := m.config.Store.Get(id)
+	if err != nil {
+		return err
+	}
+	fits := amount < c.Remaining()
+	c.Spent += min(amount, c.Remaining())
+	c.UpdatedAt = time.Now().Unix()
+	if err := m.config.Store.Put(c); err != nil {
+		return err
+	}
+	if !fits {
+		return ErrBudgetExhausted
+	}
+	return nil
+}
+
+// Run claims accrued charges every SettleInterval until ctx is done, then
+// makes a final attempt so nothing charged before shutdown is left unclaimed.
+func (m *Manager) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.config.SettleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			final, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			m.Settle(final, true)
+			cancel()
+			return
+		case <-ticker.C:
+			m.Settle(ctx, false)
+		}
+	}
+}
+
+// Settle claims the unsettled charges of every channel in one batch. Charges
+// below MinSettlement wait for a later batch unless force is set or the
+// budget is spent. It returns the number of channels claimed from.
+func (m *Manager) Settle(ctx context.Context, force bool) int {
+	channels, err := m.config.Store.List()
+	if err != nil {
+		log.Printf("channel: failed to list channels: %v", err)
+		return 0
+	}
+	settled := 0
+	for _, c := range channels {
+		if c.Unsettled() <= 0 {
+			continue
+		}
+		if !force && c.Unsettled() < m.config.MinSettlement && c.Remaining() > 0 {
+			continue
+		}
+		if m.settle(ctx, c) {
+			settled++
+		}
+	}
+	return settled
+}
+
+// settle claims a channel's charges up to its spend when listed. Charges
+// made meanwhile are left for the next batch.
+func (m *Manager) settle(ctx context.Context, c *Channel) bool {
+	total := c.Spent
+	resp, err := m.config.Payments.Claim(ctx, payment.ClaimRequest{AccessToken: c.Token, Total: total})
+	if err == nil && !resp.Success {
+		err = fmt.Errorf("claim rejected: %s", resp.Message)
+	}
+
+	m.mu.Lock()
+	latest, getErr := m.config.Store.Get(c.ID)
+	if getErr != nil {
+		m.mu.Unlock()
+		log.Printf("channel: failed to load channel %s: %v", c.ID, getErr)
+		return false
+	}
+	if err != nil {
+		latest.LastError = err.Error()
+	} else {
+		latest.Claimed = max(latest.Claimed, total)
+		latest.LastTxSig = resp.TxSig
+		latest.LastError = ""
+		latest.SettledAt = time.Now().Unix()
+	}
+	putErr := m.config.Store.Put(latest)
+	m.mu.Unlock()
+	if putErr != nil {
+		log.Printf("channel: failed to save channel %s: %v", c.ID, putErr)
+	}
+
+	if m.config.OnSettle != nil {
+		m.config.OnSettle(ctx, latest)
+	}
+	return err == nil
+}
+
+// link carries a channel over to a refreshed token.
+func (m *Manager) link(oldToken, newToken string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cid, err := m.config.Store.Resolve(tokenID(oldToken))
+	if err != nil {
+		// No channel yet; the new token opens it
+		return
+	}
+	m.config.Store.Alias(tokenID(newToken), cid)
+	if c, err := m.config.Store.Get(cid); err == nil {
+		c.Token = newToken
+		m.config.Store.Put(c)
+	}
+}
+
+// tokenID identifies a token without using it as a key.
+func tokenID(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
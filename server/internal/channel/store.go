@@ -0,0 +1,87 @@
+package channel
+
+import (
+	"sol_privacy/internal/jsonfile"
+)
+
+// Store persists channels and which channel each token charges.
+type Store interface {
+	Get(id string) (*Channel, error) // Returns ErrChannelNotFound for unknown IDs
+	Put(c *Channel) error
+	List() ([]*Channel, error)
+	Resolve(tokenID string) (string, error) // Returns ErrChannelNotFound for unknown tokens
+	Alias(tokenID, id string) error
+}
+
+// NewMemoryStore creates an empty in-memory Store.
+func NewMemoryStore() Store {
+	return store{
+		channels: jsonfile.NewMemoryStore(channelID, nil),
+		aliases:  jsonfile.NewMemoryStore(aliasToken, nil),
+	}
+}
+
+// NewFileStore opens a Store persisted to JSON files after every write, so
+// charges not yet claimed survive restarts. Channels are kept at path and
+// token aliases at path.aliases; both are created on first write.
+func NewFileStore(path string) (Store, error) {
+	channels, err := jsonfile.NewStore(path, channelID, nil)
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := jsonfile.NewStore(path+".aliases", aliasToken, nil)
+	if err != nil {
+		return nil, err
+	}
+	return store{channels: channels, aliases: aliases}, nil
+}
+
+// alias records which channel a token charges.
+type alias struct {
+	Token   string `json:"token"`
+	Channel string `json:"channel"`
+}
+
+func channelID(c *Channel) string { return c.ID }
+
+func aliasToken(a *alias) string { return a.Token }
+
+// store keeps channels and aliases in jsonfile.Stores.
+type store struct {
+	channels *jsonfile.Store[Channel]
+	aliases  *jsonfile.Store[alias]
+}
+
+func (s store) Get(id string) (*Channel, error) {
+	c, ok := s.channels.Get(id)
+	if !ok {
+		return nil, ErrChannelNotFound
+	}
+	return c, nil
+}
+
+func (s store) Put(c *Channel) error {
+	return s.channels.Put(c)
+}
+
+// List returns all channels, newest first.
+func (s store) List() ([]*Channel, error) {
+	return s.channels.List(func(a, b *Channel) bool {
+		if a.OpenedAt != b.OpenedAt {
+			return a.OpenedAt > b.OpenedAt
+		}
+		return a.ID > b.ID
+	}), nil
+}
+
+func (s store) Resolve(tokenID string) (string, error) {
+	a, ok := s.aliases.Get(tokenID)
+	if !ok {
+		return "", ErrChannelNotFound
+	}
+	return a.Channel, nil
+}
+
+func (s store) Alias(tokenID, id string) error {
+	return s.aliases.Put(&alias{Token: tokenID, Channel: id})
+}
@@ -0,0 +1,364 @@
+// Package chaos injects realistic ShadowPay failures into SDK calls, so
+// integrators can check their retry, timeout and circuit-breaker behavior:
+// added latency, 5xx responses, truncated JSON bodies and timeouts, each at
+// a configurable rate.
+//
+// Plug an Injector into the SDK as middleware, or into any client through
+// its transport:
+//
+//	inj, _ := chaos.New(chaos.Config{ErrorRate: 0.1, TruncateRate: 0.05, Seed: 42})
+//	sdk := shadowpay.New(apiKey, client.WithMiddleware(inj.Middleware()))
+//	umbra := umbra.NewClient(umbra.Config{HTTPClient: inj.Client()})
+//
+// Faults are drawn from a seeded generator, so a seed replays the same
+// sequence of faults for the same sequence of requests.
+package chaos
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/client"
+)
+
+// Defaults.
+const (
+	DefaultMinLatency = 100 * time.Millisecond
+	DefaultMaxLatency = 2 * time.Second
+	DefaultHang       = 30 * time.Second
+)
+
+// DefaultErrorStatuses are the statuses of injected server errors.
+var DefaultErrorStatuses = []int{
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// Fault is a kind of injected failure.
+type Fault string
+
+const (
+	FaultLatency  Fault = "latency"  // Delays the request, then sends it
+	FaultError    Fault = "error"    // Answers with a 5xx without sending the request
+	FaultTruncate Fault = "truncate" // Sends the request and cuts the response body short
+	FaultTimeout  Fault = "timeout"  // Hangs without sending the request, then fails
+)
+
+// Config sets the probability, from 0 to 1, of each fault. Latency is drawn
+// independently and combines with the others; error, truncate and timeout
+// exclude each other, so their rates may add up to at most 1.
+type Config struct {
+	LatencyRate float64
+	MinLatency  time.Duration // Defaults to DefaultMinLatency
+	MaxLatency  time.Duration // Defaults to DefaultMaxLatency
+
+	ErrorRate     float64
+	ErrorStatuses []int // Defaults to DefaultErrorStatuses
+
+	TruncateRate float64
+
+	TimeoutRate float64
+	// Hang is how long a timed-out request blocks before failing, unless
+	// its context ends first. Defaults to DefaultHang.
+	Hang time.Duration
+
+	// Paths limits faults to requests whose URL path starts with one of
+	// these prefixes. Empty injects into every request.
+	Paths []string
+
+	// Seed makes the faults reproducible; 0 seeds randomly.
+	Seed uint64
+}
+
+// TimeoutError is returned for an injected timeout. Like the errors of
+// timed-out network calls, it is a net.Error whose Timeout is true.
+type TimeoutError struct {
+	Method string
+	URL    string
+	After  time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("chaos: %s %s: injected timeout after %s", e.Method, e.URL, e.After)
+}
+
+func (e *TimeoutError) Timeout() bool   { return true }
+func (e *TimeoutError) Temporary() bool { return true }
+
+// Stats counts the requests seen and the faults injected.
+type Stats struct {
+	Requests  int64 `json:"requests"`
+	Latency   int64 `json:"latency"`
+	Errors    int64 `json:"errors"`
+	Truncated int64 `json:"truncated"`
+	Timeouts  int64 `json:"timeouts"`
+}
+
+// Injector injects faults into requests.
+type Injector struct {
+	config Config
+
+	mu    sync.Mutex
+	rng   *rand.Rand
+	stats Stats
+}
+
+// New creates an injector.
+func New(config Config) (*Injector, error) {
+	for name, rate := range map[string]float64{
+		"latency":  config.LatencyRate,
+		"error":    config.ErrorRate,
+		"truncate": config.TruncateRate,
+		"timeout":  config.TimeoutRate,
+	} {
+		if rate < 0 || rate > 1 {
+			return nil, fmt.Errorf("chaos: %s rate must be between 0 and 1", name)
+		}
+	}
+	if config.ErrorRate+config.TruncateRate+config.TimeoutRate > 1 {
+		return nil, errors.New("chaos: error, truncate and timeout rates add up to more than 1")
+	}
+	if config.MinLatency <= 0 {
+		config.MinLatency = DefaultMinLatency
+	}
+	if config.MaxLatency <= 0 {
+		config.MaxLatency = DefaultMaxLatency
+	}
+	if config.MaxLatency < config.MinLatency {
+		return nil, errors.New("chaos: maximum latency is below the minimum")
+	}
+	if len(config.ErrorStatuses) == 0 {
+		config.ErrorStatuses = DefaultErrorStatuses
+	}
+	for _, status := range config.ErrorStatuses {
+		if status < 500 || status > 599 {
+			return nil, fmt.Errorf("chaos: error status %d is not a 5xx", status)
+		}
+	}
+	if config.Hang <= 0 {
+		config.Hang = DefaultHang
+	}
+	seed := config.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	return &Injector{config: config, rng: rand.New(rand.NewPCG(seed, seed))}, nil
+}
+
+// Middleware injects faults into SDK requests.
+func (i *Injector) Middleware() client.Middleware {
+	return func(next client.SendFunc) client.SendFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			return i.send(req, next)
+		}
+	}
+}
+
+// Transport injects faults in front of base, or http.DefaultTransport if nil.
+func (i *Injector) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripper(func(req *http.Request) (*http.Response, error) {
+		return i.send(req, base.RoundTrip)
+	})
+}
+
+// Client returns an HTTP client whose requests go through the injector.
+func (i *Injector) Client() *http.Client {
+	return &http.Client{Transport: i.Transport(nil)}
+}
+
+// Stats returns the faults injected so far.
+func (i *Injector) Stats() Stats {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.stats
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// plan is the faults drawn for one request.
+type plan struct {
+	latency time.Duration
+	fault   Fault // FaultError, FaultTruncate, FaultTimeout or ""
+	status  int
+	cut     float64 // Share of the body kept when truncating
+}
+
+func (i *Injector) draw(req *http.Request) plan {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.stats.Requests++
+
+	var p plan
+	if !i.matches(req.URL.Path) {
+		return p
+	}
+	c := i.config
+	if i.rng.Float64() < c.LatencyRate {
+		p.latency = c.MinLatency + time.Duration(i.rng.Int64N(int64(c.MaxLatency-c.MinLatency)+1))
+		i.stats.Latency++
+	}
+	switch roll := i.rng.Float64(); {
+	case roll < c.ErrorRate:
+		p.fault = FaultError
+		p.status = c.ErrorStatuses[i.rng.IntN(len(c.ErrorStatuses))]
+		i.stats.Errors++
+	case roll < c.ErrorRate+c.TruncateRate:
+		p.fault = FaultTruncate
+		p.cut = i.rng.Float64()
+		i.stats.Truncated++
+	case roll < c.ErrorRate+c.TruncateRate+c.TimeoutRate:
+		p.fault = FaultTimeout
+		i.stats.Timeouts++
+	}
+	return p
+}
+
+func (i *Injector) matches(path string) bool {
+	if len(i.config.Paths) == 0 {
+		return true
+	}
+	for _, prefix := range i.config.Paths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (i *Injector) send(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	p := i.draw(req)
+	ctx := req.Context()
+	if p.latency > 0 {
+		if err := sleep(ctx, p.latency); err != nil {
+			return nil, err
+		}
+	}
+
+	switch p.fault {
+	case FaultError:
+		body := fmt.Sprintf(`{"message":"%s","error":"chaos: injected %d"}`, http.StatusText(p.status), p.status)
+		resp := &http.Response{
+			Status:        fmt.Sprintf("%d %s", p.status, http.StatusText(p.status)),
+			StatusCode:    p.status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": {"application/json"}, "X-Chaos-Fault": {string(FaultError)}},
+			Body:          io.NopCloser(strings.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}
+		if p.status == http.StatusServiceUnavailable {
+			resp.Header.Set("Retry-After", "1")
+		}
+		return resp, nil
+
+	case FaultTimeout:
+		if err := sleep(ctx, i.config.Hang); err != nil {
+			return nil, err
+		}
+		return nil, &TimeoutError{Method: req.Method, URL: req.URL.Redacted(), After: i.config.Hang}
+
+	case FaultTruncate:
+		resp, err := next(req)
+		if err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		// Keep a random share of the body, never all of it
+		if len(body) > 0 {
+			body = body[:int(p.cut*float64(len(body)))]
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		resp.ContentLength = int64(len(body))
+		resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		resp.Header.Set("X-Chaos-Fault", string(FaultTruncate))
+		return resp, nil
+	}
+	return next(req)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// Parse reads a config from a spec such as
+//
+//	error=0.1,statuses=502|503,truncate=0.05,timeout=0.02,hang=5s,latency=0.3,min=50ms,max=1s,paths=/shadowpay/api/payment,seed=42
+//
+// as set in SHADOWPAY_CHAOS. Lists are separated by "|".
+func Parse(spec string) (Config, error) {
+	var c Config
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return c, fmt.Errorf("chaos: %q: expected key=value", part)
+		}
+		var err error
+		switch key {
+		case "latency":
+			c.LatencyRate, err = strconv.ParseFloat(value, 64)
+		case "min":
+			c.MinLatency, err = time.ParseDuration(value)
+		case "max":
+			c.MaxLatency, err = time.ParseDuration(value)
+		case "error":
+			c.ErrorRate, err = strconv.ParseFloat(value, 64)
+		case "statuses":
+			for _, s := range strings.Split(value, "|") {
+				var status int
+				if status, err = strconv.Atoi(s); err != nil {
+					break
+				}
+				c.ErrorStatuses = append(c.ErrorStatuses, status)
+			}
+		case "truncate":
+			c.TruncateRate, err = strconv.ParseFloat(value, 64)
+		case "timeout":
+			c.TimeoutRate, err = strconv.ParseFloat(value, 64)
+		case "hang":
+			c.Hang, err = time.ParseDuration(value)
+		case "paths":
+			c.Paths = strings.Split(value, "|")
+		case "seed":
+			c.Seed, err = strconv.ParseUint(value, 10, 64)
+		default:
+			return c, fmt.Errorf("chaos: unknown setting %q", key)
+		}
+		if err != nil {
+			return c, fmt.Errorf("chaos: %s: %w", key, err)
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,570 @@
+// Package checkout implements hosted checkout sessions: a payment intent bundled
+// with its price, accepted tokens, redirect URLs and expiry, paid through a page
+// served by the proxy. Payers pay from their ShadowPay escrow, topping it up
+// first if needed, so no transfer links their wallet to the merchant's, and a
+// session completes only once the relayer's settlement is confirmed on-chain.
+package checkout
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/amount"
+	"sol_privacy/internal/confirm"
+	sperrors "sol_privacy/internal/errors"
+	"sol_privacy/internal/escrow"
+	"sol_privacy/internal/intent"
+	"sol_privacy/internal/jsonfile"
+	"sol_privacy/internal/payment"
+	"sol_privacy/internal/types"
+	"sol_privacy/internal/validate"
+)
+
+// DefaultTTL is how long a session stays open when no expiry is requested.
+const DefaultTTL = 30 * time.Minute
+
+// Session statuses.
+const (
+	StatusOpen     = "open"
+	StatusComplete = "complete"
+	StatusExpired  = "expired"
+	StatusCanceled = "canceled"
+)
+
+// Event types emitted to the event handler.
+const (
+	EventSessionCompleted = "checkout.session.completed"
+	EventSessionExpired   = "checkout.session.expired"
+	EventSessionCanceled  = "checkout.session.canceled"
+)
+
+var (
+	// ErrSessionNotFound is returned for unknown session IDs.
+	ErrSessionNotFound = errors.New("checkout session not found")
+	// ErrSessionClosed is returned when completing or canceling a session that is not open.
+	ErrSessionClosed = errors.New("checkout session is not open")
+	// ErrNotPaid is returned when completing a session whose payment has not
+	// settled and been confirmed on-chain.
+	ErrNotPaid = errors.New("checkout payment has not settled")
+	// ErrTokenNotAccepted is returned when paying with a token the session
+	// does not accept.
+	ErrTokenNotAccepted = errors.New("token is not accepted by this checkout session")
+	// ErrPaymentPending is returned when paying a session whose recorded
+	// payment is still pending or has settled.
+	ErrPaymentPending = errors.New("checkout session already has a pending or settled payment")
+)
+
+// Session is a checkout session.
+type Session struct {
+	ID                 string            `json:"id"`
+	IntentID           string            `json:"intent_id"`
+	Amount             int64             `json:"amount"` // In lamports; the price in SOL
+	Recipient          string            `json:"recipient"`
+	ReceiverCommitment string            `json:"receiver_commitment"` // Merchant's ShadowID commitment payments go to
+	Reference          string            `json:"reference,omitempty"`
+	Description        string            `json:"description,omitempty"`
+	AcceptedTokens     []string          `json:"accepted_tokens,omitempty"` // Mints; empty means SOL only
+	Prices             map[string]int64  `json:"prices,omitempty"`          // Price in each accepted token's base units, by mint
+	SuccessURL         string            `json:"success_url"`
+	CancelURL          string            `json:"cancel_url"`
+	Status             string            `json:"status"`
+	URL                string            `json:"url"`                    // Hosted payment page
+	TokenMint          string            `json:"token_mint,omitempty"`   // Mint being paid with; empty for SOL
+	PaymentHash        string            `json:"payment_hash,omitempty"` // Of the prepared escrow payment
+	TxSignature        string            `json:"tx_signature,omitempty"` // Settlement transaction, once confirmed
+	Metadata           map[string]string `json:"metadata,omitempty"`
+	CreatedAt          int64             `json:"created_at"`
+	ExpiresAt          int64             `json:"expires_at"`
+	CompletedAt        int64             `json:"completed_at,omitempty"`
+}
+
+// CreateRequest represents a request to create a checkout session. A
+// session that accepts tokens needs a price in each of them, as a lamport
+// amount means nothing in a token with other decimals and value.
+type CreateRequest struct {
+	Amount             int64             `json:"amount"` // In lamports
+	Recipient          string            `json:"recipient"`
+	ReceiverCommitment string            `json:"receiver_commitment"` // Merchant's ShadowID commitment
+	Reference          string            `json:"reference,omitempty"`
+	Description        string            `json:"description,omitempty"`
+	AcceptedTokens     []string          `json:"accepted_tokens,omitempty"`
+	Prices             map[string]int64  `json:"prices,omitempty"` // Base units, by accepted mint
+	SuccessURL         string            `json:"success_url"`
+	CancelURL          string            `json:"cancel_url"`
+	ExpiresIn          int64             `json:"expires_in,omitempty"` // Seconds, defaults to DefaultTTL
+	Metadata           map[string]string `json:"metadata,omitempty"`
+}
+
+// Validate checks the request fields.
+func (r CreateRequest) Validate() error {
+	v := validate.New().
+		Amount("amount", r.Amount, 1, validate.MaxLamports).
+		Address("recipient", r.Recipient).
+		Commitment("receiver_commitment", r.ReceiverCommitment).
+		URL("success_url", r.SuccessURL, false).
+		URL("cancel_url", r.CancelURL, false).
+		Metadata("metadata", r.Metadata)
+	ValidatePrices(v, r.AcceptedTokens, r.Prices)
+	if r.ExpiresIn < 0 || r.ExpiresIn > 7*24*3600 {
+		v.Add("expires_in", fmt.Errorf("must be between 0 and 604800 seconds"))
+	}
+	return v.Err()
+}
+
+// ValidatePrices checks that each accepted token has a price, and that
+// prices are only set for accepted tokens.
+func ValidatePrices(v *validate.Validator, acceptedTokens []string, prices map[string]int64) {
+	for i, mint := range acceptedTokens {
+		field := "accepted_tokens[" + strconv.Itoa(i) + "]"
+		v.Address(field, mint)
+		if _, ok := prices[mint]; !ok {
+			v.Add("prices", fmt.Errorf("needs the price in %s", field))
+		}
+	}
+	for _, mint := range slices.Sorted(maps.Keys(prices)) {
+		if !slices.Contains(acceptedTokens, mint) {
+			v.Add("prices."+mint, errors.New("is not an accepted token"))
+			continue
+		}
+		v.Amount("prices."+mint, prices[mint], 1, math.MaxInt64)
+	}
+}
+
+// PayRequest represents a payer's request to pay a session.
+type PayRequest struct {
+	WalletAddress string `json:"wallet_address"`       // Payer, whose escrow pays
+	TokenMint     string `json:"token_mint,omitempty"` // One of the accepted tokens; empty for SOL
+}
+
+// PayResponse holds the transaction for the payer to sign and send: an
+// escrow deposit when the escrow is short, after which the payer pays
+// again, or else the escrow payment.
+type PayResponse struct {
+	Deposit     *types.UnsignedTxResponse `json:"deposit,omitempty"`
+	PaymentHash string                    `json:"payment_hash,omitempty"`
+	Transaction string                    `json:"transaction,omitempty"` // Unsigned escrow payment
+}
+
+// Event is emitted when a session completes, expires or is canceled.
+type Event struct {
+	Type      string  `json:"event"`
+	Timestamp int64   `json:"timestamp"`
+	Session   Session `json:"data"`
+}
+
+// EventHandler receives checkout events.
+type EventHandler func(ctx context.Context, event Event)
+
+// Store persists sessions.
+type Store interface {
+	Put(s *Session) error
+	Get(id string) (*Session, error) // Returns ErrSessionNotFound for unknown IDs
+	List() ([]*Session, error)
+}
+
+// NewMemoryStore creates an empty in-memory Store.
+func NewMemoryStore() Store {
+	return store{jsonfile.NewMemoryStore(func(s *Session) string { return s.ID }, nil)}
+}
+
+// store keeps sessions in a jsonfile.Store.
+type store struct {
+	sessions *jsonfile.Store[Session]
+}
+
+func (s store) Put(session *Session) error {
+	return s.sessions.Put(session)
+}
+
+func (s store) Get(id string) (*Session, error) {
+	session, ok := s.sessions.Get(id)
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	return session, nil
+}
+
+// List returns all sessions, newest first.
+func (s store) List() ([]*Session, error) {
+	return s.sessions.List(func(a, b *Session) bool { return a.CreatedAt > b.CreatedAt }), nil
+}
+
+// Config holds checkout configuration.
+type Config struct {
+	Intents  *intent.Service
+	Escrow   *escrow.Service
+	Payments *payment.Service
+	Tracker  *confirm.Tracker // Confirms settlements on-chain
+	Store    Store            // Defaults to an in-memory store
+	BaseURL  string           // Public URL the hosted pages are served under, e.g. https://pay.example.com/api/checkout
+	OnEvent  EventHandler     // Optional
+}
+
+// Manager creates and tracks checkout sessions.
+type Manager struct {
+	intents  *intent.Service
+	escrow   *escrow.Service
+	payments *payment.Service
+	tracker  *confirm.Tracker
+	store    Store
+	baseURL  string
+	onEvent  EventHandler
+
+	mu sync.Mutex // Serializes state transitions
+}
+
+// NewManager creates a checkout manager.
+func NewManager(config Config) *Manager {
+	if config.Store == nil {
+		config.Store = NewMemoryStore()
+	}
+	return &Manager{
+		intents:  config.Intents,
+		escrow:   config.Escrow,
+		payments: config.Payments,
+		tracker:  config.Tracker,
+		store:    config.Store,
+		baseURL:  config.BaseURL,
+		onEvent:  config.OnEvent,
+	}
+}
+
+// Create creates a payment intent and an open session for it.
+func (m *Manager) Create(ctx context.Context, req CreateRequest) (*Session, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	id := newID()
+	reference := req.Reference
+	if reference == "" {
+		reference = id
+	}
+	in, err := m.intents.Create(ctx, intent.CreateRequest{
+		Amount:    req.Amount,
+		Recipient: req.Recipient,
+		Reference: reference,
+		Metadata:  req.Metadata,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := DefaultTTL
+	if req.ExpiresIn > 0 {
+		ttl = time.Duration(req.ExpiresIn) * time.Second
+	}
+	now := time.Now()
+	s := &Session{
+		ID:                 id,
+		IntentID:           in.IntentID,
+		Amount:             req.Amount,
+		Recipient:          req.Recipient,
+		Reference:          reference,
+		Description:        req.Description,
+		AcceptedTokens:     req.AcceptedTokens,
+		Prices:             req.Prices,
+		SuccessURL:         req.SuccessURL,
+		CancelURL:          req.CancelURL,
+		Metadata:           req.Metadata,
+		Status:             StatusOpen,
+		URL:                m.baseURL + "/" + id,
+		ReceiverCommitment: req.ReceiverCommitment,
+		CreatedAt:          now.Unix(),
+		ExpiresAt:          now.Add(ttl).Unix(),
+	}
+
+	if err := m.store.Put(s); err != nil {
+		return nil, fmt.Errorf("failed to save checkout session: %w", err)
+	}
+	return s, nil
+}
+
+// Get returns a session, expiring it first if its deadline has passed.
+func (m *Manager) Get(ctx context.Context, id string) (*Session, error) {
+	m.mu.Lock()
+	s, err := m.store.Get(id)
+	if err != nil {
+		m.mu.Unlock()
+		return nil, err
+	}
+	expired := s.Status == StatusOpen && time.Now().Unix() >= s.ExpiresAt
+	if expired {
+		s.Status = StatusExpired
+		if err := m.store.Put(s); err != nil {
+			m.mu.Unlock()
+			return nil, fmt.Errorf("failed to save checkout session: %w", err)
+		}
+	}
+	m.mu.Unlock()
+
+	if expired {
+		m.emit(ctx, EventSessionExpired, *s)
+	}
+	return s, nil
+}
+
+// List returns all sessions, newest first.
+func (m *Manager) List(ctx context.Context) ([]*Session, error) {
+	return m.store.List()
+}
+
+// Pay returns the next transaction for a payer to pay a session from
+// their escrow. While the escrow holds less than the price it is a deposit
+// of the difference; then it is the escrow payment to the recipient, whose
+// hash the session records for Complete. Paying again replaces an
+// earlier payment only if it failed or the relayer does not know it, i.e. it
+// was never sent.
+func (m *Manager) Pay(ctx context.Context, id string, req PayRequest) (*PayResponse, error) {
+	if err := validate.New().
+		Address("wallet_address", req.WalletAddress).
+		OptionalAddress("token_mint", req.TokenMint).
+		Err(); err != nil {
+		return nil, err
+	}
+	s, err := m.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if s.Status != StatusOpen {
+		return nil, ErrSessionClosed
+	}
+	if !s.Accepts(req.TokenMint) {
+		return nil, ErrTokenNotAccepted
+	}
+	if err := m.checkReplaceable(ctx, s.PaymentHash); err != nil {
+		return nil, err
+	}
+	price := amount.Lamports(s.Price(req.TokenMint))
+
+	var balance *escrow.BalanceResponse
+	if req.TokenMint == "" {
+		balance, err = m.escrow.GetBalance(ctx, req.WalletAddress)
+	} else {
+		balance, err = m.escrow.GetTokenBalance(ctx, req.WalletAddress, req.TokenMint)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch escrow balance: %w", err)
+	}
+	if short := price - balance.BalanceAmount(); short > 0 {
+		deposit, err := m.escrow.Deposit(ctx, escrow.TransactionRequest{
+			WalletAddress: types.Address(req.WalletAddress),
+			Amount:        short.Int64(),
+			Mint:          req.TokenMint,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &PayResponse{Deposit: deposit}, nil
+	}
+
+	prepared, err := m.payments.Prepare(ctx, payment.PrepareRequest{
+		ReceiverCommitment: types.Commitment(s.ReceiverCommitment),
+		Amount:             price.Int64(),
+		TokenMint:          req.TokenMint,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	current, err := m.store.Get(id)
+	switch {
+	case err != nil:
+	case current.Status != StatusOpen:
+		err = ErrSessionClosed
+	case current.PaymentHash != s.PaymentHash:
+		// Another payment was recorded since it was checked
+		err = ErrPaymentPending
+	default:
+		current.TokenMint = req.TokenMint
+		current.PaymentHash = prepared.PaymentHash
+		if err = m.store.Put(current); err != nil {
+			err = fmt.Errorf("failed to save checkout session: %w", err)
+		}
+	}
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return &PayResponse{PaymentHash: prepared.PaymentHash, Transaction: prepared.Transaction}, nil
+}
+
+// checkReplaceable returns ErrPaymentPending unless the payment with hash,
+// if any, failed or is unknown to the relayer.
+func (m *Manager) checkReplaceable(ctx context.Context, hash string) error {
+	if hash == "" {
+		return nil
+	}
+	status, err := m.payments.GetStatus(ctx, hash)
+	var apiErr *sperrors.ErrorResponse
+	switch {
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound:
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to check the recorded payment: %w", err)
+	case status.Status == payment.StatusFailed:
+		return nil
+	}
+	return ErrPaymentPending
+}
+
+// Price returns the session's price in a mint's base units, "" being SOL.
+func (s *Session) Price(mint string) int64 {
+	if mint == "" {
+		return s.Amount
+	}
+	return s.Prices[mint]
+}
+
+// Accepts reports whether a session can be paid with a mint, "" being SOL.
+func (s *Session) Accepts(mint string) bool {
+	if len(s.AcceptedTokens) == 0 {
+		return mint == ""
+	}
+	return slices.Contains(s.AcceptedTokens, mint)
+}
+
+// Complete marks a session paid once the relayer reports its prepared
+// payment settled and the settlement transaction is confirmed on-chain, and
+// emits checkout.session.completed.
+func (m *Manager) Complete(ctx context.Context, id string) (*Session, error) {
+	s, err := m.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if s.Status == StatusComplete {
+		return s, nil
+	}
+	if s.Status != StatusOpen {
+		return nil, ErrSessionClosed
+	}
+	if s.PaymentHash == "" {
+		return nil, ErrNotPaid
+	}
+
+	status, err := m.payments.GetStatus(ctx, s.PaymentHash)
+	if err != nil {
+		return nil, err
+	}
+	if status.Status != payment.StatusSettled || status.TxSig == "" {
+		return nil, ErrNotPaid
+	}
+	onChain, err := m.tracker.Status(ctx, status.TxSig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to confirm settlement: %w", err)
+	}
+	if onChain.Status != confirm.StatusConfirmed && onChain.Status != confirm.StatusFinalized {
+		return nil, ErrNotPaid
+	}
+
+	m.mu.Lock()
+	current, err := m.store.Get(id)
+	if err != nil {
+		m.mu.Unlock()
+		return nil, err
+	}
+	if current.Status == StatusComplete {
+		// Completed concurrently
+		m.mu.Unlock()
+		return current, nil
+	}
+	if current.Status != StatusOpen || current.PaymentHash != s.PaymentHash {
+		m.mu.Unlock()
+		return nil, ErrSessionClosed
+	}
+	current.Status = StatusComplete
+	current.TxSignature = status.TxSig
+	current.CompletedAt = time.Now().Unix()
+	err = m.store.Put(current)
+	m.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to save checkout session: %w", err)
+	}
+
+	m.emit(ctx, EventSessionCompleted, *current)
+	return current, nil
+}
+
+// Cancel closes an open session and emits checkout.session.canceled.
+func (m *Manager) Cancel(ctx context.Context, id string) (*Session, error) {
+	s, err := m.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Status != StatusOpen {
+		return nil, ErrSessionClosed
+	}
+
+	m.mu.Lock()
+	current, err := m.store.Get(id)
+	if err == nil && current.Status != StatusOpen {
+		err = ErrSessionClosed
+	}
+	if err != nil {
+		m.mu.Unlock()
+		return nil, err
+	}
+	current.Status = StatusCanceled
+	err = m.store.Put(current)
+	m.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to save checkout session: %w", err)
+	}
+
+	m.emit(ctx, EventSessionCanceled, *current)
+	return current, nil
+}
+
+func (m *Manager) emit(ctx context.Context, eventType string, s Session) {
+	if m.onEvent == nil {
+		return
+	}
+	m.onEvent(ctx, Event{Type: eventType, Timestamp: time.Now().Unix(), Session: s})
+}
+
+// WebhookHandler returns an EventHandler that POSTs events as JSON to url.
+// Delivery errors are reported to onError if it is non-nil.
+func WebhookHandler(url string, httpClient *http.Client, onError func(error)) EventHandler {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return func(ctx context.Context, event Event) {
+		body, err := json.Marshal(event)
+		if err == nil {
+			var req *http.Request
+			if req, err = http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body)); err == nil {
+				req.Header.Set("Content-Type", "application/json")
+				var resp *http.Response
+				if resp, err = httpClient.Do(req); err == nil {
+					resp.Body.Close()
+					if resp.StatusCode >= 400 {
+						err = fmt.Errorf("checkout webhook returned status %d", resp.StatusCode)
+					}
+				}
+			}
+		}
+		if err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
+
+func newID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "cs_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,164 @@
+package checkout
+
+import (
+	"html/template"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Web3Asset is the @solana/web3.js IIFE build the hosted page loads from the
+// proxy, copied from node_modules/@solana/web3.js/lib/index.iife.min.js into
+// CHECKOUT_ASSETS_DIR.
+const Web3Asset = "web3.iife.min.js"
+
+// PageData is the view model of the hosted payment page.
+type PageData struct {
+	Session   *Session
+	AmountSOL string
+	ExpiresIn time.Duration
+	APIBase   string // Base URL of the checkout API, used by the page to complete the session
+	RPCURL    string // Solana RPC used by the wallet-connect flow
+}
+
+// RenderPage writes the hosted payment page for a session.
+func RenderPage(w io.Writer, s *Session, apiBase, rpcURL string) error {
+	return pageTemplate.Execute(w, PageData{
+		Session:   s,
+		AmountSOL: strconv.FormatFloat(float64(s.Amount)/1e9, 'f', -1, 64),
+		ExpiresIn: time.Until(time.Unix(s.ExpiresAt, 0)).Round(time.Second),
+		APIBase:   apiBase,
+		RPCURL:    rpcURL,
+	})
+}
+
+var pageTemplate = template.Must(template.New("checkout").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Checkout{{with .Session.Description}} · {{.}}{{end}}</title>
+<style>
+  body { font-family: system-ui, sans-serif; background: #0f0f14; color: #eee; display: flex; justify-content: center; padding: 48px 16px; }
+  .card { background: #1a1a24; border-radius: 16px; padding: 32px; max-width: 380px; width: 100%; text-align: center; }
+  .amount { font-size: 32px; font-weight: 600; margin: 8px 0 24px; }
+  select { width: 100%; padding: 10px; border-radius: 10px; background: #0f0f14; color: #eee; border: 1px solid #333; }
+  button { width: 100%; padding: 14px; margin-top: 16px; border: 0; border-radius: 10px; font-size: 16px; cursor: pointer; background: #7c5cff; color: #fff; }
+  button:disabled { opacity: .5; cursor: default; }
+  a.cancel { display: block; margin-top: 16px; color: #999; }
+  .muted { color: #999; font-size: 13px; }
+  #status { min-height: 20px; margin-top: 12px; }
+</style>
+</head>
+<body>
+<div class="card">
+  {{with .Session.Description}}<div>{{.}}</div>{{end}}
+  {{if not .Session.AcceptedTokens}}
+  <div class="amount">{{.AmountSOL}} SOL</div>
+  {{end}}
+  {{if eq .Session.Status "open"}}
+  {{with .Session.AcceptedTokens}}
+  <select id="token">{{range .}}<option value="{{.}}">{{index $.Session.Prices .}} base units of {{.}}</option>{{end}}</select>
+  {{end}}
+  <p class="muted">You pay from your ShadowPay escrow, topping it up first if needed, so your wallet does not transfer to the merchant directly.</p>
+  <button id="pay">Connect wallet &amp; pay</button>
+  <div id="status" class="muted"></div>
+  <a class="cancel" href="#" id="cancel">Cancel</a>
+  <p class="muted">Expires in {{.ExpiresIn}}</p>
+  {{else}}
+  <p>This checkout session is {{.Session.Status}}.</p>
+  {{end}}
+</div>
+{{if eq .Session.Status "open"}}
+<script src="{{.APIBase}}/assets/web3.iife.min.js"></script>
+<script>
+(function () {
+  var session = {
+    id: {{.Session.ID}},
+    successURL: {{.Session.SuccessURL}},
+    cancelURL: {{.Session.CancelURL}}
+  };
+  var apiBase = {{.APIBase}};
+  var rpcURL = {{.RPCURL}};
+  var status = document.getElementById("status");
+  var token = document.getElementById("token");
+
+  function post(action, body) {
+    return fetch(apiBase + "/sessions/" + session.id + "/" + action, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(body || {})
+    }).then(function (r) {
+      return r.json().then(function (data) {
+        if (!r.ok) {
+          var err = new Error(data.error || r.statusText);
+          err.status = r.status;
+          throw err;
+        }
+        return data;
+      });
+    });
+  }
+
+  function sleep(ms) {
+    return new Promise(function (resolve) { setTimeout(resolve, ms); });
+  }
+
+  // Signs and sends a base64 transaction built by the API, waiting until it
+  // is confirmed
+  async function send(wallet, conn, encoded) {
+    var bytes = Uint8Array.from(atob(encoded), function (c) { return c.charCodeAt(0); });
+    var tx = solanaWeb3.VersionedTransaction.deserialize(bytes);
+    var sent = await wallet.signAndSendTransaction(tx);
+    await conn.confirmTransaction(sent.signature, "confirmed");
+  }
+
+  document.getElementById("pay").onclick = async function () {
+    var button = this;
+    var wallet = window.solana;
+    if (!wallet) { status.textContent = "No browser wallet found."; return; }
+    if (!window.solanaWeb3) { status.textContent = "Wallet payments are unavailable on this page."; return; }
+    button.disabled = true;
+    try {
+      status.textContent = "Connecting wallet...";
+      var resp = await wallet.connect();
+      var conn = new solanaWeb3.Connection(rpcURL, "confirmed");
+      var body = { wallet_address: resp.publicKey.toString(), token_mint: token ? token.value : "" };
+
+      var prepared = await post("pay", body);
+      if (prepared.deposit) {
+        status.textContent = "Approve the deposit into your ShadowPay escrow...";
+        await send(wallet, conn, prepared.deposit.unsigned_tx_base64);
+        prepared = await post("pay", body);
+        if (!prepared.transaction) throw new Error("Your escrow deposit has not arrived yet. Try again shortly.");
+      }
+      status.textContent = "Approve the payment from your escrow...";
+      await send(wallet, conn, prepared.transaction);
+
+      status.textContent = "Waiting for the payment to settle...";
+      for (var i = 0; ; i++) {
+        try {
+          await post("complete");
+          break;
+        } catch (e) {
+          if (e.status !== 402 || i >= 40) throw e;
+          await sleep(3000);
+        }
+      }
+      window.location = session.successURL;
+    } catch (e) {
+      status.textContent = e.message || String(e);
+      button.disabled = false;
+    }
+  };
+
+  document.getElementById("cancel").onclick = function (e) {
+    e.preventDefault();
+    post("cancel").finally(function () { window.location = session.cancelURL; });
+  };
+})();
+</script>
+{{end}}
+</body>
+</html>
+`))
@@ -11,6 +11,7 @@ import (
 func Run() error {
 	// Get API key from environment
 	apiKey := os.Getenv("SHADOWPAY_API_KEY")
+	locale = localeFromEnv()
 
 	// Create the model
 	m := NewModel(apiKey)
@@ -1,15 +1,35 @@
 package cli
 
 import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+
+	"sol_privacy/internal/addressbook"
+	"sol_privacy/internal/amount"
+	"sol_privacy/internal/resolve"
+	"sol_privacy/internal/validate"
+	"sol_privacy/internal/wallets"
 )
 
+// maxSuggestions is the number of suggestions shown below the focused field.
+const maxSuggestions = 5
+
+// resolveTimeout bounds .sol lookups, which block the form.
+const resolveTimeout = 5 * time.Second
+
 type inputForm struct {
 	title       string
 	inputs      []textinput.Model
 	labels      []string
+	validators  []func(string) error // nil for free-form fields
+	errs        []string             // Inline error per field
 	focusIndex  int
 	submitFunc  func([]string) tea.Cmd
 	cancelFunc  func() tea.Cmd
@@ -17,10 +37,15 @@ type inputForm struct {
 
 func newInputForm(title string, fields []string, submitFunc func([]string) tea.Cmd) inputForm {
 	inputs := make([]textinput.Model, len(fields))
+	validators := make([]func(string) error, len(fields))
 	for i, field := range fields {
 		ti := textinput.New()
-		ti.Placeholder = field
+		ti.Placeholder = t(field)
 		ti.CharLimit = 156
+		if isSecretField(field) {
+			ti.EchoMode = textinput.EchoPassword
+		}
+		validators[i] = fieldValidator(field)
 
 		if i == 0 {
 			ti.Focus()
@@ -35,11 +60,173 @@ func newInputForm(title string, fields []string, submitFunc func([]string) tea.C
 		title:      title,
 		inputs:     inputs,
 		labels:     fields,
+		validators: validators,
+		errs:       make([]string, len(fields)),
 		focusIndex: 0,
 		submitFunc: submitFunc,
 	}
 }
 
+// isSecretField reports whether a field holds a secret that must not be echoed.
+func isSecretField(label string) bool {
+	l := strings.ToLower(label)
+	return strings.Contains(l, "private key") || strings.Contains(l, "secret") || strings.Contains(l, "access token")
+}
+
+// fieldValidator infers a validator from a field label: amounts in SOL, mint
+// and wallet addresses, and URLs. Fields marked optional or "blank = ..." may
+// be left empty.
+func fieldValidator(label string) func(string) error {
+	l := strings.ToLower(label)
+	optional := strings.Contains(l, "optional") || strings.Contains(l, "blank")
+
+	var check func(string) error
+	switch {
+	case strings.Contains(l, "(sol"):
+		check = validateSOL
+	case strings.Contains(l, "url"):
+		requireHTTPS := strings.Contains(l, "https")
+		check = func(v string) error { return validate.URL(v, requireHTTPS) }
+	case strings.HasPrefix(l, "mint address"), strings.HasPrefix(l, "address"):
+		check = validate.Address
+	default:
+		return nil
+	}
+	return func(v string) error {
+		if v == "" && optional {
+			return nil
+		}
+		return check(v)
+	}
+}
+
+// validateSOL checks a positive SOL amount that fits in lamports.
+func validateSOL(v string) error {
+	if v == "" {
+		return fmt.Errorf("is required")
+	}
+	lamports, err := amount.ParseSOL(v)
+	if err != nil {
+		return fmt.Errorf("must be a number of SOL with at most 9 decimals")
+	}
+	if lamports <= 0 {
+		return fmt.Errorf("must be greater than 0")
+	}
+	if lamports > validate.MaxLamports {
+		return fmt.Errorf("is too large")
+	}
+	return nil
+}
+
+// contactFields maps the labels of fields that take an address or commitment
+// to the kind of address book entry they accept.
+var contactFields = map[string]addressbook.Kind{
+	"Wallet Address":                 addressbook.KindWallet,
+	"User Wallet":                    addressbook.KindWallet,
+	"Destination Wallet":             addressbook.KindWallet,
+	"Recipient Wallet":               addressbook.KindWallet,
+	"Your Wallet (receives payment)": addressbook.KindWallet,
+	"Merchant Wallet":                addressbook.KindWallet,
+	"Receiver Commitment":            addressbook.KindCommitment,
+	"Commitment":                     addressbook.KindCommitment,
+}
+
+// ownWalletFields are prefilled with the default wallet.
+var ownWalletFields = map[string]bool{
+	"Wallet Address":                 true,
+	"User Wallet":                    true,
+	"Destination Wallet":             true,
+	"Recipient Wallet":               true,
+	"Your Wallet (receives payment)": true,
+}
+
+// useContacts lets address and commitment fields take a saved wallet name or
+// address book label, suggested as the user types (→ accepts); wallet fields
+// also take .sol domains. Names are resolved on submit and the values used
+// are remembered as recent.
+func (f *inputForm) useContacts(w *wallets.Manager, book *addressbook.Book) {
+	ctx := context.Background()
+	def, _ := w.Default()
+	fields := make(map[int]addressbook.Kind)
+
+	// resolveValue maps a wallet name, contact label or .sol domain to the value
+	// it stands for
+	resolveValue := func(kind addressbook.Kind, value string) (string, error) {
+		if e, err := book.Lookup(ctx, kind, value); err == nil {
+			return e.Value, nil
+		}
+		if kind == addressbook.KindWallet && resolve.IsName(value) {
+			lookup, cancel := context.WithTimeout(ctx, resolveTimeout)
+			defer cancel()
+			return resolve.Address(lookup, value)
+		}
+		if kind == addressbook.KindWallet {
+			return w.Resolve(value)
+		}
+		return value, nil
+	}
+
+	for i, label := range f.labels {
+		kind, ok := contactFields[label]
+		if !ok {
+			continue
+		}
+		fields[i] = kind
+
+		var suggestions []string
+		if kind == addressbook.KindWallet {
+			for _, wl := range w.List() {
+				suggestions = append(suggestions, wl.Name)
+			}
+		}
+		entries, _ := book.Search(ctx, "", kind, 0)
+		for _, e := range entries {
+			suggestions = append(suggestions, e.Label)
+		}
+		recent, _ := book.Recent(ctx, kind)
+		for _, r := range recent {
+			suggestions = append(suggestions, r.Value)
+		}
+
+		f.inputs[i].Placeholder = t(label) + " " + t("or name")
+		f.inputs[i].ShowSuggestions = true
+		f.inputs[i].KeyMap.AcceptSuggestion = key.NewBinding(key.WithKeys("right"))
+		f.inputs[i].SetSuggestions(suggestions)
+		if ownWalletFields[label] && def != nil && f.inputs[i].Value() == "" {
+			f.inputs[i].SetValue(def.Name)
+		}
+
+		f.validators[i] = func(v string) error {
+			resolved, err := resolveValue(kind, v)
+			if err != nil {
+				return err
+			}
+			if kind == addressbook.KindCommitment {
+				return validate.Commitment(resolved)
+			}
+			return validate.Address(resolved)
+		}
+	}
+	if len(fields) == 0 {
+		return
+	}
+
+	labels, submit := f.labels, f.submitFunc
+	f.submitFunc = func(values []string) tea.Cmd {
+		for i, kind := range fields {
+			value, err := resolveValue(kind, values[i])
+			if err != nil {
+				return func() tea.Msg {
+					return operationErrorMsg{fmt.Errorf("%s: %w", labels[i], err)}
+				}
+			}
+			values[i] = value
+			book.Touch(ctx, kind, value)
+		}
+		return submit(values)
+	}
+}
+
 func (f *inputForm) Update(msg tea.Msg) tea.Cmd {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
@@ -47,8 +234,11 @@ func (f *inputForm) Update(msg tea.Msg) tea.Cmd {
 		case "tab", "shift+tab", "enter", "up", "down":
 			s := msg.String()
 
-			// Submit on enter from last field
+			// Submit on enter from last field once every field is valid
 			if s == "enter" && f.focusIndex == len(f.inputs)-1 {
+				if invalid := f.validateAll(); invalid >= 0 {
+					return f.focus(invalid)
+				}
 				values := make([]string, len(f.inputs))
 				for i, input := range f.inputs {
 					values[i] = input.Value()
@@ -56,41 +246,82 @@ func (f *inputForm) Update(msg tea.Msg) tea.Cmd {
 				return f.submitFunc(values)
 			}
 
-			// Cycle through inputs
-			if s == "up" || s == "shift+tab" {
-				f.focusIndex--
+			// Check the field being left, unless it was skipped
+			if f.inputs[f.focusIndex].Value() != "" {
+				f.validateField(f.focusIndex)
 			} else {
-				f.focusIndex++
+				f.errs[f.focusIndex] = ""
 			}
 
-			if f.focusIndex > len(f.inputs)-1 {
-				f.focusIndex = 0
-			} else if f.focusIndex < 0 {
-				f.focusIndex = len(f.inputs) - 1
+			// Cycle through inputs
+			next := f.focusIndex
+			if s == "up" || s == "shift+tab" {
+				next--
+			} else {
+				next++
 			}
 
-			cmds := make([]tea.Cmd, len(f.inputs))
-			for i := 0; i <= len(f.inputs)-1; i++ {
-				if i == f.focusIndex {
-					cmds[i] = f.inputs[i].Focus()
-					f.inputs[i].PromptStyle = lipgloss.NewStyle().Foreground(primaryColor)
-					f.inputs[i].TextStyle = lipgloss.NewStyle().Foreground(textColor)
-					continue
-				}
-				f.inputs[i].Blur()
-				f.inputs[i].PromptStyle = lipgloss.NewStyle().Foreground(subtleColor)
-				f.inputs[i].TextStyle = lipgloss.NewStyle().Foreground(subtleColor)
+			if next > len(f.inputs)-1 {
+				next = 0
+			} else if next < 0 {
+				next = len(f.inputs) - 1
 			}
-
-			return tea.Batch(cmds...)
+			return f.focus(next)
 		}
 	}
 
 	// Handle character input
 	cmd := f.updateInputs(msg)
+	// Clear an inline error as soon as the field is fixed
+	if f.errs[f.focusIndex] != "" {
+		f.validateField(f.focusIndex)
+	}
 	return cmd
 }
 
+// focus moves the focus to field i.
+func (f *inputForm) focus(i int) tea.Cmd {
+	f.focusIndex = i
+	cmds := make([]tea.Cmd, len(f.inputs))
+	for i := 0; i <= len(f.inputs)-1; i++ {
+		if i == f.focusIndex {
+			cmds[i] = f.inputs[i].Focus()
+			f.inputs[i].PromptStyle = lipgloss.NewStyle().Foreground(primaryColor)
+			f.inputs[i].TextStyle = lipgloss.NewStyle().Foreground(textColor)
+			continue
+		}
+		f.inputs[i].Blur()
+		f.inputs[i].PromptStyle = lipgloss.NewStyle().Foreground(subtleColor)
+		f.inputs[i].TextStyle = lipgloss.NewStyle().Foreground(subtleColor)
+	}
+	return tea.Batch(cmds...)
+}
+
+// validateField runs the validator of field i and records its inline error.
+func (f *inputForm) validateField(i int) bool {
+	f.errs[i] = ""
+	if f.validators[i] == nil {
+		return true
+	}
+	if err := f.validators[i](strings.TrimSpace(f.inputs[i].Value())); err != nil {
+		f.errs[i] = t(err.Error())
+		return false
+	}
+	return true
+}
+
+// validateAll checks every field and returns the index of the first invalid
+// one, or -1.
+func (f *inputForm) validateAll() int {
+	invalid := -1
+	for i := range f.inputs {
+		if !f.validateField(i) && invalid < 0 {
+			invalid = i
+		}
+	}
+	return invalid
+}
+
 func (f *inputForm) updateInputs(msg tea.Msg) tea.Cmd {
 	cmds := make([]tea.Cmd, len(f.inputs))
 	for i := range f.inputs {
@@ -100,7 +331,7 @@ func (f *inputForm) updateInputs(msg tea.Msg) tea.Cmd {
 }
 
 func (f inputForm) View(width, height int) string {
-	title := titleStyle.Render(f.title)
+	title := titleStyle.Render(t(f.title))
 
 	labelStyle := lipgloss.NewStyle().
 		Foreground(secondaryColor).
@@ -108,16 +339,30 @@ func (f inputForm) View(width, height int) string {
 		MarginBottom(0)
 
 	var inputsView string
+	var suggesting bool
 	for i, input := range f.inputs {
 		// Add label above input
-		inputsView += labelStyle.Render(f.labels[i]) + "\n"
+		inputsView += labelStyle.Render(t(f.labels[i])) + "\n"
 		inputsView += input.View() + "\n"
+		if input.ShowSuggestions {
+			suggesting = true
+			if i == f.focusIndex {
+				inputsView += suggestionsView(&input)
+			}
+		}
+		if f.errs[i] != "" {
+			inputsView += errorStyle.Render("✗ "+f.errs[i]) + "\n"
+		}
 		if i < len(f.inputs)-1 {
 			inputsView += "\n"
 		}
 	}
 
-	help := helpStyle.Render("tab/shift+tab: navigate • enter: submit • esc: cancel")
+	helpText := t("tab/shift+tab: navigate • enter: submit • esc: cancel")
+	if suggesting {
+		helpText += "\n" + t("→: accept suggestion • ctrl+n/ctrl+p: next/previous suggestion")
+	}
+	help := helpStyle.Render(helpText)
 
 	content := lipgloss.JoinVertical(
 		lipgloss.Left,
@@ -136,3 +381,33 @@ func (f inputForm) View(width, height int) string {
 		content,
 	)
 }
+
+// suggestionsView lists the suggestions matching what was typed into input,
+// highlighting the one → would accept.
+func suggestionsView(input *textinput.Model) string {
+	if input.Value() == "" {
+		return ""
+	}
+	matched := input.MatchedSuggestions()
+	if len(matched) == 0 || (len(matched) == 1 && matched[0] == input.Value()) {
+		return ""
+	}
+
+	current := input.CurrentSuggestionIndex()
+	start := 0
+	if current >= maxSuggestions {
+		start = current - maxSuggestions + 1
+	}
+	var out string
+	for i := start; i < len(matched) && i < start+maxSuggestions; i++ {
+		if i == current {
+			out += selectedMenuItemStyle.Render("❯ "+matched[i]) + "\n"
+		} else {
+			out += menuItemStyle.Foreground(subtleColor).Render(matched[i]) + "\n"
+		}
+	}
+	if len(matched) > maxSuggestions {
+		out += helpStyle.UnsetMarginTop().Render("  " + fmt.Sprintf(t("%d matches"), len(matched))) + "\n"
+	}
+	return out
+}
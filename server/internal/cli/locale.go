@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"os"
+	"strings"
+	"unicode"
+
+	"sol_privacy/internal/i18n"
+)
+
+// locale is the language of the TUI, chosen once at startup.
+var locale = i18n.English
+
+// localeFromEnv reads SHADOWPAY_LANG, falling back to the system LANG.
+func localeFromEnv() i18n.Locale {
+	if lang := os.Getenv("SHADOWPAY_LANG"); lang != "" {
+		return i18n.Parse(lang)
+	}
+	return i18n.Parse(os.Getenv("LANG"))
+}
+
+// t translates a TUI string. A leading icon such as "💸 " is kept as is and
+// only the text after it is translated.
+func t(s string) string {
+	r := []rune(s)
+	if len(r) == 0 || unicode.IsLetter(r[0]) || unicode.IsDigit(r[0]) {
+		return i18n.T(locale, s)
+	}
+	icon, text, ok := strings.Cut(s, " ")
+	if !ok {
+		return s
+	}
+	trimmed := strings.TrimLeft(text, " ")
+	return icon + " " + text[:len(text)-len(trimmed)] + i18n.T(locale, trimmed)
+}
@@ -3,10 +3,15 @@ package cli
 import (
 	"context"
 	"fmt"
+	"os"
+	"time"
 
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"sol_privacy"
+	"sol_privacy/internal/addressbook"
+	"sol_privacy/internal/wallets"
 )
 
 type view int
@@ -21,6 +26,8 @@ const (
 	webhookView
 	shadowIDView
 	settingsView
+	walletsView
+	onboardingView
 )
 
 type Model struct {
@@ -35,8 +42,20 @@ type Model struct {
 	messageStyle lipgloss.Style
 	loading      bool
 	loadingMsg   string
+	loadingSince time.Time
+	spinner      spinner.Model
+	currentOp    int64              // Operation shown by the loading view
+	doneOp       int64              // Last operation that finished
+	cancelledOp  int64              // Last operation cancelled with esc
+	cancelOp     context.CancelFunc // Cancels the current operation
 	showingInput bool
 	inputForm    inputForm
+	showingResults bool
+	results        resultsPane
+	wallets      *wallets.Manager
+	addressBook  *addressbook.Book
+	profile      *profile
+	profilePath  string
 
 	// Sub-models for different views
 	paymentModel       *PaymentModel
@@ -47,13 +66,13 @@ type Model struct {
 
 func NewModel(apiKey string) Model {
 	ctx := context.Background()
-	var client *shadowpay.ShadowPay
-	if apiKey != "" {
-		client = shadowpay.New(apiKey)
-	}
-	return Model{
+	sp := spinner.New()
+	sp.Spinner = spinner.Dot
+	sp.Style = lipgloss.NewStyle().Foreground(secondaryColor)
+
+	m := Model{
 		ctx:          ctx,
-		client:       client,
+		spinner:      sp,
 		currentView:  mainMenuView,
 		cursor:       0,
 		apiKey:       apiKey,
@@ -61,6 +80,51 @@ func NewModel(apiKey string) Model {
 		loading:      false,
 		showingInput: false,
 	}
+
+	// Named wallets prefill and resolve wallet fields in forms
+	w, err := wallets.Open(wallets.DefaultPath())
+	if err != nil {
+		w, _ = wallets.Open("")
+		m.message = fmt.Sprintf("Wallets not loaded: %v", err)
+		m.messageStyle = errorStyle
+	}
+	m.wallets = w
+
+	// The address book is only persisted once a passphrase is configured
+	var store addressbook.Store
+	if key := os.Getenv("SHADOWPAY_ADDRESSBOOK_KEY"); key != "" {
+		fs, err := addressbook.NewEncryptedFileStore(addressbook.DefaultPath(), key)
+		if err != nil {
+			m.message = fmt.Sprintf("Address book not loaded: %v", err)
+			m.messageStyle = errorStyle
+		} else {
+			store = fs
+		}
+	}
+	m.addressBook = addressbook.NewBook(store)
+
+	// The wizard's profile supplies the API key when none is set, and a new
+	// user starts in the wizard
+	m.profilePath = profilePath()
+	p, err := loadProfile(m.profilePath)
+	if err != nil {
+		p = &profile{}
+		m.message = fmt.Sprintf("Profile not loaded: %v", err)
+		m.messageStyle = errorStyle
+	}
+	if baseURL := os.Getenv("SHADOWPAY_BASE_URL"); baseURL != "" {
+		p.BaseURL = baseURL
+	}
+	m.profile = p
+	if m.apiKey == "" {
+		m.apiKey = p.APIKey
+	}
+	if m.apiKey != "" {
+		m.client = p.newClient(m.apiKey)
+	} else if p.WalletAddress == "" {
+		m.startOnboarding()
+	}
+	return m
 }
 
 func (m Model) Init() tea.Cmd {
@@ -72,8 +136,20 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		m.results.setSize(msg.Width, msg.Height)
 		return m, nil
 
+	case resultsMsg:
+		m.loading = false
+		m.showingInput = false
+		m.message = ""
+		m.results = newResultsPane(msg.title, msg.rows, m.width, m.height)
+		m.showingResults = true
+		return m, nil
+
+	case onboardingMsg:
+		return m.handleOnboardingResult(msg)
+
 	case operationSuccessMsg:
 		m.loading = false
 		m.showingInput = false
@@ -89,11 +165,65 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case loadingMsg:
+		if msg.id == m.doneOp {
+			// Finished before its loading message arrived
+			return m, nil
+		}
 		m.loading = true
 		m.loadingMsg = msg.message
-		return m, nil
+		m.loadingSince = time.Now()
+		m.currentOp = msg.id
+		m.cancelOp = msg.cancel
+		return m, m.spinner.Tick
+
+	case operationDoneMsg:
+		if msg.id == m.cancelledOp {
+			return m, nil
+		}
+		m.doneOp = msg.id
+		m.cancelOp = nil
+		return m.Update(msg.msg)
+
+	case spinner.TickMsg:
+		if !m.loading {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
 
 	case tea.KeyMsg:
+		// Esc cancels the running operation; other keys wait for it
+		if m.loading {
+			switch msg.String() {
+			case "ctrl+c":
+				return m, tea.Quit
+			case "esc":
+				if m.cancelOp != nil {
+					m.cancelOp()
+					m.cancelOp = nil
+				}
+				m.cancelledOp = m.currentOp
+				m.loading = false
+				m.showingInput = false
+				m.message = t("Operation cancelled")
+				m.messageStyle = errorStyle
+			}
+			return m, nil
+		}
+
+		// Handle results pane
+		if m.showingResults {
+			if msg.String() == "ctrl+c" {
+				return m, tea.Quit
+			}
+			cmd, closed := m.results.Update(msg)
+			if closed {
+				m.showingResults = false
+			}
+			return m, cmd
+		}
+
 		// Handle input form
 		if m.showingInput {
 			switch msg.String() {
@@ -154,6 +284,10 @@ func (m Model) View() string {
 		return m.renderLoading()
 	}
 
+	if m.showingResults {
+		return m.results.View(m.width, m.height)
+	}
+
 	if m.showingInput {
 		return m.inputForm.View(m.width, m.height)
 	}
@@ -177,19 +311,26 @@ func (m Model) View() string {
 		return m.renderShadowIDView()
 	case settingsView:
 		return m.renderSettingsView()
+	case walletsView:
+		return m.renderWalletsView()
+	case onboardingView:
+		return m.renderOnboardingView()
 	default:
 		return m.renderMainMenu()
 	}
 }
 
 func (m Model) renderMainMenu() string {
-	title := titleStyle.Render("🔒 ShadowPay CLI")
+	title := titleStyle.Render(t("🔒 ShadowPay CLI"))
 
 	var statusText string
 	if m.client != nil {
-		statusText = successStyle.Render("✓ Connected")
+		statusText = successStyle.Render(t("✓ Connected"))
 	} else {
-		statusText = errorStyle.Render("✗ Not Connected (Set API Key)")
+		statusText = errorStyle.Render(t("✗ Not Connected (Set API Key)"))
+	}
+	if w, err := m.wallets.Default(); err == nil {
+		statusText += "\n" + helpStyle.Render("👛 "+w.Name+" ("+shortAddress(w.Address)+")")
 	}
 
 	menu := []string{
@@ -200,6 +341,8 @@ func (m Model) renderMainMenu() string {
 		"💰 Merchant Tools",
 		"🔔 Webhooks",
 		"👤 ShadowID",
+		"👛 Wallets & Contacts",
+		"🧭 Setup Wizard",
 		"⚙️  Settings",
 		"🚪 Exit",
 	}
@@ -212,10 +355,10 @@ func (m Model) renderMainMenu() string {
 			cursor = "❯ "
 			style = selectedMenuItemStyle
 		}
-		menuStr += cursor + style.Render(item) + "\n"
+		menuStr += cursor + style.Render(t(item)) + "\n"
 	}
 
-	help := helpStyle.Render("↑/↓: navigate • enter: select • q: quit")
+	help := helpStyle.Render(t("↑/↓: navigate • enter: select • q: quit"))
 
 	var messageBox string
 	if m.message != "" {
@@ -245,7 +388,17 @@ func (m Model) renderMainMenu() string {
 func (m Model) getMaxCursor() int {
 	switch m.currentView {
 	case mainMenuView:
-		return 8 // 9 menu items (0-8)
+		return 10 // 11 menu items (0-10)
+	case paymentView:
+		return 7
+	case merchantView:
+		return 9
+	case walletsView:
+		return 10
+	case shadowIDView:
+		return 6
+	case onboardingView:
+		return 0
 	default:
 		return 5
 	}
@@ -256,7 +409,7 @@ func (m *Model) handleEnter() (tea.Model, tea.Cmd) {
 		switch m.cursor {
 		case 0: // ZK Payments
 			if m.client == nil {
-				m.message = "Please set API key in Settings first"
+				m.message = t("Please set API key in Settings first")
 				m.messageStyle = errorStyle
 				return *m, nil
 			}
@@ -266,7 +419,7 @@ func (m *Model) handleEnter() (tea.Model, tea.Cmd) {
 
 		case 1: // Privacy Pool
 			if m.client == nil {
-				m.message = "Please set API key in Settings first"
+				m.message = t("Please set API key in Settings first")
 				m.messageStyle = errorStyle
 				return *m, nil
 			}
@@ -276,7 +429,7 @@ func (m *Model) handleEnter() (tea.Model, tea.Cmd) {
 
 		case 2: // Token Management
 			if m.client == nil {
-				m.message = "Please set API key in Settings first"
+				m.message = t("Please set API key in Settings first")
 				m.messageStyle = errorStyle
 				return *m, nil
 			}
@@ -286,7 +439,7 @@ func (m *Model) handleEnter() (tea.Model, tea.Cmd) {
 
 		case 3: // Bot Authorization
 			if m.client == nil {
-				m.message = "Please set API key in Settings first"
+				m.message = t("Please set API key in Settings first")
 				m.messageStyle = errorStyle
 				return *m, nil
 			}
@@ -296,7 +449,7 @@ func (m *Model) handleEnter() (tea.Model, tea.Cmd) {
 
 		case 4: // Merchant Tools
 			if m.client == nil {
-				m.message = "Please set API key in Settings first"
+				m.message = t("Please set API key in Settings first")
 				m.messageStyle = errorStyle
 				return *m, nil
 			}
@@ -306,7 +459,7 @@ func (m *Model) handleEnter() (tea.Model, tea.Cmd) {
 
 		case 5: // Webhooks
 			if m.client == nil {
-				m.message = "Please set API key in Settings first"
+				m.message = t("Please set API key in Settings first")
 				m.messageStyle = errorStyle
 				return *m, nil
 			}
@@ -316,7 +469,7 @@ func (m *Model) handleEnter() (tea.Model, tea.Cmd) {
 
 		case 6: // ShadowID
 			if m.client == nil {
-				m.message = "Please set API key in Settings first"
+				m.message = t("Please set API key in Settings first")
 				m.messageStyle = errorStyle
 				return *m, nil
 			}
@@ -324,39 +477,57 @@ func (m *Model) handleEnter() (tea.Model, tea.Cmd) {
 			m.cursor = 0
 			m.message = ""
 
-		case 7: // Settings
+		case 7: // Wallets & Contacts
+			m.currentView = walletsView
+			m.cursor = 0
+			m.message = ""
+
+		case 8: // Setup Wizard
+			m.startOnboarding()
+
+		case 9: // Settings
 			m.currentView = settingsView
 			m.cursor = 0
 			m.message = ""
 
-		case 8: // Exit
+		case 10: // Exit
 			return *m, tea.Quit
 		}
 	} else {
 		// Handle sub-menu selections
+		var cmd tea.Cmd
 		switch m.currentView {
 		case paymentView:
-			return *m, m.handlePaymentSelection()
+			cmd = m.handlePaymentSelection()
 		case poolView:
-			return *m, m.handlePoolSelection()
+			cmd = m.handlePoolSelection()
 		case tokenView:
-			return *m, m.handleTokenSelection()
+			cmd = m.handleTokenSelection()
 		case authorizationView:
-			return *m, m.handleAuthorizationSelection()
+			cmd = m.handleAuthorizationSelection()
 		case merchantView:
-			return *m, m.handleMerchantSelection()
+			cmd = m.handleMerchantSelection()
 		case webhookView:
-			return *m, m.handleWebhookSelection()
+			cmd = m.handleWebhookSelection()
 		case shadowIDView:
-			return *m, m.handleShadowIDSelection()
+			cmd = m.handleShadowIDSelection()
+		case walletsView:
+			cmd = m.handleWalletsSelection()
+		case onboardingView:
+			cmd = m.handleOnboardingEnter()
+		}
+		// Wallet fields accept wallet names and default to the selected wallet
+		if m.showingInput {
+			m.inputForm.useContacts(m.wallets, m.addressBook)
 		}
+		return *m, cmd
 	}
 
 	return *m, nil
 }
 
 func (m Model) renderPaymentView() string {
-	title := titleStyle.Render("💸 ZK Payments")
+	title := titleStyle.Render(t("💸 ZK Payments"))
 
 	menu := []string{
 		"📥 Deposit Funds",
@@ -365,6 +536,7 @@ func (m Model) renderPaymentView() string {
 		"✅ Authorize Payment",
 		"🔍 Verify Access",
 		"⚡ Settle Payment",
+		"🛰️  Track Transaction",
 		"◀ Back",
 	}
 
@@ -376,10 +548,10 @@ func (m Model) renderPaymentView() string {
 			cursor = "❯ "
 			style = selectedMenuItemStyle
 		}
-		menuStr += cursor + style.Render(item) + "\n"
+		menuStr += cursor + style.Render(t(item)) + "\n"
 	}
 
-	help := helpStyle.Render("↑/↓: navigate • enter: select • esc: back")
+	help := helpStyle.Render(t("↑/↓: navigate • enter: select • esc: back"))
 
 	var messageBox string
 	if m.message != "" {
@@ -390,7 +562,7 @@ func (m Model) renderPaymentView() string {
 		lipgloss.Left,
 		title,
 		"",
-		headerStyle.Render("Select an operation:"),
+		headerStyle.Render(t("Select an operation:")),
 		menuStr,
 		messageBox,
 		"",
@@ -407,17 +579,18 @@ func (m Model) renderPaymentView() string {
 }
 
 func (m Model) renderPoolView() string {
-	title := titleStyle.Render("🏊 Privacy Pool")
+	title := titleStyle.Render(t("🏊 Privacy Pool"))
 
-	info := infoBoxStyle.Render(
+	info := infoBoxStyle.Render(t(
 		"Privacy pools mix your funds with other users\n" +
-		"for maximum anonymity on-chain.",
+		"for maximum anonymity on-chain."),
 	)
 
 	menu := []string{
 		"💰 Check Balance",
 		"📥 Deposit to Pool",
 		"📤 Withdraw from Pool",
+		"🧮 Quote Withdrawal Fee",
 		"📍 Get Deposit Address",
 		"◀ Back",
 	}
@@ -430,10 +603,10 @@ func (m Model) renderPoolView() string {
 			cursor = "❯ "
 			style = selectedMenuItemStyle
 		}
-		menuStr += cursor + style.Render(item) + "\n"
+		menuStr += cursor + style.Render(t(item)) + "\n"
 	}
 
-	help := helpStyle.Render("↑/↓: navigate • enter: select • esc: back")
+	help := helpStyle.Render(t("↑/↓: navigate • enter: select • esc: back"))
 
 	var messageBox string
 	if m.message != "" {
@@ -444,7 +617,7 @@ func (m Model) renderPoolView() string {
 		lipgloss.Left,
 		title,
 		info,
-		headerStyle.Render("Select an operation:"),
+		headerStyle.Render(t("Select an operation:")),
 		menuStr,
 		messageBox,
 		"",
@@ -460,11 +633,95 @@ func (m Model) renderPoolView() string {
 	)
 }
 
+func (m Model) renderWalletsView() string {
+	title := titleStyle.Render(t("👛 Wallets & Contacts"))
+
+	info := "No wallets saved yet."
+	if list := m.wallets.List(); len(list) > 0 {
+		def, _ := m.wallets.Default()
+		info = ""
+		for i, w := range list {
+			marker := "  "
+			if def != nil && def.Name == w.Name {
+				marker = "★ "
+			}
+			kind := "watch-only"
+			if w.HasKeypair() {
+				kind = "keypair"
+			}
+			if i > 0 {
+				info += "\n"
+			}
+			info += fmt.Sprintf("%s%s  %s  (%s)", marker, w.Name, shortAddress(w.Address), kind)
+		}
+	}
+
+	menu := []string{
+		"📋 List Wallets",
+		"➕ Add Wallet",
+		"★ Select Default",
+		"🗑️  Remove Wallet",
+		"📒 Add Contact",
+		"🔎 Search Contacts",
+		"✂️  Remove Contact",
+		"🪪 Register Stealth Handle",
+		"🔭 Look Up Handle",
+		"🚰 Devnet Faucet",
+		"◀ Back",
+	}
+
+	var menuStr string
+	for i, item := range menu {
+		cursor := "  "
+		style := menuItemStyle
+		if m.cursor == i {
+			cursor = "❯ "
+			style = selectedMenuItemStyle
+		}
+		menuStr += cursor + style.Render(t(item)) + "\n"
+	}
+
+	help := helpStyle.Render(t("↑/↓: navigate • enter: select • esc: back"))
+
+	var messageBox string
+	if m.message != "" {
+		messageBox = "\n" + infoBoxStyle.Render(m.messageStyle.Render(m.message))
+	}
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		infoBoxStyle.Render(info),
+		headerStyle.Render(t("Select an operation:")),
+		menuStr,
+		messageBox,
+		"",
+		help,
+	)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		content,
+	)
+}
+
+// shortAddress abbreviates a base58 address for display.
+func shortAddress(address string) string {
+	if len(address) <= 12 {
+		return address
+	}
+	return address[:4] + "..." + address[len(address)-4:]
+}
+
 func (m Model) renderTokenView() string {
-	title := titleStyle.Render("🪙 Token Management")
+	title := titleStyle.Render(t("🪙 Token Management"))
 
 	menu := []string{
 		"📋 List Supported Tokens",
+		"💼 Escrow Balances",
 		"➕ Add New Token",
 		"✏️  Update Token",
 		"🗑️  Remove Token",
@@ -479,10 +736,10 @@ func (m Model) renderTokenView() string {
 			cursor = "❯ "
 			style = selectedMenuItemStyle
 		}
-		menuStr += cursor + style.Render(item) + "\n"
+		menuStr += cursor + style.Render(t(item)) + "\n"
 	}
 
-	help := helpStyle.Render("↑/↓: navigate • enter: select • esc: back")
+	help := helpStyle.Render(t("↑/↓: navigate • enter: select • esc: back"))
 
 	var messageBox string
 	if m.message != "" {
@@ -493,7 +750,7 @@ func (m Model) renderTokenView() string {
 		lipgloss.Left,
 		title,
 		"",
-		headerStyle.Render("Manage SPL tokens:"),
+		headerStyle.Render(t("Manage SPL tokens:")),
 		menuStr,
 		messageBox,
 		"",
@@ -510,16 +767,17 @@ func (m Model) renderTokenView() string {
 }
 
 func (m Model) renderAuthorizationView() string {
-	title := titleStyle.Render("🤖 Bot Authorization")
+	title := titleStyle.Render(t("🤖 Bot Authorization"))
 
-	info := infoBoxStyle.Render(
+	info := infoBoxStyle.Render(t(
 		"Allow bots and services to spend from your\n" +
-		"escrow with custom limits and expiration.",
+		"escrow with custom limits and expiration."),
 	)
 
 	menu := []string{
 		"✅ Authorize Bot Spending",
 		"📋 List Authorizations",
+		"✏️  Update Limits",
 		"🚫 Revoke Authorization",
 		"◀ Back",
 	}
@@ -532,10 +790,10 @@ func (m Model) renderAuthorizationView() string {
 			cursor = "❯ "
 			style = selectedMenuItemStyle
 		}
-		menuStr += cursor + style.Render(item) + "\n"
+		menuStr += cursor + style.Render(t(item)) + "\n"
 	}
 
-	help := helpStyle.Render("↑/↓: navigate • enter: select • esc: back")
+	help := helpStyle.Render(t("↑/↓: navigate • enter: select • esc: back"))
 
 	var messageBox string
 	if m.message != "" {
@@ -546,7 +804,7 @@ func (m Model) renderAuthorizationView() string {
 		lipgloss.Left,
 		title,
 		info,
-		headerStyle.Render("Manage bot permissions:"),
+		headerStyle.Render(t("Manage bot permissions:")),
 		menuStr,
 		messageBox,
 		"",
@@ -563,13 +821,18 @@ func (m Model) renderAuthorizationView() string {
 }
 
 func (m Model) renderMerchantView() string {
-	title := titleStyle.Render("💰 Merchant Tools")
+	title := titleStyle.Render(t("💰 Merchant Tools"))
 
 	menu := []string{
 		"💵 View Earnings",
 		"📊 Get Analytics",
 		"📤 Withdraw Earnings",
 		"🔓 Decrypt Amount",
+		"🔗 Create Payment Link",
+		"📋 List Payment Links",
+		"🧾 Create Invoice",
+		"📑 List Invoices",
+		"⬇️  Download Invoice PDF",
 		"◀ Back",
 	}
 
@@ -581,10 +844,10 @@ func (m Model) renderMerchantView() string {
 			cursor = "❯ "
 			style = selectedMenuItemStyle
 		}
-		menuStr += cursor + style.Render(item) + "\n"
+		menuStr += cursor + style.Render(t(item)) + "\n"
 	}
 
-	help := helpStyle.Render("↑/↓: navigate • enter: select • esc: back")
+	help := helpStyle.Render(t("↑/↓: navigate • enter: select • esc: back"))
 
 	var messageBox string
 	if m.message != "" {
@@ -595,7 +858,7 @@ func (m Model) renderMerchantView() string {
 		lipgloss.Left,
 		title,
 		"",
-		headerStyle.Render("Merchant operations:"),
+		headerStyle.Render(t("Merchant operations:")),
 		menuStr,
 		messageBox,
 		"",
@@ -612,7 +875,7 @@ func (m Model) renderMerchantView() string {
 }
 
 func (m Model) renderWebhookView() string {
-	title := titleStyle.Render("🔔 Webhooks")
+	title := titleStyle.Render(t("🔔 Webhooks"))
 
 	menu := []string{
 		"➕ Register Webhook",
@@ -632,10 +895,10 @@ func (m Model) renderWebhookView() string {
 			cursor = "❯ "
 			style = selectedMenuItemStyle
 		}
-		menuStr += cursor + style.Render(item) + "\n"
+		menuStr += cursor + style.Render(t(item)) + "\n"
 	}
 
-	help := helpStyle.Render("↑/↓: navigate • enter: select • esc: back")
+	help := helpStyle.Render(t("↑/↓: navigate • enter: select • esc: back"))
 
 	var messageBox string
 	if m.message != "" {
@@ -646,7 +909,7 @@ func (m Model) renderWebhookView() string {
 		lipgloss.Left,
 		title,
 		"",
-		headerStyle.Render("Webhook operations:"),
+		headerStyle.Render(t("Webhook operations:")),
 		menuStr,
 		messageBox,
 		"",
@@ -663,11 +926,11 @@ func (m Model) renderWebhookView() string {
 }
 
 func (m Model) renderShadowIDView() string {
-	title := titleStyle.Render("👤 ShadowID")
+	title := titleStyle.Render(t("👤 ShadowID"))
 
-	info := infoBoxStyle.Render(
+	info := infoBoxStyle.Render(t(
 		"Anonymous identity system using Merkle trees\n" +
-		"for privacy-preserving authentication.",
+		"for privacy-preserving authentication."),
 	)
 
 	menu := []string{
@@ -676,6 +939,7 @@ func (m Model) renderShadowIDView() string {
 		"🔍 Get Proof",
 		"🌳 Get Tree Root",
 		"📋 Check Status",
+		"🧪 Debug Proof",
 		"◀ Back",
 	}
 
@@ -687,10 +951,10 @@ func (m Model) renderShadowIDView() string {
 			cursor = "❯ "
 			style = selectedMenuItemStyle
 		}
-		menuStr += cursor + style.Render(item) + "\n"
+		menuStr += cursor + style.Render(t(item)) + "\n"
 	}
 
-	help := helpStyle.Render("↑/↓: navigate • enter: select • esc: back")
+	help := helpStyle.Render(t("↑/↓: navigate • enter: select • esc: back"))
 
 	var messageBox string
 	if m.message != "" {
@@ -701,7 +965,7 @@ func (m Model) renderShadowIDView() string {
 		lipgloss.Left,
 		title,
 		info,
-		headerStyle.Render("ShadowID operations:"),
+		headerStyle.Render(t("ShadowID operations:")),
 		menuStr,
 		messageBox,
 		"",
@@ -718,31 +982,34 @@ func (m Model) renderShadowIDView() string {
 }
 
 func (m Model) renderSettingsView() string {
-	title := titleStyle.Render("⚙️  Settings")
+	title := titleStyle.Render(t("⚙️  Settings"))
 
 	var statusBox string
 	if m.apiKey == "" {
-		statusBox = infoBoxStyle.Render(errorStyle.Render("⚠ API Key not set"))
+		statusBox = infoBoxStyle.Render(errorStyle.Render(t("⚠ API Key not set")))
 	} else {
 		maskedKey := m.apiKey
 		if len(maskedKey) > 12 {
 			maskedKey = maskedKey[:4] + "..." + maskedKey[len(maskedKey)-4:]
 		}
 		statusBox = infoBoxStyle.Render(
-			successStyle.Render("✓ API Key: ") + maskedKey,
+			successStyle.Render(t("✓ API Key: ")) + maskedKey,
 		)
 	}
+	statusBox += "\n" + helpStyle.UnsetMarginTop().Render(t("🌐 Language: ")+string(locale))
 
 	instructions := lipgloss.NewStyle().
 		Foreground(subtleColor).
-		Render(
+		Render(t(
 			"To set your API key, run:\n" +
 			"export SHADOWPAY_API_KEY=your_key_here\n\n" +
 			"Or create a .env file with:\n" +
-			"SHADOWPAY_API_KEY=your_key_here",
+			"SHADOWPAY_API_KEY=your_key_here") + "\n\n" +
+			t("Or run the Setup Wizard to generate one.") + "\n\n" +
+			t("To change the language, set SHADOWPAY_LANG (en, es, zh)."),
 		)
 
-	help := helpStyle.Render("esc: back to main menu")
+	help := helpStyle.Render(t("esc: back to main menu"))
 
 	content := lipgloss.JoinVertical(
 		lipgloss.Left,
@@ -764,22 +1031,22 @@ func (m Model) renderSettingsView() string {
 }
 
 func (m Model) renderLoading() string {
-	spinner := "⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏"
-	frame := spinner[0:1] // Simple static spinner for now
-
-	title := titleStyle.Render("Processing...")
+	title := titleStyle.Render(t("Processing..."))
 	loadingText := lipgloss.NewStyle().
 		Foreground(secondaryColor).
 		Bold(true).
-		Render(frame + " " + m.loadingMsg)
+		Render(m.spinner.View() + " " + t(m.loadingMsg))
+	elapsed := helpStyle.UnsetMarginTop().Render(
+		fmt.Sprintf(t("Elapsed: %s"), time.Since(m.loadingSince).Truncate(time.Second)))
 
 	content := lipgloss.JoinVertical(
 		lipgloss.Left,
 		title,
 		"",
 		loadingText,
+		elapsed,
 		"",
-		helpStyle.Render("Please wait..."),
+		helpStyle.Render(t("esc: cancel")),
 	)
 
 	return lipgloss.Place(
@@ -0,0 +1,458 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"sol_privacy/internal/amount"
+	"sol_privacy/internal/confirm"
+	"sol_privacy/internal/elgamal"
+	"sol_privacy/internal/faucet"
+	"sol_privacy/internal/keys"
+	"sol_privacy/internal/pool"
+	"sol_privacy/internal/shadowid"
+	"sol_privacy/internal/solana"
+	"sol_privacy/internal/types"
+	"sol_privacy/internal/wallet"
+	"sol_privacy/internal/wallets"
+)
+
+type onboardingStep int
+
+const (
+	stepWallet onboardingStep = iota
+	stepAPIKey
+	stepShadowID
+	stepElGamal
+	stepDeposit
+	stepVerify
+	stepsDone
+)
+
+var onboardingSteps = []string{
+	"👛 Choose a wallet",
+	"🔑 Generate an API key",
+	"👤 Register your ShadowID",
+	"🔐 Generate an ElGamal keypair",
+	"🏊 Deposit to the pool on devnet",
+	"✅ Verify the setup",
+}
+
+const (
+	onboardingWalletName = "main"
+	onboardingFeeReserve = 5_000_000 // Kept back for transaction fees
+)
+
+// onboardingMsg is the result of a wizard step. apply records it in the
+// profile, which is saved before the message or results are shown.
+type onboardingMsg struct {
+	apply   func(p *profile)
+	message string
+	results *resultsMsg
+}
+
+// startOnboarding opens the wizard, filling in the network settings of a new
+// profile from the environment.
+func (m *Model) startOnboarding() {
+	if m.profile.Network == "" {
+		m.profile.Network = "devnet"
+	}
+	if m.profile.RPCURL == "" {
+		m.profile.RPCURL = os.Getenv("SOLANA_RPC_URL")
+		if m.profile.RPCURL == "" {
+			m.profile.RPCURL = solana.DevnetRPCURL
+		}
+	}
+	m.currentView = onboardingView
+	m.cursor = 0
+	m.message = ""
+}
+
+// nextOnboardingStep returns the first step without a result in the profile.
+func (m Model) nextOnboardingStep() onboardingStep {
+	p := m.profile
+	switch {
+	case p.WalletAddress == "":
+		return stepWallet
+	case m.apiKey == "":
+		return stepAPIKey
+	case p.Commitment == "":
+		return stepShadowID
+	case p.ElGamalPublic == "":
+		return stepElGamal
+	case p.DepositSignature == "":
+		return stepDeposit
+	case p.VerifiedAt == "":
+		return stepVerify
+	default:
+		return stepsDone
+	}
+}
+
+func (m *Model) handleOnboardingEnter() tea.Cmd {
+	p := *m.profile
+	switch m.nextOnboardingStep() {
+	case stepWallet:
+		return m.onboardWallet()
+	case stepAPIKey:
+		return onboardAPIKey(p)
+	case stepShadowID:
+		return m.onboardShadowID(p)
+	case stepElGamal:
+		return onboardElGamal()
+	case stepDeposit:
+		return m.onboardDeposit(p)
+	case stepVerify:
+		return m.onboardVerify(p)
+	default:
+		m.currentView = mainMenuView
+		m.cursor = 0
+		m.message = ""
+		return nil
+	}
+}
+
+func (m *Model) handleOnboardingResult(msg onboardingMsg) (tea.Model, tea.Cmd) {
+	m.loading = false
+	if msg.apply != nil {
+		msg.apply(m.profile)
+		if err := m.profile.save(m.profilePath); err != nil {
+			m.message = fmt.Sprintf("Error: profile not saved: %v", err)
+			m.messageStyle = errorStyle
+			return *m, nil
+		}
+		if m.apiKey == "" && m.profile.APIKey != "" {
+			m.apiKey = m.profile.APIKey
+			m.client = m.profile.newClient(m.apiKey)
+		}
+	}
+	if msg.results != nil {
+		return m.Update(*msg.results)
+	}
+	m.message = msg.message
+	m.messageStyle = successStyle
+	return *m, nil
+}
+
+// onboardWallet uses the default wallet, creating and selecting a new keypair
+// when there is none. The wizard signs with it, so it cannot be watch-only.
+func (m *Model) onboardWallet() tea.Cmd {
+	return func() tea.Msg {
+		w, err := m.wallets.Default()
+		created := false
+		if err == nil && !w.HasKeypair() {
+			return operationErrorMsg{fmt.Errorf("default wallet %q is watch-only; select a wallet with a secret key in Wallets & Contacts", w.Name)}
+		}
+		if err != nil {
+			kp, err := wallet.Generate()
+			if err != nil {
+				return operationErrorMsg{err}
+			}
+			w, err = m.wallets.Add(wallets.AddRequest{Name: onboardingWalletName, SecretKey: kp.SecretKey()})
+			if err != nil {
+				return operationErrorMsg{err}
+			}
+			if err := m.wallets.SetDefault(w.Name); err != nil {
+				return operationErrorMsg{err}
+			}
+			created = true
+		}
+
+		msg := fmt.Sprintf("Using wallet %q\nAddress: %s", w.Name, w.Address)
+		if created {
+			msg = fmt.Sprintf("Created wallet %q\nAddress: %s", w.Name, w.Address)
+		}
+		return onboardingMsg{
+			apply: func(p *profile) {
+				p.Wallet = w.Name
+				p.WalletAddress = w.Address
+			},
+			message: msg,
+		}
+	}
+}
+
+// onboardingKeypair loads the keypair of the wallet chosen by the wizard.
+func (m *Model) onboardingKeypair(p profile) (*wallet.Keypair, error) {
+	w, err := m.wallets.Get(p.Wallet)
+	if err != nil {
+		return nil, err
+	}
+	if w.Address != p.WalletAddress {
+		return nil, fmt.Errorf("wallet %q no longer has address %s", p.Wallet, p.WalletAddress)
+	}
+	return w.Keypair()
+}
+
+func onboardAPIKey(p profile) tea.Cmd {
+	return withLoading("Generating API key...", func(ctx context.Context) tea.Msg {
+		resp, err := p.newClient("").Keys.Create(ctx, keys.GenerateRequest{WalletAddress: p.WalletAddress})
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+		if resp.APIKey == "" {
+			return operationErrorMsg{errors.New("no API key returned")}
+		}
+		return onboardingMsg{
+			apply:   func(p *profile) { p.APIKey = resp.APIKey },
+			message: fmt.Sprintf("API key generated for %s", shortAddress(p.WalletAddress)),
+		}
+	})
+}
+
+// onboardShadowID derives the wallet's identity and registers it. An identity
+// registered by an earlier, interrupted run is accepted as is.
+func (m *Model) onboardShadowID(p profile) tea.Cmd {
+	return withLoading("Registering ShadowID...", func(ctx context.Context) tea.Msg {
+		kp, err := m.onboardingKeypair(p)
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+		id, err := shadowid.DeriveCommitment(kp)
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+		commitment := id.CommitmentHex()
+
+		leafIndex := 0
+		resp, err := m.client.ShadowID.AutoRegister(ctx, id.AutoRegisterRequest())
+		if err == nil && resp.Success {
+			leafIndex = resp.LeafIndex
+		} else {
+			status, statusErr := m.client.ShadowID.GetStatus(ctx, commitment)
+			if statusErr != nil || !status.Registered {
+				if err == nil {
+					err = fmt.Errorf("registration failed: %s", resp.Message)
+				}
+				return operationErrorMsg{err}
+			}
+			leafIndex = status.LeafIndex
+		}
+
+		return onboardingMsg{
+			apply: func(p *profile) {
+				p.Commitment = commitment
+				p.LeafIndex = leafIndex
+			},
+			message: fmt.Sprintf("ShadowID registered\nCommitment: %s\nLeaf Index: %d", commitment, leafIndex),
+		}
+	})
+}
+
+// onboardElGamal generates the keypair amounts are encrypted to. It never
+// leaves this machine; only the public key is shared.
+func onboardElGamal() tea.Cmd {
+	return func() tea.Msg {
+		key, err := elgamal.GenerateKey(nil)
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+		pub := key.Public().Hex()
+		return onboardingMsg{
+			apply: func(p *profile) {
+				p.ElGamalPublic = pub
+				p.ElGamalPrivate = key.Hex()
+			},
+			message: fmt.Sprintf("ElGamal keypair generated\nPublic Key: %s", pub),
+		}
+	}
+}
+
+// onboardDeposit makes the smallest pool deposit the API accepts, signed by
+// the wizard's wallet and submitted to devnet. A wallet without enough SOL is
+// topped up from the devnet faucet first.
+func (m *Model) onboardDeposit(p profile) tea.Cmd {
+	return withLoading("Depositing to the pool on devnet...", func(ctx context.Context) tea.Msg {
+		if p.RPCURL == solana.MainnetRPCURL {
+			return operationErrorMsg{errors.New("the setup deposit only runs on devnet; set SOLANA_RPC_URL to a devnet node")}
+		}
+		kp, err := m.onboardingKeypair(p)
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, 3*time.Minute)
+		defer cancel()
+
+		rpc := solana.NewClient(solana.Config{URL: p.RPCURL})
+
+		lamports := amount.Lamports(pool.MinDepositLamports)
+		if balance, err := m.client.Pool.GetBalance(ctx, p.WalletAddress); err == nil && balance.MinDepositAmount() > lamports {
+			lamports = balance.MinDepositAmount()
+		}
+		if _, err := faucet.EnsureBalance(ctx, rpc, p.WalletAddress, lamports+onboardingFeeReserve); err != nil {
+			return operationErrorMsg{fmt.Errorf("%w\nFund %s at https://faucet.solana.com and retry", err, p.WalletAddress)}
+		}
+
+		resp, err := m.client.Pool.Deposit(ctx, pool.DepositRequest{
+			WalletAddress: types.Address(p.WalletAddress),
+			Amount:        lamports.Int64(),
+		})
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+		signed, err := wallet.SignTransaction(kp, resp.Transaction)
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+		sig, err := rpc.SendTransaction(ctx, signed)
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+		result, err := confirm.NewTracker(rpc, 0).WaitConfirmed(ctx, sig)
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+		if result.Status == confirm.StatusFailed {
+			return operationErrorMsg{fmt.Errorf("deposit %s failed: %s", sig, result.Error)}
+		}
+
+		return onboardingMsg{
+			apply:   func(p *profile) { p.DepositSignature = sig },
+			message: fmt.Sprintf("Deposited %s SOL to the pool\nSignature: %s", lamports.SOL(), sig),
+		}
+	})
+}
+
+// onboardVerify checks every result of the wizard against the API and the
+// cluster. The setup is marked verified only when all checks pass.
+func (m *Model) onboardVerify(p profile) tea.Cmd {
+	return withLoading("Verifying setup...", func(ctx context.Context) tea.Msg {
+		var rows []resultRow
+		failed := 0
+		check := func(name string, detail string, err error) {
+			if err != nil {
+				failed++
+				rows = append(rows, resultRow{summary: "❌ " + name, detail: err.Error()})
+				return
+			}
+			rows = append(rows, resultRow{summary: "✓ " + name, detail: detail})
+		}
+
+		limits, err := m.client.Keys.GetLimits(ctx)
+		detail := ""
+		if err == nil {
+			detail = fmt.Sprintf("Rate limit: %d of %d requests remaining", limits.Remaining, limits.Limit)
+		}
+		check("API key accepted", detail, err)
+
+		status, err := m.client.ShadowID.GetStatus(ctx, p.Commitment)
+		detail = ""
+		if err == nil && !status.Registered {
+			err = fmt.Errorf("commitment %s is not registered", p.Commitment)
+		} else if err == nil {
+			detail = fmt.Sprintf("Commitment: %s\nLeaf Index: %d", p.Commitment, status.LeafIndex)
+		}
+		check("ShadowID registered", detail, err)
+
+		check("ElGamal keypair decrypts", fmt.Sprintf("Public Key: %s", p.ElGamalPublic), elgamalRoundTrip(p))
+
+		tracker := confirm.NewTracker(solana.NewClient(solana.Config{URL: p.RPCURL}), 0)
+		result, err := tracker.Status(ctx, p.DepositSignature)
+		detail = ""
+		if err == nil && result.Status != confirm.StatusConfirmed && result.Status != confirm.StatusFinalized {
+			err = fmt.Errorf("deposit %s is %s", p.DepositSignature, result.Status)
+		} else if err == nil {
+			detail = fmt.Sprintf("Signature: %s\nSlot: %d", p.DepositSignature, result.Slot)
+			if balance, err := m.client.Pool.GetBalance(ctx, p.WalletAddress); err == nil {
+				detail += fmt.Sprintf("\nPool Balance: %s SOL", balance.BalanceAmount().SOL())
+			}
+		}
+		check("Pool deposit confirmed", detail, err)
+
+		title := "✅ Setup Verified"
+		var apply func(p *profile)
+		if failed == 0 {
+			verifiedAt := time.Now().UTC().Format(time.RFC3339)
+			apply = func(p *profile) { p.VerifiedAt = verifiedAt }
+		} else {
+			title = fmt.Sprintf("❌ Setup Incomplete (%d failed)", failed)
+		}
+		return onboardingMsg{apply: apply, results: &resultsMsg{title: title, rows: rows}}
+	})
+}
+
+// elgamalRoundTrip encrypts a test amount to the profile's public key and
+// checks the private key decrypts it.
+func elgamalRoundTrip(p profile) error {
+	key, err := elgamal.ParsePrivateKey(p.ElGamalPrivate)
+	if err != nil {
+		return err
+	}
+	if key.Public().Hex() != p.ElGamalPublic {
+		return errors.New("private key does not match the public key")
+	}
+	const probe = 4242
+	c, err := elgamal.Encrypt(key.Public(), probe, nil)
+	if err != nil {
+		return err
+	}
+	got, err := key.Decrypt(c, probe)
+	if err != nil {
+		return err
+	}
+	if got != probe {
+		return fmt.Errorf("decrypted %d, want %d", got, probe)
+	}
+	return nil
+}
+
+func (m Model) renderOnboardingView() string {
+	title := titleStyle.Render(t("🧭 Setup Wizard"))
+
+	info := infoBoxStyle.Render(t(
+		"Sets up a wallet, API key, ShadowID and encryption\n" +
+			"keys, and tries a small pool deposit on devnet."),
+	)
+
+	next := m.nextOnboardingStep()
+	var steps string
+	for i, step := range onboardingSteps {
+		marker := "  "
+		style := menuItemStyle
+		switch {
+		case onboardingStep(i) < next:
+			marker = "✓ "
+			style = successStyle
+		case onboardingStep(i) == next:
+			marker = "❯ "
+			style = selectedMenuItemStyle
+		}
+		steps += marker + style.Render(t(step)) + "\n"
+	}
+
+	help := helpStyle.Render(t("enter: run next step • esc: back"))
+	if next == stepsDone {
+		steps += "\n" + successStyle.Render(t("Setup complete. Saved to ")+m.profilePath) + "\n"
+		help = helpStyle.Render(t("enter: main menu • esc: back"))
+	}
+
+	var messageBox string
+	if m.message != "" {
+		messageBox = "\n" + infoBoxStyle.Render(m.messageStyle.Render(m.message))
+	}
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		info,
+		headerStyle.Render(t("Steps:")),
+		steps,
+		messageBox,
+		"",
+		help,
+	)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		content,
+	)
+}
@@ -2,18 +2,36 @@ package cli
 
 import (
 	"context"
+	"crypto/ecdh"
+	"encoding/hex"
 	"fmt"
+	"math"
+	"os"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"sol_privacy/internal/addressbook"
+	"sol_privacy/internal/amount"
 	"sol_privacy/internal/authorization"
+	"sol_privacy/internal/confirm"
+	"sol_privacy/internal/faucet"
+	"sol_privacy/internal/invoice"
 	"sol_privacy/internal/merchant"
 	"sol_privacy/internal/payment"
+	"sol_privacy/internal/paymentlink"
 	"sol_privacy/internal/pool"
 	"sol_privacy/internal/privacy"
+	"sol_privacy/internal/proxyclient"
+	"sol_privacy/internal/registry"
 	"sol_privacy/internal/shadowid"
+	"sol_privacy/internal/solana"
+	"sol_privacy/internal/stealth"
 	"sol_privacy/internal/token"
+	"sol_privacy/internal/types"
+	"sol_privacy/internal/wallets"
 	"sol_privacy/internal/webhook"
 )
 
@@ -28,15 +46,31 @@ type operationErrorMsg struct {
 
 type loadingMsg struct {
 	message string
+	id      int64
+	cancel  context.CancelFunc
 }
 
-// Helper function to wrap operations with loading indicator
-func withLoading(loadingMessage string, operation func() tea.Msg) tea.Cmd {
+// operationDoneMsg carries the result of the operation with the given id.
+type operationDoneMsg struct {
+	id  int64
+	msg tea.Msg
+}
+
+var operationIDs atomic.Int64
+
+// Helper function to wrap operations with loading indicator. Esc cancels the
+// context passed to operation, and the result of a cancelled operation is dropped.
+func withLoading(loadingMessage string, operation func(ctx context.Context) tea.Msg) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	id := operationIDs.Add(1)
 	return tea.Batch(
 		func() tea.Msg {
-			return loadingMsg{message: loadingMessage}
+			return loadingMsg{message: loadingMessage, id: id, cancel: cancel}
+		},
+		func() tea.Msg {
+			defer cancel()
+			return operationDoneMsg{id: id, msg: operation(ctx)}
 		},
-		operation,
 	)
 }
 
@@ -56,13 +90,59 @@ func (m *Model) handlePaymentSelection() tea.Cmd {
 	case 5: // Settle Payment
 		m.message = "Settle is complex - requires x402 payload. Use API directly."
 		m.messageStyle = errorStyle
-	case 6: // Back
+	case 6: // Track Transaction
+		return m.showTrackTransactionForm()
+	case 7: // Back
 		m.currentView = mainMenuView
 		m.cursor = 0
 	}
 	return nil
 }
 
+func (m *Model) showTrackTransactionForm() tea.Cmd {
+	m.inputForm = newInputForm(
+		"🛰️  Track Transaction",
+		[]string{"Signature", "Last Valid Block Height"},
+		func(values []string) tea.Cmd {
+			return m.performTrackTransaction(values[0], values[1])
+		},
+	)
+	m.showingInput = true
+	return nil
+}
+
+func (m *Model) performTrackTransaction(signature, lastValidStr string) tea.Cmd {
+	return withLoading("Waiting for confirmation...", func(ctx context.Context) tea.Msg {
+		lastValid, err := strconv.ParseUint(lastValidStr, 10, 64)
+		if err != nil {
+			return operationErrorMsg{fmt.Errorf("invalid block height: %w", err)}
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+		defer cancel()
+
+		tracker := confirm.NewTracker(solana.NewClient(solana.Config{
+			URL: os.Getenv("SOLANA_RPC_URL"),
+		}), 0)
+		result, err := tracker.Track(ctx, signature, lastValid, confirm.Callbacks{})
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+
+		msg := fmt.Sprintf("Transaction %s\n\nSignature: %s", result.Status, result.Signature)
+		if result.Slot > 0 {
+			msg += fmt.Sprintf("\nSlot: %d", result.Slot)
+		}
+		if result.Error != "" {
+			return operationErrorMsg{fmt.Errorf("%s\nError: %s", msg, result.Error)}
+		}
+		if result.Status == confirm.StatusExpired {
+			return operationErrorMsg{fmt.Errorf("transaction expired before confirmation; rebuild and resubmit")}
+		}
+		return operationSuccessMsg{message: msg}
+	})
+}
+
 func (m *Model) showDepositForm() tea.Cmd {
 	m.inputForm = newInputForm(
 		"💸 Deposit to Payment Account",
@@ -76,19 +156,17 @@ func (m *Model) showDepositForm() tea.Cmd {
 }
 
 func (m *Model) performPaymentDeposit(wallet, amountStr string) tea.Cmd {
-	return func() tea.Msg {
-		amount, err := strconv.ParseFloat(amountStr, 64)
+	return withLoading("Creating deposit...", func(ctx context.Context) tea.Msg {
+		lamports, err := amount.ParseSOL(amountStr)
 		if err != nil {
-			return operationErrorMsg{fmt.Errorf("invalid amount: %w", err)}
+			return operationErrorMsg{err}
 		}
 
-		lamports := int64(amount * 1e9)
 		req := payment.DepositRequest{
-			WalletAddress: wallet,
-			Amount:        lamports,
+			WalletAddress: types.Address(wallet),
+			Amount:        lamports.Int64(),
 		}
 
-		ctx := context.Background()
 		resp, err := m.client.Payment.Deposit(ctx, req)
 		if err != nil {
 			return operationErrorMsg{err}
@@ -97,7 +175,7 @@ func (m *Model) performPaymentDeposit(wallet, amountStr string) tea.Cmd {
 		return operationSuccessMsg{
 			message: fmt.Sprintf("Deposit transaction created!\nBlockhash: %s\nSign and send the transaction to complete.", resp.RecentBlockhash),
 		}
-	}
+	})
 }
 
 func (m *Model) showWithdrawForm() tea.Cmd {
@@ -113,19 +191,17 @@ func (m *Model) showWithdrawForm() tea.Cmd {
 }
 
 func (m *Model) performPaymentWithdraw(wallet, amountStr string) tea.Cmd {
-	return func() tea.Msg {
-		amount, err := strconv.ParseFloat(amountStr, 64)
+	return withLoading("Creating withdrawal...", func(ctx context.Context) tea.Msg {
+		lamports, err := amount.ParseSOL(amountStr)
 		if err != nil {
-			return operationErrorMsg{fmt.Errorf("invalid amount: %w", err)}
+			return operationErrorMsg{err}
 		}
 
-		lamports := int64(amount * 1e9)
 		req := payment.WithdrawRequest{
-			WalletAddress: wallet,
-			Amount:        lamports,
+			WalletAddress: types.Address(wallet),
+			Amount:        lamports.Int64(),
 		}
 
-		ctx := context.Background()
 		resp, err := m.client.Payment.Withdraw(ctx, req)
 		if err != nil {
 			return operationErrorMsg{err}
@@ -134,7 +210,7 @@ func (m *Model) performPaymentWithdraw(wallet, amountStr string) tea.Cmd {
 		return operationSuccessMsg{
 			message: fmt.Sprintf("Withdraw transaction created!\nBlockhash: %s\n%s", resp.RecentBlockhash, resp.Message),
 		}
-	}
+	})
 }
 
 func (m *Model) showPreparePaymentForm() tea.Cmd {
@@ -150,19 +226,17 @@ func (m *Model) showPreparePaymentForm() tea.Cmd {
 }
 
 func (m *Model) performPreparePayment(commitment, amountStr string) tea.Cmd {
-	return func() tea.Msg {
-		amount, err := strconv.ParseFloat(amountStr, 64)
+	return withLoading("Preparing payment...", func(ctx context.Context) tea.Msg {
+		lamports, err := amount.ParseSOL(amountStr)
 		if err != nil {
-			return operationErrorMsg{fmt.Errorf("invalid amount: %w", err)}
+			return operationErrorMsg{err}
 		}
 
-		lamports := int64(amount * 1e9)
 		req := payment.PrepareRequest{
-			ReceiverCommitment: commitment,
-			Amount:             lamports,
+			ReceiverCommitment: types.Commitment(commitment),
+			Amount:             lamports.Int64(),
 		}
 
-		ctx := context.Background()
 		resp, err := m.client.Payment.Prepare(ctx, req)
 		if err != nil {
 			return operationErrorMsg{err}
@@ -171,7 +245,7 @@ func (m *Model) performPreparePayment(commitment, amountStr string) tea.Cmd {
 		return operationSuccessMsg{
 			message: fmt.Sprintf("Payment prepared!\nPayment Hash: %s\nCommitment: %s\n%s", resp.PaymentHash, resp.Commitment[:20]+"...", resp.Message),
 		}
-	}
+	})
 }
 
 func (m *Model) showAuthorizePaymentForm() tea.Cmd {
@@ -187,21 +261,19 @@ func (m *Model) showAuthorizePaymentForm() tea.Cmd {
 }
 
 func (m *Model) performAuthorizePayment(commitment, nullifier, amountStr, merchant string) tea.Cmd {
-	return func() tea.Msg {
-		amount, err := strconv.ParseFloat(amountStr, 64)
+	return withLoading("Authorizing payment...", func(ctx context.Context) tea.Msg {
+		lamports, err := amount.ParseSOL(amountStr)
 		if err != nil {
-			return operationErrorMsg{fmt.Errorf("invalid amount: %w", err)}
+			return operationErrorMsg{err}
 		}
 
-		lamports := int64(amount * 1e9)
 		req := payment.AuthorizeRequest{
-			Commitment: commitment,
+			Commitment: types.Commitment(commitment),
 			Nullifier:  nullifier,
-			Amount:     lamports,
-			Merchant:   merchant,
+			Amount:     lamports.Int64(),
+			Merchant:   types.Address(merchant),
 		}
 
-		ctx := context.Background()
 		resp, err := m.client.Payment.Authorize(ctx, req)
 		if err != nil {
 			return operationErrorMsg{err}
@@ -211,7 +283,7 @@ func (m *Model) performAuthorizePayment(commitment, nullifier, amountStr, mercha
 			message: fmt.Sprintf("Payment authorized!\nAccess Token: %s...\nExpires in: %d seconds\n%s",
 				resp.AccessToken[:20], resp.ExpiresIn, resp.Message),
 		}
-	}
+	})
 }
 
 func (m *Model) showVerifyAccessForm() tea.Cmd {
@@ -227,8 +299,7 @@ func (m *Model) showVerifyAccessForm() tea.Cmd {
 }
 
 func (m *Model) performVerifyAccess(token string) tea.Cmd {
-	return func() tea.Msg {
-		ctx := context.Background()
+	return withLoading("Verifying access...", func(ctx context.Context) tea.Msg {
 		resp, err := m.client.Payment.VerifyAccess(ctx, token)
 		if err != nil {
 			return operationErrorMsg{err}
@@ -243,7 +314,7 @@ func (m *Model) performVerifyAccess(token string) tea.Cmd {
 			message: fmt.Sprintf("Access verification: %s\nMerchant: %s\nAmount: %d\nExpires: %s\n%s",
 				status, resp.Merchant, resp.Amount, resp.ExpiresAt, resp.Message),
 		}
-	}
+	})
 }
 
 // Pool operations
@@ -255,9 +326,11 @@ func (m *Model) handlePoolSelection() tea.Cmd {
 		return m.showPoolDepositForm()
 	case 2: // Withdraw
 		return m.showPoolWithdrawForm()
-	case 3: // Get Deposit Address
+	case 3: // Quote Withdrawal Fee
+		return m.showPoolQuoteForm()
+	case 4: // Get Deposit Address
 		return m.performGetDepositAddress()
-	case 4: // Back
+	case 5: // Back
 		m.currentView = mainMenuView
 		m.cursor = 0
 	}
@@ -277,18 +350,15 @@ func (m *Model) showPoolBalanceForm() tea.Cmd {
 }
 
 func (m *Model) performPoolBalance(wallet string) tea.Cmd {
-	return withLoading("Checking balance...", func() tea.Msg {
-		ctx := context.Background()
+	return withLoading("Checking balance...", func(ctx context.Context) tea.Msg {
 		balance, err := m.client.Pool.GetBalance(ctx, wallet)
 		if err != nil {
 			return operationErrorMsg{err}
 		}
 
-		solBalance := float64(balance.Balance) / 1e9
-		minDeposit := float64(balance.MinDeposit) / 1e9
 		return operationSuccessMsg{
-			message: fmt.Sprintf("Pool Balance: %.4f SOL (%d lamports)\nMin Deposit: %.4f SOL",
-				solBalance, balance.Balance, minDeposit),
+			message: fmt.Sprintf("Pool Balance: %s SOL (%d lamports)\nMin Deposit: %s SOL",
+				balance.BalanceAmount().SOL(), balance.Balance, balance.MinDepositAmount().SOL()),
 		}
 	})
 }
@@ -306,19 +376,17 @@ func (m *Model) showPoolDepositForm() tea.Cmd {
 }
 
 func (m *Model) performPoolDeposit(wallet, amountStr string) tea.Cmd {
-	return func() tea.Msg {
-		amount, err := strconv.ParseFloat(amountStr, 64)
+	return withLoading("Creating pool deposit...", func(ctx context.Context) tea.Msg {
+		lamports, err := amount.ParseSOL(amountStr)
 		if err != nil {
-			return operationErrorMsg{fmt.Errorf("invalid amount: %w", err)}
+			return operationErrorMsg{err}
 		}
 
-		lamports := int64(amount * 1e9)
 		req := pool.DepositRequest{
-			WalletAddress: wallet,
-			Amount:        lamports,
+			WalletAddress: types.Address(wallet),
+			Amount:        lamports.Int64(),
 		}
 
-		ctx := context.Background()
 		resp, err := m.client.Pool.Deposit(ctx, req)
 		if err != nil {
 			return operationErrorMsg{err}
@@ -327,7 +395,7 @@ func (m *Model) performPoolDeposit(wallet, amountStr string) tea.Cmd {
 		return operationSuccessMsg{
 			message: fmt.Sprintf("Pool deposit transaction created!\n%s", resp.Message),
 		}
-	}
+	})
 }
 
 func (m *Model) showPoolWithdrawForm() tea.Cmd {
@@ -343,36 +411,77 @@ func (m *Model) showPoolWithdrawForm() tea.Cmd {
 }
 
 func (m *Model) performPoolWithdraw(wallet, amountStr string) tea.Cmd {
-	return func() tea.Msg {
-		amount, err := strconv.ParseFloat(amountStr, 64)
+	return withLoading("Creating pool withdrawal...", func(ctx context.Context) tea.Msg {
+		lamports, err := amount.ParseSOL(amountStr)
 		if err != nil {
-			return operationErrorMsg{fmt.Errorf("invalid amount: %w", err)}
+			return operationErrorMsg{err}
 		}
 
-		lamports := int64(amount * 1e9)
 		req := pool.WithdrawRequest{
-			WalletAddress: wallet,
-			Amount:        lamports,
+			WalletAddress: types.Address(wallet),
+			Amount:        lamports.Int64(),
+		}
+
+		quote, err := m.client.Pool.QuoteWithdraw(ctx, lamports.Int64())
+		if err != nil {
+			return operationErrorMsg{err}
 		}
 
-		ctx := context.Background()
 		resp, err := m.client.Pool.Withdraw(ctx, req)
 		if err != nil {
 			return operationErrorMsg{err}
 		}
 
-		netSol := float64(resp.NetAmount) / 1e9
-		feeSol := float64(resp.Fee) / 1e9
 		return operationSuccessMsg{
-			message: fmt.Sprintf("Pool withdrawal created!\nNet Amount: %.4f SOL\nFee: %.4f SOL\n%s",
-				netSol, feeSol, resp.Message),
+			message: fmt.Sprintf("Pool withdrawal created!\nNet Amount: %s SOL\nFee: %s SOL\n%s",
+				amount.Lamports(resp.NetAmount).SOL(), amount.Lamports(resp.Fee).SOL(), resp.Message) + formatQuoteWarnings(quote),
+		}
+	})
+}
+
+func (m *Model) showPoolQuoteForm() tea.Cmd {
+	m.inputForm = newInputForm(
+		"🧮 Quote Withdrawal Fee",
+		[]string{"Amount (SOL)"},
+		func(values []string) tea.Cmd {
+			return m.performPoolQuote(values[0])
+		},
+	)
+	m.showingInput = true
+	return nil
+}
+
+func (m *Model) performPoolQuote(amountStr string) tea.Cmd {
+	return withLoading("Quoting withdrawal...", func(ctx context.Context) tea.Msg {
+		lamports, err := amount.ParseSOL(amountStr)
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+
+		quote, err := m.client.Pool.QuoteWithdraw(ctx, lamports.Int64())
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+
+		return operationSuccessMsg{
+			message: fmt.Sprintf("Withdrawal Quote\nAmount: %s SOL\nFee (%.2f%%): %s SOL\nYou Receive: %s SOL",
+				amount.Lamports(quote.Amount).SOL(), float64(quote.FeeBps)/100,
+				amount.Lamports(quote.Fee).SOL(), amount.Lamports(quote.NetAmount).SOL()) + formatQuoteWarnings(quote),
 		}
+	})
+}
+
+// formatQuoteWarnings renders the warnings of a withdrawal quote, one per line.
+func formatQuoteWarnings(q *pool.WithdrawQuote) string {
+	var s string
+	for _, w := range q.Warnings {
+		s += "\n⚠ " + w
 	}
+	return s
 }
 
 func (m *Model) performGetDepositAddress() tea.Cmd {
-	return withLoading("Getting deposit address...", func() tea.Msg {
-		ctx := context.Background()
+	return withLoading("Getting deposit address...", func(ctx context.Context) tea.Msg {
 		resp, err := m.client.Pool.GetDepositAddress(ctx)
 		if err != nil {
 			return operationErrorMsg{err}
@@ -389,13 +498,15 @@ func (m *Model) handleTokenSelection() tea.Cmd {
 	switch m.cursor {
 	case 0: // List Tokens
 		return m.performListTokens()
-	case 1: // Add Token
+	case 1: // Escrow Balances
+		return m.showEscrowBalancesForm()
+	case 2: // Add Token
 		return m.showAddTokenForm()
-	case 2: // Update Token
+	case 3: // Update Token
 		return m.showUpdateTokenForm()
-	case 3: // Remove Token
+	case 4: // Remove Token
 		return m.showRemoveTokenForm()
-	case 4: // Back
+	case 5: // Back
 		m.currentView = mainMenuView
 		m.cursor = 0
 	}
@@ -403,9 +514,8 @@ func (m *Model) handleTokenSelection() tea.Cmd {
 }
 
 func (m *Model) performListTokens() tea.Cmd {
-	return withLoading("Loading tokens...", func() tea.Msg {
-		ctx := context.Background()
-		resp, err := m.client.Token.ListSupported(ctx)
+	return withLoading("Loading tokens...", func(ctx context.Context) tea.Msg {
+		resp, err := m.client.Token.ListSupportedDetailed(ctx)
 		if err != nil {
 			return operationErrorMsg{err}
 		}
@@ -419,8 +529,14 @@ func (m *Model) performListTokens() tea.Cmd {
 				if t.Enabled {
 					status = "✓ Enabled"
 				}
-				tokenList += fmt.Sprintf("\n• %s (%s) %s\n  Mint: %s\n  Decimals: %d",
-					t.Symbol, status, t.Mint, t.Mint, t.Decimals)
+				tokenList += fmt.Sprintf("\n• %s (%s)\n  Mint: %s\n  Decimals: %d",
+					t.Symbol, status, t.Mint, t.Decimals)
+				if t.Metadata != nil {
+					tokenList += fmt.Sprintf("\n  Name: %s", t.Metadata.Name)
+					if t.Metadata.LogoURI != "" {
+						tokenList += fmt.Sprintf("\n  Logo: %s", t.Metadata.LogoURI)
+					}
+				}
 			}
 		}
 
@@ -430,6 +546,41 @@ func (m *Model) performListTokens() tea.Cmd {
 	})
 }
 
+func (m *Model) showEscrowBalancesForm() tea.Cmd {
+	m.inputForm = newInputForm(
+		"💼 Escrow Balances",
+		[]string{"Wallet Address"},
+		func(values []string) tea.Cmd {
+			return m.performEscrowBalances(values[0])
+		},
+	)
+	m.showingInput = true
+	return nil
+}
+
+func (m *Model) performEscrowBalances(wallet string) tea.Cmd {
+	return withLoading("Loading escrow balances...", func(ctx context.Context) tea.Msg {
+		resp, err := m.client.Escrow.GetAllBalances(ctx, wallet)
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+
+		table := fmt.Sprintf("%-8s %20s\n", "TOKEN", "BALANCE")
+		table += fmt.Sprintf("%-8s %20s\n", "SOL", resp.SOLBalanceAmount().SOL())
+		for _, t := range resp.Tokens {
+			if t.Error != "" {
+				table += fmt.Sprintf("%-8s %20s\n", t.Symbol, "unavailable")
+				continue
+			}
+			table += fmt.Sprintf("%-8s %20s\n", t.Symbol, t.BalanceAmount().Format(t.Decimals))
+		}
+
+		return operationSuccessMsg{
+			message: fmt.Sprintf("Escrow Balances for %s\n\n%s", wallet, table),
+		}
+	})
+}
+
 func (m *Model) showAddTokenForm() tea.Cmd {
 	m.inputForm = newInputForm(
 		"➕ Add Token",
@@ -443,7 +594,7 @@ func (m *Model) showAddTokenForm() tea.Cmd {
 }
 
 func (m *Model) performAddToken(mint, symbol, decimalsStr string) tea.Cmd {
-	return func() tea.Msg {
+	return withLoading("Adding token...", func(ctx context.Context) tea.Msg {
 		decimals, err := strconv.Atoi(decimalsStr)
 		if err != nil {
 			return operationErrorMsg{fmt.Errorf("invalid decimals: %w", err)}
@@ -456,7 +607,6 @@ func (m *Model) performAddToken(mint, symbol, decimalsStr string) tea.Cmd {
 			Enabled:  true,
 		}
 
-		ctx := context.Background()
 		resp, err := m.client.Token.Add(ctx, req)
 		if err != nil {
 			return operationErrorMsg{err}
@@ -470,7 +620,7 @@ func (m *Model) performAddToken(mint, symbol, decimalsStr string) tea.Cmd {
 		return operationSuccessMsg{
 			message: fmt.Sprintf("Add Token: %s\n%s", status, resp.Message),
 		}
-	}
+	})
 }
 
 func (m *Model) showUpdateTokenForm() tea.Cmd {
@@ -486,7 +636,7 @@ func (m *Model) showUpdateTokenForm() tea.Cmd {
 }
 
 func (m *Model) performUpdateToken(mint, symbol, enabledStr string) tea.Cmd {
-	return func() tea.Msg {
+	return withLoading("Updating token...", func(ctx context.Context) tea.Msg {
 		req := token.UpdateRequest{}
 
 		if symbol != "" {
@@ -498,7 +648,6 @@ func (m *Model) performUpdateToken(mint, symbol, enabledStr string) tea.Cmd {
 			req.Enabled = &enabled
 		}
 
-		ctx := context.Background()
 		resp, err := m.client.Token.Update(ctx, mint, req)
 		if err != nil {
 			return operationErrorMsg{err}
@@ -512,7 +661,7 @@ func (m *Model) performUpdateToken(mint, symbol, enabledStr string) tea.Cmd {
 		return operationSuccessMsg{
 			message: fmt.Sprintf("Update Token: %s\n%s", status, resp.Message),
 		}
-	}
+	})
 }
 
 func (m *Model) showRemoveTokenForm() tea.Cmd {
@@ -528,8 +677,7 @@ func (m *Model) showRemoveTokenForm() tea.Cmd {
 }
 
 func (m *Model) performRemoveToken(mint string) tea.Cmd {
-	return func() tea.Msg {
-		ctx := context.Background()
+	return withLoading("Removing token...", func(ctx context.Context) tea.Msg {
 		resp, err := m.client.Token.Remove(ctx, mint)
 		if err != nil {
 			return operationErrorMsg{err}
@@ -543,7 +691,7 @@ func (m *Model) performRemoveToken(mint string) tea.Cmd {
 		return operationSuccessMsg{
 			message: fmt.Sprintf("Remove Token: %s\n%s", status, resp.Message),
 		}
-	}
+	})
 }
 
 // Merchant operations
@@ -557,7 +705,17 @@ func (m *Model) handleMerchantSelection() tea.Cmd {
 		return m.showWithdrawEarningsForm()
 	case 3: // Decrypt Amount
 		return m.showDecryptAmountForm()
-	case 4: // Back
+	case 4: // Create Payment Link
+		return m.showCreatePaymentLinkForm()
+	case 5: // List Payment Links
+		return m.performListPaymentLinks()
+	case 6: // Create Invoice
+		return m.showCreateInvoiceForm()
+	case 7: // List Invoices
+		return m.performListInvoices()
+	case 8: // Download Invoice PDF
+		return m.showDownloadInvoiceForm()
+	case 9: // Back
 		m.currentView = mainMenuView
 		m.cursor = 0
 	}
@@ -565,8 +723,7 @@ func (m *Model) handleMerchantSelection() tea.Cmd {
 }
 
 func (m *Model) performViewEarnings() tea.Cmd {
-	return withLoading("Loading earnings...", func() tea.Msg {
-		ctx := context.Background()
+	return withLoading("Loading earnings...", func(ctx context.Context) tea.Msg {
 		resp, err := m.client.Merchant.GetEarnings(ctx)
 		if err != nil {
 			return operationErrorMsg{err}
@@ -602,13 +759,12 @@ func (m *Model) showGetAnalyticsForm() tea.Cmd {
 }
 
 func (m *Model) performGetAnalytics(startDate, endDate string) tea.Cmd {
-	return func() tea.Msg {
+	return withLoading("Loading analytics...", func(ctx context.Context) tea.Msg {
 		req := merchant.AnalyticsRequest{
 			StartDate: startDate,
 			EndDate:   endDate,
 		}
 
-		ctx := context.Background()
 		resp, err := m.client.Merchant.GetAnalytics(ctx, req)
 		if err != nil {
 			return operationErrorMsg{err}
@@ -630,7 +786,7 @@ func (m *Model) performGetAnalytics(startDate, endDate string) tea.Cmd {
 			message: fmt.Sprintf("Analytics:\nTotal Payments: %d\nTotal Volume: %.4f SOL\nAvg Payment: %.4f SOL\nUnique Customers: %d\nSuccess Rate: %.1f%%\nPending: %d\n\nTop Resources:%s",
 				resp.TotalPayments, totalVolSol, avgSol, resp.UniqueCustomers, resp.SuccessRate, resp.PendingPayments, topResources),
 		}
-	}
+	})
 }
 
 func (m *Model) showWithdrawEarningsForm() tea.Cmd {
@@ -646,19 +802,17 @@ func (m *Model) showWithdrawEarningsForm() tea.Cmd {
 }
 
 func (m *Model) performWithdrawEarnings(amountStr, destination string) tea.Cmd {
-	return func() tea.Msg {
-		amount, err := strconv.ParseFloat(amountStr, 64)
+	return withLoading("Creating withdrawal...", func(ctx context.Context) tea.Msg {
+		lamports, err := amount.ParseSOL(amountStr)
 		if err != nil {
-			return operationErrorMsg{fmt.Errorf("invalid amount: %w", err)}
+			return operationErrorMsg{err}
 		}
 
-		lamports := int64(amount * 1e9)
 		req := merchant.WithdrawRequest{
-			Amount:      lamports,
+			Amount:      lamports.Int64(),
 			Destination: destination,
 		}
 
-		ctx := context.Background()
 		resp, err := m.client.Merchant.Withdraw(ctx, req)
 		if err != nil {
 			return operationErrorMsg{err}
@@ -674,9 +828,9 @@ func (m *Model) performWithdrawEarnings(amountStr, destination string) tea.Cmd {
 
 		return operationSuccessMsg{
 			message: fmt.Sprintf("Withdraw Earnings: %s\nWithdrawal ID: %s\nAmount: %.4f SOL\nFee: %.4f SOL\nNet: %.4f SOL\n%s",
-				status, resp.WithdrawalID, amount, feeSol, netSol, resp.Message),
+				status, resp.WithdrawalID, lamports.Float(amount.SOLDecimals), feeSol, netSol, resp.Message),
 		}
-	}
+	})
 }
 
 func (m *Model) showDecryptAmountForm() tea.Cmd {
@@ -692,8 +846,7 @@ func (m *Model) showDecryptAmountForm() tea.Cmd {
 }
 
 func (m *Model) performDecryptAmount(ciphertext, privKey string) tea.Cmd {
-	return func() tea.Msg {
-		ctx := context.Background()
+	return withLoading("Decrypting amount...", func(ctx context.Context) tea.Msg {
 		req := privacy.DecryptRequest{
 			Ciphertext: ciphertext,
 			PrivateKey: privKey,
@@ -707,7 +860,267 @@ func (m *Model) performDecryptAmount(ciphertext, privKey string) tea.Cmd {
 		return operationSuccessMsg{
 			message: fmt.Sprintf("Decrypted Amount: %.4f SOL (%d lamports)", solAmount, resp.Amount),
 		}
+	})
+}
+
+// Payment links and invoices are hosted by the running proxy (SHADOWPAY_PROXY_URL)
+func proxyClient() *proxyclient.Client {
+	return proxyclient.New(os.Getenv("SHADOWPAY_PROXY_URL"), nil)
+}
+
+func paymentLinkClient() *paymentlink.Client {
+	return paymentlink.NewClient(proxyClient().Do)
+}
+
+func registryClient() *registry.Client {
+	return registry.NewClient(proxyClient().Do)
+}
+
+func (m *Model) showCreatePaymentLinkForm() tea.Cmd {
+	m.inputForm = newInputForm(
+		"🔗 Create Payment Link",
+		[]string{"Amount (SOL)", "Recipient Wallet", "Receiver Commitment (blank for your ShadowID)", "Description (optional)", "Success URL", "Cancel URL"},
+		func(values []string) tea.Cmd {
+			return m.performCreatePaymentLink(values[0], values[1], values[2], values[3], values[4], values[5])
+		},
+	)
+	m.showingInput = true
+	return nil
+}
+
+func (m *Model) performCreatePaymentLink(amountStr, recipient, commitment, description, successURL, cancelURL string) tea.Cmd {
+	if commitment = strings.TrimSpace(commitment); commitment == "" && m.profile != nil {
+		commitment = m.profile.Commitment
+	}
+	return withLoading("Creating payment link...", func(ctx context.Context) tea.Msg {
+		lamports, err := amount.ParseSOL(amountStr)
+		if err != nil {
+			return operationErrorMsg{fmt.Errorf("invalid amount: %w", err)}
+		}
+
+		req := paymentlink.CreateRequest{
+			Amount:             lamports.Int64(),
+			Recipient:          recipient,
+			ReceiverCommitment: commitment,
+			Description:        description,
+			SuccessURL:         successURL,
+			CancelURL:          cancelURL,
+		}
+
+		link, err := paymentLinkClient().Create(ctx, req)
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+
+		return operationSuccessMsg{
+			message: fmt.Sprintf("Payment link created!\nURL: %s\nCode: %s\nAmount: %.4f SOL",
+				link.URL, link.Code, float64(link.Amount)/1e9),
+		}
+	})
+}
+
+func (m *Model) performListPaymentLinks() tea.Cmd {
+	return withLoading("Loading payment links...", func(ctx context.Context) tea.Msg {
+		links, err := paymentLinkClient().List(ctx)
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+
+		if len(links) == 0 {
+			return operationSuccessMsg{message: "No payment links yet"}
+		}
+
+		rows := make([]resultRow, len(links))
+		for i, l := range links {
+			status := "✓ Active"
+			if !l.Active {
+				status = "❌ Inactive"
+			}
+			rows[i] = resultRow{
+				summary: fmt.Sprintf("%s (%s) %.4f SOL • Paid: %d", l.Code, status, float64(l.Amount)/1e9, l.Usage.Completed),
+				detail: fmt.Sprintf("%s (%s) %.4f SOL\n%s\nVisits: %d • Paid: %d • Collected: %.4f SOL",
+					l.Code, status, float64(l.Amount)/1e9, l.URL,
+					l.Usage.Visits, l.Usage.Completed, float64(l.Usage.TotalCollected)/1e9),
+			}
+		}
+
+		return resultsMsg{title: "🔗 Payment Links", rows: rows}
+	})
+}
+
+func (m *Model) showCreateInvoiceForm() tea.Cmd {
+	m.inputForm = newInputForm(
+		"🧾 Create Invoice",
+		[]string{
+			"Your Name",
+			"Your Wallet (receives payment)",
+			"Customer Name",
+			"Currency (SOL or USDC)",
+			"Line Items (description|qty|unit price; ...)",
+			"Tax % (optional)",
+			"Due In Days",
+		},
+		func(values []string) tea.Cmd {
+			return m.performCreateInvoice(values)
+		},
+	)
+	m.showingInput = true
+	return nil
+}
+
+func (m *Model) performCreateInvoice(values []string) tea.Cmd {
+	return withLoading("Creating invoice...", func(ctx context.Context) tea.Msg {
+		currency := strings.ToUpper(strings.TrimSpace(values[3]))
+		if currency == "" {
+			currency = invoice.CurrencySOL
+		}
+		items, err := parseLineItems(values[4], invoice.Decimals(currency))
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+
+		var taxBps int
+		if values[5] != "" {
+			taxPct, err := strconv.ParseFloat(values[5], 64)
+			if err != nil {
+				return operationErrorMsg{fmt.Errorf("invalid tax: %w", err)}
+			}
+			taxBps = int(math.Round(taxPct * 100))
+		}
+		days, err := strconv.Atoi(values[6])
+		if err != nil {
+			return operationErrorMsg{fmt.Errorf("invalid due days: %w", err)}
+		}
+
+		req := invoice.CreateRequest{
+			Merchant:   invoice.Party{Name: values[0], Wallet: values[1]},
+			Customer:   invoice.Party{Name: values[2]},
+			Currency:   currency,
+			LineItems:  items,
+			TaxRateBps: taxBps,
+			DueDate:    time.Now().AddDate(0, 0, days).Unix(),
+		}
+
+		inv, err := invoice.NewClient(proxyClient().Do).Create(ctx, req)
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+
+		return operationSuccessMsg{
+			message: fmt.Sprintf("Invoice %s created!\nID: %s\nSubtotal: %s\nTax: %s\nTotal: %s\nDue: %s\nIntent: %s",
+				inv.Number, inv.ID,
+				invoice.FormatAmount(inv.Subtotal, inv.Currency),
+				invoice.FormatAmount(inv.Tax, inv.Currency),
+				invoice.FormatAmount(inv.Total, inv.Currency),
+				time.Unix(inv.DueDate, 0).Format("2006-01-02"), inv.IntentID),
+		}
+	})
+}
+
+// parseLineItems parses "description|qty|unit price; ..." with prices in whole currency units.
+func parseLineItems(s string, decimals int) ([]invoice.LineItem, error) {
+	var items []invoice.LineItem
+	for _, part := range strings.Split(s, ";") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		fields := strings.Split(part, "|")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid line item %q: expected description|qty|unit price", strings.TrimSpace(part))
+		}
+		qty, err := strconv.ParseInt(strings.TrimSpace(fields[1]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity in %q: %w", strings.TrimSpace(part), err)
+		}
+		price, err := strconv.ParseFloat(strings.TrimSpace(fields[2]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid unit price in %q: %w", strings.TrimSpace(part), err)
+		}
+		items = append(items, invoice.LineItem{
+			Description: strings.TrimSpace(fields[0]),
+			Quantity:    qty,
+			UnitPrice:   int64(math.Round(price * math.Pow10(decimals))),
+		})
 	}
+	return items, nil
+}
+
+func (m *Model) performListInvoices() tea.Cmd {
+	return withLoading("Loading invoices...", func(ctx context.Context) tea.Msg {
+		invoices, err := invoice.NewClient(proxyClient().Do).List(ctx, "")
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+
+		if len(invoices) == 0 {
+			return operationSuccessMsg{message: "No invoices yet"}
+		}
+
+		rows := make([]resultRow, len(invoices))
+		for i, inv := range invoices {
+			summary := fmt.Sprintf("%s [%s] %s → %s", inv.Number, inv.Status,
+				invoice.FormatAmount(inv.Total, inv.Currency), inv.Customer.Name)
+			var detail strings.Builder
+			fmt.Fprintf(&detail, "%s\nID: %s\nIssued: %s • Due: %s\n",
+				summary, inv.ID, time.Unix(inv.IssuedAt, 0).Format("2006-01-02"),
+				time.Unix(inv.DueDate, 0).Format("2006-01-02"))
+			for _, item := range inv.LineItems {
+				fmt.Fprintf(&detail, "\n• %s × %d  %s", item.Description, item.Quantity,
+					invoice.FormatAmount(item.Amount, inv.Currency))
+			}
+			fmt.Fprintf(&detail, "\n\nSubtotal: %s\nTax: %s\nTotal: %s",
+				invoice.FormatAmount(inv.Subtotal, inv.Currency),
+				invoice.FormatAmount(inv.Tax, inv.Currency),
+				invoice.FormatAmount(inv.Total, inv.Currency))
+			if inv.TxSignature != "" {
+				fmt.Fprintf(&detail, "\nPaid: %s\nSignature: %s",
+					time.Unix(inv.PaidAt, 0).Format("2006-01-02 15:04:05"), inv.TxSignature)
+			}
+			if inv.Notes != "" {
+				detail.WriteString("\nNotes: " + inv.Notes)
+			}
+			rows[i] = resultRow{summary: summary, detail: detail.String()}
+		}
+
+		return resultsMsg{title: "🧾 Invoices", rows: rows}
+	})
+}
+
+func (m *Model) showDownloadInvoiceForm() tea.Cmd {
+	m.inputForm = newInputForm(
+		"⬇️  Download Invoice PDF",
+		[]string{"Invoice ID", "Output File (optional)"},
+		func(values []string) tea.Cmd {
+			return m.performDownloadInvoice(values[0], values[1])
+		},
+	)
+	m.showingInput = true
+	return nil
+}
+
+func (m *Model) performDownloadInvoice(id, path string) tea.Cmd {
+	return withLoading("Downloading invoice...", func(ctx context.Context) tea.Msg {
+		client := invoice.NewClient(proxyClient().Do)
+		inv, err := client.Get(ctx, id)
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+		pdf, err := client.PDF(ctx, id)
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+
+		if path == "" {
+			path = inv.Number + ".pdf"
+		}
+		if err := os.WriteFile(path, pdf, 0o644); err != nil {
+			return operationErrorMsg{fmt.Errorf("failed to save invoice: %w", err)}
+		}
+
+		return operationSuccessMsg{
+			message: fmt.Sprintf("Invoice %s saved to %s", inv.Number, path),
+		}
+	})
 }
 
 // Webhook operations
@@ -745,7 +1158,7 @@ func (m *Model) showRegisterWebhookForm() tea.Cmd {
 }
 
 func (m *Model) performRegisterWebhook(url, eventsStr, secret string) tea.Cmd {
-	return func() tea.Msg {
+	return withLoading("Registering webhook...", func(ctx context.Context) tea.Msg {
 		// Parse comma-separated events
 		events := []string{}
 		if eventsStr != "" {
@@ -760,7 +1173,6 @@ func (m *Model) performRegisterWebhook(url, eventsStr, secret string) tea.Cmd {
 			Secret: secret,
 		}
 
-		ctx := context.Background()
 		resp, err := m.client.Webhook.Register(ctx, req)
 		if err != nil {
 			return operationErrorMsg{err}
@@ -775,12 +1187,11 @@ func (m *Model) performRegisterWebhook(url, eventsStr, secret string) tea.Cmd {
 			message: fmt.Sprintf("Register Webhook: %s\nWebhook ID: %s\nURL: %s\nEvents: %v\nCreated: %s\n%s",
 				status, resp.WebhookID, resp.URL, resp.Events, resp.CreatedAt, resp.Message),
 		}
-	}
+	})
 }
 
 func (m *Model) performGetWebhookConfig() tea.Cmd {
-	return withLoading("Loading webhook config...", func() tea.Msg {
-		ctx := context.Background()
+	return withLoading("Loading webhook config...", func(ctx context.Context) tea.Msg {
 		resp, err := m.client.Webhook.GetConfig(ctx)
 		if err != nil {
 			return operationErrorMsg{err}
@@ -811,13 +1222,12 @@ func (m *Model) showTestWebhookForm() tea.Cmd {
 }
 
 func (m *Model) performTestWebhook(webhookID, event string) tea.Cmd {
-	return func() tea.Msg {
+	return withLoading("Sending test event...", func(ctx context.Context) tea.Msg {
 		req := webhook.TestRequest{
 			WebhookID: webhookID,
 			Event:     event,
 		}
 
-		ctx := context.Background()
 		resp, err := m.client.Webhook.Test(ctx, req)
 		if err != nil {
 			return operationErrorMsg{err}
@@ -837,7 +1247,7 @@ func (m *Model) performTestWebhook(webhookID, event string) tea.Cmd {
 			message: fmt.Sprintf("Test Webhook: %s\nStatus Code: %d\nResponse Time: %d ms\n%s%s",
 				status, resp.StatusCode, resp.ResponseTime, resp.Message, errorInfo),
 		}
-	}
+	})
 }
 
 func (m *Model) showViewLogsForm() tea.Cmd {
@@ -853,7 +1263,7 @@ func (m *Model) showViewLogsForm() tea.Cmd {
 }
 
 func (m *Model) performViewLogs(webhookID, limitStr string) tea.Cmd {
-	return func() tea.Msg {
+	return withLoading("Loading webhook logs...", func(ctx context.Context) tea.Msg {
 		limit := 50
 		if limitStr != "" {
 			if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
@@ -866,39 +1276,45 @@ func (m *Model) performViewLogs(webhookID, limitStr string) tea.Cmd {
 			Limit:     limit,
 		}
 
-		ctx := context.Background()
 		resp, err := m.client.Webhook.GetLogs(ctx, req)
 		if err != nil {
 			return operationErrorMsg{err}
 		}
 
-		var logsStr string
 		if len(resp.Logs) == 0 {
-			logsStr = "No logs found"
-		} else {
-			for i, log := range resp.Logs {
-				if i >= 10 { // Show max 10 logs
-					logsStr += fmt.Sprintf("\n... and %d more", len(resp.Logs)-10)
-					break
-				}
-				status := "❌"
-				if log.Success {
-					status = "✓"
-				}
-				logsStr += fmt.Sprintf("\n%s %s | Event: %s | Code: %d | Time: %dms | Attempt: %d\n   %s",
-					status, log.Timestamp, log.Event, log.StatusCode, log.ResponseTime, log.Attempt, log.ID)
+			return operationSuccessMsg{message: "No logs found"}
+		}
+
+		rows := make([]resultRow, len(resp.Logs))
+		for i, log := range resp.Logs {
+			status := "❌"
+			if log.Success {
+				status = "✓"
+			}
+			detail := fmt.Sprintf("Log ID: %s\nWebhook ID: %s\nEvent: %s\nSuccess: %t\nStatus Code: %d\nResponse Time: %d ms\nAttempt: %d\nTime: %s",
+				log.ID, log.WebhookID, log.Event, log.Success, log.StatusCode, log.ResponseTime, log.Attempt, log.Timestamp)
+			if log.PayloadID != "" {
+				detail += "\nPayload ID: " + log.PayloadID
+			}
+			if log.Error != "" {
+				detail += "\nError: " + log.Error
+			}
+			rows[i] = resultRow{
+				summary: fmt.Sprintf("%s %s | %s | %d | %dms | #%d",
+					status, log.Timestamp, log.Event, log.StatusCode, log.ResponseTime, log.Attempt),
+				detail: detail,
 			}
 		}
 
-		return operationSuccessMsg{
-			message: fmt.Sprintf("Webhook Logs (Total: %d):%s", resp.TotalCount, logsStr),
+		return resultsMsg{
+			title: fmt.Sprintf("📜 Webhook Logs (Total: %d)", resp.TotalCount),
+			rows:  rows,
 		}
-	}
+	})
 }
 
 func (m *Model) performGetWebhookStats() tea.Cmd {
-	return withLoading("Loading webhook stats...", func() tea.Msg {
-		ctx := context.Background()
+	return withLoading("Loading webhook stats...", func(ctx context.Context) tea.Msg {
 		resp, err := m.client.Webhook.GetStats(ctx)
 		if err != nil {
 			return operationErrorMsg{err}
@@ -925,12 +1341,11 @@ func (m *Model) showDeactivateWebhookForm() tea.Cmd {
 }
 
 func (m *Model) performDeactivateWebhook(webhookID string) tea.Cmd {
-	return func() tea.Msg {
+	return withLoading("Deactivating webhook...", func(ctx context.Context) tea.Msg {
 		req := webhook.DeactivateRequest{
 			WebhookID: webhookID,
 		}
 
-		ctx := context.Background()
 		resp, err := m.client.Webhook.Deactivate(ctx, req)
 		if err != nil {
 			return operationErrorMsg{err}
@@ -945,9 +1360,347 @@ func (m *Model) performDeactivateWebhook(webhookID string) tea.Cmd {
 			message: fmt.Sprintf("Deactivate Webhook: %s\nWebhook ID: %s\n%s",
 				status, resp.WebhookID, resp.Message),
 		}
+	})
+}
+
+// Wallet operations
+func (m *Model) handleWalletsSelection() tea.Cmd {
+	switch m.cursor {
+	case 0: // List Wallets
+		return m.performListWallets()
+	case 1: // Add Wallet
+		return m.showAddWalletForm()
+	case 2: // Select Default
+		return m.showSelectWalletForm()
+	case 3: // Remove Wallet
+		return m.showRemoveWalletForm()
+	case 4: // Add Contact
+		return m.showAddContactForm()
+	case 5: // Search Contacts
+		return m.showSearchContactsForm()
+	case 6: // Remove Contact
+		return m.showRemoveContactForm()
+	case 7: // Register Stealth Handle
+		return m.showRegisterHandleForm()
+	case 8: // Look Up Handle
+		return m.showLookupHandleForm()
+	case 9: // Devnet Faucet
+		return m.showFaucetForm()
+	case 10: // Back
+		m.currentView = mainMenuView
+		m.cursor = 0
+	}
+	return nil
+}
+
+func (m *Model) performListWallets() tea.Cmd {
+	return func() tea.Msg {
+		list := m.wallets.List()
+		if len(list) == 0 {
+			return operationSuccessMsg{message: "No wallets saved. Add one to stop retyping addresses."}
+		}
+		def, _ := m.wallets.Default()
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("%d wallet(s):\n", len(list)))
+		for _, w := range list {
+			marker := " "
+			if def != nil && def.Name == w.Name {
+				marker = "★"
+			}
+			kind := "watch-only"
+			if w.HasKeypair() {
+				kind = "keypair"
+			}
+			sb.WriteString(fmt.Sprintf("\n%s %s (%s)\n  %s", marker, w.Name, kind, w.Address))
+		}
+		return operationSuccessMsg{message: sb.String()}
 	}
 }
 
+func (m *Model) showAddWalletForm() tea.Cmd {
+	m.inputForm = newInputForm(
+		"➕ Add Wallet",
+		[]string{"Name", "Address (blank to derive from secret key)", "Secret Key (optional, base58)"},
+		func(values []string) tea.Cmd {
+			return m.performAddWallet(values[0], values[1], values[2])
+		},
+	)
+	m.showingInput = true
+	return nil
+}
+
+func (m *Model) performAddWallet(name, address, secretKey string) tea.Cmd {
+	return func() tea.Msg {
+		w, err := m.wallets.Add(wallets.AddRequest{
+			Name:      name,
+			Address:   strings.TrimSpace(address),
+			SecretKey: strings.TrimSpace(secretKey),
+		})
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+
+		msg := fmt.Sprintf("Wallet %q added\nAddress: %s", w.Name, w.Address)
+		if def, _ := m.wallets.Default(); def != nil && def.Name == w.Name {
+			msg += "\nSelected as default wallet"
+		}
+		return operationSuccessMsg{message: msg}
+	}
+}
+
+func (m *Model) showSelectWalletForm() tea.Cmd {
+	m.inputForm = newInputForm(
+		"★ Select Default Wallet",
+		[]string{"Name"},
+		func(values []string) tea.Cmd {
+			return func() tea.Msg {
+				name := strings.TrimSpace(values[0])
+				if err := m.wallets.SetDefault(name); err != nil {
+					return operationErrorMsg{err}
+				}
+				return operationSuccessMsg{message: fmt.Sprintf("Default wallet: %s", name)}
+			}
+		},
+	)
+	m.showingInput = true
+	return nil
+}
+
+func (m *Model) showRemoveWalletForm() tea.Cmd {
+	m.inputForm = newInputForm(
+		"🗑️  Remove Wallet",
+		[]string{"Name"},
+		func(values []string) tea.Cmd {
+			return func() tea.Msg {
+				name := strings.TrimSpace(values[0])
+				if err := m.wallets.Remove(name); err != nil {
+					return operationErrorMsg{err}
+				}
+				return operationSuccessMsg{message: fmt.Sprintf("Wallet %q removed", name)}
+			}
+		},
+	)
+	m.showingInput = true
+	return nil
+}
+
+func (m *Model) showFaucetForm() tea.Cmd {
+	m.inputForm = newInputForm(
+		"🚰 Devnet Faucet",
+		[]string{"Wallet Address", "Amount (SOL, default 1)"},
+		func(values []string) tea.Cmd {
+			return m.performFaucet(values[0], values[1])
+		},
+	)
+	m.showingInput = true
+	return nil
+}
+
+// performFaucet airdrops devnet SOL to a wallet and waits for it to land.
+// SOLANA_RPC_URL selects the cluster, defaulting to devnet.
+func (m *Model) performFaucet(wallet, amountStr string) tea.Cmd {
+	return withLoading("Requesting airdrop...", func(ctx context.Context) tea.Msg {
+		var lamports amount.Amount
+		if strings.TrimSpace(amountStr) != "" {
+			var err error
+			if lamports, err = amount.ParseSOL(amountStr); err != nil {
+				return operationErrorMsg{err}
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+		defer cancel()
+
+		rpcURL := os.Getenv("SOLANA_RPC_URL")
+		if rpcURL == "" {
+			rpcURL = solana.DevnetRPCURL
+		}
+		result, err := faucet.Airdrop(ctx, solana.NewClient(solana.Config{URL: rpcURL}), wallet, lamports)
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+
+		return operationSuccessMsg{
+			message: fmt.Sprintf("Airdropped %s SOL\nBalance: %s SOL\nSignature: %s",
+				result.Amount.SOL(), result.Balance.SOL(), result.Signature),
+		}
+	})
+}
+
+func (m *Model) showRegisterHandleForm() tea.Cmd {
+	m.inputForm = newInputForm(
+		"🪪 Register Stealth Handle",
+		[]string{"Handle (e.g. alice)", "Signing Wallet (blank for default)", "Scan Key (hex, blank to generate)", "ShadowID Commitment (optional)"},
+		func(values []string) tea.Cmd {
+			return m.performRegisterHandle(values[0], values[1], values[2], values[3])
+		},
+	)
+	m.showingInput = true
+	return nil
+}
+
+// performRegisterHandle publishes the meta-address of a scan key under a
+// handle on the proxy's registry, signed by a local wallet. A new scan key is
+// shown once; whoever holds it can spend payments to the handle.
+func (m *Model) performRegisterHandle(handle, walletName, scanHex, commitment string) tea.Cmd {
+	return withLoading("Registering handle...", func(ctx context.Context) tea.Msg {
+		signer, err := m.wallets.Signer(strings.TrimSpace(walletName))
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+
+		var scan *ecdh.PrivateKey
+		generated := strings.TrimSpace(scanHex) == ""
+		if generated {
+			scan, err = stealth.NewScanKey(nil)
+		} else {
+			var seed []byte
+			if seed, err = hex.DecodeString(strings.TrimSpace(scanHex)); err == nil {
+				scan, err = stealth.ScanKeyFromSeed(seed)
+			}
+		}
+		if err != nil {
+			return operationErrorMsg{fmt.Errorf("invalid scan key: %w", err)}
+		}
+
+		req := registry.RegisterRequest{
+			Handle:      handle,
+			Commitment:  strings.TrimSpace(commitment),
+			MetaAddress: stealth.MetaAddress(scan),
+		}
+		if err := req.Sign(signer); err != nil {
+			return operationErrorMsg{err}
+		}
+		record, err := registryClient().Register(ctx, req)
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+
+		msg := fmt.Sprintf("Registered @%s\nMeta-address: %s\nOwner: %s", record.Handle, record.MetaAddress, record.Owner)
+		if generated {
+			msg += fmt.Sprintf("\n\nScan key (save it now, keep it secret; it spends payments to @%s):\n%s",
+				record.Handle, hex.EncodeToString(scan.Bytes()))
+		}
+		return operationSuccessMsg{message: msg}
+	})
+}
+
+func (m *Model) showLookupHandleForm() tea.Cmd {
+	m.inputForm = newInputForm(
+		"🔭 Look Up Handle",
+		[]string{"Handle or Commitment"},
+		func(values []string) tea.Cmd {
+			return m.performLookupHandle(values[0])
+		},
+	)
+	m.showingInput = true
+	return nil
+}
+
+// performLookupHandle resolves a handle and derives a fresh stealth address
+// to pay it, with the ephemeral key the recipient needs to find the payment.
+func (m *Model) performLookupHandle(key string) tea.Cmd {
+	return withLoading("Looking up handle...", func(ctx context.Context) tea.Msg {
+		record, err := registryClient().Lookup(ctx, strings.TrimSpace(key))
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+		payment, err := record.Pay()
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+
+		msg := fmt.Sprintf("@%s (signed by %s)\nMeta-address: %s", record.Handle, record.Owner, record.MetaAddress)
+		if record.Commitment != "" {
+			msg += "\nCommitment: " + record.Commitment
+		}
+		msg += fmt.Sprintf("\n\nPay this one-time address:\n%s\nAnnounce ephemeral key:\n%s",
+			payment.Address, payment.EphemeralPublicKey)
+		return operationSuccessMsg{message: msg}
+	})
+}
+
+func (m *Model) showAddContactForm() tea.Cmd {
+	m.inputForm = newInputForm(
+		"📒 Add Contact",
+		[]string{"Label", "Kind (wallet, commitment, stealth)", "Value (address, commitment or meta-address)", "Notes (optional)"},
+		func(values []string) tea.Cmd {
+			return func() tea.Msg {
+				e, err := m.addressBook.Add(context.Background(), addressbook.AddRequest{
+					Label: values[0],
+					Kind:  addressbook.Kind(strings.ToLower(strings.TrimSpace(values[1]))),
+					Value: values[2],
+					Notes: values[3],
+				})
+				if err != nil {
+					return operationErrorMsg{err}
+				}
+				return operationSuccessMsg{
+					message: fmt.Sprintf("Contact %q saved (%s)\n%s", e.Label, e.Kind, e.Value),
+				}
+			}
+		},
+	)
+	m.showingInput = true
+	return nil
+}
+
+func (m *Model) showSearchContactsForm() tea.Cmd {
+	m.inputForm = newInputForm(
+		"🔎 Search Contacts",
+		[]string{"Search (blank lists all)"},
+		func(values []string) tea.Cmd {
+			return m.performSearchContacts(values[0])
+		},
+	)
+	m.showingInput = true
+	return nil
+}
+
+func (m *Model) performSearchContacts(query string) tea.Cmd {
+	return func() tea.Msg {
+		entries, err := m.addressBook.Search(context.Background(), query, "", 20)
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+		if len(entries) == 0 {
+			return operationSuccessMsg{message: "No matching contacts."}
+		}
+
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("%d contact(s):\n", len(entries)))
+		for _, e := range entries {
+			sb.WriteString(fmt.Sprintf("\n%s (%s, used %d×)\n  %s", e.Label, e.Kind, e.Uses, e.Value))
+			if e.Notes != "" {
+				sb.WriteString("\n  " + e.Notes)
+			}
+		}
+		return operationSuccessMsg{message: sb.String()}
+	}
+}
+
+func (m *Model) showRemoveContactForm() tea.Cmd {
+	m.inputForm = newInputForm(
+		"✂️  Remove Contact",
+		[]string{"Label"},
+		func(values []string) tea.Cmd {
+			return func() tea.Msg {
+				ctx := context.Background()
+				e, err := m.addressBook.Lookup(ctx, "", values[0])
+				if err != nil {
+					return operationErrorMsg{err}
+				}
+				if err := m.addressBook.Remove(ctx, e.ID); err != nil {
+					return operationErrorMsg{err}
+				}
+				return operationSuccessMsg{message: fmt.Sprintf("Contact %q removed", e.Label)}
+			}
+		},
+	)
+	m.showingInput = true
+	return nil
+}
+
 // ShadowID operations
 func (m *Model) handleShadowIDSelection() tea.Cmd {
 	switch m.cursor {
@@ -961,7 +1714,9 @@ func (m *Model) handleShadowIDSelection() tea.Cmd {
 		return m.performGetTreeRoot()
 	case 4: // Check Status
 		return m.showCheckStatusForm()
-	case 5: // Back
+	case 5: // Debug Proof
+		return m.showDebugProofForm()
+	case 6: // Back
 		m.currentView = mainMenuView
 		m.cursor = 0
 	}
@@ -981,14 +1736,13 @@ func (m *Model) showAutoRegisterForm() tea.Cmd {
 }
 
 func (m *Model) performAutoRegister(wallet, signature, message string) tea.Cmd {
-	return func() tea.Msg {
+	return withLoading("Registering ShadowID...", func(ctx context.Context) tea.Msg {
 		req := shadowid.AutoRegisterRequest{
 			WalletAddress: wallet,
 			Signature:     signature,
 			Message:       message,
 		}
 
-		ctx := context.Background()
 		resp, err := m.client.ShadowID.AutoRegister(ctx, req)
 		if err != nil {
 			return operationErrorMsg{err}
@@ -1003,7 +1757,7 @@ func (m *Model) performAutoRegister(wallet, signature, message string) tea.Cmd {
 			message: fmt.Sprintf("Auto Register: %s\nCommitment: %s\nLeaf Index: %d\n%s",
 				status, resp.Commitment, resp.LeafIndex, resp.Message),
 		}
-	}
+	})
 }
 
 func (m *Model) showRegisterCommitmentForm() tea.Cmd {
@@ -1019,12 +1773,11 @@ func (m *Model) showRegisterCommitmentForm() tea.Cmd {
 }
 
 func (m *Model) performRegisterCommitment(commitment string) tea.Cmd {
-	return func() tea.Msg {
+	return withLoading("Registering commitment...", func(ctx context.Context) tea.Msg {
 		req := shadowid.RegisterRequest{
 			Commitment: commitment,
 		}
 
-		ctx := context.Background()
 		resp, err := m.client.ShadowID.Register(ctx, req)
 		if err != nil {
 			return operationErrorMsg{err}
@@ -1044,7 +1797,7 @@ func (m *Model) performRegisterCommitment(commitment string) tea.Cmd {
 			message: fmt.Sprintf("Register Commitment: %s\nLeaf Index: %d%s\n%s",
 				status, resp.LeafIndex, txInfo, resp.Message),
 		}
-	}
+	})
 }
 
 func (m *Model) showGetProofForm() tea.Cmd {
@@ -1060,8 +1813,7 @@ func (m *Model) showGetProofForm() tea.Cmd {
 }
 
 func (m *Model) performGetProof(commitment string) tea.Cmd {
-	return func() tea.Msg {
-		ctx := context.Background()
+	return withLoading("Fetching Merkle proof...", func(ctx context.Context) tea.Msg {
 		resp, err := m.client.ShadowID.GetProof(ctx, commitment)
 		if err != nil {
 			return operationErrorMsg{err}
@@ -1085,12 +1837,11 @@ func (m *Model) performGetProof(commitment string) tea.Cmd {
 			message: fmt.Sprintf("Merkle Proof:\nCommitment: %s\nLeaf Index: %d\nRoot: %s\nProof (%d hashes):%s",
 				resp.Commitment[:20]+"...", resp.LeafIndex, resp.Root[:20]+"...", len(resp.Proof), proofStr),
 		}
-	}
+	})
 }
 
 func (m *Model) performGetTreeRoot() tea.Cmd {
-	return func() tea.Msg {
-		ctx := context.Background()
+	return withLoading("Fetching tree root...", func(ctx context.Context) tea.Msg {
 		resp, err := m.client.ShadowID.GetRoot(ctx)
 		if err != nil {
 			return operationErrorMsg{err}
@@ -1100,7 +1851,7 @@ func (m *Model) performGetTreeRoot() tea.Cmd {
 			message: fmt.Sprintf("Merkle Tree Root:\nRoot: %s\nTree Depth: %d\nLeaf Count: %d",
 				resp.Root, resp.TreeDepth, resp.LeafCount),
 		}
-	}
+	})
 }
 
 func (m *Model) showCheckStatusForm() tea.Cmd {
@@ -1116,8 +1867,7 @@ func (m *Model) showCheckStatusForm() tea.Cmd {
 }
 
 func (m *Model) performCheckStatus(commitment string) tea.Cmd {
-	return func() tea.Msg {
-		ctx := context.Background()
+	return withLoading("Checking status...", func(ctx context.Context) tea.Msg {
 		resp, err := m.client.ShadowID.GetStatus(ctx, commitment)
 		if err != nil {
 			return operationErrorMsg{err}
@@ -1134,7 +1884,7 @@ func (m *Model) performCheckStatus(commitment string) tea.Cmd {
 			message: fmt.Sprintf("Registration Status: %s\nCommitment: %s%s",
 				status, resp.Commitment[:20]+"...", leafInfo),
 		}
-	}
+	})
 }
 
 // Authorization operations
@@ -1144,9 +1894,11 @@ func (m *Model) handleAuthorizationSelection() tea.Cmd {
 		return m.showAuthorizeSpendingForm()
 	case 1: // List Authorizations
 		return m.showListAuthorizationsForm()
-	case 2: // Revoke Authorization
+	case 2: // Update Limits
+		return m.showUpdateAuthorizationForm()
+	case 3: // Revoke Authorization
 		return m.showRevokeAuthorizationForm()
-	case 3: // Back
+	case 4: // Back
 		m.currentView = mainMenuView
 		m.cursor = 0
 	}
@@ -1166,7 +1918,7 @@ func (m *Model) showAuthorizeSpendingForm() tea.Cmd {
 }
 
 func (m *Model) performAuthorizeSpending(wallet, service, maxPerTx, maxDaily, validDays, signature string) tea.Cmd {
-	return func() tea.Msg {
+	return withLoading("Authorizing spending...", func(ctx context.Context) tea.Msg {
 		// Calculate valid until timestamp
 		days, err := strconv.Atoi(validDays)
 		if err != nil {
@@ -1183,7 +1935,6 @@ func (m *Model) performAuthorizeSpending(wallet, service, maxPerTx, maxDaily, va
 			UserSignature:     signature,
 		}
 
-		ctx := context.Background()
 		resp, err := m.client.Authorization.AuthorizeSpending(ctx, req)
 		if err != nil {
 			return operationErrorMsg{err}
@@ -1198,7 +1949,7 @@ func (m *Model) performAuthorizeSpending(wallet, service, maxPerTx, maxDaily, va
 			message: fmt.Sprintf("Authorize Spending: %s\nAuthorization ID: %d\n%s",
 				status, resp.AuthorizationID, resp.Message),
 		}
-	}
+	})
 }
 
 func (m *Model) showListAuthorizationsForm() tea.Cmd {
@@ -1214,40 +1965,97 @@ func (m *Model) showListAuthorizationsForm() tea.Cmd {
 }
 
 func (m *Model) performListAuthorizations(wallet string) tea.Cmd {
-	return func() tea.Msg {
-		ctx := context.Background()
+	return withLoading("Loading authorizations...", func(ctx context.Context) tea.Msg {
 		resp, err := m.client.Authorization.ListAuthorizations(ctx, wallet)
 		if err != nil {
 			return operationErrorMsg{err}
 		}
 
-		var authList string
 		if len(resp.Authorizations) == 0 {
-			authList = "No authorizations found"
-		} else {
-			for i, auth := range resp.Authorizations {
-				status := "Active ✓"
-				if auth.Revoked {
-					status = "Revoked ❌"
-				}
+			return operationSuccessMsg{message: "No authorizations found"}
+		}
+
+		rows := make([]resultRow, len(resp.Authorizations))
+		for i, auth := range resp.Authorizations {
+			status := "Active ✓"
+			if auth.Revoked {
+				status = "Revoked ❌"
+			}
+
+			maxPerTxSol := float64(auth.MaxAmountPerTx) / 1e9
+			maxDailySol := float64(auth.MaxDailySpend) / 1e9
+			spentTodaySol := float64(auth.SpentToday) / 1e9
+
+			validUntilTime := time.Unix(auth.ValidUntil, 0)
+			createdTime := time.Unix(auth.CreatedAt, 0)
+
+			rows[i] = resultRow{
+				summary: fmt.Sprintf("[%d] %s • %s • %.4f / %.4f SOL today",
+					i+1, status, auth.AuthorizedService, spentTodaySol, maxDailySol),
+				detail: fmt.Sprintf("%s\nService: %s\nMax Per Tx: %.4f SOL\nMax Daily: %.4f SOL\nSpent Today: %.4f SOL\nValid Until: %s\nCreated: %s\nLast Reset: %s",
+					status, auth.AuthorizedService, maxPerTxSol, maxDailySol, spentTodaySol,
+					validUntilTime.Format("2006-01-02 15:04:05"), createdTime.Format("2006-01-02 15:04:05"), auth.LastResetDate),
+			}
+		}
 
-				maxPerTxSol := float64(auth.MaxAmountPerTx) / 1e9
-				maxDailySol := float64(auth.MaxDailySpend) / 1e9
-				spentTodaySol := float64(auth.SpentToday) / 1e9
+		return resultsMsg{
+			title: "📋 Authorizations for " + shortAddress(wallet),
+			rows:  rows,
+		}
+	})
+}
 
-				validUntilTime := time.Unix(auth.ValidUntil, 0)
-				createdTime := time.Unix(auth.CreatedAt, 0)
+func (m *Model) showUpdateAuthorizationForm() tea.Cmd {
+	m.inputForm = newInputForm(
+		"✏️  Update Authorization Limits",
+		[]string{"User Wallet", "Authorized Service", "Max Per Tx (SOL, blank = keep)", "Max Daily (SOL, blank = keep)", "Valid Until (days from now, blank = keep)", "User Signature (base58)"},
+		func(values []string) tea.Cmd {
+			return m.performUpdateAuthorization(values[0], values[1], values[2], values[3], values[4], values[5])
+		},
+	)
+	m.showingInput = true
+	return nil
+}
 
-				authList += fmt.Sprintf("\n\n[%d] %s\nService: %s\nMax Per Tx: %.4f SOL\nMax Daily: %.4f SOL\nSpent Today: %.4f SOL\nValid Until: %s\nCreated: %s\nLast Reset: %s",
-					i+1, status, auth.AuthorizedService, maxPerTxSol, maxDailySol, spentTodaySol,
-					validUntilTime.Format("2006-01-02 15:04:05"), createdTime.Format("2006-01-02 15:04:05"), auth.LastResetDate)
+func (m *Model) performUpdateAuthorization(wallet, service, maxPerTx, maxDaily, validDays, signature string) tea.Cmd {
+	return withLoading("Updating authorization...", func(ctx context.Context) tea.Msg {
+		req := authorization.UpdateAuthorizationRequest{
+			UserWallet:        wallet,
+			AuthorizedService: service,
+			UserSignature:     signature,
+		}
+		if maxPerTx != "" {
+			req.MaxAmountPerTx = &maxPerTx
+		}
+		if maxDaily != "" {
+			req.MaxDailySpend = &maxDaily
+		}
+		if validDays != "" {
+			days, err := strconv.Atoi(validDays)
+			if err != nil {
+				return operationErrorMsg{fmt.Errorf("invalid valid days: %w", err)}
 			}
+			validUntil := time.Now().Add(time.Duration(days) * 24 * time.Hour).Unix()
+			req.ValidUntil = &validUntil
+		}
+
+		resp, err := m.client.Authorization.UpdateAuthorization(ctx, req)
+		if err != nil {
+			return operationErrorMsg{err}
 		}
 
+		status := "Failed"
+		if resp.Success {
+			status = "Success ✓"
+		}
+
+		auth := resp.Authorization
 		return operationSuccessMsg{
-			message: fmt.Sprintf("Authorizations for %s:%s", wallet, authList),
+			message: fmt.Sprintf("Update Authorization: %s\nMax Per Tx: %.4f SOL\nMax Daily: %.4f SOL\nSpent Today: %.4f SOL\nValid Until: %s\n%s",
+				status, float64(auth.MaxAmountPerTx)/1e9, float64(auth.MaxDailySpend)/1e9, float64(auth.SpentToday)/1e9,
+				time.Unix(auth.ValidUntil, 0).Format("2006-01-02 15:04:05"), resp.Message),
 		}
-	}
+	})
 }
 
 func (m *Model) showRevokeAuthorizationForm() tea.Cmd {
@@ -1263,14 +2071,13 @@ func (m *Model) showRevokeAuthorizationForm() tea.Cmd {
 }
 
 func (m *Model) performRevokeAuthorization(wallet, service, signature string) tea.Cmd {
-	return func() tea.Msg {
+	return withLoading("Revoking authorization...", func(ctx context.Context) tea.Msg {
 		req := authorization.RevokeAuthorizationRequest{
 			UserWallet:        wallet,
 			AuthorizedService: service,
 			UserSignature:     signature,
 		}
 
-		ctx := context.Background()
 		resp, err := m.client.Authorization.RevokeAuthorization(ctx, req)
 		if err != nil {
 			return operationErrorMsg{err}
@@ -1285,7 +2092,7 @@ func (m *Model) performRevokeAuthorization(wallet, service, signature string) te
 			message: fmt.Sprintf("Revoke Authorization: %s\nAuthorization ID: %d\n%s",
 				status, resp.AuthorizationID, resp.Message),
 		}
-	}
+	})
 }
 
 // Helper function for splitting and trimming strings
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+
+	"sol_privacy"
+	"sol_privacy/internal/client"
+	"sol_privacy/internal/jsonfile"
+)
+
+// profile is the CLI configuration written by the onboarding wizard. Each
+// step records its result, so an interrupted setup resumes where it stopped.
+type profile struct {
+	APIKey           string `json:"api_key,omitempty"`
+	BaseURL          string `json:"base_url,omitempty"`
+	Network          string `json:"network,omitempty"`
+	RPCURL           string `json:"rpc_url,omitempty"`
+	Wallet           string `json:"wallet,omitempty"`
+	WalletAddress    string `json:"wallet_address,omitempty"`
+	Commitment       string `json:"shadowid_commitment,omitempty"`
+	LeafIndex        int    `json:"shadowid_leaf_index,omitempty"`
+	ElGamalPublic    string `json:"elgamal_public_key,omitempty"`
+	ElGamalPrivate   string `json:"elgamal_private_key,omitempty"`
+	DepositSignature string `json:"deposit_signature,omitempty"`
+	VerifiedAt       string `json:"verified_at,omitempty"`
+}
+
+// profilePath returns $SHADOWPAY_PROFILE_FILE, or ~/.shadowpay/profile.json.
+func profilePath() string {
+	if path := os.Getenv("SHADOWPAY_PROFILE_FILE"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".shadowpay", "profile.json")
+}
+
+// loadProfile reads the profile at path. A missing file gives an empty profile.
+func loadProfile(path string) (*profile, error) {
+	p := &profile{}
+	if path == "" {
+		return p, nil
+	}
+	if err := jsonfile.Load(path, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// save writes the profile, which holds keys and is readable only by the owner.
+func (p *profile) save(path string) error {
+	if path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return jsonfile.Save(path, p)
+}
+
+// newClient creates an SDK client against the profile's API, or the default
+// one when the profile does not name it.
+func (p *profile) newClient(apiKey string) *shadowpay.ShadowPay {
+	var opts []client.Option
+	if p.BaseURL != "" {
+		opts = append(opts, client.WithBaseURL(p.BaseURL))
+	}
+	return shadowpay.New(apiKey, opts...)
+}
@@ -0,0 +1,266 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"sol_privacy/internal/merkle"
+	"sol_privacy/internal/poseidon"
+	"sol_privacy/internal/shadowid"
+)
+
+func (m *Model) showDebugProofForm() tea.Cmd {
+	m.inputForm = newInputForm(
+		"🧪 Debug Merkle Proof",
+		[]string{"Commitment", "Compare with local replica (y/N)"},
+		func(values []string) tea.Cmd {
+			replica := strings.HasPrefix(strings.ToLower(strings.TrimSpace(values[1])), "y")
+			return m.performDebugProof(strings.TrimSpace(values[0]), replica)
+		},
+	)
+	m.showingInput = true
+	return nil
+}
+
+// performDebugProof fetches a commitment's proof and the current root, and
+// recomputes the root level by level. With a replica, the whole tree is synced
+// locally and every sibling checked against it, locating the first bad level.
+func (m *Model) performDebugProof(commitment string, replica bool) tea.Cmd {
+	return withLoading("Debugging proof...", func(ctx context.Context) tea.Msg {
+		proof, err := m.client.ShadowID.GetProof(ctx, commitment)
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+		root, err := m.client.ShadowID.GetRoot(ctx)
+		if err != nil {
+			return operationErrorMsg{err}
+		}
+
+		var tree *merkle.Tree
+		var replicaErr error
+		if replica {
+			depth := root.TreeDepth
+			if depth == 0 {
+				depth = len(proof.Proof)
+			}
+			if tree, replicaErr = merkle.NewPoseidon(depth); replicaErr == nil {
+				replicaErr = m.client.ShadowID.SyncReplica(ctx, tree)
+			}
+			if replicaErr != nil {
+				tree = nil
+			}
+		}
+
+		rows := debugProof(commitment, proof, root, tree)
+		if replicaErr != nil {
+			rows = append(rows, resultRow{
+				summary: "⚠ Local replica unavailable",
+				detail:  fmt.Sprintf("The tree could not be synced, so siblings were not checked against it:\n%v", replicaErr),
+			})
+		}
+		return resultsMsg{title: "🧪 Proof Debug", rows: rows}
+	})
+}
+
+// debugProof walks a proof from its leaf to the root with Poseidon. The first
+// row is the verdict; the rest describe the leaf, each level and the roots.
+// Each finding is marked ❌ (breaks verification) or ⚠ (suspicious).
+func debugProof(commitment string, proof *shadowid.ProofResponse, root *shadowid.RootResponse, replica *merkle.Tree) []resultRow {
+	var rows []resultRow
+	divergence := "" // First place verification breaks
+
+	diverge := func(where string) {
+		if divergence == "" {
+			divergence = where
+		}
+	}
+
+	// The leaf must be the commitment that was asked for
+	leaf, err := merkle.ParseElement(proof.Commitment)
+	leafRow := resultRow{
+		summary: fmt.Sprintf("✓ Leaf #%d %s", proof.LeafIndex, shortElement(leaf)),
+		detail:  fmt.Sprintf("Leaf index: %d\nCommitment: %s", proof.LeafIndex, proof.Commitment),
+	}
+	switch {
+	case err != nil:
+		leafRow.summary = fmt.Sprintf("❌ Leaf #%d is not a field element", proof.LeafIndex)
+		leafRow.detail += "\n\n" + err.Error()
+		diverge("the leaf")
+	case leaf.Cmp(poseidon.Modulus) >= 0:
+		leafRow.summary = fmt.Sprintf("❌ Leaf #%d is outside the BN254 field", proof.LeafIndex)
+		leafRow.detail += "\n\nThe circuit reduces it, so it no longer matches the registered commitment."
+		diverge("the leaf")
+	default:
+		if asked, err := merkle.ParseElement(commitment); err == nil && asked.Cmp(leaf) != 0 {
+			leafRow.summary = fmt.Sprintf("❌ Leaf #%d is not the requested commitment", proof.LeafIndex)
+			leafRow.detail += "\nRequested:  " + commitment
+			diverge("the leaf")
+		}
+	}
+	if proof.LeafIndex < 0 || (root.LeafCount > 0 && proof.LeafIndex >= root.LeafCount) {
+		leafRow.summary = fmt.Sprintf("❌ Leaf #%d is past the end of the tree", proof.LeafIndex)
+		leafRow.detail += fmt.Sprintf("\n\nThe tree holds %d leaves.", root.LeafCount)
+		diverge("the leaf index")
+	}
+	rows = append(rows, leafRow)
+
+	if root.TreeDepth > 0 && len(proof.Proof) != root.TreeDepth {
+		rows = append(rows, resultRow{
+			summary: fmt.Sprintf("❌ Proof has %d siblings, the tree is %d deep", len(proof.Proof), root.TreeDepth),
+			detail:  "A proof needs exactly one sibling per level; the circuit rejects any other length.",
+		})
+		diverge("the proof length")
+	}
+
+	// The replica's own proof gives the siblings the proof should contain
+	var expected *merkle.Proof
+	if replica != nil && leaf != nil {
+		if p, err := replica.Proof(proof.LeafIndex); err != nil {
+			rows = append(rows, resultRow{summary: "⚠ Leaf is not in the local replica", detail: err.Error()})
+		} else if p.Leaf.Cmp(leaf) != 0 {
+			rows = append(rows, resultRow{
+				summary: fmt.Sprintf("❌ Replica holds a different leaf at #%d", proof.LeafIndex),
+				detail:  fmt.Sprintf("Proof:   %s\nReplica: %s", proof.Commitment, merkle.FormatElement(p.Leaf)),
+			})
+			diverge("the leaf")
+		} else {
+			expected = p
+		}
+	}
+
+	// Only a proof against the current root can be checked against the current leaf count
+	proofRoot, proofRootErr := merkle.ParseElement(proof.Root)
+	currentRoot, currentRootErr := merkle.ParseElement(root.Root)
+	current := proofRootErr == nil && currentRootErr == nil && proofRoot.Cmp(currentRoot) == 0
+
+	zeros := zeroSubtrees(len(proof.Proof))
+	cur := leaf
+	for l, s := range proof.Proof {
+		right := (proof.LeafIndex>>l)&1 == 1
+		position := "left"
+		if right {
+			position = "right"
+		}
+		sibling, err := merkle.ParseElement(s)
+		if err == nil && sibling.Cmp(poseidon.Modulus) >= 0 {
+			err = fmt.Errorf("%s is outside the BN254 field", s)
+		}
+		if err != nil {
+			rows = append(rows, resultRow{
+				summary: fmt.Sprintf("❌ L%02d sibling is not a field element", l),
+				detail:  fmt.Sprintf("Level %d\nSibling: %s\n\n%v", l, s, err),
+			})
+			diverge(fmt.Sprintf("level %d", l))
+			cur = nil
+			continue
+		}
+
+		var node *big.Int
+		if cur != nil {
+			if right {
+				node, err = poseidon.Hash(sibling, cur)
+			} else {
+				node, err = poseidon.Hash(cur, sibling)
+			}
+			if err != nil {
+				node = nil
+			}
+		}
+
+		mark, note := "✓", ""
+		switch {
+		case expected != nil && l < len(expected.Siblings) && expected.Siblings[l].Cmp(sibling) != 0:
+			mark = "❌"
+			note = "sibling differs from the replica's " + shortElement(expected.Siblings[l])
+			diverge(fmt.Sprintf("level %d", l))
+		case current && root.LeafCount > 0:
+			// A sibling subtree is empty exactly when it starts past the last leaf
+			empty := ((proof.LeafIndex>>l)^1)<<l >= root.LeafCount
+			switch {
+			case empty && sibling.Cmp(zeros[l]) != 0:
+				mark, note = "⚠", "sibling subtree is empty but its hash is not the zero hash"
+			case !empty && sibling.Cmp(zeros[l]) == 0:
+				mark, note = "⚠", "sibling subtree has leaves but its hash is the zero hash"
+			}
+		}
+
+		summary := fmt.Sprintf("%s L%02d %-5s sibling %s → %s", mark, l, position, shortElement(sibling), shortElement(node))
+		detail := fmt.Sprintf("Level %d, node is the %s child\nSibling:  %s\nComputed: %s",
+			l, position, merkle.FormatElement(sibling), formatNode(node))
+		if expected != nil && l < len(expected.Siblings) {
+			detail += "\nReplica:  " + merkle.FormatElement(expected.Siblings[l])
+		}
+		if note != "" {
+			summary += " (" + note + ")"
+			detail += "\n\n" + strings.ToUpper(note[:1]) + note[1:] + "."
+		}
+		rows = append(rows, resultRow{summary: summary, detail: detail})
+		cur = node
+	}
+
+	// The recomputed root must match both the proof's root and the tree's
+	rootRow := func(name, value string, parsed *big.Int, parseErr error) {
+		r := resultRow{detail: fmt.Sprintf("%s:  %s\nComputed: %s", name, value, formatNode(cur))}
+		switch {
+		case parseErr != nil:
+			r.summary = fmt.Sprintf("❌ %s is not a field element", name)
+			diverge(strings.ToLower(name))
+		case cur == nil:
+			r.summary = fmt.Sprintf("⚠ %s %s could not be recomputed", name, shortElement(parsed))
+		case cur.Cmp(parsed) != 0:
+			r.summary = fmt.Sprintf("❌ %s %s ≠ computed %s", name, shortElement(parsed), shortElement(cur))
+			diverge(strings.ToLower(name))
+		default:
+			r.summary = fmt.Sprintf("✓ %s %s matches", name, shortElement(parsed))
+		}
+		rows = append(rows, r)
+	}
+	rootRow("Proof root", proof.Root, proofRoot, proofRootErr)
+	rootRow("Tree root", root.Root, currentRoot, currentRootErr)
+
+	verdict := resultRow{
+		summary: "✓ Proof verifies against the current root",
+		detail:  "The leaf, every level and both roots agree.",
+	}
+	if divergence != "" {
+		verdict.summary = "❌ Verification diverges at " + divergence
+		verdict.detail = "The first problem found is at " + divergence + ". Rows marked ❌ break verification, rows marked ⚠ are suspicious."
+		switch {
+		case divergence == "proof root" && replica == nil:
+			verdict.detail += "\n\nEvery level hashed, but the result is not the proof's own root, so a sibling is wrong. Compare with a local replica to find which."
+		case divergence == "tree root" && !current:
+			verdict.detail += "\n\nThe proof was built against an older root. Fetch a fresh proof before settling."
+		}
+	}
+	verdict.detail += fmt.Sprintf("\n\nTree depth: %d • Leaves: %d", root.TreeDepth, root.LeafCount)
+	return append([]resultRow{verdict}, rows...)
+}
+
+// zeroSubtrees returns the roots of empty subtrees of height 0 to depth.
+func zeroSubtrees(depth int) []*big.Int {
+	zeros := []*big.Int{big.NewInt(0)}
+	for l := 1; l <= depth; l++ {
+		z, _ := poseidon.Hash(zeros[l-1], zeros[l-1])
+		zeros = append(zeros, z)
+	}
+	return zeros
+}
+
+// shortElement abbreviates a field element for display.
+func shortElement(n *big.Int) string {
+	if n == nil {
+		return "?"
+	}
+	s := merkle.FormatElement(n)
+	return s[:8] + "…" + s[len(s)-4:]
+}
+
+func formatNode(n *big.Int) string {
+	if n == nil {
+		return "(not computed)"
+	}
+	return merkle.FormatElement(n)
+}
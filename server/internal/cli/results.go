@@ -0,0 +1,233 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// resultRow is one entry of a results pane: a one-line summary and the full
+// detail shown when the row is opened.
+type resultRow struct {
+	summary string
+	detail  string
+}
+
+// resultsMsg shows rows in the results pane instead of the message box.
+type resultsMsg struct {
+	title string
+	rows  []resultRow
+}
+
+// resultsPane is a scrollable list of results with search (/), page keys and
+// a detail view of the selected row (enter).
+type resultsPane struct {
+	title     string
+	rows      []resultRow
+	matches   []int // Indexes of the rows matching the search
+	cursor    int   // Position in matches
+	search    textinput.Model
+	searching bool
+	detail    bool
+	viewport  viewport.Model
+	width     int
+}
+
+func newResultsPane(title string, rows []resultRow, width, height int) resultsPane {
+	search := textinput.New()
+	search.Prompt = "/"
+	search.Placeholder = t("search")
+	search.CharLimit = 64
+
+	p := resultsPane{
+		title:    title,
+		rows:     rows,
+		search:   search,
+		viewport: viewport.New(0, 0),
+	}
+	p.setSize(width, height)
+	p.filter()
+	return p
+}
+
+// setSize fits the pane to the terminal, leaving room for title and help.
+func (p *resultsPane) setSize(width, height int) {
+	p.width = min(max(width-4, 20), 100)
+	p.viewport.Width = p.width
+	p.viewport.Height = max(height-10, 3)
+	p.refresh()
+}
+
+// filter keeps the rows containing the search query, ignoring case.
+func (p *resultsPane) filter() {
+	q := strings.ToLower(strings.TrimSpace(p.search.Value()))
+	p.matches = p.matches[:0]
+	for i, r := range p.rows {
+		if q == "" || strings.Contains(strings.ToLower(r.summary+"\n"+r.detail), q) {
+			p.matches = append(p.matches, i)
+		}
+	}
+	p.cursor = 0
+	p.viewport.GotoTop()
+	p.refresh()
+}
+
+// refresh renders the list, or the opened row, into the viewport.
+func (p *resultsPane) refresh() {
+	if p.detail {
+		p.viewport.SetContent(lipgloss.NewStyle().Width(p.width).Render(p.rows[p.matches[p.cursor]].detail))
+		return
+	}
+
+	lines := make([]string, len(p.matches))
+	line := lipgloss.NewStyle().MaxWidth(p.width)
+	for i, idx := range p.matches {
+		if i == p.cursor {
+			lines[i] = line.Render(selectedMenuItemStyle.Render("❯ " + p.rows[idx].summary))
+		} else {
+			lines[i] = line.Render(menuItemStyle.Render(p.rows[idx].summary))
+		}
+	}
+	p.viewport.SetContent(strings.Join(lines, "\n"))
+
+	// Keep the selected row on screen
+	if p.cursor < p.viewport.YOffset {
+		p.viewport.SetYOffset(p.cursor)
+	} else if p.cursor >= p.viewport.YOffset+p.viewport.Height {
+		p.viewport.SetYOffset(p.cursor - p.viewport.Height + 1)
+	}
+}
+
+// moveCursor moves the selection by n rows, clamped to the list.
+func (p *resultsPane) moveCursor(n int) {
+	p.cursor = min(max(p.cursor+n, 0), max(len(p.matches)-1, 0))
+	p.refresh()
+}
+
+// Update handles a key press and reports whether the pane was closed.
+func (p *resultsPane) Update(msg tea.KeyMsg) (tea.Cmd, bool) {
+	if p.searching {
+		switch msg.String() {
+		case "enter":
+			p.searching = false
+			p.search.Blur()
+			return nil, false
+		case "esc":
+			p.searching = false
+			p.search.Blur()
+			p.search.SetValue("")
+			p.filter()
+			return nil, false
+		}
+		prev := p.search.Value()
+		var cmd tea.Cmd
+		p.search, cmd = p.search.Update(msg)
+		if p.search.Value() != prev {
+			p.filter()
+		}
+		return cmd, false
+	}
+
+	if p.detail {
+		switch msg.String() {
+		case "esc", "backspace", "q":
+			p.detail = false
+			p.refresh()
+			return nil, false
+		}
+		var cmd tea.Cmd
+		p.viewport, cmd = p.viewport.Update(msg)
+		return cmd, false
+	}
+
+	page := p.viewport.Height
+	switch msg.String() {
+	case "esc":
+		// Clear an active search before leaving
+		if p.search.Value() != "" {
+			p.search.SetValue("")
+			p.filter()
+			return nil, false
+		}
+		return nil, true
+	case "q":
+		return nil, true
+	case "/":
+		p.searching = true
+		return p.search.Focus(), false
+	case "up", "k":
+		p.moveCursor(-1)
+	case "down", "j":
+		p.moveCursor(1)
+	case "pgup", "b", "ctrl+u":
+		p.moveCursor(-page)
+	case "pgdown", "f", " ", "ctrl+d":
+		p.moveCursor(page)
+	case "home", "g":
+		p.moveCursor(-len(p.matches))
+	case "end", "G":
+		p.moveCursor(len(p.matches))
+	case "enter":
+		if len(p.matches) > 0 {
+			p.detail = true
+			p.viewport.GotoTop()
+			p.refresh()
+		}
+	}
+	return nil, false
+}
+
+func (p resultsPane) View(width, height int) string {
+	title := titleStyle.Render(t(p.title))
+
+	var status string
+	switch {
+	case p.detail:
+		status = fmt.Sprintf(t("Row %d of %d"), p.cursor+1, len(p.matches))
+	case len(p.matches) == 0:
+		status = t("No matching results")
+	default:
+		status = fmt.Sprintf(t("Row %d of %d"), p.cursor+1, len(p.matches))
+		if len(p.matches) != len(p.rows) {
+			status += " " + fmt.Sprintf(t("(filtered from %d)"), len(p.rows))
+		}
+	}
+	if p.viewport.TotalLineCount() > p.viewport.Height {
+		status += fmt.Sprintf(" • %3.f%%", p.viewport.ScrollPercent()*100)
+	}
+
+	var searchLine string
+	if p.searching || p.search.Value() != "" {
+		searchLine = p.search.View()
+	}
+
+	helpText := t("↑/↓: select • pgup/pgdn: page • /: search • enter: open • esc: back")
+	switch {
+	case p.searching:
+		helpText = t("enter: apply search • esc: clear search")
+	case p.detail:
+		helpText = t("↑/↓/pgup/pgdn: scroll • esc: back to list")
+	}
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		headerStyle.UnsetMarginBottom().Render(status),
+		searchLine,
+		"",
+		p.viewport.View(),
+		helpStyle.Render(helpText),
+	)
+
+	return lipgloss.Place(
+		width,
+		height,
+		lipgloss.Center,
+		lipgloss.Center,
+		content,
+	)
+}
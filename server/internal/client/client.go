@@ -12,6 +12,8 @@ import (
 	"time"
 
 	"sol_privacy/internal/errors"
+	"sol_privacy/internal/reqsign"
+	"sol_privacy/internal/wallet"
 )
 
 const (
@@ -25,6 +27,18 @@ type Client struct {
 	httpClient *http.Client
 	apiKey     string
 	userAgent  string
+	middleware []Middleware
+	send       SendFunc // httpClient.Do wrapped in middleware
+
+	endpoints      *endpointPool // Nil with a single base URL
+	endpointHealth HealthCheck
+	cache          *responseCache // Nil unless WithResponseCache
+
+	dryRun   bool     // Mutating requests are never sent
+	simulate SendFunc // Answers requests held back by dry runs
+
+	sandbox     *SandboxConfig // Nil unless WithSandbox
+	sandboxSend SendFunc       // Like send, to the sandbox and guarded against mainnet
 }
 
 // Option allows for functional configuration of the Client.
@@ -35,6 +49,7 @@ func WithBaseURL(rawURL string) Option {
 	return func(c *Client) {
 		if parsed, err := url.Parse(strings.TrimRight(rawURL, "/")); err == nil {
 			c.baseURL = parsed
+			c.endpoints = nil
 		}
 	}
 }
@@ -46,6 +61,69 @@ func WithHTTPClient(httpClient *http.Client) Option {
 	}
 }
 
+// SendFunc sends a request and returns its response, like http.Client.Do.
+type SendFunc func(req *http.Request) (*http.Response, error)
+
+// Middleware wraps the sending of every request, e.g. to add headers, sign
+// requests, log them or inject faults. It may return a response without
+// calling next. A middleware that returns an error must close any response
+// body it received.
+type Middleware func(next SendFunc) SendFunc
+
+// RequestHook inspects or modifies a request before it is sent. An error
+// aborts the request.
+type RequestHook func(req *http.Request) error
+
+// ResponseHook inspects a response, including API errors, before its body is
+// decoded. An error is returned to the caller instead of the response.
+type ResponseHook func(resp *http.Response) error
+
+// WithMiddleware appends middleware to the chain. Middleware added first is
+// outermost: it sees requests first and responses last.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(c *Client) {
+		c.middleware = append(c.middleware, mw...)
+	}
+}
+
+// WithRequestHook adds a middleware that calls hook before sending.
+func WithRequestHook(hook RequestHook) Option {
+	return WithMiddleware(func(next SendFunc) SendFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			if err := hook(req); err != nil {
+				return nil, err
+			}
+			return next(req)
+		}
+	})
+}
+
+// WithResponseHook adds a middleware that calls hook on every response.
+func WithResponseHook(hook ResponseHook) Option {
+	return WithMiddleware(func(next SendFunc) SendFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			resp, err := next(req)
+			if err != nil {
+				return nil, err
+			}
+			if err := hook(resp); err != nil {
+				resp.Body.Close()
+				return nil, err
+			}
+			return resp, nil
+		}
+	})
+}
+
+// WithRequestSigner signs every request with the wallet key of signer, which
+// proxies configured to verify signatures require on sensitive endpoints.
+// Add it last so it signs the body other middleware sends.
+func WithRequestSigner(signer wallet.Signer) Option {
+	return WithRequestHook(func(req *http.Request) error {
+		return reqsign.Sign(req, signer)
+	})
+}
+
 // New creates a new ShadowPay API client.
 // apiKey can be empty if you are only calling public endpoints (like key generation).
 func New(apiKey string, opts ...Option) *Client {
@@ -61,13 +139,44 @@ func New(apiKey string, opts ...Option) *Client {
 		opt(c)
 	}
 
+	c.send = c.httpClient.Do
+	if c.endpoints != nil {
+		c.send = c.endpoints.send(c.httpClient)
+	}
+	if c.cache != nil {
+		c.send = c.cache.wrap(c.send)
+	}
+	c.sandboxSend = guardMainnet(c.httpClient.Do)
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		c.send = c.middleware[i](c.send)
+		c.sandboxSend = c.middleware[i](c.sandboxSend)
+	}
+
 	return c
 }
 
-// NewRequest creates an authenticated HTTP request.
+// NewRequest creates an authenticated HTTP request. path is relative to the
+// base URL and may carry a query; callers escape the segments and query
+// values they fill in with url.PathEscape and url.QueryEscape.
 func (c *Client) NewRequest(ctx context.Context, method, path string, body interface{}) (*http.Request, error) {
-	rel := &url.URL{Path: path}
-	u := c.baseURL.ResolveReference(rel)
+	rel, err := url.Parse(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request path: %w", err)
+	}
+	// An unescaped segment could point the request, and the API key, at
+	// another host
+	if rel.Scheme != "" || rel.Host != "" || rel.User != nil || rel.Fragment != "" || !strings.HasPrefix(rel.Path, "/") {
+		return nil, fmt.Errorf("invalid request path %q", path)
+	}
+	base, apiKey := c.baseURL, c.apiKey
+	sandboxed := c.sandboxed(ctx)
+	if sandboxed {
+		if base, apiKey, err = c.sandboxTarget(); err != nil {
+			return nil, err
+		}
+		body = tagTestData(rel.Path, body)
+	}
+	u := base.ResolveReference(rel)
 
 	var buf io.ReadWriter
 	if body != nil {
@@ -84,16 +193,29 @@ func (c *Client) NewRequest(ctx context.Context, method, path string, body inter
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", c.userAgent)
-	if c.apiKey != "" {
-		req.Header.Set("X-API-Key", c.apiKey)
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+	if sandboxed {
+		req.Header.Set(HeaderEnvironment, EnvironmentSandbox)
 	}
 
 	return req, nil
 }
 
-// Do executes the HTTP request and decodes the response.
+// Do executes the HTTP request through the middleware chain and decodes the
+// response.
 func (c *Client) Do(req *http.Request, v interface{}) error {
-	resp, err := c.httpClient.Do(req)
+	send := c.send
+	if c.holdBack(req) {
+		if c.simulate == nil {
+			return dryRunError(req)
+		}
+		send = c.simulate
+	} else if c.sandboxed(req.Context()) {
+		send = c.sandboxSend
+	}
+	resp, err := send(req)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
@@ -115,9 +237,9 @@ func (c *Client) Do(req *http.Request, v interface{}) error {
 
 func (c *Client) handleError(resp *http.Response) error {
 	var apiErr errors.ErrorResponse
-	if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil {
-		// Fallback if JSON decoding fails
-		return fmt.Errorf("api error (status %d): %s", resp.StatusCode, resp.Status)
+	if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil || apiErr.Message == "" {
+		// Fallback for bodies that are not a JSON error, keeping the status
+		apiErr.Message = http.StatusText(resp.StatusCode)
 	}
 	apiErr.StatusCode = resp.StatusCode
 	return &apiErr
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrDryRun is matched by the errors of requests a dry run did not send.
+var ErrDryRun = errors.New("dry run")
+
+// DryRunError is returned instead of sending a mutating request in a dry
+// run. The request was built and its parameters validated; it is what would
+// have been sent.
+type DryRunError struct {
+	Method string          `json:"method"`
+	URL    string          `json:"url"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+func (e *DryRunError) Error() string {
+	return fmt.Sprintf("dry run: %s %s not sent", e.Method, e.URL)
+}
+
+// Is reports whether target is ErrDryRun.
+func (e *DryRunError) Is(target error) bool {
+	return target == ErrDryRun
+}
+
+// readOnlyPaths are POST endpoints that change nothing, so dry runs send them.
+var readOnlyPaths = []string{
+	"/shadowpay/api/merchant/decrypt",
+	"/shadowpay/api/privacy/decrypt",
+	"/shadowpay/api/shadowid/proof",
+	"/shadowpay/v1/pay/intents/search",
+	"/shadowpay/v1/pay/verify",
+	"/shadowpay/verify",
+}
+
+type dryRunKey struct{}
+
+// WithDryRun makes every mutating call of the client a dry run: deposits,
+// withdrawals, settlements, token additions and any other request that is
+// not a read. Requests are built and validated as usual but not sent, and
+// the call fails with a *DryRunError (matching ErrDryRun) holding the
+// request, unless WithDryRunSimulator answers it. Reads are sent, so a
+// configuration can be checked against production credentials safely.
+func WithDryRun() Option {
+	return func(c *Client) {
+		c.dryRun = true
+	}
+}
+
+// WithDryRunSimulator answers the requests a dry run does not send, e.g.
+// with canned responses or by sending them to a devnet deployment, so calls
+// return results instead of a *DryRunError. Its responses are decoded like
+// the API's.
+func WithDryRunSimulator(simulate SendFunc) Option {
+	return func(c *Client) {
+		c.simulate = simulate
+	}
+}
+
+// DryRun returns a context whose calls are dry runs, as with WithDryRun, for
+// clients that otherwise send mutating requests.
+func DryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// IsDryRun reports whether calls made with ctx are dry runs.
+func IsDryRun(ctx context.Context) bool {
+	v, _ := ctx.Value(dryRunKey{}).(bool)
+	return v
+}
+
+// holdBack reports whether req must not be sent: it is a mutating request in
+// a dry run.
+func (c *Client) holdBack(req *http.Request) bool {
+	if !c.dryRun && !IsDryRun(req.Context()) {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	if req.Method == http.MethodPost {
+		path := strings.TrimPrefix(req.URL.Path, strings.TrimRight(c.baseURL.Path, "/"))
+		for _, p := range readOnlyPaths {
+			if path == p {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// dryRunError describes a held-back request.
+func dryRunError(req *http.Request) error {
+	e := &DryRunError{Method: req.Method, URL: req.URL.String()}
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return err
+		}
+		if json.Valid(body) {
+			e.Body = json.RawMessage(bytes.TrimSpace(body))
+		}
+	}
+	return e
+}
@@ -0,0 +1,315 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Endpoint health defaults.
+const (
+	DefaultHealthPath     = "/"
+	DefaultHealthInterval = 30 * time.Second
+	DefaultCooldown       = 15 * time.Second
+)
+
+// latencyWeight is the weight of a new sample in an endpoint's moving
+// average latency.
+const latencyWeight = 0.3
+
+// EndpointStatus describes one upstream base URL.
+type EndpointStatus struct {
+	URL       string        `json:"url"`
+	Healthy   bool          `json:"healthy"`
+	Latency   time.Duration `json:"latency"`  // Moving average; zero until measured
+	Failures  int           `json:"failures"` // Consecutive
+	LastError string        `json:"last_error,omitempty"`
+	CheckedAt time.Time     `json:"checked_at"`
+}
+
+// HealthCheck configures how unhealthy endpoints are probed.
+type HealthCheck struct {
+	// Path is requested with GET on each base URL; any status below 500
+	// counts as reachable. Defaults to DefaultHealthPath.
+	Path string
+	// Interval between probes of an endpoint, made in the background as
+	// requests come in. Defaults to DefaultHealthInterval.
+	Interval time.Duration
+	// Cooldown is how long a failed endpoint is skipped before it is tried
+	// again. Defaults to DefaultCooldown.
+	Cooldown time.Duration
+}
+
+// WithBaseURLs sets several API base URLs, e.g. one per region. Each request
+// goes to the healthy endpoint with the lowest observed latency, and fails
+// over to the next one when an endpoint cannot be reached or answers with a
+// gateway error. Requests an endpoint may have processed (after a 502, 504 or
+// a dropped connection) are only resent elsewhere if they are idempotent
+// (GET, HEAD, OPTIONS, PUT, DELETE).
+func WithBaseURLs(rawURLs ...string) Option {
+	return func(c *Client) {
+		var urls []*url.URL
+		for _, raw := range rawURLs {
+			if parsed, err := url.Parse(strings.TrimRight(strings.TrimSpace(raw), "/")); err == nil && parsed.Host != "" {
+				urls = append(urls, parsed)
+			}
+		}
+		if len(urls) == 0 {
+			return
+		}
+		c.baseURL = urls[0]
+		if len(urls) > 1 {
+			c.endpoints = newEndpointPool(urls, c.endpointHealth)
+		}
+	}
+}
+
+// WithHealthCheck tunes the health checks of endpoints set by WithBaseURLs.
+func WithHealthCheck(hc HealthCheck) Option {
+	return func(c *Client) {
+		c.endpointHealth = hc
+		if c.endpoints != nil {
+			c.endpoints.setHealthCheck(hc)
+		}
+	}
+}
+
+// Endpoints reports the health and latency of each base URL, in the order
+// they were configured.
+func (c *Client) Endpoints() []EndpointStatus {
+	if c.endpoints == nil {
+		return []EndpointStatus{{URL: c.baseURL.String(), Healthy: true}}
+	}
+	return c.endpoints.status()
+}
+
+type endpoint struct {
+	url *url.URL
+
+	latency   time.Duration
+	failures  int
+	downUntil time.Time
+	lastError string
+	checkedAt time.Time
+	probing   bool
+}
+
+// endpointPool routes requests across base URLs.
+type endpointPool struct {
+	mu        sync.Mutex
+	endpoints []*endpoint
+	health    HealthCheck
+}
+
+func newEndpointPool(urls []*url.URL, hc HealthCheck) *endpointPool {
+	p := &endpointPool{}
+	for _, u := range urls {
+		p.endpoints = append(p.endpoints, &endpoint{url: u})
+	}
+	p.setHealthCheck(hc)
+	return p
+}
+
+func (p *endpointPool) setHealthCheck(hc HealthCheck) {
+	if hc.Path == "" {
+		hc.Path = DefaultHealthPath
+	}
+	if hc.Interval <= 0 {
+		hc.Interval = DefaultHealthInterval
+	}
+	if hc.Cooldown <= 0 {
+		hc.Cooldown = DefaultCooldown
+	}
+	p.mu.Lock()
+	p.health = hc
+	p.mu.Unlock()
+}
+
+func (p *endpointPool) status() []EndpointStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	out := make([]EndpointStatus, len(p.endpoints))
+	for i, e := range p.endpoints {
+		out[i] = EndpointStatus{
+			URL:       e.url.String(),
+			Healthy:   !now.Before(e.downUntil),
+			Latency:   e.latency,
+			Failures:  e.failures,
+			LastError: e.lastError,
+			CheckedAt: e.checkedAt,
+		}
+	}
+	return out
+}
+
+// order returns the endpoints to try: healthy ones by latency, then those
+// cooling down, soonest available first. Endpoints whose last check is
+// older than the health interval are probed in the background.
+func (p *endpointPool) order(client *http.Client) []*endpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	out := make([]*endpoint, len(p.endpoints))
+	copy(out, p.endpoints)
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		aUp, bUp := !now.Before(a.downUntil), !now.Before(b.downUntil)
+		if aUp != bUp {
+			return aUp
+		}
+		if !aUp {
+			return a.downUntil.Before(b.downUntil)
+		}
+		return a.latency < b.latency
+	})
+	for _, e := range p.endpoints {
+		if !e.probing && now.Sub(e.checkedAt) >= p.health.Interval {
+			e.probing = true
+			go p.check(client, e)
+		}
+	}
+	return out
+}
+
+// check probes an endpoint's health path.
+func (p *endpointPool) check(client *http.Client, e *endpoint) {
+	p.mu.Lock()
+	path := p.health.Path
+	timeout := p.health.Cooldown
+	p.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	start := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.url.String()+path, nil)
+	if err == nil {
+		req.Header.Set("User-Agent", UserAgent)
+		var resp *http.Response
+		if resp, err = client.Do(req); err == nil {
+			resp.Body.Close()
+			if resp.StatusCode >= 500 {
+				err = errors.New(resp.Status)
+			}
+		}
+	}
+
+	p.mu.Lock()
+	e.probing = false
+	p.mu.Unlock()
+	p.record(e, time.Since(start), err)
+}
+
+// record updates an endpoint after a request or probe.
+func (p *endpointPool) record(e *endpoint, latency time.Duration, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e.checkedAt = time.Now()
+	if err != nil {
+		e.failures++
+		e.lastError = err.Error()
+		e.downUntil = e.checkedAt.Add(p.health.Cooldown)
+		return
+	}
+	e.failures, e.lastError, e.downUntil = 0, "", time.Time{}
+	if e.latency == 0 {
+		e.latency = latency
+	} else {
+		e.latency += time.Duration(latencyWeight * float64(latency-e.latency))
+	}
+}
+
+// send returns a SendFunc that routes requests through the pool.
+func (p *endpointPool) send(client *http.Client) SendFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		var lastErr error
+		var lastResp *http.Response
+		for i, e := range p.order(client) {
+			if i > 0 {
+				if req.Body != nil && req.GetBody == nil {
+					break
+				}
+				if lastErr != nil && !idempotent(req.Method) && !unsent(lastErr) {
+					break
+				}
+				// A 503 means the endpoint turned the request away
+				if lastResp != nil && !idempotent(req.Method) && lastResp.StatusCode != http.StatusServiceUnavailable {
+					break
+				}
+			}
+			attempt, err := routed(req, e.url)
+			if err != nil {
+				return nil, err
+			}
+			if lastResp != nil {
+				lastResp.Body.Close()
+				lastResp = nil
+			}
+
+			start := time.Now()
+			resp, err := client.Do(attempt)
+			if req.Context().Err() != nil {
+				// The caller gave up; that says nothing about the endpoint
+				return resp, err
+			}
+			switch {
+			case err != nil:
+				p.record(e, 0, err)
+				lastErr = err
+			case gatewayError(resp.StatusCode):
+				p.record(e, 0, errors.New(resp.Status))
+				lastErr, lastResp = nil, resp
+			default:
+				p.record(e, time.Since(start), nil)
+				return resp, nil
+			}
+		}
+		if lastResp != nil {
+			return lastResp, nil
+		}
+		return nil, lastErr
+	}
+}
+
+// routed returns a copy of req sent to the endpoint at base.
+func routed(req *http.Request, base *url.URL) (*http.Request, error) {
+	out := req.Clone(req.Context())
+	out.URL.Scheme, out.URL.Host = base.Scheme, base.Host
+	out.Host = ""
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		out.Body = body
+	}
+	return out, nil
+}
+
+func gatewayError(status int) bool {
+	return status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// unsent reports whether err means the request never reached the endpoint.
+func unsent(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
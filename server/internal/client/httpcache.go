@@ -0,0 +1,231 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCachePaths are the idempotent reads cached by WithResponseCache when
+// no paths are given: the ShadowID tree root, supported tokens, the pool
+// deposit address and the supported x402 schemes.
+var DefaultCachePaths = []string{
+	"/shadowpay/api/shadowid/root",
+	"/shadowpay/api/tokens/supported",
+	"/shadowpay/api/pool/deposit-address",
+	"/shadowpay/supported",
+}
+
+// Cache response header values set on responses served by the cache.
+const (
+	CacheHeader      = "X-Cache"
+	CacheHit         = "HIT"         // Served from memory without a request
+	CacheRevalidated = "REVALIDATED" // Upstream answered 304 Not Modified
+)
+
+// CacheConfig configures the response cache.
+type CacheConfig struct {
+	// TTL is how long responses are served without asking the API. The API's
+	// Cache-Control max-age takes precedence; no-store responses are never
+	// cached. Once expired, responses with an ETag are revalidated with
+	// If-None-Match, which does not transfer the body again.
+	TTL time.Duration
+	// Paths are the GET paths cached, matched exactly. Defaults to
+	// DefaultCachePaths.
+	Paths []string
+	// MaxEntries bounds the number of cached responses, defaults to 1000.
+	MaxEntries int
+}
+
+// WithResponseCache caches GET responses of the configured paths in memory.
+// Requests with Cache-Control: no-cache are always revalidated. Any other
+// successful request (a POST, PUT or DELETE) empties the cache, since it may
+// have changed what the cached reads return.
+func WithResponseCache(config CacheConfig) Option {
+	return func(c *Client) {
+		if config.Paths == nil {
+			config.Paths = DefaultCachePaths
+		}
+		if config.MaxEntries <= 0 {
+			config.MaxEntries = 1000
+		}
+		rc := &responseCache{
+			config:  config,
+			paths:   make(map[string]bool, len(config.Paths)),
+			entries: make(map[string]*cachedResponse),
+		}
+		for _, p := range config.Paths {
+			rc.paths[p] = true
+		}
+		c.cache = rc
+	}
+}
+
+type revalidateKey struct{}
+
+// Revalidate returns a context whose cached reads are checked with the API
+// rather than served from memory, as if sent with Cache-Control: no-cache.
+// Pollers use it to see changes as soon as the API has them.
+func Revalidate(ctx context.Context) context.Context {
+	return context.WithValue(ctx, revalidateKey{}, true)
+}
+
+// PurgeCache empties the response cache.
+func (c *Client) PurgeCache() {
+	if c.cache != nil {
+		c.cache.purge()
+	}
+}
+
+type cachedResponse struct {
+	status   int
+	header   http.Header
+	body     []byte
+	etag     string
+	storedAt time.Time
+	maxAge   time.Duration
+}
+
+type responseCache struct {
+	config CacheConfig
+	paths  map[string]bool
+
+	mu      sync.Mutex
+	entries map[string]*cachedResponse
+}
+
+func (rc *responseCache) purge() {
+	rc.mu.Lock()
+	rc.entries = make(map[string]*cachedResponse)
+	rc.mu.Unlock()
+}
+
+// wrap returns next with the cache in front of it.
+func (rc *responseCache) wrap(next SendFunc) SendFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodGet {
+			resp, err := next(req)
+			if err == nil && resp.StatusCode < 400 {
+				rc.purge()
+			}
+			return resp, err
+		}
+		if !rc.paths[req.URL.Path] {
+			return next(req)
+		}
+
+		key := req.URL.String()
+		rc.mu.Lock()
+		cached := rc.entries[key]
+		rc.mu.Unlock()
+		revalidate := strings.Contains(strings.ToLower(req.Header.Get("Cache-Control")), "no-cache") ||
+			req.Context().Value(revalidateKey{}) != nil
+		if cached != nil && !revalidate && time.Since(cached.storedAt) < cached.maxAge {
+			return cached.response(req, CacheHit), nil
+		}
+		if cached != nil && cached.etag != "" {
+			req = req.Clone(req.Context())
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+
+		resp, err := next(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusNotModified && cached != nil {
+			resp.Body.Close()
+			rc.store(key, cached.status, cached.header, cached.body, resp.Header)
+			return cached.response(req, CacheRevalidated), nil
+		}
+		if resp.StatusCode != http.StatusOK {
+			return resp, nil
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		rc.store(key, resp.StatusCode, resp.Header, body, resp.Header)
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		return resp, nil
+	}
+}
+
+// store caches a response unless its freshness headers forbid it.
+func (rc *responseCache) store(key string, status int, header http.Header, body []byte, freshness http.Header) {
+	maxAge, ok := rc.maxAge(freshness)
+	etag := freshness.Get("ETag")
+	if etag == "" {
+		etag = header.Get("ETag")
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if !ok || (maxAge <= 0 && etag == "") {
+		delete(rc.entries, key)
+		return
+	}
+	if _, exists := rc.entries[key]; !exists && len(rc.entries) >= rc.config.MaxEntries {
+		rc.evictOldest()
+	}
+	rc.entries[key] = &cachedResponse{
+		status:   status,
+		header:   header.Clone(),
+		body:     body,
+		etag:     etag,
+		storedAt: time.Now(),
+		maxAge:   maxAge,
+	}
+}
+
+// maxAge returns how long a response is fresh, and false if it must not be
+// stored.
+func (rc *responseCache) maxAge(h http.Header) (time.Duration, bool) {
+	for _, directive := range strings.Split(strings.ToLower(h.Get("Cache-Control")), ",") {
+		directive = strings.TrimSpace(directive)
+		switch {
+		case directive == "no-store" || directive == "private":
+			return 0, false
+		case directive == "no-cache":
+			return 0, true
+		case strings.HasPrefix(directive, "max-age="):
+			if secs, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil {
+				return time.Duration(secs) * time.Second, true
+			}
+		}
+	}
+	return rc.config.TTL, true
+}
+
+func (rc *responseCache) evictOldest() {
+	var oldestKey string
+	var oldest time.Time
+	for k, e := range rc.entries {
+		if oldestKey == "" || e.storedAt.Before(oldest) {
+			oldestKey, oldest = k, e.storedAt
+		}
+	}
+	delete(rc.entries, oldestKey)
+}
+
+// response rebuilds a cached response for req.
+func (e *cachedResponse) response(req *http.Request, how string) *http.Response {
+	header := e.header.Clone()
+	header.Set(CacheHeader, how)
+	return &http.Response{
+		Status:        strconv.Itoa(e.status) + " " + http.StatusText(e.status),
+		StatusCode:    e.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,158 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"sol_privacy/internal/solana"
+)
+
+// HeaderEnvironment marks requests to the sandbox, so the resources they
+// create are kept as test data.
+const HeaderEnvironment = "X-ShadowPay-Environment"
+
+// EnvironmentSandbox is the HeaderEnvironment value of sandbox requests.
+const EnvironmentSandbox = "sandbox"
+
+var (
+	// ErrNoSandbox is returned for sandboxed calls without WithSandbox.
+	ErrNoSandbox = errors.New("no sandbox configured")
+	// ErrNoSandboxKey is returned for sandboxed calls when no sandbox API key
+	// is configured; the production key is never sent to the sandbox.
+	ErrNoSandboxKey = errors.New("no sandbox API key configured")
+	// ErrSandboxMainnet is returned instead of sending a sandboxed request to
+	// a mainnet endpoint or for a mainnet network.
+	ErrSandboxMainnet = errors.New("sandbox request refused: it targets mainnet")
+)
+
+// SandboxConfig configures the sandbox environment.
+type SandboxConfig struct {
+	URL string // Sandbox API base URL
+
+	// APIKey is the sandbox API key. With Always it defaults to the
+	// client's key, which is then a sandbox credential.
+	APIKey string
+
+	// Always sandboxes every call of the client, not only those made with a
+	// Sandboxed context.
+	Always bool
+}
+
+// taggedPaths create resources the sandbox must keep as test data; their
+// bodies are sent with "test": true.
+var taggedPaths = []string{
+	"/shadowpay/v1/pay/intent",
+	"/shadowpay/api/webhooks/register",
+	"/shadowpay/v1/keys/new",
+}
+
+// mainnetHosts are never reached by sandboxed requests.
+var mainnetHosts = []string{hostOf(DefaultBaseURL), hostOf(solana.MainnetRPCURL)}
+
+type sandboxKey struct{}
+
+// WithSandbox configures a sandbox upstream. Sandboxed calls go to it with
+// the sandbox API key and HeaderEnvironment, creations of intents, webhooks
+// and keys are tagged as test data, and requests that would reach mainnet
+// (the production API or a mainnet RPC endpoint, or name a mainnet network)
+// are refused with ErrSandboxMainnet.
+func WithSandbox(config SandboxConfig) Option {
+	return func(c *Client) {
+		c.sandbox = &config
+	}
+}
+
+// Sandboxed returns a context whose calls go to the sandbox, for clients
+// configured with WithSandbox that otherwise call production.
+func Sandboxed(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sandboxKey{}, true)
+}
+
+// IsSandboxed reports whether ctx was returned by Sandboxed.
+func IsSandboxed(ctx context.Context) bool {
+	v, _ := ctx.Value(sandboxKey{}).(bool)
+	return v
+}
+
+// sandboxed reports whether calls made with ctx go to the sandbox.
+func (c *Client) sandboxed(ctx context.Context) bool {
+	return (c.sandbox != nil && c.sandbox.Always) || IsSandboxed(ctx)
+}
+
+// sandboxTarget returns the sandbox base URL and API key.
+func (c *Client) sandboxTarget() (*url.URL, string, error) {
+	if c.sandbox == nil || c.sandbox.URL == "" {
+		return nil, "", ErrNoSandbox
+	}
+	base, err := url.Parse(strings.TrimRight(c.sandbox.URL, "/"))
+	if err != nil {
+		return nil, "", ErrNoSandbox
+	}
+	key := c.sandbox.APIKey
+	if key == "" && c.sandbox.Always {
+		key = c.apiKey
+	}
+	if key == "" {
+		return nil, "", ErrNoSandboxKey
+	}
+	return base, key, nil
+}
+
+// tagTestData adds "test": true to the body of requests creating resources.
+func tagTestData(path string, body interface{}) interface{} {
+	tagged := false
+	for _, p := range taggedPaths {
+		if strings.TrimSuffix(path, "/") == p {
+			tagged = true
+		}
+	}
+	if !tagged {
+		return body
+	}
+	fields := map[string]interface{}{}
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil || json.Unmarshal(data, &fields) != nil {
+			return body
+		}
+	}
+	fields["test"] = true
+	return fields
+}
+
+// guardMainnet refuses sandboxed requests to mainnet. It runs after the
+// middleware, on the request as sent.
+func guardMainnet(next SendFunc) SendFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		host := strings.ToLower(req.URL.Hostname())
+		for _, h := range mainnetHosts {
+			if host == h {
+				return nil, ErrSandboxMainnet
+			}
+		}
+		if req.GetBody != nil {
+			if body, err := req.GetBody(); err == nil {
+				data, _ := io.ReadAll(body)
+				body.Close()
+				var fields struct {
+					Network string `json:"network"`
+				}
+				if json.Unmarshal(bytes.TrimSpace(data), &fields) == nil && strings.Contains(fields.Network, "mainnet") {
+					return nil, ErrSandboxMainnet
+				}
+			}
+		}
+		return next(req)
+	}
+}
+
+func hostOf(rawURL string) string {
+	u, _ := url.Parse(rawURL)
+	return strings.ToLower(u.Hostname())
+}
@@ -0,0 +1,491 @@
+// Package config loads the API server configuration from defaults, an
+// optional YAML file, an optional sealed secrets file and environment
+// variables, in increasing precedence.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"sol_privacy/internal/alerts"
+	"sol_privacy/internal/i18n"
+	"sol_privacy/internal/secrets"
+	"sol_privacy/internal/validate"
+)
+
+// Config is the API server configuration.
+type Config struct {
+	// Path is the file the configuration was loaded from, if any.
+	Path string
+
+	APIKey     string
+	ClientKeys []string // X-API-Key values of the main merchant, required with tenants
+	Server     ServerConfig
+	CORS       CORSConfig
+	Timeouts   TimeoutConfig
+	Umbra      UmbraConfig
+	RateLimit  RateLimitConfig
+	Storage    StorageConfig
+	TLS        TLSConfig
+	Signing    SigningConfig
+	Secrets    SecretsConfig
+	Alerts     AlertsConfig
+	Tenants    []TenantConfig
+}
+
+// ServerConfig sets where the server listens and its default language.
+type ServerConfig struct {
+	Port   string
+	Socket string // Unix socket path served instead of Port
+	Locale string // Default language of error messages, overridden by Accept-Language
+}
+
+// CORSConfig sets the cross-origin policy. Routes override it on the paths
+// they match.
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowCredentials bool
+	MaxAge           int // Seconds browsers may cache a preflight response
+	Routes           []CORSRoute
+}
+
+// TimeoutConfig bounds connections and requests.
+type TimeoutConfig struct {
+	ReadHeader time.Duration
+	Read       time.Duration
+	Write      time.Duration
+	Idle       time.Duration
+	Request    time.Duration // Per-request deadline of API handlers
+	Shutdown   time.Duration // Grace period for in-flight requests on shutdown
+}
+
+// UmbraConfig enables the Umbra stealth address integration.
+type UmbraConfig struct {
+	URL string
+}
+
+// RateLimitConfig limits requests per client IP. Zero disables limiting.
+type RateLimitConfig struct {
+	RequestsPerMinute int
+	Burst             int
+}
+
+// StorageConfig selects where stores persist data.
+type StorageConfig struct {
+	// DSN is "memory:" to keep data in memory, or "file:///dir" (or a plain
+	// directory) for JSON files. Per-store *_DB variables take precedence.
+	DSN string
+}
+
+// SecretsConfig points at a file of secrets sealed with the master key (see
+// package secrets). Its values stand in for unset environment variables.
+type SecretsConfig struct {
+	File   string
+	Values map[string]string // Decrypted, by environment variable name
+}
+
+// SigningConfig sets whether sensitive routes verify wallet request
+// signatures: off, optional (verified when present) or require.
+type SigningConfig struct {
+	Mode   string
+	MaxAge time.Duration // How far a signature's timestamp may be from now
+}
+
+// TLSConfig enables HTTPS with a certificate from files or from an ACME
+// authority, and optionally requires client certificates (mutual TLS).
+// Certificate files and client CAs are re-read on reload.
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+	ACME     ACMEConfig
+
+	ClientCAFile string // PEM bundle of CAs that sign client certificates
+	ClientAuth   string // require (default with a client CA) or optional
+}
+
+// ACMEConfig obtains the certificate from an ACME authority such as Let's
+// Encrypt. The authority must reach HTTPPort on every domain.
+type ACMEConfig struct {
+	Domains   []string
+	Email     string
+	CacheDir  string // Keeps the account key and certificate across restarts
+	Directory string // Directory URL; Let's Encrypt if empty
+	HTTPPort  string // Answers http-01 challenges and redirects to HTTPS
+}
+
+// AlertsConfig posts operational alerts to Slack and Discord incoming
+// webhooks (see package alerts).
+type AlertsConfig struct {
+	Slack   AlertChannelConfig
+	Discord AlertChannelConfig
+
+	WebhookFailureThreshold int           // Failed webhook deliveries per interval that alert
+	AuthorizationWallets    []string      // Wallets whose authorizations are checked
+	AuthorizationUsage      int           // Percent of a daily limit spent that alerts
+	Interval                time.Duration // How often deliveries and authorizations are checked
+}
+
+// AlertChannelConfig configures one alert channel. It is enabled by setting
+// WebhookURL.
+type AlertChannelConfig struct {
+	WebhookURL string
+	Alerts     []string // Kinds of alert posted; all if empty
+	Templates  string   // JSON file of {"title", "text"} templates by kind
+}
+
+// Enabled reports whether the server should serve HTTPS.
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != "" || t.ACME.Enabled()
+}
+
+// Enabled reports whether certificates come from an ACME authority.
+func (a ACMEConfig) Enabled() bool {
+	return len(a.Domains) > 0
+}
+
+// RequireClientCert reports whether connections without a verified client
+// certificate are refused.
+func (t TLSConfig) RequireClientCert() bool {
+	return t.ClientCAFile != "" && t.ClientAuth != "optional"
+}
+
+// Default returns the configuration used when nothing is set.
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{Port: "8080", Locale: string(i18n.English)},
+		CORS: CORSConfig{
+			AllowedOrigins:   []string{"http://localhost:*", "http://127.0.0.1:*"},
+			AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+			AllowCredentials: true,
+			MaxAge:           300,
+		},
+		Timeouts: TimeoutConfig{
+			ReadHeader: 10 * time.Second,
+			Read:       30 * time.Second,
+			Write:      90 * time.Second,
+			Idle:       120 * time.Second,
+			Request:    60 * time.Second,
+			Shutdown:   15 * time.Second,
+		},
+		Storage: StorageConfig{DSN: "memory:"},
+		TLS:     TLSConfig{ACME: ACMEConfig{HTTPPort: "80"}},
+		Signing: SigningConfig{Mode: "off", MaxAge: 5 * time.Minute},
+		Alerts: AlertsConfig{
+			WebhookFailureThreshold: alerts.DefaultWebhookFailureThreshold,
+			AuthorizationUsage:      100,
+			Interval:                alerts.DefaultInterval,
+		},
+	}
+}
+
+// Load builds the configuration from defaults, the YAML file at path (if
+// path is not empty), the secrets file it names and environment variables,
+// then validates it.
+func Load(path string) (*Config, error) {
+	c := Default()
+	c.Path = path
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("config: %w", err)
+		}
+		values, err := parseYAML(data)
+		if err != nil {
+			return nil, fmt.Errorf("config: %s: %w", path, err)
+		}
+		if err := c.apply(values); err != nil {
+			return nil, fmt.Errorf("config: %s: %w", path, err)
+		}
+	}
+
+	if file := os.Getenv("SECRETS_FILE"); file != "" {
+		c.Secrets.File = file
+	}
+	if c.Secrets.File != "" {
+		values, err := secrets.Load(c.Secrets.File)
+		if err != nil {
+			return nil, fmt.Errorf("config: %s: %w", c.Secrets.File, err)
+		}
+		c.Secrets.Values = values
+	}
+
+	env := make(map[string][]string)
+	for _, f := range fields {
+		if v := c.Env(f.env); v != "" {
+			if f.list {
+				env[f.key] = splitList(v)
+			} else {
+				env[f.key] = []string{v}
+			}
+		}
+	}
+	if err := c.apply(env); err != nil {
+		return nil, fmt.Errorf("config: environment: %w", err)
+	}
+	c.loadTenantKeys()
+
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	return c, nil
+}
+
+// apply sets the fields named by values' keys.
+func (c *Config) apply(values map[string][]string) error {
+	v := validate.New()
+	byKey := make(map[string]field, len(fields))
+	for _, f := range fields {
+		byKey[f.key] = f
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if strings.HasPrefix(k, corsRoutesKey) {
+			if err := c.setCORSRoute(k, values[k]); err != nil {
+				v.Add(k, err)
+			}
+			continue
+		}
+		if strings.HasPrefix(k, tenantsKey) {
+			if err := c.setTenant(k, values[k]); err != nil {
+				v.Add(k, err)
+			}
+			continue
+		}
+		f, ok := byKey[k]
+		if !ok {
+			v.Add(k, errors.New("is not a known setting"))
+			continue
+		}
+		if !f.list && len(values[k]) > 1 {
+			v.Add(k, errors.New("must be a single value"))
+			continue
+		}
+		if err := f.set(c, values[k]); err != nil {
+			v.Add(k, err)
+		}
+	}
+	return v.Err()
+}
+
+// Validate checks the configuration is usable.
+func (c *Config) Validate() error {
+	v := validate.New().Required("api_key", c.APIKey)
+
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		v.Add("server.port", errors.New("must be a port number"))
+	}
+	if c.Server.Locale != "" && i18n.Parse(c.Server.Locale) == i18n.English && !strings.HasPrefix(strings.ToLower(c.Server.Locale), "en") {
+		v.Add("server.locale", errors.New("must be en, es or zh"))
+	}
+
+	c.validateCORS(v)
+
+	for _, d := range []struct {
+		key string
+		d   time.Duration
+	}{
+		{"timeouts.read_header", c.Timeouts.ReadHeader},
+		{"timeouts.read", c.Timeouts.Read},
+		{"timeouts.write", c.Timeouts.Write},
+		{"timeouts.idle", c.Timeouts.Idle},
+		{"timeouts.request", c.Timeouts.Request},
+		{"timeouts.shutdown", c.Timeouts.Shutdown},
+	} {
+		if d.d <= 0 {
+			v.Add(d.key, errors.New("must be positive"))
+		}
+	}
+	if c.Timeouts.Write > 0 && c.Timeouts.Request > c.Timeouts.Write {
+		v.Add("timeouts.request", errors.New("must not exceed timeouts.write"))
+	}
+
+	if c.Umbra.URL != "" {
+		v.URL("umbra.url", c.Umbra.URL, false)
+	}
+
+	if c.RateLimit.RequestsPerMinute < 0 {
+		v.Add("rate_limit.requests_per_minute", errors.New("must not be negative"))
+	}
+	if c.RateLimit.Burst < 0 {
+		v.Add("rate_limit.burst", errors.New("must not be negative"))
+	}
+
+	if _, err := c.Storage.Dir(); err != nil {
+		v.Add("storage.dsn", err)
+	}
+
+	switch c.Signing.Mode {
+	case "off", "optional", "require":
+	default:
+		v.Add("signing.mode", errors.New("must be off, optional or require"))
+	}
+	if c.Signing.MaxAge <= 0 {
+		v.Add("signing.max_age", errors.New("must be positive"))
+	}
+
+	c.validateTLS(v)
+	c.validateAlerts(v)
+	c.validateTenants(v)
+	return v.Err()
+}
+
+func (c *Config) validateAlerts(v *validate.Validator) {
+	a := c.Alerts
+	for _, ch := range []struct {
+		key string
+		c   AlertChannelConfig
+	}{
+		{"alerts.slack", a.Slack},
+		{"alerts.discord", a.Discord},
+	} {
+		if ch.c.WebhookURL != "" {
+			v.URL(ch.key+".webhook_url", ch.c.WebhookURL, true)
+		}
+		for _, kind := range ch.c.Alerts {
+			if !slices.Contains(alerts.Kinds, kind) {
+				v.Add(ch.key+".alerts", fmt.Errorf("%q must be one of %s", kind, strings.Join(alerts.Kinds, ", ")))
+			}
+		}
+		if ch.c.Templates != "" {
+			if _, err := os.Stat(ch.c.Templates); err != nil {
+				v.Add(ch.key+".templates", errors.New("file not readable"))
+			}
+		}
+	}
+	if a.WebhookFailureThreshold < 1 {
+		v.Add("alerts.webhook_failure_threshold", errors.New("must be positive"))
+	}
+	for _, w := range a.AuthorizationWallets {
+		v.Address("alerts.authorization_wallets", w)
+	}
+	if a.AuthorizationUsage < 1 || a.AuthorizationUsage > 100 {
+		v.Add("alerts.authorization_usage", errors.New("must be a percentage from 1 to 100"))
+	}
+	if a.Interval <= 0 {
+		v.Add("alerts.interval", errors.New("must be positive"))
+	}
+}
+
+func (c *Config) validateTLS(v *validate.Validator) {
+	t := c.TLS
+	switch {
+	case t.ACME.Enabled():
+		if t.CertFile != "" || t.KeyFile != "" {
+			v.Add("tls.acme", errors.New("cannot be used with cert_file and key_file"))
+		}
+		for _, d := range t.ACME.Domains {
+			if d == "" || strings.ContainsAny(d, "*:/ ") {
+				v.Add("tls.acme.domains", fmt.Errorf("%q is not a host name", d))
+			}
+		}
+		v.Required("tls.acme.cache_dir", t.ACME.CacheDir)
+		if t.ACME.Directory != "" {
+			v.URL("tls.acme.directory", t.ACME.Directory, true)
+		}
+		if port, err := strconv.Atoi(t.ACME.HTTPPort); err != nil || port < 1 || port > 65535 {
+			v.Add("tls.acme.http_port", errors.New("must be a port number"))
+		} else if t.ACME.HTTPPort == c.Server.Port {
+			v.Add("tls.acme.http_port", errors.New("must differ from server.port"))
+		}
+	case t.CertFile != "" || t.KeyFile != "":
+		if t.CertFile == "" || t.KeyFile == "" {
+			v.Add("tls", errors.New("cert_file and key_file must be set together"))
+		}
+	}
+	for _, f := range []struct{ key, path string }{
+		{"tls.cert_file", t.CertFile},
+		{"tls.key_file", t.KeyFile},
+		{"tls.client_ca_file", t.ClientCAFile},
+	} {
+		if f.path == "" {
+			continue
+		}
+		if _, err := os.Stat(f.path); err != nil {
+			v.Add(f.key, errors.New("file not readable"))
+		}
+	}
+
+	switch t.ClientAuth {
+	case "", "require", "optional":
+	default:
+		v.Add("tls.client_auth", errors.New("must be require or optional"))
+	}
+	if t.ClientAuth != "" && t.ClientCAFile == "" {
+		v.Add("tls.client_auth", errors.New("needs tls.client_ca_file"))
+	}
+	if t.ClientCAFile != "" && !t.Enabled() {
+		v.Add("tls.client_ca_file", errors.New("needs a certificate or tls.acme"))
+	}
+}
+
+// Dir returns the directory stores persist to, or "" to keep data in memory.
+func (s StorageConfig) Dir() (string, error) {
+	switch {
+	case s.DSN == "" || s.DSN == "memory:" || s.DSN == "memory://":
+		return "", nil
+	case strings.HasPrefix(s.DSN, "file:"):
+		u, err := url.Parse(s.DSN)
+		if err != nil {
+			return "", err
+		}
+		dir := u.Path
+		if dir == "" {
+			dir = u.Opaque
+		}
+		if dir == "" {
+			return "", errors.New("file DSN needs a directory")
+		}
+		return dir, nil
+	case strings.Contains(s.DSN, "://"):
+		return "", errors.New("must be memory: or file:///dir")
+	}
+	return s.DSN, nil
+}
+
+// Env returns the environment variable name, or its sealed value if unset.
+func (c *Config) Env(name string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return c.Secrets.Values[name]
+}
+
+// Changed lists the settings that differ between c and next, by key.
+func (c *Config) Changed(next *Config) []string {
+	var changed []string
+	for _, f := range fields {
+		if f.get(c) != f.get(next) {
+			changed = append(changed, f.key)
+		}
+	}
+	if !slices.EqualFunc(c.CORS.Routes, next.CORS.Routes, func(a, b CORSRoute) bool { return a.String() == b.String() }) {
+		changed = append(changed, "cors.routes")
+	}
+	if !slices.EqualFunc(c.Tenants, next.Tenants, func(a, b TenantConfig) bool { return a.String() == b.String() }) {
+		changed = append(changed, "tenants")
+	}
+	return changed
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"sol_privacy/internal/validate"
+)
+
+// CORSRoute overrides the cross-origin policy on the paths it matches: an
+// exact path such as "/health", or a prefix ending in "/*" such as
+// "/api/payment/*". The most specific route wins. Unset fields keep the
+// global setting.
+//
+// Routes are set in the file only, keyed by path:
+//
+//	cors:
+//	  routes:
+//	    /api/payment/*:
+//	      allowed_origins: [https://shop.example.com]
+//	      allowed_methods: [POST]
+//	      allow_credentials: false
+type CORSRoute struct {
+	Path             string
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowCredentials *bool
+}
+
+const corsRoutesKey = "cors.routes."
+
+// corsMethods are the methods the API serves.
+var corsMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+
+// setCORSRoute applies a "cors.routes.<path>.<setting>" key.
+func (c *Config) setCORSRoute(key string, values []string) error {
+	rest := strings.TrimPrefix(key, corsRoutesKey)
+	dot := strings.LastIndex(rest, ".")
+	if dot <= 0 {
+		return errors.New("must be cors.routes.<path>.<setting>")
+	}
+	path, setting := rest[:dot], rest[dot+1:]
+
+	i := slices.IndexFunc(c.CORS.Routes, func(r CORSRoute) bool { return r.Path == path })
+	if i < 0 {
+		c.CORS.Routes = append(c.CORS.Routes, CORSRoute{Path: path})
+		i = len(c.CORS.Routes) - 1
+	}
+	route := &c.CORS.Routes[i]
+
+	switch setting {
+	case "allowed_origins":
+		route.AllowedOrigins = append([]string(nil), values...)
+	case "allowed_methods":
+		route.AllowedMethods = append([]string(nil), values...)
+	case "allow_credentials":
+		if len(values) > 1 {
+			return errors.New("must be a single value")
+		}
+		b, err := strconv.ParseBool(first(values))
+		if err != nil {
+			return errors.New("must be true or false")
+		}
+		route.AllowCredentials = &b
+	default:
+		return errors.New("is not a known setting")
+	}
+	return nil
+}
+
+// Origins returns the origins allowed on the route.
+func (r CORSRoute) Origins(global CORSConfig) []string {
+	if r.AllowedOrigins != nil {
+		return r.AllowedOrigins
+	}
+	return global.AllowedOrigins
+}
+
+// Methods returns the methods allowed on the route.
+func (r CORSRoute) Methods(global CORSConfig) []string {
+	if r.AllowedMethods != nil {
+		return r.AllowedMethods
+	}
+	return global.AllowedMethods
+}
+
+// Credentials reports whether the route allows credentials.
+func (r CORSRoute) Credentials(global CORSConfig) bool {
+	if r.AllowCredentials != nil {
+		return *r.AllowCredentials
+	}
+	return global.AllowCredentials
+}
+
+func (r CORSRoute) String() string {
+	s := fmt.Sprintf("%s origins=%s methods=%s", r.Path, strings.Join(r.AllowedOrigins, ","), strings.Join(r.AllowedMethods, ","))
+	if r.AllowCredentials != nil {
+		s += " credentials=" + strconv.FormatBool(*r.AllowCredentials)
+	}
+	return s
+}
+
+func (c *Config) validateCORS(v *validate.Validator) {
+	validateCORSPolicy(v, "cors", c.CORS.AllowedOrigins, c.CORS.AllowedMethods, c.CORS.AllowCredentials)
+	if c.CORS.MaxAge < 0 {
+		v.Add("cors.max_age", errors.New("must not be negative"))
+	}
+
+	for _, r := range c.CORS.Routes {
+		key := corsRoutesKey + r.Path
+		prefix := strings.TrimSuffix(r.Path, "/*")
+		if !strings.HasPrefix(r.Path, "/") || strings.Contains(prefix, "*") {
+			v.Add(key, errors.New("must be a path, or a prefix ending in /*"))
+		}
+		validateCORSPolicy(v, key, r.AllowedOrigins, r.AllowedMethods, r.Credentials(c.CORS))
+		// Inherited origins were checked with the global credentials setting
+		if r.AllowedOrigins == nil && r.Credentials(c.CORS) && !c.CORS.AllowCredentials && slices.ContainsFunc(c.CORS.AllowedOrigins, anyOrigin) {
+			v.Add(key+".allow_credentials", errors.New("must be false while cors.allowed_origins allows every origin"))
+		}
+	}
+}
+
+// validateCORSPolicy checks one policy. Browsers send cookies and client
+// certificates to any origin a credentialed policy allows, so a policy that
+// allows credentials must list its origins instead of allowing all of them.
+func validateCORSPolicy(v *validate.Validator, key string, origins, methods []string, credentials bool) {
+	for _, o := range origins {
+		if o != "*" && !strings.Contains(o, "://") {
+			v.Add(key+".allowed_origins", fmt.Errorf("%q must include a scheme", o))
+		}
+		if credentials && anyOrigin(o) {
+			v.Add(key+".allowed_origins", fmt.Errorf("%q allows every origin, which needs allow_credentials: false", o))
+		}
+	}
+	for _, m := range methods {
+		if !slices.Contains(corsMethods, m) {
+			v.Add(key+".allowed_methods", fmt.Errorf("%q must be one of %s", m, strings.Join(corsMethods, ", ")))
+		}
+	}
+}
+
+// anyOrigin reports whether an origin pattern matches every host, such as
+// "*" or "https://*".
+func anyOrigin(o string) bool {
+	if o == "*" {
+		return true
+	}
+	_, host, ok := strings.Cut(o, "://")
+	return ok && strings.HasPrefix(host, "*") && !strings.Contains(host, ".")
+}
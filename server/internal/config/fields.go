@@ -0,0 +1,133 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// field is a setting with its YAML key and environment variable.
+type field struct {
+	key  string
+	env  string
+	list bool
+	set  func(c *Config, values []string) error
+	get  func(c *Config) string // For change detection
+}
+
+// fields lists every setting. Secrets such as the API key may be given in the
+// file but are best left to the environment.
+var fields = []field{
+	stringField("api_key", "SHADOWPAY_API_KEY", func(c *Config) *string { return &c.APIKey }),
+	listField("client_keys", "CLIENT_KEYS", func(c *Config) *[]string { return &c.ClientKeys }),
+	stringField("server.port", "PORT", func(c *Config) *string { return &c.Server.Port }),
+	stringField("server.socket", "UNIX_SOCKET", func(c *Config) *string { return &c.Server.Socket }),
+	stringField("server.locale", "DEFAULT_LOCALE", func(c *Config) *string { return &c.Server.Locale }),
+	listField("cors.allowed_origins", "CORS_ALLOWED_ORIGINS", func(c *Config) *[]string { return &c.CORS.AllowedOrigins }),
+	listField("cors.allowed_methods", "CORS_ALLOWED_METHODS", func(c *Config) *[]string { return &c.CORS.AllowedMethods }),
+	boolField("cors.allow_credentials", "CORS_ALLOW_CREDENTIALS", func(c *Config) *bool { return &c.CORS.AllowCredentials }),
+	intField("cors.max_age", "CORS_MAX_AGE", func(c *Config) *int { return &c.CORS.MaxAge }),
+	durationField("timeouts.read_header", "TIMEOUT_READ_HEADER", func(c *Config) *time.Duration { return &c.Timeouts.ReadHeader }),
+	durationField("timeouts.read", "TIMEOUT_READ", func(c *Config) *time.Duration { return &c.Timeouts.Read }),
+	durationField("timeouts.write", "TIMEOUT_WRITE", func(c *Config) *time.Duration { return &c.Timeouts.Write }),
+	durationField("timeouts.idle", "TIMEOUT_IDLE", func(c *Config) *time.Duration { return &c.Timeouts.Idle }),
+	durationField("timeouts.request", "TIMEOUT_REQUEST", func(c *Config) *time.Duration { return &c.Timeouts.Request }),
+	durationField("timeouts.shutdown", "TIMEOUT_SHUTDOWN", func(c *Config) *time.Duration { return &c.Timeouts.Shutdown }),
+	stringField("umbra.url", "UMBRA_API_URL", func(c *Config) *string { return &c.Umbra.URL }),
+	intField("rate_limit.requests_per_minute", "RATE_LIMIT_RPM", func(c *Config) *int { return &c.RateLimit.RequestsPerMinute }),
+	intField("rate_limit.burst", "RATE_LIMIT_BURST", func(c *Config) *int { return &c.RateLimit.Burst }),
+	stringField("storage.dsn", "STORAGE_DSN", func(c *Config) *string { return &c.Storage.DSN }),
+	stringField("secrets.file", "SECRETS_FILE", func(c *Config) *string { return &c.Secrets.File }),
+	stringField("signing.mode", "REQUEST_SIGNING", func(c *Config) *string { return &c.Signing.Mode }),
+	durationField("signing.max_age", "REQUEST_SIGNING_MAX_AGE", func(c *Config) *time.Duration { return &c.Signing.MaxAge }),
+	stringField("tls.cert_file", "TLS_CERT_FILE", func(c *Config) *string { return &c.TLS.CertFile }),
+	stringField("tls.key_file", "TLS_KEY_FILE", func(c *Config) *string { return &c.TLS.KeyFile }),
+	stringField("tls.client_ca_file", "TLS_CLIENT_CA_FILE", func(c *Config) *string { return &c.TLS.ClientCAFile }),
+	stringField("tls.client_auth", "TLS_CLIENT_AUTH", func(c *Config) *string { return &c.TLS.ClientAuth }),
+	listField("tls.acme.domains", "ACME_DOMAINS", func(c *Config) *[]string { return &c.TLS.ACME.Domains }),
+	stringField("tls.acme.email", "ACME_EMAIL", func(c *Config) *string { return &c.TLS.ACME.Email }),
+	stringField("tls.acme.cache_dir", "ACME_CACHE_DIR", func(c *Config) *string { return &c.TLS.ACME.CacheDir }),
+	stringField("tls.acme.directory", "ACME_DIRECTORY", func(c *Config) *string { return &c.TLS.ACME.Directory }),
+	stringField("tls.acme.http_port", "ACME_HTTP_PORT", func(c *Config) *string { return &c.TLS.ACME.HTTPPort }),
+	stringField("alerts.slack.webhook_url", "ALERTS_SLACK_WEBHOOK_URL", func(c *Config) *string { return &c.Alerts.Slack.WebhookURL }),
+	listField("alerts.slack.alerts", "ALERTS_SLACK_TYPES", func(c *Config) *[]string { return &c.Alerts.Slack.Alerts }),
+	stringField("alerts.slack.templates", "ALERTS_SLACK_TEMPLATES", func(c *Config) *string { return &c.Alerts.Slack.Templates }),
+	stringField("alerts.discord.webhook_url", "ALERTS_DISCORD_WEBHOOK_URL", func(c *Config) *string { return &c.Alerts.Discord.WebhookURL }),
+	listField("alerts.discord.alerts", "ALERTS_DISCORD_TYPES", func(c *Config) *[]string { return &c.Alerts.Discord.Alerts }),
+	stringField("alerts.discord.templates", "ALERTS_DISCORD_TEMPLATES", func(c *Config) *string { return &c.Alerts.Discord.Templates }),
+	intField("alerts.webhook_failure_threshold", "ALERTS_WEBHOOK_FAILURE_THRESHOLD", func(c *Config) *int { return &c.Alerts.WebhookFailureThreshold }),
+	listField("alerts.authorization_wallets", "ALERTS_AUTHORIZATION_WALLETS", func(c *Config) *[]string { return &c.Alerts.AuthorizationWallets }),
+	intField("alerts.authorization_usage", "ALERTS_AUTHORIZATION_USAGE", func(c *Config) *int { return &c.Alerts.AuthorizationUsage }),
+	durationField("alerts.interval", "ALERTS_INTERVAL", func(c *Config) *time.Duration { return &c.Alerts.Interval }),
+}
+
+func stringField(key, env string, p func(*Config) *string) field {
+	return field{key: key, env: env,
+		set: func(c *Config, v []string) error {
+			*p(c) = first(v)
+			return nil
+		},
+		get: func(c *Config) string { return *p(c) },
+	}
+}
+
+func listField(key, env string, p func(*Config) *[]string) field {
+	return field{key: key, env: env, list: true,
+		set: func(c *Config, v []string) error {
+			*p(c) = append([]string(nil), v...)
+			return nil
+		},
+		get: func(c *Config) string { return strings.Join(*p(c), ",") },
+	}
+}
+
+func boolField(key, env string, p func(*Config) *bool) field {
+	return field{key: key, env: env,
+		set: func(c *Config, v []string) error {
+			b, err := strconv.ParseBool(first(v))
+			if err != nil {
+				return errors.New("must be true or false")
+			}
+			*p(c) = b
+			return nil
+		},
+		get: func(c *Config) string { return strconv.FormatBool(*p(c)) },
+	}
+}
+
+func intField(key, env string, p func(*Config) *int) field {
+	return field{key: key, env: env,
+		set: func(c *Config, v []string) error {
+			n, err := strconv.Atoi(first(v))
+			if err != nil {
+				return errors.New("must be an integer")
+			}
+			*p(c) = n
+			return nil
+		},
+		get: func(c *Config) string { return strconv.Itoa(*p(c)) },
+	}
+}
+
+func durationField(key, env string, p func(*Config) *time.Duration) field {
+	return field{key: key, env: env,
+		set: func(c *Config, v []string) error {
+			d, err := time.ParseDuration(first(v))
+			if err != nil {
+				return fmt.Errorf("must be a duration such as 30s")
+			}
+			*p(c) = d
+			return nil
+		},
+		get: func(c *Config) string { return p(c).String() },
+	}
+}
+
+func first(v []string) string {
+	if len(v) == 0 {
+		return ""
+	}
+	return v[0]
+}
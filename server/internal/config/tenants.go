@@ -0,0 +1,193 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"sol_privacy/internal/validate"
+)
+
+// TenantConfig lets one proxy serve another merchant. Requests whose
+// X-API-Key header is one of the tenant's client keys are served with its
+// own ShadowPay API key, stores, caches, event deliveries and rate limit.
+// Once a tenant is configured, the main merchant needs client keys too
+// (client_keys, or CLIENT_KEYS), and requests without a known key are
+// refused, except the hosted checkout pages buyers open.
+//
+// Tenants are set in the file only, keyed by ID. Their keys are best left
+// to the environment or the secrets file, as TENANT_<ID>_API_KEY and
+// TENANT_<ID>_CLIENT_KEYS (the ID upper-cased, with "-" as "_"):
+//
+//	tenants:
+//	  acme:
+//	    rate_limit:
+//	      requests_per_minute: 300
+//	    env:
+//	      EVENTS_WEBHOOK_URL: https://hooks.acme.example/shadowpay
+type TenantConfig struct {
+	ID         string
+	APIKey     string   // Upstream ShadowPay API key
+	ClientKeys []string // X-API-Key values that identify the tenant
+
+	// RequestsPerMinute and Burst override the global rate limit when set
+	RequestsPerMinute *int
+	Burst             *int
+
+	// Env holds settings of the tenant's handler by environment variable
+	// name, such as EVENTS_WEBHOOK_URL.
+	Env map[string]string
+}
+
+const tenantsKey = "tenants."
+
+var (
+	tenantID = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	envName  = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+)
+
+// setTenant applies a "tenants.<id>.<setting>" key.
+func (c *Config) setTenant(key string, values []string) error {
+	id, setting, ok := strings.Cut(strings.TrimPrefix(key, tenantsKey), ".")
+	if !ok || id == "" {
+		return errors.New("must be tenants.<id>.<setting>")
+	}
+
+	i := slices.IndexFunc(c.Tenants, func(t TenantConfig) bool { return t.ID == id })
+	if i < 0 {
+		c.Tenants = append(c.Tenants, TenantConfig{ID: id})
+		i = len(c.Tenants) - 1
+	}
+	t := &c.Tenants[i]
+
+	if name, ok := strings.CutPrefix(setting, "env."); ok {
+		if len(values) > 1 {
+			return errors.New("must be a single value")
+		}
+		if t.Env == nil {
+			t.Env = make(map[string]string)
+		}
+		t.Env[name] = first(values)
+		return nil
+	}
+	switch setting {
+	case "api_key":
+		if len(values) > 1 {
+			return errors.New("must be a single value")
+		}
+		t.APIKey = first(values)
+	case "client_keys":
+		t.ClientKeys = append([]string(nil), values...)
+	case "rate_limit.requests_per_minute", "rate_limit.burst":
+		if len(values) > 1 {
+			return errors.New("must be a single value")
+		}
+		n, err := strconv.Atoi(first(values))
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		if setting == "rate_limit.burst" {
+			t.Burst = &n
+		} else {
+			t.RequestsPerMinute = &n
+		}
+	default:
+		return errors.New("is not a known setting")
+	}
+	return nil
+}
+
+// loadTenantKeys fills in keys left out of the file from the environment.
+func (c *Config) loadTenantKeys() {
+	for i := range c.Tenants {
+		t := &c.Tenants[i]
+		prefix := "TENANT_" + strings.ToUpper(strings.ReplaceAll(t.ID, "-", "_")) + "_"
+		if v := c.Env(prefix + "API_KEY"); v != "" {
+			t.APIKey = v
+		}
+		if v := c.Env(prefix + "CLIENT_KEYS"); v != "" {
+			t.ClientKeys = splitList(v)
+		}
+	}
+}
+
+// RateLimit returns the tenant's rate limit.
+func (t TenantConfig) RateLimit(global RateLimitConfig) RateLimitConfig {
+	if t.RequestsPerMinute != nil {
+		global.RequestsPerMinute = *t.RequestsPerMinute
+	}
+	if t.Burst != nil {
+		global.Burst = *t.Burst
+	}
+	return global
+}
+
+// String describes the tenant for change detection, with its keys hashed.
+func (t TenantConfig) String() string {
+	s := fmt.Sprintf("%s api_key=%s client_keys=%s", t.ID, fingerprint(t.APIKey), fingerprint(strings.Join(t.ClientKeys, ",")))
+	if t.RequestsPerMinute != nil {
+		s += " requests_per_minute=" + strconv.Itoa(*t.RequestsPerMinute)
+	}
+	if t.Burst != nil {
+		s += " burst=" + strconv.Itoa(*t.Burst)
+	}
+	for _, name := range slices.Sorted(maps.Keys(t.Env)) {
+		s += " " + name + "=" + t.Env[name]
+	}
+	return s
+}
+
+func fingerprint(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:4])
+}
+
+func (c *Config) validateTenants(v *validate.Validator) {
+	if len(c.Tenants) == 0 {
+		return
+	}
+	if len(c.ClientKeys) == 0 {
+		v.Add("client_keys", errors.New("is required when tenants are configured"))
+	}
+	owner := make(map[string]string)
+	for _, k := range c.ClientKeys {
+		owner[k] = ""
+	}
+	for _, t := range c.Tenants {
+		key := tenantsKey + t.ID
+		if !tenantID.MatchString(t.ID) {
+			v.Add(key, errors.New("ID must be lower-case letters, digits, - and _"))
+		}
+		v.Required(key+".api_key", t.APIKey)
+		if len(t.ClientKeys) == 0 {
+			v.Add(key+".client_keys", errors.New("is required"))
+		}
+		for _, k := range t.ClientKeys {
+			if other, ok := owner[k]; ok && other == "" {
+				v.Add(key+".client_keys", errors.New("must not share a key with client_keys"))
+			} else if ok && other != t.ID {
+				v.Add(key+".client_keys", fmt.Errorf("must not share a key with tenants.%s", other))
+			}
+			owner[k] = t.ID
+		}
+		if t.RequestsPerMinute != nil && *t.RequestsPerMinute < 0 {
+			v.Add(key+".rate_limit.requests_per_minute", errors.New("must not be negative"))
+		}
+		if t.Burst != nil && *t.Burst < 0 {
+			v.Add(key+".rate_limit.burst", errors.New("must not be negative"))
+		}
+		for _, name := range slices.Sorted(maps.Keys(t.Env)) {
+			if !envName.MatchString(name) {
+				v.Add(key+".env."+name, errors.New("must be an environment variable name"))
+			} else if strings.HasSuffix(name, "_WEBHOOK_URL") && t.Env[name] != "" {
+				v.URL(key+".env."+name, t.Env[name], true)
+			}
+		}
+	}
+}
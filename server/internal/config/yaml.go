@@ -0,0 +1,148 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseYAML reads the subset of YAML used by config files: nested mappings,
+// block and flow ([a, b]) sequences of scalars, comments and quoted strings.
+// It returns the values of every scalar or sequence keyed by dotted path, e.g.
+// "cors.allowed_origins".
+func parseYAML(data []byte) (map[string][]string, error) {
+	type parent struct {
+		indent int
+		path   string
+	}
+	values := make(map[string][]string)
+	stack := []parent{{indent: -1}}
+
+	for n, line := range strings.Split(string(data), "\n") {
+		lineNo := n + 1
+		line = strings.TrimRight(stripComment(line), " \r")
+		if strings.TrimSpace(line) == "" || line == "---" {
+			continue
+		}
+		content := strings.TrimLeft(line, " ")
+		if strings.HasPrefix(content, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", lineNo)
+		}
+		indent := len(line) - len(content)
+
+		// Sequence item of the closest key above it
+		if item, ok := strings.CutPrefix(content, "-"); ok && (item == "" || item[0] == ' ') {
+			for len(stack) > 1 && stack[len(stack)-1].indent > indent {
+				stack = stack[:len(stack)-1]
+			}
+			top := stack[len(stack)-1]
+			if top.path == "" {
+				return nil, fmt.Errorf("line %d: sequence item outside a key", lineNo)
+			}
+			v, err := unquote(strings.TrimSpace(item))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			values[top.path] = append(values[top.path], v)
+			continue
+		}
+
+		key, value, ok := strings.Cut(content, ":")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", lineNo)
+		}
+		for len(stack) > 1 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		path := strings.TrimSpace(key)
+		if prefix := stack[len(stack)-1].path; prefix != "" {
+			path = prefix + "." + path
+		}
+		if _, dup := values[path]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", lineNo, path)
+		}
+
+		value = strings.TrimSpace(value)
+		switch {
+		case value == "":
+			// Mapping or block sequence follows
+			values[path] = nil
+			stack = append(stack, parent{indent: indent, path: path})
+		case strings.HasPrefix(value, "["):
+			if !strings.HasSuffix(value, "]") {
+				return nil, fmt.Errorf("line %d: unterminated sequence", lineNo)
+			}
+			items := []string{}
+			if inner := strings.TrimSpace(value[1 : len(value)-1]); inner != "" {
+				for _, item := range strings.Split(inner, ",") {
+					v, err := unquote(strings.TrimSpace(item))
+					if err != nil {
+						return nil, fmt.Errorf("line %d: %w", lineNo, err)
+					}
+					items = append(items, v)
+				}
+			}
+			values[path] = items
+		default:
+			v, err := unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			values[path] = []string{v}
+		}
+	}
+
+	// Keys that only introduced a mapping carry no value themselves
+	for path, v := range values {
+		if v == nil && hasChildren(values, path) {
+			delete(values, path)
+		}
+	}
+	return values, nil
+}
+
+func hasChildren(values map[string][]string, path string) bool {
+	for p := range values {
+		if strings.HasPrefix(p, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// stripComment removes a trailing "# comment" outside of quotes.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' {
+				i++
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' '):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// unquote returns a scalar without its single or double quotes.
+func unquote(s string) (string, error) {
+	switch {
+	case len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"':
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return "", fmt.Errorf("invalid quoted string %s", s)
+		}
+		return v, nil
+	case len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'':
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	case s == "~" || s == "null":
+		return "", nil
+	}
+	return s, nil
+}
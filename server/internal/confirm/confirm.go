@@ -0,0 +1,163 @@
+// Package confirm tracks submitted transactions until they are confirmed,
+// finalized or expire because their blockhash aged out.
+package confirm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sol_privacy/internal/solana"
+)
+
+// Tracking states.
+const (
+	StatusPending   = "pending"
+	StatusConfirmed = "confirmed"
+	StatusFinalized = "finalized"
+	StatusExpired   = "expired"
+	StatusFailed    = "failed"
+)
+
+// DefaultPollInterval is how often the tracker polls the cluster.
+const DefaultPollInterval = 2 * time.Second
+
+// Result is the outcome of tracking a signature.
+type Result struct {
+	Signature string `json:"signature"`
+	Status    string `json:"status"`
+	Slot      uint64 `json:"slot,omitempty"`
+	Error     string `json:"error,omitempty"` // On-chain execution error, if failed
+}
+
+// Callbacks are invoked as a tracked transaction changes state. Any may be nil.
+type Callbacks struct {
+	OnConfirmed func(Result)
+	OnFinalized func(Result)
+	OnExpired   func(Result)
+	OnFailed    func(Result)
+}
+
+// Tracker watches signatures against a Solana RPC node.
+type Tracker struct {
+	rpc          *solana.Client
+	pollInterval time.Duration
+}
+
+// NewTracker creates a tracker. A zero pollInterval uses DefaultPollInterval.
+func NewTracker(rpc *solana.Client, pollInterval time.Duration) *Tracker {
+	if pollInterval == 0 {
+		pollInterval = DefaultPollInterval
+	}
+	return &Tracker{rpc: rpc, pollInterval: pollInterval}
+}
+
+// Track blocks until the signature is finalized, fails, or expires, invoking callbacks
+// along the way. A transaction is expired once the block height passes lastValidBlockHeight
+// without the signature being seen. RPC errors are retried until ctx is done.
+func (t *Tracker) Track(ctx context.Context, signature string, lastValidBlockHeight uint64, cb Callbacks) (*Result, error) {
+	result := Result{Signature: signature, Status: StatusPending}
+	ticker := time.NewTicker(t.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		status, err := t.poll(ctx, signature)
+		if err == nil {
+			switch {
+			case status == nil:
+				// Not seen yet; only an expired blockhash ends the wait
+				height, err := t.rpc.GetBlockHeight(ctx, "confirmed")
+				if err == nil && lastValidBlockHeight > 0 && height > lastValidBlockHeight {
+					result.Status = StatusExpired
+					notify(cb.OnExpired, result)
+					return &result, nil
+				}
+			case status.Failed():
+				result.Status = StatusFailed
+				result.Slot = status.Slot
+				result.Error = string(status.Err)
+				notify(cb.OnFailed, result)
+				return &result, nil
+			case status.ConfirmationStatus == StatusFinalized:
+				result.Slot = status.Slot
+				if result.Status != StatusConfirmed {
+					result.Status = StatusConfirmed
+					notify(cb.OnConfirmed, result)
+				}
+				result.Status = StatusFinalized
+				notify(cb.OnFinalized, result)
+				return &result, nil
+			case status.ConfirmationStatus == StatusConfirmed && result.Status != StatusConfirmed:
+				result.Slot = status.Slot
+				result.Status = StatusConfirmed
+				notify(cb.OnConfirmed, result)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return &result, fmt.Errorf("stopped tracking %s: %w", signature, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// WaitConfirmed blocks until the signature is confirmed or fails, without
+// waiting for finality or watching for expiry. RPC errors are retried until
+// ctx is done.
+func (t *Tracker) WaitConfirmed(ctx context.Context, signature string) (*Result, error) {
+	ticker := time.NewTicker(t.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		result, err := t.Status(ctx, signature)
+		if err == nil && result.Status != StatusPending {
+			return result, nil
+		}
+		select {
+		case <-ctx.Done():
+			return &Result{Signature: signature, Status: StatusPending}, fmt.Errorf("stopped tracking %s: %w", signature, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// Status returns the current tracking state of a signature without waiting.
+func (t *Tracker) Status(ctx context.Context, signature string) (*Result, error) {
+	status, err := t.poll(ctx, signature)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Signature: signature, Status: StatusPending}
+	if status == nil {
+		return result, nil
+	}
+	result.Slot = status.Slot
+	switch {
+	case status.Failed():
+		result.Status = StatusFailed
+		result.Error = string(status.Err)
+	case status.ConfirmationStatus == StatusFinalized:
+		result.Status = StatusFinalized
+	case status.ConfirmationStatus == StatusConfirmed:
+		result.Status = StatusConfirmed
+	}
+	return result, nil
+}
+
+func (t *Tracker) poll(ctx context.Context, signature string) (*solana.SignatureStatus, error) {
+	statuses, err := t.rpc.GetSignatureStatuses(ctx, []string{signature})
+	if err != nil {
+		return nil, err
+	}
+	if len(statuses) == 0 {
+		return nil, nil
+	}
+	return statuses[0], nil
+}
+
+func notify(fn func(Result), r Result) {
+	if fn != nil {
+		fn(r)
+	}
+}
@@ -0,0 +1,301 @@
+// Package customers lets merchants recognize returning customers without
+// deanonymizing them. Customers are keyed by the ShadowID commitment they pay
+// with; the merchant attaches an opaque alias and repeat-purchase stats are kept
+// locally. Wallet addresses are never accepted or stored.
+package customers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/jsonfile"
+	"sol_privacy/internal/merchant"
+	"sol_privacy/internal/validate"
+)
+
+// Length limits for merchant-supplied text.
+const (
+	MaxAliasLength = 64
+	MaxNotesLength = 256
+)
+
+var (
+	// ErrCustomerNotFound is returned for unknown commitments.
+	ErrCustomerNotFound = errors.New("customer not found")
+	// errWalletAddress rejects text that would link a commitment to a wallet.
+	errWalletAddress = errors.New("must not contain a wallet address")
+)
+
+// Customer is a returning customer known only by commitment.
+type Customer struct {
+	Commitment  string `json:"commitment"` // ShadowID commitment
+	Alias       string `json:"alias,omitempty"`
+	Notes       string `json:"notes,omitempty"`
+	Purchases   int    `json:"purchases"`
+	TotalSpent  int64  `json:"total_spent"` // Lamports, SOL purchases only
+	FirstSeenAt int64  `json:"first_seen_at,omitempty"`
+	LastSeenAt  int64  `json:"last_seen_at,omitempty"`
+
+	History []Purchase `json:"history,omitempty"`
+}
+
+// Purchase is a single recorded payment.
+type Purchase struct {
+	Amount    int64  `json:"amount"`
+	TokenMint string `json:"token_mint,omitempty"` // Empty for SOL
+	At        int64  `json:"at"`
+}
+
+// Returning reports whether the customer has paid more than once.
+func (c *Customer) Returning() bool {
+	return c.Purchases > 1
+}
+
+// AliasRequest sets a customer's alias and notes.
+type AliasRequest struct {
+	Alias string `json:"alias"`
+	Notes string `json:"notes,omitempty"`
+}
+
+// checkText rejects overlong text and any word that parses as a Solana address.
+func checkText(v *validate.Validator, field, s string, max int) {
+	if len(s) > max {
+		v.Add(field, fmt.Errorf("must be at most %d characters", max))
+		return
+	}
+	for _, word := range strings.Fields(s) {
+		if validate.Address(word) == nil {
+			v.Add(field, errWalletAddress)
+			return
+		}
+	}
+}
+
+// PurchaseRequest records a purchase by a customer.
+type PurchaseRequest struct {
+	Amount    int64  `json:"amount"`               // Smallest units of the token
+	TokenMint string `json:"token_mint,omitempty"` // Empty for SOL
+}
+
+// Stats summarizes the customer base.
+type Stats struct {
+	Customers          int     `json:"customers"`
+	ReturningCustomers int     `json:"returning_customers"`
+	RepeatRate         float64 `json:"repeat_rate"` // Returning / customers
+	Purchases          int     `json:"purchases"`
+	TotalSpent         int64   `json:"total_spent"`
+	AverageOrder       int64   `json:"average_order"`
+}
+
+// Store persists customers.
+type Store interface {
+	Put(c *Customer) error
+	Get(commitment string) (*Customer, error) // Returns ErrCustomerNotFound for unknown commitments
+	Delete(commitment string) error
+	List() ([]*Customer, error)
+}
+
+// NewMemoryStore creates an empty in-memory Store.
+func NewMemoryStore() Store {
+	return store{jsonfile.NewMemoryStore(customerKey, nil)}
+}
+
+// NewFileStore opens a Store persisted to a JSON file after every write,
+// creating it on first write.
+func NewFileStore(path string) (Store, error) {
+	customers, err := jsonfile.NewStore(path, customerKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	return store{customers}, nil
+}
+
+func customerKey(c *Customer) string { return c.Commitment }
+
+// store keeps customers in a jsonfile.Store.
+type store struct {
+	customers *jsonfile.Store[Customer]
+}
+
+func (s store) Put(c *Customer) error {
+	return s.customers.Put(c)
+}
+
+func (s store) Get(commitment string) (*Customer, error) {
+	c, ok := s.customers.Get(commitment)
+	if !ok {
+		return nil, ErrCustomerNotFound
+	}
+	return c, nil
+}
+
+func (s store) Delete(commitment string) error {
+	c, err := s.customers.Delete(commitment)
+	if c == nil && err == nil {
+		return ErrCustomerNotFound
+	}
+	return err
+}
+
+// List returns all customers, most purchases first.
+func (s store) List() ([]*Customer, error) {
+	return s.customers.List(func(a, b *Customer) bool {
+		if a.Purchases != b.Purchases {
+			return a.Purchases > b.Purchases
+		}
+		return a.LastSeenAt > b.LastSeenAt
+	}), nil
+}
+
+// Service manages customer aliases and purchase stats.
+type Service struct {
+	store Store
+	mu    sync.Mutex // Serializes read-modify-write updates
+}
+
+// NewService creates a customer service. A nil store selects an in-memory store.
+func NewService(store Store) *Service {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	return &Service{store: store}
+}
+
+// SetAlias attaches an alias and notes to a commitment, creating the customer if needed.
+func (s *Service) SetAlias(ctx context.Context, commitment string, req AliasRequest) (*Customer, error) {
+	v := validate.New().Commitment("commitment", commitment)
+	v.Required("alias", req.Alias)
+	checkText(v, "alias", req.Alias, MaxAliasLength)
+	checkText(v, "notes", req.Notes, MaxNotesLength)
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, err := s.getOrNew(commitment)
+	if err != nil {
+		return nil, err
+	}
+	c.Alias = req.Alias
+	c.Notes = req.Notes
+	if err := s.store.Put(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// RecordPurchase counts a purchase paid with commitment.
+func (s *Service) RecordPurchase(ctx context.Context, commitment string, req PurchaseRequest) (*Customer, error) {
+	v := validate.New().
+		Commitment("commitment", commitment).
+		Amount("amount", req.Amount, 0, validate.MaxLamports).
+		OptionalAddress("token_mint", req.TokenMint)
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, err := s.getOrNew(commitment)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	if c.FirstSeenAt == 0 {
+		c.FirstSeenAt = now
+	}
+	c.LastSeenAt = now
+	c.Purchases++
+	if req.TokenMint == "" {
+		c.TotalSpent += req.Amount
+	}
+	c.History = append(c.History, Purchase{Amount: req.Amount, TokenMint: req.TokenMint, At: now})
+	if err := s.store.Put(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Get returns a customer by commitment.
+func (s *Service) Get(ctx context.Context, commitment string) (*Customer, error) {
+	return s.store.Get(commitment)
+}
+
+// List returns customers, most purchases first, optionally filtered by a
+// case-insensitive alias substring.
+func (s *Service) List(ctx context.Context, alias string) ([]*Customer, error) {
+	all, err := s.store.List()
+	if err != nil || alias == "" {
+		return all, err
+	}
+	alias = strings.ToLower(alias)
+	out := make([]*Customer, 0, len(all))
+	for _, c := range all {
+		if strings.Contains(strings.ToLower(c.Alias), alias) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+// Delete forgets a customer.
+func (s *Service) Delete(ctx context.Context, commitment string) error {
+	return s.store.Delete(commitment)
+}
+
+// Stats summarizes all customers.
+func (s *Service) Stats(ctx context.Context) (*Stats, error) {
+	all, err := s.store.List()
+	if err != nil {
+		return nil, err
+	}
+	st := &Stats{Customers: len(all)}
+	for _, c := range all {
+		if c.Returning() {
+			st.ReturningCustomers++
+		}
+		st.Purchases += c.Purchases
+		st.TotalSpent += c.TotalSpent
+	}
+	if st.Customers > 0 {
+		st.RepeatRate = float64(st.ReturningCustomers) / float64(st.Customers)
+	}
+	if st.Purchases > 0 {
+		st.AverageOrder = st.TotalSpent / int64(st.Purchases)
+	}
+	return st, nil
+}
+
+// PaymentRecords returns every recorded purchase keyed by commitment, for
+// merchant analytics computed on the proxy.
+func (s *Service) PaymentRecords(ctx context.Context) ([]merchant.PaymentRecord, error) {
+	all, err := s.store.List()
+	if err != nil {
+		return nil, err
+	}
+	var out []merchant.PaymentRecord
+	for _, c := range all {
+		for _, p := range c.History {
+			out = append(out, merchant.PaymentRecord{
+				Customer:  c.Commitment,
+				TokenMint: p.TokenMint,
+				Amount:    p.Amount,
+				Timestamp: p.At,
+			})
+		}
+	}
+	return out, nil
+}
+
+func (s *Service) getOrNew(commitment string) (*Customer, error) {
+	c, err := s.store.Get(commitment)
+	if errors.Is(err, ErrCustomerNotFound) {
+		return &Customer{Commitment: commitment}, nil
+	}
+	return c, err
+}
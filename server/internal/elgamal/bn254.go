@@ -0,0 +1,161 @@
+package elgamal
+
+import (
+	"errors"
+	"math/big"
+
+	"sol_privacy/internal/poseidon"
+)
+
+// The BN254 G1 group: y² = x³ + 3 over the base field, generated by (1, 2).
+// Its order is the scalar field of Poseidon and the circuits, and its
+// cofactor is 1, so every point on the curve is in the group.
+var (
+	fieldPrime, _ = new(big.Int).SetString("21888242871839275222246405745257275088696311157297823662689037894645226208583", 10)
+	groupOrder    = poseidon.Modulus
+	curveB        = big.NewInt(3)
+	generator     = point{x: big.NewInt(1), y: big.NewInt(2)}
+
+	// sqrtExp is (p+1)/4; p ≡ 3 mod 4, so a^sqrtExp is a square root of a.
+	sqrtExp = new(big.Int).Rsh(new(big.Int).Add(fieldPrime, big.NewInt(1)), 2)
+	halfP   = new(big.Int).Rsh(fieldPrime, 1)
+)
+
+// PointSize is the size of a compressed point.
+const PointSize = 32
+
+// Flags in the top bits of a compressed point, as encoded by gnark-crypto.
+const (
+	flagMask     = 0b11 << 6
+	flagSmallest = 0b10 << 6 // y ≤ (p-1)/2
+	flagLargest  = 0b11 << 6 // y > (p-1)/2
+	flagInfinity = 0b01 << 6
+)
+
+// point is an affine G1 point; inf marks the identity.
+type point struct {
+	x, y *big.Int
+	inf  bool
+}
+
+var infinity = point{inf: true}
+
+func (a point) equal(b point) bool {
+	if a.inf || b.inf {
+		return a.inf == b.inf
+	}
+	return a.x.Cmp(b.x) == 0 && a.y.Cmp(b.y) == 0
+}
+
+func (a point) neg() point {
+	if a.inf {
+		return a
+	}
+	return point{x: a.x, y: new(big.Int).Sub(fieldPrime, a.y)}
+}
+
+func (a point) add(b point) point {
+	switch {
+	case a.inf:
+		return b
+	case b.inf:
+		return a
+	}
+	var lambda *big.Int
+	if a.x.Cmp(b.x) == 0 {
+		if a.y.Cmp(b.y) != 0 || a.y.Sign() == 0 {
+			return infinity
+		}
+		// Doubling: λ = 3x² / 2y
+		num := new(big.Int).Mul(a.x, a.x)
+		num.Mul(num, big.NewInt(3))
+		den := new(big.Int).Lsh(a.y, 1)
+		lambda = num.Mul(num, den.ModInverse(den.Mod(den, fieldPrime), fieldPrime))
+	} else {
+		// Addition: λ = (y₂ - y₁) / (x₂ - x₁)
+		num := new(big.Int).Sub(b.y, a.y)
+		den := new(big.Int).Sub(b.x, a.x)
+		lambda = num.Mul(num, den.ModInverse(den.Mod(den, fieldPrime), fieldPrime))
+	}
+	lambda.Mod(lambda, fieldPrime)
+
+	x := new(big.Int).Mul(lambda, lambda)
+	x.Sub(x, a.x).Sub(x, b.x).Mod(x, fieldPrime)
+	y := new(big.Int).Sub(a.x, x)
+	y.Mul(y, lambda).Sub(y, a.y).Mod(y, fieldPrime)
+	return point{x: x, y: y}
+}
+
+// mul returns k·a by double-and-add. It is not constant time.
+func (a point) mul(k *big.Int) point {
+	k = new(big.Int).Mod(k, groupOrder)
+	r := infinity
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		r = r.add(r)
+		if k.Bit(i) == 1 {
+			r = r.add(a)
+		}
+	}
+	return r
+}
+
+// onCurve reports whether y² = x³ + 3.
+func onCurve(x, y *big.Int) bool {
+	return new(big.Int).Mod(new(big.Int).Mul(y, y), fieldPrime).Cmp(curveRHS(x)) == 0
+}
+
+func curveRHS(x *big.Int) *big.Int {
+	rhs := new(big.Int).Mul(x, x)
+	rhs.Mul(rhs, x).Add(rhs, curveB)
+	return rhs.Mod(rhs, fieldPrime)
+}
+
+// compress encodes a point as its big-endian x with the sign of y in the
+// top bits.
+func (a point) compress() [PointSize]byte {
+	var out [PointSize]byte
+	if a.inf {
+		out[0] = flagInfinity
+		return out
+	}
+	a.x.FillBytes(out[:])
+	if a.y.Cmp(halfP) > 0 {
+		out[0] |= flagLargest
+	} else {
+		out[0] |= flagSmallest
+	}
+	return out
+}
+
+func decompress(b []byte) (point, error) {
+	if len(b) != PointSize {
+		return point{}, errors.New("elgamal: a point must be 32 bytes")
+	}
+	flag := b[0] & flagMask
+	buf := make([]byte, PointSize)
+	copy(buf, b)
+	buf[0] &^= flagMask
+	x := new(big.Int).SetBytes(buf)
+
+	switch flag {
+	case flagInfinity:
+		if x.Sign() != 0 {
+			return point{}, errors.New("elgamal: invalid encoding of the point at infinity")
+		}
+		return infinity, nil
+	case flagSmallest, flagLargest:
+	default:
+		return point{}, errors.New("elgamal: point is not compressed")
+	}
+	if x.Cmp(fieldPrime) >= 0 {
+		return point{}, errors.New("elgamal: point coordinate is not a field element")
+	}
+	y := new(big.Int).Exp(curveRHS(x), sqrtExp, fieldPrime)
+	if !onCurve(x, y) {
+		return point{}, errors.New("elgamal: point is not on the curve")
+	}
+	if (y.Cmp(halfP) > 0) != (flag == flagLargest) {
+		y.Sub(fieldPrime, y)
+	}
+	return point{x: x, y: y}, nil
+}
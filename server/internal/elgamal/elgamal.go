@@ -0,0 +1,221 @@
+// Package elgamal implements exponential ElGamal over the BN254 G1 group,
+// the scheme that encrypts payment amounts. It decrypts locally, so a
+// merchant's private key never has to be sent to the privacy API.
+//
+// Encodings follow the sizes of the privacy API: private keys are 32-byte
+// big-endian scalars, public keys are compressed points, and ciphertexts are
+// the two compressed points C1 || C2, all as 0x-prefixed hex. Points are
+// compressed as by gnark-crypto: big-endian x with the sign of y in the top
+// two bits.
+//
+// An amount m encrypted to public key P with nonce k is
+//
+//	C1 = k·G,  C2 = m·G + k·P
+//
+// Decryption recovers m·G and solves for m with baby-step giant-step, so it
+// is only practical for amounts up to a bound such as DefaultMaxAmount.
+package elgamal
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// CiphertextSize is the size of an encoded ciphertext.
+const CiphertextSize = 2 * PointSize
+
+// DefaultMaxAmount is the largest amount Decrypt searches for by default,
+// about 68.7 SOL in lamports.
+const DefaultMaxAmount = 1 << 36
+
+// ErrAmountNotFound is returned when a ciphertext does not decrypt to an
+// amount within the searched bound, usually because the key is wrong.
+var ErrAmountNotFound = errors.New("elgamal: no amount within bound; wrong key or corrupt ciphertext")
+
+// PrivateKey is an ElGamal secret scalar.
+type PrivateKey struct {
+	d *big.Int
+}
+
+// PublicKey is the point d·G of a private key d.
+type PublicKey struct {
+	p point
+}
+
+// Ciphertext is an encrypted amount.
+type Ciphertext struct {
+	c1, c2 point
+}
+
+// GenerateKey creates a random private key, reading from crypto/rand if r is nil.
+func GenerateKey(r io.Reader) (*PrivateKey, error) {
+	d, err := randomScalar(r)
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKey{d: d}, nil
+}
+
+// NewPrivateKey creates a private key from a scalar in [1, group order).
+func NewPrivateKey(d *big.Int) (*PrivateKey, error) {
+	if d.Sign() <= 0 || d.Cmp(groupOrder) >= 0 {
+		return nil, errors.New("elgamal: private key out of range")
+	}
+	return &PrivateKey{d: new(big.Int).Set(d)}, nil
+}
+
+// ParsePrivateKey parses a 0x-prefixed hex private key.
+func ParsePrivateKey(s string) (*PrivateKey, error) {
+	b, err := decodeHex(s, PointSize)
+	if err != nil {
+		return nil, fmt.Errorf("elgamal: invalid private key: %w", err)
+	}
+	return NewPrivateKey(new(big.Int).SetBytes(b))
+}
+
+// Hex encodes the private key as 0x-prefixed hex.
+func (k *PrivateKey) Hex() string {
+	var b [PointSize]byte
+	k.d.FillBytes(b[:])
+	return "0x" + hex.EncodeToString(b[:])
+}
+
+// Public returns the public key.
+func (k *PrivateKey) Public() *PublicKey {
+	return &PublicKey{p: generator.mul(k.d)}
+}
+
+// ParsePublicKey parses a 0x-prefixed hex compressed public key.
+func ParsePublicKey(s string) (*PublicKey, error) {
+	b, err := decodeHex(s, PointSize)
+	if err != nil {
+		return nil, fmt.Errorf("elgamal: invalid public key: %w", err)
+	}
+	p, err := decompress(b)
+	if err != nil {
+		return nil, err
+	}
+	if p.inf {
+		return nil, errors.New("elgamal: public key is the point at infinity")
+	}
+	return &PublicKey{p: p}, nil
+}
+
+// Hex encodes the public key as 0x-prefixed hex.
+func (k *PublicKey) Hex() string {
+	b := k.p.compress()
+	return "0x" + hex.EncodeToString(b[:])
+}
+
+// Encrypt encrypts amount to pub with a random nonce, reading from
+// crypto/rand if r is nil.
+func Encrypt(pub *PublicKey, amount uint64, r io.Reader) (*Ciphertext, error) {
+	k, err := randomScalar(r)
+	if err != nil {
+		return nil, err
+	}
+	return EncryptWithNonce(pub, amount, k)
+}
+
+// EncryptWithNonce encrypts amount to pub with the given nonce. It exists
+// for test vectors: reusing a nonce reveals the difference of the amounts.
+func EncryptWithNonce(pub *PublicKey, amount uint64, nonce *big.Int) (*Ciphertext, error) {
+	if nonce.Sign() <= 0 || nonce.Cmp(groupOrder) >= 0 {
+		return nil, errors.New("elgamal: nonce out of range")
+	}
+	m := generator.mul(new(big.Int).SetUint64(amount))
+	return &Ciphertext{
+		c1: generator.mul(nonce),
+		c2: m.add(pub.p.mul(nonce)),
+	}, nil
+}
+
+// ParseCiphertext parses a 0x-prefixed hex ciphertext.
+func ParseCiphertext(s string) (*Ciphertext, error) {
+	b, err := decodeHex(s, CiphertextSize)
+	if err != nil {
+		return nil, fmt.Errorf("elgamal: invalid ciphertext: %w", err)
+	}
+	c1, err := decompress(b[:PointSize])
+	if err != nil {
+		return nil, err
+	}
+	c2, err := decompress(b[PointSize:])
+	if err != nil {
+		return nil, err
+	}
+	return &Ciphertext{c1: c1, c2: c2}, nil
+}
+
+// Hex encodes the ciphertext as 0x-prefixed hex.
+func (c *Ciphertext) Hex() string {
+	c1, c2 := c.c1.compress(), c.c2.compress()
+	return "0x" + hex.EncodeToString(c1[:]) + hex.EncodeToString(c2[:])
+}
+
+// Add returns a ciphertext of the sum of both amounts.
+func (c *Ciphertext) Add(other *Ciphertext) *Ciphertext {
+	return &Ciphertext{c1: c.c1.add(other.c1), c2: c.c2.add(other.c2)}
+}
+
+// Decrypt recovers the amount of a ciphertext, searching amounts up to
+// maxAmount. It returns ErrAmountNotFound if none matches.
+func (k *PrivateKey) Decrypt(c *Ciphertext, maxAmount uint64) (uint64, error) {
+	// m·G = C2 - d·C1
+	target := c.c2.add(c.c1.mul(k.d).neg())
+
+	// Baby steps j·G for j < n, then giant steps of n·G from the target
+	n := uint64(math.Ceil(math.Sqrt(float64(maxAmount) + 1)))
+	baby := make(map[[PointSize]byte]uint64, n)
+	step := infinity
+	for j := uint64(0); j < n; j++ {
+		baby[step.compress()] = j
+		step = step.add(generator)
+	}
+	giant := step.neg() // -n·G
+	for i := uint64(0); i <= maxAmount/n; i++ {
+		if j, ok := baby[target.compress()]; ok {
+			if m := i*n + j; m <= maxAmount {
+				return m, nil
+			}
+			break
+		}
+		target = target.add(giant)
+	}
+	return 0, ErrAmountNotFound
+}
+
+func randomScalar(r io.Reader) (*big.Int, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+	// 64 bytes reduce into the group order with negligible bias
+	var b [64]byte
+	for {
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return nil, fmt.Errorf("elgamal: reading randomness: %w", err)
+		}
+		k := new(big.Int).SetBytes(b[:])
+		if k.Mod(k, groupOrder).Sign() != 0 {
+			return k, nil
+		}
+	}
+}
+
+func decodeHex(s string, size int) ([]byte, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != size {
+		return nil, fmt.Errorf("expected %d bytes, got %d", size, len(b))
+	}
+	return b, nil
+}
@@ -0,0 +1,91 @@
+package elgamal
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// sealDomain separates sealing keys from other hashes of shared points.
+const sealDomain = "shadowpay/elgamal-seal/v1"
+
+// SealOverhead is the size sealing adds to a message: the point C1, the GCM
+// nonce and the GCM tag.
+const SealOverhead = PointSize + 12 + 16
+
+// ErrUnsealFailed is returned when sealed data does not decrypt, usually
+// because the key or associated data is wrong.
+var ErrUnsealFailed = errors.New("elgamal: sealed data does not decrypt; wrong key or corrupt data")
+
+// Seal encrypts arbitrary data to pub, which exponential ElGamal cannot
+// carry, reading from crypto/rand if r is nil. It uses the same keys as
+// amounts, with ElGamal as a key encapsulation: for a random k,
+//
+//	C1 = k·G,  key = SHA-256(domain || k·P || C1)
+//
+// and the data is sealed with AES-256-GCM under key, authenticating ad. The
+// result is C1 || nonce || ciphertext as 0x-prefixed hex.
+func Seal(pub *PublicKey, data, ad []byte, r io.Reader) (string, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+	k, err := randomScalar(r)
+	if err != nil {
+		return "", err
+	}
+	c1 := generator.mul(k).compress()
+	aead, err := sealCipher(pub.p.mul(k), c1)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(r, nonce); err != nil {
+		return "", fmt.Errorf("elgamal: reading randomness: %w", err)
+	}
+	out := append(c1[:], nonce...)
+	out = aead.Seal(out, nonce, data, ad)
+	return "0x" + hex.EncodeToString(out), nil
+}
+
+// Unseal decrypts data sealed to the key's public key with the same ad.
+func (k *PrivateKey) Unseal(sealed string, ad []byte) ([]byte, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(sealed, "0x"), "0X"))
+	if err != nil || len(b) < SealOverhead {
+		return nil, fmt.Errorf("elgamal: invalid sealed data")
+	}
+	c1, err := decompress(b[:PointSize])
+	if err != nil {
+		return nil, err
+	}
+	var enc [PointSize]byte
+	copy(enc[:], b[:PointSize])
+	aead, err := sealCipher(c1.mul(k.d), enc)
+	if err != nil {
+		return nil, err
+	}
+	nonce := b[PointSize : PointSize+aead.NonceSize()]
+	data, err := aead.Open(nil, nonce, b[PointSize+aead.NonceSize():], ad)
+	if err != nil {
+		return nil, ErrUnsealFailed
+	}
+	return data, nil
+}
+
+func sealCipher(shared point, c1 [PointSize]byte) (cipher.AEAD, error) {
+	s := shared.compress()
+	h := sha256.New()
+	h.Write([]byte(sealDomain))
+	h.Write(s[:])
+	h.Write(c1[:])
+	block, err := aes.NewCipher(h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
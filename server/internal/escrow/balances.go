@@ -0,0 +1,84 @@
+package escrow
+
+import (
+	"context"
+	"sync"
+
+	"sol_privacy/internal/amount"
+	"sol_privacy/internal/token"
+)
+
+// TokenBalance is the escrow balance of a single mint.
+type TokenBalance struct {
+	Mint     string `json:"mint"`
+	Symbol   string `json:"symbol"`
+	Decimals int    `json:"decimals"`
+	Balance  int64  `json:"balance"` // In smallest token units
+	Error    string `json:"error,omitempty"`
+}
+
+// BalanceAmount returns Balance as an amount of the mint's base units.
+func (b TokenBalance) BalanceAmount() amount.Amount {
+	return amount.Lamports(b.Balance)
+}
+
+// AllBalancesResponse aggregates SOL and SPL token escrow balances for a wallet.
+type AllBalancesResponse struct {
+	WalletAddress string         `json:"wallet_address"`
+	SOLBalance    int64          `json:"sol_balance"` // In lamports
+	Tokens        []TokenBalance `json:"tokens"`
+}
+
+// SOLBalanceAmount returns SOLBalance as an amount.
+func (r AllBalancesResponse) SOLBalanceAmount() amount.Amount {
+	return amount.Lamports(r.SOLBalance)
+}
+
+// GetAllBalances retrieves the SOL balance and the balance of every enabled supported
+// SPL token in one call. Token balances are fetched concurrently; a failed lookup is
+// reported on its entry rather than failing the whole call.
+func (s *Service) GetAllBalances(ctx context.Context, wallet string) (*AllBalancesResponse, error) {
+	supported, err := token.NewService(s.doRequest).ListSupported(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &AllBalancesResponse{WalletAddress: wallet}
+	for _, t := range supported.Tokens {
+		if t.Enabled {
+			resp.Tokens = append(resp.Tokens, TokenBalance{Mint: t.Mint, Symbol: t.Symbol, Decimals: t.Decimals})
+		}
+	}
+
+	var wg sync.WaitGroup
+	var solErr error
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		bal, err := s.GetBalance(ctx, wallet)
+		if err != nil {
+			solErr = err
+			return
+		}
+		resp.SOLBalance = bal.Balance
+	}()
+
+	for i := range resp.Tokens {
+		wg.Add(1)
+		go func(tb *TokenBalance) {
+			defer wg.Done()
+			bal, err := s.GetTokenBalance(ctx, wallet, tb.Mint)
+			if err != nil {
+				tb.Error = err.Error()
+				return
+			}
+			tb.Balance = bal.Balance
+		}(&resp.Tokens[i])
+	}
+	wg.Wait()
+
+	if solErr != nil {
+		return nil, solErr
+	}
+	return resp, nil
+}
@@ -3,13 +3,22 @@ package escrow
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"os"
+	"sync"
 
+	"sol_privacy/internal/amount"
+	"sol_privacy/internal/solana"
 	"sol_privacy/internal/types"
+	"sol_privacy/internal/validate"
 )
 
 // Service handles escrow operations.
 type Service struct {
 	doRequest func(ctx context.Context, method, path string, body, result interface{}) error
+
+	feesMu sync.Mutex
+	fees   types.FeeEstimator
 }
 
 // NewService creates a new escrow service.
@@ -26,16 +35,49 @@ type BalanceResponse struct {
 	Mint          string `json:"mint,omitempty"`
 }
 
+// BalanceAmount returns Balance as an amount, in the mint's base units for
+// token balances.
+func (r BalanceResponse) BalanceAmount() amount.Amount {
+	return amount.Lamports(r.Balance)
+}
+
 // TransactionRequest represents a request to generate an unsigned escrow transaction.
 type TransactionRequest struct {
-	WalletAddress string `json:"wallet_address"`
-	Amount        int64  `json:"amount"` // In lamports or smallest token unit
-	Mint          string `json:"mint,omitempty"`
+	WalletAddress types.Address `json:"wallet_address"`
+	Amount        int64         `json:"amount"` // In lamports or smallest token unit
+	Mint          string        `json:"mint,omitempty"`
+
+	ComputeBudget *types.ComputeBudget `json:"compute_budget,omitempty"` // Optional priority fee
+}
+
+// Validate checks the request fields.
+func (r TransactionRequest) Validate() error {
+	return validate.New().
+		Add("wallet_address", r.WalletAddress.Validate()).
+		Amount("amount", r.Amount, 1, validate.MaxLamports).
+		OptionalAddress("mint", r.Mint).
+		Err()
+}
+
+// SetFeeEstimator configures the estimator used for ComputeBudget auto mode.
+func (s *Service) SetFeeEstimator(e types.FeeEstimator) {
+	s.feesMu.Lock()
+	defer s.feesMu.Unlock()
+	s.fees = e
+}
+
+func (s *Service) feeEstimator() types.FeeEstimator {
+	s.feesMu.Lock()
+	defer s.feesMu.Unlock()
+	if s.fees == nil {
+		s.fees = solana.NewClient(solana.Config{URL: os.Getenv("SOLANA_RPC_URL")})
+	}
+	return s.fees
 }
 
 // GetBalance retrieves the SOL escrow balance for a wallet.
 func (s *Service) GetBalance(ctx context.Context, wallet string) (*BalanceResponse, error) {
-	path := fmt.Sprintf("/shadowpay/api/escrow/balance/%s", wallet)
+	path := fmt.Sprintf("/shadowpay/api/escrow/balance/%s", url.PathEscape(wallet))
 	var resp BalanceResponse
 	if err := s.doRequest(ctx, "GET", path, nil, &resp); err != nil {
 		return nil, err
@@ -45,7 +87,7 @@ func (s *Service) GetBalance(ctx context.Context, wallet string) (*BalanceRespon
 
 // GetTokenBalance retrieves the SPL token escrow balance for a wallet.
 func (s *Service) GetTokenBalance(ctx context.Context, wallet, mint string) (*BalanceResponse, error) {
-	path := fmt.Sprintf("/shadowpay/api/escrow/balance-token/%s/%s", wallet, mint)
+	path := fmt.Sprintf("/shadowpay/api/escrow/balance-token/%s/%s", url.PathEscape(wallet), url.PathEscape(mint))
 	var resp BalanceResponse
 	if err := s.doRequest(ctx, "GET", path, nil, &resp); err != nil {
 		return nil, err
@@ -55,27 +97,51 @@ func (s *Service) GetTokenBalance(ctx context.Context, wallet, mint string) (*Ba
 
 // Deposit creates an unsigned transaction to deposit SOL into escrow.
 func (s *Service) Deposit(ctx context.Context, req TransactionRequest) (*types.UnsignedTxResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := req.ComputeBudget.Resolve(ctx, s.feeEstimator(), string(req.WalletAddress)); err != nil {
+		return nil, fmt.Errorf("failed to estimate priority fee: %w", err)
+	}
 	var resp types.UnsignedTxResponse
 	if err := s.doRequest(ctx, "POST", "/shadowpay/api/escrow/deposit", req, &resp); err != nil {
 		return nil, err
 	}
-	return &resp, nil
+	return resp.WithRebuild(func(ctx context.Context) (*types.UnsignedTxResponse, error) {
+		return s.Deposit(ctx, req)
+	}), nil
 }
 
 // Withdraw creates an unsigned transaction to withdraw SOL from escrow.
 func (s *Service) Withdraw(ctx context.Context, req TransactionRequest) (*types.UnsignedTxResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := req.ComputeBudget.Resolve(ctx, s.feeEstimator(), string(req.WalletAddress)); err != nil {
+		return nil, fmt.Errorf("failed to estimate priority fee: %w", err)
+	}
 	var resp types.UnsignedTxResponse
 	if err := s.doRequest(ctx, "POST", "/shadowpay/api/escrow/withdraw", req, &resp); err != nil {
 		return nil, err
 	}
-	return &resp, nil
+	return resp.WithRebuild(func(ctx context.Context) (*types.UnsignedTxResponse, error) {
+		return s.Withdraw(ctx, req)
+	}), nil
 }
 
 // WithdrawToken creates an unsigned transaction to withdraw SPL tokens from escrow.
 func (s *Service) WithdrawToken(ctx context.Context, req TransactionRequest) (*types.UnsignedTxResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := req.ComputeBudget.Resolve(ctx, s.feeEstimator(), string(req.WalletAddress)); err != nil {
+		return nil, fmt.Errorf("failed to estimate priority fee: %w", err)
+	}
 	var resp types.UnsignedTxResponse
 	if err := s.doRequest(ctx, "POST", "/shadowpay/api/escrow/withdraw-tokens", req, &resp); err != nil {
 		return nil, err
 	}
-	return &resp, nil
+	return resp.WithRebuild(func(ctx context.Context) (*types.UnsignedTxResponse, error) {
+		return s.WithdrawToken(ctx, req)
+	}), nil
 }
@@ -0,0 +1,615 @@
+// Package events is the proxy's internal event bus. Subsystems publish typed
+// events (settlements, checkout sessions, swaps, incoming webhooks) and
+// consume them through two kinds of subscriptions:
+//
+//   - Durable subscribers get every matching event at least once. Deliveries
+//     are written to the store before Publish returns and retried with backoff
+//     until the handler succeeds, across restarts, in publish order.
+//   - Streams get matching events on a channel while they are connected, for
+//     live views such as server-sent events. Slow streams miss events rather
+//     than hold up publishers.
+//
+// Every event is also kept in a History, so merchants can page through past
+// events and have them delivered again after an outage.
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/jsonfile"
+)
+
+// Event types. Data carries the payload named alongside each.
+const (
+	SettlementSucceeded = "settlement.succeeded"       // settlement.Job
+	SettlementFailed    = "settlement.failed"          // settlement.Job
+	CheckoutCompleted   = "checkout.session.completed" // checkout.Session
+	CheckoutExpired     = "checkout.session.expired"   // checkout.Session
+	CheckoutCanceled    = "checkout.session.canceled"  // checkout.Session
+	SwapCompleted       = "swap.completed"             // swap.Receipt
+	SwapFailed          = "swap.failed"                // swap.Receipt
+	WebhookReceived     = "webhook.received"           // invoice.WebhookEvent
+
+	PaymentRequestApproved = "payment_request.approved" // bots.PaymentRequest
+	PaymentRequestDeclined = "payment_request.declined" // bots.PaymentRequest
+
+	AuthorizationSpendAlert = "authorization.spend_alert" // authorization.SpendAlert
+	DeliveryFailing         = "delivery.failing"          // alerts.DeliveryFailure
+	ShadowIDRootChanged     = "shadowid.root_changed"     // shadowid.RootResponse
+
+	ShippingAddressSubmitted = "shipping.address_submitted" // shipping.Order
+	ShippingAddressRevealed  = "shipping.address_revealed"  // shipping.Order
+
+	HoldCreated  = "hold.created"  // hold.Hold
+	HoldReleased = "hold.released" // hold.Hold
+	HoldRefunded = "hold.refunded" // hold.Hold
+	HoldDisputed = "hold.disputed" // hold.Hold
+
+	AccountBalanceChanged  = "account.balance_changed" // accountwatch.Change
+	ReconciliationMismatch = "reconciliation.mismatch" // reconcile.Finding
+)
+
+// Delivery defaults.
+const (
+	DefaultBaseBackoff = time.Second
+	DefaultMaxBackoff  = 10 * time.Minute
+	streamBuffer       = 64
+	pruneInterval      = time.Hour
+)
+
+// ErrUnknownSubscriber is returned when redelivering to a durable subscriber
+// that is not registered or not subscribed to the event's type.
+var ErrUnknownSubscriber = errors.New("unknown subscriber")
+
+// Event is a notification published on the bus. The event, timestamp and
+// data fields match the webhook payloads the proxy sent before the bus.
+type Event struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"event"`
+	Source    string          `json:"source"` // Publishing subsystem
+	Timestamp int64           `json:"timestamp"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// New creates an event with data encoded as its payload.
+func New(eventType, source string, data interface{}) (Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{
+		ID:        newID("evt_"),
+		Type:      eventType,
+		Source:    source,
+		Timestamp: time.Now().Unix(),
+		Data:      raw,
+	}, nil
+}
+
+// Decode decodes the payload into v.
+func (e Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+// Match reports whether an event type matches any of the patterns: exact
+// types, or prefixes ending in "." or "*" ("checkout." and "checkout.*" match
+// every checkout event). No patterns match everything.
+func Match(eventType string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		p = strings.TrimSuffix(p, "*")
+		if p == "" || p == eventType || (strings.HasSuffix(p, ".") && strings.HasPrefix(eventType, p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handler processes an event for a durable subscriber. An error schedules
+// another attempt, so handlers must tolerate seeing an event twice.
+type Handler func(ctx context.Context, e Event) error
+
+// WebhookHandler returns a Handler that POSTs events as JSON to url. Non-2xx
+// responses fail the delivery, so it is retried.
+func WebhookHandler(url string, httpClient *http.Client) Handler {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return func(ctx context.Context, e Event) error {
+		body, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("failed to encode event: %w", err)
+		}
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("failed to create event request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Event-ID", e.ID)
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("event delivery failed: %w", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("event webhook returned status %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// AppendHandler returns a Handler that appends events to the file at path as
+// JSON lines, e.g. for an audit trail.
+func AppendHandler(path string) Handler {
+	var mu sync.Mutex
+	return func(ctx context.Context, e Event) error {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(append(line, '\n')); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+}
+
+// Delivery is an event waiting for a durable subscriber.
+type Delivery struct {
+	ID            string `json:"id"`
+	Seq           int64  `json:"seq"` // Publish order
+	Subscriber    string `json:"subscriber"`
+	Event         Event  `json:"event"`
+	Attempts      int    `json:"attempts"`
+	LastError     string `json:"last_error,omitempty"`
+	NextAttemptAt int64  `json:"next_attempt_at,omitempty"` // Unix timestamp
+}
+
+// Store persists pending deliveries.
+type Store interface {
+	Put(d *Delivery) error
+	Delete(id string) error
+	List() ([]*Delivery, error) // In publish order
+}
+
+// NewMemoryStore creates an empty in-memory Store.
+func NewMemoryStore() Store {
+	return store{jsonfile.NewMemoryStore(deliveryID, nil)}
+}
+
+// NewFileStore opens a Store persisted to a JSON file after every write, so
+// pending deliveries survive proxy restarts. The file is created on first
+// write.
+func NewFileStore(path string) (Store, error) {
+	deliveries, err := jsonfile.NewStore(path, deliveryID, nil)
+	if err != nil {
+		return nil, err
+	}
+	return store{deliveries}, nil
+}
+
+func deliveryID(d *Delivery) string { return d.ID }
+
+// store keeps deliveries in a jsonfile.Store.
+type store struct {
+	deliveries *jsonfile.Store[Delivery]
+}
+
+func (s store) Put(d *Delivery) error {
+	return s.deliveries.Put(d)
+}
+
+func (s store) Delete(id string) error {
+	_, err := s.deliveries.Delete(id)
+	return err
+}
+
+// List returns all deliveries in publish order.
+func (s store) List() ([]*Delivery, error) {
+	return s.deliveries.List(func(a, b *Delivery) bool { return a.Seq < b.Seq }), nil
+}
+
+// Config holds bus configuration.
+type Config struct {
+	Store       Store   // Defaults to an in-memory store
+	History     History // Defaults to a MemoryHistory
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+
+	// HistoryRetention is how long events are kept in the history; forever
+	// if zero.
+	HistoryRetention time.Duration
+}
+
+// SubscriberStats describes a durable subscriber's backlog.
+type SubscriberStats struct {
+	Name      string   `json:"name"`
+	Types     []string `json:"types,omitempty"`
+	Pending   int      `json:"pending"`
+	Delivered int64    `json:"delivered"` // Since the process started
+	Failures  int64    `json:"failures"`
+	LastError string   `json:"last_error,omitempty"`
+}
+
+type subscriber struct {
+	name    string
+	types   []string
+	handler Handler
+	wake    chan struct{}
+
+	delivered int64
+	failures  int64
+	lastError string
+}
+
+type stream struct {
+	types []string
+	ch    chan Event
+}
+
+// Bus routes published events to subscribers.
+type Bus struct {
+	config Config
+
+	mu          sync.Mutex
+	seq         int64
+	subscribers map[string]*subscriber
+	streams     map[*stream]bool
+}
+
+// NewBus creates a bus. Register durable subscribers, then call Run.
+func NewBus(config Config) *Bus {
+	if config.Store == nil {
+		config.Store = NewMemoryStore()
+	}
+	if config.History == nil {
+		config.History = NewMemoryHistory()
+	}
+	if config.BaseBackoff <= 0 {
+		config.BaseBackoff = DefaultBaseBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = DefaultMaxBackoff
+	}
+	b := &Bus{
+		config:      config,
+		subscribers: make(map[string]*subscriber),
+		streams:     make(map[*stream]bool),
+	}
+	if pending, err := config.Store.List(); err == nil && len(pending) > 0 {
+		b.seq = pending[len(pending)-1].Seq
+	}
+	return b
+}
+
+// SubscribeDurable registers a durable subscriber for event types matching
+// patterns (see Match). The name identifies its pending deliveries across
+// restarts, so it must stay the same; deliveries left by a subscriber that
+// is no longer registered wait until it is.
+func (b *Bus) SubscribeDurable(name string, patterns []string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[name] = &subscriber{
+		name:    name,
+		types:   patterns,
+		handler: handler,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Subscribe returns a channel of events matching patterns, and a function
+// that closes it.
+func (b *Bus) Subscribe(patterns ...string) (<-chan Event, func()) {
+	s := &stream{types: patterns, ch: make(chan Event, streamBuffer)}
+	b.mu.Lock()
+	b.streams[s] = true
+	b.mu.Unlock()
+	var once sync.Once
+	return s.ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.streams, s)
+			b.mu.Unlock()
+			close(s.ch)
+		})
+	}
+}
+
+// Publish records the event in the history and a delivery for each matching
+// durable subscriber, then passes it to connected streams. An error means the
+// event may not reach every durable subscriber or may be missing from the
+// history.
+func (b *Bus) Publish(ctx context.Context, e Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var errs []error
+	if err := b.config.History.Append(e); err != nil {
+		errs = append(errs, fmt.Errorf("failed to record event: %w", err))
+	}
+	for _, s := range b.subscribers {
+		if !Match(e.Type, s.types) {
+			continue
+		}
+		if err := b.enqueue(s, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for s := range b.streams {
+		if !Match(e.Type, s.types) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+			// The stream is not keeping up; it misses this event
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// enqueue records a delivery of e to s and wakes it. The caller holds b.mu.
+func (b *Bus) enqueue(s *subscriber, e Event) error {
+	b.seq++
+	d := &Delivery{ID: newID("dlv_"), Seq: b.seq, Subscriber: s.name, Event: e}
+	if err := b.config.Store.Put(d); err != nil {
+		return err
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Events returns a page of the history.
+func (b *Bus) Events(q HistoryQuery) (*HistoryPage, error) {
+	return b.config.History.List(q)
+}
+
+// Event returns an event from the history.
+func (b *Bus) Event(id string) (Event, error) {
+	return b.config.History.Get(id)
+}
+
+// Redeliver queues an event from the history for delivery again, to the
+// named durable subscriber or, if name is empty, to every one subscribed to
+// its type, and returns their names. Deliveries go after those already
+// pending, so subscribers rebuilding their state get events in order when
+// they are redelivered in order.
+func (b *Bus) Redeliver(ctx context.Context, id, name string) ([]string, error) {
+	e, err := b.config.History.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var subs []*subscriber
+	for _, s := range b.subscribers {
+		if (name == "" || s.name == name) && Match(e.Type, s.types) {
+			subs = append(subs, s)
+		}
+	}
+	if name != "" && len(subs) == 0 {
+		return nil, ErrUnknownSubscriber
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].name < subs[j].name })
+
+	names := make([]string, 0, len(subs))
+	var errs []error
+	for _, s := range subs {
+		if err := b.enqueue(s, e); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		names = append(names, s.name)
+	}
+	return names, errors.Join(errs...)
+}
+
+// Emit creates and publishes an event, logging failures. Use it where a
+// publisher has no way to report an error.
+func (b *Bus) Emit(ctx context.Context, eventType, source string, data interface{}) {
+	e, err := New(eventType, source, data)
+	if err == nil {
+		err = b.Publish(ctx, e)
+	}
+	if err != nil {
+		log.Printf("events: publishing %s failed: %v", eventType, err)
+	}
+}
+
+// Stats returns the backlog of each durable subscriber, by name.
+func (b *Bus) Stats() ([]SubscriberStats, error) {
+	pending, err := b.config.Store.List()
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, d := range pending {
+		counts[d.Subscriber]++
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]SubscriberStats, 0, len(b.subscribers))
+	for _, s := range b.subscribers {
+		out = append(out, SubscriberStats{
+			Name:      s.name,
+			Types:     s.types,
+			Pending:   counts[s.name],
+			Delivered: s.delivered,
+			Failures:  s.failures,
+			LastError: s.lastError,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// Run delivers events to durable subscribers until ctx is done, starting
+// with those left pending by a previous run, and prunes the history.
+func (b *Bus) Run(ctx context.Context) {
+	b.mu.Lock()
+	subs := make([]*subscriber, 0, len(b.subscribers))
+	for _, s := range b.subscribers {
+		subs = append(subs, s)
+	}
+	b.mu.Unlock()
+
+	var wg sync.WaitGroup
+	if b.config.HistoryRetention > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.pruneHistory(ctx)
+		}()
+	}
+	for _, s := range subs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.deliver(ctx, s)
+		}()
+	}
+	wg.Wait()
+}
+
+// deliver hands a subscriber its deliveries in publish order. A failing
+// delivery holds back later ones until it succeeds.
+func (b *Bus) deliver(ctx context.Context, s *subscriber) {
+	for {
+		wait := b.deliverNext(ctx, s)
+		if ctx.Err() != nil {
+			return
+		}
+		if wait == 0 {
+			continue
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-s.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// deliverNext attempts the subscriber's oldest delivery if it is due, and
+// returns how long to wait before the next attempt (zero to go on at once).
+func (b *Bus) deliverNext(ctx context.Context, s *subscriber) time.Duration {
+	pending, err := b.config.Store.List()
+	if err != nil {
+		log.Printf("events: %v", err)
+		return b.config.BaseBackoff
+	}
+	var d *Delivery
+	for _, p := range pending {
+		if p.Subscriber == s.name {
+			d = p
+			break
+		}
+	}
+	if d == nil {
+		return time.Hour
+	}
+	if due := time.Unix(d.NextAttemptAt, 0); d.NextAttemptAt > 0 && time.Now().Before(due) {
+		return time.Until(due)
+	}
+
+	err = s.handler(ctx, d.Event)
+	if ctx.Err() != nil {
+		// Shutting down: the delivery stays pending for the next run
+		return 0
+	}
+
+	b.mu.Lock()
+	if err == nil {
+		s.delivered++
+	} else {
+		s.failures++
+		s.lastError = err.Error()
+	}
+	b.mu.Unlock()
+
+	if err == nil {
+		if err := b.config.Store.Delete(d.ID); err != nil {
+			log.Printf("events: %v", err)
+			return b.config.BaseBackoff
+		}
+		return 0
+	}
+	d.Attempts++
+	d.LastError = err.Error()
+	backoff := b.backoff(d.Attempts)
+	d.NextAttemptAt = time.Now().Add(backoff).Unix()
+	if err := b.config.Store.Put(d); err != nil {
+		log.Printf("events: %v", err)
+	}
+	log.Printf("events: delivering %s to %s failed (attempt %d): %v", d.Event.Type, s.name, d.Attempts, err)
+	return backoff
+}
+
+// pruneHistory drops events older than the retention period, now and then
+// hourly, until ctx is done.
+func (b *Bus) pruneHistory(ctx context.Context) {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+	for {
+		before := time.Now().Add(-b.config.HistoryRetention).Unix()
+		if err := b.config.History.Prune(before); err != nil {
+			log.Printf("events: pruning history failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// backoff returns the delay after the given number of failed attempts: the
+// base backoff doubled per attempt, capped.
+func (b *Bus) backoff(attempts int) time.Duration {
+	d := b.config.BaseBackoff
+	for i := 1; i < attempts && d < b.config.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > b.config.MaxBackoff {
+		d = b.config.MaxBackoff
+	}
+	return d
+}
+
+func newID(prefix string) string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return prefix + hex.EncodeToString(b)
+}
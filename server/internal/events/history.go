@@ -0,0 +1,219 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// History page sizes.
+const (
+	DefaultHistoryPage = 100
+	MaxHistoryPage     = 1000
+)
+
+var (
+	// ErrEventNotFound is returned for events not in the history.
+	ErrEventNotFound = errors.New("event not found")
+	// ErrInvalidCursor is returned for cursors not returned by List.
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// HistoryQuery selects a page of the history.
+type HistoryQuery struct {
+	From   int64    // Unix timestamp; earlier events are skipped
+	Types  []string // Patterns, see Match
+	Cursor string   // NextCursor of the previous page
+	Limit  int      // Defaults to DefaultHistoryPage
+}
+
+// HistoryPage is a page of events in publish order.
+type HistoryPage struct {
+	Events     []Event `json:"events"`
+	NextCursor string  `json:"next_cursor,omitempty"` // Empty on the last page
+}
+
+// History keeps every published event, so merchants can replay them after
+// an outage.
+type History interface {
+	Append(e Event) error
+	Get(id string) (Event, error) // Returns ErrEventNotFound for unknown events
+	List(q HistoryQuery) (*HistoryPage, error)
+	Prune(before int64) error // Drops events published before the Unix timestamp
+}
+
+// MemoryHistory is an in-process History.
+type MemoryHistory struct {
+	mu     sync.RWMutex
+	events []Event
+	index  map[string]int // Position in events, by ID
+}
+
+// NewMemoryHistory creates an empty in-memory history.
+func NewMemoryHistory() *MemoryHistory {
+	return &MemoryHistory{index: make(map[string]int)}
+}
+
+// Append records an event.
+func (m *MemoryHistory) Append(e Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.index[e.ID] = len(m.events)
+	m.events = append(m.events, e)
+	return nil
+}
+
+// Get returns the event with the given ID.
+func (m *MemoryHistory) Get(id string) (Event, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	i, ok := m.index[id]
+	if !ok {
+		return Event{}, ErrEventNotFound
+	}
+	return m.events[i], nil
+}
+
+// List returns the events matching q after its cursor.
+func (m *MemoryHistory) List(q HistoryQuery) (*HistoryPage, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultHistoryPage
+	}
+	if limit > MaxHistoryPage {
+		limit = MaxHistoryPage
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	start := 0
+	if q.Cursor != "" {
+		i, ok := m.index[q.Cursor]
+		if !ok {
+			return nil, ErrInvalidCursor
+		}
+		start = i + 1
+	}
+	page := &HistoryPage{Events: []Event{}}
+	for _, e := range m.events[start:] {
+		if e.Timestamp < q.From || !Match(e.Type, q.Types) {
+			continue
+		}
+		if len(page.Events) == limit {
+			page.NextCursor = page.Events[limit-1].ID
+			break
+		}
+		page.Events = append(page.Events, e)
+	}
+	return page, nil
+}
+
+// Prune drops events published before the Unix timestamp.
+func (m *MemoryHistory) Prune(before int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	kept := m.events[:0]
+	for _, e := range m.events {
+		if e.Timestamp >= before {
+			kept = append(kept, e)
+		}
+	}
+	m.events = kept
+	m.index = make(map[string]int, len(kept))
+	for i, e := range kept {
+		m.index[e.ID] = i
+	}
+	return nil
+}
+
+// FileHistory is a MemoryHistory persisted to a JSON-lines file. Events are
+// appended as they are published; the file is only rewritten by Prune.
+type FileHistory struct {
+	*MemoryHistory
+	path string
+	mu   sync.Mutex // Serializes file writes
+}
+
+// NewFileHistory opens the history at path, creating it on first write.
+func NewFileHistory(path string) (*FileHistory, error) {
+	fh := &FileHistory{MemoryHistory: NewMemoryHistory(), path: path}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fh, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer f.Close()
+	dec := json.NewDecoder(bufio.NewReader(f))
+	for {
+		var e Event
+		if err := dec.Decode(&e); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		fh.MemoryHistory.Append(e)
+	}
+	return fh, nil
+}
+
+// Append records the event and appends it to the file.
+func (f *FileHistory) Append(e Event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return f.MemoryHistory.Append(e)
+}
+
+// Prune drops events published before the Unix timestamp and rewrites the
+// file.
+func (f *FileHistory) Prune(before int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.MemoryHistory.Prune(before)
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	f.MemoryHistory.mu.RLock()
+	for _, e := range f.events {
+		if err = enc.Encode(e); err != nil {
+			break
+		}
+	}
+	f.MemoryHistory.mu.RUnlock()
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", f.path, err)
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
@@ -0,0 +1,519 @@
+package eventsink
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Kafka API keys and the versions used, supported by brokers from 1.0 to 4.x
+const (
+	kafkaProduce          = 0
+	kafkaMetadata         = 3
+	kafkaSaslHandshake    = 17
+	kafkaSaslAuthenticate = 36
+
+	kafkaProduceVersion  = 3
+	kafkaMetadataVersion = 4
+)
+
+// Kafka error codes after which topic metadata is refreshed
+var kafkaStaleMetadata = map[int16]bool{
+	3:  true, // UNKNOWN_TOPIC_OR_PARTITION
+	5:  true, // LEADER_NOT_AVAILABLE
+	6:  true, // NOT_LEADER_OR_FOLLOWER
+	13: true, // NETWORK_EXCEPTION
+}
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// KafkaError is an error code returned by a broker.
+type KafkaError struct {
+	Op   string
+	Code int16
+}
+
+func (e *KafkaError) Error() string {
+	return fmt.Sprintf("eventsink: kafka %s: error code %d", e.Op, e.Code)
+}
+
+// kafkaProducer produces records to the leader of the partition each key
+// hashes to, with the same murmur2 partitioner as the Java client. Records
+// are acknowledged by all in-sync replicas.
+type kafkaProducer struct {
+	bootstrap []string
+	user      string
+	pass      string
+	tls       *tls.Config
+	timeout   time.Duration
+
+	mu      sync.Mutex
+	conns   map[string]*kafkaConn // By broker address
+	brokers map[int32]string      // Node ID to address
+	topics  map[string][]int32    // Partition leaders by partition index
+}
+
+func newKafkaProducer(bootstrap []string, user, pass string, tlsConfig *tls.Config, timeout time.Duration) *kafkaProducer {
+	return &kafkaProducer{
+		bootstrap: bootstrap,
+		user:      user,
+		pass:      pass,
+		tls:       tlsConfig,
+		timeout:   timeout,
+		conns:     make(map[string]*kafkaConn),
+		brokers:   make(map[int32]string),
+		topics:    make(map[string][]int32),
+	}
+}
+
+func (k *kafkaProducer) Publish(ctx context.Context, m Message) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	leaders, ok := k.topics[m.Topic]
+	if !ok {
+		var err error
+		if leaders, err = k.metadata(ctx, m.Topic); err != nil {
+			return err
+		}
+	}
+	partition := int32(murmur2(m.Key)&0x7fffffff) % int32(len(leaders))
+	addr, ok := k.brokers[leaders[partition]]
+	if !ok {
+		delete(k.topics, m.Topic)
+		return fmt.Errorf("eventsink: kafka: no leader for %s/%d", m.Topic, partition)
+	}
+
+	conn, err := k.conn(ctx, addr)
+	if err != nil {
+		return err
+	}
+	err = k.produce(ctx, conn, m, partition)
+	var kerr *KafkaError
+	if errors.As(err, &kerr) && kafkaStaleMetadata[kerr.Code] {
+		delete(k.topics, m.Topic)
+	} else if err != nil && !errors.As(err, &kerr) {
+		// The connection is in an unknown state
+		k.drop(addr)
+		delete(k.topics, m.Topic)
+	}
+	return err
+}
+
+// metadata looks up the partition leaders of a topic from any bootstrap
+// broker. Brokers configured to auto-create topics create it.
+func (k *kafkaProducer) metadata(ctx context.Context, topic string) ([]int32, error) {
+	var req kafkaEncoder
+	req.int32(1)
+	req.string(topic)
+	req.bool(true) // allow_auto_topic_creation
+
+	var lastErr error
+	for _, addr := range k.bootstrap {
+		conn, err := k.conn(ctx, addr)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp, err := conn.roundTrip(ctx, kafkaMetadata, kafkaMetadataVersion, req.b)
+		if err != nil {
+			k.drop(addr)
+			lastErr = fmt.Errorf("eventsink: kafka metadata: %w", err)
+			continue
+		}
+		return k.parseMetadata(resp, topic)
+	}
+	return nil, lastErr
+}
+
+func (k *kafkaProducer) parseMetadata(resp []byte, topic string) ([]int32, error) {
+	d := kafkaDecoder{b: resp}
+	d.int32() // throttle_time_ms
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		id := d.int32()
+		host := d.string()
+		port := d.int32()
+		d.nullableString() // rack
+		k.brokers[id] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	d.nullableString() // cluster_id
+	d.int32()          // controller_id
+
+	var leaders []int32
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		code := d.int16()
+		name := d.string()
+		d.bool() // is_internal
+		var parts []int32
+		for j, m := 0, d.arrayLen(); j < m; j++ {
+			d.int16() // partition error_code
+			index := d.int32()
+			leader := d.int32()
+			d.int32Array() // replica_nodes
+			d.int32Array() // isr_nodes
+			for int(index) >= len(parts) {
+				parts = append(parts, -1)
+			}
+			parts[index] = leader
+		}
+		if name != topic {
+			continue
+		}
+		if code != 0 {
+			return nil, &KafkaError{Op: "metadata " + topic, Code: code}
+		}
+		leaders = parts
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("eventsink: kafka metadata: %w", d.err)
+	}
+	if len(leaders) == 0 {
+		return nil, &KafkaError{Op: "metadata " + topic, Code: 3}
+	}
+	k.topics[topic] = leaders
+	return leaders, nil
+}
+
+func (k *kafkaProducer) produce(ctx context.Context, conn *kafkaConn, m Message, partition int32) error {
+	batch := recordBatch(m, time.Now())
+
+	var req kafkaEncoder
+	req.int16(-1) // transactional_id
+	req.int16(-1) // acks: all in-sync replicas
+	req.int32(int32(k.timeout / time.Millisecond))
+	req.int32(1)
+	req.string(m.Topic)
+	req.int32(1)
+	req.int32(partition)
+	req.bytes(batch)
+
+	resp, err := conn.roundTrip(ctx, kafkaProduce, kafkaProduceVersion, req.b)
+	if err != nil {
+		return fmt.Errorf("eventsink: kafka produce: %w", err)
+	}
+	d := kafkaDecoder{b: resp}
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		d.string() // topic
+		for j, m := 0, d.arrayLen(); j < m; j++ {
+			d.int32() // partition
+			code := d.int16()
+			d.int64() // base_offset
+			d.int64() // log_append_time_ms
+			if code != 0 {
+				return &KafkaError{Op: "produce", Code: code}
+			}
+		}
+	}
+	if d.err != nil {
+		return fmt.Errorf("eventsink: kafka produce: %w", d.err)
+	}
+	return nil
+}
+
+// conn returns an open connection to a broker, dialing and authenticating
+// if needed.
+func (k *kafkaProducer) conn(ctx context.Context, addr string) (*kafkaConn, error) {
+	if c, ok := k.conns[addr]; ok {
+		return c, nil
+	}
+	dialer := &net.Dialer{Timeout: k.timeout}
+	var nc net.Conn
+	var err error
+	if k.tls != nil {
+		config := k.tls.Clone()
+		if config.ServerName == "" {
+			config.ServerName, _, _ = net.SplitHostPort(addr)
+		}
+		nc, err = (&tls.Dialer{NetDialer: dialer, Config: config}).DialContext(ctx, "tcp", addr)
+	} else {
+		nc, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("eventsink: kafka: %w", err)
+	}
+	c := &kafkaConn{conn: nc, timeout: k.timeout}
+	if k.user != "" {
+		if err := c.saslPlain(ctx, k.user, k.pass); err != nil {
+			nc.Close()
+			return nil, err
+		}
+	}
+	k.conns[addr] = c
+	return c, nil
+}
+
+func (k *kafkaProducer) drop(addr string) {
+	if c, ok := k.conns[addr]; ok {
+		c.conn.Close()
+		delete(k.conns, addr)
+	}
+}
+
+func (k *kafkaProducer) Close() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for addr := range k.conns {
+		k.drop(addr)
+	}
+	return nil
+}
+
+// kafkaConn exchanges requests and responses with one broker.
+type kafkaConn struct {
+	conn          net.Conn
+	timeout       time.Duration
+	correlationID int32
+}
+
+// roundTrip sends a request and returns the response body after its header.
+func (c *kafkaConn) roundTrip(ctx context.Context, apiKey, version int16, body []byte) ([]byte, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(c.timeout)
+	}
+	c.conn.SetDeadline(deadline)
+
+	c.correlationID++
+	var req kafkaEncoder
+	req.int32(0) // Size, set below
+	req.int16(apiKey)
+	req.int16(version)
+	req.int32(c.correlationID)
+	req.string("shadowpay-proxy")
+	req.b = append(req.b, body...)
+	binary.BigEndian.PutUint32(req.b, uint32(len(req.b)-4))
+	if _, err := c.conn.Write(req.b); err != nil {
+		return nil, err
+	}
+
+	var size [4]byte
+	if _, err := io.ReadFull(c.conn, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n < 4 || n > 64<<20 {
+		return nil, fmt.Errorf("invalid response size %d", n)
+	}
+	resp := make([]byte, n)
+	if _, err := io.ReadFull(c.conn, resp); err != nil {
+		return nil, err
+	}
+	if id := int32(binary.BigEndian.Uint32(resp)); id != c.correlationID {
+		return nil, fmt.Errorf("response %d does not match request %d", id, c.correlationID)
+	}
+	return resp[4:], nil
+}
+
+// saslPlain authenticates with SASL/PLAIN, as Confluent Cloud and most
+// managed clusters require.
+func (c *kafkaConn) saslPlain(ctx context.Context, user, pass string) error {
+	var req kafkaEncoder
+	req.string("PLAIN")
+	resp, err := c.roundTrip(ctx, kafkaSaslHandshake, 1, req.b)
+	if err != nil {
+		return fmt.Errorf("eventsink: kafka sasl: %w", err)
+	}
+	d := kafkaDecoder{b: resp}
+	if code := d.int16(); code != 0 {
+		return &KafkaError{Op: "sasl handshake (PLAIN)", Code: code}
+	}
+
+	req = kafkaEncoder{}
+	req.bytes([]byte("\x00" + user + "\x00" + pass))
+	if resp, err = c.roundTrip(ctx, kafkaSaslAuthenticate, 0, req.b); err != nil {
+		return fmt.Errorf("eventsink: kafka sasl: %w", err)
+	}
+	d = kafkaDecoder{b: resp}
+	code := d.int16()
+	message := d.nullableString()
+	if code != 0 {
+		return fmt.Errorf("eventsink: kafka sasl: %s (error code %d)", message, code)
+	}
+	return d.err
+}
+
+// recordBatch encodes a message as a v2 record batch of one record.
+func recordBatch(m Message, now time.Time) []byte {
+	var rec kafkaEncoder
+	rec.int8(0)   // attributes
+	rec.varint(0) // timestamp_delta
+	rec.varint(0) // offset_delta
+	rec.varBytes(m.Key)
+	rec.varBytes(m.Value)
+	rec.varint(int64(len(m.Headers)))
+	for k, v := range m.Headers {
+		rec.varBytes([]byte(k))
+		rec.varBytes([]byte(v))
+	}
+
+	ts := now.UnixMilli()
+	var body kafkaEncoder // From attributes on, covered by the CRC
+	body.int16(0)         // attributes: no compression, create time
+	body.int32(0)         // last_offset_delta
+	body.int64(ts)        // base_timestamp
+	body.int64(ts)        // max_timestamp
+	body.int64(-1)        // producer_id
+	body.int16(-1)        // producer_epoch
+	body.int32(-1)        // base_sequence
+	body.int32(1)         // records
+	body.varint(int64(len(rec.b)))
+	body.b = append(body.b, rec.b...)
+
+	var batch kafkaEncoder
+	batch.int64(0)                              // base_offset
+	batch.int32(int32(4 + 1 + 4 + len(body.b))) // batch_length
+	batch.int32(-1)                             // partition_leader_epoch
+	batch.int8(2)                               // magic
+	batch.int32(int32(crc32.Checksum(body.b, crc32c)))
+	batch.b = append(batch.b, body.b...)
+	return batch.b
+}
+
+// murmur2 is the hash of the Kafka Java client's default partitioner.
+func murmur2(data []byte) int32 {
+	const m = 0x5bd1e995
+	h := uint32(0x9747b28c) ^ uint32(len(data))
+	n := len(data) / 4 * 4
+	for i := 0; i < n; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= m
+		k ^= k >> 24
+		k *= m
+		h *= m
+		h ^= k
+	}
+	switch len(data) - n {
+	case 3:
+		h ^= uint32(data[n+2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(data[n+1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(data[n])
+		h *= m
+	}
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+	return int32(h)
+}
+
+type kafkaEncoder struct{ b []byte }
+
+func (e *kafkaEncoder) int8(v int8)    { e.b = append(e.b, byte(v)) }
+func (e *kafkaEncoder) int16(v int16)  { e.b = binary.BigEndian.AppendUint16(e.b, uint16(v)) }
+func (e *kafkaEncoder) int32(v int32)  { e.b = binary.BigEndian.AppendUint32(e.b, uint32(v)) }
+func (e *kafkaEncoder) int64(v int64)  { e.b = binary.BigEndian.AppendUint64(e.b, uint64(v)) }
+func (e *kafkaEncoder) varint(v int64) { e.b = binary.AppendVarint(e.b, v) }
+
+func (e *kafkaEncoder) bool(v bool) {
+	if v {
+		e.int8(1)
+	} else {
+		e.int8(0)
+	}
+}
+
+func (e *kafkaEncoder) string(s string) {
+	e.int16(int16(len(s)))
+	e.b = append(e.b, s...)
+}
+
+func (e *kafkaEncoder) bytes(b []byte) {
+	e.int32(int32(len(b)))
+	e.b = append(e.b, b...)
+}
+
+// varBytes appends a varint length-prefixed field, -1 for nil.
+func (e *kafkaEncoder) varBytes(b []byte) {
+	if b == nil {
+		e.varint(-1)
+		return
+	}
+	e.varint(int64(len(b)))
+	e.b = append(e.b, b...)
+}
+
+// kafkaDecoder reads a response, keeping the first error.
+type kafkaDecoder struct {
+	b   []byte
+	err error
+}
+
+func (d *kafkaDecoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(d.b) {
+		d.err = errors.New("truncated response")
+		d.b = nil
+		return nil
+	}
+	v := d.b[:n]
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *kafkaDecoder) int16() int16 {
+	if b := d.take(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int32() int32 {
+	if b := d.take(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int64() int64 {
+	if b := d.take(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) bool() bool {
+	b := d.take(1)
+	return b != nil && b[0] != 0
+}
+
+func (d *kafkaDecoder) string() string {
+	return string(d.take(int(d.int16())))
+}
+
+func (d *kafkaDecoder) nullableString() string {
+	n := d.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(d.take(int(n)))
+}
+
+func (d *kafkaDecoder) arrayLen() int {
+	n := int(d.int32())
+	if n < 0 || n > len(d.b) {
+		if n > len(d.b) && d.err == nil {
+			d.err = errors.New("truncated response")
+		}
+		return 0
+	}
+	return n
+}
+
+func (d *kafkaDecoder) int32Array() {
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		d.int32()
+	}
+}
@@ -0,0 +1,196 @@
+package eventsink
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// natsConn publishes to a NATS server over one connection, redialed after
+// errors. Each publish is followed by a PING, and returns once the server's
+// PONG confirms it processed the message.
+type natsConn struct {
+	addr     string
+	tls      *tls.Config
+	forceTLS bool
+	user     string
+	pass     string
+	token    string
+	timeout  time.Duration
+
+	mu      sync.Mutex
+	conn    net.Conn
+	r       *bufio.Reader
+	headers bool // Server accepts HPUB
+}
+
+type natsInfo struct {
+	TLSRequired bool `json:"tls_required"`
+	Headers     bool `json:"headers"`
+	MaxPayload  int  `json:"max_payload"`
+}
+
+func (n *natsConn) Publish(ctx context.Context, m Message) error {
+	if m.Topic == "" || strings.ContainsAny(m.Topic, " \t\r\n") {
+		return fmt.Errorf("eventsink: invalid NATS subject %q", m.Topic)
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.conn == nil {
+		if err := n.connect(ctx); err != nil {
+			return err
+		}
+	}
+	if err := n.publish(ctx, m); err != nil {
+		n.conn.Close()
+		n.conn = nil
+		return fmt.Errorf("eventsink: nats: %w", err)
+	}
+	return nil
+}
+
+func (n *natsConn) publish(ctx context.Context, m Message) error {
+	n.setDeadline(ctx)
+
+	var msg strings.Builder
+	if n.headers && len(m.Headers) > 0 {
+		var hdr strings.Builder
+		hdr.WriteString("NATS/1.0\r\n")
+		keys := make([]string, 0, len(m.Headers))
+		for k := range m.Headers {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&hdr, "%s: %s\r\n", k, m.Headers[k])
+		}
+		hdr.WriteString("\r\n")
+		fmt.Fprintf(&msg, "HPUB %s %d %d\r\n%s", m.Topic, hdr.Len(), hdr.Len()+len(m.Value), hdr.String())
+	} else {
+		fmt.Fprintf(&msg, "PUB %s %d\r\n", m.Topic, len(m.Value))
+	}
+	msg.Write(m.Value)
+	msg.WriteString("\r\nPING\r\n")
+	if _, err := n.conn.Write([]byte(msg.String())); err != nil {
+		return err
+	}
+	return n.awaitPong()
+}
+
+func (n *natsConn) connect(ctx context.Context) error {
+	dialer := &net.Dialer{Timeout: n.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return fmt.Errorf("eventsink: nats: %w", err)
+	}
+	n.conn, n.r = conn, bufio.NewReader(conn)
+	if err := n.handshake(ctx); err != nil {
+		n.conn.Close()
+		n.conn = nil
+		return fmt.Errorf("eventsink: nats: %w", err)
+	}
+	return nil
+}
+
+// handshake reads the server's INFO, upgrades to TLS if either side asks for
+// it, and authenticates with CONNECT.
+func (n *natsConn) handshake(ctx context.Context) error {
+	n.setDeadline(ctx)
+	line, err := n.r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	payload, ok := strings.CutPrefix(strings.TrimSpace(line), "INFO ")
+	if !ok {
+		return fmt.Errorf("unexpected greeting %q", strings.TrimSpace(line))
+	}
+	var info natsInfo
+	if err := json.Unmarshal([]byte(payload), &info); err != nil {
+		return fmt.Errorf("invalid INFO: %w", err)
+	}
+	n.headers = info.Headers
+
+	if n.forceTLS || info.TLSRequired {
+		config := n.tls
+		if config == nil {
+			config = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		config = config.Clone()
+		if config.ServerName == "" {
+			config.ServerName, _, _ = net.SplitHostPort(n.addr)
+		}
+		tlsConn := tls.Client(n.conn, config)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return err
+		}
+		n.conn, n.r = tlsConn, bufio.NewReader(tlsConn)
+	}
+
+	connect, _ := json.Marshal(map[string]interface{}{
+		"verbose":      false,
+		"pedantic":     false,
+		"tls_required": n.forceTLS || info.TLSRequired,
+		"name":         "shadowpay-proxy",
+		"lang":         "go",
+		"version":      "1.0.0",
+		"protocol":     1,
+		"headers":      info.Headers,
+		"user":         n.user,
+		"pass":         n.pass,
+		"auth_token":   n.token,
+	})
+	if _, err := fmt.Fprintf(n.conn, "CONNECT %s\r\nPING\r\n", connect); err != nil {
+		return err
+	}
+	return n.awaitPong()
+}
+
+// awaitPong reads server messages until the PONG answering our PING.
+func (n *natsConn) awaitPong() error {
+	for {
+		line, err := n.r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := n.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New(strings.Trim(strings.TrimPrefix(line, "-ERR "), "'"))
+		}
+		// +OK and INFO updates need no answer
+	}
+}
+
+func (n *natsConn) setDeadline(ctx context.Context) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(n.timeout)
+	}
+	n.conn.SetDeadline(deadline)
+}
+
+func (n *natsConn) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.conn == nil {
+		return nil
+	}
+	err := n.conn.Close()
+	n.conn = nil
+	return err
+}
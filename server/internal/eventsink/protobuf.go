@@ -0,0 +1,38 @@
+package eventsink
+
+import (
+	"encoding/binary"
+
+	"sol_privacy/internal/events"
+)
+
+// Protobuf wire types
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+// marshalProto encodes an event as the Event message of event.proto.
+func marshalProto(e events.Event) []byte {
+	var b []byte
+	b = appendProtoBytes(b, 1, []byte(e.ID))
+	b = appendProtoBytes(b, 2, []byte(e.Type))
+	b = appendProtoBytes(b, 3, []byte(e.Source))
+	if e.Timestamp != 0 {
+		b = binary.AppendUvarint(b, 4<<3|wireVarint)
+		b = binary.AppendUvarint(b, uint64(e.Timestamp))
+	}
+	b = appendProtoBytes(b, 5, e.Data)
+	return b
+}
+
+// appendProtoBytes appends a length-delimited field, omitted when empty as
+// proto3 does.
+func appendProtoBytes(b []byte, field int, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = binary.AppendUvarint(b, uint64(field)<<3|wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
@@ -0,0 +1,81 @@
+// Package faucet requests SOL airdrops on devnet and waits until they land,
+// so flows that spend SOL can be tried without funding wallets by hand.
+package faucet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"sol_privacy/internal/amount"
+	"sol_privacy/internal/confirm"
+	"sol_privacy/internal/solana"
+	"sol_privacy/internal/wallet"
+)
+
+// DefaultAmount is airdropped when no amount is given (1 SOL).
+const DefaultAmount amount.Amount = 1_000_000_000
+
+// ErrMainnet is returned for airdrops against mainnet, which has no faucet.
+var ErrMainnet = errors.New("faucet: mainnet has no faucet; use a devnet RPC URL")
+
+// Result describes a confirmed airdrop.
+type Result struct {
+	Signature string        `json:"signature"`
+	Amount    amount.Amount `json:"amount"`
+	Balance   amount.Amount `json:"balance"` // Wallet balance once the airdrop landed
+}
+
+// Airdrop requests lamports for address from the cluster's faucet and blocks
+// until the airdrop is confirmed. A zero amount requests DefaultAmount. The
+// public devnet faucet is rate limited; its refusals are returned as errors.
+func Airdrop(ctx context.Context, rpc *solana.Client, address string, lamports amount.Amount) (*Result, error) {
+	if rpc.URL() == solana.MainnetRPCURL {
+		return nil, ErrMainnet
+	}
+	if _, err := wallet.PublicKey(address); err != nil {
+		return nil, err
+	}
+	if lamports == 0 {
+		lamports = DefaultAmount
+	}
+	if lamports < 0 {
+		return nil, fmt.Errorf("faucet: invalid amount %d", lamports)
+	}
+
+	sig, err := rpc.RequestAirdrop(ctx, address, uint64(lamports))
+	if err != nil {
+		return nil, fmt.Errorf("faucet: airdrop refused: %w", err)
+	}
+	status, err := confirm.NewTracker(rpc, 0).WaitConfirmed(ctx, sig)
+	if err != nil {
+		return nil, err
+	}
+	if status.Status == confirm.StatusFailed {
+		return nil, fmt.Errorf("faucet: airdrop %s failed: %s", sig, status.Error)
+	}
+
+	balance, err := rpc.GetBalance(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Signature: sig, Amount: lamports, Balance: amount.Amount(balance)}, nil
+}
+
+// EnsureBalance airdrops to address when its balance is below min, requesting
+// the shortfall or DefaultAmount, whichever is larger. It returns the airdrop,
+// or nil if the wallet already had enough.
+func EnsureBalance(ctx context.Context, rpc *solana.Client, address string, min amount.Amount) (*Result, error) {
+	balance, err := rpc.GetBalance(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	if amount.Amount(balance) >= min {
+		return nil, nil
+	}
+	lamports := min - amount.Amount(balance)
+	if lamports < DefaultAmount {
+		lamports = DefaultAmount
+	}
+	return Airdrop(ctx, rpc, address, lamports)
+}
@@ -0,0 +1,218 @@
+// Package gallery runs the SDK's example scenarios (escrow, x402 paywall,
+// stealth payment, bot authorization) against a configured deployment. Every
+// step is reported with its outcome, so the gallery doubles as an integration
+// smoke test. A dry run performs only the steps that need no network and
+// lists the requests the others would send.
+package gallery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	shadowpay "sol_privacy"
+	"sol_privacy/internal/amount"
+	"sol_privacy/internal/client"
+	"sol_privacy/internal/wallet"
+	"sol_privacy/internal/wallets"
+)
+
+// DefaultAmount is the payment size when EXAMPLES_AMOUNT is unset (0.001 SOL).
+const DefaultAmount amount.Amount = 1_000_000
+
+// Step outcomes.
+const (
+	StatusOK      = "ok"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped" // Needs the network in a dry run
+)
+
+// Scenario is an example flow.
+type Scenario struct {
+	Name        string
+	Description string
+	run         func(ctx context.Context, r *run) error
+}
+
+// scenarios is the registry, in gallery order.
+var scenarios = []Scenario{
+	escrowScenario,
+	paywallScenario,
+	stealthScenario,
+	authorizationScenario,
+}
+
+// Scenarios returns every scenario in gallery order.
+func Scenarios() []Scenario {
+	return append([]Scenario(nil), scenarios...)
+}
+
+// Select returns the named scenarios in the order given, or all of them if
+// names is empty.
+func Select(names []string) ([]Scenario, error) {
+	if len(names) == 0 {
+		return Scenarios(), nil
+	}
+	var selected []Scenario
+	for _, name := range names {
+		found := false
+		for _, s := range scenarios {
+			if s.Name == strings.TrimSpace(name) {
+				selected = append(selected, s)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown scenario %q", name)
+		}
+	}
+	return selected, nil
+}
+
+// Config configures the scenarios.
+type Config struct {
+	SDK      *shadowpay.ShadowPay // Required
+	Signer   wallet.Signer        // Required, the paying wallet
+	Merchant string               // Wallet receiving payments
+	Amount   amount.Amount        // Size of each payment
+	DryRun   bool
+}
+
+// ConfigFromEnv configures the scenarios from SHADOWPAY_API_KEY,
+// SHADOWPAY_BASE_URL and:
+//
+//   - EXAMPLES_WALLET: the named wallet that pays (default: the default wallet)
+//   - EXAMPLES_MERCHANT: the address that gets paid (default: a new address)
+//   - EXAMPLES_AMOUNT: the payment size in SOL (default 0.001)
+//
+// Without a wallet to sign with, a throwaway keypair is generated; it has no
+// funds, so only a dry run gets far with it.
+func ConfigFromEnv() (Config, error) {
+	var opts []client.Option
+	if baseURL := os.Getenv("SHADOWPAY_BASE_URL"); baseURL != "" {
+		opts = append(opts, client.WithBaseURL(baseURL))
+	}
+	c := Config{
+		SDK:      shadowpay.New(os.Getenv("SHADOWPAY_API_KEY"), opts...),
+		Merchant: os.Getenv("EXAMPLES_MERCHANT"),
+		Amount:   DefaultAmount,
+	}
+
+	ws, err := wallets.Open(wallets.DefaultPath())
+	if err != nil {
+		return c, err
+	}
+	name := os.Getenv("EXAMPLES_WALLET")
+	if c.Signer, err = ws.Signer(name); err != nil {
+		if name != "" {
+			return c, fmt.Errorf("EXAMPLES_WALLET: %w", err)
+		}
+		if c.Signer, err = wallet.Generate(); err != nil {
+			return c, err
+		}
+	}
+
+	if c.Merchant == "" {
+		kp, err := wallet.Generate()
+		if err != nil {
+			return c, err
+		}
+		c.Merchant = kp.Address()
+	}
+	if s := os.Getenv("EXAMPLES_AMOUNT"); s != "" {
+		if c.Amount, err = amount.ParseSOL(s); err != nil {
+			return c, fmt.Errorf("EXAMPLES_AMOUNT: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// Step is the outcome of one step of a scenario.
+type Step struct {
+	Name    string  `json:"name"`
+	Status  string  `json:"status"`
+	Seconds float64 `json:"duration_seconds"`
+	Detail  any     `json:"detail,omitempty"` // What the step produced, or the request a dry run skipped
+	Error   string  `json:"error,omitempty"`
+}
+
+// Result is the outcome of a scenario. It failed if any step failed.
+type Result struct {
+	Scenario string  `json:"scenario"`
+	OK       bool    `json:"ok"`
+	DryRun   bool    `json:"dry_run,omitempty"`
+	Seconds  float64 `json:"duration_seconds"`
+	Steps    []Step  `json:"steps"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// Run runs the scenarios in order. A failing scenario stops at the failed
+// step; the following scenarios still run.
+func Run(ctx context.Context, config Config, selected []Scenario) []Result {
+	results := make([]Result, 0, len(selected))
+	for _, s := range selected {
+		results = append(results, runScenario(ctx, config, s))
+	}
+	return results
+}
+
+func runScenario(ctx context.Context, config Config, s Scenario) Result {
+	start := time.Now()
+	r := &run{config: config}
+	err := errors.New("gallery needs an SDK and a signer")
+	if config.SDK != nil && config.Signer != nil {
+		err = s.run(ctx, r)
+	}
+	result := Result{
+		Scenario: s.Name,
+		OK:       err == nil,
+		DryRun:   config.DryRun,
+		Seconds:  time.Since(start).Seconds(),
+		Steps:    r.steps,
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// run records the steps of a scenario in progress.
+type run struct {
+	config Config
+	steps  []Step
+}
+
+// local runs a step that needs no network, also in a dry run.
+func (r *run) local(name string, fn func() (any, error)) error {
+	start := time.Now()
+	detail, err := fn()
+	return r.record(name, start, detail, err)
+}
+
+// call runs a step that reaches the API or the cluster, or depends on one
+// that did. A dry run skips it, recording request as its detail.
+func (r *run) call(ctx context.Context, name string, request any, fn func(ctx context.Context) (any, error)) error {
+	if r.config.DryRun {
+		r.steps = append(r.steps, Step{Name: name, Status: StatusSkipped, Detail: request})
+		return nil
+	}
+	start := time.Now()
+	detail, err := fn(ctx)
+	return r.record(name, start, detail, err)
+}
+
+func (r *run) record(name string, start time.Time, detail any, err error) error {
+	step := Step{Name: name, Status: StatusOK, Seconds: time.Since(start).Seconds(), Detail: detail}
+	if err != nil {
+		step.Status = StatusFailed
+		step.Detail = nil
+		step.Error = err.Error()
+		err = fmt.Errorf("%s: %w", name, err)
+	}
+	r.steps = append(r.steps, step)
+	return err
+}
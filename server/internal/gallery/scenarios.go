@@ -0,0 +1,273 @@
+package gallery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"sol_privacy/agent"
+	"sol_privacy/internal/authorization"
+	"sol_privacy/internal/escrow"
+	"sol_privacy/internal/merchant"
+	"sol_privacy/internal/payment"
+	"sol_privacy/internal/stealth"
+	"sol_privacy/internal/types"
+	"sol_privacy/internal/wallet"
+	"sol_privacy/internal/x402"
+)
+
+var escrowScenario = Scenario{
+	Name:        "escrow",
+	Description: "Check the escrow balance, then build and sign a deposit and a withdrawal",
+	run: func(ctx context.Context, r *run) error {
+		sdk, signer := r.config.SDK, r.config.Signer
+		address := signer.Address()
+
+		if err := r.call(ctx, "get escrow balance", address, func(ctx context.Context) (any, error) {
+			balance, err := sdk.Escrow.GetBalance(ctx, address)
+			if err != nil {
+				return nil, err
+			}
+			return fmt.Sprintf("%s SOL", balance.BalanceAmount().SOL()), nil
+		}); err != nil {
+			return err
+		}
+
+		req := escrow.TransactionRequest{WalletAddress: types.Address(address), Amount: r.config.Amount.Int64()}
+		var deposit, withdrawal *types.UnsignedTxResponse
+		if err := r.call(ctx, "build deposit", req, func(ctx context.Context) (any, error) {
+			var err error
+			deposit, err = sdk.Escrow.Deposit(ctx, req)
+			return deposit, err
+		}); err != nil {
+			return err
+		}
+		if err := r.call(ctx, "sign deposit", nil, func(context.Context) (any, error) {
+			return signed(signer, deposit.UnsignedTxBase64)
+		}); err != nil {
+			return err
+		}
+
+		if err := r.call(ctx, "build withdrawal", req, func(ctx context.Context) (any, error) {
+			var err error
+			withdrawal, err = sdk.Escrow.Withdraw(ctx, req)
+			return withdrawal, err
+		}); err != nil {
+			return err
+		}
+		return r.call(ctx, "sign withdrawal", nil, func(context.Context) (any, error) {
+			return signed(signer, withdrawal.UnsignedTxBase64)
+		})
+	},
+}
+
+var paywallScenario = Scenario{
+	Name:        "x402-paywall",
+	Description: "Serve a resource behind the x402 middleware and pay for it with the agent",
+	run: func(ctx context.Context, r *run) error {
+		sdk := r.config.SDK
+
+		content := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"report":"paid content"}`))
+		})
+		var paywall http.Handler
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			paywall.ServeHTTP(w, req)
+		}))
+		defer server.Close()
+		url := server.URL + "/report"
+
+		if err := r.local("build payment requirements", func() (any, error) {
+			accepts, err := x402.NewRequirements().
+				Price(r.config.Amount.Int64()).
+				Resource(url).
+				Description("Example report").
+				PayTo(r.config.Merchant).
+				Build()
+			if err != nil {
+				return nil, err
+			}
+			paywall = sdk.Payment.RequireAccess(payment.MiddlewareOptions{
+				Accepts: []payment.Requirements{accepts},
+			})(content)
+			return accepts, nil
+		}); err != nil {
+			return err
+		}
+
+		if err := r.local("unpaid request is refused", func() (any, error) {
+			resp, err := http.Get(url)
+			if err != nil {
+				return nil, err
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusPaymentRequired {
+				return nil, fmt.Errorf("got %s, want 402 Payment Required", resp.Status)
+			}
+			var challenge agent.Challenge
+			if err := json.NewDecoder(resp.Body).Decode(&challenge); err != nil {
+				return nil, fmt.Errorf("invalid challenge: %w", err)
+			}
+			if len(challenge.Accepts) == 0 {
+				return nil, fmt.Errorf("challenge offers no payment option")
+			}
+			return fmt.Sprintf("402 with %d payment option(s)", len(challenge.Accepts)), nil
+		}); err != nil {
+			return err
+		}
+
+		return r.call(ctx, "agent pays and fetches", url, func(ctx context.Context) (any, error) {
+			a, err := agent.New(agent.Config{SDK: sdk, Signer: r.config.Signer})
+			if err != nil {
+				return nil, err
+			}
+			result, err := a.Pay(ctx, url, r.config.Amount.Int64())
+			if err != nil {
+				return nil, err
+			}
+			defer result.Response.Body.Close()
+			body, _ := io.ReadAll(io.LimitReader(result.Response.Body, 1024))
+			if result.Response.StatusCode != http.StatusOK {
+				return nil, fmt.Errorf("resource answered %s after payment", result.Response.Status)
+			}
+			return map[string]any{
+				"paid":    result.Paid,
+				"amount":  result.Amount.SOL(),
+				"tx_sig":  result.TxSig,
+				"content": string(body),
+			}, nil
+		})
+	},
+}
+
+var stealthScenario = Scenario{
+	Name:        "stealth",
+	Description: "Derive a one-time stealth address, recover its key and build a payout to it",
+	run: func(ctx context.Context, r *run) error {
+		var meta string
+		var target *stealth.Payment
+		scan, err := stealth.NewScanKey(nil)
+		if err != nil {
+			return err
+		}
+
+		if err := r.local("recipient publishes a meta-address", func() (any, error) {
+			meta = stealth.MetaAddress(scan)
+			return meta, nil
+		}); err != nil {
+			return err
+		}
+		if err := r.local("sender derives a stealth address", func() (any, error) {
+			ephemeral, err := stealth.NewScanKey(nil)
+			if err != nil {
+				return nil, err
+			}
+			target, err = stealth.Derive(meta, ephemeral)
+			return target, err
+		}); err != nil {
+			return err
+		}
+		if err := r.local("recipient recovers its key", func() (any, error) {
+			kp, err := stealth.Recover(scan, target.EphemeralPublicKey)
+			if err != nil {
+				return nil, err
+			}
+			if kp.Address() != target.Address {
+				return nil, fmt.Errorf("recovered %s, want %s", kp.Address(), target.Address)
+			}
+			return kp.Address(), nil
+		}); err != nil {
+			return err
+		}
+
+		req := merchant.WithdrawRequest{Amount: r.config.Amount.Int64(), Destination: target.Address}
+		var payout *merchant.WithdrawResponse
+		if err := r.call(ctx, "build payout to the stealth address", req, func(ctx context.Context) (any, error) {
+			var err error
+			payout, err = r.config.SDK.Merchant.Withdraw(ctx, req)
+			return payout, err
+		}); err != nil {
+			return err
+		}
+		return r.call(ctx, "sign payout", nil, func(context.Context) (any, error) {
+			return signed(r.config.Signer, payout.Transaction)
+		})
+	},
+}
+
+var authorizationScenario = Scenario{
+	Name:        "bot-authorization",
+	Description: "Authorize a bot to spend with limits, find it in the list, then revoke it",
+	run: func(ctx context.Context, r *run) error {
+		sdk, signer := r.config.SDK, r.config.Signer
+		service := fmt.Sprintf("gallery-bot-%d", time.Now().Unix())
+
+		req := authorization.AuthorizeSpendingRequest{
+			AuthorizedService: service,
+			MaxAmountPerTx:    r.config.Amount.SOL(),
+			MaxDailySpend:     (10 * r.config.Amount).SOL(),
+			ValidUntil:        time.Now().Add(time.Hour).Unix(),
+		}
+		if err := r.local("sign authorization", func() (any, error) {
+			if err := authorization.SignAuthorization(signer, &req); err != nil {
+				return nil, err
+			}
+			return req.UserWallet, nil
+		}); err != nil {
+			return err
+		}
+		if err := r.call(ctx, "authorize bot spending", req, func(ctx context.Context) (any, error) {
+			resp, err := sdk.Authorization.AuthorizeSpending(ctx, req)
+			if err == nil && !resp.Success {
+				err = fmt.Errorf("not authorized: %s", resp.Message)
+			}
+			return resp, err
+		}); err != nil {
+			return err
+		}
+
+		if err := r.call(ctx, "list authorizations", signer.Address(), func(ctx context.Context) (any, error) {
+			list, err := sdk.Authorization.ListAuthorizations(ctx, signer.Address())
+			if err != nil {
+				return nil, err
+			}
+			for _, a := range list.Authorizations {
+				if a.AuthorizedService == service && !a.Revoked {
+					return a, nil
+				}
+			}
+			return nil, fmt.Errorf("%s is not listed", service)
+		}); err != nil {
+			return err
+		}
+
+		revoke := authorization.RevokeAuthorizationRequest{AuthorizedService: service}
+		if err := r.local("sign revocation", func() (any, error) {
+			return nil, authorization.SignRevocation(signer, &revoke)
+		}); err != nil {
+			return err
+		}
+		return r.call(ctx, "revoke authorization", revoke, func(ctx context.Context) (any, error) {
+			resp, err := sdk.Authorization.RevokeAuthorization(ctx, revoke)
+			if err == nil && !resp.Success {
+				err = fmt.Errorf("not revoked: %s", resp.Message)
+			}
+			return resp, err
+		})
+	},
+}
+
+// signed signs a transaction a scenario built, to check it is well formed.
+// Scenarios never submit what they build.
+func signed(signer wallet.Signer, txBase64 string) (any, error) {
+	tx, err := wallet.SignTransaction(signer, txBase64)
+	if err != nil {
+		return nil, err
+	}
+	return fmt.Sprintf("signed, %d base64 characters; not submitted", len(tx)), nil
+}
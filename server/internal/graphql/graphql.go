@@ -0,0 +1,397 @@
+// Package graphql is a small read-only GraphQL executor. Root fields are backed by
+// resolver functions returning ordinary Go values; their JSON form is projected onto
+// the query's selection sets, with camelCase field names matching snake_case JSON keys.
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Pagination defaults for connection fields.
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// Args holds the resolved arguments of a root field.
+type Args map[string]interface{}
+
+// String returns a string argument, or "" if unset.
+func (a Args) String(name string) string {
+	s, _ := a[name].(string)
+	return s
+}
+
+// Int returns an integer argument, or def if unset.
+func (a Args) Int(name string, def int) (int, error) {
+	switch v := a[name].(type) {
+	case nil:
+		return def, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		if v != float64(int(v)) {
+			return 0, fmt.Errorf("argument %s must be an integer", name)
+		}
+		return int(v), nil
+	}
+	return 0, fmt.Errorf("argument %s must be an integer", name)
+}
+
+// Bool returns a boolean argument, or nil if unset.
+func (a Args) Bool(name string) (*bool, error) {
+	switch v := a[name].(type) {
+	case nil:
+		return nil, nil
+	case bool:
+		return &v, nil
+	}
+	return nil, fmt.Errorf("argument %s must be a boolean", name)
+}
+
+// Floats returns a list of numbers argument, or nil if unset.
+func (a Args) Floats(name string) ([]float64, error) {
+	switch v := a[name].(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		out := make([]float64, len(v))
+		for i, item := range v {
+			switch n := item.(type) {
+			case int64:
+				out[i] = float64(n)
+			case float64:
+				out[i] = n
+			default:
+				return nil, fmt.Errorf("argument %s must be a list of numbers", name)
+			}
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("argument %s must be a list of numbers", name)
+}
+
+// ResolveFunc resolves a root query field.
+type ResolveFunc func(ctx context.Context, args Args) (interface{}, error)
+
+// Schema is a set of root query fields.
+type Schema struct {
+	fields map[string]ResolveFunc
+}
+
+// NewSchema creates an empty schema.
+func NewSchema() *Schema {
+	return &Schema{fields: make(map[string]ResolveFunc)}
+}
+
+// Field registers a root query field.
+func (s *Schema) Field(name string, fn ResolveFunc) {
+	s.fields[name] = fn
+}
+
+// Request is a GraphQL request body.
+type Request struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+}
+
+// Error is a GraphQL error.
+type Error struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// Response is a GraphQL response body.
+type Response struct {
+	Data   *Object `json:"data"`
+	Errors []Error `json:"errors,omitempty"`
+}
+
+// Execute parses and runs a query. Root fields are resolved concurrently;
+// a failing field is returned as null with an entry in Errors.
+func (s *Schema) Execute(ctx context.Context, req Request) *Response {
+	doc, err := Parse(req.Query)
+	if err != nil {
+		return &Response{Errors: []Error{{Message: err.Error()}}}
+	}
+	if req.OperationName != "" && doc.Name != "" && req.OperationName != doc.Name {
+		return &Response{Errors: []Error{{Message: fmt.Sprintf("unknown operation %q", req.OperationName)}}}
+	}
+
+	vars := make(map[string]interface{}, len(doc.Variables))
+	for k, v := range doc.Variables {
+		vars[k] = v
+	}
+	for k, v := range req.Variables {
+		vars[k] = normalizeNumber(v)
+	}
+
+	for _, f := range doc.Selections {
+		if _, ok := s.fields[f.Name]; !ok && f.Name != "__typename" {
+			return &Response{Errors: []Error{{
+				Message: fmt.Sprintf("cannot query field %q on type Query", f.Name),
+				Path:    []interface{}{f.ResponseKey()},
+			}}}
+		}
+	}
+
+	values := make([]interface{}, len(doc.Selections))
+	errs := make([][]Error, len(doc.Selections))
+	var wg sync.WaitGroup
+	for i, f := range doc.Selections {
+		if f.Name == "__typename" {
+			values[i] = "Query"
+			continue
+		}
+		wg.Add(1)
+		go func(i int, f Field) {
+			defer wg.Done()
+			args := make(Args, len(f.Arguments))
+			for k, v := range f.Arguments {
+				args[k] = v.Resolve(vars)
+			}
+			path := []interface{}{f.ResponseKey()}
+			raw, err := s.fields[f.Name](ctx, args)
+			if err != nil {
+				errs[i] = []Error{{Message: err.Error(), Path: path}}
+				return
+			}
+			values[i], errs[i] = project(raw, f, path)
+		}(i, f)
+	}
+	wg.Wait()
+
+	resp := &Response{Data: newObject()}
+	for i, f := range doc.Selections {
+		resp.Data.Set(f.ResponseKey(), values[i])
+		resp.Errors = append(resp.Errors, errs[i]...)
+	}
+	return resp
+}
+
+// project converts a resolved value to JSON form and keeps only the selected fields.
+func project(raw interface{}, f Field, path []interface{}) (interface{}, []Error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, []Error{{Message: "failed to encode result: " + err.Error(), Path: path}}
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var generic interface{}
+	if err := dec.Decode(&generic); err != nil {
+		return nil, []Error{{Message: "failed to encode result: " + err.Error(), Path: path}}
+	}
+	return selectFields(generic, f.Selections, path)
+}
+
+func selectFields(v interface{}, sels []Field, path []interface{}) (interface{}, []Error) {
+	switch val := v.(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		var errs []Error
+		for i, item := range val {
+			var itemErrs []Error
+			out[i], itemErrs = selectFields(item, sels, appendPath(path, i))
+			errs = append(errs, itemErrs...)
+		}
+		return out, errs
+	case map[string]interface{}:
+		if len(sels) == 0 {
+			return nil, []Error{{Message: "field of object type requires a selection set", Path: path}}
+		}
+		out := newObject()
+		var errs []Error
+		for _, sel := range sels {
+			key := sel.ResponseKey()
+			if sel.Name == "__typename" {
+				out.Set(key, "Object")
+				continue
+			}
+			child, ok := val[sel.Name]
+			if !ok {
+				child = val[snakeCase(sel.Name)]
+			}
+			projected, childErrs := selectFields(child, sel.Selections, appendPath(path, key))
+			out.Set(key, projected)
+			errs = append(errs, childErrs...)
+		}
+		return out, errs
+	default:
+		if len(sels) > 0 {
+			return nil, []Error{{Message: "scalar field cannot have a selection set", Path: path}}
+		}
+		return val, nil
+	}
+}
+
+func appendPath(path []interface{}, elem interface{}) []interface{} {
+	out := make([]interface{}, len(path), len(path)+1)
+	copy(out, path)
+	return append(out, elem)
+}
+
+// snakeCase converts totalEarnings to total_earnings.
+func snakeCase(s string) string {
+	var sb strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				sb.WriteByte('_')
+			}
+			sb.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// normalizeNumber converts JSON variable numbers to int64 when they are integral.
+func normalizeNumber(v interface{}) interface{} {
+	switch n := v.(type) {
+	case float64:
+		if n == float64(int64(n)) {
+			return int64(n)
+		}
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			return i
+		}
+		f, _ := n.Float64()
+		return f
+	}
+	return v
+}
+
+// Object is a JSON object that preserves field order, as GraphQL responses must.
+type Object struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newObject() *Object {
+	return &Object{values: make(map[string]interface{})}
+}
+
+// Set adds or replaces a field.
+func (o *Object) Set(key string, value interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// Get returns a field value.
+func (o *Object) Get(key string) interface{} {
+	return o.values[key]
+}
+
+// MarshalJSON implements json.Marshaler.
+func (o *Object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		kb, _ := json.Marshal(k)
+		buf.Write(kb)
+		buf.WriteByte(':')
+		vb, err := json.Marshal(o.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(vb)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Connection is a cursor-paginated list in the Relay connection shape.
+type Connection struct {
+	Edges      []Edge        `json:"edges"`
+	Nodes      []interface{} `json:"nodes"`
+	PageInfo   PageInfo      `json:"page_info"`
+	TotalCount int           `json:"total_count"`
+}
+
+// Edge is a node with its cursor.
+type Edge struct {
+	Cursor string      `json:"cursor"`
+	Node   interface{} `json:"node"`
+}
+
+// PageInfo describes the position of a page.
+type PageInfo struct {
+	HasNextPage     bool   `json:"has_next_page"`
+	HasPreviousPage bool   `json:"has_previous_page"`
+	StartCursor     string `json:"start_cursor,omitempty"`
+	EndCursor       string `json:"end_cursor,omitempty"`
+}
+
+// Page reads the first/after arguments of a connection field.
+func Page(args Args) (first, offset int, err error) {
+	first, err = args.Int("first", DefaultPageSize)
+	if err != nil {
+		return 0, 0, err
+	}
+	if first < 1 || first > MaxPageSize {
+		return 0, 0, fmt.Errorf("argument first must be between 1 and %d", MaxPageSize)
+	}
+	if after := args.String("after"); after != "" {
+		if offset, err = DecodeCursor(after); err != nil {
+			return 0, 0, err
+		}
+		offset++
+	}
+	return first, offset, nil
+}
+
+// NewConnection builds a connection page from nodes starting at offset out of total.
+func NewConnection[T any](nodes []T, offset, total int) *Connection {
+	c := &Connection{
+		Edges:      make([]Edge, len(nodes)),
+		Nodes:      make([]interface{}, len(nodes)),
+		TotalCount: total,
+	}
+	for i, n := range nodes {
+		c.Edges[i] = Edge{Cursor: EncodeCursor(offset + i), Node: n}
+		c.Nodes[i] = n
+	}
+	c.PageInfo.HasPreviousPage = offset > 0
+	c.PageInfo.HasNextPage = offset+len(nodes) < total
+	if len(nodes) > 0 {
+		c.PageInfo.StartCursor = c.Edges[0].Cursor
+		c.PageInfo.EndCursor = c.Edges[len(nodes)-1].Cursor
+	}
+	return c
+}
+
+// EncodeCursor returns the opaque cursor for a list offset.
+func EncodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(offset)))
+}
+
+// DecodeCursor returns the list offset of a cursor.
+func DecodeCursor(cursor string) (int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(b), "offset:") {
+		return 0, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(string(b), "offset:"))
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	return n, nil
+}
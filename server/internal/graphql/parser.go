@@ -0,0 +1,500 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Document is a parsed query operation.
+type Document struct {
+	Name       string
+	Variables  map[string]interface{} // Default values of declared variables
+	Selections []Field
+}
+
+// Field is a selected field with its arguments and sub-selections.
+type Field struct {
+	Alias      string
+	Name       string
+	Arguments  map[string]Value
+	Selections []Field
+}
+
+// ResponseKey is the key the field is returned under.
+func (f Field) ResponseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+// Value is an argument value, possibly referencing a variable.
+type Value struct {
+	Variable string
+	Literal  interface{}
+	List     []Value
+	Object   map[string]Value
+}
+
+// Resolve substitutes variables and returns a plain Go value.
+func (v Value) Resolve(vars map[string]interface{}) interface{} {
+	switch {
+	case v.Variable != "":
+		return vars[v.Variable]
+	case v.List != nil:
+		out := make([]interface{}, len(v.List))
+		for i, item := range v.List {
+			out[i] = item.Resolve(vars)
+		}
+		return out
+	case v.Object != nil:
+		out := make(map[string]interface{}, len(v.Object))
+		for k, item := range v.Object {
+			out[k] = item.Resolve(vars)
+		}
+		return out
+	}
+	return v.Literal
+}
+
+// Parse parses a query document containing a single operation.
+// Supported: query keyword and name, variable definitions with defaults, aliases,
+// arguments of every literal kind, and nested selection sets. Fragments,
+// directives and mutations are not supported.
+func Parse(query string) (*Document, error) {
+	p := &parser{lex: newLexer(query)}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	doc, err := p.parseDocument()
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokName
+	tokInt
+	tokFloat
+	tokString
+	tokPunct
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+type lexer struct {
+	src []rune
+	pos int
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{src: []rune(src)}
+}
+
+func (l *lexer) next() (token, error) {
+	// Skip whitespace, commas and comments
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if c == '#' {
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+			continue
+		}
+		if unicode.IsSpace(c) || c == ',' {
+			l.pos++
+			continue
+		}
+		break
+	}
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF, pos: l.pos}, nil
+	}
+
+	start := l.pos
+	c := l.src[l.pos]
+	switch {
+	case strings.ContainsRune("{}():[]!$=@", c):
+		l.pos++
+		return token{kind: tokPunct, value: string(c), pos: start}, nil
+	case c == '.':
+		if l.pos+2 < len(l.src) && l.src[l.pos+1] == '.' && l.src[l.pos+2] == '.' {
+			l.pos += 3
+			return token{kind: tokPunct, value: "...", pos: start}, nil
+		}
+	case c == '_' || unicode.IsLetter(c):
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || unicode.IsLetter(l.src[l.pos]) || unicode.IsDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		return token{kind: tokName, value: string(l.src[start:l.pos]), pos: start}, nil
+	case c == '-' || unicode.IsDigit(c):
+		l.pos++
+		kind := tokInt
+		for l.pos < len(l.src) {
+			d := l.src[l.pos]
+			if unicode.IsDigit(d) {
+				l.pos++
+				continue
+			}
+			if d == '.' || d == 'e' || d == 'E' || ((d == '+' || d == '-') && (l.src[l.pos-1] == 'e' || l.src[l.pos-1] == 'E')) {
+				kind = tokFloat
+				l.pos++
+				continue
+			}
+			break
+		}
+		return token{kind: kind, value: string(l.src[start:l.pos]), pos: start}, nil
+	case c == '"':
+		return l.readString()
+	}
+	return token{}, fmt.Errorf("unexpected character %q at position %d", c, start)
+}
+
+func (l *lexer) readString() (token, error) {
+	start := l.pos
+	l.pos++
+	var sb strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch c {
+		case '"':
+			l.pos++
+			return token{kind: tokString, value: sb.String(), pos: start}, nil
+		case '\n':
+			return token{}, fmt.Errorf("unterminated string at position %d", start)
+		case '\\':
+			l.pos++
+			if l.pos >= len(l.src) {
+				return token{}, fmt.Errorf("unterminated string at position %d", start)
+			}
+			switch e := l.src[l.pos]; e {
+			case 'n':
+				sb.WriteRune('\n')
+			case 't':
+				sb.WriteRune('\t')
+			case 'r':
+				sb.WriteRune('\r')
+			case 'b':
+				sb.WriteRune('\b')
+			case 'f':
+				sb.WriteRune('\f')
+			case 'u':
+				if l.pos+4 >= len(l.src) {
+					return token{}, fmt.Errorf("invalid unicode escape at position %d", l.pos)
+				}
+				n, err := strconv.ParseUint(string(l.src[l.pos+1:l.pos+5]), 16, 32)
+				if err != nil {
+					return token{}, fmt.Errorf("invalid unicode escape at position %d", l.pos)
+				}
+				sb.WriteRune(rune(n))
+				l.pos += 4
+			default:
+				sb.WriteRune(e)
+			}
+		default:
+			sb.WriteRune(c)
+		}
+		l.pos++
+	}
+	return token{}, fmt.Errorf("unterminated string at position %d", start)
+}
+
+// maxDepth bounds selection nesting to keep hostile queries cheap.
+const maxDepth = 16
+
+type parser struct {
+	lex *lexer
+	tok token
+}
+
+func (p *parser) advance() error {
+	t, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = t
+	return nil
+}
+
+func (p *parser) is(kind tokenKind, value string) bool {
+	return p.tok.kind == kind && p.tok.value == value
+}
+
+func (p *parser) expect(kind tokenKind, value string) error {
+	if !p.is(kind, value) {
+		return p.unexpected()
+	}
+	return p.advance()
+}
+
+func (p *parser) unexpected() error {
+	if p.tok.kind == tokEOF {
+		return fmt.Errorf("unexpected end of query")
+	}
+	return fmt.Errorf("unexpected %q at position %d", p.tok.value, p.tok.pos)
+}
+
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokName {
+		return "", p.unexpected()
+	}
+	n := p.tok.value
+	return n, p.advance()
+}
+
+func (p *parser) parseDocument() (*Document, error) {
+	doc := &Document{Variables: map[string]interface{}{}}
+
+	if p.tok.kind == tokName {
+		switch p.tok.value {
+		case "query":
+		case "mutation", "subscription":
+			return nil, fmt.Errorf("%s operations are not supported", p.tok.value)
+		case "fragment":
+			return nil, fmt.Errorf("fragments are not supported")
+		default:
+			return nil, p.unexpected()
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind == tokName {
+			doc.Name = p.tok.value
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		}
+		if p.is(tokPunct, "(") {
+			if err := p.parseVariableDefinitions(doc); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sels, err := p.parseSelectionSet(0)
+	if err != nil {
+		return nil, err
+	}
+	doc.Selections = sels
+
+	if p.tok.kind != tokEOF {
+		return nil, fmt.Errorf("only a single operation is supported")
+	}
+	return doc, nil
+}
+
+func (p *parser) parseVariableDefinitions(doc *Document) error {
+	if err := p.expect(tokPunct, "("); err != nil {
+		return err
+	}
+	for !p.is(tokPunct, ")") {
+		if err := p.expect(tokPunct, "$"); err != nil {
+			return err
+		}
+		name, err := p.name()
+		if err != nil {
+			return err
+		}
+		if err := p.expect(tokPunct, ":"); err != nil {
+			return err
+		}
+		if err := p.skipType(); err != nil {
+			return err
+		}
+		doc.Variables[name] = nil
+		if p.is(tokPunct, "=") {
+			if err := p.advance(); err != nil {
+				return err
+			}
+			v, err := p.parseValue(true)
+			if err != nil {
+				return err
+			}
+			doc.Variables[name] = v.Resolve(nil)
+		}
+	}
+	return p.advance()
+}
+
+// skipType consumes a type reference such as [String!]!; types are not checked.
+func (p *parser) skipType() error {
+	if p.is(tokPunct, "[") {
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if err := p.skipType(); err != nil {
+			return err
+		}
+		if err := p.expect(tokPunct, "]"); err != nil {
+			return err
+		}
+	} else if _, err := p.name(); err != nil {
+		return err
+	}
+	if p.is(tokPunct, "!") {
+		return p.advance()
+	}
+	return nil
+}
+
+func (p *parser) parseSelectionSet(depth int) ([]Field, error) {
+	if depth >= maxDepth {
+		return nil, fmt.Errorf("query exceeds maximum depth of %d", maxDepth)
+	}
+	if err := p.expect(tokPunct, "{"); err != nil {
+		return nil, err
+	}
+	var fields []Field
+	for !p.is(tokPunct, "}") {
+		if p.is(tokPunct, "...") {
+			return nil, fmt.Errorf("fragments are not supported")
+		}
+		if p.is(tokPunct, "@") {
+			return nil, fmt.Errorf("directives are not supported")
+		}
+		f, err := p.parseField(depth)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("selection set must not be empty")
+	}
+	return fields, p.advance()
+}
+
+func (p *parser) parseField(depth int) (Field, error) {
+	var f Field
+	name, err := p.name()
+	if err != nil {
+		return f, err
+	}
+	f.Name = name
+	if p.is(tokPunct, ":") {
+		if err := p.advance(); err != nil {
+			return f, err
+		}
+		f.Alias = name
+		if f.Name, err = p.name(); err != nil {
+			return f, err
+		}
+	}
+
+	if p.is(tokPunct, "(") {
+		if err := p.advance(); err != nil {
+			return f, err
+		}
+		f.Arguments = map[string]Value{}
+		for !p.is(tokPunct, ")") {
+			argName, err := p.name()
+			if err != nil {
+				return f, err
+			}
+			if err := p.expect(tokPunct, ":"); err != nil {
+				return f, err
+			}
+			v, err := p.parseValue(false)
+			if err != nil {
+				return f, err
+			}
+			f.Arguments[argName] = v
+		}
+		if err := p.advance(); err != nil {
+			return f, err
+		}
+	}
+
+	if p.is(tokPunct, "{") {
+		if f.Selections, err = p.parseSelectionSet(depth + 1); err != nil {
+			return f, err
+		}
+	}
+	return f, nil
+}
+
+func (p *parser) parseValue(constant bool) (Value, error) {
+	t := p.tok
+	switch {
+	case t.kind == tokPunct && t.value == "$":
+		if constant {
+			return Value{}, fmt.Errorf("variables are not allowed in default values")
+		}
+		if err := p.advance(); err != nil {
+			return Value{}, err
+		}
+		name, err := p.name()
+		return Value{Variable: name}, err
+	case t.kind == tokInt:
+		n, err := strconv.ParseInt(t.value, 10, 64)
+		if err != nil {
+			return Value{}, fmt.Errorf("invalid integer %q", t.value)
+		}
+		return Value{Literal: n}, p.advance()
+	case t.kind == tokFloat:
+		f, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return Value{}, fmt.Errorf("invalid float %q", t.value)
+		}
+		return Value{Literal: f}, p.advance()
+	case t.kind == tokString:
+		return Value{Literal: t.value}, p.advance()
+	case t.kind == tokName:
+		var lit interface{}
+		switch t.value {
+		case "true":
+			lit = true
+		case "false":
+			lit = false
+		case "null":
+			lit = nil
+		default:
+			lit = t.value // Enum values are passed as strings
+		}
+		return Value{Literal: lit}, p.advance()
+	case t.kind == tokPunct && t.value == "[":
+		if err := p.advance(); err != nil {
+			return Value{}, err
+		}
+		list := []Value{}
+		for !p.is(tokPunct, "]") {
+			v, err := p.parseValue(constant)
+			if err != nil {
+				return Value{}, err
+			}
+			list = append(list, v)
+		}
+		return Value{List: list}, p.advance()
+	case t.kind == tokPunct && t.value == "{":
+		if err := p.advance(); err != nil {
+			return Value{}, err
+		}
+		obj := map[string]Value{}
+		for !p.is(tokPunct, "}") {
+			name, err := p.name()
+			if err != nil {
+				return Value{}, err
+			}
+			if err := p.expect(tokPunct, ":"); err != nil {
+				return Value{}, err
+			}
+			v, err := p.parseValue(constant)
+			if err != nil {
+				return Value{}, err
+			}
+			obj[name] = v
+		}
+		return Value{Object: obj}, p.advance()
+	}
+	return Value{}, p.unexpected()
+}
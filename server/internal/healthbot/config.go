@@ -0,0 +1,234 @@
+package healthbot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"sol_privacy/internal/client"
+	"sol_privacy/internal/validate"
+)
+
+// Checks, in the order they run.
+const (
+	CheckKeygen  = "keygen"  // Generates an ElGamal keypair
+	CheckBalance = "balance" // Reads the probe wallet's pool balance
+	CheckPrepare = "prepare" // Prepares a payment to the probe commitment
+	CheckVerify  = "verify"  // Verifies the probe token, or lists supported schemes
+)
+
+// AllChecks are run when an environment lists none.
+var AllChecks = []string{CheckKeygen, CheckBalance, CheckPrepare, CheckVerify}
+
+// Defaults.
+const (
+	DefaultInterval     = time.Minute
+	DefaultTimeout      = 15 * time.Second
+	DefaultListen       = ":9464"
+	DefaultAmount       = 1000 // Lamports prepared by the prepare check
+	DefaultAvailability = 0.99
+	DefaultLatency      = 2 * time.Second
+	DefaultWindow       = time.Hour
+	DefaultMinSamples   = 5
+	DefaultRepeat       = time.Hour
+)
+
+// Duration is a time.Duration written as "30s" in JSON.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"30s\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Config configures the bot. It is read from JSON so infrastructure tools
+// can render it, e.g. with Terraform's jsonencode.
+type Config struct {
+	Interval Duration `json:"interval,omitempty"` // Between rounds of checks
+	Timeout  Duration `json:"timeout,omitempty"`  // Per check
+	Listen   string   `json:"listen,omitempty"`   // Address serving /metrics and /status
+
+	SLO   SLO         `json:"slo,omitempty"` // Default objectives of every environment
+	Alert AlertConfig `json:"alert,omitempty"`
+
+	Environments []Environment `json:"environments"`
+}
+
+// Environment is a ShadowPay deployment to exercise.
+type Environment struct {
+	Name    string `json:"name"`
+	BaseURL string `json:"base_url,omitempty"` // Defaults to the public API
+	// APIKeyEnv names the variable holding the API key, keeping it out of
+	// the config file.
+	APIKeyEnv string `json:"api_key_env,omitempty"`
+
+	Wallet             string `json:"wallet,omitempty"`              // Needed by balance
+	ReceiverCommitment string `json:"receiver_commitment,omitempty"` // Needed by prepare
+	Amount             int64  `json:"amount,omitempty"`              // Prepared lamports
+	VerifyToken        string `json:"verify_token,omitempty"`        // Verified by verify, if set
+
+	Checks []string `json:"checks,omitempty"` // Defaults to AllChecks
+	SLO    *SLO     `json:"slo,omitempty"`    // Overrides the default objectives
+}
+
+// SLO is the objective of each check of an environment over a rolling
+// window. It is breached when too few checks succeed or the 95th percentile
+// latency is too high, once the window holds MinSamples results.
+type SLO struct {
+	Availability float64  `json:"availability,omitempty"` // Success ratio, e.g. 0.99
+	Latency      Duration `json:"latency,omitempty"`      // Maximum p95
+	Window       Duration `json:"window,omitempty"`
+	MinSamples   int      `json:"min_samples,omitempty"`
+}
+
+// AlertConfig sends SLO breaches and recoveries to a webhook, as JSON with a
+// Slack-compatible "text" field.
+type AlertConfig struct {
+	WebhookURL string   `json:"webhook_url,omitempty"`
+	Repeat     Duration `json:"repeat,omitempty"` // Re-alerts while a breach lasts
+}
+
+// LoadConfig reads a JSON config file, or with an empty path configures one
+// environment from SHADOWPAY_API_KEY, SHADOWPAY_BASE_URL, HEALTHBOT_WALLET,
+// HEALTHBOT_RECEIVER_COMMITMENT and HEALTHBOT_ALERT_WEBHOOK.
+func LoadConfig(path string) (*Config, error) {
+	var c Config
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	} else {
+		e := Environment{
+			Name:               "default",
+			BaseURL:            os.Getenv("SHADOWPAY_BASE_URL"),
+			APIKeyEnv:          "SHADOWPAY_API_KEY",
+			Wallet:             os.Getenv("HEALTHBOT_WALLET"),
+			ReceiverCommitment: os.Getenv("HEALTHBOT_RECEIVER_COMMITMENT"),
+			Checks:             []string{CheckKeygen},
+		}
+		// Checks needing probe data run when it is configured
+		if e.Wallet != "" {
+			e.Checks = append(e.Checks, CheckBalance)
+		}
+		if e.ReceiverCommitment != "" {
+			e.Checks = append(e.Checks, CheckPrepare)
+		}
+		e.Checks = append(e.Checks, CheckVerify)
+		c.Environments = []Environment{e}
+		c.Alert.WebhookURL = os.Getenv("HEALTHBOT_ALERT_WEBHOOK")
+		if s := os.Getenv("HEALTHBOT_INTERVAL"); s != "" {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return nil, fmt.Errorf("HEALTHBOT_INTERVAL: %w", err)
+			}
+			c.Interval = Duration(d)
+		}
+	}
+	c.defaults()
+	return &c, c.Validate()
+}
+
+func (c *Config) defaults() {
+	if c.Interval <= 0 {
+		c.Interval = Duration(DefaultInterval)
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = Duration(DefaultTimeout)
+	}
+	if c.Listen == "" {
+		c.Listen = DefaultListen
+	}
+	if c.Alert.Repeat <= 0 {
+		c.Alert.Repeat = Duration(DefaultRepeat)
+	}
+	c.SLO.defaults(SLO{
+		Availability: DefaultAvailability,
+		Latency:      Duration(DefaultLatency),
+		Window:       Duration(DefaultWindow),
+		MinSamples:   DefaultMinSamples,
+	})
+	for i := range c.Environments {
+		e := &c.Environments[i]
+		if e.BaseURL == "" {
+			e.BaseURL = client.DefaultBaseURL
+		}
+		if e.Amount == 0 {
+			e.Amount = DefaultAmount
+		}
+		if len(e.Checks) == 0 {
+			e.Checks = AllChecks
+		}
+		if e.SLO == nil {
+			e.SLO = &SLO{}
+		}
+		e.SLO.defaults(c.SLO)
+	}
+}
+
+func (s *SLO) defaults(d SLO) {
+	if s.Availability == 0 {
+		s.Availability = d.Availability
+	}
+	if s.Latency == 0 {
+		s.Latency = d.Latency
+	}
+	if s.Window == 0 {
+		s.Window = d.Window
+	}
+	if s.MinSamples == 0 {
+		s.MinSamples = d.MinSamples
+	}
+}
+
+// Validate checks that every environment can run its checks.
+func (c *Config) Validate() error {
+	v := validate.New()
+	if len(c.Environments) == 0 {
+		v.Add("environments", errors.New("at least one environment is required"))
+	}
+	names := make(map[string]bool)
+	for i, e := range c.Environments {
+		field := "environments[" + strconv.Itoa(i) + "]"
+		if e.Name == "" || strings.ContainsAny(e.Name, "\"\\\n") {
+			v.Add(field+".name", errors.New("must be set and not contain quotes"))
+		} else if names[e.Name] {
+			v.Add(field+".name", fmt.Errorf("duplicate environment %q", e.Name))
+		}
+		names[e.Name] = true
+		for _, check := range e.Checks {
+			switch check {
+			case CheckKeygen, CheckVerify:
+			case CheckBalance:
+				v.Address(field+".wallet", e.Wallet)
+			case CheckPrepare:
+				v.Commitment(field+".receiver_commitment", e.ReceiverCommitment)
+			default:
+				v.Add(field+".checks", fmt.Errorf("unknown check %q, expected one of %s", check, strings.Join(AllChecks, ", ")))
+			}
+		}
+		if e.SLO.Availability <= 0 || e.SLO.Availability > 1 {
+			v.Add(field+".slo.availability", errors.New("must be greater than 0 and at most 1"))
+		}
+	}
+	return v.Err()
+}
@@ -0,0 +1,320 @@
+// Package healthbot is synthetic monitoring for ShadowPay deployments. It
+// periodically exercises critical flows (keygen, balance, prepare, verify)
+// against each configured environment through the SDK, tracks every check
+// against a service level objective over a rolling window, alerts when one
+// is breached, and serves the results as Prometheus metrics and JSON.
+package healthbot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	shadowpay "sol_privacy"
+	"sol_privacy/internal/client"
+	"sol_privacy/internal/payment"
+	"sol_privacy/internal/types"
+)
+
+// Result is the outcome of one check.
+type Result struct {
+	Check    string        `json:"check"`
+	OK       bool          `json:"ok"`
+	Duration time.Duration `json:"-"`
+	Seconds  float64       `json:"duration_seconds"`
+	Error    string        `json:"error,omitempty"`
+	At       int64         `json:"at"` // Unix timestamp
+}
+
+// sample is a result kept in the SLO window.
+type sample struct {
+	at       time.Time
+	ok       bool
+	duration time.Duration
+}
+
+// checkState is the history of one check of one environment.
+type checkState struct {
+	last        Result
+	samples     []sample // Within the SLO window, oldest first
+	successes   int64
+	failures    int64
+	histogram   []int64 // Counts per latencyBuckets, plus +Inf
+	durationSum float64 // Seconds
+
+	breached  bool
+	alertedAt time.Time
+}
+
+type environment struct {
+	config  Environment
+	sdk     *shadowpay.ShadowPay
+	checks  map[string]*checkState
+	lastRun time.Time
+}
+
+// Bot runs the checks.
+type Bot struct {
+	config Config
+	http   *http.Client
+
+	mu   sync.Mutex
+	envs []*environment
+}
+
+// New creates a bot from a validated config.
+func New(config Config) *Bot {
+	b := &Bot{config: config, http: &http.Client{Timeout: 10 * time.Second}}
+	for _, e := range config.Environments {
+		env := &environment{
+			config: e,
+			sdk:    shadowpay.New(os.Getenv(e.APIKeyEnv), client.WithBaseURL(e.BaseURL)),
+			checks: make(map[string]*checkState),
+		}
+		for _, check := range e.Checks {
+			env.checks[check] = &checkState{histogram: make([]int64, len(latencyBuckets)+1)}
+		}
+		b.envs = append(b.envs, env)
+	}
+	return b
+}
+
+// Run runs a round of checks immediately, then every Interval until ctx is
+// done.
+func (b *Bot) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(b.config.Interval))
+	defer ticker.Stop()
+	for {
+		b.RunOnce(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce runs every check of every environment, environments in parallel,
+// and reports whether all of them succeeded.
+func (b *Bot) RunOnce(ctx context.Context) bool {
+	var wg sync.WaitGroup
+	results := make([][]Result, len(b.envs))
+	for i, env := range b.envs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, check := range env.config.Checks {
+				results[i] = append(results[i], b.runCheck(ctx, env, check))
+			}
+		}()
+	}
+	wg.Wait()
+
+	ok := true
+	for i, env := range b.envs {
+		for _, r := range results[i] {
+			if !r.OK {
+				ok = false
+				log.Printf("healthbot: %s/%s failed after %s: %s", env.config.Name, r.Check, r.Duration.Round(time.Millisecond), r.Error)
+			}
+			b.record(ctx, env, r)
+		}
+	}
+	return ok
+}
+
+func (b *Bot) runCheck(ctx context.Context, env *environment, check string) Result {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(b.config.Timeout))
+	defer cancel()
+
+	start := time.Now()
+	err := b.exercise(ctx, env, check)
+	elapsed := time.Since(start)
+	r := Result{Check: check, OK: err == nil, Duration: elapsed, Seconds: elapsed.Seconds(), At: start.Unix()}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	return r
+}
+
+// exercise runs one flow and checks its response is usable.
+func (b *Bot) exercise(ctx context.Context, env *environment, check string) error {
+	sdk, e := env.sdk, env.config
+	switch check {
+	case CheckKeygen:
+		resp, err := sdk.Privacy.GenerateKeypair(ctx)
+		if err != nil {
+			return err
+		}
+		if resp.PublicKey == "" || resp.PrivateKey == "" {
+			return errors.New("keypair is incomplete")
+		}
+	case CheckBalance:
+		resp, err := sdk.Pool.GetBalance(ctx, e.Wallet)
+		if err != nil {
+			return err
+		}
+		if resp.Balance < 0 {
+			return fmt.Errorf("negative balance %d", resp.Balance)
+		}
+	case CheckPrepare:
+		resp, err := sdk.Payment.Prepare(ctx, payment.PrepareRequest{ReceiverCommitment: types.Commitment(e.ReceiverCommitment), Amount: e.Amount})
+		if err != nil {
+			return err
+		}
+		if resp.PaymentHash == "" {
+			return errors.New("no payment hash")
+		}
+	case CheckVerify:
+		if e.VerifyToken != "" {
+			// Any answer shows verification works; the token may have expired
+			_, err := sdk.Verify.X402(ctx, e.VerifyToken)
+			return err
+		}
+		resp, err := sdk.Verify.GetSupported(ctx)
+		if err != nil {
+			return err
+		}
+		if len(resp.Schemes) == 0 {
+			return errors.New("no supported schemes")
+		}
+	default:
+		return fmt.Errorf("unknown check %q", check)
+	}
+	return nil
+}
+
+// record adds a result to its check's history and alerts on SLO changes.
+func (b *Bot) record(ctx context.Context, env *environment, r Result) {
+	b.mu.Lock()
+	s := env.checks[r.Check]
+	now := time.Unix(r.At, 0)
+	env.lastRun = now
+	s.last = r
+	if r.OK {
+		s.successes++
+	} else {
+		s.failures++
+	}
+	s.histogram[sort.SearchFloat64s(latencyBuckets, r.Duration.Seconds())]++
+	s.durationSum += r.Duration.Seconds()
+
+	slo := env.config.SLO
+	s.samples = append(s.samples, sample{at: now, ok: r.OK, duration: r.Duration})
+	cutoff := now.Add(-time.Duration(slo.Window))
+	drop := 0
+	for drop < len(s.samples) && s.samples[drop].at.Before(cutoff) {
+		drop++
+	}
+	s.samples = s.samples[drop:]
+
+	report := s.report(slo)
+	var alert *Alert
+	switch {
+	case report.Breached && (!s.breached || now.Sub(s.alertedAt) >= time.Duration(b.config.Alert.Repeat)):
+		alert = &Alert{Status: "firing", Environment: env.config.Name, Check: r.Check, SLO: report}
+		s.alertedAt = now
+	case !report.Breached && s.breached:
+		alert = &Alert{Status: "resolved", Environment: env.config.Name, Check: r.Check, SLO: report}
+	}
+	s.breached = report.Breached
+	b.mu.Unlock()
+
+	if alert != nil {
+		b.alert(ctx, alert)
+	}
+}
+
+// SLOReport is a check's performance over the SLO window.
+type SLOReport struct {
+	Samples      int     `json:"samples"`
+	Availability float64 `json:"availability"` // Success ratio
+	P95Seconds   float64 `json:"p95_seconds"`
+	Target       float64 `json:"target_availability"`
+	MaxP95       float64 `json:"max_p95_seconds"`
+	Breached     bool    `json:"breached"`
+	Reason       string  `json:"reason,omitempty"`
+}
+
+func (s *checkState) report(slo *SLO) SLOReport {
+	r := SLOReport{
+		Samples:      len(s.samples),
+		Availability: 1,
+		Target:       slo.Availability,
+		MaxP95:       time.Duration(slo.Latency).Seconds(),
+	}
+	if len(s.samples) == 0 {
+		return r
+	}
+	ok := 0
+	durations := make([]time.Duration, len(s.samples))
+	for i, sample := range s.samples {
+		if sample.ok {
+			ok++
+		}
+		durations[i] = sample.duration
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	r.Availability = float64(ok) / float64(len(s.samples))
+	r.P95Seconds = durations[(len(durations)*95+99)/100-1].Seconds()
+
+	if len(s.samples) < slo.MinSamples {
+		return r
+	}
+	switch {
+	case r.Availability < slo.Availability:
+		r.Breached = true
+		r.Reason = fmt.Sprintf("availability %.2f%% below %.2f%%", r.Availability*100, slo.Availability*100)
+	case r.P95Seconds > r.MaxP95:
+		r.Breached = true
+		r.Reason = fmt.Sprintf("p95 latency %.2fs above %.2fs", r.P95Seconds, r.MaxP95)
+	}
+	return r
+}
+
+// Alert is posted to the alert webhook when a check's SLO is breached or
+// recovers.
+type Alert struct {
+	Status      string    `json:"status"` // "firing" or "resolved"
+	Environment string    `json:"environment"`
+	Check       string    `json:"check"`
+	SLO         SLOReport `json:"slo"`
+	Text        string    `json:"text"` // Human-readable summary, shown by Slack
+}
+
+func (b *Bot) alert(ctx context.Context, a *Alert) {
+	if a.Status == "firing" {
+		a.Text = fmt.Sprintf("ShadowPay %s %s SLO breached: %s", a.Environment, a.Check, a.SLO.Reason)
+	} else {
+		a.Text = fmt.Sprintf("ShadowPay %s %s SLO recovered", a.Environment, a.Check)
+	}
+	log.Printf("healthbot: %s", a.Text)
+	if b.config.Alert.WebhookURL == "" {
+		return
+	}
+
+	body, _ := json.Marshal(a)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.config.Alert.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("healthbot: alert not sent: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := b.http.Do(req)
+	if err != nil {
+		log.Printf("healthbot: alert not sent: %v", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Printf("healthbot: alert webhook returned %s", resp.Status)
+	}
+}
@@ -0,0 +1,175 @@
+package healthbot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the check latency
+// histogram.
+var latencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Status is the JSON status of every environment.
+type Status struct {
+	OK           bool                `json:"ok"` // No SLO is breached
+	Environments []EnvironmentStatus `json:"environments"`
+}
+
+// EnvironmentStatus is the state of one environment's checks.
+type EnvironmentStatus struct {
+	Name    string        `json:"name"`
+	BaseURL string        `json:"base_url"`
+	LastRun int64         `json:"last_run,omitempty"` // Unix timestamp
+	Checks  []CheckStatus `json:"checks"`
+}
+
+// CheckStatus is the last result and SLO of a check.
+type CheckStatus struct {
+	Check     string    `json:"check"`
+	Last      *Result   `json:"last,omitempty"` // Nil until the check first runs
+	Successes int64     `json:"successes"`
+	Failures  int64     `json:"failures"`
+	SLO       SLOReport `json:"slo"`
+}
+
+// Status returns the current state of every check.
+func (b *Bot) Status() Status {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := Status{OK: true}
+	for _, env := range b.envs {
+		es := EnvironmentStatus{Name: env.config.Name, BaseURL: env.config.BaseURL}
+		if !env.lastRun.IsZero() {
+			es.LastRun = env.lastRun.Unix()
+		}
+		for _, check := range env.config.Checks {
+			s := env.checks[check]
+			cs := CheckStatus{Check: check, Successes: s.successes, Failures: s.failures, SLO: s.report(env.config.SLO)}
+			if s.last.Check != "" {
+				last := s.last
+				cs.Last = &last
+			}
+			if cs.SLO.Breached {
+				status.OK = false
+			}
+			es.Checks = append(es.Checks, cs)
+		}
+		status.Environments = append(status.Environments, es)
+	}
+	return status
+}
+
+// Handler serves /metrics in the Prometheus text format, /status as JSON
+// (503 while an SLO is breached) and /healthz for the bot's own liveness.
+func (b *Bot) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		b.writeMetrics(w)
+	})
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		status := b.Status()
+		w.Header().Set("Content-Type", "application/json")
+		if !status.OK {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(status)
+	})
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok\n"))
+	})
+	return mux
+}
+
+func (b *Bot) writeMetrics(w io.Writer) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	type series struct {
+		env   *environment
+		check string
+		state *checkState
+	}
+	var all []series
+	for _, env := range b.envs {
+		for _, check := range env.config.Checks {
+			all = append(all, series{env, check, env.checks[check]})
+		}
+	}
+	labels := func(s series) string {
+		return fmt.Sprintf(`environment="%s",check="%s"`, s.env.config.Name, s.check)
+	}
+	gauge := func(name, help string, value func(series) (float64, bool)) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+		for _, s := range all {
+			if v, ok := value(s); ok {
+				fmt.Fprintf(w, "%s{%s} %s\n", name, labels(s), formatFloat(v))
+			}
+		}
+	}
+	ran := func(s series) bool { return s.state.last.Check != "" }
+
+	gauge("shadowpay_healthbot_check_up", "Whether the last run of the check succeeded.", func(s series) (float64, bool) {
+		return boolFloat(s.state.last.OK), ran(s)
+	})
+	gauge("shadowpay_healthbot_check_last_duration_seconds", "Duration of the last run of the check.", func(s series) (float64, bool) {
+		return s.state.last.Duration.Seconds(), ran(s)
+	})
+	gauge("shadowpay_healthbot_check_last_run_timestamp_seconds", "When the check last ran.", func(s series) (float64, bool) {
+		return float64(s.state.last.At), ran(s)
+	})
+
+	fmt.Fprint(w, "# HELP shadowpay_healthbot_checks_total Check runs by result.\n# TYPE shadowpay_healthbot_checks_total counter\n")
+	for _, s := range all {
+		fmt.Fprintf(w, "shadowpay_healthbot_checks_total{%s,result=\"success\"} %d\n", labels(s), s.state.successes)
+		fmt.Fprintf(w, "shadowpay_healthbot_checks_total{%s,result=\"failure\"} %d\n", labels(s), s.state.failures)
+	}
+
+	fmt.Fprint(w, "# HELP shadowpay_healthbot_check_duration_seconds Check latency.\n# TYPE shadowpay_healthbot_check_duration_seconds histogram\n")
+	for _, s := range all {
+		var cumulative int64
+		for i, le := range latencyBuckets {
+			cumulative += s.state.histogram[i]
+			fmt.Fprintf(w, "shadowpay_healthbot_check_duration_seconds_bucket{%s,le=\"%s\"} %d\n", labels(s), formatFloat(le), cumulative)
+		}
+		cumulative += s.state.histogram[len(latencyBuckets)]
+		fmt.Fprintf(w, "shadowpay_healthbot_check_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels(s), cumulative)
+		fmt.Fprintf(w, "shadowpay_healthbot_check_duration_seconds_sum{%s} %s\n", labels(s), formatFloat(s.state.durationSum))
+		fmt.Fprintf(w, "shadowpay_healthbot_check_duration_seconds_count{%s} %d\n", labels(s), cumulative)
+	}
+
+	reports := make(map[*checkState]SLOReport, len(all))
+	for _, s := range all {
+		reports[s.state] = s.state.report(s.env.config.SLO)
+	}
+	gauge("shadowpay_healthbot_slo_availability", "Success ratio over the SLO window.", func(s series) (float64, bool) {
+		return reports[s.state].Availability, reports[s.state].Samples > 0
+	})
+	gauge("shadowpay_healthbot_slo_availability_target", "Objective success ratio.", func(s series) (float64, bool) {
+		return reports[s.state].Target, true
+	})
+	gauge("shadowpay_healthbot_slo_latency_p95_seconds", "95th percentile latency over the SLO window.", func(s series) (float64, bool) {
+		return reports[s.state].P95Seconds, reports[s.state].Samples > 0
+	})
+	gauge("shadowpay_healthbot_slo_latency_target_seconds", "Objective 95th percentile latency.", func(s series) (float64, bool) {
+		return reports[s.state].MaxP95, true
+	})
+	gauge("shadowpay_healthbot_slo_breached", "Whether the check's SLO is breached.", func(s series) (float64, bool) {
+		return boolFloat(reports[s.state].Breached), true
+	})
+}
+
+func boolFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
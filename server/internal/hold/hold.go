@@ -0,0 +1,410 @@
+// Package hold tracks conditional payments: payments settled with their
+// funds locked in the merchant's escrow until the customer confirms
+// delivery, the merchant refunds, or a timeout elapses.
+//
+// A hold starts held and ends released (funds to the merchant) or refunded
+// (funds back to the payer). Either side can dispute a held payment, which
+// stops the timeout until the dispute is resolved by a release or refund.
+// Every transition is persisted and reported through OnEvent.
+package hold
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/jsonfile"
+	"sol_privacy/internal/payment"
+	"sol_privacy/internal/validate"
+)
+
+// Defaults and limits.
+const (
+	DefaultTimeout       = 14 * 24 * time.Hour
+	MaxTimeout           = 90 * 24 * time.Hour
+	DefaultCheckInterval = time.Minute
+	MaxReasonLength      = 500
+)
+
+// Event types.
+const (
+	EventCreated  = "hold.created"
+	EventReleased = "hold.released"
+	EventRefunded = "hold.refunded"
+	EventDisputed = "hold.disputed"
+)
+
+var (
+	// ErrHoldNotFound is returned for unknown hold IDs.
+	ErrHoldNotFound = errors.New("hold not found")
+	// ErrInvalidTransition is returned for transitions the hold's status does not allow.
+	ErrInvalidTransition = errors.New("invalid hold transition")
+)
+
+// Status is the state of a hold.
+type Status string
+
+const (
+	StatusHeld     Status = "held"     // Funds locked until confirmation or ReleaseAt
+	StatusDisputed Status = "disputed" // Funds locked until resolved
+	StatusReleased Status = "released" // Funds unlocked for the merchant
+	StatusRefunded Status = "refunded" // Funds returned to the payer
+)
+
+// Transition is a recorded change of status.
+type Transition struct {
+	From   Status `json:"from"`
+	To     Status `json:"to"`
+	Reason string `json:"reason,omitempty"`
+	TxSig  string `json:"tx_sig,omitempty"` // Release or refund transaction
+	At     int64  `json:"at"`
+}
+
+// Hold is a payment whose funds are locked pending delivery.
+type Hold struct {
+	ID        string       `json:"id"`
+	TxSig     string       `json:"tx_sig"` // Settlement transaction
+	Resource  string       `json:"resource,omitempty"`
+	Customer  string       `json:"customer_commitment,omitempty"`
+	Payer     string       `json:"payer_wallet,omitempty"` // Wallet that may dispute the hold
+	Amount    string       `json:"amount,omitempty"`       // As settled, e.g. the x402 maxAmountRequired
+	Status    Status       `json:"status"`
+	ReleaseAt int64        `json:"release_at"` // Released automatically after this while held
+	LastError string       `json:"last_error,omitempty"`
+	History   []Transition `json:"history,omitempty"`
+	CreatedAt int64        `json:"created_at"`
+	UpdatedAt int64        `json:"updated_at"`
+}
+
+// Done reports whether the hold's funds have been released or refunded.
+func (h *Hold) Done() bool {
+	return h.Status == StatusReleased || h.Status == StatusRefunded
+}
+
+// CreateRequest records a payment settled with SettleRequest.Hold.
+type CreateRequest struct {
+	TxSig    string
+	Resource string
+	Customer string
+	Payer    string // Wallet that may dispute the hold
+	Amount   string
+	Timeout  time.Duration // Defaults to the manager's timeout, at most MaxTimeout
+}
+
+// Event is a change to a hold.
+type Event struct {
+	Type      string `json:"event"`
+	Timestamp int64  `json:"timestamp"`
+	Hold      Hold   `json:"data"`
+}
+
+// EventHandler receives hold events.
+type EventHandler func(ctx context.Context, event Event)
+
+// Store persists holds.
+type Store interface {
+	Put(h *Hold) error
+	Get(id string) (*Hold, error) // Returns ErrHoldNotFound for unknown IDs
+	List() ([]*Hold, error)
+}
+
+// NewMemoryStore creates an empty in-memory Store.
+func NewMemoryStore() Store {
+	return store{jsonfile.NewMemoryStore(holdID, cloneHold)}
+}
+
+// NewFileStore opens a Store persisted to a JSON file after every write,
+// creating it on first write.
+func NewFileStore(path string) (Store, error) {
+	holds, err := jsonfile.NewStore(path, holdID, cloneHold)
+	if err != nil {
+		return nil, err
+	}
+	return store{holds}, nil
+}
+
+func holdID(h *Hold) string { return h.ID }
+
+func cloneHold(h Hold) Hold {
+	h.History = append([]Transition(nil), h.History...)
+	return h
+}
+
+// store keeps holds in a jsonfile.Store.
+type store struct {
+	holds *jsonfile.Store[Hold]
+}
+
+func (s store) Put(h *Hold) error {
+	return s.holds.Put(h)
+}
+
+func (s store) Get(id string) (*Hold, error) {
+	h, ok := s.holds.Get(id)
+	if !ok {
+		return nil, ErrHoldNotFound
+	}
+	return h, nil
+}
+
+// List returns all holds, newest first.
+func (s store) List() ([]*Hold, error) {
+	return s.holds.List(func(a, b *Hold) bool {
+		if a.CreatedAt != b.CreatedAt {
+			return a.CreatedAt > b.CreatedAt
+		}
+		return a.ID > b.ID
+	}), nil
+}
+
+// MoveFunc releases or refunds a held payment upstream.
+type MoveFunc func(ctx context.Context, req payment.HoldRequest) (*payment.HoldResponse, error)
+
+// Config holds manager configuration.
+type Config struct {
+	Release       MoveFunc      // Required
+	Refund        MoveFunc      // Required
+	Store         Store         // Defaults to an in-memory store
+	Timeout       time.Duration // Defaults to DefaultTimeout
+	CheckInterval time.Duration // How often expired holds are released; defaults to DefaultCheckInterval
+	OnEvent       EventHandler  // Optional
+}
+
+// Manager moves holds through their states.
+type Manager struct {
+	config Config
+	mu     sync.Mutex // Serializes transitions
+}
+
+// NewManager creates a manager. Call Run to release expired holds.
+func NewManager(config Config) *Manager {
+	if config.Store == nil {
+		config.Store = NewMemoryStore()
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.Timeout > MaxTimeout {
+		config.Timeout = MaxTimeout
+	}
+	if config.CheckInterval <= 0 {
+		config.CheckInterval = DefaultCheckInterval
+	}
+	return &Manager{config: config}
+}
+
+// Create records a held payment. Recording the same settlement again
+// returns the existing hold, so at-least-once callers are safe.
+func (m *Manager) Create(ctx context.Context, req CreateRequest) (*Hold, error) {
+	v := validate.New().Required("tx_sig", req.TxSig)
+	if req.Timeout < 0 || req.Timeout > MaxTimeout {
+		v.Add("timeout", fmt.Errorf("must be at most %s", MaxTimeout))
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+	if req.Timeout == 0 {
+		req.Timeout = m.config.Timeout
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	all, err := m.config.Store.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range all {
+		if h.TxSig == req.TxSig {
+			return h, nil
+		}
+	}
+	now := time.Now()
+	h := &Hold{
+		ID:        newID(),
+		TxSig:     req.TxSig,
+		Resource:  req.Resource,
+		Customer:  req.Customer,
+		Payer:     req.Payer,
+		Amount:    req.Amount,
+		Status:    StatusHeld,
+		ReleaseAt: now.Add(req.Timeout).Unix(),
+		CreatedAt: now.Unix(),
+		UpdatedAt: now.Unix(),
+	}
+	if err := m.config.Store.Put(h); err != nil {
+		return nil, err
+	}
+	m.emit(ctx, EventCreated, h)
+	return h, nil
+}
+
+// Get returns a hold.
+func (m *Manager) Get(ctx context.Context, id string) (*Hold, error) {
+	return m.config.Store.Get(id)
+}
+
+// List returns holds with the given status (all if empty), newest first.
+func (m *Manager) List(ctx context.Context, status Status) ([]*Hold, error) {
+	holds, err := m.config.Store.List()
+	if err != nil || status == "" {
+		return holds, err
+	}
+	out := holds[:0]
+	for _, h := range holds {
+		if h.Status == status {
+			out = append(out, h)
+		}
+	}
+	return out, nil
+}
+
+// Release unlocks the funds for the merchant, as when the customer confirms
+// delivery or a dispute is resolved in the merchant's favor.
+func (m *Manager) Release(ctx context.Context, id, reason string) (*Hold, error) {
+	return m.move(ctx, id, StatusReleased, reason, false)
+}
+
+// Refund returns the funds to the payer.
+func (m *Manager) Refund(ctx context.Context, id, reason string) (*Hold, error) {
+	return m.move(ctx, id, StatusRefunded, reason, false)
+}
+
+// Dispute freezes a held payment until it is released or refunded.
+func (m *Manager) Dispute(ctx context.Context, id, reason string) (*Hold, error) {
+	if err := checkReason(reason, true); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, err := m.config.Store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if h.Status != StatusHeld {
+		return nil, fmt.Errorf("%w: %s hold cannot be disputed", ErrInvalidTransition, h.Status)
+	}
+	if err := m.transition(h, StatusDisputed, reason, ""); err != nil {
+		return nil, err
+	}
+	m.emit(ctx, EventDisputed, h)
+	return h, nil
+}
+
+// move releases or refunds a held or disputed payment upstream, then
+// records it. If the upstream call fails the hold keeps its status. With
+// expired set, only a held payment past its ReleaseAt is moved, since it
+// may have been disputed since it was listed.
+func (m *Manager) move(ctx context.Context, id string, to Status, reason string, expired bool) (*Hold, error) {
+	if err := checkReason(reason, false); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, err := m.config.Store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if h.Done() {
+		return nil, fmt.Errorf("%w: hold already %s", ErrInvalidTransition, h.Status)
+	}
+	if expired && (h.Status != StatusHeld || h.ReleaseAt > time.Now().Unix()) {
+		return nil, fmt.Errorf("%w: %s hold has not timed out", ErrInvalidTransition, h.Status)
+	}
+
+	fn, eventType := m.config.Release, EventReleased
+	if to == StatusRefunded {
+		fn, eventType = m.config.Refund, EventRefunded
+	}
+	resp, err := fn(ctx, payment.HoldRequest{TxSig: h.TxSig, Reason: reason})
+	if err != nil {
+		h.LastError = err.Error()
+		h.UpdatedAt = time.Now().Unix()
+		if perr := m.config.Store.Put(h); perr != nil {
+			log.Printf("hold: saving %s failed: %v", h.ID, perr)
+		}
+		return nil, err
+	}
+	h.LastError = ""
+	if err := m.transition(h, to, reason, resp.TxSig); err != nil {
+		return nil, err
+	}
+	m.emit(ctx, eventType, h)
+	return h, nil
+}
+
+func (m *Manager) transition(h *Hold, to Status, reason, txSig string) error {
+	now := time.Now().Unix()
+	h.History = append(h.History, Transition{From: h.Status, To: to, Reason: reason, TxSig: txSig, At: now})
+	h.Status = to
+	h.UpdatedAt = now
+	return m.config.Store.Put(h)
+}
+
+// Run releases held payments whose timeout has elapsed, every
+// CheckInterval until ctx is done. Failed releases are retried on the next
+// check.
+func (m *Manager) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.config.CheckInterval)
+	defer ticker.Stop()
+	for {
+		m.ReleaseExpired(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ReleaseExpired releases every held payment past its ReleaseAt and
+// returns how many were released.
+func (m *Manager) ReleaseExpired(ctx context.Context) int {
+	holds, err := m.List(ctx, StatusHeld)
+	if err != nil {
+		log.Printf("hold: %v", err)
+		return 0
+	}
+	now := time.Now().Unix()
+	released := 0
+	for _, h := range holds {
+		if h.ReleaseAt > now {
+			continue
+		}
+		if _, err := m.move(ctx, h.ID, StatusReleased, "timeout", true); err != nil {
+			if !errors.Is(err, ErrInvalidTransition) {
+				log.Printf("hold: releasing %s after timeout failed: %v", h.ID, err)
+			}
+			continue
+		}
+		released++
+	}
+	return released
+}
+
+func (m *Manager) emit(ctx context.Context, eventType string, h *Hold) {
+	if m.config.OnEvent != nil {
+		m.config.OnEvent(ctx, Event{Type: eventType, Timestamp: time.Now().Unix(), Hold: *h})
+	}
+}
+
+func checkReason(reason string, required bool) error {
+	v := validate.New()
+	if required {
+		v.Required("reason", reason)
+	}
+	if len(reason) > MaxReasonLength {
+		v.Add("reason", fmt.Errorf("must be at most %d characters", MaxReasonLength))
+	}
+	return v.Err()
+}
+
+func newID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "hld_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,342 @@
+package i18n
+
+var spanish = map[string]string{
+	// HTTP status titles
+	"Bad Request":              "Solicitud incorrecta",
+	"Unauthorized":             "No autorizado",
+	"Payment Required":         "Pago requerido",
+	"Forbidden":                "Prohibido",
+	"Not Found":                "No encontrado",
+	"Conflict":                 "Conflicto",
+	"Unprocessable Entity":     "Entidad no procesable",
+	"Request Entity Too Large": "Entidad de solicitud demasiado grande",
+	"Too Many Requests":        "Demasiadas solicitudes",
+	"Internal Server Error":    "Error interno del servidor",
+	"Bad Gateway":              "Puerta de enlace incorrecta",
+	"Service Unavailable":      "Servicio no disponible",
+	"Gateway Timeout":          "Tiempo de espera de la puerta de enlace agotado",
+
+	// API errors
+	"Rate limit exceeded, retry later":             "Demasiadas solicitudes, inténtelo de nuevo más tarde",
+	"Unknown API key":                              "Clave de API desconocida",
+	"Invalid request body":                         "Cuerpo de la solicitud no válido",
+	"Request body too large":                       "El cuerpo de la solicitud es demasiado grande",
+	"Request body is required":                     "El cuerpo de la solicitud es obligatorio",
+	"Invalid variables":                            "Variables no válidas",
+	"Missing required field":                       "Falta el campo obligatorio",
+	"Missing required fields":                      "Faltan campos obligatorios",
+	"Umbra integration is not enabled":             "La integración con Umbra no está habilitada",
+	"mint address required":                        "se requiere la dirección del mint",
+	"commitment required":                          "se requiere el compromiso",
+	"session token required":                       "se requiere el token de sesión",
+	"session is not authorized for this wallet":    "la sesión no está autorizada para esta billetera",
+	"reqsign: request signature required":          "reqsign: se requiere la firma de la solicitud",
+	"reqsign: malformed request signature headers": "reqsign: cabeceras de firma de la solicitud mal formadas",
+	"reqsign: request signature expired":           "reqsign: la firma de la solicitud ha caducado",
+	"reqsign: request signature already used":      "reqsign: la firma de la solicitud ya se utilizó",
+	"reqsign: invalid request signature":           "reqsign: firma de la solicitud no válida",
+	"request is not signed by this wallet":         "la solicitud no está firmada por esta billetera",
+	"wallet address and service required":          "se requieren la dirección de la billetera y el servicio",
+	"since must be a non-negative integer":         "since debe ser un entero no negativo",
+	"Swap receipt not found":                       "Recibo de intercambio no encontrado",
+	"settlement job not found":                     "no se encontró el trabajo de liquidación",
+	"only failed settlement jobs can be retried":   "solo se pueden reintentar los trabajos de liquidación fallidos",
+	"Streaming unsupported":                        "La transmisión no es compatible",
+	"Warehouse export is not enabled":              "La exportación al almacén de datos no está habilitada",
+	"No reconciliation has run yet":                "Todavía no se ha ejecutado ninguna conciliación",
+	"Unknown environment":                          "Entorno desconocido",
+	"Unknown webhook":                              "Webhook desconocido",
+	"event not found":                              "evento no encontrado",
+	"Name not found":                               "Nombre no encontrado",
+	"name not found":                               "nombre no encontrado",
+	"Artifact not found":                           "Artefacto no encontrado",
+	"Artifact failed its integrity check":          "El artefacto no superó la comprobación de integridad",
+	"Invalid .sol name":                            "Nombre .sol no válido",
+	"invalid .sol name":                            "nombre .sol no válido",
+	"Failed to resolve name":                       "No se pudo resolver el nombre",
+	"handle not found":                             "identificador no encontrado",
+	"handle is registered to another wallet":       "el identificador está registrado a otra billetera",
+	"commitment is registered to another handle":   "el compromiso está registrado a otro identificador",
+	"signed request is stale":                      "la solicitud firmada está caducada",
+	"unknown subscriber":                           "suscriptor desconocido",
+	"Sandbox is not configured":                    "El entorno de pruebas no está configurado",
+	"movement already recorded":                    "el movimiento ya está registrado",
+	"movement value unknown":                       "se desconoce el valor del movimiento",
+	"Token payment rejected":                       "Pago con token rechazado",
+	"Failed to validate token payment":             "No se pudo validar el pago con token",
+	"Failed to prepare payment":                    "No se pudo preparar el pago",
+	"Failed to generate stealth address":           "No se pudo generar la dirección oculta",
+	"Failed to deposit to Umbra pool":              "No se pudo depositar en el pool de Umbra",
+
+	// Validation
+	"is required":                                           "es obligatorio",
+	"must be at most %d characters":                         "debe tener como máximo %d caracteres",
+	"must be 32-44 base58 characters":                       "debe tener entre 32 y 44 caracteres base58",
+	"is not valid base58":                                   "no es base58 válido",
+	"must decode to 32 bytes, got %d":                       "debe decodificarse en 32 bytes, se obtuvieron %d",
+	"hex commitment must be 1-64 hex digits":                "el compromiso hexadecimal debe tener de 1 a 64 dígitos",
+	"is not valid hex":                                      "no es hexadecimal válido",
+	"must be 32 bytes encoded as hex or base58":             "debe ser de 32 bytes en hexadecimal o base58",
+	"must be at least %d":                                   "debe ser al menos %d",
+	"must be at most %d":                                    "debe ser como máximo %d",
+	"must be an absolute URL":                               "debe ser una URL absoluta",
+	"must use https":                                        "debe usar https",
+	"must use http or https":                                "debe usar http o https",
+	"must not contain credentials":                          "no debe contener credenciales",
+	"must be a unix timestamp":                              "debe ser una marca de tiempo unix",
+	"must be a number":                                      "debe ser un número",
+	"must be greater than 0":                                "debe ser mayor que 0",
+	"is too large":                                          "es demasiado grande",
+	"is not a known field":                                  "no es un campo conocido",
+	"must not be null":                                      "no debe ser nulo",
+	"must be a string":                                      "debe ser una cadena",
+	"must be an integer":                                    "debe ser un entero",
+	"must be a boolean":                                     "debe ser un booleano",
+	"must be an object":                                     "debe ser un objeto",
+	"must be an array":                                      "debe ser una lista",
+	"must be one of %s":                                     "debe ser uno de %s",
+	"must be at least %d characters":                        "debe tener al menos %d caracteres",
+	"must be 3-32 lowercase letters, digits or underscores": "debe tener 3-32 letras minúsculas, dígitos o guiones bajos",
+	"must be a base58 X25519 public key":                    "debe ser una clave pública X25519 en base58",
+	"must be an RFC 3339 time or Unix timestamp":            "debe ser una hora RFC 3339 o una marca de tiempo Unix",
+	"must be a slot number":                                 "debe ser un número de slot",
+	"is not a cursor returned by a previous page":           "no es un cursor devuelto por una página anterior",
+	"must be %s or %s":                                      "debe ser %s o %s",
+	"must be a non-negative number":                         "debe ser un número no negativo",
+	"must be a calendar year":                               "debe ser un año calendario",
+
+	// CLI: main menu and status
+	"ShadowPay CLI":               "ShadowPay CLI",
+	"Connected":                   "Conectado",
+	"Not Connected (Set API Key)": "Sin conexión (configure la clave API)",
+	"ZK Payments":                 "Pagos ZK",
+	"Privacy Pool":                "Pool de privacidad",
+	"Token Management":            "Gestión de tokens",
+	"Bot Authorization":           "Autorización de bots",
+	"Merchant Tools":              "Herramientas de comercio",
+	"Webhooks":                    "Webhooks",
+	"ShadowID":                    "ShadowID",
+	"Wallets & Contacts":          "Billeteras y contactos",
+	"Setup Wizard":                "Asistente de configuración",
+	"Settings":                    "Configuración",
+	"Exit":                        "Salir",
+	"Back":                        "Volver",
+	"↑/↓: navigate • enter: select • q: quit":   "↑/↓: navegar • enter: seleccionar • q: salir",
+	"↑/↓: navigate • enter: select • esc: back": "↑/↓: navegar • enter: seleccionar • esc: volver",
+	"esc: back to main menu":                    "esc: volver al menú principal",
+	"Please set API key in Settings first":      "Primero configure la clave API en Configuración",
+
+	// CLI: section headers and descriptions
+	"Select an operation:":    "Seleccione una operación:",
+	"Manage SPL tokens:":      "Gestionar tokens SPL:",
+	"Manage bot permissions:": "Gestionar permisos de bots:",
+	"Merchant operations:":    "Operaciones de comercio:",
+	"Webhook operations:":     "Operaciones de webhooks:",
+	"ShadowID operations:":    "Operaciones de ShadowID:",
+	"Privacy pools mix your funds with other users\nfor maximum anonymity on-chain.":        "Los pools de privacidad mezclan sus fondos con los de otros\nusuarios para lograr el máximo anonimato en la cadena.",
+	"Allow bots and services to spend from your\nescrow with custom limits and expiration.": "Permita que bots y servicios gasten de su depósito\nen garantía con límites y vencimiento personalizados.",
+	"Anonymous identity system using Merkle trees\nfor privacy-preserving authentication.":  "Sistema de identidad anónima con árboles de Merkle\npara una autenticación que preserva la privacidad.",
+	"API Key not set": "Clave API no configurada",
+	"API Key: ":       "Clave API: ",
+	"Language: ":      "Idioma: ",
+	"To set your API key, run:\nexport SHADOWPAY_API_KEY=your_key_here\n\nOr create a .env file with:\nSHADOWPAY_API_KEY=your_key_here": "Para configurar su clave API, ejecute:\nexport SHADOWPAY_API_KEY=su_clave\n\nO cree un archivo .env con:\nSHADOWPAY_API_KEY=su_clave",
+	"To change the language, set SHADOWPAY_LANG (en, es, zh).":                                                                          "Para cambiar el idioma, defina SHADOWPAY_LANG (en, es, zh).",
+	"Or run the Setup Wizard to generate one.":                                                                                          "O ejecute el asistente de configuración para generar una.",
+
+	// CLI: menu items
+	"Deposit Funds":           "Depositar fondos",
+	"Withdraw Funds":          "Retirar fondos",
+	"Prepare Payment":         "Preparar pago",
+	"Authorize Payment":       "Autorizar pago",
+	"Verify Access":           "Verificar acceso",
+	"Settle Payment":          "Liquidar pago",
+	"Track Transaction":       "Seguir transacción",
+	"Check Balance":           "Consultar saldo",
+	"Deposit to Pool":         "Depositar en el pool",
+	"Withdraw from Pool":      "Retirar del pool",
+	"Quote Withdrawal Fee":    "Cotizar comisión de retiro",
+	"Get Deposit Address":     "Obtener dirección de depósito",
+	"List Wallets":            "Listar billeteras",
+	"Add Wallet":              "Agregar billetera",
+	"Select Default":          "Elegir predeterminada",
+	"Remove Wallet":           "Eliminar billetera",
+	"Add Contact":             "Agregar contacto",
+	"Search Contacts":         "Buscar contactos",
+	"Remove Contact":          "Eliminar contacto",
+	"Register Stealth Handle": "Registrar identificador sigiloso",
+	"Look Up Handle":          "Buscar identificador",
+	"List Supported Tokens":   "Listar tokens admitidos",
+	"Escrow Balances":         "Saldos en garantía",
+	"Add New Token":           "Agregar token",
+	"Update Token":            "Actualizar token",
+	"Remove Token":            "Eliminar token",
+	"Authorize Bot Spending":  "Autorizar gastos de bots",
+	"List Authorizations":     "Listar autorizaciones",
+	"Update Limits":           "Actualizar límites",
+	"Revoke Authorization":    "Revocar autorización",
+	"View Earnings":           "Ver ganancias",
+	"Get Analytics":           "Ver analíticas",
+	"Withdraw Earnings":       "Retirar ganancias",
+	"Decrypt Amount":          "Descifrar monto",
+	"Create Payment Link":     "Crear enlace de pago",
+	"List Payment Links":      "Listar enlaces de pago",
+	"Create Invoice":          "Crear factura",
+	"List Invoices":           "Listar facturas",
+	"Download Invoice PDF":    "Descargar factura PDF",
+	"Register Webhook":        "Registrar webhook",
+	"Get Configuration":       "Ver configuración",
+	"Test Webhook":            "Probar webhook",
+	"View Logs":               "Ver registros",
+	"Get Stats":               "Ver estadísticas",
+	"Deactivate Webhook":      "Desactivar webhook",
+	"Auto Register":           "Registro automático",
+	"Register Commitment":     "Registrar compromiso",
+	"Get Proof":               "Obtener prueba",
+	"Get Tree Root":           "Obtener raíz del árbol",
+	"Check Status":            "Consultar estado",
+	"Debug Proof":             "Depurar prueba",
+
+	// CLI: form titles
+	"Select Default Wallet":         "Elegir billetera predeterminada",
+	"Update Authorization Limits":   "Actualizar límites de autorización",
+	"Add Token":                     "Agregar token",
+	"Auto Register ShadowID":        "Registro automático de ShadowID",
+	"Check Pool Balance":            "Consultar saldo del pool",
+	"Deposit to Payment Account":    "Depositar en la cuenta de pagos",
+	"Withdraw from Payment Account": "Retirar de la cuenta de pagos",
+	"Check Registration Status":     "Consultar estado del registro",
+	"View Webhook Logs":             "Ver registros del webhook",
+	"Get Merkle Proof":              "Obtener prueba de Merkle",
+	"Proof Debug":                   "Depuración de prueba",
+	"Debug Merkle Proof":            "Depurar prueba de Merkle",
+	"Verify Access Token":           "Verificar token de acceso",
+	"Prepare ZK Payment":            "Preparar pago ZK",
+
+	// CLI: form fields
+	"Access Token": "Token de acceso",
+	"Address (blank to derive from secret key)": "Dirección (vacío para derivarla de la clave secreta)",
+	"Amount (SOL)":                                 "Monto (SOL)",
+	"Authorized Service":                           "Servicio autorizado",
+	"Cancel URL":                                   "URL de cancelación",
+	"Commitment":                                   "Compromiso",
+	"Compare with local replica (y/N)":             "Comparar con réplica local (s/N)",
+	"Decimals":                                     "Decimales",
+	"Description (optional)":                       "Descripción (opcional)",
+	"Destination Wallet":                           "Billetera de destino",
+	"Enabled (true/false)":                         "Habilitado (true/false)",
+	"Encrypted Ciphertext (hex)":                   "Texto cifrado (hex)",
+	"End Date (YYYY-MM-DD, optional)":              "Fecha final (AAAA-MM-DD, opcional)",
+	"Event Type (optional)":                        "Tipo de evento (opcional)",
+	"Events (comma-separated)":                     "Eventos (separados por comas)",
+	"Invoice ID":                                   "ID de factura",
+	"Kind (wallet, commitment, stealth)":           "Tipo (wallet, commitment, stealth)",
+	"Label":                                        "Etiqueta",
+	"Last Valid Block Height":                      "Última altura de bloque válida",
+	"Limit (default 50)":                           "Límite (50 por defecto)",
+	"Max Daily (SOL)":                              "Máximo diario (SOL)",
+	"Max Daily (SOL, blank = keep)":                "Máximo diario (SOL, vacío = mantener)",
+	"Max Per Tx (SOL)":                             "Máximo por transacción (SOL)",
+	"Max Per Tx (SOL, blank = keep)":               "Máximo por transacción (SOL, vacío = mantener)",
+	"Merchant Wallet":                              "Billetera del comercio",
+	"Message":                                      "Mensaje",
+	"Mint Address":                                 "Dirección del mint",
+	"Name":                                         "Nombre",
+	"New Symbol (optional)":                        "Nuevo símbolo (opcional)",
+	"Notes (optional)":                             "Notas (opcional)",
+	"Nullifier":                                    "Anulador",
+	"Output File (optional)":                       "Archivo de salida (opcional)",
+	"Poseidon Hash Commitment":                     "Compromiso hash Poseidon",
+	"Private Key (hex)":                            "Clave privada (hex)",
+	"Receiver Commitment":                          "Compromiso del receptor",
+	"Recipient Wallet":                             "Billetera del destinatario",
+	"Search (blank lists all)":                     "Buscar (vacío lista todo)",
+	"Handle (e.g. alice)":                          "Identificador (p. ej. alice)",
+	"Signing Wallet (blank for default)":           "Billetera firmante (vacío para la predeterminada)",
+	"Scan Key (hex, blank to generate)":            "Clave de escaneo (hex, vacío para generar)",
+	"ShadowID Commitment (optional)":               "Compromiso ShadowID (opcional)",
+	"Handle or Commitment":                         "Identificador o compromiso",
+	"Secret (optional)":                            "Secreto (opcional)",
+	"Secret Key (optional, base58)":                "Clave secreta (opcional, base58)",
+	"Signature":                                    "Firma",
+	"Signature (base58)":                           "Firma (base58)",
+	"Start Date (YYYY-MM-DD, optional)":            "Fecha inicial (AAAA-MM-DD, opcional)",
+	"Success URL":                                  "URL de éxito",
+	"Symbol":                                       "Símbolo",
+	"User Signature (base58)":                      "Firma del usuario (base58)",
+	"User Wallet":                                  "Billetera del usuario",
+	"Valid Until (days from now)":                  "Válido hasta (días desde hoy)",
+	"Valid Until (days from now, blank = keep)":    "Válido hasta (días desde hoy, vacío = mantener)",
+	"Value (address, commitment or meta-address)":  "Valor (dirección, compromiso o meta-dirección)",
+	"Wallet Address":                               "Dirección de la billetera",
+	"Webhook ID":                                   "ID del webhook",
+	"Webhook ID (optional)":                        "ID del webhook (opcional)",
+	"Webhook URL (https://...)":                    "URL del webhook (https://...)",
+	"Your Name":                                    "Su nombre",
+	"Your Wallet (receives payment)":               "Su billetera (recibe el pago)",
+	"Customer Name":                                "Nombre del cliente",
+	"Currency (SOL or USDC)":                       "Moneda (SOL o USDC)",
+	"Line Items (description|qty|unit price; ...)": "Conceptos (descripción|cant.|precio unitario; ...)",
+	"Tax % (optional)":                             "Impuesto % (opcional)",
+	"Due In Days":                                  "Vence en días",
+	"or name":                                      "o nombre",
+
+	// CLI: forms, loading and results
+	"tab/shift+tab: navigate • enter: submit • esc: cancel":          "tab/shift+tab: navegar • enter: enviar • esc: cancelar",
+	"→: accept suggestion • ctrl+n/ctrl+p: next/previous suggestion": "→: aceptar sugerencia • ctrl+n/ctrl+p: sugerencia siguiente/anterior",
+	"%d matches":          "%d coincidencias",
+	"Processing...":       "Procesando...",
+	"Elapsed: %s":         "Transcurrido: %s",
+	"esc: cancel":         "esc: cancelar",
+	"Operation cancelled": "Operación cancelada",
+	"Row %d of %d":        "Fila %d de %d",
+	"(filtered from %d)":  "(filtrado de %d)",
+	"No matching results": "Sin resultados coincidentes",
+	"search":              "buscar",
+	"↑/↓: select • pgup/pgdn: page • /: search • enter: open • esc: back": "↑/↓: seleccionar • pgup/pgdn: página • /: buscar • enter: abrir • esc: volver",
+	"enter: apply search • esc: clear search":                             "enter: aplicar búsqueda • esc: borrar búsqueda",
+	"↑/↓/pgup/pgdn: scroll • esc: back to list":                           "↑/↓/pgup/pgdn: desplazar • esc: volver a la lista",
+
+	// CLI: loading messages
+	"Adding token...":             "Agregando token...",
+	"Authorizing payment...":      "Autorizando pago...",
+	"Authorizing spending...":     "Autorizando gastos...",
+	"Checking balance...":         "Consultando saldo...",
+	"Checking status...":          "Consultando estado...",
+	"Creating deposit...":         "Creando depósito...",
+	"Creating invoice...":         "Creando factura...",
+	"Creating payment link...":    "Creando enlace de pago...",
+	"Creating pool deposit...":    "Creando depósito en el pool...",
+	"Creating pool withdrawal...": "Creando retiro del pool...",
+	"Creating withdrawal...":      "Creando retiro...",
+	"Deactivating webhook...":     "Desactivando webhook...",
+	"Decrypting amount...":        "Descifrando monto...",
+	"Downloading invoice...":      "Descargando factura...",
+	"Fetching Merkle proof...":    "Obteniendo prueba de Merkle...",
+	"Debugging proof...":          "Depurando prueba...",
+	"Fetching tree root...":       "Obteniendo raíz del árbol...",
+	"Getting deposit address...":  "Obteniendo dirección de depósito...",
+	"Loading analytics...":        "Cargando analíticas...",
+	"Loading authorizations...":   "Cargando autorizaciones...",
+	"Loading earnings...":         "Cargando ganancias...",
+	"Loading escrow balances...":  "Cargando saldos en garantía...",
+	"Loading invoices...":         "Cargando facturas...",
+	"Loading payment links...":    "Cargando enlaces de pago...",
+	"Loading tokens...":           "Cargando tokens...",
+	"Loading webhook config...":   "Cargando configuración del webhook...",
+	"Loading webhook logs...":     "Cargando registros del webhook...",
+	"Loading webhook stats...":    "Cargando estadísticas del webhook...",
+	"Preparing payment...":        "Preparando pago...",
+	"Quoting withdrawal...":       "Cotizando retiro...",
+	"Registering ShadowID...":     "Registrando ShadowID...",
+	"Registering commitment...":   "Registrando compromiso...",
+	"Registering webhook...":      "Registrando webhook...",
+	"Registering handle...":       "Registrando identificador...",
+	"Looking up handle...":        "Buscando identificador...",
+	"Removing token...":           "Eliminando token...",
+	"Revoking authorization...":   "Revocando autorización...",
+	"Sending test event...":       "Enviando evento de prueba...",
+	"Updating authorization...":   "Actualizando autorización...",
+	"Updating token...":           "Actualizando token...",
+	"Verifying access...":         "Verificando acceso...",
+	"Waiting for confirmation...": "Esperando confirmación...",
+}
@@ -0,0 +1,182 @@
+// Package i18n translates user-facing strings of the CLI and the API proxy.
+//
+// Catalogs are keyed by the English message, so untranslated strings fall
+// back to English and call sites keep reading naturally. Keys may contain %d
+// and %s verbs to match formatted messages, and a message of the form
+// "Prefix: detail" is translated by its prefix when it has no entry itself.
+package i18n
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Locale is a supported language.
+type Locale string
+
+// Supported locales.
+const (
+	English Locale = "en"
+	Spanish Locale = "es"
+	Chinese Locale = "zh"
+)
+
+// Locales lists the supported locales, English first.
+var Locales = []Locale{English, Spanish, Chinese}
+
+// catalogs maps a locale to its translations of English messages.
+var catalogs = map[Locale]map[string]string{
+	Spanish: spanish,
+	Chinese: chinese,
+}
+
+// template is a catalog key with verbs, compiled to match formatted messages.
+type template struct {
+	re          *regexp.Regexp
+	translation string
+}
+
+var (
+	templatesOnce sync.Once
+	templates     map[Locale][]template
+)
+
+// compileTemplates builds the matchers of catalog keys containing verbs,
+// longest first so the most specific key wins.
+func compileTemplates() {
+	templates = make(map[Locale][]template)
+	for l, catalog := range catalogs {
+		var ts []template
+		for key, translation := range catalog {
+			if !strings.Contains(key, "%d") && !strings.Contains(key, "%s") {
+				continue
+			}
+			pattern := regexp.QuoteMeta(key)
+			pattern = strings.ReplaceAll(pattern, "%d", `(-?\d+)`)
+			pattern = strings.ReplaceAll(pattern, "%s", `(.+?)`)
+			ts = append(ts, template{
+				re:          regexp.MustCompile("^" + pattern + "$"),
+				translation: translation,
+			})
+		}
+		sort.Slice(ts, func(i, j int) bool { return len(ts[i].re.String()) > len(ts[j].re.String()) })
+		templates[l] = ts
+	}
+}
+
+// T translates msg into l, returning msg unchanged when there is no translation.
+func T(l Locale, msg string) string {
+	catalog := catalogs[l]
+	if catalog == nil || msg == "" {
+		return msg
+	}
+	if t, ok := catalog[msg]; ok {
+		return t
+	}
+
+	templatesOnce.Do(compileTemplates)
+	for _, t := range templates[l] {
+		if m := t.re.FindStringSubmatch(msg); m != nil {
+			return fill(t.translation, m[1:])
+		}
+	}
+
+	// "Failed to do X: upstream detail" translates the prefix only
+	if prefix, detail, ok := strings.Cut(msg, ": "); ok {
+		if t, ok := catalog[prefix]; ok {
+			return t + ": " + detail
+		}
+	}
+	return msg
+}
+
+// fill replaces the verbs of a translation with the matched values in order.
+func fill(translation string, values []string) string {
+	var sb strings.Builder
+	for i := 0; i < len(translation); i++ {
+		if translation[i] == '%' && i+1 < len(translation) && (translation[i+1] == 'd' || translation[i+1] == 's') && len(values) > 0 {
+			sb.WriteString(values[0])
+			values = values[1:]
+			i++
+			continue
+		}
+		sb.WriteByte(translation[i])
+	}
+	return sb.String()
+}
+
+// Parse picks the best supported locale from an Accept-Language header or a
+// locale setting such as "es", "zh-CN" or "es_MX.UTF-8", falling back to English.
+func Parse(s string) Locale {
+	best, bestQ := English, 0.0
+	for _, part := range strings.Split(s, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = f
+		}
+		tag, _, _ = strings.Cut(tag, ".")
+		lang, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		lang, _, _ = strings.Cut(lang, "_")
+		for _, l := range Locales {
+			if Locale(lang) == l && q > bestQ {
+				best, bestQ = l, q
+			}
+		}
+	}
+	return best
+}
+
+type contextKey struct{}
+
+// WithLocale returns a context carrying l.
+func WithLocale(ctx context.Context, l Locale) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the locale carried by ctx, or English.
+func FromContext(ctx context.Context) Locale {
+	if l, ok := ctx.Value(contextKey{}).(Locale); ok {
+		return l
+	}
+	return English
+}
+
+// Middleware selects each request's locale from its Accept-Language header,
+// using def when the header is missing or names no supported language.
+func Middleware(def Locale) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			l := def
+			if h := r.Header.Get("Accept-Language"); h != "" {
+				if parsed := Parse(h); parsed != English || acceptsEnglish(h) {
+					l = parsed
+				}
+			}
+			w.Header().Set("Content-Language", string(l))
+			w.Header().Add("Vary", "Accept-Language")
+			next.ServeHTTP(w, r.WithContext(WithLocale(r.Context(), l)))
+		})
+	}
+}
+
+// acceptsEnglish reports whether an Accept-Language header asks for English,
+// as opposed to Parse falling back to it.
+func acceptsEnglish(h string) bool {
+	for _, part := range strings.Split(h, ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if lang, _, _ := strings.Cut(strings.ToLower(tag), "-"); lang == "en" {
+			return true
+		}
+	}
+	return false
+}
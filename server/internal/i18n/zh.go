@@ -0,0 +1,342 @@
+package i18n
+
+var chinese = map[string]string{
+	// HTTP status titles
+	"Bad Request":              "请求无效",
+	"Unauthorized":             "未授权",
+	"Payment Required":         "需要付款",
+	"Forbidden":                "禁止访问",
+	"Not Found":                "未找到",
+	"Conflict":                 "冲突",
+	"Unprocessable Entity":     "无法处理的实体",
+	"Request Entity Too Large": "请求实体过大",
+	"Too Many Requests":        "请求过多",
+	"Internal Server Error":    "服务器内部错误",
+	"Bad Gateway":              "网关错误",
+	"Service Unavailable":      "服务不可用",
+	"Gateway Timeout":          "网关超时",
+
+	// API errors
+	"Rate limit exceeded, retry later":             "请求过多，请稍后重试",
+	"Unknown API key":                              "未知的 API 密钥",
+	"Invalid request body":                         "请求体无效",
+	"Request body too large":                       "请求体过大",
+	"Request body is required":                     "请求体为必填项",
+	"Invalid variables":                            "变量无效",
+	"Missing required field":                       "缺少必填字段",
+	"Missing required fields":                      "缺少必填字段",
+	"Umbra integration is not enabled":             "未启用 Umbra 集成",
+	"mint address required":                        "需要提供代币铸造地址",
+	"commitment required":                          "需要提供承诺",
+	"session token required":                       "需要提供会话令牌",
+	"session is not authorized for this wallet":    "该会话无权操作此钱包",
+	"reqsign: request signature required":          "reqsign: 需要请求签名",
+	"reqsign: malformed request signature headers": "reqsign: 请求签名标头格式错误",
+	"reqsign: request signature expired":           "reqsign: 请求签名已过期",
+	"reqsign: request signature already used":      "reqsign: 请求签名已被使用",
+	"reqsign: invalid request signature":           "reqsign: 请求签名无效",
+	"request is not signed by this wallet":         "请求未由此钱包签名",
+	"wallet address and service required":          "需要提供钱包地址和服务",
+	"since must be a non-negative integer":         "since 必须是非负整数",
+	"Swap receipt not found":                       "未找到兑换收据",
+	"settlement job not found":                     "未找到结算任务",
+	"only failed settlement jobs can be retried":   "只能重试失败的结算任务",
+	"Streaming unsupported":                        "不支持流式传输",
+	"Warehouse export is not enabled":              "未启用数据仓库导出",
+	"No reconciliation has run yet":                "尚未运行对账",
+	"Unknown environment":                          "未知环境",
+	"Unknown webhook":                              "未知的 Webhook",
+	"event not found":                              "未找到事件",
+	"Name not found":                               "未找到该名称",
+	"name not found":                               "未找到该名称",
+	"Artifact not found":                           "未找到工件",
+	"Artifact failed its integrity check":          "工件未通过完整性校验",
+	"Invalid .sol name":                            "无效的 .sol 名称",
+	"invalid .sol name":                            "无效的 .sol 名称",
+	"Failed to resolve name":                       "名称解析失败",
+	"handle not found":                             "未找到该名称",
+	"handle is registered to another wallet":       "该名称已注册到其他钱包",
+	"commitment is registered to another handle":   "该承诺已注册到其他名称",
+	"signed request is stale":                      "签名请求已过期",
+	"unknown subscriber":                           "未知的订阅者",
+	"Sandbox is not configured":                    "未配置沙盒环境",
+	"movement already recorded":                    "该资金变动已记录",
+	"movement value unknown":                       "资金变动的价值未知",
+	"Token payment rejected":                       "代币付款被拒绝",
+	"Failed to validate token payment":             "代币付款验证失败",
+	"Failed to prepare payment":                    "准备付款失败",
+	"Failed to generate stealth address":           "生成隐身地址失败",
+	"Failed to deposit to Umbra pool":              "存入 Umbra 池失败",
+
+	// Validation
+	"is required":                                           "为必填项",
+	"must be at most %d characters":                         "最多 %d 个字符",
+	"must be 32-44 base58 characters":                       "必须是 32-44 个 base58 字符",
+	"is not valid base58":                                   "不是有效的 base58",
+	"must decode to 32 bytes, got %d":                       "解码后必须为 32 字节，实际为 %d",
+	"hex commitment must be 1-64 hex digits":                "十六进制承诺必须为 1-64 位",
+	"is not valid hex":                                      "不是有效的十六进制",
+	"must be 32 bytes encoded as hex or base58":             "必须是十六进制或 base58 编码的 32 字节",
+	"must be at least %d":                                   "不能小于 %d",
+	"must be at most %d":                                    "不能大于 %d",
+	"must be an absolute URL":                               "必须是绝对 URL",
+	"must use https":                                        "必须使用 https",
+	"must use http or https":                                "必须使用 http 或 https",
+	"must not contain credentials":                          "不能包含凭据",
+	"must be a unix timestamp":                              "必须是 unix 时间戳",
+	"must be a number":                                      "必须是数字",
+	"must be greater than 0":                                "必须大于 0",
+	"is too large":                                          "过大",
+	"is not a known field":                                  "不是已知字段",
+	"must not be null":                                      "不能为 null",
+	"must be a string":                                      "必须是字符串",
+	"must be an integer":                                    "必须是整数",
+	"must be a boolean":                                     "必须是布尔值",
+	"must be an object":                                     "必须是对象",
+	"must be an array":                                      "必须是数组",
+	"must be one of %s":                                     "必须是以下之一：%s",
+	"must be at least %d characters":                        "至少 %d 个字符",
+	"must be 3-32 lowercase letters, digits or underscores": "必须是 3-32 个小写字母、数字或下划线",
+	"must be a base58 X25519 public key":                    "必须是 base58 编码的 X25519 公钥",
+	"must be an RFC 3339 time or Unix timestamp":            "必须是 RFC 3339 时间或 Unix 时间戳",
+	"must be a slot number":                                 "必须是槽位编号",
+	"is not a cursor returned by a previous page":           "不是上一页返回的游标",
+	"must be %s or %s":                                      "必须是 %s 或 %s",
+	"must be a non-negative number":                         "必须是非负数",
+	"must be a calendar year":                               "必须是日历年份",
+
+	// CLI: main menu and status
+	"ShadowPay CLI":               "ShadowPay 命令行",
+	"Connected":                   "已连接",
+	"Not Connected (Set API Key)": "未连接（请设置 API 密钥）",
+	"ZK Payments":                 "零知识支付",
+	"Privacy Pool":                "隐私池",
+	"Token Management":            "代币管理",
+	"Bot Authorization":           "机器人授权",
+	"Merchant Tools":              "商户工具",
+	"Webhooks":                    "Webhook",
+	"ShadowID":                    "ShadowID",
+	"Wallets & Contacts":          "钱包与联系人",
+	"Setup Wizard":                "设置向导",
+	"Settings":                    "设置",
+	"Exit":                        "退出",
+	"Back":                        "返回",
+	"↑/↓: navigate • enter: select • q: quit":   "↑/↓：移动 • enter：选择 • q：退出",
+	"↑/↓: navigate • enter: select • esc: back": "↑/↓：移动 • enter：选择 • esc：返回",
+	"esc: back to main menu":                    "esc：返回主菜单",
+	"Please set API key in Settings first":      "请先在设置中配置 API 密钥",
+
+	// CLI: section headers and descriptions
+	"Select an operation:":    "请选择操作：",
+	"Manage SPL tokens:":      "管理 SPL 代币：",
+	"Manage bot permissions:": "管理机器人权限：",
+	"Merchant operations:":    "商户操作：",
+	"Webhook operations:":     "Webhook 操作：",
+	"ShadowID operations:":    "ShadowID 操作：",
+	"Privacy pools mix your funds with other users\nfor maximum anonymity on-chain.":        "隐私池将您的资金与其他用户的资金混合，\n实现链上最大程度的匿名。",
+	"Allow bots and services to spend from your\nescrow with custom limits and expiration.": "允许机器人和服务在自定义限额和有效期内\n从您的托管账户中支出。",
+	"Anonymous identity system using Merkle trees\nfor privacy-preserving authentication.":  "基于默克尔树的匿名身份系统，\n用于保护隐私的身份验证。",
+	"API Key not set": "未设置 API 密钥",
+	"API Key: ":       "API 密钥：",
+	"Language: ":      "语言：",
+	"To set your API key, run:\nexport SHADOWPAY_API_KEY=your_key_here\n\nOr create a .env file with:\nSHADOWPAY_API_KEY=your_key_here": "要设置 API 密钥，请运行：\nexport SHADOWPAY_API_KEY=您的密钥\n\n或创建包含以下内容的 .env 文件：\nSHADOWPAY_API_KEY=您的密钥",
+	"To change the language, set SHADOWPAY_LANG (en, es, zh).":                                                                          "要更改语言，请设置 SHADOWPAY_LANG（en、es、zh）。",
+	"Or run the Setup Wizard to generate one.":                                                                                          "或运行设置向导来生成一个。",
+
+	// CLI: menu items
+	"Deposit Funds":           "存入资金",
+	"Withdraw Funds":          "提取资金",
+	"Prepare Payment":         "准备付款",
+	"Authorize Payment":       "授权付款",
+	"Verify Access":           "验证访问",
+	"Settle Payment":          "结算付款",
+	"Track Transaction":       "跟踪交易",
+	"Check Balance":           "查询余额",
+	"Deposit to Pool":         "存入隐私池",
+	"Withdraw from Pool":      "从隐私池提取",
+	"Quote Withdrawal Fee":    "查询提取手续费",
+	"Get Deposit Address":     "获取存款地址",
+	"List Wallets":            "钱包列表",
+	"Add Wallet":              "添加钱包",
+	"Select Default":          "设为默认",
+	"Remove Wallet":           "删除钱包",
+	"Add Contact":             "添加联系人",
+	"Search Contacts":         "搜索联系人",
+	"Remove Contact":          "删除联系人",
+	"Register Stealth Handle": "注册隐身名称",
+	"Look Up Handle":          "查找名称",
+	"List Supported Tokens":   "支持的代币",
+	"Escrow Balances":         "托管余额",
+	"Add New Token":           "添加代币",
+	"Update Token":            "更新代币",
+	"Remove Token":            "删除代币",
+	"Authorize Bot Spending":  "授权机器人支出",
+	"List Authorizations":     "授权列表",
+	"Update Limits":           "更新限额",
+	"Revoke Authorization":    "撤销授权",
+	"View Earnings":           "查看收入",
+	"Get Analytics":           "查看分析",
+	"Withdraw Earnings":       "提取收入",
+	"Decrypt Amount":          "解密金额",
+	"Create Payment Link":     "创建付款链接",
+	"List Payment Links":      "付款链接列表",
+	"Create Invoice":          "创建发票",
+	"List Invoices":           "发票列表",
+	"Download Invoice PDF":    "下载发票 PDF",
+	"Register Webhook":        "注册 Webhook",
+	"Get Configuration":       "查看配置",
+	"Test Webhook":            "测试 Webhook",
+	"View Logs":               "查看日志",
+	"Get Stats":               "查看统计",
+	"Deactivate Webhook":      "停用 Webhook",
+	"Auto Register":           "自动注册",
+	"Register Commitment":     "注册承诺",
+	"Get Proof":               "获取证明",
+	"Get Tree Root":           "获取树根",
+	"Check Status":            "查询状态",
+	"Debug Proof":             "调试证明",
+
+	// CLI: form titles
+	"Select Default Wallet":         "选择默认钱包",
+	"Update Authorization Limits":   "更新授权限额",
+	"Add Token":                     "添加代币",
+	"Auto Register ShadowID":        "自动注册 ShadowID",
+	"Check Pool Balance":            "查询隐私池余额",
+	"Deposit to Payment Account":    "存入付款账户",
+	"Withdraw from Payment Account": "从付款账户提取",
+	"Check Registration Status":     "查询注册状态",
+	"View Webhook Logs":             "查看 Webhook 日志",
+	"Get Merkle Proof":              "获取默克尔证明",
+	"Proof Debug":                   "证明调试",
+	"Debug Merkle Proof":            "调试默克尔证明",
+	"Verify Access Token":           "验证访问令牌",
+	"Prepare ZK Payment":            "准备零知识付款",
+
+	// CLI: form fields
+	"Access Token": "访问令牌",
+	"Address (blank to derive from secret key)": "地址（留空则从私钥推导）",
+	"Amount (SOL)":                                 "金额（SOL）",
+	"Authorized Service":                           "授权服务",
+	"Cancel URL":                                   "取消 URL",
+	"Commitment":                                   "承诺",
+	"Compare with local replica (y/N)":             "与本地副本比较 (y/N)",
+	"Decimals":                                     "小数位数",
+	"Description (optional)":                       "描述（可选）",
+	"Destination Wallet":                           "目标钱包",
+	"Enabled (true/false)":                         "启用（true/false）",
+	"Encrypted Ciphertext (hex)":                   "加密密文（hex）",
+	"End Date (YYYY-MM-DD, optional)":              "结束日期（YYYY-MM-DD，可选）",
+	"Event Type (optional)":                        "事件类型（可选）",
+	"Events (comma-separated)":                     "事件（逗号分隔）",
+	"Invoice ID":                                   "发票 ID",
+	"Kind (wallet, commitment, stealth)":           "类型（wallet、commitment、stealth）",
+	"Label":                                        "标签",
+	"Last Valid Block Height":                      "最后有效区块高度",
+	"Limit (default 50)":                           "数量上限（默认 50）",
+	"Max Daily (SOL)":                              "每日上限（SOL）",
+	"Max Daily (SOL, blank = keep)":                "每日上限（SOL，留空则保持不变）",
+	"Max Per Tx (SOL)":                             "单笔上限（SOL）",
+	"Max Per Tx (SOL, blank = keep)":               "单笔上限（SOL，留空则保持不变）",
+	"Merchant Wallet":                              "商户钱包",
+	"Message":                                      "消息",
+	"Mint Address":                                 "代币铸造地址",
+	"Name":                                         "名称",
+	"New Symbol (optional)":                        "新符号（可选）",
+	"Notes (optional)":                             "备注（可选）",
+	"Nullifier":                                    "作废值",
+	"Output File (optional)":                       "输出文件（可选）",
+	"Poseidon Hash Commitment":                     "Poseidon 哈希承诺",
+	"Private Key (hex)":                            "私钥（hex）",
+	"Receiver Commitment":                          "收款方承诺",
+	"Recipient Wallet":                             "收款钱包",
+	"Search (blank lists all)":                     "搜索（留空列出全部）",
+	"Handle (e.g. alice)":                          "名称（例如 alice）",
+	"Signing Wallet (blank for default)":           "签名钱包（留空使用默认）",
+	"Scan Key (hex, blank to generate)":            "扫描密钥（十六进制，留空则生成）",
+	"ShadowID Commitment (optional)":               "ShadowID 承诺（可选）",
+	"Handle or Commitment":                         "名称或承诺",
+	"Secret (optional)":                            "密钥（可选）",
+	"Secret Key (optional, base58)":                "私钥（可选，base58）",
+	"Signature":                                    "签名",
+	"Signature (base58)":                           "签名（base58）",
+	"Start Date (YYYY-MM-DD, optional)":            "开始日期（YYYY-MM-DD，可选）",
+	"Success URL":                                  "成功 URL",
+	"Symbol":                                       "符号",
+	"User Signature (base58)":                      "用户签名（base58）",
+	"User Wallet":                                  "用户钱包",
+	"Valid Until (days from now)":                  "有效期（从今天起的天数）",
+	"Valid Until (days from now, blank = keep)":    "有效期（从今天起的天数，留空则保持不变）",
+	"Value (address, commitment or meta-address)":  "值（地址、承诺或元地址）",
+	"Wallet Address":                               "钱包地址",
+	"Webhook ID":                                   "Webhook ID",
+	"Webhook ID (optional)":                        "Webhook ID（可选）",
+	"Webhook URL (https://...)":                    "Webhook URL（https://...）",
+	"Your Name":                                    "您的名称",
+	"Your Wallet (receives payment)":               "您的钱包（收款）",
+	"Customer Name":                                "客户名称",
+	"Currency (SOL or USDC)":                       "币种（SOL 或 USDC）",
+	"Line Items (description|qty|unit price; ...)": "明细（描述|数量|单价; ...）",
+	"Tax % (optional)":                             "税率 %（可选）",
+	"Due In Days":                                  "到期天数",
+	"or name":                                      "或名称",
+
+	// CLI: forms, loading and results
+	"tab/shift+tab: navigate • enter: submit • esc: cancel":          "tab/shift+tab：切换 • enter：提交 • esc：取消",
+	"→: accept suggestion • ctrl+n/ctrl+p: next/previous suggestion": "→：接受建议 • ctrl+n/ctrl+p：下一个/上一个建议",
+	"%d matches":          "%d 个匹配项",
+	"Processing...":       "处理中...",
+	"Elapsed: %s":         "已用时间：%s",
+	"esc: cancel":         "esc：取消",
+	"Operation cancelled": "操作已取消",
+	"Row %d of %d":        "第 %d 行，共 %d 行",
+	"(filtered from %d)":  "（从 %d 行中筛选）",
+	"No matching results": "没有匹配的结果",
+	"search":              "搜索",
+	"↑/↓: select • pgup/pgdn: page • /: search • enter: open • esc: back": "↑/↓：选择 • pgup/pgdn：翻页 • /：搜索 • enter：打开 • esc：返回",
+	"enter: apply search • esc: clear search":                             "enter：应用搜索 • esc：清除搜索",
+	"↑/↓/pgup/pgdn: scroll • esc: back to list":                           "↑/↓/pgup/pgdn：滚动 • esc：返回列表",
+
+	// CLI: loading messages
+	"Adding token...":             "正在添加代币...",
+	"Authorizing payment...":      "正在授权付款...",
+	"Authorizing spending...":     "正在授权支出...",
+	"Checking balance...":         "正在查询余额...",
+	"Checking status...":          "正在查询状态...",
+	"Creating deposit...":         "正在创建存款...",
+	"Creating invoice...":         "正在创建发票...",
+	"Creating payment link...":    "正在创建付款链接...",
+	"Creating pool deposit...":    "正在创建隐私池存款...",
+	"Creating pool withdrawal...": "正在创建隐私池提款...",
+	"Creating withdrawal...":      "正在创建提款...",
+	"Deactivating webhook...":     "正在停用 Webhook...",
+	"Decrypting amount...":        "正在解密金额...",
+	"Downloading invoice...":      "正在下载发票...",
+	"Fetching Merkle proof...":    "正在获取默克尔证明...",
+	"Debugging proof...":          "正在调试证明...",
+	"Fetching tree root...":       "正在获取树根...",
+	"Getting deposit address...":  "正在获取存款地址...",
+	"Loading analytics...":        "正在加载分析数据...",
+	"Loading authorizations...":   "正在加载授权...",
+	"Loading earnings...":         "正在加载收入...",
+	"Loading escrow balances...":  "正在加载托管余额...",
+	"Loading invoices...":         "正在加载发票...",
+	"Loading payment links...":    "正在加载付款链接...",
+	"Loading tokens...":           "正在加载代币...",
+	"Loading webhook config...":   "正在加载 Webhook 配置...",
+	"Loading webhook logs...":     "正在加载 Webhook 日志...",
+	"Loading webhook stats...":    "正在加载 Webhook 统计...",
+	"Preparing payment...":        "正在准备付款...",
+	"Quoting withdrawal...":       "正在计算提款费用...",
+	"Registering ShadowID...":     "正在注册 ShadowID...",
+	"Registering commitment...":   "正在注册承诺...",
+	"Registering webhook...":      "正在注册 Webhook...",
+	"Registering handle...":       "正在注册名称...",
+	"Looking up handle...":        "正在查找名称...",
+	"Removing token...":           "正在删除代币...",
+	"Revoking authorization...":   "正在撤销授权...",
+	"Sending test event...":       "正在发送测试事件...",
+	"Updating authorization...":   "正在更新授权...",
+	"Updating token...":           "正在更新代币...",
+	"Verifying access...":         "正在验证访问...",
+	"Waiting for confirmation...": "正在等待确认...",
+}
@@ -2,6 +2,10 @@ package intent
 
 import (
 	"context"
+	"fmt"
+
+	"sol_privacy/internal/resolve"
+	"sol_privacy/internal/validate"
 )
 
 // Service handles payment intent operations.
@@ -19,15 +23,26 @@ func NewService(doRequest func(ctx context.Context, method, path string, body, r
 // CreateRequest represents a request to create a payment intent.
 type CreateRequest struct {
 	Amount    int64  `json:"amount"`
-	Recipient string `json:"recipient"`
+	Recipient string `json:"recipient"` // Wallet address or .sol name
 	Reference string `json:"reference"` // Often a unique ID
+
+	// Metadata holds custom fields, e.g. an order ID, customer reference or
+	// SKU list. It is echoed back by Verify and in webhook events, and can be
+	// searched by. See validate.MaxMetadataKeys for the limits.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Validate checks the request fields.
+func (r CreateRequest) Validate() error {
+	return validate.New().Metadata("metadata", r.Metadata).Err()
 }
 
 // Response represents a payment intent response.
 type Response struct {
-	IntentID     string `json:"intent_id"`
-	ClientSecret string `json:"client_secret"`
-	Status       string `json:"status"`
+	IntentID     string            `json:"intent_id"`
+	ClientSecret string            `json:"client_secret"`
+	Status       string            `json:"status"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
 }
 
 // VerifyRequest represents a request to verify a payment intent.
@@ -40,10 +55,57 @@ type VerifyResponse struct {
 	Status    string `json:"status"`
 	Verified  bool   `json:"verified"`
 	Timestamp int64  `json:"timestamp"`
+
+	Reference string            `json:"reference,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// Intent is a payment intent as returned by Search.
+type Intent struct {
+	IntentID  string            `json:"intent_id"`
+	Amount    int64             `json:"amount"`
+	Recipient string            `json:"recipient"`
+	Reference string            `json:"reference"`
+	Status    string            `json:"status"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	CreatedAt int64             `json:"created_at"`
+}
+
+// SearchRequest filters payment intents. Intents match when their metadata
+// has every key in Metadata with the same value.
+type SearchRequest struct {
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Status   string            `json:"status,omitempty"`
+	Limit    int               `json:"limit,omitempty"`
+	Cursor   string            `json:"cursor,omitempty"` // NextCursor of the previous page
+}
+
+// Validate checks the request fields.
+func (r SearchRequest) Validate() error {
+	v := validate.New().Metadata("metadata", r.Metadata)
+	if r.Limit < 0 || r.Limit > 100 {
+		v.Add("limit", fmt.Errorf("must be between 0 and 100"))
+	}
+	return v.Err()
 }
 
-// Create creates a new standard payment intent.
+// SearchResponse is a page of matching payment intents.
+type SearchResponse struct {
+	Intents    []Intent `json:"intents"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+// Create creates a new standard payment intent. A .sol recipient is
+// resolved to the wallet that owns it.
 func (s *Service) Create(ctx context.Context, req CreateRequest) (*Response, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	recipient, err := resolve.Recipient(ctx, req.Recipient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve recipient %s: %w", req.Recipient, err)
+	}
+	req.Recipient = recipient
 	var resp Response
 	if err := s.doRequest(ctx, "POST", "/shadowpay/v1/pay/intent", req, &resp); err != nil {
 		return nil, err
@@ -61,6 +123,19 @@ func (s *Service) Verify(ctx context.Context, intentID string) (*VerifyResponse,
 	return &resp, nil
 }
 
+// Search lists payment intents filtered by metadata and status. Filtering
+// happens server-side; page through results with NextCursor.
+func (s *Service) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var resp SearchResponse
+	if err := s.doRequest(ctx, "POST", "/shadowpay/v1/pay/intents/search", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
 // GetPublicKey retrieves the server's public key for payment verification.
 func (s *Service) GetPublicKey(ctx context.Context) (string, error) {
 	type keyResponse struct {
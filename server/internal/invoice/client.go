@@ -0,0 +1,89 @@
+package invoice
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+)
+
+// Client manages invoices on a running proxy, e.g. through proxyclient.Client.Do.
+type Client struct {
+	doRequest func(ctx context.Context, method, path string, body, result interface{}) error
+}
+
+// NewClient creates an invoice client.
+func NewClient(doRequest func(ctx context.Context, method, path string, body, result interface{}) error) *Client {
+	return &Client{doRequest: doRequest}
+}
+
+// Create issues an invoice.
+func (c *Client) Create(ctx context.Context, req CreateRequest) (*Invoice, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var inv Invoice
+	if err := c.doRequest(ctx, "POST", "/invoices", req, &inv); err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// Get returns an invoice.
+func (c *Client) Get(ctx context.Context, id string) (*Invoice, error) {
+	var inv Invoice
+	if err := c.doRequest(ctx, "GET", "/invoices/"+id, nil, &inv); err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// List returns invoices, newest first, optionally filtered by status.
+func (c *Client) List(ctx context.Context, status string) ([]*Invoice, error) {
+	path := "/invoices"
+	if status != "" {
+		path += "?status=" + url.QueryEscape(status)
+	}
+	var resp struct {
+		Invoices []*Invoice `json:"invoices"`
+	}
+	if err := c.doRequest(ctx, "GET", path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Invoices, nil
+}
+
+// Update changes an open invoice.
+func (c *Client) Update(ctx context.Context, id string, req UpdateRequest) (*Invoice, error) {
+	var inv Invoice
+	if err := c.doRequest(ctx, "PATCH", "/invoices/"+id, req, &inv); err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// Void cancels an unpaid invoice.
+func (c *Client) Void(ctx context.Context, id string) (*Invoice, error) {
+	var inv Invoice
+	if err := c.doRequest(ctx, "POST", "/invoices/"+id+"/void", nil, &inv); err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// Refresh checks whether an invoice has been paid.
+func (c *Client) Refresh(ctx context.Context, id string) (*Invoice, error) {
+	var inv Invoice
+	if err := c.doRequest(ctx, "POST", "/invoices/"+id+"/refresh", nil, &inv); err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// PDF downloads the rendered invoice.
+func (c *Client) PDF(ctx context.Context, id string) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := c.doRequest(ctx, "GET", "/invoices/"+id+"/pdf", nil, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,489 @@
+// Package invoice bills customers with itemized invoices paid through a
+// ShadowPay payment intent, tracking paid and overdue state.
+package invoice
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/intent"
+	"sol_privacy/internal/jsonfile"
+	"sol_privacy/internal/validate"
+)
+
+// Invoice statuses. Overdue is derived from the due date of open invoices.
+const (
+	StatusOpen    = "open"
+	StatusOverdue = "overdue"
+	StatusPaid    = "paid"
+	StatusVoid    = "void"
+)
+
+// Supported currencies.
+const (
+	CurrencySOL  = "SOL"
+	CurrencyUSDC = "USDC"
+)
+
+// Webhook events that prompt a payment check.
+const (
+	EventPaymentReceived = "payment.received"
+	EventPaymentSettled  = "payment.settled"
+)
+
+// MaxTaxRateBps caps the tax rate at 100%.
+const MaxTaxRateBps = 10000
+
+var (
+	// ErrInvoiceNotFound is returned for unknown invoice IDs.
+	ErrInvoiceNotFound = errors.New("invoice not found")
+	// ErrInvoiceClosed is returned when modifying a paid or void invoice.
+	ErrInvoiceClosed = errors.New("invoice is paid or void")
+)
+
+// Decimals returns the number of decimals of a currency's base unit.
+func Decimals(currency string) int {
+	if currency == CurrencyUSDC {
+		return 6
+	}
+	return 9
+}
+
+// FormatAmount formats base units as a decimal amount in currency.
+func FormatAmount(amount int64, currency string) string {
+	return strconv.FormatFloat(float64(amount)/pow10(Decimals(currency)), 'f', Decimals(currency), 64) + " " + currency
+}
+
+func pow10(n int) float64 {
+	f := 1.0
+	for i := 0; i < n; i++ {
+		f *= 10
+	}
+	return f
+}
+
+// Party identifies the merchant or customer on an invoice.
+type Party struct {
+	Name   string `json:"name"`
+	Email  string `json:"email,omitempty"`
+	Wallet string `json:"wallet,omitempty"`
+}
+
+// LineItem is a billed item. UnitPrice is in the currency's base units.
+type LineItem struct {
+	Description string `json:"description"`
+	Quantity    int64  `json:"quantity"`
+	UnitPrice   int64  `json:"unit_price"`
+	Amount      int64  `json:"amount"` // Quantity * UnitPrice, computed
+}
+
+// Invoice is an itemized bill.
+type Invoice struct {
+	ID          string     `json:"id"`
+	Number      string     `json:"number"`
+	Merchant    Party      `json:"merchant"` // Merchant.Wallet receives the payment
+	Customer    Party      `json:"customer"`
+	Currency    string     `json:"currency"`
+	LineItems   []LineItem `json:"line_items"`
+	TaxRateBps  int        `json:"tax_rate_bps"`
+	Subtotal    int64      `json:"subtotal"`
+	Tax         int64      `json:"tax"`
+	Total       int64      `json:"total"`
+	Notes       string     `json:"notes,omitempty"`
+	Status      string     `json:"status"`
+	IntentID    string     `json:"intent_id"`
+	TxSignature string     `json:"tx_signature,omitempty"`
+	IssuedAt    int64      `json:"issued_at"`
+	DueDate     int64      `json:"due_date"`
+	PaidAt      int64      `json:"paid_at,omitempty"`
+	VoidedAt    int64      `json:"voided_at,omitempty"`
+}
+
+// withStatus returns the invoice with open invoices past their due date marked overdue.
+func (inv Invoice) withStatus(now time.Time) *Invoice {
+	if inv.Status == StatusOpen && now.Unix() > inv.DueDate {
+		inv.Status = StatusOverdue
+	}
+	return &inv
+}
+
+// CreateRequest represents a request to create an invoice.
+type CreateRequest struct {
+	Merchant   Party      `json:"merchant"`
+	Customer   Party      `json:"customer"`
+	Currency   string     `json:"currency"` // SOL (default) or USDC
+	LineItems  []LineItem `json:"line_items"`
+	TaxRateBps int        `json:"tax_rate_bps,omitempty"`
+	DueDate    int64      `json:"due_date"` // Unix time
+	Notes      string     `json:"notes,omitempty"`
+}
+
+// Validate checks the request fields.
+func (r CreateRequest) Validate() error {
+	v := validate.New().
+		Address("merchant.wallet", r.Merchant.Wallet).
+		Required("customer.name", r.Customer.Name)
+	if r.Currency != "" && r.Currency != CurrencySOL && r.Currency != CurrencyUSDC {
+		v.Add("currency", errors.New("must be SOL or USDC"))
+	}
+	if len(r.LineItems) == 0 {
+		v.Add("line_items", errors.New("at least one line item is required"))
+	}
+	var subtotal int64
+	for i, item := range r.LineItems {
+		field := "line_items[" + strconv.Itoa(i) + "]"
+		v.Required(field+".description", item.Description)
+		v.Amount(field+".quantity", item.Quantity, 1, 1000000)
+		v.Amount(field+".unit_price", item.UnitPrice, 1, validate.MaxLamports)
+		if item.Quantity > 0 && item.UnitPrice > 0 {
+			if item.UnitPrice > (validate.MaxLamports-subtotal)/item.Quantity {
+				v.Add("line_items", errors.New("invoice total is too large"))
+				break
+			}
+			subtotal += item.Quantity * item.UnitPrice
+		}
+	}
+	if r.TaxRateBps < 0 || r.TaxRateBps > MaxTaxRateBps {
+		v.Add("tax_rate_bps", fmt.Errorf("must be between 0 and %d", MaxTaxRateBps))
+	}
+	if r.DueDate <= 0 {
+		v.Add("due_date", errors.New("is required"))
+	}
+	return v.Err()
+}
+
+// UpdateRequest changes an open invoice. Nil fields are left unchanged.
+type UpdateRequest struct {
+	Customer   *Party     `json:"customer,omitempty"`
+	LineItems  []LineItem `json:"line_items,omitempty"`
+	TaxRateBps *int       `json:"tax_rate_bps,omitempty"`
+	DueDate    *int64     `json:"due_date,omitempty"`
+	Notes      *string    `json:"notes,omitempty"`
+}
+
+// WebhookEvent is the payload of a ShadowPay payment webhook.
+type WebhookEvent struct {
+	Event string `json:"event"`
+	Data  struct {
+		IntentID    string            `json:"intent_id"`
+		Reference   string            `json:"reference"`
+		TxSignature string            `json:"tx_signature"`
+		Metadata    map[string]string `json:"metadata,omitempty"` // The intent's metadata
+	} `json:"data"`
+}
+
+// Store persists invoices.
+type Store interface {
+	Put(inv *Invoice) error
+	Get(id string) (*Invoice, error) // Returns ErrInvoiceNotFound for unknown IDs
+	List() ([]*Invoice, error)
+}
+
+// NewMemoryStore creates an empty in-memory Store.
+func NewMemoryStore() Store {
+	return store{jsonfile.NewMemoryStore(invoiceID, nil)}
+}
+
+// NewFileStore opens a Store persisted to a JSON file after every write,
+// creating it on first write.
+func NewFileStore(path string) (Store, error) {
+	invoices, err := jsonfile.NewStore(path, invoiceID, nil)
+	if err != nil {
+		return nil, err
+	}
+	return store{invoices}, nil
+}
+
+func invoiceID(inv *Invoice) string { return inv.ID }
+
+// store keeps invoices in a jsonfile.Store.
+type store struct {
+	invoices *jsonfile.Store[Invoice]
+}
+
+func (s store) Put(inv *Invoice) error {
+	return s.invoices.Put(inv)
+}
+
+func (s store) Get(id string) (*Invoice, error) {
+	inv, ok := s.invoices.Get(id)
+	if !ok {
+		return nil, ErrInvoiceNotFound
+	}
+	return inv, nil
+}
+
+// List returns all invoices, newest first.
+func (s store) List() ([]*Invoice, error) {
+	return s.invoices.List(func(a, b *Invoice) bool { return a.Number > b.Number }), nil
+}
+
+// Service creates invoices and tracks their payment.
+type Service struct {
+	intents *intent.Service
+	store   Store
+
+	mu sync.Mutex // Serializes numbering and state transitions
+}
+
+// NewService creates an invoice service. A nil store selects an in-memory store.
+func NewService(intents *intent.Service, store Store) *Service {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	return &Service{intents: intents, store: store}
+}
+
+// Create issues an open invoice and the payment intent for its total.
+func (s *Service) Create(ctx context.Context, req CreateRequest) (*Invoice, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if req.Currency == "" {
+		req.Currency = CurrencySOL
+	}
+
+	inv := &Invoice{
+		ID:         newID(),
+		Merchant:   req.Merchant,
+		Customer:   req.Customer,
+		Currency:   req.Currency,
+		LineItems:  req.LineItems,
+		TaxRateBps: req.TaxRateBps,
+		Notes:      req.Notes,
+		Status:     StatusOpen,
+		IssuedAt:   time.Now().Unix(),
+		DueDate:    req.DueDate,
+	}
+	inv.computeTotals()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	number, err := s.nextNumber()
+	if err != nil {
+		return nil, err
+	}
+	inv.Number = number
+
+	if err := s.attachIntent(ctx, inv); err != nil {
+		return nil, err
+	}
+	if err := s.store.Put(inv); err != nil {
+		return nil, fmt.Errorf("failed to save invoice: %w", err)
+	}
+	return inv.withStatus(time.Now()), nil
+}
+
+// Get returns an invoice.
+func (s *Service) Get(ctx context.Context, id string) (*Invoice, error) {
+	inv, err := s.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return inv.withStatus(time.Now()), nil
+}
+
+// List returns invoices, newest first, optionally filtered by status.
+func (s *Service) List(ctx context.Context, status string) ([]*Invoice, error) {
+	all, err := s.store.List()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	out := make([]*Invoice, 0, len(all))
+	for _, inv := range all {
+		inv = inv.withStatus(now)
+		if status == "" || inv.Status == status {
+			out = append(out, inv)
+		}
+	}
+	return out, nil
+}
+
+// Update changes an open invoice. A new payment intent is created if the total changes.
+func (s *Service) Update(ctx context.Context, id string, req UpdateRequest) (*Invoice, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inv, err := s.openInvoice(id)
+	if err != nil {
+		return nil, err
+	}
+
+	update := CreateRequest{
+		Merchant:   inv.Merchant,
+		Customer:   inv.Customer,
+		Currency:   inv.Currency,
+		LineItems:  inv.LineItems,
+		TaxRateBps: inv.TaxRateBps,
+		DueDate:    inv.DueDate,
+		Notes:      inv.Notes,
+	}
+	if req.Customer != nil {
+		update.Customer = *req.Customer
+	}
+	if req.LineItems != nil {
+		update.LineItems = req.LineItems
+	}
+	if req.TaxRateBps != nil {
+		update.TaxRateBps = *req.TaxRateBps
+	}
+	if req.DueDate != nil {
+		update.DueDate = *req.DueDate
+	}
+	if req.Notes != nil {
+		update.Notes = *req.Notes
+	}
+	if err := update.Validate(); err != nil {
+		return nil, err
+	}
+
+	oldTotal := inv.Total
+	inv.Customer = update.Customer
+	inv.LineItems = update.LineItems
+	inv.TaxRateBps = update.TaxRateBps
+	inv.DueDate = update.DueDate
+	inv.Notes = update.Notes
+	inv.computeTotals()
+
+	if inv.Total != oldTotal {
+		if err := s.attachIntent(ctx, inv); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.store.Put(inv); err != nil {
+		return nil, fmt.Errorf("failed to save invoice: %w", err)
+	}
+	return inv.withStatus(time.Now()), nil
+}
+
+// Void cancels an unpaid invoice.
+func (s *Service) Void(ctx context.Context, id string) (*Invoice, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inv, err := s.openInvoice(id)
+	if err != nil {
+		return nil, err
+	}
+	inv.Status = StatusVoid
+	inv.VoidedAt = time.Now().Unix()
+	if err := s.store.Put(inv); err != nil {
+		return nil, fmt.Errorf("failed to save invoice: %w", err)
+	}
+	return inv, nil
+}
+
+// Refresh checks the invoice's payment intent and marks it paid once verified.
+func (s *Service) Refresh(ctx context.Context, id, txSignature string) (*Invoice, error) {
+	inv, err := s.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if inv.Status != StatusOpen {
+		return inv.withStatus(time.Now()), nil
+	}
+
+	verified, err := s.intents.Verify(ctx, inv.IntentID)
+	if err != nil {
+		return nil, err
+	}
+	if !verified.Verified {
+		return inv.withStatus(time.Now()), nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if inv, err = s.store.Get(id); err != nil {
+		return nil, err
+	}
+	if inv.Status == StatusOpen {
+		inv.Status = StatusPaid
+		inv.PaidAt = time.Now().Unix()
+		inv.TxSignature = txSignature
+		if err := s.store.Put(inv); err != nil {
+			return nil, fmt.Errorf("failed to save invoice: %w", err)
+		}
+	}
+	return inv, nil
+}
+
+// HandleWebhook refreshes the invoice a payment webhook refers to, matched by
+// intent ID or by invoice number as the intent reference. The event only
+// triggers a check; payment is always confirmed against the intent.
+func (s *Service) HandleWebhook(ctx context.Context, event WebhookEvent) (*Invoice, error) {
+	if event.Event != EventPaymentReceived && event.Event != EventPaymentSettled {
+		return nil, nil
+	}
+
+	all, err := s.store.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, inv := range all {
+		if (event.Data.IntentID != "" && inv.IntentID == event.Data.IntentID) ||
+			(event.Data.Reference != "" && inv.Number == event.Data.Reference) {
+			return s.Refresh(ctx, inv.ID, event.Data.TxSignature)
+		}
+	}
+	return nil, ErrInvoiceNotFound
+}
+
+func (s *Service) openInvoice(id string) (*Invoice, error) {
+	inv, err := s.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if inv.Status != StatusOpen {
+		return nil, ErrInvoiceClosed
+	}
+	return inv, nil
+}
+
+// attachIntent creates the payment intent for the invoice total, referenced by invoice number.
+func (s *Service) attachIntent(ctx context.Context, inv *Invoice) error {
+	resp, err := s.intents.Create(ctx, intent.CreateRequest{
+		Amount:    inv.Total,
+		Recipient: inv.Merchant.Wallet,
+		Reference: inv.Number,
+	})
+	if err != nil {
+		return err
+	}
+	inv.IntentID = resp.IntentID
+	return nil
+}
+
+// nextNumber returns the next sequential invoice number. Callers hold s.mu.
+func (s *Service) nextNumber() (string, error) {
+	all, err := s.store.List()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("INV-%06d", len(all)+1), nil
+}
+
+func (inv *Invoice) computeTotals() {
+	inv.Subtotal = 0
+	for i := range inv.LineItems {
+		inv.LineItems[i].Amount = inv.LineItems[i].Quantity * inv.LineItems[i].UnitPrice
+		inv.Subtotal += inv.LineItems[i].Amount
+	}
+	// Split to avoid overflow; rounds half up
+	bps := int64(inv.TaxRateBps)
+	inv.Tax = inv.Subtotal/MaxTaxRateBps*bps + (inv.Subtotal%MaxTaxRateBps*bps+MaxTaxRateBps/2)/MaxTaxRateBps
+	inv.Total = inv.Subtotal + inv.Tax
+}
+
+func newID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "inv_" + hex.EncodeToString(b)
+}
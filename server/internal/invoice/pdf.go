@@ -0,0 +1,153 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PDF layout on an A4 page, in points.
+const (
+	pageWidth    = 595
+	pageHeight   = 842
+	pageMargin   = 50
+	lineHeight   = 16
+	linesPerPage = (pageHeight - 2*pageMargin) / lineHeight
+)
+
+// pdfLine is a line of text; Right is right-aligned at the margin on the same baseline.
+type pdfLine struct {
+	Text  string
+	Right string
+	Bold  bool
+	Size  int
+}
+
+// WritePDF renders the invoice as a PDF document using the standard Helvetica fonts.
+func WritePDF(w io.Writer, inv *Invoice) error {
+	var lines []pdfLine
+	add := func(text, right string, bold bool, size int) {
+		lines = append(lines, pdfLine{Text: text, Right: right, Bold: bold, Size: size})
+	}
+	date := func(unix int64) string { return time.Unix(unix, 0).UTC().Format("2006-01-02") }
+
+	add("INVOICE "+inv.Number, strings.ToUpper(inv.Status), true, 18)
+	add("", "", false, 10)
+	add("Issued: "+date(inv.IssuedAt), "Due: "+date(inv.DueDate), false, 10)
+	add("", "", false, 10)
+	add("From", "Bill to", true, 11)
+	add(inv.Merchant.Name, inv.Customer.Name, false, 10)
+	if inv.Merchant.Email != "" || inv.Customer.Email != "" {
+		add(inv.Merchant.Email, inv.Customer.Email, false, 10)
+	}
+	add(inv.Merchant.Wallet, inv.Customer.Wallet, false, 8)
+	add("", "", false, 10)
+	add("Description", "Amount", true, 11)
+	for _, item := range inv.LineItems {
+		add(fmt.Sprintf("%s  (%d x %s)", item.Description, item.Quantity, FormatAmount(item.UnitPrice, inv.Currency)),
+			FormatAmount(item.Amount, inv.Currency), false, 10)
+	}
+	add("", "", false, 10)
+	add("Subtotal", FormatAmount(inv.Subtotal, inv.Currency), false, 10)
+	add(fmt.Sprintf("Tax (%s%%)", strconv.FormatFloat(float64(inv.TaxRateBps)/100, 'f', -1, 64)),
+		FormatAmount(inv.Tax, inv.Currency), false, 10)
+	add("Total", FormatAmount(inv.Total, inv.Currency), true, 12)
+	add("", "", false, 10)
+	add("Payment intent: "+inv.IntentID, "", false, 8)
+	if inv.TxSignature != "" {
+		add("Transaction: "+inv.TxSignature, "", false, 8)
+	}
+	if inv.Notes != "" {
+		add("", "", false, 10)
+		for _, note := range strings.Split(inv.Notes, "\n") {
+			add(note, "", false, 10)
+		}
+	}
+
+	var pages []string
+	for start := 0; start < len(lines); start += linesPerPage {
+		end := start + linesPerPage
+		if end > len(lines) {
+			end = len(lines)
+		}
+		pages = append(pages, pageContent(lines[start:end]))
+	}
+	return writeDocument(w, pages)
+}
+
+func pageContent(lines []pdfLine) string {
+	var b strings.Builder
+	y := pageHeight - pageMargin
+	for _, l := range lines {
+		font := "F1"
+		if l.Bold {
+			font = "F2"
+		}
+		if l.Text != "" {
+			fmt.Fprintf(&b, "BT /%s %d Tf %d %d Td (%s) Tj ET\n", font, l.Size, pageMargin, y, pdfEscape(l.Text))
+		}
+		if l.Right != "" {
+			// Helvetica averages about half an em per character
+			x := pageWidth - pageMargin - len(l.Right)*l.Size/2
+			fmt.Fprintf(&b, "BT /%s %d Tf %d %d Td (%s) Tj ET\n", font, l.Size, x, y, pdfEscape(l.Right))
+		}
+		y -= lineHeight
+	}
+	return b.String()
+}
+
+// writeDocument writes the catalog, page tree, fonts and one content stream per page.
+func writeDocument(w io.Writer, pages []string) error {
+	var buf bytes.Buffer
+	var offsets []int
+	obj := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+	// Objects 1-4 are fixed; each page then takes a page and a content object
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, content := range pages {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 6+2*i))
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// pdfEscape escapes a PDF string literal, replacing characters outside printable ASCII.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,140 @@
+package jsonfile
+
+import (
+	"slices"
+	"sort"
+	"sync"
+)
+
+// Store is a set of values by key, held in memory and, when it has a path,
+// saved to that file as a JSON list after every write. Values keep the order
+// they were first put in. It stores and returns copies, so callers never
+// share a value with it.
+type Store[V any] struct {
+	path  string
+	key   func(*V) string
+	clone func(V) V
+	mu    sync.RWMutex // Also serializes file writes
+	rows  map[string]V
+	keys  []string // In insertion order
+}
+
+// NewStore opens the store at path, creating it on first write. key returns
+// a value's key; clone, if not nil, deep-copies values that hold slices or
+// maps.
+func NewStore[V any](path string, key func(*V) string, clone func(V) V) (*Store[V], error) {
+	s := &Store[V]{path: path, key: key, clone: clone, rows: make(map[string]V)}
+	if path == "" {
+		return s, nil
+	}
+	var rows []V
+	if err := Load(path, &rows); err != nil {
+		return nil, err
+	}
+	for _, v := range rows {
+		s.add(key(&v), v)
+	}
+	return s, nil
+}
+
+// NewMemoryStore creates an empty store that is never saved.
+func NewMemoryStore[V any](key func(*V) string, clone func(V) V) *Store[V] {
+	s, _ := NewStore("", key, clone)
+	return s
+}
+
+// add sets the value of key, appending new keys. The caller holds s.mu.
+func (s *Store[V]) add(key string, v V) {
+	if _, ok := s.rows[key]; !ok {
+		s.keys = append(s.keys, key)
+	}
+	s.rows[key] = v
+}
+
+func (s *Store[V]) copy(v V) V {
+	if s.clone == nil {
+		return v
+	}
+	return s.clone(v)
+}
+
+// Get returns a copy of the value with key, or false if there is none.
+func (s *Store[V]) Get(key string) (*V, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.rows[key]
+	if !ok {
+		return nil, false
+	}
+	v = s.copy(v)
+	return &v, true
+}
+
+// Put saves a copy of v, replacing the value with the same key.
+func (s *Store[V]) Put(v *V) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.add(s.key(v), s.copy(*v))
+	return s.save()
+}
+
+// Delete removes and returns the value with key, or returns nil if there
+// is none.
+func (s *Store[V]) Delete(key string) (*V, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.rows[key]
+	if !ok {
+		return nil, nil
+	}
+	delete(s.rows, key)
+	s.keys = slices.DeleteFunc(s.keys, func(k string) bool { return k == key })
+	return &v, s.save()
+}
+
+// DeleteFunc removes the values drop returns true for.
+func (s *Store[V]) DeleteFunc(drop func(*V) bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.keys)
+	s.keys = slices.DeleteFunc(s.keys, func(k string) bool {
+		v := s.rows[k]
+		if !drop(&v) {
+			return false
+		}
+		delete(s.rows, k)
+		return true
+	})
+	if len(s.keys) == n {
+		return nil
+	}
+	return s.save()
+}
+
+// List returns copies of all values, ordered by less if it is not nil.
+// Values less does not order keep their insertion order.
+func (s *Store[V]) List(less func(a, b *V) bool) []*V {
+	s.mu.RLock()
+	out := make([]*V, len(s.keys))
+	for i, k := range s.keys {
+		v := s.copy(s.rows[k])
+		out[i] = &v
+	}
+	s.mu.RUnlock()
+	if less != nil {
+		sort.SliceStable(out, func(i, j int) bool { return less(out[i], out[j]) })
+	}
+	return out
+}
+
+// save rewrites the file. The caller holds s.mu.
+func (s *Store[V]) save() error {
+	if s.path == "" {
+		return nil
+	}
+	rows := make([]V, len(s.keys))
+	for i, k := range s.keys {
+		rows[i] = s.rows[k]
+	}
+	return Save(s.path, rows)
+}
@@ -0,0 +1,173 @@
+// Package jupiter provides a client for the Jupiter aggregator quote and swap API.
+package jupiter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const DefaultBaseURL = "https://quote-api.jup.ag/v6"
+
+// Well-known mints used as swap targets.
+const (
+	USDCMint = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+	SOLMint  = "So11111111111111111111111111111111111111112"
+)
+
+// Config holds configuration for the Jupiter client.
+type Config struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// Client is a client for the Jupiter API.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient creates a new Jupiter client.
+func NewClient(config Config) *Client {
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultBaseURL
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{
+			Timeout: 15 * time.Second,
+		}
+	}
+	return &Client{
+		baseURL:    config.BaseURL,
+		httpClient: config.HTTPClient,
+	}
+}
+
+// QuoteRequest represents a request for a swap quote.
+type QuoteRequest struct {
+	InputMint   string
+	OutputMint  string
+	Amount      int64 // In smallest units of InputMint
+	SlippageBps int
+}
+
+// Quote is a swap route quote. It is passed back unchanged to Swap.
+type Quote struct {
+	InputMint            string            `json:"inputMint"`
+	InAmount             string            `json:"inAmount"`
+	OutputMint           string            `json:"outputMint"`
+	OutAmount            string            `json:"outAmount"`
+	OtherAmountThreshold string            `json:"otherAmountThreshold"` // Minimum out after slippage
+	SwapMode             string            `json:"swapMode"`
+	SlippageBps          int               `json:"slippageBps"`
+	PriceImpactPct       string            `json:"priceImpactPct"`
+	RoutePlan            []json.RawMessage `json:"routePlan"`
+	ContextSlot          int64             `json:"contextSlot,omitempty"`
+	TimeTaken            float64           `json:"timeTaken,omitempty"`
+}
+
+// OutAmountInt returns the quoted output amount in smallest units.
+func (q *Quote) OutAmountInt() int64 {
+	n, _ := strconv.ParseInt(q.OutAmount, 10, 64)
+	return n
+}
+
+// MinOutAmountInt returns the minimum output amount after slippage.
+func (q *Quote) MinOutAmountInt() int64 {
+	n, _ := strconv.ParseInt(q.OtherAmountThreshold, 10, 64)
+	return n
+}
+
+// PriceImpact returns the price impact as a fraction (0.01 = 1%).
+func (q *Quote) PriceImpact() float64 {
+	f, _ := strconv.ParseFloat(q.PriceImpactPct, 64)
+	return f
+}
+
+// GetQuote fetches the best route for swapping InputMint to OutputMint.
+func (c *Client) GetQuote(ctx context.Context, req QuoteRequest) (*Quote, error) {
+	q := url.Values{}
+	q.Set("inputMint", req.InputMint)
+	q.Set("outputMint", req.OutputMint)
+	q.Set("amount", strconv.FormatInt(req.Amount, 10))
+	if req.SlippageBps > 0 {
+		q.Set("slippageBps", strconv.Itoa(req.SlippageBps))
+	}
+
+	var resp Quote
+	if err := c.do(ctx, "GET", "/quote?"+q.Encode(), nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get quote: %w", err)
+	}
+	return &resp, nil
+}
+
+// SwapRequest represents a request to build a swap transaction from a quote.
+type SwapRequest struct {
+	QuoteResponse    *Quote `json:"quoteResponse"`
+	UserPublicKey    string `json:"userPublicKey"`
+	WrapAndUnwrapSol bool   `json:"wrapAndUnwrapSol"`
+}
+
+// SwapResponse contains the unsigned swap transaction.
+type SwapResponse struct {
+	SwapTransaction      string `json:"swapTransaction"` // Base64 encoded versioned transaction
+	LastValidBlockHeight int64  `json:"lastValidBlockHeight"`
+}
+
+// Swap builds an unsigned swap transaction for the quote.
+func (c *Client) Swap(ctx context.Context, req SwapRequest) (*SwapResponse, error) {
+	var resp SwapResponse
+	if err := c.do(ctx, "POST", "/swap", req, &resp); err != nil {
+		return nil, fmt.Errorf("failed to build swap: %w", err)
+	}
+	return &resp, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, result interface{}) error {
+	var buf *bytes.Buffer
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		buf = bytes.NewBuffer(jsonData)
+	}
+
+	var req *http.Request
+	var err error
+	if buf != nil {
+		req, err = http.NewRequestWithContext(ctx, method, c.baseURL+path, buf)
+	} else {
+		req, err = http.NewRequestWithContext(ctx, method, c.baseURL+path, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		var errorResp struct {
+			Error string `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&errorResp); err == nil && errorResp.Error != "" {
+			return fmt.Errorf("jupiter API error: %s", errorResp.Error)
+		}
+		return fmt.Errorf("jupiter API error: status %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
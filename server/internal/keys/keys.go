@@ -3,6 +3,7 @@ package keys
 import (
 	"context"
 	"fmt"
+	"net/url"
 )
 
 // Service handles API key operations.
@@ -47,7 +48,7 @@ func (s *Service) Create(ctx context.Context, req GenerateRequest) (*Response, e
 
 // GetByWallet retrieves an existing API key for a wallet.
 func (s *Service) GetByWallet(ctx context.Context, wallet string) (*Response, error) {
-	path := fmt.Sprintf("/shadowpay/v1/keys/by-wallet/%s", wallet)
+	path := fmt.Sprintf("/shadowpay/v1/keys/by-wallet/%s", url.PathEscape(wallet))
 	var resp Response
 	if err := s.doRequest(ctx, "GET", path, nil, &resp); err != nil {
 		return nil, err
@@ -0,0 +1,210 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"sol_privacy/internal/wallet"
+)
+
+// awsSigningAlgorithm signs the message itself (pure EdDSA, RFC 8032), so
+// signatures verify like any Solana wallet's.
+const awsSigningAlgorithm = "ED25519_SHA_512"
+
+// AWSCredentials sign requests to AWS (Signature Version 4).
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // For temporary credentials
+}
+
+// AWSConfig selects an AWS KMS key.
+type AWSConfig struct {
+	Region string // AWS_REGION or AWS_DEFAULT_REGION if empty
+	KeyID  string // Key ID, ARN or alias/name of an ECC_NIST_EDWARDS25519 key
+
+	// Credentials returns the credentials of each request; those in
+	// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN if nil.
+	// Plug in instance or workload role credentials here.
+	Credentials func(ctx context.Context) (AWSCredentials, error)
+
+	Endpoint   string // https://kms.<region>.amazonaws.com if empty
+	HTTPClient *http.Client
+	Timeout    time.Duration // DefaultTimeout if zero
+}
+
+type awsKMS struct {
+	cfg      AWSConfig
+	endpoint *url.URL
+	client   *http.Client
+}
+
+// NewAWSSigner returns a signer for an AWS KMS Ed25519 key, fetching its
+// public key to derive the wallet address.
+func NewAWSSigner(ctx context.Context, cfg AWSConfig) (wallet.Signer, error) {
+	if cfg.Region == "" {
+		cfg.Region = os.Getenv("AWS_REGION")
+	}
+	if cfg.Region == "" {
+		cfg.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if cfg.Region == "" || cfg.KeyID == "" {
+		return nil, errors.New("kms: AWS region and key ID are required")
+	}
+	if cfg.Credentials == nil {
+		cfg.Credentials = awsEnvCredentials
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://kms." + cfg.Region + ".amazonaws.com"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("kms: endpoint: %w", err)
+	}
+	k := &awsKMS{cfg: cfg, endpoint: endpoint, client: cfg.HTTPClient}
+	if k.client == nil {
+		k.client = &http.Client{Timeout: cfg.Timeout}
+	}
+
+	var key struct {
+		PublicKey         []byte   `json:"PublicKey"` // DER, base64 in JSON
+		KeySpec           string   `json:"KeySpec"`
+		SigningAlgorithms []string `json:"SigningAlgorithms"`
+	}
+	if err := k.call(ctx, "GetPublicKey", map[string]string{"KeyId": cfg.KeyID}, &key); err != nil {
+		return nil, err
+	}
+	public, err := parsePublicKey(key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &signer{public: public, timeout: cfg.Timeout, sign: k.sign}, nil
+}
+
+func (k *awsKMS) sign(ctx context.Context, message []byte) ([]byte, error) {
+	var out struct {
+		Signature []byte `json:"Signature"`
+	}
+	err := k.call(ctx, "Sign", map[string]interface{}{
+		"KeyId":            k.cfg.KeyID,
+		"Message":          message,
+		"MessageType":      "RAW",
+		"SigningAlgorithm": awsSigningAlgorithm,
+	}, &out)
+	return out.Signature, err
+}
+
+// call invokes a KMS action with the JSON protocol.
+func (k *awsKMS) call(ctx context.Context, action string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.endpoint.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService."+action)
+	creds, err := k.cfg.Credentials(ctx)
+	if err != nil {
+		return fmt.Errorf("kms: credentials: %w", err)
+	}
+	signV4(req, body, creds, k.cfg.Region, "kms", time.Now())
+
+	data, err := do(k.client, req, action)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+func awsEnvCredentials(context.Context) (AWSCredentials, error) {
+	creds := AWSCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return creds, errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are not set")
+	}
+	return creds, nil
+}
+
+// signV4 adds an AWS Signature Version 4 Authorization header to req.
+func signV4(req *http.Request, body []byte, creds AWSCredentials, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+creds.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
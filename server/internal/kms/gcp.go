@@ -0,0 +1,155 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/wallet"
+)
+
+// metadataTokenURL issues access tokens for the service account of the GCE,
+// GKE or Cloud Run workload.
+const metadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// GCPConfig selects a Google Cloud KMS key version.
+type GCPConfig struct {
+	// KeyVersion is the resource name of an EC_SIGN_ED25519 key version:
+	// projects/p/locations/l/keyRings/r/cryptoKeys/k/cryptoKeyVersions/v
+	KeyVersion string
+
+	// Token returns an OAuth access token for each request. If nil it is
+	// GOOGLE_OAUTH_ACCESS_TOKEN if set, else the workload's service account
+	// token from the metadata server.
+	Token func(ctx context.Context) (string, error)
+
+	Endpoint   string // https://cloudkms.googleapis.com if empty
+	HTTPClient *http.Client
+	Timeout    time.Duration // DefaultTimeout if zero
+}
+
+type gcpKMS struct {
+	cfg    GCPConfig
+	client *http.Client
+}
+
+// NewGCPSigner returns a signer for a Cloud KMS Ed25519 key version,
+// fetching its public key to derive the wallet address.
+func NewGCPSigner(ctx context.Context, cfg GCPConfig) (wallet.Signer, error) {
+	if !strings.HasPrefix(cfg.KeyVersion, "projects/") || !strings.Contains(cfg.KeyVersion, "/cryptoKeyVersions/") {
+		return nil, errors.New("kms: GCP key version must be projects/.../cryptoKeyVersions/<v>")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://cloudkms.googleapis.com"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	k := &gcpKMS{cfg: cfg, client: cfg.HTTPClient}
+	if k.client == nil {
+		k.client = &http.Client{Timeout: cfg.Timeout}
+	}
+	if k.cfg.Token == nil {
+		if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+			k.cfg.Token = func(context.Context) (string, error) { return token, nil }
+		} else {
+			k.cfg.Token = (&metadataToken{client: k.client}).get
+		}
+	}
+
+	var key struct {
+		PEM       string `json:"pem"`
+		Algorithm string `json:"algorithm"`
+	}
+	if err := k.call(ctx, http.MethodGet, "/publicKey", nil, &key); err != nil {
+		return nil, err
+	}
+	if key.Algorithm != "EC_SIGN_ED25519" {
+		return nil, ErrNotEd25519
+	}
+	public, err := parsePublicKey([]byte(key.PEM))
+	if err != nil {
+		return nil, err
+	}
+	return &signer{public: public, timeout: cfg.Timeout, sign: k.sign}, nil
+}
+
+func (k *gcpKMS) sign(ctx context.Context, message []byte) ([]byte, error) {
+	var out struct {
+		Signature []byte `json:"signature"`
+	}
+	// Ed25519 keys sign the data itself rather than a digest
+	err := k.call(ctx, http.MethodPost, ":asymmetricSign", map[string][]byte{"data": message}, &out)
+	return out.Signature, err
+}
+
+// call invokes a method of the key version.
+func (k *gcpKMS) call(ctx context.Context, method, suffix string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, k.cfg.Endpoint+"/v1/"+k.cfg.KeyVersion+suffix, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	token, err := k.cfg.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("kms: access token: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	data, err := do(k.client, req, strings.TrimPrefix(suffix, "/"))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// metadataToken caches the metadata server's access token until shortly
+// before it expires.
+type metadataToken struct {
+	client *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func (m *metadataToken) get(ctx context.Context) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.token != "" && time.Until(m.expires) > time.Minute {
+		return m.token, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataTokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	data, err := do(m.client, req, "metadata token")
+	if err != nil {
+		return "", err
+	}
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(data, &tok); err != nil {
+		return "", err
+	}
+	m.token = tok.AccessToken
+	m.expires = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	return m.token, nil
+}
@@ -0,0 +1,115 @@
+// Package kms provides wallet signers whose Ed25519 keys never leave a cloud
+// key management service: AWS KMS (ECC_NIST_EDWARDS25519 keys) and Google
+// Cloud KMS (EC_SIGN_ED25519 keys). They implement wallet.Signer, so treasury
+// keys kept in KMS can sign the withdrawal and payout transactions the SDK
+// builds:
+//
+//	signer, err := kms.Open(ctx, "awskms://us-east-1/alias/treasury")
+//	signed, err := wallet.SignTransaction(signer, tx.UnsignedTxBase64)
+package kms
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"sol_privacy/internal/base58"
+	"sol_privacy/internal/wallet"
+)
+
+// DefaultTimeout bounds each call to the KMS.
+const DefaultTimeout = 30 * time.Second
+
+// ErrNotEd25519 is returned for KMS keys that are not Ed25519 signing keys.
+var ErrNotEd25519 = errors.New("kms: key is not an Ed25519 signing key")
+
+// Open returns the signer for a key URI:
+//
+//	awskms://<region>/<key id, ARN or alias/name>
+//	gcpkms://projects/<p>/locations/<l>/keyRings/<r>/cryptoKeys/<k>/cryptoKeyVersions/<v>
+//
+// Credentials come from the environment as described by NewAWSSigner and
+// NewGCPSigner.
+func Open(ctx context.Context, uri string) (wallet.Signer, error) {
+	switch {
+	case strings.HasPrefix(uri, "awskms://"):
+		region, keyID, ok := strings.Cut(strings.TrimPrefix(uri, "awskms://"), "/")
+		if !ok || keyID == "" {
+			return nil, fmt.Errorf("kms: %q: expected awskms://<region>/<key id>", uri)
+		}
+		return NewAWSSigner(ctx, AWSConfig{Region: region, KeyID: keyID})
+	case strings.HasPrefix(uri, "gcpkms://"):
+		return NewGCPSigner(ctx, GCPConfig{KeyVersion: strings.TrimPrefix(uri, "gcpkms://")})
+	}
+	return nil, fmt.Errorf("kms: %q: unsupported key URI", uri)
+}
+
+// parsePublicKey decodes a DER (or PEM) SubjectPublicKeyInfo holding an
+// Ed25519 key.
+func parsePublicKey(der []byte) (ed25519.PublicKey, error) {
+	if block, _ := pem.Decode(der); block != nil {
+		der = block.Bytes
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		if strings.Contains(err.Error(), "unknown public key algorithm") {
+			return nil, ErrNotEd25519
+		}
+		return nil, fmt.Errorf("kms: public key: %w", err)
+	}
+	key, ok := pub.(ed25519.PublicKey)
+	if !ok {
+		return nil, ErrNotEd25519
+	}
+	return key, nil
+}
+
+// signer holds what both backends share: the wallet address and a check that
+// every signature the KMS returns verifies against it.
+type signer struct {
+	public  ed25519.PublicKey
+	timeout time.Duration
+	sign    func(ctx context.Context, message []byte) ([]byte, error)
+}
+
+func (s *signer) Address() string {
+	return base58.Encode(s.public)
+}
+
+func (s *signer) SignMessage(message []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	sig, err := s.sign(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+	if len(sig) != ed25519.SignatureSize || !ed25519.Verify(s.public, message, sig) {
+		return nil, errors.New("kms: signature does not verify against the key's public key")
+	}
+	return sig, nil
+}
+
+// do sends req and returns the body of a 2xx response, or an error carrying
+// the service's message.
+func do(client *http.Client, req *http.Request, service string) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("kms: %s: %w", service, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("kms: %s: %w", service, err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("kms: %s: status %d: %s", service, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
@@ -0,0 +1,337 @@
+// Package ledger keeps a local record of the fees a wallet pays (pool and
+// merchant withdrawal fees, relayer fees and network fees) so they can be
+// summarized per month and exported for cost accounting.
+package ledger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/jsonfile"
+	"sol_privacy/internal/solana"
+	"sol_privacy/internal/validate"
+)
+
+// Kind is the kind of fee an entry records.
+type Kind string
+
+// Fee kinds.
+const (
+	KindPoolWithdrawal     Kind = "pool_withdrawal"
+	KindMerchantWithdrawal Kind = "merchant_withdrawal"
+	KindRelayer            Kind = "relayer"
+	KindNetwork            Kind = "network"
+)
+
+// Kinds lists every fee kind.
+var Kinds = []Kind{KindPoolWithdrawal, KindMerchantWithdrawal, KindRelayer, KindNetwork}
+
+// Export formats.
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// MaxNoteLength limits the free-text note on an entry.
+const MaxNoteLength = 256
+
+var (
+	// ErrTransactionNotFound is returned when a network fee is recorded for a
+	// signature the cluster does not know (yet).
+	ErrTransactionNotFound = errors.New("transaction not found")
+	// ErrDuplicate is returned when a fee with the same kind and reference is already recorded.
+	ErrDuplicate = errors.New("fee already recorded")
+)
+
+// Entry is a single recorded fee.
+type Entry struct {
+	ID        string `json:"id"`
+	Wallet    string `json:"wallet"`
+	Kind      Kind   `json:"kind"`
+	Amount    int64  `json:"amount"`               // Smallest units of the token
+	TokenMint string `json:"token_mint,omitempty"` // Empty for SOL
+	Reference string `json:"reference,omitempty"`  // Transaction signature or withdrawal ID
+	Note      string `json:"note,omitempty"`
+	At        int64  `json:"at"`
+}
+
+// RecordRequest records a fee paid by a wallet.
+type RecordRequest struct {
+	Wallet    string `json:"wallet"`
+	Kind      Kind   `json:"kind"`
+	Amount    int64  `json:"amount"`
+	TokenMint string `json:"token_mint,omitempty"`
+	Reference string `json:"reference,omitempty"`
+	Note      string `json:"note,omitempty"`
+	At        int64  `json:"at,omitempty"` // Unix seconds; defaults to now
+}
+
+// Validate checks the request fields.
+func (r RecordRequest) Validate() error {
+	v := validate.New().
+		Address("wallet", r.Wallet).
+		Add("kind", checkKind(r.Kind)).
+		Amount("amount", r.Amount, 0, validate.MaxLamports).
+		OptionalAddress("token_mint", r.TokenMint)
+	if len(r.Reference) > validate.MaxFieldLength {
+		v.Add("reference", fmt.Errorf("must be at most %d characters", validate.MaxFieldLength))
+	}
+	if len(r.Note) > MaxNoteLength {
+		v.Add("note", fmt.Errorf("must be at most %d characters", MaxNoteLength))
+	}
+	if r.At < 0 {
+		v.Add("at", fmt.Errorf("must be a unix timestamp"))
+	}
+	return v.Err()
+}
+
+func checkKind(k Kind) error {
+	for _, known := range Kinds {
+		if k == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("must be one of %v", Kinds)
+}
+
+// Filter selects entries. Zero fields match everything; From and To are
+// inclusive unix seconds.
+type Filter struct {
+	Wallet string
+	Kind   Kind
+	From   int64
+	To     int64
+}
+
+func (f Filter) match(e *Entry) bool {
+	return (f.Wallet == "" || e.Wallet == f.Wallet) &&
+		(f.Kind == "" || e.Kind == f.Kind) &&
+		(f.From == 0 || e.At >= f.From) &&
+		(f.To == 0 || e.At <= f.To)
+}
+
+// MonthlySummary totals the fees paid in a calendar month (UTC).
+type MonthlySummary struct {
+	Month   string           `json:"month"` // YYYY-MM
+	Entries int              `json:"entries"`
+	Total   int64            `json:"total"`            // Lamports, SOL fees only
+	ByKind  map[Kind]int64   `json:"by_kind"`          // Lamports, SOL fees only
+	Tokens  map[string]int64 `json:"tokens,omitempty"` // SPL fees by mint
+}
+
+// Store persists ledger entries.
+type Store interface {
+	Add(e *Entry) error
+	List() ([]*Entry, error) // Oldest first
+}
+
+// NewMemoryStore creates an empty in-memory Store.
+func NewMemoryStore() Store {
+	return store{jsonfile.NewMemoryStore(entryID, nil)}
+}
+
+// NewFileStore opens a Store persisted to a JSON file after every write,
+// creating it on first write.
+func NewFileStore(path string) (Store, error) {
+	entries, err := jsonfile.NewStore(path, entryID, nil)
+	if err != nil {
+		return nil, err
+	}
+	return store{entries}, nil
+}
+
+func entryID(e *Entry) string { return e.ID }
+
+// store keeps entries in a jsonfile.Store.
+type store struct {
+	entries *jsonfile.Store[Entry]
+}
+
+func (s store) Add(e *Entry) error {
+	return s.entries.Put(e)
+}
+
+// List returns all entries, oldest first.
+func (s store) List() ([]*Entry, error) {
+	return s.entries.List(func(a, b *Entry) bool { return a.At < b.At }), nil
+}
+
+// TransactionSource looks up confirmed transactions. *solana.Client implements it.
+type TransactionSource interface {
+	GetTransactionMeta(ctx context.Context, signature string) (*solana.TransactionMeta, error)
+}
+
+// Service records and reports fees.
+type Service struct {
+	store Store
+	txs   TransactionSource
+	mu    sync.Mutex // Serializes duplicate checks with writes
+}
+
+// NewService creates a ledger. A nil store selects an in-memory store; txs
+// is only needed by RecordNetworkFee.
+func NewService(store Store, txs TransactionSource) *Service {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	return &Service{store: store, txs: txs}
+}
+
+// Record adds a fee entry. A fee with the same kind and non-empty reference is
+// only recorded once.
+func (s *Service) Record(ctx context.Context, req RecordRequest) (*Entry, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if req.At == 0 {
+		req.At = time.Now().Unix()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if req.Reference != "" {
+		all, err := s.store.List()
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range all {
+			if e.Kind == req.Kind && e.Reference == req.Reference {
+				return nil, ErrDuplicate
+			}
+		}
+	}
+
+	e := &Entry{
+		ID:        newID(),
+		Wallet:    req.Wallet,
+		Kind:      req.Kind,
+		Amount:    req.Amount,
+		TokenMint: req.TokenMint,
+		Reference: req.Reference,
+		Note:      req.Note,
+		At:        req.At,
+	}
+	if err := s.store.Add(e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// RecordNetworkFee looks up the network fee of a confirmed transaction and
+// records it against the wallet that paid it.
+func (s *Service) RecordNetworkFee(ctx context.Context, wallet, signature string) (*Entry, error) {
+	v := validate.New().Address("wallet", wallet).Required("signature", signature)
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+	if s.txs == nil {
+		return nil, fmt.Errorf("no Solana RPC configured")
+	}
+	meta, err := s.txs.GetTransactionMeta(ctx, signature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transaction: %w", err)
+	}
+	if meta == nil {
+		return nil, ErrTransactionNotFound
+	}
+	return s.Record(ctx, RecordRequest{
+		Wallet:    wallet,
+		Kind:      KindNetwork,
+		Amount:    int64(meta.Fee),
+		Reference: signature,
+	})
+}
+
+// List returns the entries matching f, oldest first.
+func (s *Service) List(ctx context.Context, f Filter) ([]*Entry, error) {
+	all, err := s.store.List()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*Entry, 0, len(all))
+	for _, e := range all {
+		if f.match(e) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// Summary totals the entries matching f per calendar month, oldest month first.
+func (s *Service) Summary(ctx context.Context, f Filter) ([]MonthlySummary, error) {
+	entries, err := s.List(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	var out []MonthlySummary
+	index := make(map[string]int)
+	for _, e := range entries {
+		month := time.Unix(e.At, 0).UTC().Format("2006-01")
+		i, ok := index[month]
+		if !ok {
+			i = len(out)
+			index[month] = i
+			out = append(out, MonthlySummary{Month: month, ByKind: make(map[Kind]int64)})
+		}
+		m := &out[i]
+		m.Entries++
+		if e.TokenMint != "" {
+			if m.Tokens == nil {
+				m.Tokens = make(map[string]int64)
+			}
+			m.Tokens[e.TokenMint] += e.Amount
+			continue
+		}
+		m.Total += e.Amount
+		m.ByKind[e.Kind] += e.Amount
+	}
+	return out, nil
+}
+
+// Export writes the entries matching f to w as CSV or JSON.
+func (s *Service) Export(ctx context.Context, w io.Writer, format string, f Filter) error {
+	entries, err := s.List(ctx, f)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	case FormatCSV, "":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"id", "date", "wallet", "kind", "amount", "token_mint", "reference", "note"})
+		for _, e := range entries {
+			cw.Write([]string{
+				e.ID,
+				time.Unix(e.At, 0).UTC().Format(time.RFC3339),
+				e.Wallet,
+				string(e.Kind),
+				strconv.FormatInt(e.Amount, 10),
+				e.TokenMint,
+				e.Reference,
+				e.Note,
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return validate.Errors{{Field: "format", Message: "must be csv or json"}}
+	}
+}
+
+func newID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "fee_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,194 @@
+// Package mcp serves tools over the Model Context Protocol: JSON-RPC 2.0
+// messages, one per line, on a reader and writer such as stdin and stdout.
+// It implements the subset tool servers need: initialize, ping, tools/list
+// and tools/call.
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+)
+
+// ProtocolVersion is the MCP revision this server speaks.
+const ProtocolVersion = "2024-11-05"
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// maxMessageSize caps a single JSON-RPC message.
+const maxMessageSize = 4 << 20
+
+// Tool is a function the model can call.
+type Tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"inputSchema"` // JSON Schema of the arguments
+
+	// Handler runs the tool. Its result is returned to the model as JSON; an
+	// error is returned as a tool error the model can read and react to.
+	Handler func(ctx context.Context, args json.RawMessage) (any, error) `json:"-"`
+}
+
+// Server dispatches MCP requests to its tools.
+type Server struct {
+	name    string
+	version string
+	tools   []*Tool
+	byName  map[string]*Tool
+}
+
+// NewServer creates a server that identifies itself by name and version.
+func NewServer(name, version string) *Server {
+	return &Server{name: name, version: version, byName: make(map[string]*Tool)}
+}
+
+// AddTool registers a tool. Tools are listed in the order they are added.
+func (s *Server) AddTool(t *Tool) {
+	s.tools = append(s.tools, t)
+	s.byName[t.Name] = t
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"` // Absent for notifications
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// content is an MCP content block.
+type content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type callResult struct {
+	Content []content `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+// Serve reads requests from r and writes responses to w until r ends or ctx
+// is done. Tool calls run concurrently and are answered as they finish;
+// other requests are answered in order.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	var (
+		wmu sync.Mutex
+		wg  sync.WaitGroup
+	)
+	enc := json.NewEncoder(w)
+	write := func(resp *response) {
+		wmu.Lock()
+		defer wmu.Unlock()
+		if err := enc.Encode(resp); err != nil {
+			log.Printf("mcp: failed to write response: %v", err)
+		}
+	}
+	defer wg.Wait()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var req request
+		if err := json.Unmarshal(line, &req); err != nil {
+			write(&response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{codeParseError, "Parse error"}})
+			continue
+		}
+		if req.JSONRPC != "2.0" || req.Method == "" {
+			if req.ID != nil {
+				write(&response{JSONRPC: "2.0", ID: req.ID, Error: &rpcError{codeInvalidRequest, "Invalid request"}})
+			}
+			continue
+		}
+		if req.ID == nil {
+			// Notifications, e.g. notifications/initialized, need no answer
+			continue
+		}
+
+		if req.Method != "tools/call" {
+			result, rerr := s.handle(ctx, &req)
+			write(&response{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rerr})
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, rerr := s.handle(ctx, &req)
+			write(&response{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rerr})
+		}()
+	}
+	return scanner.Err()
+}
+
+func (s *Server) handle(ctx context.Context, req *request) (any, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		return map[string]any{
+			"protocolVersion": ProtocolVersion,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]string{"name": s.name, "version": s.version},
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		return map[string]any{"tools": s.tools}, nil
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{codeInvalidParams, "Invalid params"}
+		}
+		tool, ok := s.byName[params.Name]
+		if !ok {
+			return nil, &rpcError{codeInvalidParams, fmt.Sprintf("Unknown tool %q", params.Name)}
+		}
+		if len(params.Arguments) == 0 {
+			params.Arguments = json.RawMessage("{}")
+		}
+		return call(ctx, tool, params.Arguments), nil
+	default:
+		return nil, &rpcError{codeMethodNotFound, fmt.Sprintf("Method %q not found", req.Method)}
+	}
+}
+
+// call runs a tool, reporting its error or result as text content.
+func call(ctx context.Context, tool *Tool, args json.RawMessage) *callResult {
+	out, err := tool.Handler(ctx, args)
+	if err != nil {
+		return &callResult{Content: []content{{Type: "text", Text: err.Error()}}, IsError: true}
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return &callResult{Content: []content{{Type: "text", Text: err.Error()}}, IsError: true}
+	}
+	return &callResult{Content: []content{{Type: "text", Text: string(data)}}}
+}
@@ -0,0 +1,141 @@
+package mcp
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/x402"
+)
+
+// Policy defaults, in lamports, used when the environment does not set them.
+const (
+	DefaultMaxAmount  = 100_000_000   // 0.1 SOL
+	DefaultDailyLimit = 1_000_000_000 // 1 SOL
+	DefaultMaxAuthTTL = 7 * 24 * time.Hour
+)
+
+// Policy is the spending guardrail applied to every tool call before it
+// reaches the API. Limits of zero are not enforced.
+type Policy struct {
+	MaxAmount         int64         // Lamports per payment
+	DailyLimit        int64         // Lamports settled per rolling 24 hours
+	AllowedRecipients []string      // Receiver commitments and payTo wallets; empty allows any
+	MaxAuthTTL        time.Duration // Longest spending authorization
+
+	mu     sync.Mutex
+	spent  []spend
+	nextID uint64
+}
+
+type spend struct {
+	id     uint64
+	at     time.Time
+	amount int64
+}
+
+// PolicyFromEnv reads the policy from MCP_MAX_AMOUNT and MCP_DAILY_LIMIT (in
+// SOL, "0" for no limit), MCP_ALLOWED_RECIPIENTS (comma-separated) and
+// MCP_MAX_AUTH_TTL (a duration).
+func PolicyFromEnv() (*Policy, error) {
+	p := &Policy{MaxAmount: DefaultMaxAmount, DailyLimit: DefaultDailyLimit, MaxAuthTTL: DefaultMaxAuthTTL}
+	var err error
+	if s := os.Getenv("MCP_MAX_AMOUNT"); s != "" {
+		if p.MaxAmount, err = x402.ParseSOL(s); err != nil {
+			return nil, fmt.Errorf("MCP_MAX_AMOUNT: %w", err)
+		}
+	}
+	if s := os.Getenv("MCP_DAILY_LIMIT"); s != "" {
+		if p.DailyLimit, err = x402.ParseSOL(s); err != nil {
+			return nil, fmt.Errorf("MCP_DAILY_LIMIT: %w", err)
+		}
+	}
+	if s := os.Getenv("MCP_MAX_AUTH_TTL"); s != "" {
+		if p.MaxAuthTTL, err = time.ParseDuration(s); err != nil {
+			return nil, fmt.Errorf("MCP_MAX_AUTH_TTL: %w", err)
+		}
+	}
+	for _, r := range strings.Split(os.Getenv("MCP_ALLOWED_RECIPIENTS"), ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			p.AllowedRecipients = append(p.AllowedRecipients, r)
+		}
+	}
+	return p, nil
+}
+
+// CheckPayment rejects a payment over the per-payment limit or to a
+// recipient outside the allowlist.
+func (p *Policy) CheckPayment(recipient string, amount int64) error {
+	if amount <= 0 {
+		return fmt.Errorf("policy: amount must be positive")
+	}
+	if p.MaxAmount > 0 && amount > p.MaxAmount {
+		return fmt.Errorf("policy: %s SOL exceeds the per-payment limit of %s SOL", x402.FormatSOL(amount), x402.FormatSOL(p.MaxAmount))
+	}
+	if len(p.AllowedRecipients) > 0 && !slices.Contains(p.AllowedRecipients, recipient) {
+		return fmt.Errorf("policy: recipient %s is not in the allowlist", recipient)
+	}
+	return nil
+}
+
+// Spend checks a payment against the policy and the daily limit and, if it
+// passes, records it against the limit. Call refund if the payment fails.
+func (p *Policy) Spend(recipient string, amount int64) (refund func(), err error) {
+	if err := p.CheckPayment(recipient, amount); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	spent := p.spentLocked(time.Now())
+	if p.DailyLimit > 0 && spent+amount > p.DailyLimit {
+		return nil, fmt.Errorf("policy: %s SOL would exceed the daily limit of %s SOL (%s SOL spent)",
+			x402.FormatSOL(amount), x402.FormatSOL(p.DailyLimit), x402.FormatSOL(spent))
+	}
+	p.nextID++
+	id := p.nextID
+	p.spent = append(p.spent, spend{id: id, at: time.Now(), amount: amount})
+	return func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.spent = slices.DeleteFunc(p.spent, func(s spend) bool { return s.id == id })
+	}, nil
+}
+
+// Spent returns the lamports spent in the last 24 hours.
+func (p *Policy) Spent() int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.spentLocked(time.Now())
+}
+
+func (p *Policy) spentLocked(now time.Time) int64 {
+	cutoff := now.Add(-24 * time.Hour)
+	drop := 0
+	for drop < len(p.spent) && p.spent[drop].at.Before(cutoff) {
+		drop++
+	}
+	p.spent = p.spent[drop:]
+	var total int64
+	for _, s := range p.spent {
+		total += s.amount
+	}
+	return total
+}
+
+// CheckAuthorization rejects a spending authorization whose limits exceed
+// the policy's, or that lasts longer than MaxAuthTTL.
+func (p *Policy) CheckAuthorization(perTx, daily int64, validUntil time.Time) error {
+	if p.MaxAmount > 0 && perTx > p.MaxAmount {
+		return fmt.Errorf("policy: per-transaction limit of %s SOL exceeds the policy's %s SOL", x402.FormatSOL(perTx), x402.FormatSOL(p.MaxAmount))
+	}
+	if p.DailyLimit > 0 && daily > p.DailyLimit {
+		return fmt.Errorf("policy: daily limit of %s SOL exceeds the policy's %s SOL", x402.FormatSOL(daily), x402.FormatSOL(p.DailyLimit))
+	}
+	if p.MaxAuthTTL > 0 && time.Until(validUntil) > p.MaxAuthTTL {
+		return fmt.Errorf("policy: authorizations may last at most %s", p.MaxAuthTTL)
+	}
+	return nil
+}
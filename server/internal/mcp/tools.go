@@ -0,0 +1,182 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	shadowpay "sol_privacy"
+	"sol_privacy/internal/authorization"
+	"sol_privacy/internal/payment"
+	"sol_privacy/internal/types"
+	"sol_privacy/internal/x402"
+)
+
+// NewPaymentServer creates an MCP server exposing ShadowPay payment
+// operations as tools. Every tool that moves or commits funds is checked
+// against policy before it reaches the API.
+func NewPaymentServer(sdk *shadowpay.ShadowPay, policy *Policy, version string) *Server {
+	s := NewServer("shadowpay", version)
+
+	s.AddTool(&Tool{
+		Name:        "pool_balance",
+		Description: "Get the privacy pool balance of a Solana wallet, in lamports.",
+		InputSchema: json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "wallet": {"type": "string", "description": "Base58 wallet address"}
+  },
+  "required": ["wallet"]
+}`),
+		Handler: func(ctx context.Context, args json.RawMessage) (any, error) {
+			var in struct {
+				Wallet string `json:"wallet"`
+			}
+			if err := decode(args, &in); err != nil {
+				return nil, err
+			}
+			return sdk.Pool.GetBalance(ctx, in.Wallet)
+		},
+	})
+
+	s.AddTool(&Tool{
+		Name:        "authorize_spending",
+		Description: "Authorize a service to spend from a wallet up to per-transaction and daily limits until a deadline. The limits and duration must fit the server's spending policy.",
+		InputSchema: json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "user_wallet": {"type": "string", "description": "Base58 wallet address granting the authorization"},
+    "authorized_service": {"type": "string", "description": "Service allowed to spend"},
+    "max_amount_per_tx": {"type": "string", "description": "Per-transaction limit in SOL, e.g. \"0.01\""},
+    "max_daily_spend": {"type": "string", "description": "Daily limit in SOL, e.g. \"0.5\""},
+    "valid_until": {"type": "integer", "description": "Unix timestamp the authorization expires at"},
+    "user_signature": {"type": "string", "description": "Base58 wallet signature of the authorization"}
+  },
+  "required": ["user_wallet", "authorized_service", "max_amount_per_tx", "max_daily_spend", "valid_until", "user_signature"]
+}`),
+		Handler: func(ctx context.Context, args json.RawMessage) (any, error) {
+			var in authorization.AuthorizeSpendingRequest
+			if err := decode(args, &in); err != nil {
+				return nil, err
+			}
+			perTx, err := x402.ParseSOL(in.MaxAmountPerTx)
+			if err != nil {
+				return nil, fmt.Errorf("max_amount_per_tx: %w", err)
+			}
+			daily, err := x402.ParseSOL(in.MaxDailySpend)
+			if err != nil {
+				return nil, fmt.Errorf("max_daily_spend: %w", err)
+			}
+			if err := policy.CheckAuthorization(perTx, daily, time.Unix(in.ValidUntil, 0)); err != nil {
+				return nil, err
+			}
+			return sdk.Authorization.AuthorizeSpending(ctx, in)
+		},
+	})
+
+	s.AddTool(&Tool{
+		Name:        "prepare_payment",
+		Description: "Prepare a private payment to a receiver commitment. Returns the payment hash and commitment to settle. The amount must fit the server's spending policy.",
+		InputSchema: json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "receiver_commitment": {"type": "string", "description": "Receiver commitment, base58 or hex"},
+    "amount": {"type": "integer", "minimum": 1, "description": "Amount in lamports"},
+    "token_mint": {"type": "string", "description": "SPL token mint, omitted for SOL"}
+  },
+  "required": ["receiver_commitment", "amount"]
+}`),
+		Handler: func(ctx context.Context, args json.RawMessage) (any, error) {
+			var in payment.PrepareRequest
+			if err := decode(args, &in); err != nil {
+				return nil, err
+			}
+			if err := policy.CheckPayment(string(in.ReceiverCommitment), in.Amount); err != nil {
+				return nil, err
+			}
+			return sdk.Payment.Prepare(ctx, in)
+		},
+	})
+
+	s.AddTool(&Tool{
+		Name:        "settle_payment",
+		Description: "Settle an x402 payment on-chain. Settled amounts count toward the server's daily spending limit.",
+		InputSchema: json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "x402Version": {"type": "integer", "description": "x402 protocol version, defaults to 1"},
+    "paymentHeader": {"type": "string", "description": "Base64 encoded payment payload"},
+    "resource": {"type": "string", "description": "URL of the paid resource"},
+    "paymentRequirements": {
+      "type": "object",
+      "description": "The payment option from the resource's 402 response",
+      "properties": {
+        "scheme": {"type": "string"},
+        "network": {"type": "string"},
+        "maxAmountRequired": {"type": "string", "description": "Price in SOL"},
+        "resource": {"type": "string"},
+        "description": {"type": "string"},
+        "mimeType": {"type": "string"},
+        "payTo": {"type": "string"},
+        "maxTimeoutSeconds": {"type": "integer"},
+        "extra": {"type": "object"}
+      },
+      "required": ["scheme", "network", "maxAmountRequired", "payTo"]
+    }
+  },
+  "required": ["paymentHeader", "resource", "paymentRequirements"]
+}`),
+		Handler: func(ctx context.Context, args json.RawMessage) (any, error) {
+			var in payment.SettleRequest
+			if err := decode(args, &in); err != nil {
+				return nil, err
+			}
+			if in.X402Version == 0 {
+				in.X402Version = types.DefaultX402Version
+			}
+			amount, err := x402.ParseSOL(in.PaymentRequirements.MaxAmountRequired)
+			if err != nil {
+				return nil, fmt.Errorf("maxAmountRequired: %w", err)
+			}
+			refund, err := policy.Spend(in.PaymentRequirements.PayTo, amount)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := sdk.Payment.Settle(ctx, in)
+			if err != nil || !resp.Success {
+				refund()
+			}
+			return resp, err
+		},
+	})
+
+	s.AddTool(&Tool{
+		Name:        "spending_policy",
+		Description: "Show the server's spending limits and how much was settled in the last 24 hours.",
+		InputSchema: json.RawMessage(`{"type": "object", "properties": {}}`),
+		Handler: func(ctx context.Context, args json.RawMessage) (any, error) {
+			return map[string]any{
+				"max_amount":         policy.MaxAmount,
+				"daily_limit":        policy.DailyLimit,
+				"spent_last_24h":     policy.Spent(),
+				"allowed_recipients": policy.AllowedRecipients,
+				"max_auth_ttl":       policy.MaxAuthTTL.String(),
+			}, nil
+		},
+	})
+
+	return s
+}
+
+// decode parses tool arguments, rejecting unknown fields so a misspelled
+// argument fails instead of being silently dropped.
+func decode(args json.RawMessage, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(args))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
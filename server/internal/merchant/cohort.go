@@ -0,0 +1,295 @@
+package merchant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"sol_privacy/internal/receipt"
+	"sol_privacy/internal/validate"
+)
+
+// Cohort is the customers whose first payment fell in Month, and the share of
+// them paying again in each following month.
+type Cohort struct {
+	Month     string    `json:"month"` // YYYY-MM
+	Customers int       `json:"customers"`
+	Retention []float64 `json:"retention"` // Retention[k] is the active share k months after Month; Retention[0] is 1
+}
+
+// TokenVolumeSeries is the payment volume of one token over time.
+type TokenVolumeSeries struct {
+	TokenMint string             `json:"token_mint"`
+	Points    []TokenVolumePoint `json:"points"`
+}
+
+// TokenVolumePoint is the volume of a token in one interval.
+type TokenVolumePoint struct {
+	Timestamp    string `json:"timestamp"`
+	PaymentCount int    `json:"payment_count"`
+	TotalAmount  int64  `json:"total_amount"`
+}
+
+// PercentileStat is a payment size percentile.
+type PercentileStat struct {
+	Percentile float64 `json:"percentile"`
+	Amount     int64   `json:"amount"`
+}
+
+// PaymentRecord is a settled payment known locally, used to compute analytics
+// the upstream does not provide. Customer is any stable opaque key, such as a
+// ShadowID commitment.
+type PaymentRecord struct {
+	Customer  string
+	TokenMint string // Empty for SOL
+	Amount    int64
+	Timestamp int64
+}
+
+// RecordSource supplies locally known payment records.
+type RecordSource interface {
+	PaymentRecords(ctx context.Context) ([]PaymentRecord, error)
+}
+
+// RecordsFromReceipts converts a customer's signed receipts into payment records.
+func RecordsFromReceipts(customer string, receipts []receipt.Receipt) []PaymentRecord {
+	out := make([]PaymentRecord, len(receipts))
+	for i, r := range receipts {
+		out[i] = PaymentRecord{Customer: customer, Amount: r.Body.AmountLamports, Timestamp: r.Body.Timestamp}
+	}
+	return out
+}
+
+// Validate checks the date range and extended analytics options.
+func (r AnalyticsRequest) Validate() error {
+	v := validate.New()
+	if _, _, err := parseDate(r.StartDate); err != nil {
+		v.Add("start_date", err)
+	}
+	if _, _, err := parseDate(r.EndDate); err != nil {
+		v.Add("end_date", err)
+	}
+	for i, p := range r.Percentiles {
+		if p <= 0 || p > 100 {
+			v.Add(fmt.Sprintf("percentiles[%d]", i), errors.New("must be greater than 0 and at most 100"))
+		}
+	}
+	return v.Err()
+}
+
+// SetRecordSource configures the records used to compute extended analytics
+// locally when the upstream does not return them.
+func (s *Service) SetRecordSource(src RecordSource) {
+	s.recordsMu.Lock()
+	defer s.recordsMu.Unlock()
+	s.records = src
+}
+
+func (s *Service) recordSource() RecordSource {
+	s.recordsMu.Lock()
+	defer s.recordsMu.Unlock()
+	return s.records
+}
+
+// completeAnalytics fills requested extended fields missing from the upstream response.
+func (s *Service) completeAnalytics(ctx context.Context, req AnalyticsRequest, resp *AnalyticsResponse) error {
+	needCohorts := req.Cohorts && resp.Cohorts == nil
+	needTokens := req.TokenSeries && resp.TokenVolumes == nil
+	needSizes := len(req.Percentiles) > 0 && resp.PaymentSizes == nil
+	src := s.recordSource()
+	if src == nil || !(needCohorts || needTokens || needSizes) {
+		return nil
+	}
+
+	records, err := src.PaymentRecords(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load payment records: %w", err)
+	}
+	local := ComputeAnalytics(records, req)
+	if needCohorts {
+		resp.Cohorts = local.Cohorts
+	}
+	if needTokens {
+		resp.TokenVolumes = local.TokenVolumes
+	}
+	if needSizes {
+		resp.PaymentSizes = local.PaymentSizes
+	}
+	resp.ComputedBy = "proxy"
+	return nil
+}
+
+// ComputeAnalytics computes the requested extended analytics from payment records.
+// Cohorts consider each customer's full history; series and percentiles only the date range.
+func ComputeAnalytics(records []PaymentRecord, req AnalyticsRequest) *AnalyticsResponse {
+	start, end := req.dateRange()
+	inRange := make([]PaymentRecord, 0, len(records))
+	for _, r := range records {
+		t := time.Unix(r.Timestamp, 0).UTC()
+		if (start.IsZero() || !t.Before(start)) && (end.IsZero() || t.Before(end)) {
+			inRange = append(inRange, r)
+		}
+	}
+
+	resp := &AnalyticsResponse{}
+	if req.Cohorts {
+		resp.Cohorts = cohorts(records, start, end)
+	}
+	if req.TokenSeries {
+		resp.TokenVolumes = tokenVolumes(inRange, req.Interval)
+	}
+	if len(req.Percentiles) > 0 {
+		resp.PaymentSizes = percentiles(inRange, req.Percentiles)
+	}
+	return resp
+}
+
+func cohorts(records []PaymentRecord, start, end time.Time) []Cohort {
+	first := make(map[string]time.Time)
+	active := make(map[string]map[time.Time]bool)
+	var last time.Time
+	for _, r := range records {
+		if r.Customer == "" {
+			continue
+		}
+		month := monthOf(r.Timestamp)
+		if f, ok := first[r.Customer]; !ok || month.Before(f) {
+			first[r.Customer] = month
+		}
+		if active[r.Customer] == nil {
+			active[r.Customer] = make(map[time.Time]bool)
+		}
+		active[r.Customer][month] = true
+		if month.After(last) {
+			last = month
+		}
+	}
+
+	members := make(map[time.Time][]string)
+	for customer, month := range first {
+		if (start.IsZero() || !month.Before(monthOf(start.Unix()))) && (end.IsZero() || month.Before(end)) {
+			members[month] = append(members[month], customer)
+		}
+	}
+
+	out := make([]Cohort, 0, len(members))
+	for month, customers := range members {
+		c := Cohort{Month: month.Format("2006-01"), Customers: len(customers)}
+		for m := month; !m.After(last); m = m.AddDate(0, 1, 0) {
+			n := 0
+			for _, customer := range customers {
+				if active[customer][m] {
+					n++
+				}
+			}
+			c.Retention = append(c.Retention, float64(n)/float64(len(customers)))
+		}
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Month < out[j].Month })
+	return out
+}
+
+func tokenVolumes(records []PaymentRecord, interval string) []TokenVolumeSeries {
+	byToken := make(map[string]map[time.Time]*TokenVolumePoint)
+	for _, r := range records {
+		bucket := bucketOf(r.Timestamp, interval)
+		if byToken[r.TokenMint] == nil {
+			byToken[r.TokenMint] = make(map[time.Time]*TokenVolumePoint)
+		}
+		p := byToken[r.TokenMint][bucket]
+		if p == nil {
+			p = &TokenVolumePoint{Timestamp: bucket.Format(time.RFC3339)}
+			byToken[r.TokenMint][bucket] = p
+		}
+		p.PaymentCount++
+		p.TotalAmount += r.Amount
+	}
+
+	out := make([]TokenVolumeSeries, 0, len(byToken))
+	for mint, buckets := range byToken {
+		series := TokenVolumeSeries{TokenMint: mint}
+		for _, p := range buckets {
+			series.Points = append(series.Points, *p)
+		}
+		// RFC 3339 UTC timestamps sort chronologically
+		sort.Slice(series.Points, func(i, j int) bool { return series.Points[i].Timestamp < series.Points[j].Timestamp })
+		out = append(out, series)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].TokenMint < out[j].TokenMint })
+	return out
+}
+
+// percentiles returns SOL payment size percentiles in lamports, using the nearest-rank method.
+func percentiles(records []PaymentRecord, ps []float64) []PercentileStat {
+	var amounts []int64
+	for _, r := range records {
+		if r.TokenMint == "" {
+			amounts = append(amounts, r.Amount)
+		}
+	}
+	if len(amounts) == 0 {
+		return []PercentileStat{}
+	}
+	sort.Slice(amounts, func(i, j int) bool { return amounts[i] < amounts[j] })
+
+	out := make([]PercentileStat, len(ps))
+	for i, p := range ps {
+		rank := int(math.Ceil(p / 100 * float64(len(amounts))))
+		if rank < 1 {
+			rank = 1
+		}
+		out[i] = PercentileStat{Percentile: p, Amount: amounts[rank-1]}
+	}
+	return out
+}
+
+// dateRange returns the bounds of StartDate and EndDate. A date-only EndDate
+// includes that whole day. Unset bounds are zero.
+func (r AnalyticsRequest) dateRange() (start, end time.Time) {
+	start, _, _ = parseDate(r.StartDate)
+	end, dateOnly, _ := parseDate(r.EndDate)
+	if dateOnly {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end
+}
+
+// parseDate parses YYYY-MM-DD or RFC 3339, reporting whether s was date-only.
+func parseDate(s string) (t time.Time, dateOnly bool, err error) {
+	if s == "" {
+		return time.Time{}, false, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, true, nil
+	}
+	t, err = time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, false, errors.New("must be YYYY-MM-DD or RFC 3339")
+	}
+	return t.UTC(), false, nil
+}
+
+func monthOf(unix int64) time.Time {
+	t := time.Unix(unix, 0).UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func bucketOf(unix int64, interval string) time.Time {
+	t := time.Unix(unix, 0).UTC()
+	switch interval {
+	case "hour":
+		return t.Truncate(time.Hour)
+	case "week":
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		// Weeks start on Monday
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case "month":
+		return monthOf(unix)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+}
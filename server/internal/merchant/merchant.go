@@ -2,11 +2,15 @@ package merchant
 
 import (
 	"context"
+	"sync"
 )
 
 // Service handles merchant operations including earnings, analytics, and withdrawals.
 type Service struct {
 	doRequest func(ctx context.Context, method, path string, body, result interface{}) error
+
+	recordsMu sync.Mutex
+	records   RecordSource
 }
 
 // NewService creates a new merchant service.
@@ -38,6 +42,10 @@ type AnalyticsRequest struct {
 	StartDate string `json:"start_date,omitempty"` // ISO 8601 format
 	EndDate   string `json:"end_date,omitempty"`   // ISO 8601 format
 	Interval  string `json:"interval,omitempty"`   // "hour", "day", "week", "month"
+
+	Cohorts     bool      `json:"cohorts,omitempty"`      // Include monthly cohort retention
+	TokenSeries bool      `json:"token_series,omitempty"` // Include per-token volume series
+	Percentiles []float64 `json:"percentiles,omitempty"`  // SOL payment size percentiles to include, e.g. [50, 90, 99]
 }
 
 // PaymentStats contains payment statistics for a time period.
@@ -58,6 +66,11 @@ type AnalyticsResponse struct {
 	TopResources     []ResourceStat `json:"top_resources,omitempty"`
 	SuccessRate      float64        `json:"success_rate"`
 	PendingPayments  int            `json:"pending_payments"`
+
+	Cohorts      []Cohort            `json:"cohorts,omitempty"`
+	TokenVolumes []TokenVolumeSeries `json:"token_volumes,omitempty"`
+	PaymentSizes []PercentileStat    `json:"payment_sizes,omitempty"`
+	ComputedBy   string              `json:"computed_by,omitempty"` // "proxy" when extended fields were computed from local records
 }
 
 // ResourceStat contains statistics for a specific resource.
@@ -107,11 +120,19 @@ func (s *Service) GetEarnings(ctx context.Context) (*EarningsResponse, error) {
 
 // GetAnalytics retrieves payment analytics with optional date filtering.
 // Supports filtering by date range and grouping by interval (hour, day, week, month).
+// Requested cohort, token series and percentile fields the upstream leaves empty are
+// computed from the configured RecordSource, if any.
 func (s *Service) GetAnalytics(ctx context.Context, req AnalyticsRequest) (*AnalyticsResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
 	var resp AnalyticsResponse
 	if err := s.doRequest(ctx, "GET", "/shadowpay/api/merchant/analytics", req, &resp); err != nil {
 		return nil, err
 	}
+	if err := s.completeAnalytics(ctx, req, &resp); err != nil {
+		return nil, err
+	}
 	return &resp, nil
 }
 
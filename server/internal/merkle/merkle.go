@@ -0,0 +1,244 @@
+// Package merkle implements the fixed-depth, append-only Merkle tree used by
+// ShadowID and receipts. It supports offline proof generation and verification,
+// and can be kept in sync with the remote tree as a local replica.
+package merkle
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"sol_privacy/internal/poseidon"
+)
+
+// MaxDepth bounds the tree depth to keep proofs and zero tables small.
+const MaxDepth = 32
+
+// HashFunc combines two child nodes into their parent.
+type HashFunc func(left, right *big.Int) *big.Int
+
+// Proof is a Merkle inclusion proof for a single leaf.
+type Proof struct {
+	LeafIndex   int        `json:"leaf_index"`
+	Leaf        *big.Int   `json:"leaf"`
+	Siblings    []*big.Int `json:"siblings"`     // Bottom-up sibling hashes
+	PathIndices []int      `json:"path_indices"` // 0 if the node is a left child, 1 if right
+	Root        *big.Int   `json:"root"`
+}
+
+// Tree is an append-only Merkle tree of fixed depth. It is safe for concurrent use.
+type Tree struct {
+	depth int
+	hash  HashFunc
+	zeros []*big.Int // zeros[l] is the root of an empty subtree of height l
+
+	mu     sync.RWMutex
+	levels [][]*big.Int // levels[0] are leaves, levels[depth][0] is the root
+}
+
+// PoseidonHash is the HashFunc used by the ShadowID and receipt trees.
+// Children are reduced into the BN254 field before hashing.
+func PoseidonHash(left, right *big.Int) *big.Int {
+	l := new(big.Int).Mod(left, poseidon.Modulus)
+	r := new(big.Int).Mod(right, poseidon.Modulus)
+	h, err := poseidon.Hash(l, r)
+	if err != nil {
+		// Unreachable: both inputs are reduced field elements
+		panic(err)
+	}
+	return h
+}
+
+// NewPoseidon creates an empty tree of the given depth hashed with Poseidon.
+func NewPoseidon(depth int) (*Tree, error) {
+	return New(depth, PoseidonHash)
+}
+
+// New creates an empty tree of the given depth using hash to combine nodes.
+// Empty leaves are the zero field element.
+func New(depth int, hash HashFunc) (*Tree, error) {
+	if depth < 1 || depth > MaxDepth {
+		return nil, fmt.Errorf("merkle: depth must be between 1 and %d, got %d", MaxDepth, depth)
+	}
+	if hash == nil {
+		return nil, fmt.Errorf("merkle: hash function is required")
+	}
+
+	zeros := make([]*big.Int, depth+1)
+	zeros[0] = big.NewInt(0)
+	for l := 1; l <= depth; l++ {
+		zeros[l] = hash(zeros[l-1], zeros[l-1])
+	}
+
+	return &Tree{
+		depth:  depth,
+		hash:   hash,
+		zeros:  zeros,
+		levels: make([][]*big.Int, depth+1),
+	}, nil
+}
+
+// Depth returns the tree depth.
+func (t *Tree) Depth() int {
+	return t.depth
+}
+
+// Capacity returns the maximum number of leaves.
+func (t *Tree) Capacity() int {
+	return 1 << t.depth
+}
+
+// LeafCount returns the number of inserted leaves.
+func (t *Tree) LeafCount() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.levels[0])
+}
+
+// Root returns the current root.
+func (t *Tree) Root() *big.Int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return new(big.Int).Set(t.node(t.depth, 0))
+}
+
+// Insert appends a leaf and returns its index.
+func (t *Tree) Insert(leaf *big.Int) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.insertLocked(leaf)
+}
+
+// InsertMany appends leaves in order and returns the index of the first one.
+func (t *Tree) InsertMany(leaves []*big.Int) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.levels[0])+len(leaves) > t.Capacity() {
+		return 0, fmt.Errorf("merkle: tree full (capacity %d)", t.Capacity())
+	}
+
+	first := len(t.levels[0])
+	for _, leaf := range leaves {
+		if _, err := t.insertLocked(leaf); err != nil {
+			return 0, err
+		}
+	}
+	return first, nil
+}
+
+// IndexOf returns the index of the first leaf equal to leaf, or -1.
+func (t *Tree) IndexOf(leaf *big.Int) int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for i, l := range t.levels[0] {
+		if l.Cmp(leaf) == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// Proof builds an inclusion proof for the leaf at index.
+func (t *Tree) Proof(index int) (*Proof, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if index < 0 || index >= len(t.levels[0]) {
+		return nil, fmt.Errorf("merkle: leaf index %d out of range", index)
+	}
+
+	proof := &Proof{
+		LeafIndex:   index,
+		Leaf:        new(big.Int).Set(t.levels[0][index]),
+		Siblings:    make([]*big.Int, t.depth),
+		PathIndices: make([]int, t.depth),
+		Root:        new(big.Int).Set(t.node(t.depth, 0)),
+	}
+
+	idx := index
+	for l := 0; l < t.depth; l++ {
+		proof.PathIndices[l] = idx & 1
+		proof.Siblings[l] = new(big.Int).Set(t.node(l, idx^1))
+		idx >>= 1
+	}
+	return proof, nil
+}
+
+// Verify checks the proof against its own root using hash.
+func Verify(proof *Proof, hash HashFunc) bool {
+	if proof == nil || proof.Leaf == nil || proof.Root == nil {
+		return false
+	}
+	return ComputeRoot(proof.Leaf, proof.Siblings, proof.PathIndices, hash).Cmp(proof.Root) == 0
+}
+
+// ComputeRoot folds a leaf up through its siblings and returns the resulting root.
+func ComputeRoot(leaf *big.Int, siblings []*big.Int, pathIndices []int, hash HashFunc) *big.Int {
+	cur := leaf
+	for l, sib := range siblings {
+		if l < len(pathIndices) && pathIndices[l] == 1 {
+			cur = hash(sib, cur)
+		} else {
+			cur = hash(cur, sib)
+		}
+	}
+	return cur
+}
+
+// ParseElement parses a field element encoded as 0x-prefixed hex or decimal.
+func ParseElement(s string) (*big.Int, error) {
+	n := new(big.Int)
+	var ok bool
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		_, ok = n.SetString(s[2:], 16)
+	} else {
+		_, ok = n.SetString(s, 10)
+	}
+	if !ok {
+		return nil, fmt.Errorf("merkle: invalid field element %q", s)
+	}
+	return n, nil
+}
+
+// FormatElement encodes a field element as 0x-prefixed, 32-byte hex.
+func FormatElement(n *big.Int) string {
+	return fmt.Sprintf("0x%064x", n)
+}
+
+func (t *Tree) insertLocked(leaf *big.Int) (int, error) {
+	index := len(t.levels[0])
+	if index >= t.Capacity() {
+		return 0, fmt.Errorf("merkle: tree full (capacity %d)", t.Capacity())
+	}
+
+	t.levels[0] = append(t.levels[0], new(big.Int).Set(leaf))
+
+	idx := index
+	for l := 0; l < t.depth; l++ {
+		parent := idx >> 1
+		var left, right *big.Int
+		if idx&1 == 0 {
+			left, right = t.node(l, idx), t.zeros[l]
+		} else {
+			left, right = t.node(l, idx-1), t.node(l, idx)
+		}
+		h := t.hash(left, right)
+		if parent < len(t.levels[l+1]) {
+			t.levels[l+1][parent] = h
+		} else {
+			t.levels[l+1] = append(t.levels[l+1], h)
+		}
+		idx = parent
+	}
+	return index, nil
+}
+
+// node returns the node at level l and position i, falling back to the empty subtree hash.
+func (t *Tree) node(l, i int) *big.Int {
+	if i < len(t.levels[l]) {
+		return t.levels[l][i]
+	}
+	return t.zeros[l]
+}
@@ -0,0 +1,265 @@
+// Package messages relays end-to-end encrypted messages between a merchant
+// and a customer, such as shipping details or support requests.
+//
+// Neither side is known by wallet. The merchant is its stealth meta-address,
+// whose scan key it already holds; the customer is the ShadowID commitment it
+// paid with, plus an X25519 key derived from its ShadowID secret for that
+// merchant. Both sides seal messages with AES-256-GCM under a key derived from
+// X25519 of their static keys, so the proxy stores and relays ciphertext only.
+// Static keys give no forward secrecy: whoever later obtains either private
+// key can read the whole conversation.
+package messages
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/jsonfile"
+	"sol_privacy/internal/validate"
+)
+
+// Limits of relayed messages.
+const (
+	MaxCiphertextSize = 16 << 10 // Bytes, including the GCM tag
+	MaxMessages       = 1000     // Per conversation
+)
+
+var (
+	// ErrConversationNotFound is returned for unknown conversation IDs.
+	ErrConversationNotFound = errors.New("conversation not found")
+	// ErrConversationFull is returned once a conversation holds MaxMessages.
+	ErrConversationFull = errors.New("conversation has reached its message limit")
+)
+
+// Role is the side of a conversation that sent a message.
+type Role string
+
+// Conversation roles.
+const (
+	RoleCustomer Role = "customer"
+	RoleMerchant Role = "merchant"
+)
+
+// Conversation is a thread between a customer and a merchant.
+type Conversation struct {
+	ID          string    `json:"id"`
+	Commitment  string    `json:"commitment"`   // Customer's ShadowID commitment
+	MerchantKey string    `json:"merchant_key"` // Merchant's stealth meta-address
+	CustomerKey string    `json:"customer_key"` // Customer's base58 X25519 public key
+	Count       int       `json:"message_count"`
+	CreatedAt   int64     `json:"created_at"`
+	UpdatedAt   int64     `json:"updated_at"`
+	Messages    []Message `json:"messages,omitempty"`
+}
+
+// Message is a sealed message. Seq counts from 1 within its conversation.
+type Message struct {
+	Seq        int    `json:"seq"`
+	Sender     Role   `json:"sender"`
+	Nonce      []byte `json:"nonce"`      // Base64 in JSON
+	Ciphertext []byte `json:"ciphertext"` // Base64 in JSON
+	CreatedAt  int64  `json:"created_at"`
+}
+
+// StartRequest opens a conversation. Customers start conversations, since
+// only they know the key derived for the merchant.
+type StartRequest struct {
+	Commitment  string `json:"commitment"`
+	MerchantKey string `json:"merchant_key"`
+	CustomerKey string `json:"customer_key"`
+}
+
+// PostRequest adds a sealed message to a conversation.
+type PostRequest struct {
+	Sender     Role   `json:"sender"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Filter selects conversations. Empty fields match everything.
+type Filter struct {
+	Commitment  string
+	MerchantKey string
+}
+
+// Store persists conversations and their messages.
+type Store interface {
+	Put(c *Conversation) error
+	Get(id string) (*Conversation, error) // Returns ErrConversationNotFound for unknown IDs
+	List() ([]*Conversation, error)
+}
+
+// NewMemoryStore creates an empty in-memory Store.
+func NewMemoryStore() Store {
+	return store{jsonfile.NewMemoryStore(conversationID, cloneConversation)}
+}
+
+// NewFileStore opens a Store persisted to a JSON file after every write,
+// creating it on first write.
+func NewFileStore(path string) (Store, error) {
+	conversations, err := jsonfile.NewStore(path, conversationID, cloneConversation)
+	if err != nil {
+		return nil, err
+	}
+	return store{conversations}, nil
+}
+
+func conversationID(c *Conversation) string { return c.ID }
+
+func cloneConversation(c Conversation) Conversation {
+	c.Messages = append([]Message(nil), c.Messages...)
+	return c
+}
+
+// store keeps conversations in a jsonfile.Store.
+type store struct {
+	conversations *jsonfile.Store[Conversation]
+}
+
+func (s store) Put(c *Conversation) error {
+	return s.conversations.Put(c)
+}
+
+func (s store) Get(id string) (*Conversation, error) {
+	c, ok := s.conversations.Get(id)
+	if !ok {
+		return nil, ErrConversationNotFound
+	}
+	return c, nil
+}
+
+// List returns all conversations, most recently updated first.
+func (s store) List() ([]*Conversation, error) {
+	return s.conversations.List(func(a, b *Conversation) bool {
+		if a.UpdatedAt != b.UpdatedAt {
+			return a.UpdatedAt > b.UpdatedAt
+		}
+		return a.ID < b.ID
+	}), nil
+}
+
+// Service relays sealed messages. It never sees plaintext or private keys.
+type Service struct {
+	store Store
+	mu    sync.Mutex // Serializes read-modify-write updates
+}
+
+// NewService creates a message service. A nil store selects an in-memory store.
+func NewService(store Store) *Service {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	return &Service{store: store}
+}
+
+// Start opens a conversation between a commitment and a merchant.
+func (s *Service) Start(ctx context.Context, req StartRequest) (*Conversation, error) {
+	v := validate.New().Commitment("commitment", req.Commitment)
+	v.Add("merchant_key", checkKey(req.MerchantKey))
+	v.Add("customer_key", checkKey(req.CustomerKey))
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 16)
+	rand.Read(id)
+	now := time.Now().Unix()
+	c := &Conversation{
+		ID:          hex.EncodeToString(id),
+		Commitment:  req.Commitment,
+		MerchantKey: req.MerchantKey,
+		CustomerKey: req.CustomerKey,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.store.Put(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Post appends a sealed message to a conversation. The proxy cannot check who
+// sealed it; recipients discard messages that fail to open.
+func (s *Service) Post(ctx context.Context, id string, req PostRequest) (*Message, error) {
+	v := validate.New()
+	if req.Sender != RoleCustomer && req.Sender != RoleMerchant {
+		v.Add("sender", fmt.Errorf("must be %q or %q", RoleCustomer, RoleMerchant))
+	}
+	if len(req.Nonce) != nonceSize {
+		v.Add("nonce", fmt.Errorf("must be %d bytes", nonceSize))
+	}
+	if len(req.Ciphertext) <= tagSize || len(req.Ciphertext) > MaxCiphertextSize {
+		v.Add("ciphertext", fmt.Errorf("must be between %d and %d bytes", tagSize+1, MaxCiphertextSize))
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, err := s.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.Messages) >= MaxMessages {
+		return nil, ErrConversationFull
+	}
+	now := time.Now().Unix()
+	m := Message{
+		Seq:        len(c.Messages) + 1,
+		Sender:     req.Sender,
+		Nonce:      req.Nonce,
+		Ciphertext: req.Ciphertext,
+		CreatedAt:  now,
+	}
+	c.Messages = append(c.Messages, m)
+	c.Count = len(c.Messages)
+	c.UpdatedAt = now
+	if err := s.store.Put(c); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Get returns a conversation with the messages after seq after; 0 returns
+// all of them.
+func (s *Service) Get(ctx context.Context, id string, after int) (*Conversation, error) {
+	c, err := s.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if after > 0 {
+		if after >= len(c.Messages) {
+			c.Messages = nil
+		} else {
+			c.Messages = c.Messages[after:]
+		}
+	}
+	return c, nil
+}
+
+// List returns the conversations matching f, without their messages, most
+// recently updated first.
+func (s *Service) List(ctx context.Context, f Filter) ([]*Conversation, error) {
+	all, err := s.store.List()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*Conversation, 0, len(all))
+	for _, c := range all {
+		if f.Commitment != "" && c.Commitment != f.Commitment {
+			continue
+		}
+		if f.MerchantKey != "" && c.MerchantKey != f.MerchantKey {
+			continue
+		}
+		c.Messages = nil
+		out = append(out, c)
+	}
+	return out, nil
+}
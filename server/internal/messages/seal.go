@@ -0,0 +1,172 @@
+package messages
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"sol_privacy/internal/base58"
+	"sol_privacy/internal/shadowid"
+)
+
+// Domain separates message keys from other uses of the same key material.
+const Domain = "shadowpay/messages/v1"
+
+// AES-GCM parameters.
+const (
+	nonceSize = 12
+	tagSize   = 16
+)
+
+// ErrUnreadable is returned by Open for messages sealed under another key,
+// which includes any the proxy or a third party forged.
+var ErrUnreadable = errors.New("message cannot be decrypted")
+
+// Party is one side of a conversation and its private X25519 key.
+type Party struct {
+	role Role
+	key  *ecdh.PrivateKey
+}
+
+// Merchant returns the merchant side for a stealth scan key, whose
+// meta-address customers address conversations to.
+func Merchant(scan *ecdh.PrivateKey) *Party {
+	return &Party{role: RoleMerchant, key: scan}
+}
+
+// Customer returns the customer side of conversations with merchantKey. The
+// key is derived from the ShadowID secret, so it is recoverable on any device
+// and differs per merchant:
+//
+//	SHA-256("shadowpay/messages/v1/customer" || secret || merchant key)
+func Customer(id *shadowid.Identity, merchantKey string) (*Party, error) {
+	merchant, err := parseKey(merchantKey)
+	if err != nil {
+		return nil, fmt.Errorf("messages: invalid merchant key: %w", err)
+	}
+	if id.Secret == nil || id.Secret.Sign() < 0 || id.Secret.BitLen() > 256 {
+		return nil, fmt.Errorf("messages: invalid ShadowID secret")
+	}
+	h := sha256.New()
+	h.Write([]byte(Domain + "/customer"))
+	h.Write(id.Secret.FillBytes(make([]byte, 32)))
+	h.Write(merchant.Bytes())
+	key, err := ecdh.X25519().NewPrivateKey(h.Sum(nil))
+	if err != nil {
+		return nil, fmt.Errorf("messages: %w", err)
+	}
+	return &Party{role: RoleCustomer, key: key}, nil
+}
+
+// Role returns the side the party speaks for.
+func (p *Party) Role() Role {
+	return p.role
+}
+
+// PublicKey returns the party's base58 public key: the meta-address for a
+// merchant, or the StartRequest customer key for a customer.
+func (p *Party) PublicKey() string {
+	return base58.Encode(p.key.PublicKey().Bytes())
+}
+
+// Seal encrypts plaintext for the other side of c.
+func (p *Party) Seal(c *Conversation, plaintext []byte) (*PostRequest, error) {
+	aead, err := p.aead(c)
+	if err != nil {
+		return nil, err
+	}
+	if len(plaintext)+tagSize > MaxCiphertextSize {
+		return nil, fmt.Errorf("messages: message exceeds %d bytes", MaxCiphertextSize-tagSize)
+	}
+	nonce := make([]byte, nonceSize)
+	rand.Read(nonce)
+	return &PostRequest{
+		Sender:     p.role,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, additionalData(c, p.role)),
+	}, nil
+}
+
+// Open decrypts a message of c, sent by either side.
+func (p *Party) Open(c *Conversation, m *Message) ([]byte, error) {
+	aead, err := p.aead(c)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.Nonce) != nonceSize {
+		return nil, ErrUnreadable
+	}
+	plaintext, err := aead.Open(nil, m.Nonce, m.Ciphertext, additionalData(c, m.Sender))
+	if err != nil {
+		return nil, ErrUnreadable
+	}
+	return plaintext, nil
+}
+
+// aead returns the cipher of c, keyed by
+// SHA-256(Domain || X25519(customer, merchant) || customer key || merchant key || ID).
+func (p *Party) aead(c *Conversation) (cipher.AEAD, error) {
+	own, peer := c.CustomerKey, c.MerchantKey
+	if p.role == RoleMerchant {
+		own, peer = peer, own
+	}
+	if own != p.PublicKey() {
+		return nil, fmt.Errorf("messages: conversation %s is not addressed to this %s key", c.ID, p.role)
+	}
+	peerKey, err := parseKey(peer)
+	if err != nil {
+		return nil, fmt.Errorf("messages: invalid %s key: %w", peerRole(p.role), err)
+	}
+	shared, err := p.key.ECDH(peerKey)
+	if err != nil {
+		return nil, fmt.Errorf("messages: %w", err)
+	}
+	h := sha256.New()
+	h.Write([]byte(Domain))
+	h.Write(shared)
+	h.Write([]byte(c.CustomerKey))
+	h.Write([]byte(c.MerchantKey))
+	h.Write([]byte(c.ID))
+	block, err := aes.NewCipher(h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// additionalData binds a ciphertext to its conversation and sender, so
+// messages cannot be replayed into another thread or attributed to the
+// other side.
+func additionalData(c *Conversation, sender Role) []byte {
+	return []byte(c.ID + "/" + string(sender))
+}
+
+func peerRole(r Role) Role {
+	if r == RoleMerchant {
+		return RoleCustomer
+	}
+	return RoleMerchant
+}
+
+func parseKey(s string) (*ecdh.PublicKey, error) {
+	b, err := base58.Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	return ecdh.X25519().NewPublicKey(b)
+}
+
+// checkKey validates a base58 X25519 public key.
+func checkKey(s string) error {
+	if s == "" {
+		return errors.New("is required")
+	}
+	if _, err := parseKey(s); err != nil {
+		return errors.New("must be a base58 X25519 public key")
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+// Package metering sells quota on top of x402: an authorized payment buys a
+// number of request credits or megabytes of responses, which the metered
+// middleware draws down until a new payment is required.
+package metering
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/payment"
+)
+
+// Headers reporting the quota left when a request starts.
+const (
+	HeaderRequestsRemaining = "X-Quota-Requests-Remaining"
+	HeaderBytesRemaining    = "X-Quota-Bytes-Remaining"
+)
+
+const megabyte = 1 << 20
+
+var (
+	// ErrGrantNotFound is returned for unknown grants and tokens.
+	ErrGrantNotFound = errors.New("metering grant not found")
+	// ErrQuotaExhausted is returned once a grant's quota is used up, and by
+	// writes beyond the byte quota.
+	ErrQuotaExhausted = errors.New("quota exhausted")
+)
+
+// Plan prices quota. An authorized amount buys amount/LamportsPerRequest
+// requests and amount/LamportsPerMB megabytes of response bodies; a zero
+// price leaves that dimension unmetered.
+type Plan struct {
+	LamportsPerRequest int64
+	LamportsPerMB      int64
+}
+
+// Validate checks the plan meters something and has no negative prices.
+func (p Plan) Validate() error {
+	if p.LamportsPerRequest < 0 || p.LamportsPerMB < 0 {
+		return fmt.Errorf("metering prices must not be negative")
+	}
+	if p.LamportsPerRequest == 0 && p.LamportsPerMB == 0 {
+		return fmt.Errorf("metering plan needs a request or megabyte price")
+	}
+	return nil
+}
+
+// Quota returns what an authorized amount buys, or -1 for what the plan
+// does not meter.
+func (p Plan) Quota(amount int64) (requests, bytes int64) {
+	requests, bytes = -1, -1
+	if p.LamportsPerRequest > 0 {
+		requests = amount / p.LamportsPerRequest
+	}
+	if p.LamportsPerMB > 0 {
+		b := new(big.Int).Mul(big.NewInt(amount), big.NewInt(megabyte))
+		b.Quo(b, big.NewInt(p.LamportsPerMB))
+		bytes = b.Int64()
+		if !b.IsInt64() {
+			bytes = 1<<63 - 1
+		}
+	}
+	return requests, bytes
+}
+
+// Usage is the quota bought by one access grant and how much of it was used.
+// A grant is the token issued by Authorize and every token refreshed from it.
+type Usage struct {
+	Grant        string `json:"grant"` // SHA-256 of the first token
+	Commitment   string `json:"commitment"`
+	Merchant     string `json:"merchant"`
+	Amount       int64  `json:"amount"`
+	RequestQuota int64  `json:"request_quota"` // -1 when requests are unmetered
+	Requests     int64  `json:"requests"`
+	ByteQuota    int64  `json:"byte_quota"` // -1 when bytes are unmetered
+	Bytes        int64  `json:"bytes"`
+	CreatedAt    int64  `json:"created_at"`
+	UpdatedAt    int64  `json:"updated_at"`
+}
+
+// RequestsRemaining returns the requests left, or -1 if requests are unmetered.
+func (u *Usage) RequestsRemaining() int64 {
+	if u.RequestQuota < 0 {
+		return -1
+	}
+	return max(u.RequestQuota-u.Requests, 0)
+}
+
+// BytesRemaining returns the response bytes left, or -1 if bytes are unmetered.
+func (u *Usage) BytesRemaining() int64 {
+	if u.ByteQuota < 0 {
+		return -1
+	}
+	return max(u.ByteQuota-u.Bytes, 0)
+}
+
+// Exhausted reports whether any metered quota is used up.
+func (u *Usage) Exhausted() bool {
+	return u.RequestsRemaining() == 0 || u.BytesRemaining() == 0
+}
+
+// Meter tracks usage per access grant.
+type Meter struct {
+	payments *payment.Service
+	plan     Plan
+	store    Store
+
+	mu sync.Mutex // Serializes read-modify-write of usage
+}
+
+// New creates a meter. A nil store selects an in-memory store. Tokens
+// refreshed through payments keep the usage of the token they replace.
+func New(payments *payment.Service, plan Plan, store Store) (*Meter, error) {
+	if err := plan.Validate(); err != nil {
+		return nil, err
+	}
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	m := &Meter{payments: payments, plan: plan, store: store}
+	payments.OnRefresh(m.link)
+	return m, nil
+}
+
+// Usage returns the usage of the grant a token belongs to.
+func (m *Meter) Usage(token string) (*Usage, error) {
+	grant, err := m.store.Resolve(tokenID(token))
+	if err != nil {
+		return nil, err
+	}
+	return m.store.Get(grant)
+}
+
+// Middleware returns the x402 middleware of payments.RequireAccess with
+// metering: each request uses a credit, response bodies use byte quota, the
+// quota left is returned in X-Quota-*-Remaining headers, and requests with
+// quota exhausted get 402 Payment Required. Streams stop being written once
+// their bytes run out; concurrent streams of one grant share the bytes left
+// when each started, so together they may overdraw it.
+func (m *Meter) Middleware(opts payment.MiddlewareOptions) func(http.Handler) http.Handler {
+	requireAccess := m.payments.RequireAccess(opts)
+	return func(next http.Handler) http.Handler {
+		return requireAccess(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			access := payment.AccessFromContext(r.Context())
+			u, err := m.begin(access)
+			if errors.Is(err, ErrQuotaExhausted) {
+				setRemaining(w.Header(), u)
+				payment.PaymentRequired(w, "Quota exhausted")
+				return
+			}
+			if err != nil {
+				payment.WriteError(w, http.StatusInternalServerError, "Failed to record usage")
+				return
+			}
+			setRemaining(w.Header(), u)
+			if u.ByteQuota < 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			mw := &meteredWriter{ResponseWriter: w, remaining: u.BytesRemaining()}
+			defer func() {
+				// The response is already sent; a failed save only loses this count
+				m.addBytes(u.Grant, mw.written)
+			}()
+			next.ServeHTTP(mw, r)
+		}))
+	}
+}
+
+// begin charges a request to the access's grant, creating the grant on first
+// use. If the quota is exhausted it returns the usage and ErrQuotaExhausted.
+func (m *Meter) begin(a *payment.Access) (*Usage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := tokenID(a.Token())
+	grant, err := m.store.Resolve(id)
+	if errors.Is(err, ErrGrantNotFound) {
+		grant = id
+		if err := m.store.Alias(id, grant); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	u, err := m.store.Get(grant)
+	if errors.Is(err, ErrGrantNotFound) {
+		now := time.Now().Unix()
+		u = &Usage{Grant: grant, Commitment: a.Commitment, Merchant: a.Merchant, Amount: a.Amount, CreatedAt: now}
+		u.RequestQuota, u.ByteQuota = m.plan.Quota(a.Amount)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if u.Exhausted() {
+		return u, ErrQuotaExhausted
+	}
+	if u.RequestQuota > 0 {
+		u.Requests++
+	}
+	u.UpdatedAt = time.Now().Unix()
+	if err := m.store.Put(u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (m *Meter) addBytes(grant string, n int64) error {
+	if n == 0 {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, err := m.store.Get(grant)
+	if err != nil {
+		return err
+	}
+	u.Bytes += n
+	u.UpdatedAt = time.Now().Unix()
+	return m.store.Put(u)
+}
+
+// link carries a grant over to a refreshed token.
+func (m *Meter) link(oldToken, newToken string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	grant, err := m.store.Resolve(tokenID(oldToken))
+	if err != nil {
+		// Not metered yet; the new token starts the grant
+		return
+	}
+	m.store.Alias(tokenID(newToken), grant)
+}
+
+func setRemaining(h http.Header, u *Usage) {
+	if n := u.RequestsRemaining(); n >= 0 {
+		h.Set(HeaderRequestsRemaining, strconv.FormatInt(n, 10))
+	}
+	if n := u.BytesRemaining(); n >= 0 {
+		h.Set(HeaderBytesRemaining, strconv.FormatInt(n, 10))
+	}
+}
+
+// tokenID identifies a token without storing it.
+func tokenID(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+// meteredWriter counts response body bytes and refuses writes beyond the quota.
+type meteredWriter struct {
+	http.ResponseWriter
+	remaining int64
+	written   int64
+}
+
+func (w *meteredWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) > w.remaining-w.written {
+		p = p[:max(w.remaining-w.written, 0)]
+		n, err := w.ResponseWriter.Write(p)
+		w.written += int64(n)
+		if err == nil {
+			err = ErrQuotaExhausted
+		}
+		return n, err
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.written += int64(n)
+	return n, err
+}
+
+// Flush lets streaming handlers flush through the meter.
+func (w *meteredWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *meteredWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
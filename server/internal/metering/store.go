@@ -0,0 +1,76 @@
+package metering
+
+import (
+	"sol_privacy/internal/jsonfile"
+)
+
+// Store persists usage counters and which grant each token belongs to.
+type Store interface {
+	Get(grant string) (*Usage, error) // Returns ErrGrantNotFound for unknown grants
+	Put(u *Usage) error
+	Resolve(tokenID string) (string, error) // Returns ErrGrantNotFound for unknown tokens
+	Alias(tokenID, grant string) error
+}
+
+// NewMemoryStore creates an empty in-memory Store.
+func NewMemoryStore() Store {
+	return store{
+		usage:   jsonfile.NewMemoryStore(usageGrant, nil),
+		aliases: jsonfile.NewMemoryStore(aliasToken, nil),
+	}
+}
+
+// NewFileStore opens a Store persisted to JSON files after every write,
+// creating them on first write: usage at path and token aliases at
+// path.aliases.
+func NewFileStore(path string) (Store, error) {
+	usage, err := jsonfile.NewStore(path, usageGrant, nil)
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := jsonfile.NewStore(path+".aliases", aliasToken, nil)
+	if err != nil {
+		return nil, err
+	}
+	return store{usage: usage, aliases: aliases}, nil
+}
+
+// alias records which grant a token belongs to.
+type alias struct {
+	Token string `json:"token"`
+	Grant string `json:"grant"`
+}
+
+func usageGrant(u *Usage) string { return u.Grant }
+
+func aliasToken(a *alias) string { return a.Token }
+
+// store keeps usage and aliases in jsonfile.Stores.
+type store struct {
+	usage   *jsonfile.Store[Usage]
+	aliases *jsonfile.Store[alias]
+}
+
+func (s store) Get(grant string) (*Usage, error) {
+	u, ok := s.usage.Get(grant)
+	if !ok {
+		return nil, ErrGrantNotFound
+	}
+	return u, nil
+}
+
+func (s store) Put(u *Usage) error {
+	return s.usage.Put(u)
+}
+
+func (s store) Resolve(tokenID string) (string, error) {
+	a, ok := s.aliases.Get(tokenID)
+	if !ok {
+		return "", ErrGrantNotFound
+	}
+	return a.Grant, nil
+}
+
+func (s store) Alias(tokenID, grant string) error {
+	return s.aliases.Put(&alias{Token: tokenID, Grant: grant})
+}
@@ -0,0 +1,502 @@
+// Package notify emails and texts merchants about payment events, for those
+// who don't run webhook infrastructure. Each channel (SMTP email, Twilio SMS)
+// consumes the event bus as its own durable subscriber, renders the event
+// through a template, and sends it to the merchant's address if their
+// preferences ask for that event and the channel's rate limit allows.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"sol_privacy/internal/checkout"
+	"sol_privacy/internal/events"
+	"sol_privacy/internal/settlement"
+	"sol_privacy/internal/validate"
+)
+
+// Channel names.
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+)
+
+// Defaults.
+const (
+	DefaultEmailPerHour = 30
+	DefaultSMSPerHour   = 10
+	maxSMSLength        = 1600 // Twilio's limit
+)
+
+// DefaultEvents are notified when a merchant's preferences name none.
+var DefaultEvents = []string{events.SettlementSucceeded, events.SettlementFailed, events.CheckoutCompleted}
+
+// ErrPreferenceNotFound is returned for merchants without preferences.
+var ErrPreferenceNotFound = errors.New("notification preferences not found")
+
+// Message is a rendered notification. SMS uses only Body.
+type Message struct {
+	Subject string
+	Body    string
+}
+
+// Sender delivers messages on one channel.
+type Sender interface {
+	Send(ctx context.Context, to string, msg Message) error
+}
+
+// Preference is a merchant's notification settings.
+type Preference struct {
+	Merchant  string   `json:"merchant"` // Wallet payments are made to
+	Email     string   `json:"email,omitempty"`
+	Phone     string   `json:"phone,omitempty"`      // E.164, e.g. +14155550100
+	Events    []string `json:"events,omitempty"`     // Event type patterns, default DefaultEvents
+	SMSEvents []string `json:"sms_events,omitempty"` // Narrower patterns for SMS, default Events
+	Paused    bool     `json:"paused,omitempty"`
+	UpdatedAt int64    `json:"updated_at"`
+}
+
+// Validate checks the preference fields.
+func (p Preference) Validate() error {
+	v := validate.New().Address("merchant", p.Merchant)
+	if p.Email != "" && (!strings.Contains(p.Email, "@") || strings.ContainsAny(p.Email, "\r\n ,;<>")) {
+		v.Add("email", fmt.Errorf("must be a single email address"))
+	}
+	if p.Phone != "" && !validPhone(p.Phone) {
+		v.Add("phone", fmt.Errorf("must be an E.164 number, e.g. +14155550100"))
+	}
+	return v.Err()
+}
+
+func validPhone(s string) bool {
+	if len(s) < 8 || len(s) > 16 || s[0] != '+' {
+		return false
+	}
+	_, err := strconv.ParseUint(s[1:], 10, 64)
+	return err == nil
+}
+
+// wants reports whether the preference asks for the event on a channel.
+func (p *Preference) wants(channel, eventType string) bool {
+	if p.Paused {
+		return false
+	}
+	patterns := p.Events
+	if len(patterns) == 0 {
+		patterns = DefaultEvents
+	}
+	if channel == ChannelSMS && len(p.SMSEvents) > 0 {
+		patterns = p.SMSEvents
+	}
+	return events.Match(eventType, patterns)
+}
+
+func (p *Preference) address(channel string) string {
+	if channel == ChannelSMS {
+		return p.Phone
+	}
+	return p.Email
+}
+
+// Limit caps the messages one merchant gets on a channel per hour. Zero
+// selects the channel's default; a negative limit disables it.
+type Limit int
+
+// Config holds notifier configuration.
+type Config struct {
+	Email Sender // Nil disables email
+	SMS   Sender // Nil disables SMS
+	Store Store  // Defaults to an in-memory store
+
+	// Templates override DefaultTemplates by event type; "*" overrides the
+	// fallback for types without one.
+	Templates map[string]Template
+
+	EmailPerHour Limit
+	SMSPerHour   Limit
+}
+
+// Stats counts notifications per channel since start.
+type Stats struct {
+	Sent       int64 `json:"sent"`
+	Suppressed int64 `json:"suppressed"` // Dropped by the rate limit
+	Failed     int64 `json:"failed"`     // Send attempts that failed, each retried
+}
+
+// Notifier renders events and sends them to merchants.
+type Notifier struct {
+	config    Config
+	templates map[string]*compiled
+	limits    map[string]int
+
+	mu    sync.Mutex
+	sent  map[string][]time.Time // By channel and merchant, within the last hour
+	stats map[string]*Stats
+}
+
+// New creates a notifier, compiling its templates.
+func New(config Config) (*Notifier, error) {
+	if config.Store == nil {
+		config.Store = NewMemoryStore()
+	}
+	templates := make(map[string]*compiled)
+	for eventType, t := range DefaultTemplates {
+		c, err := t.compile(eventType)
+		if err != nil {
+			return nil, err
+		}
+		templates[eventType] = c
+	}
+	for eventType, t := range config.Templates {
+		c, err := t.compile(eventType)
+		if err != nil {
+			return nil, err
+		}
+		templates[eventType] = c
+	}
+	n := &Notifier{
+		config:    config,
+		templates: templates,
+		limits: map[string]int{
+			ChannelEmail: limit(config.EmailPerHour, DefaultEmailPerHour),
+			ChannelSMS:   limit(config.SMSPerHour, DefaultSMSPerHour),
+		},
+		sent:  make(map[string][]time.Time),
+		stats: map[string]*Stats{ChannelEmail: {}, ChannelSMS: {}},
+	}
+	return n, nil
+}
+
+func limit(l Limit, def int) int {
+	switch {
+	case l == 0:
+		return def
+	case l < 0:
+		return 0
+	default:
+		return int(l)
+	}
+}
+
+// Channels returns the channels with a sender.
+func (n *Notifier) Channels() []string {
+	var out []string
+	if n.config.Email != nil {
+		out = append(out, ChannelEmail)
+	}
+	if n.config.SMS != nil {
+		out = append(out, ChannelSMS)
+	}
+	return out
+}
+
+// SetPreference saves a merchant's preferences.
+func (n *Notifier) SetPreference(p Preference) (*Preference, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	p.UpdatedAt = time.Now().Unix()
+	if err := n.config.Store.Put(&p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Preference returns a merchant's preferences.
+func (n *Notifier) Preference(merchant string) (*Preference, error) {
+	return n.config.Store.Get(merchant)
+}
+
+// DeletePreference stops all notifications to a merchant.
+func (n *Notifier) DeletePreference(merchant string) error {
+	return n.config.Store.Delete(merchant)
+}
+
+// Stats returns the counts of each channel.
+func (n *Notifier) Stats() map[string]Stats {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	out := make(map[string]Stats, len(n.stats))
+	for channel, s := range n.stats {
+		out[channel] = *s
+	}
+	return out
+}
+
+// Handler returns the durable event handler of a channel. A failed send is
+// retried by the bus; a message over the rate limit is dropped.
+func (n *Notifier) Handler(channel string) events.Handler {
+	var sender Sender
+	switch channel {
+	case ChannelEmail:
+		sender = n.config.Email
+	case ChannelSMS:
+		sender = n.config.SMS
+	}
+	return func(ctx context.Context, e events.Event) error {
+		if sender == nil {
+			return nil
+		}
+		merchant := Merchant(e)
+		if merchant == "" {
+			return nil
+		}
+		p, err := n.config.Store.Get(merchant)
+		if errors.Is(err, ErrPreferenceNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		to := p.address(channel)
+		if to == "" || !p.wants(channel, e.Type) {
+			return nil
+		}
+
+		if !n.allow(channel, merchant) {
+			log.Printf("notify: %s to %s suppressed by rate limit (%s)", channel, merchant, e.Type)
+			return nil
+		}
+		msg, err := n.render(channel, e, merchant)
+		if err != nil {
+			// A broken template won't fix itself on retry
+			log.Printf("notify: failed to render %s: %v", e.Type, err)
+			return nil
+		}
+		if err := sender.Send(ctx, to, msg); err != nil {
+			n.mu.Lock()
+			n.stats[channel].Failed++
+			n.mu.Unlock()
+			return fmt.Errorf("%s to %s: %w", channel, merchant, err)
+		}
+		n.record(channel, merchant)
+		return nil
+	}
+}
+
+// allow reports whether the merchant is under the channel's hourly limit.
+// Only sent messages count, so retries of a failed send are not penalized.
+func (n *Notifier) allow(channel, merchant string) bool {
+	max := n.limits[channel]
+	if max == 0 {
+		return true
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	key := channel + ":" + merchant
+	cutoff := time.Now().Add(-time.Hour)
+	recent := n.sent[key]
+	for len(recent) > 0 && recent[0].Before(cutoff) {
+		recent = recent[1:]
+	}
+	if len(recent) == 0 {
+		delete(n.sent, key)
+	} else {
+		n.sent[key] = recent
+	}
+	if len(recent) >= max {
+		n.stats[channel].Suppressed++
+		return false
+	}
+	return true
+}
+
+func (n *Notifier) record(channel, merchant string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.limits[channel] > 0 {
+		key := channel + ":" + merchant
+		n.sent[key] = append(n.sent[key], time.Now())
+	}
+	n.stats[channel].Sent++
+}
+
+// Merchant returns the wallet an event's payment was made to, or "" for
+// events not about a merchant's payment.
+func Merchant(e events.Event) string {
+	switch {
+	case strings.HasPrefix(e.Type, "settlement."):
+		var j settlement.Job
+		if e.Decode(&j) == nil {
+			return j.Request.PaymentRequirements.PayTo
+		}
+	case strings.HasPrefix(e.Type, "checkout."):
+		var s checkout.Session
+		if e.Decode(&s) == nil {
+			return s.Recipient
+		}
+	}
+	return ""
+}
+
+// Template renders an event with text/template. The data is TemplateData.
+type Template struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+	SMS     string `json:"sms,omitempty"` // Defaults to Body
+}
+
+// TemplateData is what templates render.
+type TemplateData struct {
+	Type     string         // Event type
+	ID       string         // Event ID
+	Time     time.Time      // Event time, UTC
+	Merchant string         // Wallet paid
+	Data     map[string]any // The event payload, as in webhooks
+}
+
+// DefaultTemplates are used for event types without a configured template.
+// The "*" template renders any other type.
+var DefaultTemplates = map[string]Template{
+	events.SettlementSucceeded: {
+		Subject: "Payment of {{.Data.request.paymentRequirements.maxAmountRequired}} SOL settled",
+		Body: "A payment of {{.Data.request.paymentRequirements.maxAmountRequired}} SOL to {{short .Merchant}} settled on {{.Time.Format \"Jan 2, 2006 15:04 MST\"}}." +
+			"{{with .Data.result}}{{with .tx_sig}}\n\nTransaction: {{.}}{{end}}{{end}}",
+		SMS: "ShadowPay: {{.Data.request.paymentRequirements.maxAmountRequired}} SOL payment to {{short .Merchant}} settled.",
+	},
+	events.SettlementFailed: {
+		Subject: "Payment of {{.Data.request.paymentRequirements.maxAmountRequired}} SOL failed to settle",
+		Body: "A payment of {{.Data.request.paymentRequirements.maxAmountRequired}} SOL to {{short .Merchant}} failed to settle after {{.Data.attempts}} attempts." +
+			"{{with .Data.last_error}}\n\nError: {{.}}{{end}}",
+		SMS: "ShadowPay: {{.Data.request.paymentRequirements.maxAmountRequired}} SOL payment to {{short .Merchant}} failed to settle.",
+	},
+	events.CheckoutCompleted: {
+		Subject: "Checkout paid: {{sol .Data.amount}} SOL",
+		Body: "Checkout session {{.Data.id}} was paid: {{sol .Data.amount}} SOL to {{short .Merchant}}." +
+			"{{with .Data.description}}\n\n{{.}}{{end}}{{with .Data.reference}}\nReference: {{.}}{{end}}{{with .Data.tx_signature}}\nTransaction: {{.}}{{end}}",
+		SMS: "ShadowPay: checkout paid, {{sol .Data.amount}} SOL{{with .Data.reference}} ({{.}}){{end}}.",
+	},
+	"*": {
+		Subject: "ShadowPay: {{.Type}}",
+		Body:    "{{.Type}} for {{short .Merchant}} at {{.Time.Format \"Jan 2, 2006 15:04 MST\"}}.",
+		SMS:     "ShadowPay: {{.Type}} for {{short .Merchant}}.",
+	},
+}
+
+var templateFuncs = template.FuncMap{
+	// sol formats a lamport amount from a payload as SOL
+	"sol": func(v any) string {
+		switch n := v.(type) {
+		case float64:
+			return strconv.FormatFloat(n/1e9, 'f', -1, 64)
+		case json.Number:
+			f, _ := n.Float64()
+			return strconv.FormatFloat(f/1e9, 'f', -1, 64)
+		default:
+			return fmt.Sprint(v)
+		}
+	},
+	// short abbreviates an address
+	"short": func(s string) string {
+		if len(s) <= 12 {
+			return s
+		}
+		return s[:4] + "..." + s[len(s)-4:]
+	},
+}
+
+type compiled struct {
+	subject, body, sms *template.Template
+}
+
+func (t Template) compile(eventType string) (*compiled, error) {
+	if t.SMS == "" {
+		t.SMS = t.Body
+	}
+	var c compiled
+	var err error
+	parse := func(part, text string) *template.Template {
+		if err != nil {
+			return nil
+		}
+		var tmpl *template.Template
+		tmpl, err = template.New(eventType + "." + part).Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			err = fmt.Errorf("template %s: %w", eventType, err)
+		}
+		return tmpl
+	}
+	c.subject = parse("subject", t.Subject)
+	c.body = parse("body", t.Body)
+	c.sms = parse("sms", t.SMS)
+	return &c, err
+}
+
+// Render renders an event for a merchant with the event type's template.
+// Body holds the email body; use RenderSMS for the text message.
+func (n *Notifier) Render(e events.Event, merchant string) (Message, error) {
+	c, data, err := n.prepare(e, merchant)
+	if err != nil {
+		return Message{}, err
+	}
+	subject, err := execute(c.subject, data)
+	if err != nil {
+		return Message{}, err
+	}
+	body, err := execute(c.body, data)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{Subject: oneLine(subject), Body: body}, nil
+}
+
+// RenderSMS renders an event's text message for a merchant.
+func (n *Notifier) RenderSMS(e events.Event, merchant string) (string, error) {
+	c, data, err := n.prepare(e, merchant)
+	if err != nil {
+		return "", err
+	}
+	return execute(c.sms, data)
+}
+
+func (n *Notifier) render(channel string, e events.Event, merchant string) (Message, error) {
+	if channel != ChannelSMS {
+		return n.Render(e, merchant)
+	}
+	text, err := n.RenderSMS(e, merchant)
+	return Message{Body: truncate(text, maxSMSLength)}, err
+}
+
+func (n *Notifier) prepare(e events.Event, merchant string) (*compiled, *TemplateData, error) {
+	c, ok := n.templates[e.Type]
+	if !ok {
+		c = n.templates["*"]
+	}
+	data := &TemplateData{Type: e.Type, ID: e.ID, Time: time.Unix(e.Timestamp, 0).UTC(), Merchant: merchant}
+	if len(e.Data) > 0 {
+		if err := json.Unmarshal(e.Data, &data.Data); err != nil {
+			return nil, nil, err
+		}
+	}
+	return c, data, nil
+}
+
+func execute(t *template.Template, data *TemplateData) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(buf.String(), "<no value>", ""), nil
+}
+
+// oneLine keeps a subject to one line, so it cannot inject mail headers.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,148 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SMTPConfig holds SMTP email configuration.
+type SMTPConfig struct {
+	Host     string // Required
+	Port     string // Defaults to 587
+	Username string // Authenticates with PLAIN when set, which needs TLS
+	Password string
+	From     string // Required, e.g. "ShadowPay <payments@example.com>"
+}
+
+// SMTP sends email through an SMTP server, upgrading to TLS when it offers STARTTLS.
+type SMTP struct {
+	config SMTPConfig
+}
+
+// NewSMTP creates an SMTP sender.
+func NewSMTP(config SMTPConfig) (*SMTP, error) {
+	if config.Host == "" || config.From == "" {
+		return nil, fmt.Errorf("SMTP host and sender address are required")
+	}
+	if strings.ContainsAny(config.From, "\r\n") {
+		return nil, fmt.Errorf("invalid SMTP sender address")
+	}
+	if config.Port == "" {
+		config.Port = "587"
+	}
+	return &SMTP{config: config}, nil
+}
+
+// Send emails msg to the address to.
+func (s *SMTP) Send(ctx context.Context, to string, msg Message) error {
+	if strings.ContainsAny(to, "\r\n") {
+		return fmt.Errorf("invalid recipient address")
+	}
+	var auth smtp.Auth
+	if s.config.Username != "" {
+		auth = smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+	}
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "From: %s\r\n", s.config.From)
+	fmt.Fprintf(&body, "To: %s\r\n", to)
+	fmt.Fprintf(&body, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", oneLine(msg.Subject)))
+	fmt.Fprintf(&body, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	body.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	body.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	body.WriteString("\r\n")
+
+	// net/smtp takes no context; run it aside so a hung server can't block past ctx
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(net.JoinHostPort(s.config.Host, s.config.Port), auth, envelope(s.config.From), []string{to}, body.Bytes())
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// envelope extracts the bare address of "Name <addr>".
+func envelope(from string) string {
+	if i := strings.LastIndex(from, "<"); i >= 0 {
+		return strings.TrimSuffix(from[i+1:], ">")
+	}
+	return from
+}
+
+// DefaultTwilioAPIURL is the Twilio REST API.
+const DefaultTwilioAPIURL = "https://api.twilio.com/2010-04-01"
+
+// TwilioConfig holds Twilio SMS configuration.
+type TwilioConfig struct {
+	AccountSID string       // Required
+	AuthToken  string       // Required
+	From       string       // Required, a Twilio number or messaging service SID (MG...)
+	APIURL     string       // Defaults to DefaultTwilioAPIURL
+	HTTPClient *http.Client // Defaults to a client with a 10s timeout
+}
+
+// Twilio sends text messages through Twilio.
+type Twilio struct {
+	config TwilioConfig
+}
+
+// NewTwilio creates a Twilio sender.
+func NewTwilio(config TwilioConfig) (*Twilio, error) {
+	if config.AccountSID == "" || config.AuthToken == "" || config.From == "" {
+		return nil, fmt.Errorf("twilio account SID, auth token and sender are required")
+	}
+	if config.APIURL == "" {
+		config.APIURL = DefaultTwilioAPIURL
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	config.APIURL = strings.TrimRight(config.APIURL, "/")
+	return &Twilio{config: config}, nil
+}
+
+// Send texts msg.Body to the number to.
+func (t *Twilio) Send(ctx context.Context, to string, msg Message) error {
+	form := url.Values{"To": {to}, "Body": {msg.Body}}
+	if strings.HasPrefix(t.config.From, "MG") {
+		form.Set("MessagingServiceSid", t.config.From)
+	} else {
+		form.Set("From", t.config.From)
+	}
+	endpoint := t.config.APIURL + "/Accounts/" + url.PathEscape(t.config.AccountSID) + "/Messages.json"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(t.config.AccountSID, t.config.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := t.config.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		var out struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&out)
+		return fmt.Errorf("twilio: status %d: %s (code %d)", resp.StatusCode, out.Message, out.Code)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package notify
+
+import (
+	"sol_privacy/internal/jsonfile"
+)
+
+// Store persists merchants' notification preferences.
+type Store interface {
+	Get(merchant string) (*Preference, error) // Returns ErrPreferenceNotFound for unknown merchants
+	Put(p *Preference) error
+	Delete(merchant string) error
+	List() ([]*Preference, error)
+}
+
+// NewMemoryStore creates an empty in-memory Store.
+func NewMemoryStore() Store {
+	return store{jsonfile.NewMemoryStore(preferenceKey, nil)}
+}
+
+// NewFileStore opens a Store persisted to a JSON file after every write,
+// creating it on first write.
+func NewFileStore(path string) (Store, error) {
+	prefs, err := jsonfile.NewStore(path, preferenceKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	return store{prefs}, nil
+}
+
+func preferenceKey(p *Preference) string { return p.Merchant }
+
+// store keeps preferences in a jsonfile.Store.
+type store struct {
+	prefs *jsonfile.Store[Preference]
+}
+
+func (s store) Get(merchant string) (*Preference, error) {
+	p, ok := s.prefs.Get(merchant)
+	if !ok {
+		return nil, ErrPreferenceNotFound
+	}
+	return p, nil
+}
+
+func (s store) Put(p *Preference) error {
+	return s.prefs.Put(p)
+}
+
+func (s store) Delete(merchant string) error {
+	p, err := s.prefs.Delete(merchant)
+	if p == nil && err == nil {
+		return ErrPreferenceNotFound
+	}
+	return err
+}
+
+// List returns all preferences, by merchant.
+func (s store) List() ([]*Preference, error) {
+	return s.prefs.List(func(a, b *Preference) bool { return a.Merchant < b.Merchant }), nil
+}
@@ -0,0 +1,76 @@
+package nullifier
+
+import (
+	"sort"
+
+	"sol_privacy/internal/merkle"
+	"sol_privacy/internal/receipt"
+)
+
+// DoubleSpend is a nullifier hash consumed more than once.
+type DoubleSpend struct {
+	Hash       string   `json:"hash"`
+	ReceiptIDs []string `json:"receipt_ids"`
+}
+
+// Report is the outcome of cross-checking receipts against the set.
+type Report struct {
+	Nullifiers int `json:"nullifiers"`
+	Receipts   int `json:"receipts"`
+
+	DoubleSpends   []DoubleSpend `json:"double_spends,omitempty"`
+	Unbacked       []string      `json:"unbacked,omitempty"`        // Receipts no consumed nullifier pays for
+	SharedReceipts []string      `json:"shared_receipts,omitempty"` // Receipts claimed by several nullifiers
+	Invalid        []string      `json:"invalid,omitempty"`         // Entries whose hash does not parse
+}
+
+// OK reports whether the audit found nothing wrong.
+func (r *Report) OK() bool {
+	return len(r.DoubleSpends) == 0 && len(r.Unbacked) == 0 && len(r.SharedReceipts) == 0 && len(r.Invalid) == 0
+}
+
+// Audit cross-checks receipts against the consumed-nullifier set: no
+// nullifier may be consumed twice, and every receipt must be paid for by
+// exactly one consumed nullifier. Receipts may be a subset, e.g. one
+// merchant's; entries for receipts not given are only checked for double
+// spends.
+func Audit(set *Set, receipts []receipt.Receipt) *Report {
+	r := &Report{Nullifiers: len(set.Entries), Receipts: len(receipts)}
+
+	byHash := make(map[string][]string) // Canonical hash to receipt IDs
+	byReceipt := make(map[string]int)
+	for _, e := range set.Entries {
+		n, err := merkle.ParseElement(e.Hash)
+		if err != nil {
+			r.Invalid = append(r.Invalid, e.Hash)
+			continue
+		}
+		key := merkle.FormatElement(n) // Hex and decimal encodings of a hash are the same nullifier
+		byHash[key] = append(byHash[key], e.ReceiptID)
+		byReceipt[e.ReceiptID]++
+	}
+
+	for hash, ids := range byHash {
+		if len(ids) > 1 {
+			r.DoubleSpends = append(r.DoubleSpends, DoubleSpend{Hash: hash, ReceiptIDs: ids})
+		}
+	}
+	sort.Slice(r.DoubleSpends, func(i, j int) bool { return r.DoubleSpends[i].Hash < r.DoubleSpends[j].Hash })
+
+	seen := make(map[string]bool, len(receipts))
+	for _, rec := range receipts {
+		id := rec.Body.ID
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		switch byReceipt[id] {
+		case 0:
+			r.Unbacked = append(r.Unbacked, id)
+		case 1:
+		default:
+			r.SharedReceipts = append(r.SharedReceipts, id)
+		}
+	}
+	return r
+}
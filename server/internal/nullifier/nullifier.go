@@ -0,0 +1,165 @@
+// Package nullifier exports the set of consumed nullifiers so third-party
+// auditors can check independently that no payment was spent twice.
+//
+// The set holds nullifier hashes, Poseidon(nullifier), which are already
+// public on-chain; the nullifiers themselves never leave the payer. Pages
+// carry a running digest of the entries so far, so auditors who each export
+// the set can confirm they were shown the same one.
+package nullifier
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"sol_privacy/internal/merkle"
+	"sol_privacy/internal/types"
+)
+
+// MaxPageSize is the most entries the API returns per page.
+const MaxPageSize = 1000
+
+// ErrDigestMismatch is returned by ExportAll when a page's digest does not
+// match the entries received, i.e. the export was altered or pages were
+// skipped.
+var ErrDigestMismatch = errors.New("nullifier set digest mismatch")
+
+// Service handles nullifier set exports.
+type Service struct {
+	doRequest func(ctx context.Context, method, path string, body, result interface{}) error
+	caps      types.Capabilities
+}
+
+// NewService creates a new nullifier service.
+func NewService(doRequest func(ctx context.Context, method, path string, body, result interface{}) error) *Service {
+	return &Service{
+		doRequest: doRequest,
+	}
+}
+
+// SetCapabilities enables exports if the API advertises them. Call it before
+// using the service.
+func (s *Service) SetCapabilities(c types.Capabilities) {
+	s.caps = c
+}
+
+// Entry is a consumed nullifier.
+type Entry struct {
+	Hash      string `json:"hash"`       // Poseidon(nullifier), 0x-prefixed hex
+	ReceiptID string `json:"receipt_id"` // Receipt of the payment that consumed it
+	TxSig     string `json:"tx_sig,omitempty"`
+	SpentAt   int64  `json:"spent_at"` // Unix timestamp
+}
+
+// ExportRequest selects a page of the set, in the order nullifiers were consumed.
+type ExportRequest struct {
+	Cursor string `json:"cursor,omitempty"` // NextCursor of the previous page; empty for the first
+	Limit  int    `json:"limit,omitempty"`  // Defaults to the API's page size, at most MaxPageSize
+}
+
+// ExportPage is a page of the consumed-nullifier set.
+type ExportPage struct {
+	Entries    []Entry `json:"entries"`
+	NextCursor string  `json:"next_cursor,omitempty"` // Empty on the last page
+	Total      int     `json:"total"`                 // Entries in the whole set
+	Digest     string  `json:"digest"`                // Digest of every entry up to this page's last
+}
+
+// Set is the whole consumed-nullifier set.
+type Set struct {
+	Entries []Entry `json:"entries"`
+	Digest  string  `json:"digest"`
+}
+
+// EmptyDigest is the digest of an empty set.
+var EmptyDigest = hex.EncodeToString(make([]byte, sha256.Size))
+
+// NextDigest chains an entry onto the digest of the entries before it:
+// SHA-256(previous digest || nullifier hash || receipt ID), over the raw
+// 32-byte digest and hash. Start from EmptyDigest.
+func NextDigest(prev string, e Entry) (string, error) {
+	p, err := hex.DecodeString(prev)
+	if err != nil || len(p) != sha256.Size {
+		return "", fmt.Errorf("invalid digest %q", prev)
+	}
+	n, err := merkle.ParseElement(e.Hash)
+	if err != nil {
+		return "", err
+	}
+	if n.Sign() < 0 || n.BitLen() > 256 {
+		return "", fmt.Errorf("nullifier hash %q is not a 32-byte value", e.Hash)
+	}
+	h := sha256.New()
+	h.Write(p)
+	h.Write(n.FillBytes(make([]byte, 32)))
+	h.Write([]byte(e.ReceiptID))
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Digest returns the digest of entries, as the export reports it.
+func Digest(entries []Entry) (string, error) {
+	d := EmptyDigest
+	for _, e := range entries {
+		var err error
+		if d, err = NextDigest(d, e); err != nil {
+			return "", err
+		}
+	}
+	return d, nil
+}
+
+// Export retrieves a page of the consumed-nullifier set.
+func (s *Service) Export(ctx context.Context, req ExportRequest) (*ExportPage, error) {
+	if req.Limit < 0 || req.Limit > MaxPageSize {
+		return nil, fmt.Errorf("limit must be between 1 and %d", MaxPageSize)
+	}
+	if err := types.Require(ctx, s.caps, types.FeatureNullifiers); err != nil {
+		return nil, err
+	}
+	q := url.Values{}
+	if req.Cursor != "" {
+		q.Set("cursor", req.Cursor)
+	}
+	if req.Limit > 0 {
+		q.Set("limit", strconv.Itoa(req.Limit))
+	}
+	path := "/shadowpay/api/nullifiers"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	var resp ExportPage
+	if err := s.doRequest(ctx, "GET", path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ExportAll retrieves the whole set page by page, checking each page's
+// digest against the entries received so far.
+func (s *Service) ExportAll(ctx context.Context) (*Set, error) {
+	set := &Set{Digest: EmptyDigest}
+	req := ExportRequest{Limit: MaxPageSize}
+	for {
+		page, err := s.Export(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range page.Entries {
+			if set.Digest, err = NextDigest(set.Digest, e); err != nil {
+				return nil, err
+			}
+		}
+		set.Entries = append(set.Entries, page.Entries...)
+		if page.Digest != set.Digest {
+			return nil, fmt.Errorf("%w after %d entries", ErrDigestMismatch, len(set.Entries))
+		}
+		if page.NextCursor == "" || len(page.Entries) == 0 {
+			return set, nil
+		}
+		req.Cursor = page.NextCursor
+	}
+}
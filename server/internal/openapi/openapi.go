@@ -0,0 +1,223 @@
+// Package openapi reads the request body schemas of the proxy's OpenAPI
+// document, so the proxy can check bodies against the spec it publishes
+// instead of a second, hand-written copy of it.
+//
+// Only what request validation needs is read: paths, operations, JSON
+// request bodies and the schema keywords in Schema. Everything else in the
+// document is ignored.
+package openapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Operation is a method on a path of the spec.
+type Operation struct {
+	Method       string // Upper case, e.g. "POST"
+	Path         string // Spec path, e.g. "/invoices/{id}"
+	Body         *Schema
+	BodyRequired bool
+
+	segments []string
+}
+
+// Spec is a parsed OpenAPI document.
+type Spec struct {
+	operations []*Operation
+}
+
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch"}
+
+// Parse reads an OpenAPI 3 document in YAML.
+func Parse(data []byte) (*Spec, error) {
+	root, err := parseYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	doc, _ := root.(map[string]any)
+	b := &builder{doc: doc, refs: make(map[string]*Schema)}
+
+	spec := &Spec{}
+	paths, _ := doc["paths"].(map[string]any)
+	for path, item := range paths {
+		item, _ := item.(map[string]any)
+		for _, method := range methods {
+			op, ok := item[method].(map[string]any)
+			if !ok {
+				continue
+			}
+			o := &Operation{
+				Method:   strings.ToUpper(method),
+				Path:     path,
+				segments: strings.Split(strings.Trim(path, "/"), "/"),
+			}
+			if body, ok := op["requestBody"].(map[string]any); ok {
+				if body, err = b.resolve(body, "requestBodies"); err != nil {
+					return nil, fmt.Errorf("openapi: %s %s: %w", o.Method, path, err)
+				}
+				o.BodyRequired = body["required"] == "true"
+				content, _ := body["content"].(map[string]any)
+				if media, ok := content["application/json"].(map[string]any); ok {
+					if o.Body, err = b.schema(media["schema"]); err != nil {
+						return nil, fmt.Errorf("openapi: %s %s: %w", o.Method, path, err)
+					}
+				}
+			}
+			spec.operations = append(spec.operations, o)
+		}
+	}
+	return spec, nil
+}
+
+// Find returns the operation serving method and path, such as "POST" and
+// "/invoices/inv_1/void", or nil. Literal path segments win over
+// parameters, so "/invoices/webhook" is not read as an invoice ID.
+func (s *Spec) Find(method, path string) *Operation {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	var best *Operation
+	bestLiterals := -1
+	for _, op := range s.operations {
+		if op.Method != method || len(op.segments) != len(segments) {
+			continue
+		}
+		literals := 0
+		for i, seg := range op.segments {
+			if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+				continue
+			}
+			if seg != segments[i] {
+				literals = -1
+				break
+			}
+			literals++
+		}
+		if literals > bestLiterals {
+			best, bestLiterals = op, literals
+		}
+	}
+	return best
+}
+
+// builder turns schema objects into Schemas, resolving references.
+type builder struct {
+	doc  map[string]any
+	refs map[string]*Schema
+}
+
+// resolve follows a $ref to a component of the given kind.
+func (b *builder) resolve(obj map[string]any, kind string) (map[string]any, error) {
+	ref, ok := obj["$ref"].(string)
+	if !ok {
+		return obj, nil
+	}
+	name, ok := strings.CutPrefix(ref, "#/components/"+kind+"/")
+	if !ok {
+		return nil, fmt.Errorf("unsupported reference %s", ref)
+	}
+	components, _ := b.doc["components"].(map[string]any)
+	group, _ := components[kind].(map[string]any)
+	target, ok := group[name].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("unresolved reference %s", ref)
+	}
+	return target, nil
+}
+
+func (b *builder) schema(v any) (*Schema, error) {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return &Schema{}, nil
+	}
+	if ref, ok := obj["$ref"].(string); ok {
+		if s, ok := b.refs[ref]; ok {
+			return s, nil
+		}
+		target, err := b.resolve(obj, "schemas")
+		if err != nil {
+			return nil, err
+		}
+		// Registered before it is filled in, for schemas that refer to themselves
+		s := &Schema{}
+		b.refs[ref] = s
+		built, err := b.schema(target)
+		if err != nil {
+			return nil, err
+		}
+		*s = *built
+		return s, nil
+	}
+
+	s := &Schema{
+		Type:     str(obj["type"]),
+		Nullable: obj["nullable"] == "true",
+		ReadOnly: obj["readOnly"] == "true",
+		Required: strs(obj["required"]),
+		Enum:     strs(obj["enum"]),
+	}
+	var err error
+	for key, p := range map[string]**float64{"minimum": &s.Minimum, "maximum": &s.Maximum} {
+		if v, ok := obj[key].(string); ok {
+			f, perr := strconv.ParseFloat(v, 64)
+			if perr != nil {
+				return nil, fmt.Errorf("invalid %s %q", key, v)
+			}
+			*p = &f
+		}
+	}
+	for key, p := range map[string]**int{"minLength": &s.MinLength, "maxLength": &s.MaxLength} {
+		if v, ok := obj[key].(string); ok {
+			n, perr := strconv.Atoi(v)
+			if perr != nil {
+				return nil, fmt.Errorf("invalid %s %q", key, v)
+			}
+			*p = &n
+		}
+	}
+	if props, ok := obj["properties"].(map[string]any); ok {
+		s.Properties = make(map[string]*Schema, len(props))
+		for name, p := range props {
+			if s.Properties[name], err = b.schema(p); err != nil {
+				return nil, err
+			}
+		}
+	}
+	// additionalProperties: true is the default; only schemas constrain
+	if _, ok := obj["additionalProperties"].(map[string]any); ok {
+		if s.AdditionalProperties, err = b.schema(obj["additionalProperties"]); err != nil {
+			return nil, err
+		}
+	}
+	if items, ok := obj["items"]; ok {
+		if s.Items, err = b.schema(items); err != nil {
+			return nil, err
+		}
+	}
+	if all, ok := obj["allOf"].([]any); ok {
+		for _, sub := range all {
+			built, err := b.schema(sub)
+			if err != nil {
+				return nil, err
+			}
+			s.AllOf = append(s.AllOf, built)
+		}
+	}
+	return s, nil
+}
+
+func str(v any) string {
+	s, _ := v.(string)
+	return s
+}
+
+func strs(v any) []string {
+	items, _ := v.([]any)
+	var out []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
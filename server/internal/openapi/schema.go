@@ -0,0 +1,165 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"sol_privacy/internal/validate"
+)
+
+// Schema is the part of an OpenAPI schema object used to check request
+// bodies. References are resolved when the spec is parsed.
+type Schema struct {
+	Type                 string // "object", "array", "string", "integer", "number", "boolean" or "" for any
+	Nullable             bool
+	ReadOnly             bool // Ignored in requests
+	Required             []string
+	Properties           map[string]*Schema
+	AdditionalProperties *Schema // Checks properties not in Properties, if set
+	Items                *Schema
+	AllOf                []*Schema
+	Enum                 []string
+	Minimum, Maximum     *float64
+	MinLength, MaxLength *int
+}
+
+// Validate checks a JSON value, decoded with json.Decoder.UseNumber, against
+// the schema. Errors name fields by path, e.g. "line_items[0].unit_price".
+func (s *Schema) Validate(v any) validate.Errors {
+	var errs validate.Errors
+	s.check("", v, &errs)
+	return errs
+}
+
+func (s *Schema) check(path string, v any, errs *validate.Errors) {
+	add := func(format string, args ...any) {
+		field := path
+		if field == "" {
+			field = "body"
+		}
+		*errs = append(*errs, validate.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, sub := range s.AllOf {
+		sub.check(path, v, errs)
+	}
+	if v == nil {
+		if !s.Nullable && s.Type != "" {
+			add("must not be null")
+		}
+		return
+	}
+	if s.Type != "" && jsonType(v, s.Type) != s.Type {
+		add("must be %s", article(s.Type))
+		return
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if p := s.Properties[name]; p != nil && p.ReadOnly {
+				continue
+			}
+			if _, ok := v[name]; !ok {
+				*errs = append(*errs, validate.FieldError{Field: join(path, name), Message: "is required"})
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			p := s.Properties[name]
+			if p == nil {
+				p = s.AdditionalProperties
+			}
+			if p != nil && !p.ReadOnly {
+				p.check(join(path, name), v[name], errs)
+			}
+		}
+	case []any:
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.check(fmt.Sprintf("%s[%d]", path, i), item, errs)
+			}
+		}
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.MinLength != nil && n < *s.MinLength {
+			add("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			add("must be at most %d characters", *s.MaxLength)
+		}
+		if len(s.Enum) > 0 && !contains(s.Enum, v) {
+			add("must be one of %s", strings.Join(s.Enum, ", "))
+		}
+	case json.Number:
+		f, _ := v.Float64()
+		if s.Minimum != nil && f < *s.Minimum {
+			add("must be at least %s", formatNumber(*s.Minimum))
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			add("must be at most %s", formatNumber(*s.Maximum))
+		}
+		if len(s.Enum) > 0 && !contains(s.Enum, v.String()) {
+			add("must be one of %s", strings.Join(s.Enum, ", "))
+		}
+	}
+}
+
+// jsonType names the schema type of a decoded JSON value. Numbers without a
+// fraction or exponent are integers, which want also accepts as numbers.
+func jsonType(v any, want string) string {
+	switch v := v.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := strconv.ParseInt(v.String(), 10, 64); err == nil && want != "number" {
+			return "integer"
+		}
+		if _, err := v.Float64(); err == nil {
+			return "number"
+		}
+	}
+	return ""
+}
+
+func article(typ string) string {
+	switch typ {
+	case "object", "array", "integer":
+		return "an " + typ
+	}
+	return "a " + typ
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
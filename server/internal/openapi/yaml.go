@@ -0,0 +1,275 @@
+package openapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseYAML reads the subset of YAML used by the spec into maps, slices and
+// string scalars: nested mappings, block sequences (including sequences of
+// mappings), flow sequences of scalars ([a, b]), empty flow mappings ({}),
+// block scalars (| and >), comments and quoted strings.
+func parseYAML(data []byte) (any, error) {
+	p := &yamlParser{lines: strings.Split(string(data), "\n")}
+	if !p.skip() {
+		return map[string]any{}, nil
+	}
+	node, err := p.node(p.indent())
+	if err != nil {
+		return nil, err
+	}
+	if p.skip() {
+		return nil, p.errorf("unexpected content at indent %d", p.indent())
+	}
+	return node, nil
+}
+
+type yamlParser struct {
+	lines []string
+	pos   int
+}
+
+func (p *yamlParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("openapi: line %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+// skip moves to the next line with content, reporting whether there is one.
+func (p *yamlParser) skip() bool {
+	for ; p.pos < len(p.lines); p.pos++ {
+		line := strings.TrimSpace(stripComment(p.lines[p.pos]))
+		if line != "" && line != "---" {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *yamlParser) indent() int {
+	line := p.lines[p.pos]
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+func (p *yamlParser) content() string {
+	return strings.TrimSpace(stripComment(p.lines[p.pos]))
+}
+
+func isItem(content string) bool {
+	return content == "-" || strings.HasPrefix(content, "- ")
+}
+
+// node parses the mapping or sequence starting at the current line.
+func (p *yamlParser) node(indent int) (any, error) {
+	if strings.HasPrefix(p.lines[p.pos][indent:], "\t") {
+		return nil, p.errorf("tabs are not allowed for indentation")
+	}
+	if isItem(p.content()) {
+		return p.sequence(indent)
+	}
+	return p.mapping(indent)
+}
+
+func (p *yamlParser) mapping(indent int) (map[string]any, error) {
+	m := make(map[string]any)
+	for p.skip() && p.indent() == indent && !isItem(p.content()) {
+		key, value, err := cutKey(p.content())
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		if _, dup := m[key]; dup {
+			return nil, p.errorf("duplicate key %q", key)
+		}
+		p.pos++
+
+		switch {
+		case value == "|" || value == "|-" || value == ">" || value == ">-":
+			m[key] = p.blockScalar(indent, value[0] == '>')
+		case value != "":
+			if m[key], err = scalarOrFlow(value); err != nil {
+				return nil, p.errorf("%v", err)
+			}
+		case !p.skip():
+			m[key] = nil
+		case p.indent() > indent, p.indent() == indent && isItem(p.content()):
+			// A nested node; sequences may sit at their key's indent
+			if m[key], err = p.node(p.indent()); err != nil {
+				return nil, err
+			}
+		default:
+			m[key] = nil
+		}
+	}
+	if p.pos < len(p.lines) && p.indent() > indent {
+		return nil, p.errorf("unexpected indent")
+	}
+	return m, nil
+}
+
+func (p *yamlParser) sequence(indent int) ([]any, error) {
+	var items []any
+	for p.skip() && p.indent() == indent && isItem(p.content()) {
+		item := strings.TrimSpace(strings.TrimPrefix(p.content(), "-"))
+		switch {
+		case item == "":
+			p.pos++
+			if !p.skip() || p.indent() <= indent {
+				items = append(items, nil)
+				continue
+			}
+			v, err := p.node(p.indent())
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		case isKeyValue(item):
+			// "- key: value" starts a mapping indented past the dash
+			column := strings.Index(p.lines[p.pos], item)
+			p.lines[p.pos] = strings.Repeat(" ", column) + item
+			v, err := p.mapping(column)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		default:
+			v, err := scalarOrFlow(item)
+			if err != nil {
+				return nil, p.errorf("%v", err)
+			}
+			items = append(items, v)
+			p.pos++
+		}
+	}
+	return items, nil
+}
+
+// blockScalar reads the lines indented past indent. Literal (|) scalars keep
+// their line breaks; folded (>) ones join lines with spaces.
+func (p *yamlParser) blockScalar(indent int, folded bool) string {
+	var lines []string
+	base := -1
+	for ; p.pos < len(p.lines); p.pos++ {
+		line := strings.TrimRight(p.lines[p.pos], " \r")
+		if strings.TrimSpace(line) == "" {
+			lines = append(lines, "")
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " "))
+		if n <= indent {
+			break
+		}
+		if base < 0 {
+			base = n
+		}
+		lines = append(lines, line[min(base, n):])
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if folded {
+		return strings.Join(lines, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// cutKey splits "key: value", where the key may be quoted.
+func cutKey(content string) (key, value string, err error) {
+	if content[0] == '"' || content[0] == '\'' {
+		end := strings.IndexByte(content[1:], content[0])
+		if end < 0 {
+			return "", "", fmt.Errorf("unterminated key %s", content)
+		}
+		rest, ok := strings.CutPrefix(content[end+2:], ":")
+		if !ok {
+			return "", "", fmt.Errorf("expected \"key: value\"")
+		}
+		key, err = unquote(content[:end+2])
+		return key, strings.TrimSpace(rest), err
+	}
+	if k, ok := strings.CutSuffix(content, ":"); ok && !strings.Contains(k, ": ") {
+		return k, "", nil
+	}
+	key, value, ok := strings.Cut(content, ": ")
+	if !ok || strings.TrimSpace(key) == "" {
+		return "", "", fmt.Errorf("expected \"key: value\"")
+	}
+	return strings.TrimSpace(key), strings.TrimSpace(value), nil
+}
+
+// isKeyValue reports whether a sequence item is itself a mapping entry.
+func isKeyValue(item string) bool {
+	if item[0] == '[' || item[0] == '{' {
+		return false
+	}
+	if item[0] == '"' || item[0] == '\'' {
+		end := strings.IndexByte(item[1:], item[0])
+		return end >= 0 && strings.HasPrefix(item[end+2:], ":")
+	}
+	return strings.HasSuffix(item, ":") || strings.Contains(item, ": ")
+}
+
+// scalarOrFlow parses an inline value: a scalar, a flow sequence of scalars
+// or an empty flow mapping.
+func scalarOrFlow(value string) (any, error) {
+	switch {
+	case value == "{}":
+		return map[string]any{}, nil
+	case strings.HasPrefix(value, "{"):
+		return nil, fmt.Errorf("flow mappings are not supported")
+	case strings.HasPrefix(value, "["):
+		if !strings.HasSuffix(value, "]") {
+			return nil, fmt.Errorf("unterminated sequence")
+		}
+		items := []any{}
+		if inner := strings.TrimSpace(value[1 : len(value)-1]); inner != "" {
+			for _, item := range strings.Split(inner, ",") {
+				v, err := unquote(strings.TrimSpace(item))
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, v)
+			}
+		}
+		return items, nil
+	}
+	return unquote(value)
+}
+
+// stripComment removes a trailing "# comment" outside of quotes.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' {
+				i++
+			}
+		case c == '"' || c == '\'':
+			// Apostrophes inside plain scalars do not start quotes
+			if i == 0 || line[i-1] == ' ' || line[i-1] == '[' || line[i-1] == ',' {
+				quote = c
+			}
+		case c == '#' && (i == 0 || line[i-1] == ' '):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// unquote returns a scalar without its single or double quotes.
+func unquote(s string) (string, error) {
+	switch {
+	case len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"':
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return "", fmt.Errorf("invalid quoted string %s", s)
+		}
+		return v, nil
+	case len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'':
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	case s == "~" || s == "null":
+		return "", nil
+	}
+	return s, nil
+}
@@ -0,0 +1,249 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/types"
+)
+
+// Headers the x402 middleware reads the access token from and returns a
+// refreshed one in.
+const (
+	HeaderAccessToken        = "X-Access-Token"
+	HeaderAccessTokenExpires = "X-Access-Token-Expires-At" // Unix seconds
+)
+
+// retryRefresh is how long a failed background refresh waits before trying again.
+const retryRefresh = 5 * time.Second
+
+// MiddlewareOptions configures RequireAccess.
+type MiddlewareOptions struct {
+	// RefreshBefore refreshes tokens expiring within it, defaults to 1 minute.
+	RefreshBefore time.Duration
+	// DisableAutoRefresh serves requests with the token as presented, for
+	// endpoints that must be paid for again once it expires.
+	DisableAutoRefresh bool
+	// Accepts are the payment options offered in 402 responses, so x402
+	// clients can pay without knowing the price in advance. Build them with
+	// x402.NewRequirements.
+	Accepts []Requirements
+}
+
+// Access is the paid access behind a request, kept current by the x402
+// middleware while the request runs.
+type Access struct {
+	Commitment string
+	Merchant   string
+	Amount     int64
+	Scope      []string // Granted path patterns; empty means the whole API
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time // Zero when the API did not report an expiry
+	refreshed chan struct{}
+}
+
+// Token returns the current access token.
+func (a *Access) Token() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.token
+}
+
+// ExpiresAt returns when the current token expires, or zero if unknown.
+func (a *Access) ExpiresAt() time.Time {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.expiresAt
+}
+
+// Refreshed is signaled after the token is refreshed, so a streaming handler
+// can forward the new token to its client, e.g. as a server-sent event.
+func (a *Access) Refreshed() <-chan struct{} {
+	return a.refreshed
+}
+
+func (a *Access) set(resp *AuthorizeResponse, notify bool) {
+	a.mu.Lock()
+	a.token = resp.AccessToken
+	a.expiresAt = time.Time{}
+	if resp.ExpiresIn > 0 {
+		a.expiresAt = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	}
+	a.mu.Unlock()
+	if !notify {
+		return
+	}
+	select {
+	case a.refreshed <- struct{}{}:
+	default:
+	}
+}
+
+type accessKey struct{}
+
+// AccessFromContext returns the access of a request that passed the x402
+// middleware, or nil.
+func AccessFromContext(ctx context.Context) *Access {
+	a, _ := ctx.Value(accessKey{}).(*Access)
+	return a
+}
+
+// RequireAccess returns x402 middleware that admits requests carrying a valid
+// access token from Authorize, as "Authorization: Bearer" or X-Access-Token,
+// and answers others with 402 Payment Required. Tokens whose scope does not
+// cover the request path get 403 Forbidden.
+//
+// Tokens about to expire are refreshed before the request is served and the
+// new token is returned in X-Access-Token. Requests that outlive their token,
+// such as streams, have it refreshed in the background; if that keeps failing
+// until the token expires, the request context is canceled.
+func (s *Service) RequireAccess(opts MiddlewareOptions) func(http.Handler) http.Handler {
+	if opts.RefreshBefore == 0 {
+		opts.RefreshBefore = time.Minute
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := accessToken(r)
+			if token == "" {
+				paymentRequired(w, "Access token required", opts.Accepts)
+				return
+			}
+			info, err := s.VerifyAccess(r.Context(), token)
+			if err != nil {
+				WriteError(w, http.StatusBadGateway, "Failed to verify access token")
+				return
+			}
+			if !info.Valid {
+				paymentRequired(w, "Access token is invalid or expired", opts.Accepts)
+				return
+			}
+			if !info.Allows(r.URL.Path) {
+				WriteError(w, http.StatusForbidden, "Access token does not cover this resource")
+				return
+			}
+
+			a := &Access{
+				Commitment: info.Commitment,
+				Merchant:   info.Merchant,
+				Amount:     info.Amount,
+				Scope:      info.Scope,
+				token:      token,
+				expiresAt:  parseExpiry(info.ExpiresAt),
+				refreshed:  make(chan struct{}, 1),
+			}
+			if opts.DisableAutoRefresh {
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), accessKey{}, a)))
+				return
+			}
+
+			// A failed refresh here is retried in the background; the token is still valid
+			if exp := a.ExpiresAt(); !exp.IsZero() && time.Until(exp) < opts.RefreshBefore {
+				if resp, err := s.RefreshAccess(r.Context(), token); err == nil && resp.AccessToken != "" {
+					a.set(resp, false) // The client gets this token in the headers
+					w.Header().Set(HeaderAccessToken, a.Token())
+					if exp := a.ExpiresAt(); !exp.IsZero() {
+						w.Header().Set(HeaderAccessTokenExpires, strconv.FormatInt(exp.Unix(), 10))
+					}
+				}
+			}
+
+			ctx, cancel := context.WithCancel(context.WithValue(r.Context(), accessKey{}, a))
+			defer cancel()
+			go s.keepAccess(ctx, cancel, a, opts.RefreshBefore)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// keepAccess refreshes the token shortly before it expires for as long as
+// the request runs, and cancels the request if it expires unrefreshed.
+func (s *Service) keepAccess(ctx context.Context, cancel context.CancelFunc, a *Access, refreshBefore time.Duration) {
+	failed := false
+	for {
+		exp := a.ExpiresAt()
+		if exp.IsZero() {
+			return
+		}
+		wait := max(time.Until(exp)-refreshBefore, 0)
+		if failed {
+			wait = min(retryRefresh, time.Until(exp))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		resp, err := s.RefreshAccess(ctx, a.Token())
+		if err == nil && resp.AccessToken != "" {
+			a.set(resp, true)
+			failed = false
+			continue
+		}
+		if !time.Now().Before(exp) {
+			cancel()
+			return
+		}
+		failed = true
+	}
+}
+
+// accessToken reads the token from the Authorization or X-Access-Token header.
+func accessToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return strings.TrimSpace(r.Header.Get(HeaderAccessToken))
+}
+
+// parseExpiry parses an expiry given as RFC 3339 or Unix seconds.
+func parseExpiry(s string) time.Time {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil && n > 0 {
+		return time.Unix(n, 0)
+	}
+	return time.Time{}
+}
+
+// PaymentRequired answers with 402 and a JSON error, as the x402 middleware
+// does for requests that need a new payment.
+func PaymentRequired(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="x402"`)
+	WriteError(w, http.StatusPaymentRequired, message)
+}
+
+// Challenge is the body of a 402 response that offers payment options.
+type Challenge struct {
+	X402Version int            `json:"x402Version"`
+	Error       string         `json:"error"`
+	Accepts     []Requirements `json:"accepts"`
+}
+
+// paymentRequired answers with 402 and, when there are payment options, an
+// x402 challenge listing them.
+func paymentRequired(w http.ResponseWriter, message string, accepts []Requirements) {
+	if len(accepts) == 0 {
+		PaymentRequired(w, message)
+		return
+	}
+	w.Header().Set("WWW-Authenticate", `Bearer realm="x402"`)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusPaymentRequired)
+	json.NewEncoder(w).Encode(Challenge{X402Version: types.DefaultX402Version, Error: message, Accepts: accepts})
+}
+
+// WriteError answers with a JSON error, as the x402 middleware does.
+func WriteError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,49 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+
+	"sol_privacy/internal/types"
+	"sol_privacy/internal/validate"
+)
+
+// HoldRequest identifies a payment settled with SettleRequest.Hold.
+type HoldRequest struct {
+	TxSig  string `json:"tx_sig"`           // Signature of the settlement
+	Reason string `json:"reason,omitempty"` // Recorded with refunds
+}
+
+// HoldResponse is the result of releasing or refunding a held payment.
+type HoldResponse struct {
+	Success bool   `json:"success"`
+	TxSig   string `json:"tx_sig"` // Signature of the release or refund
+	Message string `json:"message,omitempty"`
+}
+
+// Release unlocks a held payment's funds for the merchant.
+func (s *Service) Release(ctx context.Context, req HoldRequest) (*HoldResponse, error) {
+	return s.hold(ctx, "/shadowpay/v1/payment/release", req)
+}
+
+// Refund returns a held payment's funds to the payer's escrow.
+func (s *Service) Refund(ctx context.Context, req HoldRequest) (*HoldResponse, error) {
+	return s.hold(ctx, "/shadowpay/v1/payment/refund", req)
+}
+
+func (s *Service) hold(ctx context.Context, path string, req HoldRequest) (*HoldResponse, error) {
+	if err := validate.New().Required("tx_sig", req.TxSig).Err(); err != nil {
+		return nil, err
+	}
+	if err := types.Require(ctx, s.caps, types.FeatureHolds); err != nil {
+		return nil, err
+	}
+	var resp HoldResponse
+	if err := s.doRequest(ctx, "POST", path, req, &resp); err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("relayer declined: %s", resp.Message)
+	}
+	return &resp, nil
+}
@@ -2,11 +2,26 @@ package payment
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"sol_privacy/internal/solana"
+	"sol_privacy/internal/types"
+	"sol_privacy/internal/validate"
 )
 
 // Service handles ZK payment operations.
 type Service struct {
 	doRequest func(ctx context.Context, method, path string, body, result interface{}) error
+
+	feesMu sync.Mutex
+	fees   types.FeeEstimator
+
+	caps types.Capabilities
+
+	hooksMu   sync.Mutex
+	onRefresh []func(oldToken, newToken string)
 }
 
 // NewService creates a new payment service.
@@ -18,8 +33,18 @@ func NewService(doRequest func(ctx context.Context, method, path string, body, r
 
 // DepositRequest represents a request to deposit funds for ZK payments.
 type DepositRequest struct {
-	WalletAddress string `json:"wallet_address"`
-	Amount        int64  `json:"amount"` // Amount in lamports
+	WalletAddress types.Address `json:"wallet_address"`
+	Amount        int64 `json:"amount"` // Amount in lamports
+
+	ComputeBudget *types.ComputeBudget `json:"compute_budget,omitempty"` // Optional priority fee
+}
+
+// Validate checks the request fields.
+func (r DepositRequest) Validate() error {
+	return validate.New().
+		Add("wallet_address", r.WalletAddress.Validate()).
+		Amount("amount", r.Amount, 1, validate.MaxLamports).
+		Err()
 }
 
 // DepositResponse contains the unsigned transaction for deposit.
@@ -27,12 +52,37 @@ type DepositResponse struct {
 	UnsignedTxBase64      string `json:"unsigned_tx_base64"`
 	RecentBlockhash       string `json:"recent_blockhash"`
 	LastValidBlockHeight  int    `json:"last_valid_block_height"`
+
+	rebuild func(ctx context.Context) (*DepositResponse, error)
+}
+
+// Expired reports whether the deposit transaction's blockhash has aged out.
+func (r *DepositResponse) Expired(ctx context.Context, src types.BlockHeightSource) (bool, error) {
+	return types.BlockhashExpired(ctx, src, int64(r.LastValidBlockHeight))
+}
+
+// Rebuild re-requests a fresh deposit transaction with the same parameters.
+func (r *DepositResponse) Rebuild(ctx context.Context) (*DepositResponse, error) {
+	if r.rebuild == nil {
+		return nil, types.ErrNotRebuildable
+	}
+	return r.rebuild(ctx)
 }
 
 // WithdrawRequest represents a request to withdraw funds from payment account.
 type WithdrawRequest struct {
-	WalletAddress string `json:"wallet_address"`
-	Amount        int64  `json:"amount"` // Amount in lamports
+	WalletAddress types.Address `json:"wallet_address"`
+	Amount        int64 `json:"amount"` // Amount in lamports
+
+	ComputeBudget *types.ComputeBudget `json:"compute_budget,omitempty"` // Optional priority fee
+}
+
+// Validate checks the request fields.
+func (r WithdrawRequest) Validate() error {
+	return validate.New().
+		Add("wallet_address", r.WalletAddress.Validate()).
+		Amount("amount", r.Amount, 1, validate.MaxLamports).
+		Err()
 }
 
 // WithdrawResponse contains the unsigned transaction for withdrawal.
@@ -41,13 +91,37 @@ type WithdrawResponse struct {
 	RecentBlockhash       string `json:"recent_blockhash"`
 	LastValidBlockHeight  int    `json:"last_valid_block_height"`
 	Message               string `json:"message,omitempty"`
+
+	rebuild func(ctx context.Context) (*WithdrawResponse, error)
+}
+
+// Expired reports whether the withdrawal transaction's blockhash has aged out.
+func (r *WithdrawResponse) Expired(ctx context.Context, src types.BlockHeightSource) (bool, error) {
+	return types.BlockhashExpired(ctx, src, int64(r.LastValidBlockHeight))
+}
+
+// Rebuild re-requests a fresh withdrawal transaction with the same parameters.
+func (r *WithdrawResponse) Rebuild(ctx context.Context) (*WithdrawResponse, error) {
+	if r.rebuild == nil {
+		return nil, types.ErrNotRebuildable
+	}
+	return r.rebuild(ctx)
 }
 
 // PrepareRequest represents the data needed to prepare a ZK payment.
 type PrepareRequest struct {
-	ReceiverCommitment string `json:"receiver_commitment"` // Base58 or hex encoded
-	Amount             int64  `json:"amount"`
-	TokenMint          string `json:"token_mint,omitempty"` // Optional SPL token mint
+	ReceiverCommitment types.Commitment `json:"receiver_commitment"` // Base58 or hex encoded
+	Amount             int64    `json:"amount"`
+	TokenMint          string           `json:"token_mint,omitempty"` // Optional SPL token mint
+}
+
+// Validate checks the request fields.
+func (r PrepareRequest) Validate() error {
+	return validate.New().
+		Add("receiver_commitment", r.ReceiverCommitment.Validate()).
+		Amount("amount", r.Amount, 1, validate.MaxLamports).
+		OptionalAddress("token_mint", r.TokenMint).
+		Err()
 }
 
 // PrepareResponse contains the data for generating the proof client-side.
@@ -64,6 +138,12 @@ type SettleRequest struct {
 	PaymentHeader       string       `json:"paymentHeader"` // Base64 encoded JSON
 	Resource            string       `json:"resource"`
 	PaymentRequirements Requirements `json:"paymentRequirements"`
+
+	ComputeBudget *types.ComputeBudget `json:"computeBudget,omitempty"` // Optional priority fee
+
+	// Hold keeps the settled funds locked in the merchant's escrow until
+	// Release or Refund, for conditional payments.
+	Hold bool `json:"hold,omitempty"`
 }
 
 // Requirements details the constraints for the payment.
@@ -76,6 +156,9 @@ type Requirements struct {
 	MimeType          string `json:"mimeType"`
 	PayTo             string `json:"payTo"`
 	MaxTimeoutSeconds int    `json:"maxTimeoutSeconds"`
+
+	// Extra holds scheme-specific details, e.g. "receiverCommitment" for zkproof.
+	Extra map[string]any `json:"extra,omitempty"`
 }
 
 // SettleResponse represents the result of the settlement.
@@ -85,28 +168,72 @@ type SettleResponse struct {
 	Message string `json:"message"`
 }
 
+// SetFeeEstimator configures the estimator used for ComputeBudget auto mode.
+func (s *Service) SetFeeEstimator(e types.FeeEstimator) {
+	s.feesMu.Lock()
+	defer s.feesMu.Unlock()
+	s.fees = e
+}
+
+// SetCapabilities makes Settle send the x402 version the API speaks when a
+// request leaves it zero, and enables holds and claims if the API advertises
+// them. Call it before using the service.
+func (s *Service) SetCapabilities(c types.Capabilities) {
+	s.caps = c
+}
+
+func (s *Service) feeEstimator() types.FeeEstimator {
+	s.feesMu.Lock()
+	defer s.feesMu.Unlock()
+	if s.fees == nil {
+		s.fees = solana.NewClient(solana.Config{URL: os.Getenv("SOLANA_RPC_URL")})
+	}
+	return s.fees
+}
+
 // Deposit creates an unsigned transaction to deposit funds for ZK payments.
 // The transaction must be signed by the client and submitted to the network.
 func (s *Service) Deposit(ctx context.Context, req DepositRequest) (*DepositResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := req.ComputeBudget.Resolve(ctx, s.feeEstimator(), string(req.WalletAddress)); err != nil {
+		return nil, fmt.Errorf("failed to estimate priority fee: %w", err)
+	}
 	var resp DepositResponse
 	if err := s.doRequest(ctx, "POST", "/shadowpay/v1/payment/deposit", req, &resp); err != nil {
 		return nil, err
 	}
+	resp.rebuild = func(ctx context.Context) (*DepositResponse, error) {
+		return s.Deposit(ctx, req)
+	}
 	return &resp, nil
 }
 
 // Withdraw creates an unsigned transaction to withdraw funds from the payment account.
 // The transaction must be signed by the client and submitted to the network.
 func (s *Service) Withdraw(ctx context.Context, req WithdrawRequest) (*WithdrawResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := req.ComputeBudget.Resolve(ctx, s.feeEstimator(), string(req.WalletAddress)); err != nil {
+		return nil, fmt.Errorf("failed to estimate priority fee: %w", err)
+	}
 	var resp WithdrawResponse
 	if err := s.doRequest(ctx, "POST", "/shadowpay/v1/payment/withdraw", req, &resp); err != nil {
 		return nil, err
 	}
+	resp.rebuild = func(ctx context.Context) (*WithdrawResponse, error) {
+		return s.Withdraw(ctx, req)
+	}
 	return &resp, nil
 }
 
 // Prepare initiates the ZK payment flow.
 func (s *Service) Prepare(ctx context.Context, req PrepareRequest) (*PrepareResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
 	var resp PrepareResponse
 	if err := s.doRequest(ctx, "POST", "/shadowpay/v1/payment/prepare", req, &resp); err != nil {
 		return nil, err
@@ -116,6 +243,17 @@ func (s *Service) Prepare(ctx context.Context, req PrepareRequest) (*PrepareResp
 
 // Settle submits a ZK proof to the relayer for settlement.
 func (s *Service) Settle(ctx context.Context, req SettleRequest) (*SettleResponse, error) {
+	if req.Hold {
+		if err := types.Require(ctx, s.caps, types.FeatureHolds); err != nil {
+			return nil, err
+		}
+	}
+	if err := req.ComputeBudget.Resolve(ctx, s.feeEstimator(), req.PaymentRequirements.PayTo); err != nil {
+		return nil, fmt.Errorf("failed to estimate priority fee: %w", err)
+	}
+	if req.X402Version == 0 && s.caps != nil {
+		req.X402Version = s.caps.X402Version(ctx)
+	}
 	var resp SettleResponse
 	if err := s.doRequest(ctx, "POST", "/shadowpay/v1/payment/settle", req, &resp); err != nil {
 		return nil, err
@@ -125,10 +263,31 @@ func (s *Service) Settle(ctx context.Context, req SettleRequest) (*SettleRespons
 
 // AuthorizeRequest represents a request to validate escrow and get an access token.
 type AuthorizeRequest struct {
-	Commitment string `json:"commitment"`
-	Nullifier  string `json:"nullifier"`
-	Amount     int64  `json:"amount"`
-	Merchant   string `json:"merchant"` // Merchant wallet address
+	Commitment types.Commitment `json:"commitment"`
+	Nullifier  string           `json:"nullifier"`
+	Amount     int64    `json:"amount"`
+	Merchant   types.Address    `json:"merchant"` // Merchant wallet address
+
+	// Scope limits the token to paths of the merchant API matching any of
+	// these patterns, e.g. "/reports/*" or "/stream/**". See MatchScope.
+	// Empty grants the whole API.
+	Scope []string `json:"scope,omitempty"`
+}
+
+// Validate checks the request fields.
+func (r AuthorizeRequest) Validate() error {
+	v := validate.New().
+		Add("commitment", r.Commitment.Validate()).
+		Required("nullifier", r.Nullifier).
+		Amount("amount", r.Amount, 1, validate.MaxLamports).
+		Add("merchant", r.Merchant.Validate())
+	if len(r.Scope) > MaxScopePatterns {
+		v.Add("scope", fmt.Errorf("must have at most %d patterns", MaxScopePatterns))
+	}
+	for i, pattern := range r.Scope {
+		v.Add(fmt.Sprintf("scope[%d]", i), validScope(pattern))
+	}
+	return v.Err()
 }
 
 // AuthorizeResponse contains the JWT access token for x402 flow.
@@ -152,11 +311,21 @@ type VerifyAccessResponse struct {
 	Amount     int64  `json:"amount,omitempty"`
 	ExpiresAt  string `json:"expires_at,omitempty"`
 	Message    string `json:"message,omitempty"`
+
+	Scope []string `json:"scope,omitempty"` // Granted path patterns; empty means the whole API
+}
+
+// Allows reports whether the granted scope covers a request path.
+func (r *VerifyAccessResponse) Allows(path string) bool {
+	return ScopeAllows(r.Scope, path)
 }
 
 // Authorize validates escrow balance and returns an access token for the x402 payment flow.
 // The user must have sufficient escrow balance for the payment amount.
 func (s *Service) Authorize(ctx context.Context, req AuthorizeRequest) (*AuthorizeResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
 	var resp AuthorizeResponse
 	if err := s.doRequest(ctx, "POST", "/shadowpay/v1/payment/authorize", req, &resp); err != nil {
 		return nil, err
@@ -174,3 +343,70 @@ func (s *Service) VerifyAccess(ctx context.Context, token string) (*VerifyAccess
 	}
 	return &resp, nil
 }
+
+// RefreshAccessRequest represents a request to extend an access token.
+type RefreshAccessRequest struct {
+	Token string `json:"token"`
+}
+
+// RefreshAccess exchanges a valid access token for a new one with a fresh
+// expiry, for paid sessions that outlive the token.
+func (s *Service) RefreshAccess(ctx context.Context, token string) (*AuthorizeResponse, error) {
+	if err := validate.New().Required("token", token).Err(); err != nil {
+		return nil, err
+	}
+	var resp AuthorizeResponse
+	req := RefreshAccessRequest{Token: token}
+	if err := s.doRequest(ctx, "POST", "/shadowpay/v1/payment/refresh-access", req, &resp); err != nil {
+		return nil, err
+	}
+	if resp.AccessToken != "" {
+		s.hooksMu.Lock()
+		hooks := s.onRefresh
+		s.hooksMu.Unlock()
+		for _, fn := range hooks {
+			fn(token, resp.AccessToken)
+		}
+	}
+	return &resp, nil
+}
+
+// ClaimRequest settles part of an authorized amount to the merchant, e.g.
+// the charges accrued on a payment channel. Total is cumulative over the
+// authorization, so a retried claim settles nothing twice.
+type ClaimRequest struct {
+	AccessToken string `json:"access_token"`
+	Total       int64  `json:"total"` // Lamports claimed so far, including this claim
+}
+
+// Validate checks the request fields.
+func (r ClaimRequest) Validate() error {
+	return validate.New().
+		Required("access_token", r.AccessToken).
+		Amount("total", r.Total, 1, validate.MaxLamports).
+		Err()
+}
+
+// Claim settles the difference between Total and what was already claimed
+// on the access token's authorization.
+func (s *Service) Claim(ctx context.Context, req ClaimRequest) (*SettleResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := types.Require(ctx, s.caps, types.FeatureClaims); err != nil {
+		return nil, err
+	}
+	var resp SettleResponse
+	if err := s.doRequest(ctx, "POST", "/shadowpay/v1/payment/claim", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// OnRefresh registers fn to be called with the old and new token after every
+// successful RefreshAccess, e.g. to carry state kept per token over.
+func (s *Service) OnRefresh(fn func(oldToken, newToken string)) {
+	s.hooksMu.Lock()
+	defer s.hooksMu.Unlock()
+	s.onRefresh = append(s.onRefresh, fn)
+}
@@ -0,0 +1,67 @@
+package payment
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// MaxScopePatterns caps the patterns of a scoped access token.
+const MaxScopePatterns = 32
+
+// MatchScope reports whether a request path matches a scope pattern. Patterns
+// are slash-separated paths whose segments may use path.Match wildcards, so
+// "/reports/*" matches "/reports/daily" but not "/reports/daily/csv". A final
+// "/**" segment matches the path before it and everything below it.
+func MatchScope(pattern, p string) bool {
+	p = path.Clean("/" + p)
+	prefix, subtree := strings.CutSuffix(pattern, "/**")
+	if !subtree {
+		ok, _ := path.Match(pattern, p)
+		return ok
+	}
+	if prefix == "" {
+		return true
+	}
+	// Match the pattern against as many leading segments as it has
+	n := strings.Count(prefix, "/")
+	segments := strings.SplitAfterN(p, "/", n+2)
+	if len(segments) <= n {
+		return false
+	}
+	head := strings.TrimSuffix(strings.Join(segments[:n+1], ""), "/")
+	ok, _ := path.Match(prefix, head)
+	return ok
+}
+
+// ScopeAllows reports whether any pattern of a scope matches a request path.
+// An empty scope allows every path.
+func ScopeAllows(scope []string, p string) bool {
+	if len(scope) == 0 {
+		return true
+	}
+	for _, pattern := range scope {
+		if MatchScope(pattern, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// validScope checks that a scope pattern is an absolute, well-formed path pattern.
+func validScope(pattern string) error {
+	if !strings.HasPrefix(pattern, "/") {
+		return fmt.Errorf("must start with /")
+	}
+	if len(pattern) > 256 {
+		return fmt.Errorf("must be at most 256 characters")
+	}
+	prefix, _ := strings.CutSuffix(pattern, "/**")
+	if strings.Contains(prefix, "**") {
+		return fmt.Errorf("** is only allowed as the last segment")
+	}
+	if _, err := path.Match(prefix, ""); err != nil {
+		return fmt.Errorf("is not a valid pattern")
+	}
+	return nil
+}
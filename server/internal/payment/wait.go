@@ -0,0 +1,113 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"sol_privacy/internal/receipt"
+)
+
+// Settlement statuses reported by the relayer.
+const (
+	StatusPending = "pending"
+	StatusSettled = "settled"
+	StatusFailed  = "failed"
+)
+
+var (
+	// ErrSettlementFailed is returned when the relayer reports the settlement failed.
+	ErrSettlementFailed = errors.New("settlement failed")
+	// ErrSettlementTimeout is returned when the settlement does not land before the wait timeout.
+	ErrSettlementTimeout = errors.New("timed out waiting for settlement")
+)
+
+// StatusResponse contains the settlement status of a payment.
+type StatusResponse struct {
+	PaymentHash string           `json:"payment_hash"`
+	Status      string           `json:"status"` // pending, settled or failed
+	TxSig       string           `json:"tx_sig,omitempty"`
+	Receipt     *receipt.Receipt `json:"receipt,omitempty"`
+	Message     string           `json:"message,omitempty"`
+}
+
+// WaitOptions configures WaitForSettlement.
+type WaitOptions struct {
+	Timeout      time.Duration // Overall wait, defaults to 2 minutes
+	PollInterval time.Duration // Initial poll interval, defaults to 1 second
+	MaxInterval  time.Duration // Poll interval backs off up to this, defaults to 10 seconds
+}
+
+// SettlementResult is the on-chain outcome of a settled payment.
+type SettlementResult struct {
+	PaymentHash string           `json:"payment_hash"`
+	TxSig       string           `json:"tx_sig"`
+	Receipt     *receipt.Receipt `json:"receipt,omitempty"`
+}
+
+// GetStatus retrieves the settlement status of a payment.
+func (s *Service) GetStatus(ctx context.Context, paymentHash string) (*StatusResponse, error) {
+	path := fmt.Sprintf("/shadowpay/v1/payment/status/%s", url.PathEscape(paymentHash))
+	var resp StatusResponse
+	if err := s.doRequest(ctx, "GET", path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// WaitForSettlement polls the payment status until the settlement lands on-chain,
+// fails, or the timeout elapses. Transient polling errors are retried until the deadline.
+func (s *Service) WaitForSettlement(ctx context.Context, paymentHash string, opts WaitOptions) (*SettlementResult, error) {
+	if opts.Timeout == 0 {
+		opts.Timeout = 2 * time.Minute
+	}
+	if opts.PollInterval == 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.MaxInterval == 0 {
+		opts.MaxInterval = 10 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	interval := opts.PollInterval
+	var lastErr error
+	for {
+		status, err := s.GetStatus(ctx, paymentHash)
+		switch {
+		case err != nil:
+			lastErr = err
+		case status.Status == StatusSettled:
+			return &SettlementResult{
+				PaymentHash: paymentHash,
+				TxSig:       status.TxSig,
+				Receipt:     status.Receipt,
+			}, nil
+		case status.Status == StatusFailed:
+			if status.Message != "" {
+				return nil, fmt.Errorf("%w: %s", ErrSettlementFailed, status.Message)
+			}
+			return nil, ErrSettlementFailed
+		}
+
+		select {
+		case <-ctx.Done():
+			if lastErr != nil && ctx.Err() == context.DeadlineExceeded {
+				return nil, fmt.Errorf("%w: last error: %v", ErrSettlementTimeout, lastErr)
+			}
+			if ctx.Err() == context.DeadlineExceeded {
+				return nil, ErrSettlementTimeout
+			}
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+
+		interval *= 2
+		if interval > opts.MaxInterval {
+			interval = opts.MaxInterval
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package paymentlink
+
+import (
+	"context"
+)
+
+// Client manages payment links on a running proxy, e.g. through proxyclient.Client.Do.
+type Client struct {
+	doRequest func(ctx context.Context, method, path string, body, result interface{}) error
+}
+
+// NewClient creates a payment link client.
+func NewClient(doRequest func(ctx context.Context, method, path string, body, result interface{}) error) *Client {
+	return &Client{doRequest: doRequest}
+}
+
+// Create creates a payment link.
+func (c *Client) Create(ctx context.Context, req CreateRequest) (*Link, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var l Link
+	if err := c.doRequest(ctx, "POST", "/links", req, &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// List returns all payment links, newest first.
+func (c *Client) List(ctx context.Context) ([]*Link, error) {
+	var resp struct {
+		Links []*Link `json:"links"`
+	}
+	if err := c.doRequest(ctx, "GET", "/links", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Links, nil
+}
+
+// Stats returns usage statistics for a payment link.
+func (c *Client) Stats(ctx context.Context, code string) (*Stats, error) {
+	var st Stats
+	if err := c.doRequest(ctx, "GET", "/links/"+code+"/stats", nil, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// Deactivate deactivates a payment link.
+func (c *Client) Deactivate(ctx context.Context, code string) (*Link, error) {
+	var l Link
+	if err := c.doRequest(ctx, "POST", "/links/"+code+"/deactivate", nil, &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
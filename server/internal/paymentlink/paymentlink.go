@@ -0,0 +1,346 @@
+// Package paymentlink issues short, reusable payment URLs. Each visit to a link
+// opens a fresh checkout session with the link's price and redirect URLs.
+package paymentlink
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/checkout"
+	"sol_privacy/internal/jsonfile"
+	"sol_privacy/internal/validate"
+)
+
+// CodeLength is the length of generated link codes.
+const CodeLength = 8
+
+// MetadataKey is the checkout session metadata key holding the link code.
+const MetadataKey = "payment_link"
+
+var (
+	// ErrLinkNotFound is returned for unknown link codes.
+	ErrLinkNotFound = errors.New("payment link not found")
+	// ErrLinkInactive is returned when resolving a deactivated, expired or used-up link.
+	ErrLinkInactive = errors.New("payment link is no longer active")
+)
+
+// Link is a shareable payment link.
+type Link struct {
+	Code               string           `json:"code"`
+	URL                string           `json:"url"`
+	Amount             int64            `json:"amount"` // In lamports
+	Recipient          string           `json:"recipient"`
+	ReceiverCommitment string           `json:"receiver_commitment"` // Merchant's ShadowID commitment
+	Description        string           `json:"description,omitempty"`
+	AcceptedTokens     []string         `json:"accepted_tokens,omitempty"`
+	Prices             map[string]int64 `json:"prices,omitempty"` // Base units, by accepted mint
+	SuccessURL         string           `json:"success_url"`
+	CancelURL          string           `json:"cancel_url"`
+	MaxUses            int              `json:"max_uses,omitempty"`   // Completed payments allowed; 0 is unlimited
+	ExpiresAt          int64            `json:"expires_at,omitempty"` // 0 never expires
+	Active             bool             `json:"active"`
+	CreatedAt          int64            `json:"created_at"`
+	DeactivatedAt      int64            `json:"deactivated_at,omitempty"`
+	Usage              Usage            `json:"usage"`
+}
+
+// Usage holds usage statistics for a link.
+type Usage struct {
+	Visits         int   `json:"visits"`          // Checkout sessions opened
+	Completed      int   `json:"completed"`       // Sessions paid
+	TotalCollected int64 `json:"total_collected"` // Lamports across sessions completed in SOL
+	LastUsedAt     int64 `json:"last_used_at,omitempty"`
+}
+
+// ConversionRate returns completed payments per visit.
+func (u Usage) ConversionRate() float64 {
+	if u.Visits == 0 {
+		return 0
+	}
+	return float64(u.Completed) / float64(u.Visits)
+}
+
+// Stats summarizes a link's usage.
+type Stats struct {
+	Code           string  `json:"code"`
+	Active         bool    `json:"active"`
+	Usage          Usage   `json:"usage"`
+	ConversionRate float64 `json:"conversion_rate"`
+}
+
+// usable reports whether the link can open new checkout sessions.
+func (l *Link) usable(now time.Time) bool {
+	if !l.Active {
+		return false
+	}
+	if l.ExpiresAt > 0 && now.Unix() >= l.ExpiresAt {
+		return false
+	}
+	return l.MaxUses == 0 || l.Usage.Completed < l.MaxUses
+}
+
+// CreateRequest represents a request to create a payment link.
+type CreateRequest struct {
+	Amount             int64            `json:"amount"`
+	Recipient          string           `json:"recipient"`
+	ReceiverCommitment string           `json:"receiver_commitment"`
+	Description        string           `json:"description,omitempty"`
+	AcceptedTokens     []string         `json:"accepted_tokens,omitempty"`
+	Prices             map[string]int64 `json:"prices,omitempty"`
+	SuccessURL         string           `json:"success_url"`
+	CancelURL          string           `json:"cancel_url"`
+	MaxUses            int              `json:"max_uses,omitempty"`
+	ExpiresAt          int64            `json:"expires_at,omitempty"`
+}
+
+// Validate checks the request fields.
+func (r CreateRequest) Validate() error {
+	v := validate.New().
+		Amount("amount", r.Amount, 1, validate.MaxLamports).
+		Address("recipient", r.Recipient).
+		Commitment("receiver_commitment", r.ReceiverCommitment).
+		URL("success_url", r.SuccessURL, false).
+		URL("cancel_url", r.CancelURL, false)
+	checkout.ValidatePrices(v, r.AcceptedTokens, r.Prices)
+	if r.MaxUses < 0 {
+		v.Add("max_uses", errors.New("must not be negative"))
+	}
+	if r.ExpiresAt != 0 && r.ExpiresAt <= time.Now().Unix() {
+		v.Add("expires_at", errors.New("must be in the future"))
+	}
+	return v.Err()
+}
+
+// Store persists links.
+type Store interface {
+	Put(l *Link) error
+	Get(code string) (*Link, error) // Returns ErrLinkNotFound for unknown codes
+	List() ([]*Link, error)
+}
+
+// NewMemoryStore creates an empty in-memory Store.
+func NewMemoryStore() Store {
+	return store{jsonfile.NewMemoryStore(linkCode, nil)}
+}
+
+// NewFileStore opens a Store persisted to a JSON file after every write, so
+// links survive proxy restarts. The file is created on first write.
+func NewFileStore(path string) (Store, error) {
+	links, err := jsonfile.NewStore(path, linkCode, nil)
+	if err != nil {
+		return nil, err
+	}
+	return store{links}, nil
+}
+
+func linkCode(l *Link) string { return l.Code }
+
+// store keeps links in a jsonfile.Store.
+type store struct {
+	links *jsonfile.Store[Link]
+}
+
+func (s store) Put(l *Link) error {
+	return s.links.Put(l)
+}
+
+func (s store) Get(code string) (*Link, error) {
+	l, ok := s.links.Get(code)
+	if !ok {
+		return nil, ErrLinkNotFound
+	}
+	return l, nil
+}
+
+// List returns all links, newest first.
+func (s store) List() ([]*Link, error) {
+	return s.links.List(func(a, b *Link) bool { return a.CreatedAt > b.CreatedAt }), nil
+}
+
+// Config holds payment link configuration.
+type Config struct {
+	Checkout *checkout.Manager
+	Store    Store  // Defaults to an in-memory store
+	BaseURL  string // Public URL links resolve under, e.g. https://pay.example.com/api/l
+}
+
+// Service creates and resolves payment links.
+type Service struct {
+	checkout *checkout.Manager
+	store    Store
+	baseURL  string
+
+	mu sync.Mutex // Serializes usage updates
+}
+
+// NewService creates a payment link service.
+func NewService(config Config) *Service {
+	if config.Store == nil {
+		config.Store = NewMemoryStore()
+	}
+	return &Service{
+		checkout: config.Checkout,
+		store:    config.Store,
+		baseURL:  config.BaseURL,
+	}
+}
+
+// Create creates an active payment link.
+func (s *Service) Create(ctx context.Context, req CreateRequest) (*Link, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	code, err := s.newCode()
+	if err != nil {
+		return nil, err
+	}
+	l := &Link{
+		Code:               code,
+		URL:                s.baseURL + "/" + code,
+		Amount:             req.Amount,
+		Recipient:          req.Recipient,
+		ReceiverCommitment: req.ReceiverCommitment,
+		Description:        req.Description,
+		AcceptedTokens:     req.AcceptedTokens,
+		Prices:             req.Prices,
+		SuccessURL:         req.SuccessURL,
+		CancelURL:          req.CancelURL,
+		MaxUses:            req.MaxUses,
+		ExpiresAt:          req.ExpiresAt,
+		Active:             true,
+		CreatedAt:          time.Now().Unix(),
+	}
+	if err := s.store.Put(l); err != nil {
+		return nil, fmt.Errorf("failed to save payment link: %w", err)
+	}
+	return l, nil
+}
+
+// Get returns a link.
+func (s *Service) Get(ctx context.Context, code string) (*Link, error) {
+	return s.store.Get(code)
+}
+
+// List returns all links, newest first.
+func (s *Service) List(ctx context.Context) ([]*Link, error) {
+	return s.store.List()
+}
+
+// Stats returns usage statistics for a link.
+func (s *Service) Stats(ctx context.Context, code string) (*Stats, error) {
+	l, err := s.store.Get(code)
+	if err != nil {
+		return nil, err
+	}
+	return &Stats{
+		Code:           l.Code,
+		Active:         l.usable(time.Now()),
+		Usage:          l.Usage,
+		ConversionRate: l.Usage.ConversionRate(),
+	}, nil
+}
+
+// Deactivate stops a link from opening new checkout sessions.
+func (s *Service) Deactivate(ctx context.Context, code string) (*Link, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, err := s.store.Get(code)
+	if err != nil {
+		return nil, err
+	}
+	if !l.Active {
+		return l, nil
+	}
+	l.Active = false
+	l.DeactivatedAt = time.Now().Unix()
+	if err := s.store.Put(l); err != nil {
+		return nil, fmt.Errorf("failed to save payment link: %w", err)
+	}
+	return l, nil
+}
+
+// Resolve opens a checkout session for a visit to the link.
+func (s *Service) Resolve(ctx context.Context, code string) (*checkout.Session, error) {
+	l, err := s.store.Get(code)
+	if err != nil {
+		return nil, err
+	}
+	if !l.usable(time.Now()) {
+		return nil, ErrLinkInactive
+	}
+
+	session, err := s.checkout.Create(ctx, checkout.CreateRequest{
+		Amount:             l.Amount,
+		Recipient:          l.Recipient,
+		ReceiverCommitment: l.ReceiverCommitment,
+		Description:        l.Description,
+		AcceptedTokens:     l.AcceptedTokens,
+		Prices:             l.Prices,
+		SuccessURL:         l.SuccessURL,
+		CancelURL:          l.CancelURL,
+		Metadata:           map[string]string{MetadataKey: l.Code},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.updateUsage(code, func(u *Usage) {
+		u.Visits++
+	})
+	return session, nil
+}
+
+// HandleCheckoutEvent records completed payments against the link that opened
+// the session. Wire it into the checkout manager's event handler.
+func (s *Service) HandleCheckoutEvent(ctx context.Context, event checkout.Event) {
+	code := event.Session.Metadata[MetadataKey]
+	if event.Type != checkout.EventSessionCompleted || code == "" {
+		return
+	}
+	s.updateUsage(code, func(u *Usage) {
+		u.Completed++
+		if event.Session.TokenMint == "" {
+			u.TotalCollected += event.Session.Amount
+		}
+	})
+}
+
+func (s *Service) updateUsage(code string, fn func(u *Usage)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, err := s.store.Get(code)
+	if err != nil {
+		return
+	}
+	fn(&l.Usage)
+	l.Usage.LastUsedAt = time.Now().Unix()
+	s.store.Put(l)
+}
+
+const codeAlphabet = "23456789abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// newCode returns an unused random code without look-alike characters.
+func (s *Service) newCode() (string, error) {
+	for attempt := 0; attempt < 5; attempt++ {
+		b := make([]byte, CodeLength)
+		for i := range b {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(codeAlphabet))))
+			if err != nil {
+				return "", fmt.Errorf("failed to generate link code: %w", err)
+			}
+			b[i] = codeAlphabet[n.Int64()]
+		}
+		if _, err := s.store.Get(string(b)); errors.Is(err, ErrLinkNotFound) {
+			return string(b), nil
+		}
+	}
+	return "", errors.New("failed to generate a unique link code")
+}
@@ -0,0 +1,253 @@
+// Package platform lets marketplace operators run sub-merchants on top of
+// the merchant service: each sub-merchant has its own payout wallet and API
+// key, payments routed to it carry the platform's fee, and earnings are
+// reported per sub-merchant and in aggregate.
+package platform
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+
+	"sol_privacy/internal/keys"
+	"sol_privacy/internal/merchant"
+	"sol_privacy/internal/payment"
+	"sol_privacy/internal/types"
+	"sol_privacy/internal/validate"
+)
+
+// MaxFeeBps is the largest platform fee, in basis points.
+const MaxFeeBps = 5000
+
+// Service handles marketplace sub-merchant operations.
+type Service struct {
+	doRequest func(ctx context.Context, method, path string, body, result interface{}) error
+	caps      types.Capabilities
+}
+
+// NewService creates a new platform service.
+func NewService(doRequest func(ctx context.Context, method, path string, body, result interface{}) error) *Service {
+	return &Service{
+		doRequest: doRequest,
+	}
+}
+
+// SetCapabilities enables the service if the API advertises platform
+// endpoints. Call it before using the service.
+func (s *Service) SetCapabilities(c types.Capabilities) {
+	s.caps = c
+}
+
+// call sends a request once the API has advertised platform endpoints.
+func (s *Service) call(ctx context.Context, method, path string, body, result interface{}) error {
+	if err := types.Require(ctx, s.caps, types.FeaturePlatform); err != nil {
+		return err
+	}
+	return s.doRequest(ctx, method, path, body, result)
+}
+
+// Sub-merchant statuses.
+const (
+	StatusActive    = "active"
+	StatusSuspended = "suspended" // Payments are no longer routed to it
+)
+
+// SubMerchant is a merchant account operated by the platform.
+type SubMerchant struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Wallet    string            `json:"wallet_address"` // Payout wallet
+	FeeBps    int               `json:"fee_bps"`        // Platform fee on each payment
+	Status    string            `json:"status"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	CreatedAt int64             `json:"created_at"`
+}
+
+// CreateRequest represents a request to create a sub-merchant.
+type CreateRequest struct {
+	Name     string            `json:"name"`
+	Wallet   string            `json:"wallet_address"`
+	FeeBps   int               `json:"fee_bps"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Validate checks the request fields.
+func (r CreateRequest) Validate() error {
+	return validate.New().
+		Required("name", r.Name).
+		Address("wallet_address", r.Wallet).
+		Amount("fee_bps", int64(r.FeeBps), 0, MaxFeeBps).
+		Metadata("metadata", r.Metadata).
+		Err()
+}
+
+// UpdateRequest changes a sub-merchant. Nil fields are left unchanged.
+type UpdateRequest struct {
+	Name   *string `json:"name,omitempty"`
+	FeeBps *int    `json:"fee_bps,omitempty"`
+	Status *string `json:"status,omitempty"` // StatusActive or StatusSuspended
+}
+
+// Validate checks the request fields.
+func (r UpdateRequest) Validate() error {
+	v := validate.New()
+	if r.Name != nil {
+		v.Required("name", *r.Name)
+	}
+	if r.FeeBps != nil {
+		v.Amount("fee_bps", int64(*r.FeeBps), 0, MaxFeeBps)
+	}
+	if r.Status != nil && *r.Status != StatusActive && *r.Status != StatusSuspended {
+		v.Add("status", fmt.Errorf("must be %q or %q", StatusActive, StatusSuspended))
+	}
+	return v.Err()
+}
+
+// ListResponse contains the platform's sub-merchants.
+type ListResponse struct {
+	SubMerchants []SubMerchant `json:"sub_merchants"`
+}
+
+// Earnings are a sub-merchant's earnings and the fees the platform took.
+type Earnings struct {
+	SubMerchantID string `json:"sub_merchant_id"`
+	Name          string `json:"name,omitempty"`
+	merchant.EarningsResponse
+	PlatformFees int64  `json:"platform_fees"` // Lamports
+	Error        string `json:"error,omitempty"`
+}
+
+// EarningsSummary aggregates the earnings of every sub-merchant.
+type EarningsSummary struct {
+	SubMerchants      []Earnings `json:"sub_merchants"`
+	TotalEarnings     int64      `json:"total_earnings"` // Lamports, net of platform fees
+	TotalPlatformFees int64      `json:"total_platform_fees"`
+	PendingSettlement int64      `json:"pending_settlement"`
+}
+
+// Create creates a sub-merchant.
+func (s *Service) Create(ctx context.Context, req CreateRequest) (*SubMerchant, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var resp SubMerchant
+	if err := s.call(ctx, "POST", "/shadowpay/api/platform/merchants", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// List retrieves the platform's sub-merchants.
+func (s *Service) List(ctx context.Context) (*ListResponse, error) {
+	var resp ListResponse
+	if err := s.call(ctx, "GET", "/shadowpay/api/platform/merchants", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Get retrieves a sub-merchant.
+func (s *Service) Get(ctx context.Context, id string) (*SubMerchant, error) {
+	var resp SubMerchant
+	if err := s.call(ctx, "GET", subMerchantPath(id), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Update changes a sub-merchant's name, fee or status.
+func (s *Service) Update(ctx context.Context, id string, req UpdateRequest) (*SubMerchant, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var resp SubMerchant
+	if err := s.call(ctx, "PATCH", subMerchantPath(id), req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CreateAPIKey issues an API key scoped to the sub-merchant, so it can call
+// the merchant service for its own account. Issuing another rotates it.
+func (s *Service) CreateAPIKey(ctx context.Context, id string) (*keys.Response, error) {
+	var resp keys.Response
+	if err := s.call(ctx, "POST", subMerchantPath(id)+"/keys", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetEarnings retrieves a sub-merchant's earnings.
+func (s *Service) GetEarnings(ctx context.Context, id string) (*Earnings, error) {
+	var resp Earnings
+	if err := s.call(ctx, "GET", subMerchantPath(id)+"/earnings", nil, &resp); err != nil {
+		return nil, err
+	}
+	resp.SubMerchantID = id
+	return &resp, nil
+}
+
+// GetAllEarnings retrieves the earnings of every sub-merchant and their
+// totals. Earnings are fetched concurrently; a failed lookup is reported on
+// its entry rather than failing the whole call.
+func (s *Service) GetAllEarnings(ctx context.Context) (*EarningsSummary, error) {
+	list, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &EarningsSummary{SubMerchants: make([]Earnings, len(list.SubMerchants))}
+	var wg sync.WaitGroup
+	for i, sm := range list.SubMerchants {
+		wg.Add(1)
+		go func(e *Earnings, sm SubMerchant) {
+			defer wg.Done()
+			got, err := s.GetEarnings(ctx, sm.ID)
+			if err != nil {
+				*e = Earnings{SubMerchantID: sm.ID, Name: sm.Name, Error: err.Error()}
+				return
+			}
+			*e = *got
+			e.Name = sm.Name
+		}(&resp.SubMerchants[i], sm)
+	}
+	wg.Wait()
+
+	for _, e := range resp.SubMerchants {
+		resp.TotalEarnings += e.TotalEarnings
+		resp.TotalPlatformFees += e.PlatformFees
+		resp.PendingSettlement += e.PendingSettlement
+	}
+	return resp, nil
+}
+
+// Route returns requirements paying sub rather than the platform: PayTo is
+// the sub-merchant's wallet, and Extra names the sub-merchant and fee so the
+// relayer splits the platform's share out at settlement.
+func Route(sub *SubMerchant, req payment.Requirements) (payment.Requirements, error) {
+	if sub.Status == StatusSuspended {
+		return req, fmt.Errorf("sub-merchant %s is suspended", sub.ID)
+	}
+	extra := make(map[string]any, len(req.Extra)+2)
+	for k, v := range req.Extra {
+		extra[k] = v
+	}
+	extra["subMerchant"] = sub.ID
+	extra["platformFeeBps"] = sub.FeeBps
+	req.PayTo = sub.Wallet
+	req.Extra = extra
+	return req, nil
+}
+
+// Split divides an amount into the platform's fee and the sub-merchant's
+// share, rounding the fee down.
+func Split(amount int64, feeBps int) (fee, net int64) {
+	// Split the multiplication so large amounts cannot overflow
+	fee = amount/10000*int64(feeBps) + amount%10000*int64(feeBps)/10000
+	return fee, amount - fee
+}
+
+func subMerchantPath(id string) string {
+	return "/shadowpay/api/platform/merchants/" + url.PathEscape(id)
+}
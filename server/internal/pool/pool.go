@@ -3,6 +3,11 @@ package pool
 import (
 	"context"
 	"fmt"
+	"net/url"
+
+	"sol_privacy/internal/amount"
+	"sol_privacy/internal/types"
+	"sol_privacy/internal/validate"
 )
 
 // Service handles privacy pool operations for mixing funds across users.
@@ -24,11 +29,32 @@ type BalanceResponse struct {
 	MinDeposit    int64  `json:"min_deposit"`
 }
 
+// BalanceAmount returns Balance as an amount.
+func (r BalanceResponse) BalanceAmount() amount.Amount {
+	return amount.Lamports(r.Balance)
+}
+
+// MinDepositAmount returns MinDeposit as an amount.
+func (r BalanceResponse) MinDepositAmount() amount.Amount {
+	return amount.Lamports(r.MinDeposit)
+}
+
 // DepositRequest represents a request to deposit SOL into the pool.
 // Creates an unsigned transaction for the client to sign.
 type DepositRequest struct {
-	WalletAddress string `json:"wallet_address"`
-	Amount        int64  `json:"amount"` // Must be at least 0.01 SOL (10000000 lamports)
+	WalletAddress types.Address `json:"wallet_address"`
+	Amount        int64         `json:"amount"` // Must be at least 0.01 SOL (10000000 lamports)
+}
+
+// MinDepositLamports is the smallest accepted pool deposit (0.01 SOL).
+const MinDepositLamports = 10000000
+
+// Validate checks the request fields.
+func (r DepositRequest) Validate() error {
+	return validate.New().
+		Add("wallet_address", r.WalletAddress.Validate()).
+		Amount("amount", r.Amount, MinDepositLamports, validate.MaxLamports).
+		Err()
 }
 
 // DepositResponse contains the unsigned transaction for deposit.
@@ -40,8 +66,38 @@ type DepositResponse struct {
 // WithdrawRequest represents a request to withdraw SOL from the pool.
 // Incurs a 0.2% fee to discourage savings use.
 type WithdrawRequest struct {
-	WalletAddress string `json:"wallet_address"`
-	Amount        int64  `json:"amount"`
+	WalletAddress types.Address `json:"wallet_address"`
+	Amount        int64         `json:"amount"`
+}
+
+// Validate checks the request fields.
+func (r WithdrawRequest) Validate() error {
+	return validate.New().
+		Add("wallet_address", r.WalletAddress.Validate()).
+		Amount("amount", r.Amount, 1, validate.MaxLamports).
+		Err()
+}
+
+// WithdrawFeeBps is the pool withdrawal fee in basis points (0.2%).
+const WithdrawFeeBps = 20
+
+// RentExemptLamports is the smallest balance a new system account can hold, so a
+// withdrawal to a wallet that does not exist yet must net at least this much.
+const RentExemptLamports = 890880
+
+// WithdrawQuote previews a pool withdrawal before the transaction is built.
+type WithdrawQuote struct {
+	Amount       int64    `json:"amount"`
+	FeeBps       int      `json:"fee_bps"`
+	Fee          int64    `json:"fee"`
+	NetAmount    int64    `json:"net_amount"`
+	MinNetAmount int64    `json:"min_net_amount"` // Needed when the destination wallet is new
+	Warnings     []string `json:"warnings,omitempty"`
+}
+
+// WithdrawFee returns the fee charged on a withdrawal of lamports, rounded down.
+func WithdrawFee(lamports int64) int64 {
+	return amount.Lamports(lamports).MulBps(WithdrawFeeBps).Int64()
 }
 
 // WithdrawResponse contains the withdrawal transaction details.
@@ -61,7 +117,7 @@ type DepositAddressResponse struct {
 // GetBalance retrieves the user's available pool balance and escrow status.
 func (s *Service) GetBalance(ctx context.Context, walletAddress string) (*BalanceResponse, error) {
 	var resp BalanceResponse
-	path := fmt.Sprintf("/shadowpay/api/pool/balance/%s", walletAddress)
+	path := fmt.Sprintf("/shadowpay/api/pool/balance/%s", url.PathEscape(walletAddress))
 	if err := s.doRequest(ctx, "GET", path, nil, &resp); err != nil {
 		return nil, err
 	}
@@ -72,6 +128,9 @@ func (s *Service) GetBalance(ctx context.Context, walletAddress string) (*Balanc
 // Minimum deposit is 0.01 SOL (10000000 lamports).
 // Funds are mixed with other users for maximum privacy.
 func (s *Service) Deposit(ctx context.Context, req DepositRequest) (*DepositResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
 	var resp DepositResponse
 	if err := s.doRequest(ctx, "POST", "/shadowpay/api/pool/deposit", req, &resp); err != nil {
 		return nil, err
@@ -82,6 +141,9 @@ func (s *Service) Deposit(ctx context.Context, req DepositRequest) (*DepositResp
 // Withdraw withdraws SOL from the pool with a 0.2% fee.
 // Fee is applied to discourage using the pool as a savings account.
 func (s *Service) Withdraw(ctx context.Context, req WithdrawRequest) (*WithdrawResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
 	var resp WithdrawResponse
 	if err := s.doRequest(ctx, "POST", "/shadowpay/api/pool/withdraw", req, &resp); err != nil {
 		return nil, err
@@ -89,6 +151,30 @@ func (s *Service) Withdraw(ctx context.Context, req WithdrawRequest) (*WithdrawR
 	return &resp, nil
 }
 
+// QuoteWithdraw returns the fee, net amount and minimums of withdrawing
+// lamports, so the fee can be shown before Withdraw builds the transaction.
+// The fee is computed locally; no request is made to the API.
+func (s *Service) QuoteWithdraw(ctx context.Context, lamports int64) (*WithdrawQuote, error) {
+	if err := validate.New().Amount("amount", lamports, 1, validate.MaxLamports).Err(); err != nil {
+		return nil, err
+	}
+
+	fee := WithdrawFee(lamports)
+	q := &WithdrawQuote{
+		Amount:       lamports,
+		FeeBps:       WithdrawFeeBps,
+		Fee:          fee,
+		NetAmount:    lamports - fee,
+		MinNetAmount: RentExemptLamports,
+	}
+	if q.NetAmount < RentExemptLamports {
+		q.Warnings = append(q.Warnings, fmt.Sprintf(
+			"net amount is below the %d lamport rent-exempt minimum; the withdrawal fails if the destination wallet is new",
+			RentExemptLamports))
+	}
+	return q, nil
+}
+
 // GetDepositAddress obtains the pool PDA address for reference.
 func (s *Service) GetDepositAddress(ctx context.Context) (*DepositAddressResponse, error) {
 	var resp DepositAddressResponse
@@ -0,0 +1,250 @@
+// Package poseidon implements the Poseidon hash over the BN254 scalar field
+// with the parameters used by circomlib circuits (x^5 S-box, 8 full rounds).
+// Round constants and MDS matrices are derived with the reference Grain LFSR,
+// so outputs match circomlib's poseidon for 1 to 16 inputs.
+package poseidon
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"sync"
+)
+
+const (
+	// MaxInputs is the largest number of inputs Hash accepts.
+	MaxInputs = 16
+
+	fullRounds = 8
+	fieldBits  = 254
+)
+
+// partialRounds[t-2] is the number of partial rounds for state width t.
+var partialRounds = []int{56, 57, 56, 60, 60, 63, 64, 63, 60, 66, 60, 65, 70, 60, 64, 68}
+
+// Modulus is the BN254 scalar field prime.
+var Modulus, _ = new(big.Int).SetString("21888242871839275222246405745257275088548364400416034343698204186575808495617", 10)
+
+type params struct {
+	t         int
+	rp        int
+	constants []*big.Int
+	mds       [][]*big.Int
+}
+
+var (
+	paramsMu    sync.Mutex
+	paramsCache = make(map[int]*params)
+)
+
+// Hash returns the Poseidon hash of 1 to 16 field elements.
+func Hash(inputs ...*big.Int) (*big.Int, error) {
+	if len(inputs) == 0 || len(inputs) > MaxInputs {
+		return nil, fmt.Errorf("poseidon: expected 1 to %d inputs, got %d", MaxInputs, len(inputs))
+	}
+	for i, in := range inputs {
+		if in == nil || in.Sign() < 0 || in.Cmp(Modulus) >= 0 {
+			return nil, fmt.Errorf("poseidon: input %d is not a field element", i)
+		}
+	}
+
+	p := getParams(len(inputs) + 1)
+	state := make([]*big.Int, p.t)
+	state[0] = new(big.Int)
+	for i, in := range inputs {
+		state[i+1] = new(big.Int).Set(in)
+	}
+
+	half := fullRounds / 2
+	for r := 0; r < fullRounds+p.rp; r++ {
+		for i := range state {
+			state[i].Add(state[i], p.constants[r*p.t+i])
+			state[i].Mod(state[i], Modulus)
+		}
+		if r < half || r >= half+p.rp {
+			for i := range state {
+				sbox(state[i])
+			}
+		} else {
+			sbox(state[0])
+		}
+		state = mix(state, p.mds)
+	}
+
+	return state[0], nil
+}
+
+// FieldElement reduces arbitrary bytes (big-endian) into the BN254 scalar field.
+func FieldElement(b []byte) *big.Int {
+	n := new(big.Int).SetBytes(b)
+	return n.Mod(n, Modulus)
+}
+
+// SecretFromSeed derives a field element from seed bytes under a domain tag.
+// The same seed and domain always produce the same secret.
+func SecretFromSeed(domain string, seed []byte) *big.Int {
+	h := sha256.New()
+	h.Write([]byte(domain))
+	h.Write([]byte{0})
+	h.Write(seed)
+	return FieldElement(h.Sum(nil))
+}
+
+// Commitment computes Poseidon(nullifier, secret), the leaf registered in the tree.
+func Commitment(nullifier, secret *big.Int) (*big.Int, error) {
+	return Hash(nullifier, secret)
+}
+
+// NullifierHash computes Poseidon(nullifier), revealed when spending to prevent double use.
+func NullifierHash(nullifier *big.Int) (*big.Int, error) {
+	return Hash(nullifier)
+}
+
+func sbox(x *big.Int) {
+	x2 := new(big.Int).Mul(x, x)
+	x2.Mod(x2, Modulus)
+	x4 := new(big.Int).Mul(x2, x2)
+	x4.Mod(x4, Modulus)
+	x.Mul(x, x4)
+	x.Mod(x, Modulus)
+}
+
+func mix(state []*big.Int, mds [][]*big.Int) []*big.Int {
+	out := make([]*big.Int, len(state))
+	tmp := new(big.Int)
+	for i := range state {
+		acc := new(big.Int)
+		for j := range state {
+			tmp.Mul(mds[i][j], state[j])
+			acc.Add(acc, tmp)
+		}
+		out[i] = acc.Mod(acc, Modulus)
+	}
+	return out
+}
+
+func getParams(t int) *params {
+	paramsMu.Lock()
+	defer paramsMu.Unlock()
+
+	if p, ok := paramsCache[t]; ok {
+		return p
+	}
+	p := generateParams(t)
+	paramsCache[t] = p
+	return p
+}
+
+// generateParams derives round constants and the Cauchy MDS matrix for width t
+// following the Poseidon reference parameter generation script.
+func generateParams(t int) *params {
+	rp := partialRounds[t-2]
+	g := newGrain(fieldBits, t, fullRounds, rp)
+
+	constants := make([]*big.Int, (fullRounds+rp)*t)
+	for i := range constants {
+		c := g.element(fieldBits)
+		for c.Cmp(Modulus) >= 0 {
+			c = g.element(fieldBits)
+		}
+		constants[i] = c
+	}
+
+	var mds [][]*big.Int
+	for mds == nil {
+		vals := make([]*big.Int, 2*t)
+		for !distinct(vals) {
+			for i := range vals {
+				vals[i] = g.element(fieldBits)
+				vals[i].Mod(vals[i], Modulus)
+			}
+		}
+		xs, ys := vals[:t], vals[t:]
+
+		m := make([][]*big.Int, t)
+		for i := 0; i < t && m != nil; i++ {
+			m[i] = make([]*big.Int, t)
+			for j := 0; j < t; j++ {
+				sum := new(big.Int).Add(xs[i], ys[j])
+				sum.Mod(sum, Modulus)
+				if sum.Sign() == 0 {
+					m = nil
+					break
+				}
+				m[i][j] = sum.ModInverse(sum, Modulus)
+			}
+		}
+		mds = m
+	}
+
+	return &params{t: t, rp: rp, constants: constants, mds: mds}
+}
+
+func distinct(vals []*big.Int) bool {
+	for i := range vals {
+		if vals[i] == nil {
+			return false
+		}
+		for j := 0; j < i; j++ {
+			if vals[i].Cmp(vals[j]) == 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// grain is the 80-bit Grain LFSR used to generate Poseidon parameters.
+type grain struct {
+	bits []byte
+}
+
+func newGrain(n, t, rf, rp int) *grain {
+	g := &grain{}
+	g.push(1, 2) // prime field
+	g.push(0, 4) // x^alpha S-box
+	g.push(n, 12)
+	g.push(t, 12)
+	g.push(rf, 10)
+	g.push(rp, 10)
+	for i := 0; i < 30; i++ {
+		g.bits = append(g.bits, 1)
+	}
+	for i := 0; i < 160; i++ {
+		g.next()
+	}
+	return g
+}
+
+func (g *grain) push(v, width int) {
+	for i := width - 1; i >= 0; i-- {
+		g.bits = append(g.bits, byte(v>>i)&1)
+	}
+}
+
+func (g *grain) next() byte {
+	b := g.bits[62] ^ g.bits[51] ^ g.bits[38] ^ g.bits[23] ^ g.bits[13] ^ g.bits[0]
+	g.bits = append(g.bits[1:], b)
+	return b
+}
+
+// bit returns the next filtered output bit.
+func (g *grain) bit() byte {
+	b := g.next()
+	for b == 0 {
+		g.next()
+		b = g.next()
+	}
+	return g.next()
+}
+
+func (g *grain) element(n int) *big.Int {
+	v := new(big.Int)
+	for i := 0; i < n; i++ {
+		v.Lsh(v, 1)
+		if g.bit() == 1 {
+			v.SetBit(v, 0, 1)
+		}
+	}
+	return v
+}
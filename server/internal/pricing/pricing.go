@@ -0,0 +1,144 @@
+// Package pricing values SOL in fiat currencies at a point in time, e.g.
+// receipts at settlement time for accounting exports.
+package pricing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBaseURL is the public CoinGecko API.
+const DefaultBaseURL = "https://api.coingecko.com/api/v3"
+
+// ErrNoPrice is returned when no price is known near the requested time.
+var ErrNoPrice = errors.New("no price available")
+
+// Provider returns the price of one SOL in a fiat currency, such as "usd",
+// at a time.
+type Provider interface {
+	SOLPrice(ctx context.Context, currency string, at time.Time) (float64, error)
+}
+
+// Fixed is a Provider of one price for every currency and time, for offline
+// exports at a known rate.
+type Fixed float64
+
+// SOLPrice returns the fixed price.
+func (f Fixed) SOLPrice(ctx context.Context, currency string, at time.Time) (float64, error) {
+	return float64(f), nil
+}
+
+// Config holds configuration for the CoinGecko provider.
+type Config struct {
+	BaseURL    string // Defaults to DefaultBaseURL
+	APIKey     string // Demo key, or a pro key with the pro-api base URL
+	HTTPClient *http.Client
+}
+
+// CoinGecko reads historical SOL prices from the CoinGecko API. Prices are
+// fetched an hour at a time and cached, so exporting many receipts settled
+// close together makes few requests.
+type CoinGecko struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+
+	mu    sync.Mutex
+	hours map[string][][2]float64 // Price points around an hour, by currency and hour
+}
+
+// NewCoinGecko creates a CoinGecko provider.
+func NewCoinGecko(config Config) *CoinGecko {
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultBaseURL
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &CoinGecko{
+		baseURL:    strings.TrimSuffix(config.BaseURL, "/"),
+		apiKey:     config.APIKey,
+		httpClient: config.HTTPClient,
+		hours:      make(map[string][][2]float64),
+	}
+}
+
+// SOLPrice returns the price point closest to at.
+func (c *CoinGecko) SOLPrice(ctx context.Context, currency string, at time.Time) (float64, error) {
+	currency = strings.ToLower(currency)
+	hour := at.UTC().Truncate(time.Hour)
+	key := currency + "/" + strconv.FormatInt(hour.Unix(), 10)
+
+	c.mu.Lock()
+	points, ok := c.hours[key]
+	c.mu.Unlock()
+	if !ok {
+		var err error
+		// A margin on each side, so times near the hour's edges have a neighbor
+		if points, err = c.fetch(ctx, currency, hour.Add(-time.Hour), hour.Add(2*time.Hour)); err != nil {
+			return 0, err
+		}
+		c.mu.Lock()
+		c.hours[key] = points
+		c.mu.Unlock()
+	}
+
+	target := float64(at.UnixMilli())
+	best, bestDist := 0.0, math.Inf(1)
+	for _, p := range points {
+		if d := math.Abs(p[0] - target); d < bestDist {
+			best, bestDist = p[1], d
+		}
+	}
+	if math.IsInf(bestDist, 1) {
+		return 0, fmt.Errorf("%w for %s at %s", ErrNoPrice, currency, at.UTC().Format(time.RFC3339))
+	}
+	return best, nil
+}
+
+// fetch returns [unix milliseconds, price] points between from and to.
+func (c *CoinGecko) fetch(ctx context.Context, currency string, from, to time.Time) ([][2]float64, error) {
+	q := url.Values{
+		"vs_currency": {currency},
+		"from":        {strconv.FormatInt(from.Unix(), 10)},
+		"to":          {strconv.FormatInt(to.Unix(), 10)},
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/coins/solana/market_chart/range?"+q.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		if strings.Contains(c.baseURL, "pro-api.") {
+			req.Header.Set("x-cg-pro-api-key", c.apiKey)
+		} else {
+			req.Header.Set("x-cg-demo-api-key", c.apiKey)
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("coingecko API error: status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Prices [][2]float64 `json:"prices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return body.Prices, nil
+}
@@ -26,6 +26,8 @@ type KeygenResponse struct {
 type DecryptRequest struct {
 	Ciphertext string `json:"ciphertext"`  // 0x hex 64 bytes
 	PrivateKey string `json:"private_key"` // 0x hex 32 bytes
+
+	WalletAddress string `json:"wallet_address,omitempty"` // Signs the request when the proxy requires signatures
 }
 
 // DecryptResponse contains the decrypted plaintext amount.
@@ -0,0 +1,84 @@
+// Package proxyclient calls the API of a running ShadowPay proxy, for tools such
+// as the CLI that manage state hosted by the proxy (payment links, invoices).
+package proxyclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultURL is the API base of a locally running proxy.
+const DefaultURL = "http://localhost:8080/api"
+
+// Client is a proxy API client.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// New creates a client for the proxy API at baseURL (DefaultURL if empty).
+func New(baseURL string, httpClient *http.Client) *Client {
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// Do sends a JSON request and decodes the JSON response into result. It has the
+// doRequest signature taken by the proxy-backed service clients.
+func (c *Client) Do(ctx context.Context, method, path string, body, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		var errorResp struct {
+			Error string `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&errorResp); err == nil && errorResp.Error != "" {
+			return fmt.Errorf("proxy API error: %s", errorResp.Error)
+		}
+		return fmt.Errorf("proxy API error: status %d", resp.StatusCode)
+	}
+
+	if result == nil {
+		return nil
+	}
+	if w, ok := result.(io.Writer); ok {
+		_, err := io.Copy(w, resp.Body)
+		return err
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package receipt
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"sol_privacy/internal/amount"
+	"sol_privacy/internal/pricing"
+	"sol_privacy/internal/validate"
+)
+
+// Export formats.
+const (
+	FormatCSV = "csv"
+	FormatQIF = "qif"
+	FormatOFX = "ofx"
+)
+
+// exportPage is the number of receipts fetched per request while exporting.
+const exportPage = 100
+
+// SetPricing configures the provider Export values receipts with, in a fiat
+// currency such as "usd", at the time they settled. Without one, exports are
+// in SOL.
+func (s *Service) SetPricing(p pricing.Provider, currency string) {
+	s.pricing, s.currency = p, strings.ToLower(currency)
+}
+
+// exportRow is a receipt as a statement transaction.
+type exportRow struct {
+	receipt  Receipt
+	at       time.Time
+	lamports int64   // Signed: received by the wallet when positive
+	rate     float64 // Fiat per SOL at settlement, zero without pricing
+	fiat     float64
+}
+
+// sol returns the row's amount in SOL.
+func (r exportRow) sol() string {
+	return amount.Lamports(r.lamports).SOL()
+}
+
+// value returns the row's amount as booked: fiat when priced, SOL otherwise.
+func (r exportRow) value() string {
+	if r.rate == 0 {
+		return r.sol()
+	}
+	return strconv.FormatFloat(r.fiat, 'f', 2, 64)
+}
+
+// Export returns all of a wallet's receipts, oldest first, as a file for
+// accounting software: csv, qif (Quicken Interchange Format) or ofx (Open
+// Financial Exchange 2). Receipts paid to the wallet are credits and those it
+// paid are debits. With pricing configured, amounts are in the fiat currency
+// at the time each receipt settled; the CSV carries both.
+func (s *Service) Export(ctx context.Context, wallet, format string) ([]byte, error) {
+	format = strings.ToLower(format)
+	switch format {
+	case FormatCSV, FormatQIF, FormatOFX:
+	case "":
+		format = FormatCSV
+	default:
+		return nil, validate.Errors{{Field: "format", Message: "must be csv, qif or ofx"}}
+	}
+	if err := validate.New().Address("wallet", wallet).Err(); err != nil {
+		return nil, err
+	}
+
+	var receipts []Receipt
+	for {
+		resp, err := s.ListUserReceipts(ctx, wallet, ListUserReceiptsRequest{Limit: exportPage, Offset: len(receipts)})
+		if err != nil {
+			return nil, err
+		}
+		receipts = append(receipts, resp.Receipts...)
+		if len(resp.Receipts) == 0 || len(receipts) >= resp.TotalCount {
+			break
+		}
+	}
+	sort.SliceStable(receipts, func(i, j int) bool {
+		return receipts[i].Body.Timestamp < receipts[j].Body.Timestamp
+	})
+
+	rows := make([]exportRow, len(receipts))
+	for i, rc := range receipts {
+		row := exportRow{receipt: rc, at: time.Unix(rc.Body.Timestamp, 0).UTC(), lamports: rc.Body.AmountLamports}
+		if rc.Body.Merchant != wallet {
+			row.lamports = -row.lamports
+		}
+		if s.pricing != nil {
+			rate, err := s.pricing.SOLPrice(ctx, s.currency, row.at)
+			if err != nil {
+				return nil, fmt.Errorf("price of receipt %s: %w", rc.Body.ID, err)
+			}
+			row.rate = rate
+			// Rounded per row, so totals add up to what is booked
+			row.fiat = math.Round(float64(row.lamports)/amount.LamportsPerSOL*rate*100) / 100
+		}
+		rows[i] = row
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case FormatQIF:
+		s.writeQIF(&buf, rows)
+	case FormatOFX:
+		s.writeOFX(&buf, wallet, rows)
+	default:
+		if err := s.writeCSV(&buf, rows); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *Service) writeCSV(buf *bytes.Buffer, rows []exportRow) error {
+	cw := csv.NewWriter(buf)
+	cw.Write([]string{"date", "id", "merchant", "resource", "amount_lamports", "amount_sol", "currency", "rate", "amount_fiat"})
+	for _, r := range rows {
+		var currency, rate, fiat string
+		if r.rate != 0 {
+			currency = strings.ToUpper(s.currency)
+			rate = strconv.FormatFloat(r.rate, 'f', -1, 64)
+			fiat = r.value()
+		}
+		cw.Write([]string{
+			r.at.Format(time.RFC3339),
+			r.receipt.Body.ID,
+			r.receipt.Body.Merchant,
+			r.receipt.Body.Resource,
+			strconv.FormatInt(r.lamports, 10),
+			r.sol(),
+			currency,
+			rate,
+			fiat,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeQIF writes a bank-type QIF file. QIF has no currency field, so the
+// currency of priced amounts is noted in each memo.
+func (s *Service) writeQIF(buf *bytes.Buffer, rows []exportRow) {
+	buf.WriteString("!Type:Bank\n")
+	for _, r := range rows {
+		memo := r.receipt.Body.Resource
+		if r.rate != 0 {
+			memo = strings.TrimSpace(fmt.Sprintf("%s %s SOL at %s %s", memo, r.sol(),
+				strconv.FormatFloat(r.rate, 'f', -1, 64), strings.ToUpper(s.currency)))
+		}
+		fmt.Fprintf(buf, "D%s\nT%s\nP%s\nN%s\n", r.at.Format("01/02/2006"), r.value(),
+			qifLine(r.receipt.Body.Merchant), qifLine(r.receipt.Body.ID))
+		if memo != "" {
+			fmt.Fprintf(buf, "M%s\n", qifLine(memo))
+		}
+		buf.WriteString("^\n")
+	}
+}
+
+// qifLine keeps a field on one line.
+func qifLine(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+// writeOFX writes an OFX 2 bank statement with the wallet as the account.
+func (s *Service) writeOFX(buf *bytes.Buffer, wallet string, rows []exportRow) {
+	currency := "SOL"
+	if s.pricing != nil {
+		currency = strings.ToUpper(s.currency)
+	}
+	now := time.Now().UTC()
+	start, end := now, now
+	if len(rows) > 0 {
+		start, end = rows[0].at, rows[len(rows)-1].at
+	}
+	var lamports int64
+	var fiat float64
+	for _, r := range rows {
+		lamports += r.lamports
+		fiat += r.fiat
+	}
+	balance := amount.Lamports(lamports).SOL()
+	if s.pricing != nil {
+		balance = strconv.FormatFloat(fiat, 'f', 2, 64)
+	}
+
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n")
+	buf.WriteString(`<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n")
+	buf.WriteString("<OFX>\n<SIGNONMSGSRSV1><SONRS>\n")
+	buf.WriteString("<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>\n")
+	fmt.Fprintf(buf, "<DTSERVER>%s</DTSERVER><LANGUAGE>ENG</LANGUAGE>\n", ofxTime(now))
+	buf.WriteString("</SONRS></SIGNONMSGSRSV1>\n<BANKMSGSRSV1><STMTTRNRS>\n<TRNUID>0</TRNUID>\n")
+	buf.WriteString("<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>\n<STMTRS>\n")
+	fmt.Fprintf(buf, "<CURDEF>%s</CURDEF>\n", ofxText(currency))
+	fmt.Fprintf(buf, "<BANKACCTFROM><BANKID>SHADOWPAY</BANKID><ACCTID>%s</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>\n", ofxText(wallet))
+	fmt.Fprintf(buf, "<BANKTRANLIST><DTSTART>%s</DTSTART><DTEND>%s</DTEND>\n", ofxTime(start), ofxTime(end))
+	for _, r := range rows {
+		kind := "CREDIT"
+		if r.lamports < 0 {
+			kind = "DEBIT"
+		}
+		buf.WriteString("<STMTTRN>")
+		fmt.Fprintf(buf, "<TRNTYPE>%s</TRNTYPE><DTPOSTED>%s</DTPOSTED><TRNAMT>%s</TRNAMT>", kind, ofxTime(r.at), r.value())
+		fmt.Fprintf(buf, "<FITID>%s</FITID><NAME>%s</NAME>", ofxText(r.receipt.Body.ID), ofxText(truncate(r.receipt.Body.Merchant, 32)))
+		if r.receipt.Body.Resource != "" {
+			fmt.Fprintf(buf, "<MEMO>%s</MEMO>", ofxText(truncate(r.receipt.Body.Resource, 255)))
+		}
+		buf.WriteString("</STMTTRN>\n")
+	}
+	buf.WriteString("</BANKTRANLIST>\n")
+	fmt.Fprintf(buf, "<LEDGERBAL><BALAMT>%s</BALAMT><DTASOF>%s</DTASOF></LEDGERBAL>\n",
+		balance, ofxTime(end))
+	buf.WriteString("</STMTRS>\n</STMTTRNRS></BANKMSGSRSV1>\n</OFX>\n")
+}
+
+// ofxTime formats t as an OFX datetime in UTC.
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405") + "[0:GMT]"
+}
+
+// ofxText escapes s for an OFX element.
+func ofxText(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// truncate cuts s to OFX's field length limits.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
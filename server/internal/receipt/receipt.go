@@ -2,12 +2,30 @@ package receipt
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
+
+	"sol_privacy/internal/pricing"
+	"sol_privacy/internal/types"
+	"sol_privacy/internal/validate"
 )
 
+// ErrUnverified is returned by MintNFT when the receipt's settler signature
+// does not verify.
+var ErrUnverified = errors.New("receipt signature not verified")
+
 // Service handles receipt operations for transaction verification and history.
 type Service struct {
 	doRequest func(ctx context.Context, method, path string, body, result interface{}) error
+
+	pricing  pricing.Provider // Values exported receipts, if set
+	currency string
+
+	caps types.Capabilities
 }
 
 // NewService creates a new receipt service.
@@ -17,6 +35,12 @@ func NewService(doRequest func(ctx context.Context, method, path string, body, r
 	}
 }
 
+// SetCapabilities enables NFT receipts if the API advertises them. Call it
+// before using the service.
+func (s *Service) SetCapabilities(c types.Capabilities) {
+	s.caps = c
+}
+
 // ReceiptBody contains the core receipt data.
 type ReceiptBody struct {
 	ID            string `json:"id"`
@@ -33,6 +57,14 @@ type Receipt struct {
 	Pubkey string      `json:"pubkey"` // Settler public key (base58)
 }
 
+// Hash returns the hex SHA-256 of the receipt's JSON encoding, body and
+// signature included. It identifies the receipt without revealing it.
+func (r Receipt) Hash() string {
+	data, _ := json.Marshal(r)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
 // GetByCommitmentResponse contains the receipt for a specific commitment.
 type GetByCommitmentResponse struct {
 	Receipt    Receipt `json:"receipt"`
@@ -68,11 +100,27 @@ type GetTreeResponse struct {
 	TreeMetadata  TreeMetadata `json:"tree_metadata"`
 }
 
+// MintNFTRequest asks for a compressed NFT recording a receipt's hash.
+type MintNFTRequest struct {
+	Commitment  string `json:"commitment"`
+	ReceiptHash string `json:"receipt_hash"`
+	Owner       string `json:"owner"` // The merchant, who receives the NFT and pays for the mint
+}
+
+// MintNFTResponse contains the unsigned compressed NFT mint transaction.
+type MintNFTResponse struct {
+	types.UnsignedTxResponse
+	ReceiptHash string `json:"receipt_hash"`
+	Owner       string `json:"owner"`
+	MerkleTree  string `json:"merkle_tree"`  // Bubblegum tree the NFT is appended to
+	MetadataURI string `json:"metadata_uri"` // Off-chain metadata; holds the receipt hash, no amounts
+}
+
 // GetByCommitment fetches a receipt by commitment hash.
 // Returns the signed receipt with verification status.
 func (s *Service) GetByCommitment(ctx context.Context, commitment string) (*GetByCommitmentResponse, error) {
 	var resp GetByCommitmentResponse
-	path := fmt.Sprintf("/shadowpay/api/receipts/by-commitment?commitment=%s", commitment)
+	path := fmt.Sprintf("/shadowpay/api/receipts/by-commitment?commitment=%s", url.QueryEscape(commitment))
 	if err := s.doRequest(ctx, "GET", path, nil, &resp); err != nil {
 		return nil, err
 	}
@@ -83,7 +131,7 @@ func (s *Service) GetByCommitment(ctx context.Context, commitment string) (*GetB
 // Supports pagination via limit and offset parameters.
 func (s *Service) ListUserReceipts(ctx context.Context, walletAddress string, req ListUserReceiptsRequest) (*ListUserReceiptsResponse, error) {
 	var resp ListUserReceiptsResponse
-	path := fmt.Sprintf("/shadowpay/api/receipts/user/%s", walletAddress)
+	path := fmt.Sprintf("/shadowpay/api/receipts/user/%s", url.PathEscape(walletAddress))
 	if err := s.doRequest(ctx, "GET", path, req, &resp); err != nil {
 		return nil, err
 	}
@@ -94,9 +142,48 @@ func (s *Service) ListUserReceipts(ctx context.Context, walletAddress string, re
 // The tree allows compact verification of receipt authenticity.
 func (s *Service) GetTree(ctx context.Context, walletAddress string) (*GetTreeResponse, error) {
 	var resp GetTreeResponse
-	path := fmt.Sprintf("/shadowpay/api/receipts/tree/%s", walletAddress)
+	path := fmt.Sprintf("/shadowpay/api/receipts/tree/%s", url.PathEscape(walletAddress))
 	if err := s.doRequest(ctx, "GET", path, nil, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
 }
+
+// MintNFT builds an unsigned transaction minting a compressed NFT receipt to
+// the merchant of the payment with this commitment, as on-chain proof of
+// purchase. The NFT's metadata is the receipt hash alone, so amounts and the
+// resource stay private; holders of the full receipt can show it matches.
+// The merchant signs and pays for the mint.
+func (s *Service) MintNFT(ctx context.Context, commitment string) (*MintNFTResponse, error) {
+	if err := validate.New().Commitment("commitment", commitment).Err(); err != nil {
+		return nil, err
+	}
+	if err := types.Require(ctx, s.caps, types.FeatureReceiptNFTs); err != nil {
+		return nil, err
+	}
+	rec, err := s.GetByCommitment(ctx, commitment)
+	if err != nil {
+		return nil, err
+	}
+	if !rec.Verified {
+		return nil, ErrUnverified
+	}
+	req := MintNFTRequest{
+		Commitment:  commitment,
+		ReceiptHash: rec.Receipt.Hash(),
+		Owner:       rec.Receipt.Body.Merchant,
+	}
+	var resp MintNFTResponse
+	if err := s.doRequest(ctx, "POST", "/shadowpay/api/receipts/mint-nft", req, &resp); err != nil {
+		return nil, err
+	}
+	resp.ReceiptHash, resp.Owner = req.ReceiptHash, req.Owner
+	resp.WithRebuild(func(ctx context.Context) (*types.UnsignedTxResponse, error) {
+		fresh, err := s.MintNFT(ctx, commitment)
+		if err != nil {
+			return nil, err
+		}
+		return &fresh.UnsignedTxResponse, nil
+	})
+	return &resp, nil
+}
@@ -0,0 +1,308 @@
+// Package reconcile cross-checks the settlements and withdrawals the proxy
+// recorded against the cluster and the ShadowPay receipts, to catch
+// transactions that were dropped after being acknowledged and settlements
+// the proxy gave up on that went through anyway, e.g. because a webhook or
+// response was lost.
+//
+// Each run looks up the signature status of every recorded transaction in
+// the window (backfilling what the proxy never confirmed), matches
+// settlements to the merchant's receipts by resource and amount, and reports
+// the mismatches it finds.
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/base58"
+	"sol_privacy/internal/receipt"
+	"sol_privacy/internal/solana"
+)
+
+// Record kinds.
+const (
+	KindSettlement = "settlement"
+	KindWithdrawal = "withdrawal"
+)
+
+// Finding kinds.
+const (
+	// MissingOnChain is a recorded transaction the cluster does not know.
+	MissingOnChain = "missing_on_chain"
+	// FailedOnChain is a recorded transaction that executed with an error.
+	FailedOnChain = "failed_on_chain"
+	// MissingReceipt is a succeeded settlement without a matching receipt.
+	MissingReceipt = "missing_receipt"
+	// SettledUpstream is a failed settlement with a matching receipt.
+	SettledUpstream = "settled_upstream"
+)
+
+// Defaults.
+const (
+	DefaultWindow = 7 * 24 * time.Hour
+	DefaultGrace  = 10 * time.Minute
+
+	// maxSignatures is the most statuses getSignatureStatuses returns at once
+	maxSignatures = 256
+	receiptPage   = 100
+	maxReceipts   = 5000
+)
+
+// Record is a locally recorded settlement or withdrawal.
+type Record struct {
+	Kind      string `json:"kind"`
+	ID        string `json:"id"`                  // Settlement job or ledger entry ID
+	Signature string `json:"signature,omitempty"` // Transaction signature, if known
+	Wallet    string `json:"wallet"`              // Paid merchant, or withdrawing wallet
+	Resource  string `json:"resource,omitempty"`
+	Lamports  int64  `json:"lamports,omitempty"`
+	Succeeded bool   `json:"succeeded"`
+	At        int64  `json:"at"` // Unix seconds
+}
+
+// Finding is a mismatch between a record and the cluster or receipts.
+type Finding struct {
+	Kind    string           `json:"kind"`
+	Record  Record           `json:"record"`
+	Receipt *receipt.Receipt `json:"receipt,omitempty"`
+	Detail  string           `json:"detail"`
+}
+
+// Report is the result of a reconciliation run.
+type Report struct {
+	StartedAt  int64     `json:"started_at"`
+	FinishedAt int64     `json:"finished_at"`
+	Records    int       `json:"records"`   // Checked, within the window
+	Confirmed  int       `json:"confirmed"` // Signatures the cluster confirmed
+	Receipts   int       `json:"receipts"`  // Fetched upstream
+	Findings   []Finding `json:"findings"`
+	Errors     []string  `json:"errors,omitempty"` // Lookups that failed; their records were skipped
+}
+
+// Config configures a Reconciler.
+type Config struct {
+	// Records lists the recorded settlements and withdrawals.
+	Records func(ctx context.Context) ([]Record, error)
+	// Statuses looks up signatures, as solana.Client.GetSignatureStatuses.
+	Statuses func(ctx context.Context, signatures []string) ([]*solana.SignatureStatus, error)
+	// Receipts lists a merchant's receipts, as receipt.Service.ListUserReceipts.
+	Receipts func(ctx context.Context, wallet string, req receipt.ListUserReceiptsRequest) (*receipt.ListUserReceiptsResponse, error)
+
+	Window   time.Duration // How far back records are checked, defaults to DefaultWindow
+	Grace    time.Duration // How long a transaction may take to land, defaults to DefaultGrace
+	Interval time.Duration // Between runs of Run; zero runs only on demand
+
+	// OnFinding is called for each finding the first time it is reported.
+	OnFinding func(ctx context.Context, f Finding)
+}
+
+// Reconciler runs reconciliations and keeps the latest report.
+type Reconciler struct {
+	config Config
+
+	run      sync.Mutex // One run at a time
+	mu       sync.Mutex
+	last     *Report
+	reported map[string]bool // Finding kind and record, already passed to OnFinding
+}
+
+// New creates a reconciler.
+func New(config Config) *Reconciler {
+	if config.Window == 0 {
+		config.Window = DefaultWindow
+	}
+	if config.Grace == 0 {
+		config.Grace = DefaultGrace
+	}
+	return &Reconciler{config: config, reported: make(map[string]bool)}
+}
+
+// Last returns the latest report, or nil before the first run.
+func (r *Reconciler) Last() *Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.last
+}
+
+// Run reconciles every Interval until ctx is done. It does nothing if
+// Interval is zero.
+func (r *Reconciler) Run(ctx context.Context) {
+	if r.config.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := r.Reconcile(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("reconciliation failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reconcile runs a reconciliation now and returns its report.
+func (r *Reconciler) Reconcile(ctx context.Context) (*Report, error) {
+	r.run.Lock()
+	defer r.run.Unlock()
+
+	now := time.Now()
+	report := &Report{StartedAt: now.Unix(), Findings: []Finding{}}
+	all, err := r.config.Records(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list records: %w", err)
+	}
+	from := now.Add(-r.config.Window).Unix()
+	settled := now.Add(-r.config.Grace).Unix() // Older records should have landed
+	var records []Record
+	for _, rec := range all {
+		if rec.At >= from {
+			records = append(records, rec)
+		}
+	}
+	report.Records = len(records)
+
+	r.checkSignatures(ctx, records, settled, report)
+	r.checkReceipts(ctx, records, settled, report)
+
+	report.FinishedAt = time.Now().Unix()
+	r.mu.Lock()
+	r.last = report
+	var fresh []Finding
+	for _, f := range report.Findings {
+		key := f.Kind + "/" + f.Record.Kind + "/" + f.Record.ID
+		if !r.reported[key] {
+			r.reported[key] = true
+			fresh = append(fresh, f)
+		}
+	}
+	r.mu.Unlock()
+	if r.config.OnFinding != nil {
+		for _, f := range fresh {
+			r.config.OnFinding(ctx, f)
+		}
+	}
+	return report, nil
+}
+
+// checkSignatures looks up the recorded signatures, in batches.
+func (r *Reconciler) checkSignatures(ctx context.Context, records []Record, settled int64, report *Report) {
+	var pending []Record
+	for _, rec := range records {
+		if rec.Succeeded && isSignature(rec.Signature) {
+			pending = append(pending, rec)
+		}
+	}
+	for len(pending) > 0 {
+		batch := pending[:min(len(pending), maxSignatures)]
+		pending = pending[len(batch):]
+		sigs := make([]string, len(batch))
+		for i, rec := range batch {
+			sigs[i] = rec.Signature
+		}
+		statuses, err := r.config.Statuses(ctx, sigs)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("signature statuses: %v", err))
+			continue
+		}
+		for i, rec := range batch {
+			var status *solana.SignatureStatus
+			if i < len(statuses) {
+				status = statuses[i]
+			}
+			switch {
+			case status == nil:
+				if rec.At < settled {
+					report.Findings = append(report.Findings, Finding{Kind: MissingOnChain, Record: rec,
+						Detail: "the cluster does not know the transaction"})
+				}
+			case status.Failed():
+				report.Findings = append(report.Findings, Finding{Kind: FailedOnChain, Record: rec,
+					Detail: "the transaction failed: " + string(status.Err)})
+			default:
+				report.Confirmed++
+			}
+		}
+	}
+}
+
+// checkReceipts matches settlements to their merchant's receipts by resource
+// and amount, each receipt matching one settlement.
+func (r *Reconciler) checkReceipts(ctx context.Context, records []Record, settled int64, report *Report) {
+	byWallet := make(map[string][]Record)
+	var wallets []string
+	for _, rec := range records {
+		if rec.Kind != KindSettlement || rec.Wallet == "" || rec.At >= settled {
+			continue
+		}
+		if _, ok := byWallet[rec.Wallet]; !ok {
+			wallets = append(wallets, rec.Wallet)
+		}
+		byWallet[rec.Wallet] = append(byWallet[rec.Wallet], rec)
+	}
+
+	for _, wallet := range wallets {
+		receipts, err := r.receipts(ctx, wallet)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("receipts of %s: %v", wallet, err))
+			continue
+		}
+		report.Receipts += len(receipts)
+		used := make([]bool, len(receipts))
+		match := func(rec Record) *receipt.Receipt {
+			for i, rc := range receipts {
+				if !used[i] && rc.Body.Resource == rec.Resource && rc.Body.AmountLamports == rec.Lamports {
+					used[i] = true
+					return &receipts[i]
+				}
+			}
+			return nil
+		}
+		// Succeeded settlements claim their receipts before failed ones look
+		for _, succeeded := range []bool{true, false} {
+			for _, rec := range byWallet[wallet] {
+				if rec.Succeeded != succeeded {
+					continue
+				}
+				rc := match(rec)
+				switch {
+				case succeeded && rc == nil:
+					report.Findings = append(report.Findings, Finding{Kind: MissingReceipt, Record: rec,
+						Detail: "no receipt for the resource and amount"})
+				case !succeeded && rc != nil:
+					report.Findings = append(report.Findings, Finding{Kind: SettledUpstream, Record: rec, Receipt: rc,
+						Detail: "recorded as failed, but a receipt shows it settled"})
+				}
+			}
+		}
+	}
+}
+
+// receipts lists a wallet's receipts, page by page.
+func (r *Reconciler) receipts(ctx context.Context, wallet string) ([]receipt.Receipt, error) {
+	var out []receipt.Receipt
+	for len(out) < maxReceipts {
+		resp, err := r.config.Receipts(ctx, wallet, receipt.ListUserReceiptsRequest{Limit: receiptPage, Offset: len(out)})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, resp.Receipts...)
+		if len(resp.Receipts) == 0 || len(out) >= resp.TotalCount {
+			break
+		}
+	}
+	return out, nil
+}
+
+// isSignature reports whether s is a transaction signature rather than, say,
+// a withdrawal ID.
+func isSignature(s string) bool {
+	b, err := base58.Decode(s)
+	return err == nil && len(b) == 64
+}
@@ -0,0 +1,54 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Client calls the registry hosted by a proxy, e.g. through proxyclient.Client.Do.
+type Client struct {
+	doRequest func(ctx context.Context, method, path string, body, result interface{}) error
+}
+
+// NewClient creates a registry client.
+func NewClient(doRequest func(ctx context.Context, method, path string, body, result interface{}) error) *Client {
+	return &Client{doRequest: doRequest}
+}
+
+// Register publishes or updates a handle. Sign the request first.
+func (c *Client) Register(ctx context.Context, req RegisterRequest) (*Record, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var r Record
+	if err := c.doRequest(ctx, "POST", "/registry", req, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// Lookup resolves a handle or ShadowID commitment. The record's signature is
+// checked, so the proxy cannot substitute its own meta-address.
+func (c *Client) Lookup(ctx context.Context, key string) (*Record, error) {
+	var r Record
+	if err := c.doRequest(ctx, "GET", "/registry/"+url.PathEscape(key), nil, &r); err != nil {
+		return nil, err
+	}
+	if r.Handle != NormalizeHandle(key) && !strings.EqualFold(r.Commitment, key) {
+		return nil, fmt.Errorf("registry returned @%s for %s", r.Handle, key)
+	}
+	if err := r.Verify(); err != nil {
+		return nil, fmt.Errorf("registry record for %s is not signed by its owner: %w", key, err)
+	}
+	return &r, nil
+}
+
+// Unregister removes a handle. Sign the request first.
+func (c *Client) Unregister(ctx context.Context, req UnregisterRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	return c.doRequest(ctx, "POST", "/registry/"+url.PathEscape(NormalizeHandle(req.Handle))+"/unregister", req, nil)
+}
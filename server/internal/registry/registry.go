@@ -0,0 +1,355 @@
+// Package registry publishes stealth meta-addresses under human-readable
+// handles, so senders can pay "@alice" privately without exchanging keys out
+// of band.
+//
+// A record maps a handle, and optionally a ShadowID commitment, to the
+// owner's stealth meta-address (see package stealth). Records are registered,
+// updated and removed with messages signed by the owner's wallet, which alone
+// may change them afterwards. A commitment is asserted by the owner, not
+// proven: proving it would reveal the identity's secrets.
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/base58"
+	"sol_privacy/internal/jsonfile"
+	"sol_privacy/internal/stealth"
+	"sol_privacy/internal/validate"
+	"sol_privacy/internal/wallet"
+)
+
+// MaxClockSkew bounds how far a signed request's timestamp may be from now.
+const MaxClockSkew = 10 * time.Minute
+
+// Actions signed by owners.
+const (
+	ActionRegister   = "register"
+	ActionUnregister = "unregister"
+)
+
+var (
+	// ErrNotFound is returned for handles and commitments with no record.
+	ErrNotFound = errors.New("handle not found")
+	// ErrHandleTaken is returned when another wallet owns the handle.
+	ErrHandleTaken = errors.New("handle is registered to another wallet")
+	// ErrCommitmentTaken is returned when another handle has the commitment.
+	ErrCommitmentTaken = errors.New("commitment is registered to another handle")
+	// ErrStale is returned for signed requests that are too old or in the
+	// future, or older than the record they would change, so they cannot be
+	// replayed.
+	ErrStale = errors.New("signed request is stale")
+)
+
+var handlePattern = regexp.MustCompile(`^[a-z0-9_]{3,32}$`)
+
+// Record is a published meta-address.
+type Record struct {
+	Handle       string `json:"handle"`               // Lowercase, without "@"
+	Commitment   string `json:"commitment,omitempty"` // ShadowID commitment asserted by the owner
+	MetaAddress  string `json:"meta_address"`         // Base58 X25519 scan public key
+	Owner        string `json:"owner"`                // Wallet that signed the record
+	Timestamp    int64  `json:"timestamp"`            // When the owner signed it
+	Signature    string `json:"signature"`            // Base58 signature of the register message
+	RegisteredAt int64  `json:"registered_at"`
+	UpdatedAt    int64  `json:"updated_at"`
+}
+
+// Verify checks the owner's signature, so records fetched from a registry
+// need not be trusted blindly.
+func (r Record) Verify() error {
+	req := RegisterRequest{
+		Handle:      r.Handle,
+		Commitment:  r.Commitment,
+		MetaAddress: r.MetaAddress,
+		Owner:       r.Owner,
+		Timestamp:   r.Timestamp,
+		Signature:   r.Signature,
+	}
+	return wallet.VerifySignature(r.Owner, []byte(req.Message()), r.Signature)
+}
+
+// Pay derives a fresh stealth address to pay the record's owner.
+func (r Record) Pay() (*stealth.Payment, error) {
+	ephemeral, err := stealth.NewScanKey(nil)
+	if err != nil {
+		return nil, err
+	}
+	return stealth.Derive(r.MetaAddress, ephemeral)
+}
+
+// RegisterRequest registers or updates a handle.
+type RegisterRequest struct {
+	Handle      string `json:"handle"`
+	Commitment  string `json:"commitment,omitempty"`
+	MetaAddress string `json:"meta_address"`
+	Owner       string `json:"owner"`
+	Timestamp   int64  `json:"timestamp"` // Unix timestamp, set by Sign
+	Signature   string `json:"signature"` // Set by Sign
+}
+
+// Message returns the canonical message the owner signs.
+func (r RegisterRequest) Message() string {
+	return fmt.Sprintf("ShadowPay Stealth Registry v1\nAction: %s\nHandle: @%s\nCommitment: %s\nMeta-address: %s\nOwner: %s\nTimestamp: %d",
+		ActionRegister, NormalizeHandle(r.Handle), r.Commitment, r.MetaAddress, r.Owner, r.Timestamp)
+}
+
+// Sign sets the owner and timestamp and signs the request with the owner's
+// wallet.
+func (r *RegisterRequest) Sign(signer wallet.Signer) error {
+	r.Owner = signer.Address()
+	r.Timestamp = time.Now().Unix()
+	sig, err := signer.SignMessage([]byte(r.Message()))
+	if err != nil {
+		return fmt.Errorf("failed to sign registration: %w", err)
+	}
+	r.Signature = base58.Encode(sig)
+	return nil
+}
+
+// Validate checks the request fields.
+func (r RegisterRequest) Validate() error {
+	v := validate.New()
+	if !handlePattern.MatchString(NormalizeHandle(r.Handle)) {
+		v.Add("handle", fmt.Errorf("must be 3-32 lowercase letters, digits or underscores"))
+	}
+	if r.Commitment != "" {
+		v.Commitment("commitment", r.Commitment)
+	}
+	if b, err := base58.Decode(r.MetaAddress); err != nil || len(b) != 32 {
+		v.Add("meta_address", fmt.Errorf("must be a base58 X25519 public key"))
+	}
+	v.Address("owner", r.Owner).Required("signature", r.Signature)
+	return v.Err()
+}
+
+// UnregisterRequest removes a handle.
+type UnregisterRequest struct {
+	Handle    string `json:"handle"`
+	Owner     string `json:"owner"`
+	Timestamp int64  `json:"timestamp"`
+	Signature string `json:"signature"`
+}
+
+// Message returns the canonical message the owner signs.
+func (r UnregisterRequest) Message() string {
+	return fmt.Sprintf("ShadowPay Stealth Registry v1\nAction: %s\nHandle: @%s\nOwner: %s\nTimestamp: %d",
+		ActionUnregister, NormalizeHandle(r.Handle), r.Owner, r.Timestamp)
+}
+
+// Sign sets the owner and timestamp and signs the request with the owner's
+// wallet.
+func (r *UnregisterRequest) Sign(signer wallet.Signer) error {
+	r.Owner = signer.Address()
+	r.Timestamp = time.Now().Unix()
+	sig, err := signer.SignMessage([]byte(r.Message()))
+	if err != nil {
+		return fmt.Errorf("failed to sign unregistration: %w", err)
+	}
+	r.Signature = base58.Encode(sig)
+	return nil
+}
+
+// Validate checks the request fields.
+func (r UnregisterRequest) Validate() error {
+	return validate.New().
+		Required("handle", r.Handle).
+		Address("owner", r.Owner).
+		Required("signature", r.Signature).
+		Err()
+}
+
+// NormalizeHandle lowercases a handle and strips its "@".
+func NormalizeHandle(handle string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))
+}
+
+// Store persists records by handle.
+type Store interface {
+	Get(handle string) (*Record, error) // Returns ErrNotFound for unknown handles
+	Put(r *Record) error
+	Delete(handle string) error
+	List() ([]*Record, error)
+}
+
+// NewMemoryStore creates an empty in-memory Store.
+func NewMemoryStore() Store {
+	return store{jsonfile.NewMemoryStore(recordHandle, nil)}
+}
+
+// NewFileStore opens a Store persisted to a JSON file after every write,
+// creating it on first write.
+func NewFileStore(path string) (Store, error) {
+	records, err := jsonfile.NewStore(path, recordHandle, nil)
+	if err != nil {
+		return nil, err
+	}
+	return store{records}, nil
+}
+
+func recordHandle(r *Record) string { return r.Handle }
+
+// store keeps records in a jsonfile.Store.
+type store struct {
+	records *jsonfile.Store[Record]
+}
+
+func (s store) Get(handle string) (*Record, error) {
+	r, ok := s.records.Get(handle)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return r, nil
+}
+
+func (s store) Put(r *Record) error {
+	return s.records.Put(r)
+}
+
+func (s store) Delete(handle string) error {
+	r, err := s.records.Delete(handle)
+	if r == nil && err == nil {
+		return ErrNotFound
+	}
+	return err
+}
+
+// List returns all records, by handle.
+func (s store) List() ([]*Record, error) {
+	return s.records.List(func(a, b *Record) bool { return a.Handle < b.Handle }), nil
+}
+
+// Service registers and looks up records.
+type Service struct {
+	store Store
+	mu    sync.Mutex // Serializes changes, keeping commitments unique
+	now   func() time.Time
+}
+
+// NewService creates a registry on store, in memory if nil.
+func NewService(store Store) *Service {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	return &Service{store: store, now: time.Now}
+}
+
+// Register creates or updates the handle's record. Only the wallet that
+// registered a handle may update it.
+func (s *Service) Register(ctx context.Context, req RegisterRequest) (*Record, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	req.Handle = NormalizeHandle(req.Handle)
+	if err := wallet.VerifySignature(req.Owner, []byte(req.Message()), req.Signature); err != nil {
+		return nil, validate.Errors{{Field: "signature", Message: err.Error()}}
+	}
+	now := s.now()
+	if err := checkTimestamp(req.Timestamp, now); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, err := s.store.Get(req.Handle)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	if existing != nil {
+		if existing.Owner != req.Owner {
+			return nil, ErrHandleTaken
+		}
+		if req.Timestamp < existing.Timestamp {
+			return nil, ErrStale
+		}
+	}
+	if req.Commitment != "" {
+		if other, err := s.byCommitment(req.Commitment); err == nil && other.Handle != req.Handle {
+			return nil, ErrCommitmentTaken
+		}
+	}
+
+	r := &Record{
+		Handle:       req.Handle,
+		Commitment:   req.Commitment,
+		MetaAddress:  req.MetaAddress,
+		Owner:        req.Owner,
+		Timestamp:    req.Timestamp,
+		Signature:    req.Signature,
+		RegisteredAt: now.Unix(),
+		UpdatedAt:    now.Unix(),
+	}
+	if existing != nil {
+		r.RegisteredAt = existing.RegisteredAt
+	}
+	if err := s.store.Put(r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Unregister removes the handle's record, signed by its owner.
+func (s *Service) Unregister(ctx context.Context, req UnregisterRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	req.Handle = NormalizeHandle(req.Handle)
+	if err := wallet.VerifySignature(req.Owner, []byte(req.Message()), req.Signature); err != nil {
+		return validate.Errors{{Field: "signature", Message: err.Error()}}
+	}
+	if err := checkTimestamp(req.Timestamp, s.now()); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, err := s.store.Get(req.Handle)
+	if err != nil {
+		return err
+	}
+	if existing.Owner != req.Owner {
+		return ErrHandleTaken
+	}
+	if req.Timestamp < existing.Timestamp {
+		return ErrStale
+	}
+	return s.store.Delete(req.Handle)
+}
+
+// Lookup returns the record of a handle ("alice" or "@alice") or ShadowID
+// commitment.
+func (s *Service) Lookup(ctx context.Context, key string) (*Record, error) {
+	if validate.Commitment(key) == nil && !strings.HasPrefix(key, "@") {
+		if r, err := s.byCommitment(key); err == nil {
+			return r, nil
+		}
+	}
+	return s.store.Get(NormalizeHandle(key))
+}
+
+func (s *Service) byCommitment(commitment string) (*Record, error) {
+	records, err := s.store.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		if r.Commitment != "" && strings.EqualFold(r.Commitment, commitment) {
+			return r, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func checkTimestamp(ts int64, now time.Time) error {
+	signed := time.Unix(ts, 0)
+	if signed.Before(now.Add(-MaxClockSkew)) || signed.After(now.Add(MaxClockSkew)) {
+		return ErrStale
+	}
+	return nil
+}
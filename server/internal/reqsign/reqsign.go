@@ -0,0 +1,170 @@
+// Package reqsign signs API requests with a wallet's Ed25519 key and verifies
+// them on the proxy, proving the caller holds the wallet key in addition to
+// the API key.
+//
+// The signed message is a canonical form of the request: method, path,
+// sorted query, timestamp and the SHA-256 of the body. A signature is valid
+// for MaxAge around its timestamp and is accepted once.
+package reqsign
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/base58"
+	"sol_privacy/internal/wallet"
+)
+
+// Headers carrying the signature.
+const (
+	HeaderWallet    = "X-Wallet-Address"
+	HeaderTimestamp = "X-Signature-Timestamp"
+	HeaderSignature = "X-Wallet-Signature"
+)
+
+// DefaultMaxAge is how far a signature's timestamp may be from the verifier's
+// clock.
+const DefaultMaxAge = 5 * time.Minute
+
+// maxBody bounds the body read to compute its digest.
+const maxBody = 10 << 20
+
+var (
+	ErrMissing   = errors.New("reqsign: request signature required")
+	ErrMalformed = errors.New("reqsign: malformed request signature headers")
+	ErrExpired   = errors.New("reqsign: request signature expired")
+	ErrReplayed  = errors.New("reqsign: request signature already used")
+	ErrInvalid   = errors.New("reqsign: invalid request signature")
+)
+
+// Sign attaches a signature of req by signer. The body is read and replaced,
+// so Sign must run after the body is final.
+func Sign(req *http.Request, signer wallet.Signer) error {
+	body, err := readBody(req)
+	if err != nil {
+		return fmt.Errorf("reqsign: %w", err)
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	sig, err := signer.SignMessage(Message(req, ts, body))
+	if err != nil {
+		return fmt.Errorf("reqsign: %w", err)
+	}
+	req.Header.Set(HeaderWallet, signer.Address())
+	req.Header.Set(HeaderTimestamp, ts)
+	req.Header.Set(HeaderSignature, base58.Encode(sig))
+	return nil
+}
+
+// Message returns the canonical text signed for req with timestamp ts.
+func Message(req *http.Request, ts string, body []byte) []byte {
+	digest := sha256.Sum256(body)
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	return []byte(strings.Join([]string{
+		"shadowpay-request-v1",
+		req.Method,
+		path,
+		req.URL.Query().Encode(), // Sorted by key
+		ts,
+		hex.EncodeToString(digest[:]),
+	}, "\n"))
+}
+
+// Signed reports whether req carries signature headers.
+func Signed(req *http.Request) bool {
+	return req.Header.Get(HeaderSignature) != ""
+}
+
+// Verifier checks request signatures and remembers them until they expire so
+// a captured request cannot be replayed.
+type Verifier struct {
+	maxAge time.Duration
+	now    func() time.Time
+
+	mu   sync.Mutex
+	seen map[string]time.Time // Signature -> expiry
+}
+
+// NewVerifier creates a verifier accepting timestamps within maxAge of now,
+// DefaultMaxAge if zero.
+func NewVerifier(maxAge time.Duration) *Verifier {
+	if maxAge <= 0 {
+		maxAge = DefaultMaxAge
+	}
+	return &Verifier{maxAge: maxAge, now: time.Now, seen: make(map[string]time.Time)}
+}
+
+// Verify checks the signature of req and returns the signing wallet. The
+// body is read and replaced so handlers can still decode it.
+func (v *Verifier) Verify(req *http.Request) (string, error) {
+	address := req.Header.Get(HeaderWallet)
+	sig := req.Header.Get(HeaderSignature)
+	ts := req.Header.Get(HeaderTimestamp)
+	if sig == "" {
+		return "", ErrMissing
+	}
+	if address == "" || ts == "" {
+		return "", ErrMalformed
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return "", ErrMalformed
+	}
+	now := v.now()
+	signedAt := time.Unix(unix, 0)
+	if signedAt.Before(now.Add(-v.maxAge)) || signedAt.After(now.Add(v.maxAge)) {
+		return "", ErrExpired
+	}
+
+	body, err := readBody(req)
+	if err != nil {
+		return "", fmt.Errorf("reqsign: %w", err)
+	}
+	if err := wallet.VerifySignature(address, Message(req, ts, body), sig); err != nil {
+		return "", ErrInvalid
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for s, exp := range v.seen {
+		if now.After(exp) {
+			delete(v.seen, s)
+		}
+	}
+	if _, ok := v.seen[sig]; ok {
+		return "", ErrReplayed
+	}
+	v.seen[sig] = signedAt.Add(v.maxAge)
+	return address, nil
+}
+
+// readBody returns the body of req and replaces it with a fresh reader.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxBody+1))
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxBody {
+		return nil, errors.New("request body too large to sign")
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
@@ -0,0 +1,297 @@
+// Package resolve resolves Solana Name Service (.sol) domains to the wallets
+// that own them, and wallets back to their primary domain, so users can pay
+// names instead of raw addresses:
+//
+//	addr, err := resolve.Address(ctx, "alice.sol")
+//	name, err := resolve.Reverse(ctx, addr) // "alice.sol"
+//
+// Lookups read the name accounts over RPC and are cached. Names are resolved
+// on the cluster at SNS_RPC_URL, then SOLANA_RPC_URL, then mainnet-beta,
+// where .sol domains are registered.
+package resolve
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/base58"
+	"sol_privacy/internal/solana"
+)
+
+// DefaultTTL is how long resolved names and addresses are cached.
+const DefaultTTL = 5 * time.Minute
+
+// Name Service accounts.
+const (
+	NameProgramID       = "namesLPneVptA9Z5rqUDD9tMTWEJwofgaYwp8cawRkX"
+	RootDomain          = "58PwtjSDuFHuUkYjH9BYnnQKHfwo9reZhC2zMJv9JPkx" // Parent of .sol domains
+	ReverseLookupClass  = "33m47vH6Eav6jr5Ry86XjhRft2jRBLDnDgPSHoquXi2Z"
+	NameOffersProgramID = "85iDfUvr3HJyLM2zcq5BXSiDvUWfw6cSE1FfNBo8Ap29" // Holds primary domains
+)
+
+const (
+	hashPrefix = "SPL Name Service"
+	// Name accounts start with parent | owner | class, 32 bytes each
+	headerSize = 96
+)
+
+var (
+	// ErrNotFound is returned for unregistered names and wallets without a
+	// primary domain.
+	ErrNotFound = errors.New("name not found")
+	// ErrInvalidName is returned for values that are not .sol domains.
+	ErrInvalidName = errors.New("invalid .sol name")
+)
+
+// IsName reports whether s looks like a .sol domain rather than an address.
+func IsName(s string) bool {
+	s = strings.ToLower(strings.TrimSpace(s))
+	return len(s) > len(".sol") && strings.HasSuffix(s, ".sol")
+}
+
+// Normalize lowercases a domain and checks it is a name or subdomain under .sol.
+func Normalize(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !IsName(name) {
+		return "", ErrInvalidName
+	}
+	labels := strings.Split(strings.TrimSuffix(name, ".sol"), ".")
+	if len(labels) > 2 {
+		return "", fmt.Errorf("%w: at most one subdomain level is supported", ErrInvalidName)
+	}
+	for _, l := range labels {
+		if l == "" || strings.ContainsAny(l, " \t\r\n/") {
+			return "", ErrInvalidName
+		}
+	}
+	return name, nil
+}
+
+// Resolver looks up .sol domains over RPC, caching results.
+type Resolver struct {
+	rpc *solana.Client
+	ttl time.Duration
+
+	mu    sync.Mutex
+	cache map[string]entry
+}
+
+type entry struct {
+	value     string // Empty for cached ErrNotFound
+	expiresAt time.Time
+}
+
+// NewResolver creates a resolver backed by the given RPC client.
+// A zero ttl uses DefaultTTL.
+func NewResolver(rpc *solana.Client, ttl time.Duration) *Resolver {
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+	return &Resolver{rpc: rpc, ttl: ttl, cache: make(map[string]entry)}
+}
+
+var (
+	defaultOnce     sync.Once
+	defaultResolver *Resolver
+)
+
+// Default returns the resolver used by Address and Reverse.
+func Default() *Resolver {
+	defaultOnce.Do(func() {
+		url := os.Getenv("SNS_RPC_URL")
+		if url == "" {
+			url = os.Getenv("SOLANA_RPC_URL")
+		}
+		defaultResolver = NewResolver(solana.NewClient(solana.Config{URL: url}), 0)
+	})
+	return defaultResolver
+}
+
+// Address resolves a .sol domain with the default resolver.
+func Address(ctx context.Context, name string) (string, error) {
+	return Default().Address(ctx, name)
+}
+
+// Reverse returns a wallet's primary .sol domain with the default resolver.
+func Reverse(ctx context.Context, address string) (string, error) {
+	return Default().Reverse(ctx, address)
+}
+
+// Recipient resolves value with the default resolver if it is a .sol domain
+// and returns it unchanged otherwise.
+func Recipient(ctx context.Context, value string) (string, error) {
+	return Default().Recipient(ctx, value)
+}
+
+// Recipient resolves value if it is a .sol domain and returns it unchanged
+// otherwise.
+func (r *Resolver) Recipient(ctx context.Context, value string) (string, error) {
+	if !IsName(value) {
+		return value, nil
+	}
+	return r.Address(ctx, value)
+}
+
+// Address returns the wallet owning a .sol domain, e.g. "alice.sol" or
+// "pay.alice.sol". It returns ErrNotFound for unregistered domains.
+func (r *Resolver) Address(ctx context.Context, name string) (string, error) {
+	name, err := Normalize(name)
+	if err != nil {
+		return "", err
+	}
+	return r.cached(ctx, "name:"+name, func() (string, error) {
+		key, err := domainKey(name)
+		if err != nil {
+			return "", err
+		}
+		account, err := r.rpc.GetAccountInfo(ctx, base58.Encode(key))
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch name account: %w", err)
+		}
+		if account == nil || len(account.Data) < headerSize {
+			return "", ErrNotFound
+		}
+		owner := account.Data[32:64]
+		if isZero(owner) {
+			return "", ErrNotFound
+		}
+		return base58.Encode(owner), nil
+	})
+}
+
+// Reverse returns the primary .sol domain a wallet has set. It returns
+// ErrNotFound if there is none, or if the domain no longer resolves to the
+// wallet.
+func (r *Resolver) Reverse(ctx context.Context, address string) (string, error) {
+	owner, err := base58.Decode(address)
+	if err != nil || len(owner) != 32 {
+		return "", fmt.Errorf("invalid address %q", address)
+	}
+	name, err := r.cached(ctx, "address:"+address, func() (string, error) {
+		favourite, _, err := solana.FindProgramAddress([][]byte{[]byte("favourite_domain"), owner}, NameOffersProgramID)
+		if err != nil {
+			return "", err
+		}
+		account, err := r.rpc.GetAccountInfo(ctx, favourite)
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch primary domain: %w", err)
+		}
+		// Layout: tag(1) | name account(32)
+		if account == nil || len(account.Data) < 33 {
+			return "", ErrNotFound
+		}
+		return r.reverseLookup(ctx, account.Data[1:33])
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// The primary domain may have been transferred since it was set
+	resolved, err := r.Address(ctx, name)
+	if errors.Is(err, ErrNotFound) || (err == nil && resolved != address) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// reverseLookup reads the domain name of a name account from its reverse
+// lookup account, whose data is a little-endian u32 length and the name.
+func (r *Resolver) reverseLookup(ctx context.Context, nameAccount []byte) (string, error) {
+	class, _ := base58.Decode(ReverseLookupClass)
+	key, err := nameAccountKey(hashName(base58.Encode(nameAccount)), class, nil)
+	if err != nil {
+		return "", err
+	}
+	account, err := r.rpc.GetAccountInfo(ctx, base58.Encode(key))
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch reverse lookup: %w", err)
+	}
+	if account == nil || len(account.Data) < headerSize+4 {
+		return "", ErrNotFound
+	}
+	data := account.Data[headerSize:]
+	n := int(binary.LittleEndian.Uint32(data))
+	if len(data) < 4+n {
+		return "", fmt.Errorf("reverse lookup account truncated")
+	}
+	label := strings.TrimLeft(string(data[4:4+n]), "\x00")
+	if label == "" {
+		return "", ErrNotFound
+	}
+	return label + ".sol", nil
+}
+
+// cached returns the cached value for key, or loads and caches it. Only
+// ErrNotFound is cached among errors.
+func (r *Resolver) cached(ctx context.Context, key string, load func() (string, error)) (string, error) {
+	r.mu.Lock()
+	if e, ok := r.cache[key]; ok && time.Now().Before(e.expiresAt) {
+		r.mu.Unlock()
+		if e.value == "" {
+			return "", ErrNotFound
+		}
+		return e.value, nil
+	}
+	r.mu.Unlock()
+
+	value, err := load()
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return "", err
+	}
+	r.mu.Lock()
+	r.cache[key] = entry{value: value, expiresAt: time.Now().Add(r.ttl)}
+	r.mu.Unlock()
+	return value, err
+}
+
+// domainKey derives the name account of a normalized domain. Subdomains
+// hash with a leading zero byte under their parent's account.
+func domainKey(name string) ([]byte, error) {
+	root, _ := base58.Decode(RootDomain)
+	labels := strings.Split(strings.TrimSuffix(name, ".sol"), ".")
+	key, err := nameAccountKey(hashName(labels[len(labels)-1]), nil, root)
+	if err != nil || len(labels) == 1 {
+		return key, err
+	}
+	return nameAccountKey(hashName("\x00"+labels[0]), nil, key)
+}
+
+func hashName(name string) []byte {
+	h := sha256.Sum256([]byte(hashPrefix + name))
+	return h[:]
+}
+
+// nameAccountKey derives a name account from its hashed name, class and
+// parent; a nil class or parent is the zero key.
+func nameAccountKey(hashed, class, parent []byte) ([]byte, error) {
+	if class == nil {
+		class = make([]byte, 32)
+	}
+	if parent == nil {
+		parent = make([]byte, 32)
+	}
+	addr, _, err := solana.FindProgramAddress([][]byte{hashed, class, parent}, NameProgramID)
+	if err != nil {
+		return nil, err
+	}
+	return base58.Decode(addr)
+}
+
+func isZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,188 @@
+// Package secrets keeps proxy secrets such as the upstream API key encrypted
+// at rest. A sealed file maps environment variable names to values; it is
+// decrypted in memory at startup with a master key taken from the
+// environment, a file or a KMS command.
+package secrets
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Environment variables supplying the master key, in order of precedence.
+const (
+	MasterKeyEnv        = "SHADOWPAY_MASTER_KEY"
+	MasterKeyFileEnv    = "SHADOWPAY_MASTER_KEY_FILE"    // e.g. a systemd credential
+	MasterKeyCommandEnv = "SHADOWPAY_MASTER_KEY_COMMAND" // Prints the key, e.g. a KMS decrypt
+)
+
+// Key derivation parameters of sealed files.
+const (
+	kdfName       = "pbkdf2-sha256"
+	kdfIterations = 600_000
+	saltSize      = 16
+
+	// Iteration counts Open accepts. The count is read from the file, so
+	// without a ceiling a tampered file could stall startup in the KDF.
+	minIterations = 100_000
+	maxIterations = 10_000_000
+)
+
+var (
+	ErrNoMasterKey = errors.New("secrets: no master key; set " + MasterKeyEnv + ", " + MasterKeyFileEnv + " or " + MasterKeyCommandEnv)
+	ErrWrongKey    = errors.New("secrets: wrong master key or corrupted file")
+)
+
+// envelope is the on-disk format of a sealed file.
+type envelope struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// MasterKey returns the master key from the first of MasterKeyEnv,
+// MasterKeyFileEnv and MasterKeyCommandEnv that is set.
+func MasterKey() (string, error) {
+	if key := os.Getenv(MasterKeyEnv); key != "" {
+		return key, nil
+	}
+	if path := os.Getenv(MasterKeyFileEnv); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("secrets: master key: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	if command := os.Getenv(MasterKeyCommandEnv); command != "" {
+		var stderr bytes.Buffer
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("secrets: master key command: %w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		if key := strings.TrimSpace(string(out)); key != "" {
+			return key, nil
+		}
+		return "", errors.New("secrets: master key command printed nothing")
+	}
+	return "", ErrNoMasterKey
+}
+
+// Load decrypts the sealed file at path with the master key.
+func Load(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: %w", err)
+	}
+	key, err := MasterKey()
+	if err != nil {
+		return nil, err
+	}
+	return Open(data, key)
+}
+
+// Seal encrypts values with AES-256-GCM under a key derived from masterKey.
+func Seal(values map[string]string, masterKey string) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	rand.Read(salt)
+	aead, err := newAEAD(masterKey, salt, kdfIterations)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	rand.Read(nonce)
+	return json.MarshalIndent(envelope{
+		Version:    1,
+		KDF:        kdfName,
+		Iterations: kdfIterations,
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, nil),
+	}, "", "  ")
+}
+
+// Open decrypts a sealed file.
+func Open(data []byte, masterKey string) (map[string]string, error) {
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("secrets: %w", err)
+	}
+	if env.Version != 1 || env.KDF != kdfName {
+		return nil, errors.New("secrets: unsupported file format")
+	}
+	if env.Iterations < minIterations || env.Iterations > maxIterations {
+		return nil, fmt.Errorf("secrets: %d KDF iterations is outside %d to %d", env.Iterations, minIterations, maxIterations)
+	}
+	aead, err := newAEAD(masterKey, env.Salt, env.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.Nonce) != aead.NonceSize() {
+		return nil, ErrWrongKey
+	}
+	plaintext, err := aead.Open(nil, env.Nonce, env.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrWrongKey
+	}
+	var values map[string]string
+	if err := json.Unmarshal(plaintext, &values); err != nil {
+		return nil, fmt.Errorf("secrets: %w", err)
+	}
+	return values, nil
+}
+
+func newAEAD(masterKey string, salt []byte, iterations int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, masterKey, salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// ParseEnv reads KEY=VALUE lines, as in a .env file, to be sealed. Blank
+// lines and # comments are skipped; values may be quoted.
+func ParseEnv(r io.Reader) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("secrets: line %d: expected KEY=VALUE", n)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[key] = value
+	}
+	return values, scanner.Err()
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"sol_privacy/internal/client"
+	"sol_privacy/internal/config"
+	"sol_privacy/internal/reqsign"
+
+	"github.com/go-chi/cors"
+)
+
+// corsPolicy answers preflight requests and sets CORS headers with the
+// policy of the most specific route override matching the path, or the
+// global policy.
+func corsPolicy(c config.CORSConfig) func(http.Handler) http.Handler {
+	routes := append([]config.CORSRoute(nil), c.Routes...)
+	// Exact paths before prefixes, longer before shorter
+	sort.SliceStable(routes, func(i, j int) bool {
+		pi, pj := strings.HasSuffix(routes[i].Path, "/*"), strings.HasSuffix(routes[j].Path, "/*")
+		if pi != pj {
+			return !pi
+		}
+		return len(routes[i].Path) > len(routes[j].Path)
+	})
+
+	return func(next http.Handler) http.Handler {
+		global := corsHandler(c.AllowedOrigins, c.AllowedMethods, c.AllowCredentials, c.MaxAge)(next)
+		handlers := make([]http.Handler, len(routes))
+		for i, r := range routes {
+			handlers[i] = corsHandler(r.Origins(c), r.Methods(c), r.Credentials(c), c.MaxAge)(next)
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for i, route := range routes {
+				if matchesRoute(route.Path, r.URL.Path) {
+					handlers[i].ServeHTTP(w, r)
+					return
+				}
+			}
+			global.ServeHTTP(w, r)
+		})
+	}
+}
+
+func corsHandler(origins, methods []string, credentials bool, maxAge int) func(http.Handler) http.Handler {
+	return cors.Handler(cors.Options{
+		AllowedOrigins:   origins,
+		AllowedMethods:   methods,
+		AllowedHeaders:   []string{"Accept", "Authorization", "Cache-Control", "Content-Type", "Accept-Language", "If-None-Match", "X-API-Key", "X-Request-Id", client.HeaderEnvironment, reqsign.HeaderWallet, reqsign.HeaderTimestamp, reqsign.HeaderSignature},
+		ExposedHeaders:   []string{"Content-Language", "ETag", "Link", "Retry-After", "X-Cache", "X-Correlation-ID"},
+		AllowCredentials: credentials,
+		MaxAge:           maxAge,
+	})
+}
+
+// matchesRoute reports whether path is pattern, or under it when pattern
+// ends in "/*".
+func matchesRoute(pattern, path string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return path == prefix || strings.HasPrefix(path, prefix+"/")
+	}
+	return path == pattern
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/api"
+)
+
+// rateLimiter is a token bucket per client IP. Its limits can be changed
+// while it is in use.
+type rateLimiter struct {
+	mu      sync.Mutex
+	perSec  float64 // Tokens added per second; 0 disables limiting
+	burst   float64
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{buckets: make(map[string]*bucket)}
+}
+
+// configure sets the limit. A burst of 0 allows one minute's worth of requests.
+func (l *rateLimiter) configure(requestsPerMinute, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.perSec = float64(requestsPerMinute) / 60
+	l.burst = float64(burst)
+	if l.burst == 0 {
+		l.burst = float64(requestsPerMinute)
+	}
+}
+
+// allow takes a token for key, or returns how long until one is available.
+func (l *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.perSec == 0 {
+		return true, 0
+	}
+
+	// Forget clients idle long enough to have refilled
+	if now.Sub(l.swept) > time.Minute {
+		full := time.Duration(l.burst/l.perSec*float64(time.Second)) + time.Minute
+		for k, b := range l.buckets {
+			if now.Sub(b.last) > full {
+				delete(l.buckets, k)
+			}
+		}
+		l.swept = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.perSec)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.perSec * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// middleware rejects requests over the limit with 429 and Retry-After.
+func (l *rateLimiter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+		if ok, wait := l.allow(ip, time.Now()); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			api.RespondError(w, r, http.StatusTooManyRequests, "Rate limit exceeded, retry later")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
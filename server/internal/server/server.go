@@ -1,34 +1,227 @@
 package server
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
-	"time"
+	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
 
+	"sol_privacy/internal/acme"
+	"sol_privacy/internal/alerts"
 	"sol_privacy/internal/api"
+	"sol_privacy/internal/config"
+	"sol_privacy/internal/i18n"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/go-chi/cors"
 )
 
 // Config holds server configuration
-type Config struct {
-	APIKey string
-	Port   string
+type Config = config.Config
+
+// hotReloadable lists the settings a reload applies without a restart.
+var hotReloadable = map[string]bool{
+	"server.locale":                  true,
+	"cors.allowed_origins":           true,
+	"cors.allowed_methods":           true,
+	"cors.allow_credentials":         true,
+	"cors.max_age":                   true,
+	"cors.routes":                    true,
+	"timeouts.request":               true,
+	"rate_limit.requests_per_minute": true,
+	"rate_limit.burst":               true,
+	"tls.cert_file":                  true,
+	"tls.key_file":                   true,
+	"tls.client_ca_file":             true,
+	"tls.client_auth":                true,
+}
+
+// Server serves the API. Its middleware stack and TLS settings are rebuilt
+// from the configuration on reload, so CORS, rate limits, the request
+// timeout, the certificate and client CAs change without dropping
+// connections.
+type Server struct {
+	api     *api.Handler
+	tenants []*tenant
+	limiter *rateLimiter
+	acme    *acme.Manager // Set when certificates come from an ACME authority
+	config  atomic.Pointer[Config]
+	handler atomic.Pointer[http.Handler]
+	tls     atomic.Pointer[tls.Config]
+}
+
+// New creates a server from a validated configuration.
+func New(cfg *Config) (*Server, error) {
+	dataDir, err := cfg.Storage.Dir()
+	if err != nil {
+		return nil, err
+	}
+	alertConfig, err := alertsConfig(cfg.Alerts)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		api: api.NewHandler(cfg.APIKey, api.Options{
+			UmbraURL:        cfg.Umbra.URL,
+			DataDir:         dataDir,
+			RequestSigning:  cfg.Signing.Mode,
+			SignatureMaxAge: cfg.Signing.MaxAge,
+			Secrets:         cfg.Secrets.Values,
+			Alerts:          alertConfig,
+		}),
+		tenants: newTenants(cfg, dataDir),
+		limiter: newRateLimiter(),
+	}
+	if a := cfg.TLS.ACME; a.Enabled() {
+		domains := make([]string, len(a.Domains))
+		for i, d := range a.Domains {
+			domains[i] = strings.ToLower(d)
+		}
+		s.acme = &acme.Manager{
+			Directory: a.Directory,
+			Email:     a.Email,
+			Domains:   domains,
+			CacheDir:  a.CacheDir,
+		}
+	}
+	if err := s.apply(cfg); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// alertsConfig returns the alert channels enabled in a, with their templates.
+func alertsConfig(a config.AlertsConfig) (alerts.Config, error) {
+	out := alerts.Config{
+		WebhookFailureThreshold: a.WebhookFailureThreshold,
+		Wallets:                 a.AuthorizationWallets,
+		AuthorizationUsage:      float64(a.AuthorizationUsage) / 100,
+		Interval:                a.Interval,
+	}
+	for _, ch := range []struct {
+		format alerts.Format
+		c      config.AlertChannelConfig
+	}{
+		{alerts.FormatSlack, a.Slack},
+		{alerts.FormatDiscord, a.Discord},
+	} {
+		if ch.c.WebhookURL == "" {
+			continue
+		}
+		channel := alerts.Channel{Format: ch.format, URL: ch.c.WebhookURL, Kinds: ch.c.Alerts}
+		if ch.c.Templates != "" {
+			data, err := os.ReadFile(ch.c.Templates)
+			if err == nil {
+				err = json.Unmarshal(data, &channel.Templates)
+			}
+			if err != nil {
+				return out, fmt.Errorf("alerts.%s.templates: %w", ch.format, err)
+			}
+		}
+		out.Channels = append(out.Channels, channel)
+	}
+	return out, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	(*s.handler.Load()).ServeHTTP(w, r)
+}
+
+// Reload re-reads the configuration file and environment. Settings that need
+// a restart are logged and take effect on the next start; an invalid
+// configuration leaves the running one in place.
+func (s *Server) Reload() error {
+	cur := s.config.Load()
+	next, err := config.Load(cur.Path)
+	if err != nil {
+		return err
+	}
+
+	// How certificates are obtained is fixed by the listeners started with the
+	// server, so those settings keep their running values until a restart
+	switchTLS := cur.TLS.Enabled() != next.TLS.Enabled() || cur.TLS.ACME.Enabled() != next.TLS.ACME.Enabled()
+	var restart []string
+	for _, key := range cur.Changed(next) {
+		if !hotReloadable[key] || (switchTLS && strings.HasPrefix(key, "tls.")) {
+			restart = append(restart, key)
+		}
+	}
+	if switchTLS {
+		next.TLS = cur.TLS
+	}
+	next.TLS.ACME = cur.TLS.ACME
+	if err := s.apply(next); err != nil {
+		return err
+	}
+	if len(restart) > 0 {
+		log.Printf("config: restart to apply changes to %s", strings.Join(restart, ", "))
+	}
+	return nil
+}
+
+// apply loads the certificate and client CAs, then switches to the
+// middleware stack of cfg.
+func (s *Server) apply(cfg *Config) error {
+	if cfg.TLS.Enabled() {
+		tc, err := s.tlsConfig(cfg.TLS)
+		if err != nil {
+			return err
+		}
+		s.tls.Store(tc)
+	}
+	s.limiter.configure(cfg.RateLimit.RequestsPerMinute, cfg.RateLimit.Burst)
+	for _, t := range s.tenants {
+		limit := t.config.RateLimit(cfg.RateLimit)
+		t.limiter.configure(limit.RequestsPerMinute, limit.Burst)
+	}
+	h := s.routes(cfg)
+	s.handler.Store(&h)
+	s.config.Store(cfg)
+	return nil
 }
 
-// Run starts the HTTP server
-func Run(cfg Config) error {
-	if cfg.APIKey == "" {
-		log.Fatal("API key is required")
+// tlsConfig builds the TLS settings of new connections.
+func (s *Server) tlsConfig(t config.TLSConfig) (*tls.Config, error) {
+	tc := &tls.Config{MinVersion: tls.VersionTLS12}
+	if s.acme != nil {
+		tc.GetCertificate = s.acme.GetCertificate
+	} else {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls: %w", err)
+		}
+		tc.Certificates = []tls.Certificate{cert}
 	}
 
-	if cfg.Port == "" {
-		cfg.Port = "8080"
+	if t.ClientCAFile != "" {
+		data, err := os.ReadFile(t.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls: %w", err)
+		}
+		tc.ClientCAs = x509.NewCertPool()
+		if !tc.ClientCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("tls: no certificates in %s", t.ClientCAFile)
+		}
+		tc.ClientAuth = tls.VerifyClientCertIfGiven
+		if t.RequireClientCert() {
+			tc.ClientAuth = tls.RequireAndVerifyClientCert
+		}
 	}
+	return tc, nil
+}
 
-	// Initialize router
+// routes builds the router for cfg around the shared API handlers.
+func (s *Server) routes(cfg *Config) http.Handler {
 	r := chi.NewRouter()
 
 	// Middleware
@@ -36,20 +229,11 @@ func Run(cfg Config) error {
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(60 * time.Second))
+	r.Use(middleware.Timeout(cfg.Timeouts.Request))
+	r.Use(i18n.Middleware(i18n.Parse(cfg.Server.Locale)))
 
-	// CORS configuration
-	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:*", "http://127.0.0.1:*", "https://*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-API-Key"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: true,
-		MaxAge:           300,
-	}))
-
-	// Initialize API handlers
-	apiHandler := api.NewHandler(cfg.APIKey)
+	// CORS configuration, overridden per route
+	r.Use(corsPolicy(cfg.CORS))
 
 	// Health check
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -58,14 +242,142 @@ func Run(cfg Config) error {
 		w.Write([]byte(`{"status":"ok","service":"shadowpay-api"}`))
 	})
 
-	// Mount API routes
-	r.Mount("/api", apiHandler.Routes())
+	// Mount API routes, served as the merchant named by X-API-Key
+	r.Mount("/api", s.tenantRouter(s.limiter.middleware(s.api.Routes()), cfg.ClientKeys))
+	return r
+}
+
+// Run starts the HTTP server on the configured port or Unix socket, or on
+// the sockets passed by systemd, and notifies systemd once it is ready.
+// SIGHUP reloads the configuration; SIGINT and SIGTERM shut down gracefully.
+func Run(cfg *Config) error {
+	s, err := New(cfg)
+	if err != nil {
+		return err
+	}
+	ls, err := listeners(cfg)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: cfg.Timeouts.ReadHeader,
+		ReadTimeout:       cfg.Timeouts.Read,
+		WriteTimeout:      cfg.Timeouts.Write,
+		IdleTimeout:       cfg.Timeouts.Idle,
+	}
+	if cfg.TLS.Enabled() {
+		srv.TLSConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+				return s.tls.Load(), nil
+			},
+		}
+	}
+
+	// The ACME authority validates domains over plain HTTP, which otherwise
+	// redirects to HTTPS
+	var challenges *http.Server
+	if s.acme != nil {
+		challenges = &http.Server{
+			Addr:              ":" + cfg.TLS.ACME.HTTPPort,
+			Handler:           s.acme.HTTPHandler(nil),
+			ReadHeaderTimeout: cfg.Timeouts.ReadHeader,
+		}
+		go func() {
+			if err := challenges.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("acme: challenge listener: %v", err)
+			}
+		}()
+		renewCtx, stopRenew := context.WithCancel(context.Background())
+		defer stopRenew()
+		go s.acme.Renew(renewCtx)
+	}
+
+	// Queued settlements interrupted by shutdown resume on the next start
+	workers, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	go s.api.Run(workers)
+	for _, t := range s.tenants {
+		go t.api.Run(workers)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	done := make(chan error, 1)
+	go func() {
+		for sig := range signals {
+			if sig == syscall.SIGHUP {
+				if err := s.Reload(); err != nil {
+					log.Printf("config: reload failed, keeping current settings: %v", err)
+					notify("STATUS=Reload failed: " + err.Error())
+				} else {
+					log.Printf("config: reloaded")
+					notify("STATUS=Serving")
+				}
+				continue
+			}
+			log.Printf("shutting down (%s)", sig)
+			notify("STOPPING=1")
+			ctx, cancel := context.WithTimeout(context.Background(), s.config.Load().Timeouts.Shutdown)
+			if challenges != nil {
+				challenges.Shutdown(ctx)
+			}
+			done <- srv.Shutdown(ctx)
+			cancel()
+			return
+		}
+	}()
 
 	// Start server
-	log.Printf("🚀 ShadowPay API Server starting on port %s", cfg.Port)
-	log.Printf("📊 Health check: http://localhost:%s/health", cfg.Port)
-	log.Printf("🔌 API endpoint: http://localhost:%s/api", cfg.Port)
-	log.Printf("📖 Example: curl http://localhost:%s/api/pool/balance/<wallet>", cfg.Port)
+	scheme := "http"
+	if cfg.TLS.Enabled() {
+		scheme = "https"
+	}
+	var base, curl string
+	for _, l := range ls {
+		if l.Addr().Network() == "unix" {
+			log.Printf("🚀 ShadowPay API Server starting on socket %s", l.Addr())
+			base, curl = scheme+"://localhost", "curl --unix-socket "+l.Addr().String()
+		} else {
+			_, port, _ := net.SplitHostPort(l.Addr().String())
+			log.Printf("🚀 ShadowPay API Server starting on port %s", port)
+			base, curl = scheme+"://localhost:"+port, "curl"
+		}
+	}
+	if cfg.Path != "" {
+		log.Printf("⚙️  Config: %s (send SIGHUP to reload)", cfg.Path)
+	}
+	if s.acme != nil {
+		log.Printf("🔒 ACME certificate for %s, challenges on port %s", strings.Join(s.acme.Domains, ", "), cfg.TLS.ACME.HTTPPort)
+	}
+	if cfg.TLS.RequireClientCert() {
+		log.Printf("🔒 Client certificates required")
+	} else if cfg.TLS.ClientCAFile != "" {
+		log.Printf("🔒 Client certificates verified when given")
+	}
+	log.Printf("📊 Health check: %s/health", base)
+	log.Printf("🔌 API endpoint: %s/api", base)
+	log.Printf("📖 Example: %s %s/api/pool/balance/<wallet>", curl, base)
 
-	return http.ListenAndServe(":"+cfg.Port, r)
+	served := make(chan error, len(ls))
+	for _, l := range ls {
+		go func() {
+			if cfg.TLS.Enabled() {
+				served <- srv.ServeTLS(l, "", "")
+			} else {
+				served <- srv.Serve(l)
+			}
+		}()
+	}
+	if err := notify("READY=1\nSTATUS=Serving"); err != nil {
+		log.Printf("systemd: %v", err)
+	}
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		srv.Close()
+		return err
+	}
+	return <-done
 }
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"crypto/sha256"
+	"net/http"
+	"path"
+
+	"sol_privacy/internal/api"
+	"sol_privacy/internal/config"
+)
+
+// tenant is another merchant served by the proxy, with its own API handler
+// and rate limiter. Tenants are fixed when the server starts.
+type tenant struct {
+	config  config.TenantConfig
+	api     *api.Handler
+	limiter *rateLimiter
+}
+
+// newTenants creates a handler for each tenant in cfg. Alerts stay with the
+// main merchant.
+func newTenants(cfg *Config, dataDir string) []*tenant {
+	tenants := make([]*tenant, len(cfg.Tenants))
+	for i, t := range cfg.Tenants {
+		tenants[i] = &tenant{
+			config: t,
+			api: api.NewHandler(t.APIKey, api.Options{
+				UmbraURL:        cfg.Umbra.URL,
+				DataDir:         dataDir,
+				RequestSigning:  cfg.Signing.Mode,
+				SignatureMaxAge: cfg.Signing.MaxAge,
+				Secrets:         cfg.Secrets.Values,
+				Tenant:          t.ID,
+				TenantEnv:       t.Env,
+			}),
+			limiter: newRateLimiter(),
+		}
+	}
+	return tenants
+}
+
+// publicRoutes are the hosted checkout routes buyers open from a link, which
+// carry no client key and are served as the main merchant.
+var publicRoutes = []struct{ method, pattern string }{
+	{http.MethodGet, "/api/checkout/*"},
+	{http.MethodGet, "/api/checkout/assets/*"},
+	{http.MethodPost, "/api/checkout/sessions/*/pay"},
+	{http.MethodPost, "/api/checkout/sessions/*/complete"},
+	{http.MethodPost, "/api/checkout/sessions/*/cancel"},
+	{http.MethodGet, "/api/l/*"},
+}
+
+func isPublicRoute(r *http.Request) bool {
+	if r.URL.Path == "/api/checkout/sessions" {
+		return false
+	}
+	for _, route := range publicRoutes {
+		if ok, _ := path.Match(route.pattern, r.URL.Path); ok && r.Method == route.method {
+			return true
+		}
+	}
+	return false
+}
+
+// tenantRouter serves requests as the merchant their X-API-Key header
+// belongs to: a tenant, or main for the main merchant's client keys.
+// Missing and unknown keys are refused, except on the public checkout
+// routes, which are served with main.
+func (s *Server) tenantRouter(main http.Handler, mainKeys []string) http.Handler {
+	if len(s.tenants) == 0 {
+		return main
+	}
+	byKey := make(map[[sha256.Size]byte]http.Handler)
+	for _, key := range mainKeys {
+		byKey[sha256.Sum256([]byte(key))] = main
+	}
+	for _, t := range s.tenants {
+		h := t.limiter.middleware(t.api.Routes())
+		for _, key := range t.config.ClientKeys {
+			byKey[sha256.Sum256([]byte(key))] = h
+		}
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-API-Key")
+		if key == "" {
+			if isPublicRoute(r) {
+				main.ServeHTTP(w, r)
+				return
+			}
+			api.RespondError(w, r, http.StatusUnauthorized, "Missing API key")
+			return
+		}
+		// Hashed so the lookup does not time the key itself
+		h, ok := byKey[sha256.Sum256([]byte(key))]
+		if !ok {
+			api.RespondError(w, r, http.StatusUnauthorized, "Unknown API key")
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
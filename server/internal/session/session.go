@@ -0,0 +1,289 @@
+// Package session implements the wallet connect flow used by the API server.
+// A wallet requests a nonce, signs the challenge message with its Ed25519 key,
+// and exchanges the signature for a short-lived session token bound to that wallet.
+package session
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/wallet"
+)
+
+const (
+	// DefaultSessionTTL is how long a session token stays valid.
+	DefaultSessionTTL = 24 * time.Hour
+	// DefaultNonceTTL is how long a wallet has to sign an issued nonce.
+	DefaultNonceTTL = 5 * time.Minute
+
+	// MaxNoncesPerWallet is how many unused nonces a wallet may hold; issuing
+	// another drops its oldest.
+	MaxNoncesPerWallet = 5
+	// MaxNonces bounds the unused nonces of all wallets. Issuing nonces
+	// needs no signature, so without it anyone could fill memory.
+	MaxNonces = 100_000
+)
+
+var (
+	ErrNonceNotFound    = errors.New("session: nonce not found or already used")
+	ErrNonceExpired     = errors.New("session: nonce expired")
+	ErrInvalidSignature = errors.New("session: invalid wallet signature")
+	ErrInvalidToken     = errors.New("session: invalid session token")
+	ErrTokenExpired     = errors.New("session: session token expired")
+	ErrTooManyNonces    = errors.New("session: too many pending challenges; try again later")
+)
+
+// Config holds session manager configuration.
+type Config struct {
+	Secret     []byte        // HMAC secret for signing session tokens
+	SessionTTL time.Duration // Defaults to DefaultSessionTTL
+	NonceTTL   time.Duration // Defaults to DefaultNonceTTL
+}
+
+// Challenge is the message a wallet must sign to open a session.
+type Challenge struct {
+	WalletAddress string `json:"wallet_address"`
+	Nonce         string `json:"nonce"`
+	Message       string `json:"message"`
+	ExpiresAt     int64  `json:"expires_at"` // Unix timestamp
+}
+
+// Claims are the contents of a session token.
+type Claims struct {
+	Wallet    string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Session is returned to the client after a successful connect.
+type Session struct {
+	Token     string `json:"token"`
+	Wallet    string `json:"wallet_address"`
+	ExpiresAt int64  `json:"expires_at"` // Unix timestamp
+}
+
+// Manager issues nonces, verifies wallet signatures and validates session tokens.
+type Manager struct {
+	secret     []byte
+	sessionTTL time.Duration
+	nonceTTL   time.Duration
+
+	mu        sync.Mutex
+	nonces    map[string]Challenge
+	byWallet  map[string][]string // Unused nonces of each wallet, oldest first
+	lastPrune time.Time
+}
+
+// NewManager creates a new session manager.
+// If no secret is configured a random one is generated, which invalidates sessions on restart.
+func NewManager(cfg Config) *Manager {
+	if len(cfg.Secret) == 0 {
+		cfg.Secret = make([]byte, 32)
+		rand.Read(cfg.Secret)
+	}
+	if cfg.SessionTTL == 0 {
+		cfg.SessionTTL = DefaultSessionTTL
+	}
+	if cfg.NonceTTL == 0 {
+		cfg.NonceTTL = DefaultNonceTTL
+	}
+	return &Manager{
+		secret:     cfg.Secret,
+		sessionTTL: cfg.SessionTTL,
+		nonceTTL:   cfg.NonceTTL,
+		nonces:     make(map[string]Challenge),
+		byWallet:   make(map[string][]string),
+	}
+}
+
+// IssueNonce creates a single-use challenge for a wallet to sign.
+func (m *Manager) IssueNonce(walletAddress string) (*Challenge, error) {
+	if _, err := wallet.PublicKey(walletAddress); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	nonce := hex.EncodeToString(buf)
+	expiresAt := time.Now().Add(m.nonceTTL)
+
+	challenge := Challenge{
+		WalletAddress: walletAddress,
+		Nonce:         nonce,
+		Message:       challengeMessage(walletAddress, nonce, expiresAt.Unix()),
+		ExpiresAt:     expiresAt.Unix(),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pruneLocked()
+	if pending := m.byWallet[walletAddress]; len(pending) >= MaxNoncesPerWallet {
+		m.removeLocked(pending[0])
+	}
+	if len(m.nonces) >= MaxNonces {
+		return nil, ErrTooManyNonces
+	}
+	m.nonces[nonce] = challenge
+	m.byWallet[walletAddress] = append(m.byWallet[walletAddress], nonce)
+
+	return &challenge, nil
+}
+
+// Connect verifies the wallet's signature over a previously issued challenge
+// and returns a session token bound to the wallet.
+func (m *Manager) Connect(walletAddress, nonce, signature string) (*Session, error) {
+	m.mu.Lock()
+	challenge, ok := m.nonces[nonce]
+	if ok {
+		// Nonces are single use, even if verification fails
+		m.removeLocked(nonce)
+	}
+	m.mu.Unlock()
+
+	if !ok || challenge.WalletAddress != walletAddress {
+		return nil, ErrNonceNotFound
+	}
+	if time.Now().Unix() > challenge.ExpiresAt {
+		return nil, ErrNonceExpired
+	}
+
+	if err := VerifySignature(walletAddress, []byte(challenge.Message), signature); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	claims := Claims{
+		Wallet:    walletAddress,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(m.sessionTTL).Unix(),
+	}
+	token, err := m.sign(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Session{
+		Token:     token,
+		Wallet:    walletAddress,
+		ExpiresAt: claims.ExpiresAt,
+	}, nil
+}
+
+// Validate parses and verifies a session token.
+func (m *Manager) Validate(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	expected := m.mac(parts[0] + "." + parts[1])
+	got, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(got, expected) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if time.Now().Unix() > claims.ExpiresAt {
+		return nil, ErrTokenExpired
+	}
+
+	return &claims, nil
+}
+
+// VerifySignature checks a base58 Ed25519 signature of message by the given wallet.
+func VerifySignature(walletAddress string, message []byte, signature string) error {
+	if err := wallet.VerifySignature(walletAddress, message, signature); err != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func challengeMessage(walletAddress, nonce string, expiresAt int64) string {
+	return fmt.Sprintf("ShadowPay wallet connect\nWallet: %s\nNonce: %s\nExpires: %d", walletAddress, nonce, expiresAt)
+}
+
+// sign encodes the claims as an HS256 JWT.
+func (m *Manager) sign(claims Claims) (string, error) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode claims: %w", err)
+	}
+	signingInput := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(m.mac(signingInput)), nil
+}
+
+func (m *Manager) mac(input string) []byte {
+	h := hmac.New(sha256.New, m.secret)
+	h.Write([]byte(input))
+	return h.Sum(nil)
+}
+
+// pruneLocked drops expired nonces. It sweeps at most once a second, unless
+// the nonces are at their limit, so a flood of requests does not turn into a
+// flood of sweeps. Caller must hold m.mu.
+func (m *Manager) pruneLocked() {
+	now := time.Now()
+	if now.Sub(m.lastPrune) < time.Second && len(m.nonces) < MaxNonces {
+		return
+	}
+	m.lastPrune = now
+	for nonce, c := range m.nonces {
+		if now.Unix() > c.ExpiresAt {
+			m.removeLocked(nonce)
+		}
+	}
+}
+
+// removeLocked drops a nonce. Caller must hold m.mu.
+func (m *Manager) removeLocked(nonce string) {
+	c, ok := m.nonces[nonce]
+	if !ok {
+		return
+	}
+	delete(m.nonces, nonce)
+	pending := m.byWallet[c.WalletAddress]
+	for i, n := range pending {
+		if n == nonce {
+			pending = append(pending[:i:i], pending[i+1:]...)
+			break
+		}
+	}
+	if len(pending) == 0 {
+		delete(m.byWallet, c.WalletAddress)
+	} else {
+		m.byWallet[c.WalletAddress] = pending
+	}
+}
+
+type contextKey struct{}
+
+// WithClaims returns a copy of ctx carrying the session claims.
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, contextKey{}, claims)
+}
+
+// FromContext returns the session claims stored in ctx, if any.
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(contextKey{}).(*Claims)
+	return claims, ok
+}
@@ -0,0 +1,462 @@
+// Package settlement queues payment settlements so they survive proxy
+// restarts. Accepted requests are persisted before they are acknowledged,
+// then settled by a pool of workers that retry transient failures with
+// exponential backoff. Jobs interrupted by a crash are picked up again on
+// the next start.
+package settlement
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/jsonfile"
+	"sol_privacy/internal/payment"
+)
+
+// Queue defaults.
+const (
+	DefaultWorkers     = 4
+	DefaultMaxAttempts = 8
+	DefaultBaseBackoff = 2 * time.Second
+	DefaultMaxBackoff  = 5 * time.Minute
+)
+
+var (
+	// ErrJobNotFound is returned for unknown job IDs.
+	ErrJobNotFound = errors.New("settlement job not found")
+	// ErrNotRetryable is returned when retrying a job that has not failed.
+	ErrNotRetryable = errors.New("only failed settlement jobs can be retried")
+)
+
+// Status is the state of a job.
+type Status string
+
+const (
+	StatusQueued     Status = "queued"     // Waiting for a worker
+	StatusProcessing Status = "processing" // Being settled
+	StatusRetrying   Status = "retrying"   // Failed transiently, waiting for NextAttemptAt
+	StatusSucceeded  Status = "succeeded"
+	StatusFailed     Status = "failed" // Rejected, or out of attempts
+)
+
+// Job is a queued settlement.
+type Job struct {
+	ID      string                `json:"id"`
+	Request payment.SettleRequest `json:"request"`
+	// Metadata is carried through for the completion handler, e.g. the
+	// token and customer the payment was for.
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	Status        Status                  `json:"status"`
+	Attempts      int                     `json:"attempts"`
+	LastError     string                  `json:"last_error,omitempty"`
+	Result        *payment.SettleResponse `json:"result,omitempty"`
+	NextAttemptAt int64                   `json:"next_attempt_at,omitempty"` // Unix timestamp
+	CreatedAt     int64                   `json:"created_at"`
+	UpdatedAt     int64                   `json:"updated_at"`
+}
+
+// Done reports whether the job will not be attempted again.
+func (j *Job) Done() bool {
+	return j.Status == StatusSucceeded || j.Status == StatusFailed
+}
+
+// Stats are queue metrics.
+type Stats struct {
+	Queued     int `json:"queued"`
+	Processing int `json:"processing"`
+	Retrying   int `json:"retrying"`
+	Succeeded  int `json:"succeeded"`
+	Failed     int `json:"failed"`
+
+	// Since the process started
+	Attempts         int64   `json:"attempts"`
+	Retries          int64   `json:"retries"`
+	Recovered        int64   `json:"recovered"`          // Jobs resumed after a restart
+	AvgSettleSeconds float64 `json:"avg_settle_seconds"` // From enqueue to success
+	OldestPendingAge int64   `json:"oldest_pending_age_seconds"`
+}
+
+// Store persists jobs.
+type Store interface {
+	Put(j *Job) error
+	Get(id string) (*Job, error) // Returns ErrJobNotFound for unknown IDs
+	List() ([]*Job, error)
+}
+
+// NewMemoryStore creates an empty in-memory Store.
+func NewMemoryStore() Store {
+	return store{jsonfile.NewMemoryStore(jobID, nil)}
+}
+
+// NewFileStore opens a Store persisted to a JSON file after every write, so
+// queued settlements survive proxy restarts. The file is created on first
+// write.
+func NewFileStore(path string) (Store, error) {
+	jobs, err := jsonfile.NewStore(path, jobID, nil)
+	if err != nil {
+		return nil, err
+	}
+	return store{jobs}, nil
+}
+
+func jobID(j *Job) string { return j.ID }
+
+// store keeps jobs in a jsonfile.Store.
+type store struct {
+	jobs *jsonfile.Store[Job]
+}
+
+func (s store) Put(j *Job) error {
+	return s.jobs.Put(j)
+}
+
+func (s store) Get(id string) (*Job, error) {
+	j, ok := s.jobs.Get(id)
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return j, nil
+}
+
+// List returns all jobs, newest first.
+func (s store) List() ([]*Job, error) {
+	return s.jobs.List(func(a, b *Job) bool {
+		if a.CreatedAt != b.CreatedAt {
+			return a.CreatedAt > b.CreatedAt
+		}
+		return a.ID > b.ID
+	}), nil
+}
+
+// SettleFunc settles the payment of a job.
+type SettleFunc func(ctx context.Context, req payment.SettleRequest) (*payment.SettleResponse, error)
+
+// Config holds queue configuration.
+type Config struct {
+	Store       Store      // Defaults to an in-memory store
+	Settle      SettleFunc // Required
+	Workers     int        // Defaults to DefaultWorkers
+	MaxAttempts int        // Defaults to DefaultMaxAttempts
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+
+	// Retryable reports whether a settle error is transient. All errors are
+	// retried if nil.
+	Retryable func(err error) bool
+	// OnDone is called once a job succeeds or fails for good.
+	OnDone func(ctx context.Context, j *Job)
+}
+
+// Queue settles jobs in the background.
+type Queue struct {
+	config Config
+	wake   chan struct{}
+
+	mu      sync.Mutex
+	claimed map[string]bool // Jobs a worker is settling
+
+	statsMu     sync.Mutex
+	attempts    int64
+	retries     int64
+	recovered   int64
+	settled     int64
+	settleTotal time.Duration
+}
+
+// NewQueue creates a queue. Call Run to start its workers.
+func NewQueue(config Config) *Queue {
+	if config.Store == nil {
+		config.Store = NewMemoryStore()
+	}
+	if config.Workers <= 0 {
+		config.Workers = DefaultWorkers
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultMaxAttempts
+	}
+	if config.BaseBackoff <= 0 {
+		config.BaseBackoff = DefaultBaseBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = DefaultMaxBackoff
+	}
+	return &Queue{
+		config:  config,
+		wake:    make(chan struct{}, 1),
+		claimed: make(map[string]bool),
+	}
+}
+
+// Enqueue persists a settlement and schedules it.
+func (q *Queue) Enqueue(ctx context.Context, req payment.SettleRequest, metadata map[string]string) (*Job, error) {
+	now := time.Now().Unix()
+	j := &Job{
+		ID:        newID(),
+		Request:   req,
+		Metadata:  metadata,
+		Status:    StatusQueued,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := q.config.Store.Put(j); err != nil {
+		return nil, err
+	}
+	q.signal()
+	return j, nil
+}
+
+// Get returns a job.
+func (q *Queue) Get(ctx context.Context, id string) (*Job, error) {
+	return q.config.Store.Get(id)
+}
+
+// List returns jobs with the given status (all if empty), newest first.
+func (q *Queue) List(ctx context.Context, status Status) ([]*Job, error) {
+	jobs, err := q.config.Store.List()
+	if err != nil || status == "" {
+		return jobs, err
+	}
+	out := jobs[:0]
+	for _, j := range jobs {
+		if j.Status == status {
+			out = append(out, j)
+		}
+	}
+	return out, nil
+}
+
+// Retry requeues a failed job with a fresh set of attempts.
+func (q *Queue) Retry(ctx context.Context, id string) (*Job, error) {
+	j, err := q.config.Store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if j.Status != StatusFailed {
+		return nil, ErrNotRetryable
+	}
+	j.Status, j.Attempts, j.NextAttemptAt = StatusQueued, 0, 0
+	j.UpdatedAt = time.Now().Unix()
+	if err := q.config.Store.Put(j); err != nil {
+		return nil, err
+	}
+	q.signal()
+	return j, nil
+}
+
+// Stats returns queue metrics.
+func (q *Queue) Stats(ctx context.Context) (*Stats, error) {
+	jobs, err := q.config.Store.List()
+	if err != nil {
+		return nil, err
+	}
+	var s Stats
+	now := time.Now().Unix()
+	for _, j := range jobs {
+		switch j.Status {
+		case StatusQueued:
+			s.Queued++
+		case StatusProcessing:
+			s.Processing++
+		case StatusRetrying:
+			s.Retrying++
+		case StatusSucceeded:
+			s.Succeeded++
+		case StatusFailed:
+			s.Failed++
+		}
+		if !j.Done() && now-j.CreatedAt > s.OldestPendingAge {
+			s.OldestPendingAge = now - j.CreatedAt
+		}
+	}
+
+	q.statsMu.Lock()
+	defer q.statsMu.Unlock()
+	s.Attempts, s.Retries, s.Recovered = q.attempts, q.retries, q.recovered
+	if q.settled > 0 {
+		s.AvgSettleSeconds = q.settleTotal.Seconds() / float64(q.settled)
+	}
+	return &s, nil
+}
+
+// Run recovers jobs interrupted by a previous run, then settles due jobs
+// with the configured number of workers until ctx is done.
+func (q *Queue) Run(ctx context.Context) {
+	q.recover()
+
+	var wg sync.WaitGroup
+	for i := 0; i < q.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// recover requeues jobs a crashed process was settling. The upstream may
+// have settled them already, in which case it rejects the repeat and the
+// job fails with its message.
+func (q *Queue) recover() {
+	jobs, err := q.config.Store.List()
+	if err != nil {
+		log.Printf("settlement: recovery failed: %v", err)
+		return
+	}
+	for _, j := range jobs {
+		if j.Status != StatusProcessing {
+			continue
+		}
+		j.Status = StatusQueued
+		j.UpdatedAt = time.Now().Unix()
+		if err := q.config.Store.Put(j); err != nil {
+			log.Printf("settlement: recovery of %s failed: %v", j.ID, err)
+			continue
+		}
+		q.statsMu.Lock()
+		q.recovered++
+		q.statsMu.Unlock()
+	}
+}
+
+func (q *Queue) work(ctx context.Context) {
+	for {
+		j, wait := q.claim()
+		if j != nil {
+			q.process(ctx, j)
+			continue
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-q.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// claim marks the oldest due job as processing and returns it, or returns
+// how long to wait for the next one to become due.
+func (q *Queue) claim() (*Job, time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	jobs, err := q.config.Store.List()
+	if err != nil {
+		log.Printf("settlement: %v", err)
+		return nil, q.config.BaseBackoff
+	}
+	now := time.Now()
+	wait := time.Minute
+	var next *Job
+	for _, j := range jobs { // Newest first, so the last due job is the oldest
+		if q.claimed[j.ID] || (j.Status != StatusQueued && j.Status != StatusRetrying) {
+			continue
+		}
+		due := time.Unix(j.NextAttemptAt, 0)
+		if j.Status == StatusRetrying && due.After(now) {
+			if d := due.Sub(now); d < wait {
+				wait = d
+			}
+			continue
+		}
+		next = j
+	}
+	if next == nil {
+		return nil, wait
+	}
+
+	next.Status = StatusProcessing
+	next.Attempts++
+	next.UpdatedAt = now.Unix()
+	if err := q.config.Store.Put(next); err != nil {
+		log.Printf("settlement: %v", err)
+		return nil, q.config.BaseBackoff
+	}
+	q.claimed[next.ID] = true
+	// Let another worker look for more work
+	q.signal()
+	return next, 0
+}
+
+func (q *Queue) process(ctx context.Context, j *Job) {
+	defer func() {
+		q.mu.Lock()
+		delete(q.claimed, j.ID)
+		q.mu.Unlock()
+	}()
+
+	resp, err := q.config.Settle(ctx, j.Request)
+	q.statsMu.Lock()
+	q.attempts++
+	q.statsMu.Unlock()
+	if ctx.Err() != nil {
+		// Shutting down: leave the job processing so the next start resumes it
+		return
+	}
+
+	now := time.Now()
+	j.UpdatedAt = now.Unix()
+	switch {
+	case err == nil && resp.Success:
+		j.Status, j.Result, j.LastError = StatusSucceeded, resp, ""
+		q.statsMu.Lock()
+		q.settled++
+		q.settleTotal += now.Sub(time.Unix(j.CreatedAt, 0))
+		q.statsMu.Unlock()
+	case err == nil:
+		// The relayer answered but declined the settlement
+		j.Status, j.Result, j.LastError = StatusFailed, resp, resp.Message
+	case j.Attempts < q.config.MaxAttempts && (q.config.Retryable == nil || q.config.Retryable(err)):
+		j.Status, j.LastError = StatusRetrying, err.Error()
+		j.NextAttemptAt = now.Add(q.backoff(j.Attempts)).Unix()
+		q.statsMu.Lock()
+		q.retries++
+		q.statsMu.Unlock()
+	default:
+		j.Status, j.LastError = StatusFailed, err.Error()
+	}
+	if err := q.config.Store.Put(j); err != nil {
+		log.Printf("settlement: saving %s failed: %v", j.ID, err)
+	}
+	if j.Done() && q.config.OnDone != nil {
+		q.config.OnDone(ctx, j)
+	}
+}
+
+// backoff returns the delay after the given number of attempts: the base
+// backoff doubled per attempt, capped, with up to 20% jitter.
+func (q *Queue) backoff(attempts int) time.Duration {
+	d := q.config.BaseBackoff
+	for i := 1; i < attempts && d < q.config.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > q.config.MaxBackoff {
+		d = q.config.MaxBackoff
+	}
+	var b [1]byte
+	rand.Read(b[:])
+	return d + d*time.Duration(b[0])/(5*255)
+}
+
+func (q *Queue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func newID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "stl_" + hex.EncodeToString(b)
+}
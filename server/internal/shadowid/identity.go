@@ -0,0 +1,94 @@
+package shadowid
+
+import (
+	"fmt"
+	"math/big"
+
+	"sol_privacy/internal/base58"
+	"sol_privacy/internal/merkle"
+	"sol_privacy/internal/poseidon"
+	"sol_privacy/internal/wallet"
+)
+
+// IdentityMessageVersion is bumped if the derivation message ever changes,
+// since doing so changes every derived commitment.
+const IdentityMessageVersion = 1
+
+// Identity is a ShadowID derived deterministically from a wallet signature.
+type Identity struct {
+	WalletAddress string
+	Message       string   // Domain-separated message that was signed
+	Signature     string   // Base58 encoded wallet signature of Message
+	Secret        *big.Int // Private, never leaves the client
+	Nullifier     *big.Int // Private, revealed only as its Poseidon hash
+	Commitment    *big.Int // Poseidon(Nullifier, Secret), the registered leaf
+}
+
+// IdentityMessage returns the message a wallet signs to derive its ShadowID.
+func IdentityMessage(walletAddress string) string {
+	return fmt.Sprintf("ShadowPay ShadowID v%d\nWallet: %s\n\nSign to derive your anonymous identity. This does not send a transaction.",
+		IdentityMessageVersion, walletAddress)
+}
+
+// DeriveCommitment derives the ShadowID secret, nullifier and commitment from the
+// wallet's signature over IdentityMessage. Ed25519 signatures are deterministic,
+// so the same wallet recovers the same identity on any device without storing secrets.
+func DeriveCommitment(signer wallet.Signer) (*Identity, error) {
+	address := signer.Address()
+	message := IdentityMessage(address)
+
+	sig, err := signer.SignMessage([]byte(message))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign identity message: %w", err)
+	}
+	return derive(address, message, sig)
+}
+
+// IdentityFromSignature derives the ShadowID from a base58 signature over
+// IdentityMessage made elsewhere, such as by a browser wallet. The signature
+// is checked against the wallet address first.
+func IdentityFromSignature(walletAddress, signature string) (*Identity, error) {
+	message := IdentityMessage(walletAddress)
+	if err := wallet.VerifySignature(walletAddress, []byte(message), signature); err != nil {
+		return nil, fmt.Errorf("invalid identity signature: %w", err)
+	}
+	sig, _ := base58.Decode(signature)
+	return derive(walletAddress, message, sig)
+}
+
+func derive(address, message string, sig []byte) (*Identity, error) {
+	secret := poseidon.SecretFromSeed("shadowid/secret", sig)
+	nullifier := poseidon.SecretFromSeed("shadowid/nullifier", sig)
+	commitment, err := poseidon.Commitment(nullifier, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Identity{
+		WalletAddress: address,
+		Message:       message,
+		Signature:     base58.Encode(sig),
+		Secret:        secret,
+		Nullifier:     nullifier,
+		Commitment:    commitment,
+	}, nil
+}
+
+// CommitmentHex returns the commitment in the encoding used by the ShadowID API.
+func (id *Identity) CommitmentHex() string {
+	return merkle.FormatElement(id.Commitment)
+}
+
+// AutoRegisterRequest builds the request that registers this identity via signature.
+func (id *Identity) AutoRegisterRequest() AutoRegisterRequest {
+	return AutoRegisterRequest{
+		WalletAddress: id.WalletAddress,
+		Signature:     id.Signature,
+		Message:       id.Message,
+	}
+}
+
+// RegisterRequest builds the request that registers the commitment directly.
+func (id *Identity) RegisterRequest() RegisterRequest {
+	return RegisterRequest{Commitment: id.CommitmentHex()}
+}
@@ -2,12 +2,25 @@ package shadowid
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/big"
+	"net/url"
+	"time"
+
+	"sol_privacy/internal/client"
+	"sol_privacy/internal/merkle"
+	"sol_privacy/internal/types"
 )
 
+// DefaultRootPollInterval is how often WatchRoot polls the tree root.
+const DefaultRootPollInterval = 5 * time.Second
+
 // Service handles anonymous identity operations using Merkle tree-based commitments.
 type Service struct {
-	doRequest func(ctx context.Context, method, path string, body, result interface{}) error
+	doRequest        func(ctx context.Context, method, path string, body, result interface{}) error
+	caps             types.Capabilities
+	rootPollInterval time.Duration
 }
 
 // NewService creates a new ShadowID service.
@@ -48,6 +61,7 @@ type RegisterResponse struct {
 // ProofRequest represents a request to retrieve a Merkle proof for a commitment.
 type ProofRequest struct {
 	Commitment string `json:"commitment"`
+	Root       string `json:"root,omitempty"` // A historical root to prove against; the current root if empty
 }
 
 // ProofResponse contains the Merkle proof for the commitment.
@@ -65,6 +79,35 @@ type RootResponse struct {
 	LeafCount int    `json:"leaf_count"`
 }
 
+// HistoricalRoot is a root the tree had once LeafCount leaves were inserted.
+type HistoricalRoot struct {
+	Root      string `json:"root"`
+	LeafCount int    `json:"leaf_count"`
+	CreatedAt int64  `json:"created_at"` // Unix timestamp
+}
+
+// HistoricalRootsResponse lists the roots the API still proves against,
+// newest first.
+type HistoricalRootsResponse struct {
+	Roots     []HistoricalRoot `json:"roots"`
+	TreeDepth int              `json:"tree_depth"`
+}
+
+// Find returns the archived root equal to root, which may be hex or decimal,
+// or nil if it is not archived.
+func (r *HistoricalRootsResponse) Find(root string) *HistoricalRoot {
+	want, err := merkle.ParseElement(root)
+	if err != nil {
+		return nil
+	}
+	for i := range r.Roots {
+		if got, err := merkle.ParseElement(r.Roots[i].Root); err == nil && got.Cmp(want) == 0 {
+			return &r.Roots[i]
+		}
+	}
+	return nil
+}
+
 // StatusResponse contains the registration status of a commitment.
 type StatusResponse struct {
 	Commitment string `json:"commitment"`
@@ -72,6 +115,52 @@ type StatusResponse struct {
 	LeafIndex  int    `json:"leaf_index,omitempty"`
 }
 
+// RegisterBatchRequest represents a request to register several commitments in one call.
+type RegisterBatchRequest struct {
+	Commitments []string `json:"commitments"` // Poseidon hash commitments
+}
+
+// RegisterBatchResponse contains the leaf index assigned to each commitment, in request order.
+type RegisterBatchResponse struct {
+	Success    bool             `json:"success"`
+	Registered []RegisteredLeaf `json:"registered"`
+	Root       string           `json:"root"`
+	Message    string           `json:"message,omitempty"`
+}
+
+// RegisteredLeaf pairs a commitment with its position in the tree.
+type RegisteredLeaf struct {
+	Commitment string `json:"commitment"`
+	LeafIndex  int    `json:"leaf_index"`
+}
+
+// SyncTreeResponse contains a page of leaves in insertion order.
+// Leaves[i] is the commitment at index FromLeaf+i.
+type SyncTreeResponse struct {
+	FromLeaf  int      `json:"from_leaf"`
+	Leaves    []string `json:"leaves"`
+	LeafCount int      `json:"leaf_count"` // Total leaves in the tree
+	Root      string   `json:"root"`       // Root after all LeafCount leaves
+	HasMore   bool     `json:"has_more"`
+}
+
+// SetCapabilities adapts the service to what the API supports: batches are
+// registered one commitment at a time where batch registration is missing.
+// Call it before using the service.
+func (s *Service) SetCapabilities(c types.Capabilities) {
+	s.caps = c
+}
+
+// SetRootPollInterval changes how often WatchRoot polls the tree root. Call
+// it before WatchRoot.
+func (s *Service) SetRootPollInterval(d time.Duration) {
+	s.rootPollInterval = d
+}
+
+func (s *Service) supports(ctx context.Context, feature string) bool {
+	return s.caps == nil || s.caps.Supports(ctx, feature)
+}
+
 // AutoRegister registers a wallet via signature (production-recommended method).
 // User must sign a message with their wallet to prove ownership.
 func (s *Service) AutoRegister(ctx context.Context, req AutoRegisterRequest) (*AutoRegisterResponse, error) {
@@ -92,6 +181,95 @@ func (s *Service) Register(ctx context.Context, req RegisterRequest) (*RegisterR
 	return &resp, nil
 }
 
+// RegisterBatch adds several Poseidon hash commitments to the Merkle tree in one request.
+// Commitments are inserted in order, so the returned leaf indexes are contiguous.
+func (s *Service) RegisterBatch(ctx context.Context, commitments []string) (*RegisterBatchResponse, error) {
+	if len(commitments) == 0 {
+		return nil, fmt.Errorf("no commitments to register")
+	}
+	if !s.supports(ctx, types.FeatureShadowIDBatch) {
+		return s.registerEach(ctx, commitments)
+	}
+
+	var resp RegisterBatchResponse
+	req := RegisterBatchRequest{Commitments: commitments}
+	if err := s.doRequest(ctx, "POST", "/shadowpay/api/shadowid/register-batch", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// registerEach registers commitments in order with one request each.
+func (s *Service) registerEach(ctx context.Context, commitments []string) (*RegisterBatchResponse, error) {
+	resp := &RegisterBatchResponse{Success: true}
+	for i, c := range commitments {
+		r, err := s.Register(ctx, RegisterRequest{Commitment: c})
+		if err != nil {
+			return nil, fmt.Errorf("registered %d of %d commitments: %w", i, len(commitments), err)
+		}
+		resp.Success = resp.Success && r.Success
+		resp.Registered = append(resp.Registered, RegisteredLeaf{Commitment: c, LeafIndex: r.LeafIndex})
+	}
+	root, err := s.GetRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp.Root = root.Root
+	return resp, nil
+}
+
+// SyncTree retrieves the leaves inserted at or after sinceLeaf.
+// Verifiers call it repeatedly with FromLeaf+len(Leaves) to keep a local replica of the tree
+// instead of requesting proofs one commitment at a time.
+func (s *Service) SyncTree(ctx context.Context, sinceLeaf int) (*SyncTreeResponse, error) {
+	var resp SyncTreeResponse
+	path := fmt.Sprintf("/shadowpay/api/shadowid/leaves?since=%d", sinceLeaf)
+	if err := s.doRequest(ctx, "GET", path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SyncReplica pulls leaves the local tree has not seen yet and appends them.
+// It returns an error if the replica's root diverges from the remote root.
+func (s *Service) SyncReplica(ctx context.Context, tree *merkle.Tree) error {
+	if !s.supports(ctx, types.FeatureShadowIDSync) {
+		return fmt.Errorf("the API does not stream tree leaves: %w", errors.ErrUnsupported)
+	}
+	for {
+		resp, err := s.SyncTree(ctx, tree.LeafCount())
+		if err != nil {
+			return err
+		}
+		if resp.FromLeaf != tree.LeafCount() {
+			return fmt.Errorf("sync returned leaves from %d, expected %d", resp.FromLeaf, tree.LeafCount())
+		}
+
+		leaves := make([]*big.Int, len(resp.Leaves))
+		for i, l := range resp.Leaves {
+			if leaves[i], err = merkle.ParseElement(l); err != nil {
+				return err
+			}
+		}
+		if _, err := tree.InsertMany(leaves); err != nil {
+			return err
+		}
+
+		if !resp.HasMore || len(resp.Leaves) == 0 {
+			if resp.Root != "" && tree.LeafCount() == resp.LeafCount {
+				remote, err := merkle.ParseElement(resp.Root)
+				if err != nil {
+					return err
+				}
+				if remote.Cmp(tree.Root()) != 0 {
+					return fmt.Errorf("local replica root %s does not match remote root %s", merkle.FormatElement(tree.Root()), resp.Root)
+				}
+			}
+			return nil
+		}
+	}
+}
+
 // GetProof retrieves the Merkle proof for a given commitment.
 // The proof allows anonymous verification of membership in the identity set.
 func (s *Service) GetProof(ctx context.Context, commitment string) (*ProofResponse, error) {
@@ -103,6 +281,32 @@ func (s *Service) GetProof(ctx context.Context, commitment string) (*ProofRespon
 	return &resp, nil
 }
 
+// GetProofAtRoot retrieves the Merkle proof for a commitment against an
+// older root. The root rotates as leaves are added during a payment flow, so
+// a verifier holding a proof generated earlier asks for the path at the root
+// that proof claims. The commitment must have been inserted before that root.
+func (s *Service) GetProofAtRoot(ctx context.Context, commitment, root string) (*ProofResponse, error) {
+	if root == "" {
+		return nil, fmt.Errorf("root is required")
+	}
+	var resp ProofResponse
+	req := ProofRequest{Commitment: commitment, Root: root}
+	if err := s.doRequest(ctx, "POST", "/shadowpay/api/shadowid/proof", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListHistoricalRoots retrieves the archive of past tree roots. Verifiers
+// accept a proof whose root is listed here rather than only the current one.
+func (s *Service) ListHistoricalRoots(ctx context.Context) (*HistoricalRootsResponse, error) {
+	var resp HistoricalRootsResponse
+	if err := s.doRequest(ctx, "GET", "/shadowpay/api/shadowid/roots", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
 // GetRoot fetches the current Merkle tree root.
 // The root is used to verify proofs and represents the current state of all registered identities.
 func (s *Service) GetRoot(ctx context.Context) (*RootResponse, error) {
@@ -113,10 +317,49 @@ func (s *Service) GetRoot(ctx context.Context) (*RootResponse, error) {
 	return &resp, nil
 }
 
+// WatchRoot polls the tree root and sends it on the returned channel each
+// time it changes, starting with the current root, so verifiers can refresh
+// a cached root as soon as new leaves land instead of rejecting fresh proofs.
+// Polls bypass the response cache but are revalidated by ETag, so an
+// unchanged root costs no body. A receiver that falls behind gets the newest
+// root only. Failed polls are retried at the next tick. The channel is closed
+// once ctx is done.
+func (s *Service) WatchRoot(ctx context.Context) <-chan RootResponse {
+	interval := s.rootPollInterval
+	if interval <= 0 {
+		interval = DefaultRootPollInterval
+	}
+	ch := make(chan RootResponse, 1)
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		poll := client.Revalidate(ctx)
+		last := ""
+		for {
+			if root, err := s.GetRoot(poll); err == nil && root.Root != last {
+				last = root.Root
+				// Replace a root the receiver has not taken yet
+				select {
+				case <-ch:
+				default:
+				}
+				ch <- *root
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return ch
+}
+
 // GetStatus checks if a commitment is registered in the tree.
 func (s *Service) GetStatus(ctx context.Context, commitment string) (*StatusResponse, error) {
 	var resp StatusResponse
-	path := fmt.Sprintf("/shadowpay/shadowid/v1/id/status/%s", commitment)
+	path := fmt.Sprintf("/shadowpay/shadowid/v1/id/status/%s", url.PathEscape(commitment))
 	if err := s.doRequest(ctx, "GET", path, nil, &resp); err != nil {
 		return nil, err
 	}
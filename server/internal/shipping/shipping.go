@@ -0,0 +1,295 @@
+// Package shipping holds customers' shipping addresses in escrow until the
+// order is paid, so merchants of physical goods learn where to ship only for
+// settled payments.
+//
+// The customer seals the address to the merchant's ElGamal public key, the
+// key that encrypts payment amounts, bound to the payment commitment. The
+// proxy keeps the ciphertext and hands it to the merchant once the payment's
+// receipt verifies; the merchant decrypts it locally, so neither the proxy
+// nor the privacy API ever sees the address.
+package shipping
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/elgamal"
+	"sol_privacy/internal/jsonfile"
+	"sol_privacy/internal/receipt"
+	"sol_privacy/internal/validate"
+)
+
+// Event types.
+const (
+	EventAddressSubmitted = "shipping.address_submitted"
+	EventAddressRevealed  = "shipping.address_revealed"
+)
+
+// MaxSealedSize is the largest sealed address accepted, in bytes.
+const MaxSealedSize = 4 << 10
+
+var (
+	// ErrOrderNotFound is returned for commitments without a shipping address.
+	ErrOrderNotFound = errors.New("shipping order not found")
+	// ErrOrderExists is returned when a commitment already has an address.
+	ErrOrderExists = errors.New("shipping address already submitted")
+	// ErrNotSettled is returned by Reveal until the payment's receipt verifies.
+	ErrNotSettled = errors.New("payment not settled")
+	// ErrWrongMerchant is returned by Reveal when the payment went to another merchant.
+	ErrWrongMerchant = errors.New("payment belongs to another merchant")
+)
+
+// Status of an order.
+type Status string
+
+// Order statuses.
+const (
+	StatusPending  Status = "pending"  // Address held until settlement
+	StatusRevealed Status = "revealed" // Released to the merchant
+)
+
+// Address is a postal address, sealed as JSON.
+type Address struct {
+	Name       string `json:"name"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+	Country    string `json:"country"` // ISO 3166-1 alpha-2
+	Phone      string `json:"phone,omitempty"`
+}
+
+// Order is a sealed shipping address awaiting, or released after, settlement.
+type Order struct {
+	Commitment    string `json:"commitment"`               // Payment commitment
+	MerchantKey   string `json:"merchant_key"`             // ElGamal public key the address is sealed to
+	SealedAddress string `json:"sealed_address,omitempty"` // Only returned once revealed
+	Status        Status `json:"status"`
+	CreatedAt     int64  `json:"created_at"`
+	RevealedAt    int64  `json:"revealed_at,omitempty"`
+}
+
+// redacted returns a copy without the sealed address unless it was revealed.
+func (o Order) redacted() *Order {
+	if o.Status != StatusRevealed {
+		o.SealedAddress = ""
+	}
+	return &o
+}
+
+// CreateRequest submits a sealed shipping address for a payment.
+type CreateRequest struct {
+	Commitment    string `json:"commitment"`
+	MerchantKey   string `json:"merchant_key"`
+	SealedAddress string `json:"sealed_address"` // From SealAddress
+}
+
+// SealAddress seals a to the merchant's ElGamal public key for the payment
+// commitment, for CreateRequest.
+func SealAddress(merchantKey, commitment string, a Address) (string, error) {
+	pub, err := elgamal.ParsePublicKey(merchantKey)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		return "", err
+	}
+	return elgamal.Seal(pub, data, []byte(commitment), nil)
+}
+
+// OpenAddress decrypts a revealed order's address with the merchant's key.
+func OpenAddress(key *elgamal.PrivateKey, o *Order) (*Address, error) {
+	if o.SealedAddress == "" {
+		return nil, fmt.Errorf("shipping address of %s not revealed", o.Commitment)
+	}
+	data, err := key.Unseal(o.SealedAddress, []byte(o.Commitment))
+	if err != nil {
+		return nil, err
+	}
+	var a Address
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil, fmt.Errorf("invalid shipping address: %w", err)
+	}
+	return &a, nil
+}
+
+// Event is a change to an order.
+type Event struct {
+	Type      string `json:"event"`
+	Timestamp int64  `json:"timestamp"`
+	Order     Order  `json:"data"`
+}
+
+// EventHandler receives shipping events.
+type EventHandler func(ctx context.Context, event Event)
+
+// Store persists orders.
+type Store interface {
+	Put(o *Order) error
+	Get(commitment string) (*Order, error) // Returns ErrOrderNotFound for unknown commitments
+	List() ([]*Order, error)
+}
+
+// NewMemoryStore creates an empty in-memory Store.
+func NewMemoryStore() Store {
+	return store{jsonfile.NewMemoryStore(orderKey, nil)}
+}
+
+// NewFileStore opens a Store persisted to a JSON file after every write,
+// creating it on first write.
+func NewFileStore(path string) (Store, error) {
+	orders, err := jsonfile.NewStore(path, orderKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	return store{orders}, nil
+}
+
+func orderKey(o *Order) string { return o.Commitment }
+
+// store keeps orders in a jsonfile.Store.
+type store struct {
+	orders *jsonfile.Store[Order]
+}
+
+func (s store) Put(o *Order) error {
+	return s.orders.Put(o)
+}
+
+func (s store) Get(commitment string) (*Order, error) {
+	o, ok := s.orders.Get(commitment)
+	if !ok {
+		return nil, ErrOrderNotFound
+	}
+	return o, nil
+}
+
+// List returns all orders, newest first.
+func (s store) List() ([]*Order, error) {
+	return s.orders.List(func(a, b *Order) bool {
+		if a.CreatedAt != b.CreatedAt {
+			return a.CreatedAt > b.CreatedAt
+		}
+		return a.Commitment < b.Commitment
+	}), nil
+}
+
+// Config configures a Service.
+type Config struct {
+	Receipts *receipt.Service // Required; settlement is checked against the payment's receipt
+	Store    Store            // Defaults to an in-memory store
+	OnEvent  EventHandler     // Optional
+}
+
+// Service holds sealed addresses until settlement.
+type Service struct {
+	receipts *receipt.Service
+	store    Store
+	onEvent  EventHandler
+	mu       sync.Mutex // Serializes read-modify-write updates
+}
+
+// NewService creates a shipping service.
+func NewService(config Config) *Service {
+	if config.Store == nil {
+		config.Store = NewMemoryStore()
+	}
+	return &Service{
+		receipts: config.Receipts,
+		store:    config.Store,
+		onEvent:  config.OnEvent,
+	}
+}
+
+// Create holds a sealed address for a payment commitment.
+func (s *Service) Create(ctx context.Context, req CreateRequest) (*Order, error) {
+	v := validate.New().Commitment("commitment", req.Commitment)
+	if _, err := elgamal.ParsePublicKey(req.MerchantKey); err != nil {
+		v.Add("merchant_key", errors.New("must be a 0x-prefixed hex ElGamal public key"))
+	}
+	sealed, err := hex.DecodeString(strings.TrimPrefix(req.SealedAddress, "0x"))
+	if err != nil || len(sealed) <= elgamal.SealOverhead || len(sealed) > MaxSealedSize {
+		v.Add("sealed_address", fmt.Errorf("must be 0x-prefixed hex of at most %d bytes", MaxSealedSize))
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.store.Get(req.Commitment); err == nil {
+		return nil, ErrOrderExists
+	} else if !errors.Is(err, ErrOrderNotFound) {
+		return nil, err
+	}
+	o := &Order{
+		Commitment:    req.Commitment,
+		MerchantKey:   req.MerchantKey,
+		SealedAddress: req.SealedAddress,
+		Status:        StatusPending,
+		CreatedAt:     time.Now().Unix(),
+	}
+	if err := s.store.Put(o); err != nil {
+		return nil, err
+	}
+	s.emit(ctx, EventAddressSubmitted, o.redacted())
+	return o.redacted(), nil
+}
+
+// Get returns an order, without its address until it is revealed.
+func (s *Service) Get(ctx context.Context, commitment string) (*Order, error) {
+	o, err := s.store.Get(commitment)
+	if err != nil {
+		return nil, err
+	}
+	return o.redacted(), nil
+}
+
+// Reveal releases the sealed address to merchant once the payment's receipt
+// verifies and names merchant as the payee. Revealing again returns the same
+// order.
+func (s *Service) Reveal(ctx context.Context, commitment, merchant string) (*Order, error) {
+	if _, err := s.store.Get(commitment); err != nil {
+		return nil, err
+	}
+	r, err := s.receipts.GetByCommitment(ctx, commitment)
+	if err != nil {
+		return nil, err
+	}
+	if r.Receipt.Body.Merchant != merchant {
+		return nil, ErrWrongMerchant
+	}
+	if !r.Verified {
+		return nil, ErrNotSettled
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, err := s.store.Get(commitment)
+	if err != nil {
+		return nil, err
+	}
+	if o.Status == StatusPending {
+		o.Status = StatusRevealed
+		o.RevealedAt = time.Now().Unix()
+		if err := s.store.Put(o); err != nil {
+			return nil, err
+		}
+		s.emit(ctx, EventAddressRevealed, o)
+	}
+	return o, nil
+}
+
+func (s *Service) emit(ctx context.Context, eventType string, o *Order) {
+	if s.onEvent != nil {
+		s.onEvent(ctx, Event{Type: eventType, Timestamp: time.Now().Unix(), Order: *o})
+	}
+}
@@ -0,0 +1,101 @@
+package solana
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	"sol_privacy/internal/base58"
+)
+
+const maxSeedLength = 32
+
+// Well-known program IDs.
+const (
+	TokenMetadataProgramID = "metaqbxxUerdq28cj1RbAWkYQm3ybzjb6a8bt518x1s"
+	TokenProgramID         = "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA"
+	SystemProgramID        = "11111111111111111111111111111111"
+)
+
+// FindProgramAddress derives a program-derived address and its bump seed.
+func FindProgramAddress(seeds [][]byte, programID string) (string, uint8, error) {
+	program, err := base58.Decode(programID)
+	if err != nil || len(program) != 32 {
+		return "", 0, fmt.Errorf("invalid program id %q", programID)
+	}
+	for _, s := range seeds {
+		if len(s) > maxSeedLength {
+			return "", 0, fmt.Errorf("seed longer than %d bytes", maxSeedLength)
+		}
+	}
+
+	for bump := 255; bump >= 0; bump-- {
+		h := sha256.New()
+		for _, s := range seeds {
+			h.Write(s)
+		}
+		h.Write([]byte{byte(bump)})
+		h.Write(program)
+		h.Write([]byte("ProgramDerivedAddress"))
+		candidate := h.Sum(nil)
+		if !isOnCurve(candidate) {
+			return base58.Encode(candidate), uint8(bump), nil
+		}
+	}
+	return "", 0, fmt.Errorf("unable to find a viable program address bump seed")
+}
+
+// MetadataAddress returns the Metaplex metadata PDA for a mint.
+func MetadataAddress(mint string) (string, error) {
+	mintKey, err := base58.Decode(mint)
+	if err != nil || len(mintKey) != 32 {
+		return "", fmt.Errorf("invalid mint address %q", mint)
+	}
+	program, _ := base58.Decode(TokenMetadataProgramID)
+	addr, _, err := FindProgramAddress([][]byte{[]byte("metadata"), program, mintKey}, TokenMetadataProgramID)
+	return addr, err
+}
+
+var (
+	curveP = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+	curveD = func() *big.Int {
+		// d = -121665 / 121666 mod p
+		num := new(big.Int).Sub(curveP, big.NewInt(121665))
+		den := new(big.Int).ModInverse(big.NewInt(121666), curveP)
+		return num.Mul(num, den).Mod(num, curveP)
+	}()
+)
+
+// isOnCurve reports whether the 32 bytes decompress to a valid ed25519 point.
+func isOnCurve(b []byte) bool {
+	// Compressed points are little-endian y with the top bit holding the sign of x
+	be := make([]byte, 32)
+	for i := 0; i < 32; i++ {
+		be[i] = b[31-i]
+	}
+	be[0] &= 0x7f
+	y := new(big.Int).SetBytes(be)
+	if y.Cmp(curveP) >= 0 {
+		return false
+	}
+
+	// x^2 = (y^2 - 1) / (d*y^2 + 1)
+	y2 := new(big.Int).Mul(y, y)
+	y2.Mod(y2, curveP)
+	u := new(big.Int).Sub(y2, big.NewInt(1))
+	u.Mod(u, curveP)
+	v := new(big.Int).Mul(curveD, y2)
+	v.Add(v, big.NewInt(1))
+	v.Mod(v, curveP)
+
+	x2 := new(big.Int).ModInverse(v, curveP)
+	if x2 == nil {
+		return false
+	}
+	x2.Mul(x2, u).Mod(x2, curveP)
+	if x2.Sign() == 0 {
+		// x = 0 is only valid with a cleared sign bit
+		return b[31]&0x80 == 0
+	}
+	return big.Jacobi(x2, curveP) == 1
+}
@@ -0,0 +1,157 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// AccountUpdate is a change to a subscribed account pushed by the node.
+type AccountUpdate struct {
+	Address  string
+	Slot     uint64
+	Lamports uint64
+	Owner    string
+}
+
+// WebSocketURL returns the PubSub endpoint of an RPC URL: the same host over
+// ws or wss, with a local validator's port 8899 moved to 8900.
+func WebSocketURL(rpcURL string) string {
+	u, err := url.Parse(rpcURL)
+	if err != nil {
+		return rpcURL
+	}
+	switch u.Scheme {
+	case "http":
+		u.Scheme = "ws"
+	case "https":
+		u.Scheme = "wss"
+	}
+	if u.Port() == "8899" {
+		u.Host = strings.TrimSuffix(u.Host, "8899") + "8900"
+	}
+	return u.String()
+}
+
+// WatchOptions configures WatchAccounts.
+type WatchOptions struct {
+	Commitment string // Defaults to "confirmed"
+
+	// OnSubscribed is called once every account is subscribed, before any
+	// update is sent. May be nil.
+	OnSubscribed func()
+}
+
+// WatchAccounts subscribes to the accounts over the node's WebSocket
+// endpoint and sends their changes on updates until ctx is done or the
+// connection fails; callers reconnect.
+func WatchAccounts(ctx context.Context, wsURL string, accounts []string, opts WatchOptions, updates chan<- AccountUpdate) error {
+	if len(accounts) == 0 {
+		return errors.New("no accounts to watch")
+	}
+	commitment := opts.Commitment
+	if commitment == "" {
+		commitment = "confirmed"
+	}
+	conn, err := dialWebSocket(ctx, wsURL)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	// Responses carry the request ID, which maps each subscription ID to its
+	// account
+	pending := make(map[int64]string, len(accounts))
+	for i, address := range accounts {
+		id := int64(i + 1)
+		pending[id] = address
+		body, err := json.Marshal(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      id,
+			"method":  "accountSubscribe",
+			"params": []interface{}{address, map[string]string{
+				"encoding":   "base64",
+				"commitment": commitment,
+			}},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		if err := conn.writeText(body); err != nil {
+			return fmt.Errorf("subscribe failed: %w", err)
+		}
+	}
+
+	subscriptions := make(map[int64]string, len(accounts))
+	for {
+		message, err := conn.readMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("subscription closed: %w", err)
+		}
+
+		var envelope struct {
+			ID     *int64          `json:"id"`
+			Result json.RawMessage `json:"result"`
+			Error  *RPCError       `json:"error"`
+			Method string          `json:"method"`
+			Params struct {
+				Subscription int64 `json:"subscription"`
+				Result       struct {
+					Context struct {
+						Slot uint64 `json:"slot"`
+					} `json:"context"`
+					Value *struct {
+						Lamports uint64 `json:"lamports"`
+						Owner    string `json:"owner"`
+					} `json:"value"`
+				} `json:"result"`
+			} `json:"params"`
+		}
+		if err := json.Unmarshal(message, &envelope); err != nil {
+			return fmt.Errorf("failed to decode notification: %w", err)
+		}
+
+		switch {
+		case envelope.ID != nil:
+			address, ok := pending[*envelope.ID]
+			if !ok {
+				continue
+			}
+			delete(pending, *envelope.ID)
+			if envelope.Error != nil {
+				return fmt.Errorf("subscribe %s: %w", address, envelope.Error)
+			}
+			var sub int64
+			if err := json.Unmarshal(envelope.Result, &sub); err != nil {
+				return fmt.Errorf("subscribe %s: invalid subscription id", address)
+			}
+			subscriptions[sub] = address
+			if len(pending) == 0 && opts.OnSubscribed != nil {
+				opts.OnSubscribed()
+			}
+		case envelope.Method == "accountNotification":
+			address, ok := subscriptions[envelope.Params.Subscription]
+			if !ok {
+				continue
+			}
+			update := AccountUpdate{Address: address, Slot: envelope.Params.Result.Context.Slot}
+			// A closed account is reported without a value
+			if v := envelope.Params.Result.Value; v != nil {
+				update.Lamports, update.Owner = v.Lamports, v.Owner
+			}
+			select {
+			case updates <- update:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
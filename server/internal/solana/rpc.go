@@ -0,0 +1,340 @@
+// Package solana provides a minimal JSON-RPC client for the Solana cluster
+// endpoints the SDK needs, account subscriptions over the cluster's
+// WebSocket endpoint, and program-derived address helpers.
+package solana
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	MainnetRPCURL = "https://api.mainnet-beta.solana.com"
+	DevnetRPCURL  = "https://api.devnet.solana.com"
+)
+
+// Config holds configuration for the RPC client.
+type Config struct {
+	URL        string
+	HTTPClient *http.Client
+}
+
+// Client is a Solana JSON-RPC client.
+type Client struct {
+	url        string
+	httpClient *http.Client
+	nextID     atomic.Int64
+}
+
+// NewClient creates a new RPC client. URL defaults to mainnet-beta.
+func NewClient(config Config) *Client {
+	if config.URL == "" {
+		config.URL = MainnetRPCURL
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{
+			Timeout: 30 * time.Second,
+		}
+	}
+	return &Client{
+		url:        config.URL,
+		httpClient: config.HTTPClient,
+	}
+}
+
+// URL returns the RPC endpoint.
+func (c *Client) URL() string {
+	return c.url
+}
+
+// RPCError is an error returned by the RPC node.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("solana rpc error %d: %s", e.Code, e.Message)
+}
+
+// Call invokes an RPC method and decodes its result into result.
+func (c *Client) Call(ctx context.Context, method string, params []interface{}, result interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      c.nextID.Add(1),
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("solana rpc error: status %d", resp.StatusCode)
+	}
+
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+		Error  *RPCError       `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if envelope.Error != nil {
+		return envelope.Error
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(envelope.Result, result); err != nil {
+		return fmt.Errorf("failed to decode result: %w", err)
+	}
+	return nil
+}
+
+// AccountInfo is the decoded state of an on-chain account.
+type AccountInfo struct {
+	Lamports   uint64
+	Owner      string
+	Executable bool
+	Data       []byte
+}
+
+// GetAccountInfo fetches an account. It returns nil, nil if the account does not exist.
+func (c *Client) GetAccountInfo(ctx context.Context, address string) (*AccountInfo, error) {
+	var result struct {
+		Value *struct {
+			Lamports   uint64   `json:"lamports"`
+			Owner      string   `json:"owner"`
+			Executable bool     `json:"executable"`
+			Data       []string `json:"data"` // [base64, "base64"]
+		} `json:"value"`
+	}
+	params := []interface{}{address, map[string]string{"encoding": "base64"}}
+	if err := c.Call(ctx, "getAccountInfo", params, &result); err != nil {
+		return nil, err
+	}
+	if result.Value == nil {
+		return nil, nil
+	}
+
+	info := &AccountInfo{
+		Lamports:   result.Value.Lamports,
+		Owner:      result.Value.Owner,
+		Executable: result.Value.Executable,
+	}
+	if len(result.Value.Data) > 0 {
+		data, err := base64.StdEncoding.DecodeString(result.Value.Data[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode account data: %w", err)
+		}
+		info.Data = data
+	}
+	return info, nil
+}
+
+// SignatureStatus is the cluster status of a transaction signature.
+type SignatureStatus struct {
+	Slot               uint64          `json:"slot"`
+	Confirmations      *uint64         `json:"confirmations"` // nil once finalized
+	ConfirmationStatus string          `json:"confirmationStatus"`
+	Err                json.RawMessage `json:"err"`
+}
+
+// Failed reports whether the transaction executed with an error.
+func (s *SignatureStatus) Failed() bool {
+	return len(s.Err) > 0 && string(s.Err) != "null"
+}
+
+// GetSignatureStatuses fetches the statuses of signatures. Entries are nil for unknown signatures.
+func (c *Client) GetSignatureStatuses(ctx context.Context, signatures []string) ([]*SignatureStatus, error) {
+	var result struct {
+		Value []*SignatureStatus `json:"value"`
+	}
+	params := []interface{}{signatures, map[string]bool{"searchTransactionHistory": true}}
+	if err := c.Call(ctx, "getSignatureStatuses", params, &result); err != nil {
+		return nil, err
+	}
+	return result.Value, nil
+}
+
+// TransactionMeta is the execution metadata of a confirmed transaction.
+type TransactionMeta struct {
+	Fee         uint64          `json:"fee"` // Lamports paid by the fee payer
+	Err         json.RawMessage `json:"err"`
+	LogMessages []string        `json:"logMessages"`
+}
+
+// SignatureInfo is a transaction signature involving an address.
+type SignatureInfo struct {
+	Signature string          `json:"signature"`
+	Slot      uint64          `json:"slot"`
+	BlockTime *int64          `json:"blockTime"`
+	Err       json.RawMessage `json:"err"`
+}
+
+// Failed reports whether the transaction executed with an error.
+func (s *SignatureInfo) Failed() bool {
+	return len(s.Err) > 0 && string(s.Err) != "null"
+}
+
+// SignaturesOptions pages GetSignaturesForAddress.
+type SignaturesOptions struct {
+	Before string // Start before this signature; the newest if empty
+	Until  string // Stop at this signature, excluded
+	Limit  int    // At most 1000; defaults to 1000
+}
+
+// GetSignaturesForAddress returns confirmed signatures involving an address,
+// newest first.
+func (c *Client) GetSignaturesForAddress(ctx context.Context, address string, opts SignaturesOptions) ([]SignatureInfo, error) {
+	config := map[string]interface{}{"commitment": "confirmed"}
+	if opts.Before != "" {
+		config["before"] = opts.Before
+	}
+	if opts.Until != "" {
+		config["until"] = opts.Until
+	}
+	if opts.Limit > 0 {
+		config["limit"] = opts.Limit
+	}
+	var result []SignatureInfo
+	if err := c.Call(ctx, "getSignaturesForAddress", []interface{}{address, config}, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetTransactionMeta fetches the metadata of a confirmed transaction. It returns
+// nil, nil if the signature is unknown or not yet confirmed.
+func (c *Client) GetTransactionMeta(ctx context.Context, signature string) (*TransactionMeta, error) {
+	var result *struct {
+		Meta *TransactionMeta `json:"meta"`
+	}
+	params := []interface{}{signature, map[string]interface{}{
+		"encoding":                       "json",
+		"commitment":                     "confirmed",
+		"maxSupportedTransactionVersion": 0,
+	}}
+	if err := c.Call(ctx, "getTransaction", params, &result); err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, nil
+	}
+	return result.Meta, nil
+}
+
+// GetBlockHeight returns the current block height at the given commitment.
+func (c *Client) GetBlockHeight(ctx context.Context, commitment string) (uint64, error) {
+	var height uint64
+	var params []interface{}
+	if commitment != "" {
+		params = []interface{}{map[string]string{"commitment": commitment}}
+	}
+	if err := c.Call(ctx, "getBlockHeight", params, &height); err != nil {
+		return 0, err
+	}
+	return height, nil
+}
+
+// GetBalance returns the lamport balance of an account.
+func (c *Client) GetBalance(ctx context.Context, address string) (uint64, error) {
+	var result struct {
+		Value uint64 `json:"value"`
+	}
+	params := []interface{}{address, map[string]string{"commitment": "confirmed"}}
+	if err := c.Call(ctx, "getBalance", params, &result); err != nil {
+		return 0, err
+	}
+	return result.Value, nil
+}
+
+// SendTransaction submits a signed, base64-encoded transaction and returns its signature.
+func (c *Client) SendTransaction(ctx context.Context, txBase64 string) (string, error) {
+	var signature string
+	params := []interface{}{txBase64, map[string]string{"encoding": "base64"}}
+	if err := c.Call(ctx, "sendTransaction", params, &signature); err != nil {
+		return "", err
+	}
+	return signature, nil
+}
+
+// RequestAirdrop asks the cluster faucet for lamports and returns the airdrop
+// transaction's signature. Only devnet and testnet nodes serve airdrops.
+func (c *Client) RequestAirdrop(ctx context.Context, address string, lamports uint64) (string, error) {
+	var signature string
+	if err := c.Call(ctx, "requestAirdrop", []interface{}{address, lamports}, &signature); err != nil {
+		return "", err
+	}
+	return signature, nil
+}
+
+// PrioritizationFee is a recently observed per-slot minimum priority fee.
+type PrioritizationFee struct {
+	Slot              uint64 `json:"slot"`
+	PrioritizationFee uint64 `json:"prioritizationFee"` // Micro-lamports per compute unit
+}
+
+// GetRecentPrioritizationFees returns priority fees paid in recent slots by transactions
+// that write-lock any of the given accounts (or any transaction if accounts is empty).
+func (c *Client) GetRecentPrioritizationFees(ctx context.Context, accounts []string) ([]PrioritizationFee, error) {
+	var params []interface{}
+	if len(accounts) > 0 {
+		params = []interface{}{accounts}
+	}
+	var result []PrioritizationFee
+	if err := c.Call(ctx, "getRecentPrioritizationFees", params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RecommendedComputeUnitPrice returns the given percentile (1-100) of recent priority
+// fees in micro-lamports per compute unit.
+func (c *Client) RecommendedComputeUnitPrice(ctx context.Context, accounts []string, percentile int) (uint64, error) {
+	fees, err := c.GetRecentPrioritizationFees(ctx, accounts)
+	if err != nil {
+		return 0, err
+	}
+	if len(fees) == 0 {
+		return 0, nil
+	}
+
+	values := make([]uint64, len(fees))
+	for i, f := range fees {
+		values[i] = f.PrioritizationFee
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	if percentile < 1 {
+		percentile = 1
+	}
+	if percentile > 100 {
+		percentile = 100
+	}
+	idx := (len(values)*percentile+99)/100 - 1
+	return values[idx], nil
+}
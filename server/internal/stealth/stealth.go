@@ -0,0 +1,105 @@
+// Package stealth derives one-time Solana addresses, so payments to the same
+// recipient cannot be linked on-chain.
+//
+// A recipient publishes a meta-address, the base58 X25519 public key V of a
+// scan key v. For each payment the sender picks an ephemeral key e, pays to
+// the wallet whose Ed25519 seed is
+//
+//	SHA-256("shadowpay/stealth/v1" || X25519(e, V) || E || V)
+//
+// and announces E. The recipient computes X25519(v, E) to recover the same
+// seed, and with it the wallet's keypair. Whoever holds the scan key can spend
+// the stealth wallets, so it must be kept as secret as a wallet key.
+package stealth
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"sol_privacy/internal/base58"
+	"sol_privacy/internal/wallet"
+)
+
+// Domain separates stealth seeds from other uses of the shared secret.
+const Domain = "shadowpay/stealth/v1"
+
+// Payment is a derived stealth address and the ephemeral key announced with it.
+type Payment struct {
+	Address            string `json:"address"`              // Base58 Solana address to pay
+	EphemeralPublicKey string `json:"ephemeral_public_key"` // Base58 X25519 public key
+}
+
+// NewScanKey generates a random scan key, reading from crypto/rand if r is
+// nil. Senders also use it for ephemeral keys.
+func NewScanKey(r io.Reader) (*ecdh.PrivateKey, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+	return ecdh.X25519().GenerateKey(r)
+}
+
+// ScanKeyFromSeed loads a scan key from its 32 bytes.
+func ScanKeyFromSeed(seed []byte) (*ecdh.PrivateKey, error) {
+	return ecdh.X25519().NewPrivateKey(seed)
+}
+
+// MetaAddress returns the meta-address senders derive stealth addresses from.
+func MetaAddress(scan *ecdh.PrivateKey) string {
+	return base58.Encode(scan.PublicKey().Bytes())
+}
+
+// Derive returns the stealth address for a meta-address using the ephemeral
+// key, which must be fresh for every payment.
+func Derive(metaAddress string, ephemeral *ecdh.PrivateKey) (*Payment, error) {
+	view, err := parseKey("meta-address", metaAddress)
+	if err != nil {
+		return nil, err
+	}
+	kp, err := keypair(ephemeral, view, ephemeral.PublicKey(), view)
+	if err != nil {
+		return nil, err
+	}
+	return &Payment{
+		Address:            kp.Address(),
+		EphemeralPublicKey: base58.Encode(ephemeral.PublicKey().Bytes()),
+	}, nil
+}
+
+// Recover returns the keypair of the stealth wallet announced with
+// ephemeralPublicKey. Recipients scan payments by recovering each and
+// comparing its address.
+func Recover(scan *ecdh.PrivateKey, ephemeralPublicKey string) (*wallet.Keypair, error) {
+	ephemeral, err := parseKey("ephemeral public key", ephemeralPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return keypair(scan, ephemeral, ephemeral, scan.PublicKey())
+}
+
+func keypair(priv *ecdh.PrivateKey, peer, ephemeral, view *ecdh.PublicKey) (*wallet.Keypair, error) {
+	shared, err := priv.ECDH(peer)
+	if err != nil {
+		return nil, fmt.Errorf("stealth: %w", err)
+	}
+	h := sha256.New()
+	h.Write([]byte(Domain))
+	h.Write(shared)
+	h.Write(ephemeral.Bytes())
+	h.Write(view.Bytes())
+	return wallet.FromSeed(h.Sum(nil))
+}
+
+func parseKey(name, s string) (*ecdh.PublicKey, error) {
+	b, err := base58.Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("stealth: invalid %s: %w", name, err)
+	}
+	key, err := ecdh.X25519().NewPublicKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("stealth: invalid %s: %w", name, err)
+	}
+	return key, nil
+}
@@ -0,0 +1,269 @@
+// Package swap converts SPL tokens received at settlement into a merchant's
+// preferred asset (USDC or SOL) through Jupiter, keeping a receipt per conversion.
+package swap
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/jupiter"
+)
+
+// DefaultMaxSlippageBps is used when Config.MaxSlippageBps is zero.
+const DefaultMaxSlippageBps = 50
+
+// Receipt statuses.
+const (
+	StatusPendingSignature = "pending_signature"
+	StatusCompleted        = "completed"
+	StatusFailed           = "failed"
+)
+
+// Event types emitted to the event handler.
+const (
+	EventSwapCompleted = "swap.completed"
+	EventSwapFailed    = "swap.failed"
+)
+
+// Config holds auto-swap configuration for a merchant.
+type Config struct {
+	MerchantWallet string  // Wallet that receives settlements and signs swaps
+	TargetMint     string  // jupiter.USDCMint or jupiter.SOLMint
+	MaxSlippageBps int     // Maximum slippage accepted, defaults to DefaultMaxSlippageBps
+	MaxPriceImpact float64 // Optional, as a fraction (0.01 = 1%)
+}
+
+// Receipt records a single settlement conversion.
+type Receipt struct {
+	ID              string `json:"id"`
+	SettlementTx    string `json:"settlement_tx,omitempty"`
+	InputMint       string `json:"input_mint"`
+	InAmount        int64  `json:"in_amount"`
+	OutputMint      string `json:"output_mint"`
+	QuotedOut       int64  `json:"quoted_out"`
+	MinOut          int64  `json:"min_out"` // Guaranteed after slippage
+	SlippageBps     int    `json:"slippage_bps"`
+	SwapTransaction string `json:"swap_transaction,omitempty"` // Unsigned, base64
+	SwapSignature   string `json:"swap_signature,omitempty"`
+	Status          string `json:"status"`
+	Error           string `json:"error,omitempty"`
+	CreatedAt       int64  `json:"created_at"`
+	CompletedAt     int64  `json:"completed_at,omitempty"`
+}
+
+// Event is emitted when a conversion completes or fails.
+type Event struct {
+	Type      string  `json:"event"`
+	Timestamp int64   `json:"timestamp"`
+	Receipt   Receipt `json:"data"`
+}
+
+// EventHandler receives swap events.
+type EventHandler func(ctx context.Context, event Event)
+
+// Service builds and tracks settlement conversions.
+type Service struct {
+	jupiter *jupiter.Client
+	config  Config
+	onEvent EventHandler
+
+	mu       sync.Mutex
+	receipts map[string]*Receipt
+}
+
+// NewService creates a new swap service.
+func NewService(client *jupiter.Client, config Config, onEvent EventHandler) (*Service, error) {
+	if config.MerchantWallet == "" {
+		return nil, fmt.Errorf("merchant wallet is required")
+	}
+	if config.TargetMint != jupiter.USDCMint && config.TargetMint != jupiter.SOLMint {
+		return nil, fmt.Errorf("target mint must be USDC or SOL")
+	}
+	if config.MaxSlippageBps == 0 {
+		config.MaxSlippageBps = DefaultMaxSlippageBps
+	}
+	if client == nil {
+		client = jupiter.NewClient(jupiter.Config{})
+	}
+	return &Service{
+		jupiter:  client,
+		config:   config,
+		onEvent:  onEvent,
+		receipts: make(map[string]*Receipt),
+	}, nil
+}
+
+// ConvertOnSettlement quotes and builds a swap of a settled amount into the target mint.
+// It returns nil, nil if the settlement is already in the target mint.
+// The returned receipt carries an unsigned transaction for the merchant to sign and submit.
+func (s *Service) ConvertOnSettlement(ctx context.Context, settlementTx, mint string, amount int64) (*Receipt, error) {
+	if mint == "" || mint == s.config.TargetMint {
+		return nil, nil
+	}
+	if amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+
+	receipt := &Receipt{
+		ID:           newID(),
+		SettlementTx: settlementTx,
+		InputMint:    mint,
+		InAmount:     amount,
+		OutputMint:   s.config.TargetMint,
+		SlippageBps:  s.config.MaxSlippageBps,
+		CreatedAt:    time.Now().Unix(),
+	}
+
+	quote, err := s.jupiter.GetQuote(ctx, jupiter.QuoteRequest{
+		InputMint:   mint,
+		OutputMint:  s.config.TargetMint,
+		Amount:      amount,
+		SlippageBps: s.config.MaxSlippageBps,
+	})
+	if err != nil {
+		return s.fail(ctx, receipt, err), err
+	}
+	receipt.QuotedOut = quote.OutAmountInt()
+	receipt.MinOut = quote.MinOutAmountInt()
+
+	if s.config.MaxPriceImpact > 0 && quote.PriceImpact() > s.config.MaxPriceImpact {
+		err := fmt.Errorf("price impact %.4f exceeds limit %.4f", quote.PriceImpact(), s.config.MaxPriceImpact)
+		return s.fail(ctx, receipt, err), err
+	}
+
+	tx, err := s.jupiter.Swap(ctx, jupiter.SwapRequest{
+		QuoteResponse:    quote,
+		UserPublicKey:    s.config.MerchantWallet,
+		WrapAndUnwrapSol: true,
+	})
+	if err != nil {
+		return s.fail(ctx, receipt, err), err
+	}
+	receipt.SwapTransaction = tx.SwapTransaction
+	receipt.Status = StatusPendingSignature
+
+	s.mu.Lock()
+	s.receipts[receipt.ID] = receipt
+	snapshot := *receipt
+	s.mu.Unlock()
+
+	return &snapshot, nil
+}
+
+// Confirm records the signature of a submitted swap and emits swap.completed.
+func (s *Service) Confirm(ctx context.Context, receiptID, swapSignature string) (*Receipt, error) {
+	s.mu.Lock()
+	receipt, ok := s.receipts[receiptID]
+	if !ok {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("swap receipt %s not found", receiptID)
+	}
+	if receipt.Status != StatusPendingSignature {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("swap receipt %s is %s", receiptID, receipt.Status)
+	}
+	receipt.SwapSignature = swapSignature
+	receipt.Status = StatusCompleted
+	receipt.CompletedAt = time.Now().Unix()
+	snapshot := *receipt
+	s.mu.Unlock()
+
+	s.emit(ctx, EventSwapCompleted, snapshot)
+	return &snapshot, nil
+}
+
+// Get returns a receipt by ID.
+func (s *Service) Get(receiptID string) (*Receipt, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.receipts[receiptID]
+	if !ok {
+		return nil, false
+	}
+	snapshot := *r
+	return &snapshot, true
+}
+
+// List returns all receipts, newest first.
+func (s *Service) List() []Receipt {
+	s.mu.Lock()
+	out := make([]Receipt, 0, len(s.receipts))
+	for _, r := range s.receipts {
+		out = append(out, *r)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt > out[j].CreatedAt })
+	return out
+}
+
+func (s *Service) fail(ctx context.Context, receipt *Receipt, err error) *Receipt {
+	receipt.Status = StatusFailed
+	receipt.Error = err.Error()
+
+	s.mu.Lock()
+	s.receipts[receipt.ID] = receipt
+	snapshot := *receipt
+	s.mu.Unlock()
+
+	s.emit(ctx, EventSwapFailed, snapshot)
+	return &snapshot
+}
+
+func (s *Service) emit(ctx context.Context, eventType string, receipt Receipt) {
+	if s.onEvent == nil {
+		return
+	}
+	s.onEvent(ctx, Event{Type: eventType, Timestamp: time.Now().Unix(), Receipt: receipt})
+}
+
+// WebhookHandler returns an EventHandler that POSTs events as JSON to url.
+// Delivery errors are reported to onError if it is non-nil.
+func WebhookHandler(url string, httpClient *http.Client, onError func(error)) EventHandler {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return func(ctx context.Context, event Event) {
+		if err := postEvent(ctx, httpClient, url, event); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
+
+func postEvent(ctx context.Context, httpClient *http.Client, url string, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create event request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("event delivery failed: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("event webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func newID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "swap_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,507 @@
+// Package tax tracks the cost basis of SOL and tokens a wallet moves into and
+// out of the pool and escrow. Deposits open tax lots valued at the time they
+// were made; withdrawals close them, first in first out or last in first out,
+// realizing a gain or loss against what the withdrawal was worth. Reports
+// total the realized gains per calendar year for filing.
+//
+// Movements are stored as recorded and lots are worked out on demand, so a
+// report can be produced with either method from the same history.
+package tax
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/amount"
+	"sol_privacy/internal/jsonfile"
+	"sol_privacy/internal/pricing"
+	"sol_privacy/internal/validate"
+)
+
+// Direction is whether a movement enters or leaves the pool or escrow.
+type Direction string
+
+// Directions.
+const (
+	Deposit    Direction = "deposit"
+	Withdrawal Direction = "withdrawal"
+)
+
+// Accounts a movement can be in.
+const (
+	AccountPool   = "pool"
+	AccountEscrow = "escrow"
+)
+
+// Lot matching methods.
+const (
+	MethodFIFO = "fifo"
+	MethodLIFO = "lifo"
+)
+
+// Export formats.
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// longTermYears is how long a lot is held before its gains are long-term.
+const longTermYears = 1
+
+var (
+	// ErrDuplicate is returned when a movement with the same direction and
+	// reference is already recorded.
+	ErrDuplicate = errors.New("movement already recorded")
+	// ErrUnpriced is returned when a movement has no value and none can be
+	// looked up, as for tokens or without a pricing provider.
+	ErrUnpriced = errors.New("movement value unknown")
+)
+
+// Movement is a recorded deposit or withdrawal.
+type Movement struct {
+	ID        string    `json:"id"`
+	Wallet    string    `json:"wallet"`
+	Account   string    `json:"account"` // pool or escrow
+	Direction Direction `json:"direction"`
+	Amount    int64     `json:"amount"`               // Smallest units of the token
+	TokenMint string    `json:"token_mint,omitempty"` // Empty for SOL
+	Value     float64   `json:"value"`                // In the service currency when it was made
+	Reference string    `json:"reference,omitempty"`  // Transaction signature
+	At        int64     `json:"at"`
+}
+
+// RecordRequest records a movement.
+type RecordRequest struct {
+	Wallet    string    `json:"wallet"`
+	Account   string    `json:"account"`
+	Direction Direction `json:"direction"`
+	Amount    int64     `json:"amount"`
+	TokenMint string    `json:"token_mint,omitempty"`
+	// Value is what the movement was worth. If omitted, SOL movements are
+	// valued at the price when they were made.
+	Value     *float64 `json:"value,omitempty"`
+	Reference string   `json:"reference,omitempty"`
+	At        int64    `json:"at,omitempty"` // Unix seconds; defaults to now
+}
+
+// Validate checks the request fields.
+func (r RecordRequest) Validate() error {
+	v := validate.New().
+		Address("wallet", r.Wallet).
+		Amount("amount", r.Amount, 1, validate.MaxLamports).
+		OptionalAddress("token_mint", r.TokenMint)
+	if r.Account != AccountPool && r.Account != AccountEscrow {
+		v.Add("account", fmt.Errorf("must be %s or %s", AccountPool, AccountEscrow))
+	}
+	if r.Direction != Deposit && r.Direction != Withdrawal {
+		v.Add("direction", fmt.Errorf("must be %s or %s", Deposit, Withdrawal))
+	}
+	if r.Value != nil && (*r.Value < 0 || math.IsNaN(*r.Value) || math.IsInf(*r.Value, 0)) {
+		v.Add("value", errors.New("must be a non-negative number"))
+	}
+	if len(r.Reference) > validate.MaxFieldLength {
+		v.Add("reference", fmt.Errorf("must be at most %d characters", validate.MaxFieldLength))
+	}
+	if r.At < 0 {
+		v.Add("at", errors.New("must be a unix timestamp"))
+	}
+	return v.Err()
+}
+
+// Lot is a deposit, or what remains of it after withdrawals.
+type Lot struct {
+	MovementID string  `json:"movement_id"`
+	Account    string  `json:"account"`
+	TokenMint  string  `json:"token_mint,omitempty"`
+	Acquired   int64   `json:"acquired"`
+	Amount     int64   `json:"amount"`    // Deposited
+	Remaining  int64   `json:"remaining"` // Not yet withdrawn
+	Cost       float64 `json:"cost"`      // Of the remaining amount
+}
+
+// Disposal is the part of a withdrawal matched to one lot.
+type Disposal struct {
+	MovementID string  `json:"movement_id"` // The withdrawal
+	LotID      string  `json:"lot_id,omitempty"`
+	TokenMint  string  `json:"token_mint,omitempty"`
+	Amount     int64   `json:"amount"`
+	Acquired   int64   `json:"acquired,omitempty"` // Zero when unmatched
+	Disposed   int64   `json:"disposed"`
+	Proceeds   float64 `json:"proceeds"`
+	CostBasis  float64 `json:"cost_basis"`
+	Gain       float64 `json:"gain"`
+	LongTerm   bool    `json:"long_term"`
+	// Unmatched is set when more was withdrawn than recorded deposits hold;
+	// its cost basis is zero.
+	Unmatched bool `json:"unmatched,omitempty"`
+}
+
+// Report is a wallet's realized gains in a calendar year (UTC).
+type Report struct {
+	Wallet    string     `json:"wallet"`
+	Year      int        `json:"year"`
+	Method    string     `json:"method"`
+	Currency  string     `json:"currency"`
+	Disposals []Disposal `json:"disposals"`
+	Proceeds  float64    `json:"proceeds"`
+	CostBasis float64    `json:"cost_basis"`
+	Gain      float64    `json:"gain"`
+	ShortTerm float64    `json:"short_term_gain"`
+	LongTerm  float64    `json:"long_term_gain"`
+	Open      []Lot      `json:"open_lots"` // Held at the end of the year
+}
+
+// Store persists movements.
+type Store interface {
+	Add(m *Movement) error
+	List() ([]*Movement, error) // Oldest first
+}
+
+// NewMemoryStore creates an empty in-memory Store.
+func NewMemoryStore() Store {
+	return store{jsonfile.NewMemoryStore(movementID, nil)}
+}
+
+// NewFileStore opens a Store persisted to a JSON file after every write,
+// creating it on first write.
+func NewFileStore(path string) (Store, error) {
+	movements, err := jsonfile.NewStore(path, movementID, nil)
+	if err != nil {
+		return nil, err
+	}
+	return store{movements}, nil
+}
+
+func movementID(m *Movement) string { return m.ID }
+
+// store keeps movements in a jsonfile.Store.
+type store struct {
+	movements *jsonfile.Store[Movement]
+}
+
+func (s store) Add(m *Movement) error {
+	return s.movements.Put(m)
+}
+
+// List returns all movements, oldest first.
+func (s store) List() ([]*Movement, error) {
+	return s.movements.List(func(a, b *Movement) bool { return a.At < b.At }), nil
+}
+
+// Service records movements and works out lots and gains.
+type Service struct {
+	store    Store
+	prices   pricing.Provider
+	currency string
+	mu       sync.Mutex // Serializes duplicate checks with writes
+}
+
+// NewService creates a tax ledger valued in currency, such as "usd". A nil
+// store selects an in-memory store; prices values SOL movements recorded
+// without a value and may be nil.
+func NewService(store Store, prices pricing.Provider, currency string) *Service {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	if currency == "" {
+		currency = "usd"
+	}
+	return &Service{store: store, prices: prices, currency: currency}
+}
+
+// Currency returns the currency values are in.
+func (s *Service) Currency() string {
+	return s.currency
+}
+
+// Record adds a movement. A movement with the same direction and non-empty
+// reference is only recorded once.
+func (s *Service) Record(ctx context.Context, req RecordRequest) (*Movement, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if req.At == 0 {
+		req.At = time.Now().Unix()
+	}
+
+	var value float64
+	switch {
+	case req.Value != nil:
+		value = *req.Value
+	case req.TokenMint == "" && s.prices != nil:
+		price, err := s.prices.SOLPrice(ctx, s.currency, time.Unix(req.At, 0))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnpriced, err)
+		}
+		value = roundCents(float64(req.Amount) / amount.LamportsPerSOL * price)
+	default:
+		return nil, ErrUnpriced
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if req.Reference != "" {
+		all, err := s.store.List()
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range all {
+			if m.Direction == req.Direction && m.Reference == req.Reference {
+				return nil, ErrDuplicate
+			}
+		}
+	}
+
+	m := &Movement{
+		ID:        newID(),
+		Wallet:    req.Wallet,
+		Account:   req.Account,
+		Direction: req.Direction,
+		Amount:    req.Amount,
+		TokenMint: req.TokenMint,
+		Value:     value,
+		Reference: req.Reference,
+		At:        req.At,
+	}
+	if err := s.store.Add(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// List returns a wallet's movements, oldest first.
+func (s *Service) List(ctx context.Context, wallet string) ([]*Movement, error) {
+	all, err := s.store.List()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*Movement, 0, len(all))
+	for _, m := range all {
+		if m.Wallet == wallet {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+// Lots returns the lots a wallet holds now, oldest first.
+func (s *Service) Lots(ctx context.Context, wallet, method string) ([]Lot, error) {
+	v := validate.New().Address("wallet", wallet).Add("method", checkMethod(method))
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+	movements, err := s.List(ctx, wallet)
+	if err != nil {
+		return nil, err
+	}
+	open, _ := match(movements, method, math.MaxInt64)
+	return open, nil
+}
+
+// Report works out a wallet's realized gains in a calendar year.
+func (s *Service) Report(ctx context.Context, wallet string, year int, method string) (*Report, error) {
+	v := validate.New().Address("wallet", wallet).Add("method", checkMethod(method))
+	if year < 1970 || year > 9999 {
+		v.Add("year", errors.New("must be a calendar year"))
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+	if method == "" {
+		method = MethodFIFO
+	}
+	movements, err := s.List(ctx, wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	end := time.Date(year+1, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	open, disposals := match(movements, method, end)
+	report := &Report{
+		Wallet:    wallet,
+		Year:      year,
+		Method:    method,
+		Currency:  s.currency,
+		Disposals: []Disposal{},
+		Open:      open,
+	}
+	for _, d := range disposals {
+		if d.Disposed < start {
+			continue
+		}
+		report.Disposals = append(report.Disposals, d)
+		report.Proceeds += d.Proceeds
+		report.CostBasis += d.CostBasis
+		if d.LongTerm {
+			report.LongTerm += d.Gain
+		} else {
+			report.ShortTerm += d.Gain
+		}
+	}
+	report.Proceeds = roundCents(report.Proceeds)
+	report.CostBasis = roundCents(report.CostBasis)
+	report.ShortTerm = roundCents(report.ShortTerm)
+	report.LongTerm = roundCents(report.LongTerm)
+	report.Gain = roundCents(report.ShortTerm + report.LongTerm)
+	return report, nil
+}
+
+// Export writes a wallet's annual report to w: as CSV, one disposal per row,
+// or as JSON, the whole report.
+func (s *Service) Export(ctx context.Context, w io.Writer, format, wallet string, year int, method string) error {
+	if format != FormatCSV && format != FormatJSON && format != "" {
+		return validate.Errors{{Field: "format", Message: "must be csv or json"}}
+	}
+	report, err := s.Report(ctx, wallet, year, method)
+	if err != nil {
+		return err
+	}
+	if format == FormatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"asset", "amount", "acquired", "disposed", "proceeds", "cost_basis", "gain", "term", "withdrawal"})
+	for _, d := range report.Disposals {
+		asset, qty := "SOL", amount.Lamports(d.Amount).SOL()
+		if d.TokenMint != "" {
+			asset, qty = d.TokenMint, strconv.FormatInt(d.Amount, 10)
+		}
+		acquired, term := "unknown", "short"
+		if !d.Unmatched {
+			acquired = time.Unix(d.Acquired, 0).UTC().Format("2006-01-02")
+		}
+		if d.LongTerm {
+			term = "long"
+		}
+		cw.Write([]string{
+			asset,
+			qty,
+			acquired,
+			time.Unix(d.Disposed, 0).UTC().Format("2006-01-02"),
+			formatCents(d.Proceeds),
+			formatCents(d.CostBasis),
+			formatCents(d.Gain),
+			term,
+			d.MovementID,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// match replays the movements before end, matching withdrawals to the lots of
+// the same token. It returns the lots still open and every disposal.
+func match(movements []*Movement, method string, end int64) ([]Lot, []Disposal) {
+	lots := make(map[string][]Lot) // By token mint
+	var disposals []Disposal
+	for _, m := range movements {
+		if m.At >= end {
+			break
+		}
+		if m.Direction == Deposit {
+			lots[m.TokenMint] = append(lots[m.TokenMint], Lot{
+				MovementID: m.ID,
+				Account:    m.Account,
+				TokenMint:  m.TokenMint,
+				Acquired:   m.At,
+				Amount:     m.Amount,
+				Remaining:  m.Amount,
+				Cost:       m.Value,
+			})
+			continue
+		}
+
+		held := lots[m.TokenMint]
+		left := m.Amount
+		for left > 0 && len(held) > 0 {
+			i := 0
+			if method == MethodLIFO {
+				i = len(held) - 1
+			}
+			lot := &held[i]
+			n := min(left, lot.Remaining)
+			cost := lot.Cost * float64(n) / float64(lot.Remaining)
+			proceeds := m.Value * float64(n) / float64(m.Amount)
+			disposals = append(disposals, disposal(m, n, proceeds, cost, lot))
+			lot.Remaining -= n
+			lot.Cost -= cost
+			left -= n
+			if lot.Remaining == 0 {
+				held = append(held[:i], held[i+1:]...)
+			}
+		}
+		if left > 0 {
+			proceeds := m.Value * float64(left) / float64(m.Amount)
+			disposals = append(disposals, disposal(m, left, proceeds, 0, nil))
+		}
+		lots[m.TokenMint] = held
+	}
+
+	var open []Lot
+	for _, held := range lots {
+		for _, lot := range held {
+			lot.Cost = roundCents(lot.Cost)
+			open = append(open, lot)
+		}
+	}
+	sort.SliceStable(open, func(i, j int) bool { return open[i].Acquired < open[j].Acquired })
+	return open, disposals
+}
+
+// disposal is n units of withdrawal m matched to lot, or unmatched if nil.
+func disposal(m *Movement, n int64, proceeds, cost float64, lot *Lot) Disposal {
+	d := Disposal{
+		MovementID: m.ID,
+		TokenMint:  m.TokenMint,
+		Amount:     n,
+		Disposed:   m.At,
+		Proceeds:   roundCents(proceeds),
+		CostBasis:  roundCents(cost),
+		Unmatched:  lot == nil,
+	}
+	if lot != nil {
+		d.LotID = lot.MovementID
+		d.Acquired = lot.Acquired
+		held := time.Unix(lot.Acquired, 0).AddDate(longTermYears, 0, 0)
+		d.LongTerm = time.Unix(m.At, 0).After(held)
+	}
+	d.Gain = roundCents(d.Proceeds - d.CostBasis)
+	return d
+}
+
+func checkMethod(method string) error {
+	switch method {
+	case MethodFIFO, MethodLIFO, "":
+		return nil
+	}
+	return fmt.Errorf("must be %s or %s", MethodFIFO, MethodLIFO)
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func formatCents(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+func newID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "mv_" + hex.EncodeToString(b)
+}
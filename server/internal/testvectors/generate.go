@@ -0,0 +1,138 @@
+package testvectors
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"sol_privacy/internal/elgamal"
+	"sol_privacy/internal/merkle"
+	"sol_privacy/internal/poseidon"
+)
+
+// seed derives the fixed 32 bytes a vector is built from.
+func seed(name string, i int) []byte {
+	h := sha256.Sum256(fmt.Appendf(nil, "shadowpay/testvectors/%s/%d", name, i))
+	return h[:]
+}
+
+// element derives a fixed field element.
+func element(name string, i int) *big.Int {
+	return poseidon.FieldElement(seed(name, i))
+}
+
+// Generate builds the vectors from fixed inputs with the Go implementations.
+func Generate() (*Vectors, error) {
+	v := &Vectors{Version: Version}
+
+	// Poseidon: circomlibjs's published hashes, edge elements and every width
+	for _, ref := range circomlibPoseidon {
+		in := make([]*big.Int, len(ref.Inputs))
+		for j, s := range ref.Inputs {
+			in[j], _ = new(big.Int).SetString(s, 10)
+		}
+		h, err := poseidon.Hash(in...)
+		if err != nil {
+			return nil, err
+		}
+		if h.String() != ref.Hash {
+			return nil, fmt.Errorf("testvectors: poseidon%v is %s, circomlibjs has %s", ref.Inputs, h, ref.Hash)
+		}
+		v.Poseidon = append(v.Poseidon, ref)
+	}
+	maxElement := new(big.Int).Sub(poseidon.Modulus, big.NewInt(1))
+	inputs := [][]*big.Int{
+		{maxElement, maxElement},
+	}
+	for width := 1; width <= poseidon.MaxInputs; width++ {
+		in := make([]*big.Int, width)
+		for j := range in {
+			in[j] = element(fmt.Sprintf("poseidon/%d", width), j)
+		}
+		inputs = append(inputs, in)
+	}
+	for _, in := range inputs {
+		h, err := poseidon.Hash(in...)
+		if err != nil {
+			return nil, err
+		}
+		pv := PoseidonVector{Hash: h.String()}
+		for _, n := range in {
+			pv.Inputs = append(pv.Inputs, n.String())
+		}
+		v.Poseidon = append(v.Poseidon, pv)
+	}
+
+	// Merkle: a small tree proving every position, a deep sparse one, and an
+	// empty one whose root zk-kit publishes
+	for _, t := range []struct {
+		depth, leaves int
+		proofs        []int
+	}{
+		{4, 5, []int{0, 1, 2, 3, 4}},
+		{20, 3, []int{0, 2}},
+		{5, 0, nil},
+	} {
+		mv := MerkleVector{Depth: t.depth, Leaves: []string{}, Proofs: []MerkleProof{}}
+		for i := range t.leaves {
+			mv.Leaves = append(mv.Leaves, merkle.FormatElement(element(fmt.Sprintf("merkle/%d", t.depth), i)))
+		}
+		tree, err := buildTree(t.depth, mv.Leaves)
+		if err != nil {
+			return nil, err
+		}
+		mv.Root = merkle.FormatElement(tree.Root())
+		for _, index := range t.proofs {
+			proof, err := tree.Proof(index)
+			if err != nil {
+				return nil, err
+			}
+			mv.Proofs = append(mv.Proofs, formatProof(proof))
+		}
+		v.Merkle = append(v.Merkle, mv)
+	}
+
+	// ShadowID
+	for i := range 3 {
+		sv, err := shadowIDVector(hex.EncodeToString(seed("shadowid", i)))
+		if err != nil {
+			return nil, err
+		}
+		v.ShadowID = append(v.ShadowID, *sv)
+	}
+
+	// ElGamal: zero, small and large amounts, two to the same key
+	for i, amount := range []uint64{0, 1, 1_000_000, 2_500_000_000} {
+		d := element("elgamal/key", i/2)
+		if d.Sign() == 0 {
+			d.SetInt64(1)
+		}
+		priv, err := elgamal.NewPrivateKey(d)
+		if err != nil {
+			return nil, err
+		}
+		nonce := merkle.FormatElement(element("elgamal/nonce", i))
+		ct, err := elgamalCiphertext(priv, amount, nonce)
+		if err != nil {
+			return nil, err
+		}
+		v.ElGamal = append(v.ElGamal, ElGamalVector{
+			PrivateKey: priv.Hex(),
+			PublicKey:  priv.Public().Hex(),
+			Amount:     amount,
+			Nonce:      nonce,
+			Ciphertext: ct,
+		})
+	}
+
+	// Stealth: two payments to the same meta-address, one to another
+	for i, scan := range []int{0, 0, 1} {
+		sv, err := stealthVector(hex.EncodeToString(seed("stealth/scan", scan)), hex.EncodeToString(seed("stealth/ephemeral", i)))
+		if err != nil {
+			return nil, err
+		}
+		v.Stealth = append(v.Stealth, *sv)
+	}
+	return v, nil
+}
@@ -0,0 +1,49 @@
+package testvectors
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestVectors(t *testing.T) {
+	Check(t)
+}
+
+func TestGoldenIsCurrent(t *testing.T) {
+	v, err := Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, golden) {
+		t.Error("vectors.json is stale; run go generate ./internal/testvectors")
+	}
+}
+
+func TestVerifyDetectsChanges(t *testing.T) {
+	v, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Poseidon[len(v.Poseidon)-1].Hash = "1"
+	v.Merkle[0].Root = v.Merkle[1].Root
+	v.ElGamal[0].Amount++
+	if errs := Verify(v); len(errs) < 3 {
+		t.Errorf("Verify reported %d errors for 3 altered vectors: %v", len(errs), errs)
+	}
+}
+
+func TestVerifyReferencesRequiresPublishedValues(t *testing.T) {
+	v, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Poseidon = v.Poseidon[len(circomlibPoseidon):]
+	v.Merkle = v.Merkle[:len(v.Merkle)-1]
+	if errs := VerifyReferences(v); len(errs) != len(circomlibPoseidon)+1 {
+		t.Errorf("VerifyReferences reported %d errors, expected %d: %v", len(errs), len(circomlibPoseidon)+1, errs)
+	}
+}
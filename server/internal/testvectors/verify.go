@@ -0,0 +1,296 @@
+package testvectors
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"sol_privacy/internal/elgamal"
+	"sol_privacy/internal/merkle"
+	"sol_privacy/internal/poseidon"
+	"sol_privacy/internal/shadowid"
+	"sol_privacy/internal/stealth"
+	"sol_privacy/internal/wallet"
+)
+
+// Verify recomputes every vector from its inputs and returns one error per
+// mismatch. It also checks the outputs are consistent with each other: proofs
+// verify, ciphertexts decrypt and stealth wallets are recoverable.
+func Verify(v *Vectors) []error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	for i, pv := range v.Poseidon {
+		name := fmt.Sprintf("poseidon[%d]", i)
+		inputs := make([]*big.Int, len(pv.Inputs))
+		for j, s := range pv.Inputs {
+			n, ok := new(big.Int).SetString(s, 10)
+			if !ok {
+				fail("%s: input %d: invalid decimal %q", name, j, s)
+				continue
+			}
+			inputs[j] = n
+		}
+		h, err := poseidon.Hash(inputs...)
+		if err != nil {
+			fail("%s: %v", name, err)
+		} else if h.String() != pv.Hash {
+			fail("%s: hash is %s, expected %s", name, h, pv.Hash)
+		}
+	}
+
+	for i, mv := range v.Merkle {
+		name := fmt.Sprintf("merkle[%d]", i)
+		tree, err := buildTree(mv.Depth, mv.Leaves)
+		if err != nil {
+			fail("%s: %v", name, err)
+			continue
+		}
+		if root := merkle.FormatElement(tree.Root()); root != mv.Root {
+			fail("%s: root is %s, expected %s", name, root, mv.Root)
+		}
+		for j, want := range mv.Proofs {
+			pname := fmt.Sprintf("%s.proofs[%d]", name, j)
+			proof, err := tree.Proof(want.LeafIndex)
+			if err != nil {
+				fail("%s: %v", pname, err)
+				continue
+			}
+			if got := formatProof(proof); !equalProofs(got, want) {
+				fail("%s: proof of leaf %d differs from the tree's", pname, want.LeafIndex)
+			}
+			parsed, err := parseProof(want)
+			if err != nil {
+				fail("%s: %v", pname, err)
+			} else if !merkle.Verify(parsed, merkle.PoseidonHash) {
+				fail("%s: proof does not verify", pname)
+			}
+		}
+	}
+
+	for i, sv := range v.ShadowID {
+		name := fmt.Sprintf("shadowid[%d]", i)
+		got, err := shadowIDVector(sv.WalletSeed)
+		if err != nil {
+			fail("%s: %v", name, err)
+			continue
+		}
+		for _, f := range []struct{ field, got, want string }{
+			{"wallet_address", got.WalletAddress, sv.WalletAddress},
+			{"message", got.Message, sv.Message},
+			{"signature", got.Signature, sv.Signature},
+			{"secret", got.Secret, sv.Secret},
+			{"nullifier", got.Nullifier, sv.Nullifier},
+			{"nullifier_hash", got.NullifierHash, sv.NullifierHash},
+			{"commitment", got.Commitment, sv.Commitment},
+		} {
+			if f.got != f.want {
+				fail("%s: %s is %q, expected %q", name, f.field, f.got, f.want)
+			}
+		}
+	}
+
+	for i, ev := range v.ElGamal {
+		name := fmt.Sprintf("elgamal[%d]", i)
+		priv, err := elgamal.ParsePrivateKey(ev.PrivateKey)
+		if err != nil {
+			fail("%s: %v", name, err)
+			continue
+		}
+		if pub := priv.Public().Hex(); pub != ev.PublicKey {
+			fail("%s: public key is %s, expected %s", name, pub, ev.PublicKey)
+		}
+		got, err := elgamalCiphertext(priv, ev.Amount, ev.Nonce)
+		if err != nil {
+			fail("%s: %v", name, err)
+		} else if got != ev.Ciphertext {
+			fail("%s: ciphertext is %s, expected %s", name, got, ev.Ciphertext)
+		}
+		ct, err := elgamal.ParseCiphertext(ev.Ciphertext)
+		if err != nil {
+			fail("%s: %v", name, err)
+			continue
+		}
+		if amount, err := priv.Decrypt(ct, max(ev.Amount, 1<<16)); err != nil {
+			fail("%s: %v", name, err)
+		} else if amount != ev.Amount {
+			fail("%s: decrypts to %d, expected %d", name, amount, ev.Amount)
+		}
+	}
+
+	for i, sv := range v.Stealth {
+		name := fmt.Sprintf("stealth[%d]", i)
+		got, err := stealthVector(sv.ScanSeed, sv.EphemeralSeed)
+		if err != nil {
+			fail("%s: %v", name, err)
+			continue
+		}
+		for _, f := range []struct{ field, got, want string }{
+			{"meta_address", got.MetaAddress, sv.MetaAddress},
+			{"ephemeral_public_key", got.EphemeralPublicKey, sv.EphemeralPublicKey},
+			{"address", got.Address, sv.Address},
+		} {
+			if f.got != f.want {
+				fail("%s: %s is %q, expected %q", name, f.field, f.got, f.want)
+			}
+		}
+		scan, err := stealth.ScanKeyFromSeed(mustHex(sv.ScanSeed))
+		if err != nil {
+			fail("%s: %v", name, err)
+			continue
+		}
+		if kp, err := stealth.Recover(scan, sv.EphemeralPublicKey); err != nil {
+			fail("%s: %v", name, err)
+		} else if kp.Address() != sv.Address {
+			fail("%s: recipient recovers %s, expected %s", name, kp.Address(), sv.Address)
+		}
+	}
+	return errs
+}
+
+func buildTree(depth int, leaves []string) (*merkle.Tree, error) {
+	tree, err := merkle.NewPoseidon(depth)
+	if err != nil {
+		return nil, err
+	}
+	elements := make([]*big.Int, len(leaves))
+	for i, l := range leaves {
+		if elements[i], err = merkle.ParseElement(l); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := tree.InsertMany(elements); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+func formatProof(p *merkle.Proof) MerkleProof {
+	siblings := make([]string, len(p.Siblings))
+	for i, s := range p.Siblings {
+		siblings[i] = merkle.FormatElement(s)
+	}
+	return MerkleProof{
+		LeafIndex:   p.LeafIndex,
+		Leaf:        merkle.FormatElement(p.Leaf),
+		Siblings:    siblings,
+		PathIndices: p.PathIndices,
+		Root:        merkle.FormatElement(p.Root),
+	}
+}
+
+func parseProof(p MerkleProof) (*merkle.Proof, error) {
+	proof := &merkle.Proof{LeafIndex: p.LeafIndex, PathIndices: p.PathIndices}
+	var err error
+	if proof.Leaf, err = merkle.ParseElement(p.Leaf); err != nil {
+		return nil, err
+	}
+	if proof.Root, err = merkle.ParseElement(p.Root); err != nil {
+		return nil, err
+	}
+	proof.Siblings = make([]*big.Int, len(p.Siblings))
+	for i, s := range p.Siblings {
+		if proof.Siblings[i], err = merkle.ParseElement(s); err != nil {
+			return nil, err
+		}
+	}
+	return proof, nil
+}
+
+func equalProofs(a, b MerkleProof) bool {
+	if a.LeafIndex != b.LeafIndex || a.Leaf != b.Leaf || a.Root != b.Root ||
+		len(a.Siblings) != len(b.Siblings) || len(a.PathIndices) != len(b.PathIndices) {
+		return false
+	}
+	for i := range a.Siblings {
+		if a.Siblings[i] != b.Siblings[i] {
+			return false
+		}
+	}
+	for i := range a.PathIndices {
+		if a.PathIndices[i] != b.PathIndices[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func shadowIDVector(seed string) (*ShadowIDVector, error) {
+	b, err := hex.DecodeString(seed)
+	if err != nil {
+		return nil, fmt.Errorf("wallet seed: %w", err)
+	}
+	kp, err := wallet.FromSeed(b)
+	if err != nil {
+		return nil, err
+	}
+	id, err := shadowid.DeriveCommitment(kp)
+	if err != nil {
+		return nil, err
+	}
+	nullifierHash, err := poseidon.NullifierHash(id.Nullifier)
+	if err != nil {
+		return nil, err
+	}
+	return &ShadowIDVector{
+		WalletSeed:    seed,
+		WalletAddress: id.WalletAddress,
+		Message:       id.Message,
+		Signature:     id.Signature,
+		Secret:        merkle.FormatElement(id.Secret),
+		Nullifier:     merkle.FormatElement(id.Nullifier),
+		NullifierHash: merkle.FormatElement(nullifierHash),
+		Commitment:    id.CommitmentHex(),
+	}, nil
+}
+
+func elgamalCiphertext(priv *elgamal.PrivateKey, amount uint64, nonce string) (string, error) {
+	k, err := merkle.ParseElement(nonce)
+	if err != nil {
+		return "", fmt.Errorf("nonce: %w", err)
+	}
+	ct, err := elgamal.EncryptWithNonce(priv.Public(), amount, k)
+	if err != nil {
+		return "", err
+	}
+	return ct.Hex(), nil
+}
+
+func stealthVector(scanSeed, ephemeralSeed string) (*StealthVector, error) {
+	scanBytes, err := hex.DecodeString(scanSeed)
+	if err != nil {
+		return nil, fmt.Errorf("scan seed: %w", err)
+	}
+	ephemeralBytes, err := hex.DecodeString(ephemeralSeed)
+	if err != nil {
+		return nil, fmt.Errorf("ephemeral seed: %w", err)
+	}
+	scan, err := stealth.ScanKeyFromSeed(scanBytes)
+	if err != nil {
+		return nil, err
+	}
+	ephemeral, err := stealth.ScanKeyFromSeed(ephemeralBytes)
+	if err != nil {
+		return nil, err
+	}
+	meta := stealth.MetaAddress(scan)
+	payment, err := stealth.Derive(meta, ephemeral)
+	if err != nil {
+		return nil, err
+	}
+	return &StealthVector{
+		ScanSeed:           scanSeed,
+		MetaAddress:        meta,
+		EphemeralSeed:      ephemeralSeed,
+		EphemeralPublicKey: payment.EphemeralPublicKey,
+		Address:            payment.Address,
+	}, nil
+}
+
+// mustHex decodes a seed that stealthVector already decoded successfully.
+func mustHex(s string) []byte {
+	b, _ := hex.DecodeString(s)
+	return b
+}
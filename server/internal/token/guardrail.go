@@ -0,0 +1,103 @@
+package token
+
+import (
+	"context"
+	"fmt"
+
+	"sol_privacy/internal/jupiter"
+)
+
+// Guardrail limits which SPL-token payments a merchant accepts.
+type Guardrail struct {
+	MaxPaymentAmount  int64   `json:"max_payment_amount,omitempty"`   // In smallest token units, 0 = no limit
+	MinQuoteOut       int64   `json:"min_quote_out,omitempty"`        // Minimum quoted output in QuoteMint units
+	MaxPriceImpactPct float64 `json:"max_price_impact_pct,omitempty"` // e.g. 0.01 for 1%, 0 = no limit
+	QuoteMint         string  `json:"quote_mint,omitempty"`           // Liquidity is checked against this mint, defaults to USDC
+}
+
+// PaymentValidation is the result of checking a payment against its token's guardrail.
+type PaymentValidation struct {
+	Allowed bool           `json:"allowed"`
+	Reason  string         `json:"reason,omitempty"`
+	Quote   *jupiter.Quote `json:"quote,omitempty"`
+}
+
+// SetGuardrail configures the guardrail for a mint. A nil guardrail removes it.
+func (s *Service) SetGuardrail(mint string, g *Guardrail) {
+	s.guardMu.Lock()
+	defer s.guardMu.Unlock()
+	if s.guardrails == nil {
+		s.guardrails = make(map[string]Guardrail)
+	}
+	if g == nil {
+		delete(s.guardrails, mint)
+		return
+	}
+	s.guardrails[mint] = *g
+}
+
+// SetQuoteClient configures the Jupiter client used for liquidity checks.
+func (s *Service) SetQuoteClient(c *jupiter.Client) {
+	s.guardMu.Lock()
+	defer s.guardMu.Unlock()
+	s.jupiter = c
+}
+
+// ValidatePayment checks an SPL-token payment of amount (smallest units) against the
+// mint's guardrail. Payments in mints without a guardrail are always allowed.
+// Liquidity is checked by quoting a swap of amount into the guardrail's QuoteMint.
+func (s *Service) ValidatePayment(ctx context.Context, mint string, amount int64) (*PaymentValidation, error) {
+	if amount <= 0 {
+		return &PaymentValidation{Reason: "amount must be positive"}, nil
+	}
+
+	s.guardMu.Lock()
+	g, ok := s.guardrails[mint]
+	if s.jupiter == nil {
+		s.jupiter = jupiter.NewClient(jupiter.Config{})
+	}
+	quoter := s.jupiter
+	s.guardMu.Unlock()
+
+	if !ok {
+		return &PaymentValidation{Allowed: true}, nil
+	}
+
+	if g.MaxPaymentAmount > 0 && amount > g.MaxPaymentAmount {
+		return &PaymentValidation{
+			Reason: fmt.Sprintf("amount %d exceeds max payment size %d", amount, g.MaxPaymentAmount),
+		}, nil
+	}
+
+	if g.MinQuoteOut == 0 && g.MaxPriceImpactPct == 0 {
+		return &PaymentValidation{Allowed: true}, nil
+	}
+
+	quoteMint := g.QuoteMint
+	if quoteMint == "" {
+		quoteMint = jupiter.USDCMint
+	}
+	if quoteMint == mint {
+		return &PaymentValidation{Allowed: true}, nil
+	}
+
+	quote, err := quoter.GetQuote(ctx, jupiter.QuoteRequest{
+		InputMint:  mint,
+		OutputMint: quoteMint,
+		Amount:     amount,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PaymentValidation{Quote: quote}
+	switch {
+	case g.MinQuoteOut > 0 && quote.OutAmountInt() < g.MinQuoteOut:
+		result.Reason = fmt.Sprintf("insufficient liquidity: quoted %s, need at least %d", quote.OutAmount, g.MinQuoteOut)
+	case g.MaxPriceImpactPct > 0 && quote.PriceImpact() > g.MaxPriceImpactPct:
+		result.Reason = fmt.Sprintf("price impact %.4f exceeds limit %.4f", quote.PriceImpact(), g.MaxPriceImpactPct)
+	default:
+		result.Allowed = true
+	}
+	return result, nil
+}
@@ -0,0 +1,264 @@
+package token
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"sol_privacy/internal/solana"
+)
+
+// DefaultMetadataTTL is how long resolved token metadata is cached.
+const DefaultMetadataTTL = time.Hour
+
+// maxOffchainMetadataSize bounds the off-chain metadata JSON read for a logo.
+const maxOffchainMetadataSize = 64 << 10
+
+// errNonPublicAddress is returned for metadata URIs resolving to loopback,
+// private or otherwise internal addresses.
+var errNonPublicAddress = errors.New("metadata uri resolves to a non-public address")
+
+// Metadata contains on-chain Metaplex metadata for a mint.
+type Metadata struct {
+	Name    string `json:"name"`
+	Symbol  string `json:"symbol"`
+	URI     string `json:"uri,omitempty"`      // Off-chain JSON metadata
+	LogoURI string `json:"logo_uri,omitempty"` // "image" field of the off-chain JSON
+}
+
+// DetailedToken is a supported token enriched with on-chain metadata.
+type DetailedToken struct {
+	Token
+	Metadata      *Metadata `json:"metadata,omitempty"`
+	MetadataError string    `json:"metadata_error,omitempty"`
+}
+
+// ListSupportedDetailedResponse contains supported tokens with resolved metadata.
+type ListSupportedDetailedResponse struct {
+	Tokens []DetailedToken `json:"tokens"`
+}
+
+// MetadataResolver looks up Metaplex metadata and logos for mints, caching results.
+type MetadataResolver struct {
+	rpc        *solana.Client
+	httpClient *http.Client
+	ttl        time.Duration
+
+	mu    sync.Mutex
+	cache map[string]metadataEntry
+}
+
+type metadataEntry struct {
+	metadata  *Metadata
+	expiresAt time.Time
+}
+
+// NewMetadataResolver creates a resolver backed by the given RPC client.
+// A zero ttl uses DefaultMetadataTTL.
+func NewMetadataResolver(rpc *solana.Client, ttl time.Duration) *MetadataResolver {
+	if ttl == 0 {
+		ttl = DefaultMetadataTTL
+	}
+	return &MetadataResolver{
+		rpc:        rpc,
+		httpClient: newOffchainClient(),
+		ttl:        ttl,
+		cache:      make(map[string]metadataEntry),
+	}
+}
+
+// Resolve returns metadata for a mint. It returns nil, nil if the mint has no metadata account.
+func (r *MetadataResolver) Resolve(ctx context.Context, mint string) (*Metadata, error) {
+	r.mu.Lock()
+	if e, ok := r.cache[mint]; ok && time.Now().Before(e.expiresAt) {
+		r.mu.Unlock()
+		return e.metadata, nil
+	}
+	r.mu.Unlock()
+
+	address, err := solana.MetadataAddress(mint)
+	if err != nil {
+		return nil, err
+	}
+	account, err := r.rpc.GetAccountInfo(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch metadata account: %w", err)
+	}
+
+	var md *Metadata
+	if account != nil {
+		if md, err = parseMetadataAccount(account.Data); err != nil {
+			return nil, err
+		}
+		if md.URI != "" {
+			// Logo lookup is best effort; the on-chain fields are still useful without it
+			md.LogoURI, _ = r.fetchLogo(ctx, md.URI)
+		}
+	}
+
+	r.mu.Lock()
+	r.cache[mint] = metadataEntry{metadata: md, expiresAt: time.Now().Add(r.ttl)}
+	r.mu.Unlock()
+
+	return md, nil
+}
+
+// newOffchainClient returns the client fetching off-chain metadata. The URI
+// comes from whoever created the mint, so it may only reach public https
+// addresses: the check runs on the dialed IP, after DNS resolution and on
+// every redirect, so a hostname cannot rebind to an internal service.
+func newOffchainClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			ap, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !isPublicAddr(ap.Addr()) {
+				return errNonPublicAddress
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			// No proxy: it would be dialed instead of the metadata host
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 3 {
+				return errors.New("metadata uri redirected too many times")
+			}
+			if req.URL.Scheme != "https" {
+				return fmt.Errorf("metadata uri redirected to %s, only https is fetched", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+}
+
+// isPublicAddr reports whether ip is a globally routable unicast address.
+func isPublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() &&
+		!netip.MustParsePrefix("100.64.0.0/10").Contains(ip) // Carrier-grade NAT
+}
+
+func (r *MetadataResolver) fetchLogo(ctx context.Context, uri string) (string, error) {
+	if !strings.HasPrefix(uri, "https://") {
+		return "", fmt.Errorf("metadata uri %q is not https", uri)
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", uri, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("metadata uri returned status %d", resp.StatusCode)
+	}
+
+	var offchain struct {
+		Image string `json:"image"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxOffchainMetadataSize)).Decode(&offchain); err != nil {
+		return "", err
+	}
+	// The logo is shown to users, so it must not be a javascript: or data: URL
+	if !strings.HasPrefix(offchain.Image, "https://") {
+		return "", nil
+	}
+	return offchain.Image, nil
+}
+
+// parseMetadataAccount decodes the name, symbol and uri of a Metaplex metadata account.
+// Layout: key(1) | update_authority(32) | mint(32) | name | symbol | uri, where each
+// string is a little-endian u32 length followed by null-padded bytes.
+func parseMetadataAccount(data []byte) (*Metadata, error) {
+	offset := 1 + 32 + 32
+	readString := func() (string, error) {
+		if len(data) < offset+4 {
+			return "", fmt.Errorf("metadata account truncated")
+		}
+		n := int(binary.LittleEndian.Uint32(data[offset:]))
+		offset += 4
+		if n < 0 || len(data) < offset+n {
+			return "", fmt.Errorf("metadata account truncated")
+		}
+		s := strings.TrimRight(string(data[offset:offset+n]), "\x00")
+		offset += n
+		return s, nil
+	}
+
+	var md Metadata
+	var err error
+	if md.Name, err = readString(); err != nil {
+		return nil, err
+	}
+	if md.Symbol, err = readString(); err != nil {
+		return nil, err
+	}
+	if md.URI, err = readString(); err != nil {
+		return nil, err
+	}
+	return &md, nil
+}
+
+// SetMetadataResolver configures the resolver used by ListSupportedDetailed.
+func (s *Service) SetMetadataResolver(r *MetadataResolver) {
+	s.metadataMu.Lock()
+	defer s.metadataMu.Unlock()
+	s.metadata = r
+}
+
+func (s *Service) metadataResolver() *MetadataResolver {
+	s.metadataMu.Lock()
+	defer s.metadataMu.Unlock()
+	if s.metadata == nil {
+		s.metadata = NewMetadataResolver(solana.NewClient(solana.Config{
+			URL: os.Getenv("SOLANA_RPC_URL"),
+		}), 0)
+	}
+	return s.metadata
+}
+
+// ListSupportedDetailed retrieves supported tokens and enriches each with on-chain
+// Metaplex metadata. Metadata lookup failures are reported per token, not as an error.
+func (s *Service) ListSupportedDetailed(ctx context.Context) (*ListSupportedDetailedResponse, error) {
+	list, err := s.ListSupported(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resolver := s.metadataResolver()
+	resp := &ListSupportedDetailedResponse{Tokens: make([]DetailedToken, len(list.Tokens))}
+	for i, t := range list.Tokens {
+		resp.Tokens[i].Token = t
+		md, err := resolver.Resolve(ctx, t.Mint)
+		if err != nil {
+			resp.Tokens[i].MetadataError = err.Error()
+			continue
+		}
+		resp.Tokens[i].Metadata = md
+	}
+	return resp, nil
+}
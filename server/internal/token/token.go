@@ -3,11 +3,22 @@ package token
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"sync"
+
+	"sol_privacy/internal/jupiter"
 )
 
 // Service handles SPL token management operations.
 type Service struct {
 	doRequest func(ctx context.Context, method, path string, body, result interface{}) error
+
+	metadataMu sync.Mutex
+	metadata   *MetadataResolver
+
+	guardMu    sync.Mutex
+	guardrails map[string]Guardrail
+	jupiter    *jupiter.Client
 }
 
 // NewService creates a new token service.
@@ -86,7 +97,7 @@ func (s *Service) Add(ctx context.Context, req AddRequest) (*AddResponse, error)
 // Requires admin authentication via API key.
 func (s *Service) Update(ctx context.Context, mint string, req UpdateRequest) (*UpdateResponse, error) {
 	var resp UpdateResponse
-	path := fmt.Sprintf("/shadowpay/api/tokens/update/%s", mint)
+	path := fmt.Sprintf("/shadowpay/api/tokens/update/%s", url.PathEscape(mint))
 	if err := s.doRequest(ctx, "PATCH", path, req, &resp); err != nil {
 		return nil, err
 	}
@@ -97,7 +108,7 @@ func (s *Service) Update(ctx context.Context, mint string, req UpdateRequest) (*
 // Requires admin authentication via API key.
 func (s *Service) Remove(ctx context.Context, mint string) (*RemoveResponse, error) {
 	var resp RemoveResponse
-	path := fmt.Sprintf("/shadowpay/api/tokens/remove/%s", mint)
+	path := fmt.Sprintf("/shadowpay/api/tokens/remove/%s", url.PathEscape(mint))
 	if err := s.doRequest(ctx, "DELETE", path, nil, &resp); err != nil {
 		return nil, err
 	}
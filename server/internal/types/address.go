@@ -0,0 +1,177 @@
+package types
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"sol_privacy/internal/base58"
+	"sol_privacy/internal/validate"
+)
+
+// Redaction controls how Address and Commitment values print through String
+// and fmt, e.g. in logs. JSON always carries the full value.
+type Redaction int32
+
+// Redaction modes.
+const (
+	RedactNone  Redaction = iota // Print in full
+	RedactShort                  // Print the first and last four characters
+	RedactAll                    // Print "[redacted]"
+)
+
+var redaction atomic.Int32
+
+// SetRedaction sets how addresses and commitments print, process-wide.
+func SetRedaction(r Redaction) {
+	redaction.Store(int32(r))
+}
+
+func redact(s string) string {
+	switch Redaction(redaction.Load()) {
+	case RedactShort:
+		return short(s)
+	case RedactAll:
+		if s == "" {
+			return ""
+		}
+		return "[redacted]"
+	}
+	return s
+}
+
+func short(s string) string {
+	if len(s) <= 12 {
+		return s
+	}
+	return s[:4] + "..." + s[len(s)-4:]
+}
+
+// Address is a base58 encoded 32-byte Solana public key.
+type Address string
+
+// ParseAddress checks s and returns it as an Address.
+func ParseAddress(s string) (Address, error) {
+	if err := validate.Address(s); err != nil {
+		return "", fmt.Errorf("invalid address %q: %w", s, err)
+	}
+	return Address(s), nil
+}
+
+// MustAddress is ParseAddress for constants; it panics if s is invalid.
+func MustAddress(s string) Address {
+	a, err := ParseAddress(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// AddressFromBytes encodes a public key.
+func AddressFromBytes(b [32]byte) Address {
+	return Address(base58.Encode(b[:]))
+}
+
+// Validate reports whether the address is well formed, as a field error message.
+func (a Address) Validate() error {
+	return validate.Address(string(a))
+}
+
+// Bytes decodes the public key.
+func (a Address) Bytes() ([32]byte, error) {
+	var out [32]byte
+	if err := a.Validate(); err != nil {
+		return out, fmt.Errorf("invalid address %q: %w", string(a), err)
+	}
+	b, _ := base58.Decode(string(a))
+	copy(out[:], b)
+	return out, nil
+}
+
+// Short abbreviates the address for display, e.g. "7xKX...sAsU".
+func (a Address) Short() string {
+	return short(string(a))
+}
+
+// String returns the address, redacted as set by SetRedaction.
+func (a Address) String() string {
+	return redact(string(a))
+}
+
+// Commitment is a 32-byte ShadowID or receiver commitment, encoded as
+// 0x-prefixed hex or base58. The same commitment may arrive in either
+// encoding; compare with Equal.
+type Commitment string
+
+// ParseCommitment checks s and returns it as a Commitment.
+func ParseCommitment(s string) (Commitment, error) {
+	if err := validate.Commitment(s); err != nil {
+		return "", fmt.Errorf("invalid commitment %q: %w", s, err)
+	}
+	return Commitment(s), nil
+}
+
+// CommitmentFromBytes encodes a commitment as 0x-prefixed hex.
+func CommitmentFromBytes(b [32]byte) Commitment {
+	return Commitment("0x" + hex.EncodeToString(b[:]))
+}
+
+// Validate reports whether the commitment is well formed, as a field error message.
+func (c Commitment) Validate() error {
+	return validate.Commitment(string(c))
+}
+
+// Bytes decodes the commitment from either encoding. Hex shorter than 64
+// digits is a big-endian number and is left-padded.
+func (c Commitment) Bytes() ([32]byte, error) {
+	var out [32]byte
+	if err := c.Validate(); err != nil {
+		return out, fmt.Errorf("invalid commitment %q: %w", string(c), err)
+	}
+	s := string(c)
+	if h := strings.TrimPrefix(s, "0x"); h != s || len(h) == 64 {
+		if len(h)%2 == 1 {
+			h = "0" + h
+		}
+		b, _ := hex.DecodeString(h)
+		copy(out[32-len(b):], b)
+		return out, nil
+	}
+	b, _ := base58.Decode(s)
+	copy(out[:], b)
+	return out, nil
+}
+
+// Hex returns the commitment as 0x-prefixed hex, or the error of Bytes.
+func (c Commitment) Hex() (Commitment, error) {
+	b, err := c.Bytes()
+	if err != nil {
+		return "", err
+	}
+	return CommitmentFromBytes(b), nil
+}
+
+// Equal reports whether c and o are the same commitment, in any encoding.
+// Malformed commitments are equal only to the identical string.
+func (c Commitment) Equal(o Commitment) bool {
+	if c == o {
+		return true
+	}
+	a, err := c.Bytes()
+	if err != nil {
+		return false
+	}
+	b, err := o.Bytes()
+	return err == nil && a == b
+}
+
+// Short abbreviates the commitment for display.
+func (c Commitment) Short() string {
+	return short(string(c))
+}
+
+// String returns the commitment, redacted as set by SetRedaction.
+func (c Commitment) String() string {
+	return redact(string(c))
+}
@@ -0,0 +1,49 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Features the upstream API may support. Services check them before relying
+// on endpoints that not every deployment offers.
+const (
+	FeatureShadowIDBatch = "shadowid_batch" // Registering many commitments in one request
+	FeatureShadowIDSync  = "shadowid_sync"  // Streaming tree leaves to local replicas
+	FeatureSPLTokens     = "spl_tokens"     // Escrow and payments in SPL tokens
+	FeatureUmbra         = "umbra"          // Stealth addresses through Umbra
+)
+
+// Features whose endpoints the upstream API reference does not document.
+// Services Require them, so these calls fail before they reach a deployment
+// that has not advertised the endpoint.
+const (
+	FeatureHolds       = "holds"        // Settling with hold, then releasing or refunding
+	FeatureClaims      = "claims"       // Claiming part of an authorized amount
+	FeatureMultisig    = "multisig"     // M-of-N spending authorizations
+	FeatureNullifiers  = "nullifiers"   // Exporting spent nullifiers
+	FeaturePlatform    = "platform"     // Managing a platform's sub-merchants
+	FeatureReceiptNFTs = "receipt_nfts" // Minting receipts as NFTs
+)
+
+// DefaultX402Version is the x402 protocol version used until the API
+// advertises another.
+const DefaultX402Version = 1
+
+// Capabilities reports what the upstream API supports, so services can adapt
+// to it instead of failing, e.g. the SDK's capability discovery.
+type Capabilities interface {
+	Supports(ctx context.Context, feature string) bool
+	X402Version(ctx context.Context) int
+}
+
+// Require returns an error wrapping errors.ErrUnsupported unless caps reports
+// that the API supports feature. It fails closed: without caps, nothing is
+// supported.
+func Require(ctx context.Context, caps Capabilities, feature string) error {
+	if caps == nil || !caps.Supports(ctx, feature) {
+		return fmt.Errorf("the API does not advertise %s: %w", feature, errors.ErrUnsupported)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package types
+
+import "context"
+
+// DefaultPriorityFeePercentile is the percentile of recent fees used by auto mode.
+const DefaultPriorityFeePercentile = 75
+
+// ComputeBudget sets the compute unit price and limit of a transaction, i.e. its priority fee.
+// With Auto set, ComputeUnitPrice is filled from recent prioritization fees before the request is sent.
+type ComputeBudget struct {
+	ComputeUnitPrice uint64 `json:"compute_unit_price,omitempty"` // Micro-lamports per compute unit
+	ComputeUnitLimit uint32 `json:"compute_unit_limit,omitempty"`
+	Auto             bool   `json:"auto,omitempty"`
+	AutoPercentile   int    `json:"auto_percentile,omitempty"` // Defaults to DefaultPriorityFeePercentile
+}
+
+// FeeEstimator recommends a compute unit price, e.g. a Solana RPC client.
+type FeeEstimator interface {
+	RecommendedComputeUnitPrice(ctx context.Context, accounts []string, percentile int) (uint64, error)
+}
+
+// Resolve fills ComputeUnitPrice from the estimator when Auto is set.
+// accounts narrows the estimate to fees paid for write-locking those accounts.
+func (b *ComputeBudget) Resolve(ctx context.Context, est FeeEstimator, accounts ...string) error {
+	if b == nil || !b.Auto {
+		return nil
+	}
+	percentile := b.AutoPercentile
+	if percentile == 0 {
+		percentile = DefaultPriorityFeePercentile
+	}
+	price, err := est.RecommendedComputeUnitPrice(ctx, accounts, percentile)
+	if err != nil {
+		return err
+	}
+	b.ComputeUnitPrice = price
+	return nil
+}
@@ -1,8 +1,83 @@
 package types
 
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ErrNotRebuildable is returned by Rebuild when the transaction was not produced by a service call.
+var ErrNotRebuildable = errors.New("transaction cannot be rebuilt")
+
+// BlockHeightSource reports the current block height, e.g. a Solana RPC client.
+type BlockHeightSource interface {
+	GetBlockHeight(ctx context.Context, commitment string) (uint64, error)
+}
+
+// BlockhashExpired reports whether a transaction built with lastValidBlockHeight can no
+// longer land because the cluster's block height has moved past it.
+func BlockhashExpired(ctx context.Context, src BlockHeightSource, lastValidBlockHeight int64) (bool, error) {
+	height, err := src.GetBlockHeight(ctx, "confirmed")
+	if err != nil {
+		return false, err
+	}
+	return int64(height) > lastValidBlockHeight, nil
+}
+
+// IsBlockhashExpiredError reports whether a submission error means the transaction's
+// blockhash aged out and it must be rebuilt before resubmitting.
+func IsBlockhashExpiredError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "blockhash not found") ||
+		strings.Contains(msg, "block height exceeded") ||
+		strings.Contains(msg, "transactionexpiredblockheightexceeded")
+}
+
 // UnsignedTxResponse contains the base64 encoded transaction to be signed by the user.
 type UnsignedTxResponse struct {
 	UnsignedTxBase64     string `json:"unsigned_tx_base64"`
 	RecentBlockhash      string `json:"recent_blockhash"`
 	LastValidBlockHeight int64  `json:"last_valid_block_height"`
+
+	rebuild func(ctx context.Context) (*UnsignedTxResponse, error)
+}
+
+// WithRebuild records how to re-request this transaction with the same parameters.
+// Services call it before returning the response.
+func (t *UnsignedTxResponse) WithRebuild(fn func(ctx context.Context) (*UnsignedTxResponse, error)) *UnsignedTxResponse {
+	t.rebuild = fn
+	return t
+}
+
+// Expired reports whether the transaction's blockhash has aged out.
+func (t *UnsignedTxResponse) Expired(ctx context.Context, src BlockHeightSource) (bool, error) {
+	return BlockhashExpired(ctx, src, t.LastValidBlockHeight)
+}
+
+// Rebuild re-requests a fresh transaction with the same parameters.
+func (t *UnsignedTxResponse) Rebuild(ctx context.Context) (*UnsignedTxResponse, error) {
+	if t.rebuild == nil {
+		return nil, ErrNotRebuildable
+	}
+	return t.rebuild(ctx)
+}
+
+// RefreshIfExpired returns the transaction unchanged if its blockhash is still valid,
+// otherwise a rebuilt one. The bool reports whether a rebuild happened.
+func (t *UnsignedTxResponse) RefreshIfExpired(ctx context.Context, src BlockHeightSource) (*UnsignedTxResponse, bool, error) {
+	expired, err := t.Expired(ctx, src)
+	if err != nil {
+		return nil, false, err
+	}
+	if !expired {
+		return t, false, nil
+	}
+	fresh, err := t.Rebuild(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	return fresh, true, nil
 }
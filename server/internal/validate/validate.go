@@ -0,0 +1,221 @@
+// Package validate checks user-supplied request fields before they reach the
+// ShadowPay API, reporting problems per field instead of as opaque upstream errors.
+package validate
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"sol_privacy/internal/base58"
+)
+
+// Limits shared by request validators.
+const (
+	MaxLamports    = 600_000_000 * 1_000_000_000 // Above the total SOL supply
+	MaxFieldLength = 1024
+
+	MaxMetadataKeys        = 50
+	MaxMetadataKeyLength   = 40
+	MaxMetadataValueLength = 500
+)
+
+// FieldError describes an invalid request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// Errors is a list of field errors. It is returned as an error by Validator.Err.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.Error()
+	}
+	return "invalid request: " + strings.Join(parts, "; ")
+}
+
+// Validator accumulates field errors.
+type Validator struct {
+	errs Errors
+}
+
+// New returns an empty Validator.
+func New() *Validator {
+	return &Validator{}
+}
+
+// Err returns the accumulated errors, or nil if every field was valid.
+func (v *Validator) Err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+// Add records an error for field if err is non-nil.
+func (v *Validator) Add(field string, err error) *Validator {
+	if err != nil {
+		v.errs = append(v.errs, FieldError{Field: field, Message: err.Error()})
+	}
+	return v
+}
+
+// Required checks that a string field is set.
+func (v *Validator) Required(field, value string) *Validator {
+	if strings.TrimSpace(value) == "" {
+		return v.Add(field, fmt.Errorf("is required"))
+	}
+	if len(value) > MaxFieldLength {
+		return v.Add(field, fmt.Errorf("must be at most %d characters", MaxFieldLength))
+	}
+	return v
+}
+
+// Address checks that a field holds a Solana address.
+func (v *Validator) Address(field, value string) *Validator {
+	return v.Add(field, Address(value))
+}
+
+// OptionalAddress checks a Solana address field if it is set.
+func (v *Validator) OptionalAddress(field, value string) *Validator {
+	if value == "" {
+		return v
+	}
+	return v.Address(field, value)
+}
+
+// Commitment checks that a field holds a 32-byte commitment in hex or base58.
+func (v *Validator) Commitment(field, value string) *Validator {
+	return v.Add(field, Commitment(value))
+}
+
+// Amount checks that min <= value <= max.
+func (v *Validator) Amount(field string, value, min, max int64) *Validator {
+	return v.Add(field, Amount(value, min, max))
+}
+
+// URL checks that a field holds an absolute http(s) URL, optionally requiring https.
+func (v *Validator) URL(field, value string, requireHTTPS bool) *Validator {
+	return v.Add(field, URL(value, requireHTTPS))
+}
+
+// Metadata checks a map of custom key/value fields against the metadata limits.
+func (v *Validator) Metadata(field string, m map[string]string) *Validator {
+	if len(m) > MaxMetadataKeys {
+		return v.Add(field, fmt.Errorf("must have at most %d keys", MaxMetadataKeys))
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v.Add(field+"."+k, MetadataEntry(k, m[k]))
+	}
+	return v
+}
+
+// Address checks that s is a base58 encoded 32-byte public key.
+func Address(s string) error {
+	if s == "" {
+		return fmt.Errorf("is required")
+	}
+	if len(s) < 32 || len(s) > 44 {
+		return fmt.Errorf("must be 32-44 base58 characters")
+	}
+	b, err := base58.Decode(s)
+	if err != nil {
+		return fmt.Errorf("is not valid base58")
+	}
+	if len(b) != 32 {
+		return fmt.Errorf("must decode to 32 bytes, got %d", len(b))
+	}
+	return nil
+}
+
+// Commitment checks that s is a 32-byte value encoded as (0x-prefixed) hex or base58.
+func Commitment(s string) error {
+	if s == "" {
+		return fmt.Errorf("is required")
+	}
+	if h := strings.TrimPrefix(s, "0x"); h != s || len(h) == 64 {
+		if len(h) == 0 || len(h) > 64 {
+			return fmt.Errorf("hex commitment must be 1-64 hex digits")
+		}
+		if len(h)%2 == 1 {
+			h = "0" + h
+		}
+		if _, err := hex.DecodeString(h); err != nil {
+			return fmt.Errorf("is not valid hex")
+		}
+		return nil
+	}
+	b, err := base58.Decode(s)
+	if err != nil || len(b) != 32 {
+		return fmt.Errorf("must be 32 bytes encoded as hex or base58")
+	}
+	return nil
+}
+
+// MetadataEntry checks a metadata key and value. Keys are 1-40 letters,
+// digits, '_', '-' or '.'; values are at most 500 characters.
+func MetadataEntry(key, value string) error {
+	if key == "" || len(key) > MaxMetadataKeyLength {
+		return fmt.Errorf("key must be 1-%d characters", MaxMetadataKeyLength)
+	}
+	for _, c := range key {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' || c == '.') {
+			return fmt.Errorf("key may only contain letters, digits, '_', '-' and '.'")
+		}
+	}
+	if len(value) > MaxMetadataValueLength {
+		return fmt.Errorf("must be at most %d characters", MaxMetadataValueLength)
+	}
+	return nil
+}
+
+// Amount checks that min <= value <= max.
+func Amount(value, min, max int64) error {
+	if value < min {
+		return fmt.Errorf("must be at least %d", min)
+	}
+	if value > max {
+		return fmt.Errorf("must be at most %d", max)
+	}
+	return nil
+}
+
+// URL checks that s is an absolute http(s) URL with a host.
+func URL(s string, requireHTTPS bool) error {
+	if s == "" {
+		return fmt.Errorf("is required")
+	}
+	if len(s) > MaxFieldLength {
+		return fmt.Errorf("must be at most %d characters", MaxFieldLength)
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("must be an absolute URL")
+	}
+	switch {
+	case u.Scheme == "https":
+	case u.Scheme == "http" && !requireHTTPS:
+	case requireHTTPS:
+		return fmt.Errorf("must use https")
+	default:
+		return fmt.Errorf("must use http or https")
+	}
+	if u.User != nil {
+		return fmt.Errorf("must not contain credentials")
+	}
+	return nil
+}
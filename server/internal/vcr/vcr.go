@@ -0,0 +1,349 @@
+// Package vcr records HTTP interactions with the ShadowPay and Umbra APIs to
+// sanitized fixture files and replays them, so integrations can run deterministically.
+//
+// Plug a Recorder into either client through its HTTP client option:
+//
+//	rec, _ := vcr.New("testdata/fixtures/escrow.json", vcr.ModeReplay)
+//	sdk := shadowpay.New(apiKey, client.WithHTTPClient(rec.Client()))
+//	defer rec.Stop()
+package vcr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Mode controls whether a Recorder talks to the network.
+type Mode int
+
+const (
+	// ModeReplay serves responses from the fixture and fails on unknown requests.
+	ModeReplay Mode = iota
+	// ModeRecord sends requests upstream and records the interactions.
+	ModeRecord
+	// ModeReplayOrRecord replays known interactions and records new ones.
+	ModeReplayOrRecord
+)
+
+// ParseMode parses "replay", "record" or "replay_or_record". Empty selects ModeReplay,
+// so CI never reaches the network unless a mode is set explicitly, e.g. via VCR_MODE.
+func ParseMode(s string) (Mode, error) {
+	switch strings.ToLower(s) {
+	case "", "replay":
+		return ModeReplay, nil
+	case "record":
+		return ModeRecord, nil
+	case "replay_or_record":
+		return ModeReplayOrRecord, nil
+	}
+	return ModeReplay, fmt.Errorf("unknown vcr mode %q", s)
+}
+
+// Redacted replaces secret values in recorded fixtures.
+const Redacted = "[REDACTED]"
+
+// ErrInteractionNotFound is returned in replay mode for requests missing from the fixture.
+var ErrInteractionNotFound = errors.New("vcr: no recorded interaction for request")
+
+// DefaultRedactHeaders are headers whose values are never written to fixtures.
+var DefaultRedactHeaders = []string{"X-API-Key", "Authorization", "Cookie", "Set-Cookie"}
+
+// DefaultRedactFields are JSON body fields whose values are never written to fixtures.
+var DefaultRedactFields = []string{
+	"api_key", "apiKey", "private_key", "privateKey", "secret_key", "secretKey",
+	"secret", "seed", "mnemonic", "access_token", "token",
+}
+
+// Interaction is a single recorded request/response pair.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the sanitized form of a request.
+type RecordedRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is the sanitized form of a response.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Cassette is the on-disk fixture format.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder is an http.RoundTripper that records or replays interactions.
+type Recorder struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+
+	redactHeaders map[string]bool
+	redactFields  map[string]bool
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+	dirty    bool
+}
+
+// Option configures a Recorder.
+type Option func(*Recorder)
+
+// WithTransport sets the transport used to reach the network when recording.
+func WithTransport(t http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.transport = t
+	}
+}
+
+// WithRedactedHeaders adds headers to redact.
+func WithRedactedHeaders(headers ...string) Option {
+	return func(r *Recorder) {
+		for _, h := range headers {
+			r.redactHeaders[http.CanonicalHeaderKey(h)] = true
+		}
+	}
+}
+
+// WithRedactedFields adds JSON body fields to redact.
+func WithRedactedFields(fields ...string) Option {
+	return func(r *Recorder) {
+		for _, f := range fields {
+			r.redactFields[strings.ToLower(f)] = true
+		}
+	}
+}
+
+// New creates a Recorder backed by the fixture at path. The fixture must exist in ModeReplay.
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:          path,
+		mode:          mode,
+		transport:     http.DefaultTransport,
+		redactHeaders: make(map[string]bool),
+		redactFields:  make(map[string]bool),
+	}
+	WithRedactedHeaders(DefaultRedactHeaders...)(r)
+	WithRedactedFields(DefaultRedactFields...)(r)
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+		}
+	case errors.Is(err, os.ErrNotExist) && mode != ModeReplay:
+	default:
+		return nil, fmt.Errorf("failed to read fixture %s: %w", path, err)
+	}
+	if mode == ModeRecord {
+		r.cassette.Interactions = nil
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+
+	return r, nil
+}
+
+// Client returns an http.Client using the Recorder as its transport.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("vcr: failed to read request body: %w", err)
+	}
+	recorded := r.sanitizeRequest(req, reqBody)
+
+	if r.mode != ModeRecord {
+		if resp, ok := r.replay(req, recorded); ok {
+			return resp, nil
+		}
+		if r.mode == ModeReplay {
+			return nil, fmt.Errorf("%w: %s %s", ErrInteractionNotFound, recorded.Method, recorded.URL)
+		}
+	}
+
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("vcr: failed to read response body: %w", err)
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: recorded,
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Headers:    r.sanitizeHeaders(resp.Header),
+			Body:       r.sanitizeBody(respBody),
+		},
+	})
+	r.used = append(r.used, true)
+	r.dirty = true
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+// Stop writes newly recorded interactions to the fixture.
+func (r *Recorder) Stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.dirty {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode fixture: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+	if err := os.WriteFile(r.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write fixture %s: %w", r.path, err)
+	}
+	r.dirty = false
+	return nil
+}
+
+// replay returns the first unused interaction matching the request.
+// Identical requests are served in recording order.
+func (r *Recorder) replay(req *http.Request, recorded RecordedRequest) (*http.Response, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, in := range r.cassette.Interactions {
+		if r.used[i] || !matches(in.Request, recorded) {
+			continue
+		}
+		r.used[i] = true
+		header := in.Response.Headers.Clone()
+		if header == nil {
+			header = make(http.Header)
+		}
+		// Redaction can change the body length
+		header.Del("Content-Length")
+		return &http.Response{
+			StatusCode:    in.Response.StatusCode,
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			Header:        header,
+			Body:          io.NopCloser(strings.NewReader(in.Response.Body)),
+			ContentLength: int64(len(in.Response.Body)),
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Request:       req,
+		}, true
+	}
+	return nil, false
+}
+
+func matches(a, b RecordedRequest) bool {
+	return a.Method == b.Method && a.URL == b.URL && a.Body == b.Body
+}
+
+func (r *Recorder) sanitizeRequest(req *http.Request, body []byte) RecordedRequest {
+	u := *req.URL
+	q := u.Query()
+	for key := range q {
+		if r.redactFields[strings.ToLower(key)] {
+			q.Set(key, Redacted)
+		}
+	}
+	u.RawQuery = q.Encode()
+
+	return RecordedRequest{
+		Method:  req.Method,
+		URL:     u.String(),
+		Headers: r.sanitizeHeaders(req.Header),
+		Body:    r.sanitizeBody(body),
+	}
+}
+
+func (r *Recorder) sanitizeHeaders(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	out := h.Clone()
+	for key := range out {
+		if r.redactHeaders[http.CanonicalHeaderKey(key)] {
+			out[key] = []string{Redacted}
+		}
+	}
+	return out
+}
+
+// sanitizeBody redacts secret fields from JSON bodies. Non-JSON bodies are kept as-is.
+func (r *Recorder) sanitizeBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return string(body)
+	}
+	data, err := json.Marshal(r.redactValue(v))
+	if err != nil {
+		return string(body)
+	}
+	return string(data)
+}
+
+func (r *Recorder) redactValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, inner := range val {
+			if r.redactFields[strings.ToLower(k)] {
+				val[k] = Redacted
+				continue
+			}
+			val[k] = r.redactValue(inner)
+		}
+	case []interface{}:
+		for i, inner := range val {
+			val[i] = r.redactValue(inner)
+		}
+	}
+	return v
+}
+
+// readBody drains a body and replaces it with a re-readable copy.
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
@@ -2,11 +2,14 @@ package verify
 
 import (
 	"context"
+
+	"sol_privacy/internal/types"
 )
 
 // Service handles X402 verification operations.
 type Service struct {
 	doRequest func(ctx context.Context, method, path string, body, result interface{}) error
+	caps      types.Capabilities
 }
 
 // NewService creates a new verify service.
@@ -16,6 +19,19 @@ func NewService(doRequest func(ctx context.Context, method, path string, body, r
 	}
 }
 
+// SetCapabilities makes Verify and Settle send the x402 version the API
+// speaks when a request leaves it zero. Call it before using the service.
+func (s *Service) SetCapabilities(c types.Capabilities) {
+	s.caps = c
+}
+
+func (s *Service) x402Version(ctx context.Context, version int) int {
+	if version == 0 && s.caps != nil {
+		return s.caps.X402Version(ctx)
+	}
+	return version
+}
+
 // Request represents a request to verify an X402 token.
 type Request struct {
 	Token string `json:"token"`
@@ -113,6 +129,7 @@ func (s *Service) GetSupported(ctx context.Context) (*SupportedResponse, error)
 // Verify validates a zero-knowledge proof payment per x402 standard.
 // Returns a payment token that can be used for settlement.
 func (s *Service) Verify(ctx context.Context, req VerifyRequest) (*VerifyResponse, error) {
+	req.X402Version = s.x402Version(ctx, req.X402Version)
 	var resp VerifyResponse
 	if err := s.doRequest(ctx, "POST", "/shadowpay/verify", req, &resp); err != nil {
 		return nil, err
@@ -123,6 +140,7 @@ func (s *Service) Verify(ctx context.Context, req VerifyRequest) (*VerifyRespons
 // Settle executes on-chain payment settlement per x402 protocol.
 // Can be used in both manual and automated (relayer) modes.
 func (s *Service) Settle(ctx context.Context, req SettleRequest) (*SettleResponse, error) {
+	req.X402Version = s.x402Version(ctx, req.X402Version)
 	var resp SettleResponse
 	if err := s.doRequest(ctx, "POST", "/shadowpay/settle", req, &resp); err != nil {
 		return nil, err
@@ -0,0 +1,108 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// SignTransaction signs a base64 encoded Solana transaction, such as the
+// unsigned transactions returned by the SDK, and returns it re-encoded with
+// the signature in the signer's slot. Legacy and versioned (v0) messages are
+// supported; other signatures already present are kept.
+func SignTransaction(signer Signer, txBase64 string) (string, error) {
+	tx, err := base64.StdEncoding.DecodeString(txBase64)
+	if err != nil {
+		return "", fmt.Errorf("invalid transaction encoding: %w", err)
+	}
+	numSigs, n, err := decodeShortVec(tx)
+	if err != nil {
+		return "", err
+	}
+	sigsStart := n
+	msgStart := sigsStart + numSigs*ed25519.SignatureSize
+	if msgStart > len(tx) {
+		return "", errors.New("invalid transaction: truncated signatures")
+	}
+	message := tx[msgStart:]
+
+	keys, required, err := messageSigners(message)
+	if err != nil {
+		return "", err
+	}
+	if required != numSigs {
+		return "", fmt.Errorf("invalid transaction: %d signatures for %d required signers", numSigs, required)
+	}
+	pub, err := PublicKey(signer.Address())
+	if err != nil {
+		return "", err
+	}
+	index := -1
+	for i, key := range keys[:required] {
+		if bytes.Equal(key, pub) {
+			index = i
+		}
+	}
+	if index < 0 {
+		return "", fmt.Errorf("%s is not a signer of this transaction", signer.Address())
+	}
+
+	sig, err := signer.SignMessage(message)
+	if err != nil {
+		return "", err
+	}
+	if len(sig) != ed25519.SignatureSize {
+		return "", fmt.Errorf("signer returned a %d-byte signature", len(sig))
+	}
+	signed := bytes.Clone(tx)
+	copy(signed[sigsStart+index*ed25519.SignatureSize:], sig)
+	return base64.StdEncoding.EncodeToString(signed), nil
+}
+
+// messageSigners returns the static account keys of a message and how many of
+// them are required signers.
+func messageSigners(message []byte) ([][]byte, int, error) {
+	m := message
+	if len(m) > 0 && m[0]&0x80 != 0 {
+		if version := m[0] & 0x7f; version != 0 {
+			return nil, 0, fmt.Errorf("unsupported transaction version %d", version)
+		}
+		m = m[1:]
+	}
+	if len(m) < 3 {
+		return nil, 0, errors.New("invalid transaction: truncated message header")
+	}
+	required := int(m[0])
+	m = m[3:]
+	numKeys, n, err := decodeShortVec(m)
+	if err != nil {
+		return nil, 0, err
+	}
+	m = m[n:]
+	if len(m) < numKeys*ed25519.PublicKeySize || numKeys < required {
+		return nil, 0, errors.New("invalid transaction: truncated account keys")
+	}
+	keys := make([][]byte, numKeys)
+	for i := range keys {
+		keys[i] = m[i*ed25519.PublicKeySize : (i+1)*ed25519.PublicKeySize]
+	}
+	return keys, required, nil
+}
+
+// decodeShortVec reads a compact-u16 length prefix, returning the value and
+// the number of bytes it used.
+func decodeShortVec(b []byte) (int, int, error) {
+	var v int
+	for i := 0; i < 3; i++ {
+		if i >= len(b) {
+			return 0, 0, errors.New("invalid transaction: truncated length")
+		}
+		v |= int(b[i]&0x7f) << (7 * i)
+		if b[i]&0x80 == 0 {
+			return v, i + 1, nil
+		}
+	}
+	return 0, 0, errors.New("invalid transaction: length too long")
+}
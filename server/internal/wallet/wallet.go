@@ -0,0 +1,98 @@
+// Package wallet provides the signer abstraction used wherever the SDK needs a
+// user's wallet to sign a message, plus a local Ed25519 keypair implementation.
+package wallet
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+
+	"sol_privacy/internal/base58"
+)
+
+// Signer signs arbitrary messages with a Solana wallet key.
+// Implementations may wrap a local keypair, a hardware wallet or a remote signer.
+type Signer interface {
+	// Address returns the base58 encoded public key of the wallet.
+	Address() string
+	// SignMessage returns the raw 64-byte Ed25519 signature of message.
+	SignMessage(message []byte) ([]byte, error)
+}
+
+// VerifySignature checks a base58 encoded Ed25519 signature of message by the wallet address.
+func VerifySignature(address string, message []byte, signature string) error {
+	pub, err := PublicKey(address)
+	if err != nil {
+		return err
+	}
+	sig, err := base58.Decode(signature)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("invalid signature encoding")
+	}
+	if !ed25519.Verify(pub, message, sig) {
+		return fmt.Errorf("signature verification failed for %s", address)
+	}
+	return nil
+}
+
+// PublicKey decodes a base58 wallet address into an Ed25519 public key.
+func PublicKey(address string) (ed25519.PublicKey, error) {
+	key, err := base58.Decode(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid wallet address: %w", err)
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid wallet address: expected %d bytes, got %d", ed25519.PublicKeySize, len(key))
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// Keypair is a local Ed25519 wallet key.
+type Keypair struct {
+	private ed25519.PrivateKey
+}
+
+// Generate creates a new random keypair.
+func Generate() (*Keypair, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate keypair: %w", err)
+	}
+	return &Keypair{private: priv}, nil
+}
+
+// FromSecretKey loads a keypair from a base58 encoded 64-byte Solana secret key
+// (the format exported by Phantom and solana-keygen).
+func FromSecretKey(secret string) (*Keypair, error) {
+	b, err := base58.Decode(secret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret key: %w", err)
+	}
+	if len(b) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid secret key: expected %d bytes, got %d", ed25519.PrivateKeySize, len(b))
+	}
+	return &Keypair{private: ed25519.PrivateKey(b)}, nil
+}
+
+// FromSeed derives a keypair from a 32-byte Ed25519 seed.
+func FromSeed(seed []byte) (*Keypair, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid seed: expected %d bytes, got %d", ed25519.SeedSize, len(seed))
+	}
+	return &Keypair{private: ed25519.NewKeyFromSeed(seed)}, nil
+}
+
+// Address returns the base58 encoded public key.
+func (k *Keypair) Address() string {
+	return base58.Encode(k.private.Public().(ed25519.PublicKey))
+}
+
+// SignMessage signs message with the private key.
+func (k *Keypair) SignMessage(message []byte) ([]byte, error) {
+	return ed25519.Sign(k.private, message), nil
+}
+
+// SecretKey returns the base58 encoded 64-byte secret key. Store it securely.
+func (k *Keypair) SecretKey() string {
+	return base58.Encode(k.private)
+}
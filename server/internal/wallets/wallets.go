@@ -0,0 +1,272 @@
+// Package wallets manages a set of named wallets (addresses with optional
+// keypairs) and a default selection, so callers can refer to a wallet by name
+// or fall back to the default instead of passing base58 addresses around.
+package wallets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"sol_privacy/internal/jsonfile"
+	"sol_privacy/internal/validate"
+	"sol_privacy/internal/wallet"
+)
+
+// MaxNameLength limits wallet names.
+const MaxNameLength = 32
+
+var (
+	// ErrWalletNotFound is returned for unknown wallet names.
+	ErrWalletNotFound = errors.New("wallet not found")
+	// ErrWalletExists is returned when adding a wallet under a name already in use.
+	ErrWalletExists = errors.New("wallet name already in use")
+	// ErrNoDefault is returned when no wallet is given and none is selected as default.
+	ErrNoDefault = errors.New("no default wallet selected")
+	// ErrNoKeypair is returned when signing with a watch-only wallet.
+	ErrNoKeypair = errors.New("wallet has no keypair")
+)
+
+// Wallet is a named wallet. Wallets without a secret key are watch-only.
+type Wallet struct {
+	Name      string `json:"name"`
+	Address   string `json:"address"`
+	SecretKey string `json:"secret_key,omitempty"` // Base58 64-byte Solana secret key
+	CreatedAt int64  `json:"created_at"`
+}
+
+// HasKeypair reports whether the wallet can sign.
+func (w *Wallet) HasKeypair() bool {
+	return w.SecretKey != ""
+}
+
+// Keypair loads the wallet's keypair.
+func (w *Wallet) Keypair() (*wallet.Keypair, error) {
+	if !w.HasKeypair() {
+		return nil, ErrNoKeypair
+	}
+	return wallet.FromSecretKey(w.SecretKey)
+}
+
+// AddRequest adds a wallet. Address may be omitted when SecretKey is set.
+type AddRequest struct {
+	Name      string `json:"name"`
+	Address   string `json:"address,omitempty"`
+	SecretKey string `json:"secret_key,omitempty"`
+}
+
+// file is the on-disk format.
+type file struct {
+	Default string   `json:"default,omitempty"`
+	Wallets []Wallet `json:"wallets"`
+}
+
+// Manager holds named wallets, optionally persisted to a JSON file.
+type Manager struct {
+	mu      sync.RWMutex
+	path    string
+	def     string
+	wallets map[string]Wallet
+}
+
+// DefaultPath returns $SHADOWPAY_WALLETS_FILE, or ~/.shadowpay/wallets.json.
+func DefaultPath() string {
+	if path := os.Getenv("SHADOWPAY_WALLETS_FILE"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".shadowpay", "wallets.json")
+}
+
+// Open loads the wallets stored at path. An empty path keeps wallets in memory.
+// The file holds secret keys and is written readable only by the owner.
+func Open(path string) (*Manager, error) {
+	m := &Manager{path: path, wallets: make(map[string]Wallet)}
+	if path == "" {
+		return m, nil
+	}
+	var f file
+	if err := jsonfile.Load(path, &f); err != nil {
+		return nil, err
+	}
+	for _, w := range f.Wallets {
+		m.wallets[w.Name] = w
+	}
+	if _, ok := m.wallets[f.Default]; ok {
+		m.def = f.Default
+	}
+	return m, nil
+}
+
+// Add stores a new wallet. The first wallet added becomes the default.
+func (m *Manager) Add(req AddRequest) (*Wallet, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	v := validate.New().Required("name", req.Name)
+	if len(req.Name) > MaxNameLength {
+		v.Add("name", fmt.Errorf("must be at most %d characters", MaxNameLength))
+	}
+	if validate.Address(req.Name) == nil {
+		v.Add("name", fmt.Errorf("must not be a wallet address"))
+	}
+	if req.SecretKey != "" {
+		kp, err := wallet.FromSecretKey(req.SecretKey)
+		switch {
+		case err != nil:
+			v.Add("secret_key", err)
+		case req.Address == "":
+			req.Address = kp.Address()
+		case req.Address != kp.Address():
+			v.Add("secret_key", fmt.Errorf("does not match address"))
+		}
+	}
+	v.Address("address", req.Address)
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.wallets[req.Name]; ok {
+		return nil, ErrWalletExists
+	}
+	w := Wallet{
+		Name:      req.Name,
+		Address:   req.Address,
+		SecretKey: req.SecretKey,
+		CreatedAt: time.Now().Unix(),
+	}
+	prevDef := m.def
+	m.wallets[w.Name] = w
+	if m.def == "" {
+		m.def = w.Name
+	}
+	if err := m.save(); err != nil {
+		delete(m.wallets, w.Name)
+		m.def = prevDef
+		return nil, err
+	}
+	return &w, nil
+}
+
+// Remove deletes a wallet. Removing the default leaves no default selected.
+func (m *Manager) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.wallets[name]; !ok {
+		return ErrWalletNotFound
+	}
+	delete(m.wallets, name)
+	if m.def == name {
+		m.def = ""
+	}
+	return m.save()
+}
+
+// Get returns a wallet by name.
+func (m *Manager) Get(name string) (*Wallet, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	w, ok := m.wallets[name]
+	if !ok {
+		return nil, ErrWalletNotFound
+	}
+	return &w, nil
+}
+
+// List returns all wallets ordered by name.
+func (m *Manager) List() []*Wallet {
+	m.mu.RLock()
+	out := make([]*Wallet, 0, len(m.wallets))
+	for _, w := range m.wallets {
+		w := w
+		out = append(out, &w)
+	}
+	m.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// SetDefault selects the wallet used when none is given.
+func (m *Manager) SetDefault(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.wallets[name]; !ok {
+		return ErrWalletNotFound
+	}
+	m.def = name
+	return m.save()
+}
+
+// Default returns the default wallet.
+func (m *Manager) Default() (*Wallet, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.def == "" {
+		return nil, ErrNoDefault
+	}
+	w := m.wallets[m.def]
+	return &w, nil
+}
+
+// Resolve returns the address to use for a call: the default wallet's if s is
+// empty, the named wallet's if s is a wallet name, or s itself if it is an address.
+func (m *Manager) Resolve(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		w, err := m.Default()
+		if err != nil {
+			return "", err
+		}
+		return w.Address, nil
+	}
+	if w, err := m.Get(s); err == nil {
+		return w.Address, nil
+	}
+	if validate.Address(s) == nil {
+		return s, nil
+	}
+	return "", fmt.Errorf("%q: %w", s, ErrWalletNotFound)
+}
+
+// Signer returns a signer for the named wallet, or the default one if name is empty.
+func (m *Manager) Signer(name string) (wallet.Signer, error) {
+	var w *Wallet
+	var err error
+	if name == "" {
+		w, err = m.Default()
+	} else {
+		w, err = m.Get(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	kp, err := w.Keypair()
+	if err != nil {
+		return nil, err
+	}
+	return kp, nil
+}
+
+// save writes the wallets to disk. Callers hold m.mu.
+func (m *Manager) save() error {
+	if m.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(m.path), err)
+	}
+	f := file{Default: m.def, Wallets: make([]Wallet, 0, len(m.wallets))}
+	for _, w := range m.wallets {
+		f.Wallets = append(f.Wallets, w)
+	}
+	sort.Slice(f.Wallets, func(i, j int) bool { return f.Wallets[i].Name < f.Wallets[j].Name })
+	return jsonfile.Save(m.path, f)
+}
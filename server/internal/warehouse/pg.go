@@ -0,0 +1,457 @@
+package warehouse
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Postgres protocol 3.0 and the SSLRequest code
+const (
+	pgProtocol   = 196608
+	pgSSLRequest = 80877103
+)
+
+// PostgresError is an ErrorResponse from the server.
+type PostgresError struct {
+	Severity string
+	Code     string // SQLSTATE, e.g. "42P01"
+	Message  string
+}
+
+func (e *PostgresError) Error() string {
+	return fmt.Sprintf("warehouse: postgres %s: %s (SQLSTATE %s)", e.Severity, e.Message, e.Code)
+}
+
+// statement is one SQL statement with its $n parameters.
+type statement struct {
+	sql  string
+	args []interface{}
+}
+
+// pgConn is a minimal client for the PostgreSQL frontend/backend protocol:
+// password, MD5 and SCRAM-SHA-256 authentication, optional TLS, and
+// parameterized statements sent as a pipeline.
+type pgConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	buf  []byte
+}
+
+// dialPostgres connects to a postgres:// URL. sslmode is disable, prefer
+// (the default), require or verify-full.
+func dialPostgres(ctx context.Context, dsn string, timeout time.Duration) (*pgConn, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("warehouse: %w", err)
+	}
+	if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+		return nil, fmt.Errorf("warehouse: %q: expected a postgres:// URL", u.Redacted())
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "5432")
+	}
+	user := u.User.Username()
+	pass, _ := u.User.Password()
+	database := strings.TrimPrefix(u.Path, "/")
+	if database == "" {
+		database = user
+	}
+	sslmode := u.Query().Get("sslmode")
+	if sslmode == "" {
+		sslmode = "prefer"
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	nc, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, fmt.Errorf("warehouse: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		nc.SetDeadline(deadline)
+	} else {
+		nc.SetDeadline(time.Now().Add(timeout))
+	}
+	c := &pgConn{conn: nc, r: bufio.NewReader(nc)}
+	if err := c.startTLS(ctx, sslmode, u.Hostname()); err != nil {
+		nc.Close()
+		return nil, err
+	}
+	if err := c.startup(user, pass, database); err != nil {
+		c.conn.Close()
+		return nil, err
+	}
+	c.conn.SetDeadline(time.Time{})
+	return c, nil
+}
+
+func (c *pgConn) startTLS(ctx context.Context, sslmode, serverName string) error {
+	var config *tls.Config
+	switch sslmode {
+	case "disable":
+		return nil
+	case "prefer", "require":
+		// As libpq, these encrypt without verifying the server
+		config = &tls.Config{InsecureSkipVerify: true}
+	case "verify-full":
+		config = &tls.Config{ServerName: serverName, MinVersion: tls.VersionTLS12}
+	default:
+		return fmt.Errorf("warehouse: unsupported sslmode %q", sslmode)
+	}
+
+	var req [8]byte
+	binary.BigEndian.PutUint32(req[0:], 8)
+	binary.BigEndian.PutUint32(req[4:], pgSSLRequest)
+	if _, err := c.conn.Write(req[:]); err != nil {
+		return fmt.Errorf("warehouse: %w", err)
+	}
+	answer, err := c.r.ReadByte()
+	if err != nil {
+		return fmt.Errorf("warehouse: %w", err)
+	}
+	if answer != 'S' {
+		if sslmode == "prefer" {
+			return nil
+		}
+		return errors.New("warehouse: server does not support TLS")
+	}
+	tlsConn := tls.Client(c.conn, config)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return fmt.Errorf("warehouse: %w", err)
+	}
+	c.conn, c.r = tlsConn, bufio.NewReader(tlsConn)
+	return nil
+}
+
+func (c *pgConn) startup(user, pass, database string) error {
+	msg := binary.BigEndian.AppendUint32(nil, pgProtocol)
+	for _, kv := range [][2]string{{"user", user}, {"database", database}, {"application_name", "shadowpay-proxy"}} {
+		msg = append(append(msg, kv[0]...), 0)
+		msg = append(append(msg, kv[1]...), 0)
+	}
+	msg = append(msg, 0)
+	if _, err := c.conn.Write(append(binary.BigEndian.AppendUint32(nil, uint32(len(msg)+4)), msg...)); err != nil {
+		return fmt.Errorf("warehouse: %w", err)
+	}
+
+	var scram *scramClient
+	for {
+		typ, body, err := c.receive()
+		if err != nil {
+			return err
+		}
+		switch typ {
+		case 'E':
+			return parsePostgresError(body)
+		case 'Z':
+			return nil
+		case 'R':
+			if len(body) < 4 {
+				return errors.New("warehouse: malformed authentication request")
+			}
+			code, data := binary.BigEndian.Uint32(body), body[4:]
+			switch code {
+			case 0: // AuthenticationOk
+			case 3: // Cleartext password
+				c.send('p', append([]byte(pass), 0))
+			case 5: // MD5 with a 4-byte salt
+				inner := md5.Sum([]byte(pass + user))
+				outer := md5.Sum(append([]byte(hex.EncodeToString(inner[:])), data...))
+				c.send('p', append([]byte("md5"+hex.EncodeToString(outer[:])), 0))
+			case 10: // SASL, offering mechanisms
+				if !strings.Contains(string(data), "SCRAM-SHA-256\x00") {
+					return errors.New("warehouse: server offers no supported SASL mechanism")
+				}
+				scram = newSCRAMClient(pass)
+				first := scram.clientFirst()
+				msg := append([]byte("SCRAM-SHA-256\x00"), binary.BigEndian.AppendUint32(nil, uint32(len(first)))...)
+				c.send('p', append(msg, first...))
+			case 11: // SASLContinue
+				if scram == nil {
+					return errors.New("warehouse: unexpected SASL continuation")
+				}
+				final, err := scram.clientFinal(data)
+				if err != nil {
+					return err
+				}
+				c.send('p', final)
+			case 12: // SASLFinal
+				if scram == nil || !scram.verifyServer(data) {
+					return errors.New("warehouse: server SCRAM signature mismatch")
+				}
+			default:
+				return fmt.Errorf("warehouse: unsupported authentication method %d", code)
+			}
+			if err := c.flush(); err != nil {
+				return err
+			}
+		}
+		// ParameterStatus, BackendKeyData and notices need no answer
+	}
+}
+
+// exec runs statements in one round trip. Statements sent before a Sync run
+// in one implicit transaction, so either all of them apply or none do. It
+// returns the rows of the last statement, NULLs as "".
+func (c *pgConn) exec(ctx context.Context, stmts ...statement) ([][]string, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		c.conn.SetDeadline(deadline)
+		defer c.conn.SetDeadline(time.Time{})
+	}
+	for _, s := range stmts {
+		// Parse and Bind an unnamed statement and portal, then Execute
+		parse := append(append([]byte{0}, s.sql...), 0, 0, 0)
+		c.send('P', parse)
+		bind := []byte{0, 0, 0, 0} // Portal and statement names, text parameters
+		bind = binary.BigEndian.AppendUint16(bind, uint16(len(s.args)))
+		for _, arg := range s.args {
+			v, null := encodeParam(arg)
+			if null {
+				bind = binary.BigEndian.AppendUint32(bind, 0xffffffff)
+				continue
+			}
+			bind = binary.BigEndian.AppendUint32(bind, uint32(len(v)))
+			bind = append(bind, v...)
+		}
+		bind = append(bind, 0, 0) // Text results
+		c.send('B', bind)
+		c.send('E', []byte{0, 0, 0, 0, 0})
+	}
+	c.send('S', nil)
+	if err := c.flush(); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	var firstErr error
+	complete := false
+	for {
+		typ, body, err := c.receive()
+		if err != nil {
+			return nil, err
+		}
+		switch typ {
+		case 'E':
+			if firstErr == nil {
+				firstErr = parsePostgresError(body)
+			}
+		case 'C':
+			complete = true
+		case 'D':
+			if complete {
+				// A later statement's rows replace an earlier one's
+				rows, complete = nil, false
+			}
+			row, err := parseDataRow(body)
+			if err != nil {
+				return nil, err
+			}
+			rows = append(rows, row)
+		case 'Z':
+			return rows, firstErr
+		}
+	}
+}
+
+func (c *pgConn) close() error {
+	c.send('X', nil)
+	c.flush()
+	return c.conn.Close()
+}
+
+// send buffers a message until flush.
+func (c *pgConn) send(typ byte, body []byte) {
+	c.buf = append(c.buf, typ)
+	c.buf = binary.BigEndian.AppendUint32(c.buf, uint32(len(body)+4))
+	c.buf = append(c.buf, body...)
+}
+
+func (c *pgConn) flush() error {
+	_, err := c.conn.Write(c.buf)
+	c.buf = c.buf[:0]
+	if err != nil {
+		return fmt.Errorf("warehouse: %w", err)
+	}
+	return nil
+}
+
+func (c *pgConn) receive() (byte, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return 0, nil, fmt.Errorf("warehouse: %w", err)
+	}
+	n := binary.BigEndian.Uint32(header[1:])
+	if n < 4 || n > 1<<30 {
+		return 0, nil, fmt.Errorf("warehouse: malformed message length %d", n)
+	}
+	body := make([]byte, n-4)
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		return 0, nil, fmt.Errorf("warehouse: %w", err)
+	}
+	return header[0], body, nil
+}
+
+func parsePostgresError(body []byte) error {
+	e := &PostgresError{}
+	for len(body) > 1 {
+		field := body[0]
+		end := strings.IndexByte(string(body[1:]), 0)
+		if end < 0 {
+			break
+		}
+		value := string(body[1 : 1+end])
+		body = body[2+end:]
+		switch field {
+		case 'S':
+			e.Severity = value
+		case 'C':
+			e.Code = value
+		case 'M':
+			e.Message = value
+		}
+	}
+	return e
+}
+
+func parseDataRow(body []byte) ([]string, error) {
+	if len(body) < 2 {
+		return nil, errors.New("warehouse: malformed data row")
+	}
+	n := int(binary.BigEndian.Uint16(body))
+	body = body[2:]
+	row := make([]string, n)
+	for i := range row {
+		if len(body) < 4 {
+			return nil, errors.New("warehouse: malformed data row")
+		}
+		size := int32(binary.BigEndian.Uint32(body))
+		body = body[4:]
+		if size < 0 {
+			continue
+		}
+		if int(size) > len(body) {
+			return nil, errors.New("warehouse: malformed data row")
+		}
+		row[i] = string(body[:size])
+		body = body[size:]
+	}
+	return row, nil
+}
+
+// encodeParam formats a parameter in Postgres text format.
+func encodeParam(v interface{}) ([]byte, bool) {
+	switch v := v.(type) {
+	case nil:
+		return nil, true
+	case string:
+		return []byte(v), false
+	case []byte:
+		return []byte(`\x` + hex.EncodeToString(v)), false
+	case int:
+		return strconv.AppendInt(nil, int64(v), 10), false
+	case int64:
+		return strconv.AppendInt(nil, v, 10), false
+	case float64:
+		return strconv.AppendFloat(nil, v, 'g', -1, 64), false
+	case bool:
+		return []byte(strconv.FormatBool(v)), false
+	case time.Time:
+		if v.IsZero() {
+			return nil, true
+		}
+		return []byte(v.UTC().Format(time.RFC3339Nano)), false
+	}
+	return []byte(fmt.Sprint(v)), false
+}
+
+// scramClient authenticates with SCRAM-SHA-256 (RFC 7677), without channel
+// binding.
+type scramClient struct {
+	password    string
+	nonce       string
+	firstBare   string
+	authMessage string
+	salted      []byte
+}
+
+func newSCRAMClient(password string) *scramClient {
+	nonce := make([]byte, 18)
+	rand.Read(nonce)
+	return &scramClient{password: password, nonce: base64.RawStdEncoding.EncodeToString(nonce)}
+}
+
+func (s *scramClient) clientFirst() []byte {
+	// Postgres ignores the SCRAM user name in favor of the startup user
+	s.firstBare = "n=,r=" + s.nonce
+	return []byte("n,," + s.firstBare)
+}
+
+func (s *scramClient) clientFinal(serverFirst []byte) ([]byte, error) {
+	var nonce, salt string
+	iterations := 0
+	for _, attr := range strings.Split(string(serverFirst), ",") {
+		key, value, _ := strings.Cut(attr, "=")
+		switch key {
+		case "r":
+			nonce = value
+		case "s":
+			salt = value
+		case "i":
+			iterations, _ = strconv.Atoi(value)
+		}
+	}
+	saltBytes, err := base64.StdEncoding.DecodeString(salt)
+	if err != nil || !strings.HasPrefix(nonce, s.nonce) || iterations <= 0 {
+		return nil, errors.New("warehouse: malformed SCRAM challenge")
+	}
+	s.salted, err = pbkdf2.Key(sha256.New, s.password, saltBytes, iterations, sha256.Size)
+	if err != nil {
+		return nil, fmt.Errorf("warehouse: %w", err)
+	}
+
+	withoutProof := "c=biws,r=" + nonce
+	s.authMessage = s.firstBare + "," + string(serverFirst) + "," + withoutProof
+	clientKey := hmacSHA256(s.salted, "Client Key")
+	storedKey := sha256.Sum256(clientKey)
+	proof := hmacSHA256(storedKey[:], s.authMessage)
+	for i := range proof {
+		proof[i] ^= clientKey[i]
+	}
+	return []byte(withoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof)), nil
+}
+
+func (s *scramClient) verifyServer(serverFinal []byte) bool {
+	signature, ok := strings.CutPrefix(string(serverFinal), "v=")
+	if !ok || s.salted == nil {
+		return false
+	}
+	got, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	want := hmacSHA256(hmacSHA256(s.salted, "Server Key"), s.authMessage)
+	return hmac.Equal(got, want)
+}
+
+func hmacSHA256(key []byte, message string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(message))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,458 @@
+// Package wasmcrypto is the surface of the crypto primitives that the
+// WebAssembly build (cmd/wasm) exposes to browsers, so the frontend derives
+// commitments, checks proofs and decrypts amounts with the same Go code as
+// the backend instead of a JavaScript port.
+//
+// Every function takes and returns a JSON object, which keeps the bindings
+// to syscall/js trivial and lets the functions be called and tested outside
+// the browser:
+//
+//	out, err := wasmcrypto.Call("poseidon.commitment", []byte(`{"nullifier":"0x01","secret":"0x02"}`))
+//
+// Field elements, keys and ciphertexts are 0x-prefixed hex and addresses are
+// base58, as in the API. Field element inputs may also be decimal.
+package wasmcrypto
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"sol_privacy/internal/elgamal"
+	"sol_privacy/internal/merkle"
+	"sol_privacy/internal/poseidon"
+	"sol_privacy/internal/shadowid"
+	"sol_privacy/internal/stealth"
+)
+
+// Func is a function callable from JavaScript.
+type Func func(args json.RawMessage) (any, error)
+
+var funcs = map[string]Func{
+	"poseidon.hash":          poseidonHash,
+	"poseidon.commitment":    poseidonCommitment,
+	"poseidon.nullifierHash": poseidonNullifierHash,
+	"shadowid.message":       shadowIDMessage,
+	"shadowid.derive":        shadowIDDerive,
+	"merkle.computeRoot":     merkleComputeRoot,
+	"merkle.verify":          merkleVerify,
+	"elgamal.generateKey":    elgamalGenerateKey,
+	"elgamal.publicKey":      elgamalPublicKey,
+	"elgamal.encrypt":        elgamalEncrypt,
+	"elgamal.decrypt":        elgamalDecrypt,
+	"elgamal.add":            elgamalAdd,
+	"elgamal.seal":           elgamalSeal,
+	"elgamal.unseal":         elgamalUnseal,
+	"stealth.newScanKey":     stealthNewScanKey,
+	"stealth.derive":         stealthDerive,
+	"stealth.recover":        stealthRecover,
+}
+
+// Names lists the callable functions, sorted.
+func Names() []string {
+	names := make([]string, 0, len(funcs))
+	for name := range funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Call runs the named function on JSON arguments and returns its JSON result.
+func Call(name string, args []byte) ([]byte, error) {
+	fn, ok := funcs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q", name)
+	}
+	if len(args) == 0 {
+		args = []byte("{}")
+	}
+	out, err := fn(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return json.Marshal(out)
+}
+
+func decode[T any](args json.RawMessage) (T, error) {
+	var v T
+	if err := json.Unmarshal(args, &v); err != nil {
+		return v, fmt.Errorf("invalid arguments: %w", err)
+	}
+	return v, nil
+}
+
+func element(name, s string) (*big.Int, error) {
+	if s == "" {
+		return nil, fmt.Errorf("%s is required", name)
+	}
+	n, err := merkle.ParseElement(s)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if n.Sign() < 0 || n.Cmp(poseidon.Modulus) >= 0 {
+		return nil, fmt.Errorf("%s is not a field element", name)
+	}
+	return n, nil
+}
+
+func elements(name string, list []string) ([]*big.Int, error) {
+	out := make([]*big.Int, len(list))
+	for i, s := range list {
+		n, err := element(fmt.Sprintf("%s[%d]", name, i), s)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = n
+	}
+	return out, nil
+}
+
+// Poseidon
+
+func poseidonHash(args json.RawMessage) (any, error) {
+	req, err := decode[struct {
+		Inputs []string `json:"inputs"`
+	}](args)
+	if err != nil {
+		return nil, err
+	}
+	inputs, err := elements("inputs", req.Inputs)
+	if err != nil {
+		return nil, err
+	}
+	h, err := poseidon.Hash(inputs...)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"hash": merkle.FormatElement(h)}, nil
+}
+
+func poseidonCommitment(args json.RawMessage) (any, error) {
+	req, err := decode[struct {
+		Nullifier string `json:"nullifier"`
+		Secret    string `json:"secret"`
+	}](args)
+	if err != nil {
+		return nil, err
+	}
+	nullifier, err := element("nullifier", req.Nullifier)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := element("secret", req.Secret)
+	if err != nil {
+		return nil, err
+	}
+	c, err := poseidon.Commitment(nullifier, secret)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"commitment": merkle.FormatElement(c)}, nil
+}
+
+func poseidonNullifierHash(args json.RawMessage) (any, error) {
+	req, err := decode[struct {
+		Nullifier string `json:"nullifier"`
+	}](args)
+	if err != nil {
+		return nil, err
+	}
+	nullifier, err := element("nullifier", req.Nullifier)
+	if err != nil {
+		return nil, err
+	}
+	h, err := poseidon.NullifierHash(nullifier)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"nullifier_hash": merkle.FormatElement(h)}, nil
+}
+
+// ShadowID
+
+func shadowIDMessage(args json.RawMessage) (any, error) {
+	req, err := decode[struct {
+		WalletAddress string `json:"wallet_address"`
+	}](args)
+	if err != nil {
+		return nil, err
+	}
+	if req.WalletAddress == "" {
+		return nil, errors.New("wallet_address is required")
+	}
+	return map[string]string{"message": shadowid.IdentityMessage(req.WalletAddress)}, nil
+}
+
+// Identity is a derived ShadowID. Secret and Nullifier must stay in the browser.
+type Identity struct {
+	Commitment    string `json:"commitment"`
+	NullifierHash string `json:"nullifier_hash"`
+	Secret        string `json:"secret"`
+	Nullifier     string `json:"nullifier"`
+}
+
+func shadowIDDerive(args json.RawMessage) (any, error) {
+	req, err := decode[struct {
+		WalletAddress string `json:"wallet_address"`
+		Signature     string `json:"signature"` // Base58 signature of shadowid.message
+	}](args)
+	if err != nil {
+		return nil, err
+	}
+	id, err := shadowid.IdentityFromSignature(req.WalletAddress, req.Signature)
+	if err != nil {
+		return nil, err
+	}
+	nh, err := poseidon.NullifierHash(id.Nullifier)
+	if err != nil {
+		return nil, err
+	}
+	return Identity{
+		Commitment:    id.CommitmentHex(),
+		NullifierHash: merkle.FormatElement(nh),
+		Secret:        merkle.FormatElement(id.Secret),
+		Nullifier:     merkle.FormatElement(id.Nullifier),
+	}, nil
+}
+
+// Merkle
+
+type proofArgs struct {
+	Leaf        string   `json:"leaf"`
+	Siblings    []string `json:"siblings"`     // Bottom-up
+	PathIndices []int    `json:"path_indices"` // 0 for a left child, 1 for a right child
+	Root        string   `json:"root"`
+}
+
+func (p proofArgs) root() (*big.Int, error) {
+	if len(p.Siblings) > merkle.MaxDepth || len(p.PathIndices) != len(p.Siblings) {
+		return nil, errors.New("siblings and path_indices must have the same length, at most the tree depth")
+	}
+	leaf, err := element("leaf", p.Leaf)
+	if err != nil {
+		return nil, err
+	}
+	siblings, err := elements("siblings", p.Siblings)
+	if err != nil {
+		return nil, err
+	}
+	return merkle.ComputeRoot(leaf, siblings, p.PathIndices, merkle.PoseidonHash), nil
+}
+
+func merkleComputeRoot(args json.RawMessage) (any, error) {
+	req, err := decode[proofArgs](args)
+	if err != nil {
+		return nil, err
+	}
+	root, err := req.root()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"root": merkle.FormatElement(root)}, nil
+}
+
+func merkleVerify(args json.RawMessage) (any, error) {
+	req, err := decode[proofArgs](args)
+	if err != nil {
+		return nil, err
+	}
+	want, err := element("root", req.Root)
+	if err != nil {
+		return nil, err
+	}
+	root, err := req.root()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]bool{"valid": root.Cmp(want) == 0}, nil
+}
+
+// ElGamal
+
+func elgamalGenerateKey(json.RawMessage) (any, error) {
+	k, err := elgamal.GenerateKey(nil)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"private_key": k.Hex(), "public_key": k.Public().Hex()}, nil
+}
+
+func elgamalPublicKey(args json.RawMessage) (any, error) {
+	req, err := decode[struct {
+		PrivateKey string `json:"private_key"`
+	}](args)
+	if err != nil {
+		return nil, err
+	}
+	k, err := elgamal.ParsePrivateKey(req.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"public_key": k.Public().Hex()}, nil
+}
+
+func elgamalEncrypt(args json.RawMessage) (any, error) {
+	req, err := decode[struct {
+		PublicKey string `json:"public_key"`
+		Amount    uint64 `json:"amount"`
+	}](args)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := elgamal.ParsePublicKey(req.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	c, err := elgamal.Encrypt(pub, req.Amount, nil)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"ciphertext": c.Hex()}, nil
+}
+
+func elgamalDecrypt(args json.RawMessage) (any, error) {
+	req, err := decode[struct {
+		PrivateKey string `json:"private_key"`
+		Ciphertext string `json:"ciphertext"`
+		MaxAmount  uint64 `json:"max_amount,omitempty"` // Defaults to elgamal.DefaultMaxAmount
+	}](args)
+	if err != nil {
+		return nil, err
+	}
+	k, err := elgamal.ParsePrivateKey(req.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	c, err := elgamal.ParseCiphertext(req.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	if req.MaxAmount == 0 {
+		req.MaxAmount = elgamal.DefaultMaxAmount
+	}
+	amount, err := k.Decrypt(c, req.MaxAmount)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]uint64{"amount": amount}, nil
+}
+
+func elgamalAdd(args json.RawMessage) (any, error) {
+	req, err := decode[struct {
+		Ciphertexts []string `json:"ciphertexts"`
+	}](args)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Ciphertexts) == 0 {
+		return nil, errors.New("ciphertexts is required")
+	}
+	var sum *elgamal.Ciphertext
+	for i, s := range req.Ciphertexts {
+		c, err := elgamal.ParseCiphertext(s)
+		if err != nil {
+			return nil, fmt.Errorf("ciphertexts[%d]: %w", i, err)
+		}
+		if sum == nil {
+			sum = c
+		} else {
+			sum = sum.Add(c)
+		}
+	}
+	return map[string]string{"ciphertext": sum.Hex()}, nil
+}
+
+func elgamalSeal(args json.RawMessage) (any, error) {
+	req, err := decode[struct {
+		PublicKey      string `json:"public_key"`
+		Data           string `json:"data"`
+		AssociatedData string `json:"associated_data,omitempty"`
+	}](args)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := elgamal.ParsePublicKey(req.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := elgamal.Seal(pub, []byte(req.Data), []byte(req.AssociatedData), nil)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"sealed": sealed}, nil
+}
+
+func elgamalUnseal(args json.RawMessage) (any, error) {
+	req, err := decode[struct {
+		PrivateKey     string `json:"private_key"`
+		Sealed         string `json:"sealed"`
+		AssociatedData string `json:"associated_data,omitempty"`
+	}](args)
+	if err != nil {
+		return nil, err
+	}
+	k, err := elgamal.ParsePrivateKey(req.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	data, err := k.Unseal(req.Sealed, []byte(req.AssociatedData))
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"data": string(data)}, nil
+}
+
+// Stealth addresses. Scan keys are the 32-byte X25519 private key as hex.
+
+func stealthNewScanKey(json.RawMessage) (any, error) {
+	scan, err := stealth.NewScanKey(nil)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"scan_key":     "0x" + hex.EncodeToString(scan.Bytes()),
+		"meta_address": stealth.MetaAddress(scan),
+	}, nil
+}
+
+func stealthDerive(args json.RawMessage) (any, error) {
+	req, err := decode[struct {
+		MetaAddress string `json:"meta_address"`
+	}](args)
+	if err != nil {
+		return nil, err
+	}
+	ephemeral, err := stealth.NewScanKey(nil)
+	if err != nil {
+		return nil, err
+	}
+	return stealth.Derive(req.MetaAddress, ephemeral)
+}
+
+func stealthRecover(args json.RawMessage) (any, error) {
+	req, err := decode[struct {
+		ScanKey            string `json:"scan_key"`
+		EphemeralPublicKey string `json:"ephemeral_public_key"`
+	}](args)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := hex.DecodeString(strings.TrimPrefix(req.ScanKey, "0x"))
+	if err != nil {
+		return nil, errors.New("scan_key must be 32-byte hex")
+	}
+	scan, err := stealth.ScanKeyFromSeed(seed)
+	if err != nil {
+		return nil, fmt.Errorf("scan_key: %w", err)
+	}
+	kp, err := stealth.Recover(scan, req.EphemeralPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"address": kp.Address(), "secret_key": kp.SecretKey()}, nil
+}
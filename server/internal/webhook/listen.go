@@ -0,0 +1,70 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+)
+
+// SignatureHeader carries the signature of webhook deliveries.
+const SignatureHeader = "X-ShadowPay-Signature"
+
+// maxDeliverySize bounds the payloads a Listener reads.
+const maxDeliverySize = 1 << 20
+
+// Delivery is a webhook delivery received by a Listener.
+type Delivery struct {
+	Event     string          // The payload's event type
+	Payload   json.RawMessage // Raw body
+	Signature string
+	Verified  bool // The signature matches the secret
+	Header    http.Header
+	Received  time.Time
+}
+
+// Listener returns a handler receiving webhook deliveries, e.g. for local
+// development. Each is verified against secret, read from header (default
+// SignatureHeader), and passed to handle. Deliveries with a bad signature are
+// answered 401, like a merchant endpoint should; without a secret, all are
+// accepted unverified.
+func Listener(secret, header string, handle func(Delivery)) http.Handler {
+	if header == "" {
+		header = SignatureHeader
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxDeliverySize))
+		if err != nil {
+			http.Error(w, "unreadable body", http.StatusBadRequest)
+			return
+		}
+
+		d := Delivery{
+			Payload:   body,
+			Signature: r.Header.Get(header),
+			Header:    r.Header.Clone(),
+			Received:  time.Now(),
+		}
+		var event struct {
+			Event string `json:"event"`
+		}
+		if json.Unmarshal(body, &event) == nil {
+			d.Event = event.Event
+		}
+		d.Verified = VerifySignature(body, d.Signature, secret)
+		handle(d)
+
+		w.Header().Set("Content-Type", "application/json")
+		if secret != "" && !d.Verified {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"received":false,"error":"invalid signature"}`))
+			return
+		}
+		w.Write([]byte(`{"received":true}`))
+	})
+}
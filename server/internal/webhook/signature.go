@@ -0,0 +1,119 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+
+	"sol_privacy/internal/jsonfile"
+	"sol_privacy/internal/validate"
+)
+
+// ErrUnknownWebhook is returned for webhooks whose secret is not known.
+var ErrUnknownWebhook = errors.New("unknown webhook")
+
+// Sign returns the hex-encoded HMAC-SHA256 of payload under secret, as sent
+// with deliveries of webhooks registered with a secret.
+func Sign(payload []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature reports whether signature is the HMAC-SHA256 of the raw
+// payload under secret. The signature is hex, optionally prefixed with
+// "sha256=", and compared in constant time.
+func VerifySignature(payload []byte, signature, secret string) bool {
+	if secret == "" {
+		return false
+	}
+	signature = strings.TrimPrefix(strings.TrimSpace(signature), "sha256=")
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	want, _ := hex.DecodeString(Sign(payload, secret))
+	return hmac.Equal(got, want)
+}
+
+// VerifySignatureRequest is a delivery to check against its webhook's secret.
+type VerifySignatureRequest struct {
+	WebhookID string `json:"webhook_id"`
+	Payload   string `json:"payload"`   // Raw request body, exactly as received
+	Signature string `json:"signature"` // Hex HMAC-SHA256, optionally prefixed with "sha256="
+}
+
+// Validate checks the request fields.
+func (r VerifySignatureRequest) Validate() error {
+	return validate.New().
+		Required("webhook_id", r.WebhookID).
+		Required("signature", r.Signature).
+		Err()
+}
+
+// SecretStore keeps the secrets of registered webhooks, which the API only
+// returns masked.
+type SecretStore interface {
+	Secret(webhookID string) (string, error) // Returns ErrUnknownWebhook for unknown webhooks
+	PutSecret(webhookID, secret string) error
+}
+
+// MemorySecretStore is an in-process SecretStore.
+type MemorySecretStore struct {
+	mu      sync.RWMutex
+	secrets map[string]string
+}
+
+// NewMemorySecretStore creates an empty in-memory store.
+func NewMemorySecretStore() *MemorySecretStore {
+	return &MemorySecretStore{secrets: make(map[string]string)}
+}
+
+// Secret returns the webhook's secret.
+func (m *MemorySecretStore) Secret(webhookID string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	secret, ok := m.secrets[webhookID]
+	if !ok {
+		return "", ErrUnknownWebhook
+	}
+	return secret, nil
+}
+
+// PutSecret saves the webhook's secret.
+func (m *MemorySecretStore) PutSecret(webhookID, secret string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.secrets[webhookID] = secret
+	return nil
+}
+
+// FileSecretStore is a MemorySecretStore persisted to a JSON file after
+// every write.
+type FileSecretStore struct {
+	*MemorySecretStore
+	path string
+	mu   sync.Mutex // Serializes file writes
+}
+
+// NewFileSecretStore opens the store at path, creating it on first write.
+func NewFileSecretStore(path string) (*FileSecretStore, error) {
+	fs := &FileSecretStore{MemorySecretStore: NewMemorySecretStore(), path: path}
+	if err := jsonfile.Load(path, &fs.secrets); err != nil {
+		return nil, err
+	}
+	return fs, nil
+}
+
+// PutSecret saves the webhook's secret and rewrites the file.
+func (f *FileSecretStore) PutSecret(webhookID, secret string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.MemorySecretStore.PutSecret(webhookID, secret)
+	f.MemorySecretStore.mu.RLock()
+	defer f.MemorySecretStore.mu.RUnlock()
+	return jsonfile.Save(f.path, f.secrets)
+}
@@ -2,6 +2,9 @@ package webhook
 
 import (
 	"context"
+	"fmt"
+
+	"sol_privacy/internal/validate"
 )
 
 // Service handles webhook registration and management for real-time payment notifications.
@@ -23,6 +26,15 @@ type RegisterRequest struct {
 	Secret string   `json:"secret,omitempty"`  // Optional HMAC secret for signature verification
 }
 
+// Validate checks the request fields.
+func (r RegisterRequest) Validate() error {
+	v := validate.New().URL("url", r.URL, true)
+	if len(r.Events) == 0 {
+		v.Add("events", fmt.Errorf("at least one event is required"))
+	}
+	return v.Err()
+}
+
 // RegisterResponse contains the webhook registration confirmation.
 type RegisterResponse struct {
 	Success   bool   `json:"success"`
@@ -118,6 +130,9 @@ type DeactivateResponse struct {
 // Register registers a webhook URL to receive payment event notifications.
 // Supported events: "payment.received", "payment.settled", "payment.failed"
 func (s *Service) Register(ctx context.Context, req RegisterRequest) (*RegisterResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
 	var resp RegisterResponse
 	if err := s.doRequest(ctx, "POST", "/shadowpay/api/webhooks/register", req, &resp); err != nil {
 		return nil, err
@@ -0,0 +1,224 @@
+// Package x402 builds x402 payment requirements and converts between the
+// protocol's SOL amount strings and lamports.
+//
+// Requirements carry the price as a decimal SOL string, which is easy to get
+// wrong by hand; the builder takes lamports and checks every field:
+//
+//	req, err := x402.NewRequirements().
+//		Price(x402.SOL(0.001)).
+//		Resource("https://api.example.com/report").
+//		PayTo(wallet).
+//		Build()
+package x402
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+
+	"sol_privacy/internal/amount"
+	"sol_privacy/internal/payment"
+	"sol_privacy/internal/validate"
+	"sol_privacy/internal/verify"
+)
+
+// LamportsPerSOL is the number of lamports in one SOL. It is kept for
+// callers using int64 lamports; see amount.LamportsPerSOL.
+const LamportsPerSOL = amount.LamportsPerSOL
+
+// Defaults used by NewRequirements.
+const (
+	DefaultScheme   = "zkproof"
+	DefaultNetwork  = "solana-mainnet"
+	DefaultMimeType = "application/json"
+	DefaultTimeout  = 60 * time.Second
+)
+
+// SOL converts an amount in SOL to lamports, rounding to the nearest lamport.
+func SOL(sol float64) int64 {
+	return amount.SOL(sol).Int64()
+}
+
+// FormatSOL formats lamports as an x402 amount in SOL. It is kept for callers
+// using int64 lamports; see amount.Amount.SOL.
+func FormatSOL(lamports int64) string {
+	return amount.Lamports(lamports).SOL()
+}
+
+// ParseSOL parses an x402 amount in SOL, such as "0.001", into lamports,
+// rejecting amounts above validate.MaxLamports. It is kept for callers using
+// int64 lamports; see amount.ParseSOL.
+func ParseSOL(s string) (int64, error) {
+	a, err := amount.ParseSOL(s)
+	if err != nil {
+		return 0, err
+	}
+	if a > validate.MaxLamports {
+		return 0, fmt.Errorf("SOL amount %q out of range", s)
+	}
+	return a.Int64(), nil
+}
+
+// RequirementsBuilder builds payment requirements. Setters may be chained in
+// any order; Build reports every invalid field at once.
+type RequirementsBuilder struct {
+	scheme      string
+	network     string
+	price       int64
+	resource    string
+	description string
+	mimeType    string
+	payTo       string
+	timeout     time.Duration
+	extra       map[string]any
+}
+
+// NewRequirements starts requirements for the zkproof scheme on mainnet, with
+// a JSON resource and a 60 second timeout.
+func NewRequirements() *RequirementsBuilder {
+	return &RequirementsBuilder{
+		scheme:   DefaultScheme,
+		network:  DefaultNetwork,
+		mimeType: DefaultMimeType,
+		timeout:  DefaultTimeout,
+	}
+}
+
+// Scheme sets the payment scheme.
+func (b *RequirementsBuilder) Scheme(scheme string) *RequirementsBuilder {
+	b.scheme = scheme
+	return b
+}
+
+// Network sets the network, e.g. "solana-devnet".
+func (b *RequirementsBuilder) Network(network string) *RequirementsBuilder {
+	b.network = network
+	return b
+}
+
+// Price sets the price in lamports; use SOL to convert.
+func (b *RequirementsBuilder) Price(lamports int64) *RequirementsBuilder {
+	b.price = lamports
+	return b
+}
+
+// Resource sets the URL or path being paid for.
+func (b *RequirementsBuilder) Resource(resource string) *RequirementsBuilder {
+	b.resource = resource
+	return b
+}
+
+// Description sets the description shown to payers.
+func (b *RequirementsBuilder) Description(description string) *RequirementsBuilder {
+	b.description = description
+	return b
+}
+
+// MimeType sets the media type of the resource.
+func (b *RequirementsBuilder) MimeType(mimeType string) *RequirementsBuilder {
+	b.mimeType = mimeType
+	return b
+}
+
+// PayTo sets the merchant wallet receiving the payment.
+func (b *RequirementsBuilder) PayTo(wallet string) *RequirementsBuilder {
+	b.payTo = wallet
+	return b
+}
+
+// Timeout sets how long the payer has to complete the payment, in whole seconds.
+func (b *RequirementsBuilder) Timeout(d time.Duration) *RequirementsBuilder {
+	b.timeout = d
+	return b
+}
+
+// ReceiverCommitment pays a ShadowID commitment rather than the PayTo wallet.
+func (b *RequirementsBuilder) ReceiverCommitment(commitment string) *RequirementsBuilder {
+	return b.Extra("receiverCommitment", commitment)
+}
+
+// Extra sets a scheme-specific detail.
+func (b *RequirementsBuilder) Extra(key string, value any) *RequirementsBuilder {
+	if b.extra == nil {
+		b.extra = make(map[string]any)
+	}
+	b.extra[key] = value
+	return b
+}
+
+// Build validates the fields and returns the requirements.
+func (b *RequirementsBuilder) Build() (payment.Requirements, error) {
+	if err := b.validate(); err != nil {
+		return payment.Requirements{}, err
+	}
+	req := payment.Requirements{
+		Scheme:            b.scheme,
+		Network:           b.network,
+		MaxAmountRequired: FormatSOL(b.price),
+		Resource:          b.resource,
+		Description:       b.description,
+		MimeType:          b.mimeType,
+		PayTo:             b.payTo,
+		MaxTimeoutSeconds: int(b.timeout / time.Second),
+	}
+	if len(b.extra) > 0 {
+		req.Extra = make(map[string]any, len(b.extra))
+		for k, v := range b.extra {
+			req.Extra[k] = v
+		}
+	}
+	return req, nil
+}
+
+// BuildVerify returns the requirements in the form verify requests take,
+// which have no scheme-specific details.
+func (b *RequirementsBuilder) BuildVerify() (verify.Requirements, error) {
+	req, err := b.Build()
+	if err != nil {
+		return verify.Requirements{}, err
+	}
+	return verify.Requirements{
+		Scheme:            req.Scheme,
+		Network:           req.Network,
+		MaxAmountRequired: req.MaxAmountRequired,
+		Resource:          req.Resource,
+		Description:       req.Description,
+		MimeType:          req.MimeType,
+		PayTo:             req.PayTo,
+		MaxTimeoutSeconds: req.MaxTimeoutSeconds,
+	}, nil
+}
+
+// MustBuild is Build for requirements fixed at startup; it panics if they are invalid.
+func (b *RequirementsBuilder) MustBuild() payment.Requirements {
+	req, err := b.Build()
+	if err != nil {
+		panic(err)
+	}
+	return req
+}
+
+func (b *RequirementsBuilder) validate() error {
+	v := validate.New().
+		Required("scheme", b.scheme).
+		Required("network", b.network).
+		Amount("maxAmountRequired", b.price, 1, validate.MaxLamports).
+		Address("payTo", b.payTo)
+	if strings.HasPrefix(b.resource, "/") {
+		v.Required("resource", b.resource)
+	} else {
+		v.URL("resource", b.resource, false)
+	}
+	if _, _, err := mime.ParseMediaType(b.mimeType); err != nil {
+		v.Add("mimeType", errors.New("must be a media type, e.g. application/json"))
+	}
+	if b.timeout < time.Second {
+		v.Add("maxTimeoutSeconds", errors.New("must be at least one second"))
+	}
+	if c, ok := b.extra["receiverCommitment"].(string); ok {
+		v.Commitment("extra.receiverCommitment", c)
+	}
+	return v.Err()
+}
@@ -0,0 +1,408 @@
+package zkproof
+
+import (
+	"math/big"
+
+	"sol_privacy/internal/poseidon"
+)
+
+// BN254 ("bn128" in snarkjs) and its optimal ate pairing, enough to check
+// Groth16 proofs. G1 is y² = x³ + 3 over Fp; G2 is on the sextic twist
+// y² = x³ + 3/ξ over Fp2 = Fp[u]/(u² + 1), with ξ = 9 + u. The pairing maps
+// into Fp12, built as the tower
+//
+//	Fp6 = Fp2[v]/(v³ - ξ),  Fp12 = Fp6[w]/(w² - v)
+//
+// Arithmetic is on math/big and not constant time, which is fine for public
+// proofs and keys.
+var (
+	fieldPrime, _ = new(big.Int).SetString("21888242871839275222246405745257275088696311157297823662689037894645226208583", 10)
+	groupOrder    = poseidon.Modulus
+
+	// ateLoop is 6x + 2 for the curve parameter x = 4965661367192848881.
+	ateLoop, _ = new(big.Int).SetString("29793968203157093288", 10)
+
+	xi     = fp2{big.NewInt(9), big.NewInt(1)}
+	twistB = fp2Inv(xi).mulScalar(big.NewInt(3))
+
+	// Frobenius on the twist: π(x, y) = (x̄·ξ^((p-1)/3), ȳ·ξ^((p-1)/2))
+	frobX = xi.exp(new(big.Int).Div(new(big.Int).Sub(fieldPrime, big.NewInt(1)), big.NewInt(3)))
+	frobY = xi.exp(new(big.Int).Rsh(new(big.Int).Sub(fieldPrime, big.NewInt(1)), 1))
+
+	// The final exponentiation (p¹² - 1)/r is split into (p⁶ - 1)(p² + 1)
+	// and the remaining (p⁴ - p² + 1)/r.
+	pSquared = new(big.Int).Mul(fieldPrime, fieldPrime)
+	hardExp  = func() *big.Int {
+		p4 := new(big.Int).Mul(pSquared, pSquared)
+		e := p4.Sub(p4, pSquared).Add(p4, big.NewInt(1))
+		return e.Div(e, groupOrder)
+	}()
+
+	// frob2[k] is ξ^(k(p² - 1)/6): (w^k)^(p²) = w^k·frob2[k], and Fp2 is
+	// fixed by x ↦ x^(p²).
+	frob2 = func() [6]fp2 {
+		var g [6]fp2
+		base := xi.exp(new(big.Int).Div(new(big.Int).Sub(pSquared, big.NewInt(1)), big.NewInt(6)))
+		g[0] = fp2One()
+		for k := 1; k < 6; k++ {
+			g[k] = g[k-1].mul(base)
+		}
+		return g
+	}()
+)
+
+func fpMod(a *big.Int) *big.Int {
+	return a.Mod(a, fieldPrime)
+}
+
+// fp2 is a + b·u.
+type fp2 struct{ a, b *big.Int }
+
+func fp2Zero() fp2 { return fp2{new(big.Int), new(big.Int)} }
+func fp2One() fp2  { return fp2{big.NewInt(1), new(big.Int)} }
+
+func (x fp2) isZero() bool { return x.a.Sign() == 0 && x.b.Sign() == 0 }
+
+func (x fp2) equal(y fp2) bool { return x.a.Cmp(y.a) == 0 && x.b.Cmp(y.b) == 0 }
+
+func (x fp2) add(y fp2) fp2 {
+	return fp2{fpMod(new(big.Int).Add(x.a, y.a)), fpMod(new(big.Int).Add(x.b, y.b))}
+}
+
+func (x fp2) sub(y fp2) fp2 {
+	return fp2{fpMod(new(big.Int).Sub(x.a, y.a)), fpMod(new(big.Int).Sub(x.b, y.b))}
+}
+
+func (x fp2) neg() fp2 {
+	return fp2Zero().sub(x)
+}
+
+func (x fp2) conj() fp2 {
+	return fp2{new(big.Int).Set(x.a), fpMod(new(big.Int).Neg(x.b))}
+}
+
+// mul is (a + bu)(c + du) = ac - bd + ((a + b)(c + d) - ac - bd)u.
+func (x fp2) mul(y fp2) fp2 {
+	ac := new(big.Int).Mul(x.a, y.a)
+	bd := new(big.Int).Mul(x.b, y.b)
+	m := new(big.Int).Mul(new(big.Int).Add(x.a, x.b), new(big.Int).Add(y.a, y.b))
+	m.Sub(m, ac).Sub(m, bd)
+	return fp2{fpMod(ac.Sub(ac, bd)), fpMod(m)}
+}
+
+func (x fp2) mulScalar(k *big.Int) fp2 {
+	return fp2{fpMod(new(big.Int).Mul(x.a, k)), fpMod(new(big.Int).Mul(x.b, k))}
+}
+
+func (x fp2) mulXi() fp2 {
+	return x.mul(xi)
+}
+
+func fp2Inv(x fp2) fp2 {
+	// 1/(a + bu) = (a - bu)/(a² + b²)
+	n := new(big.Int).Mul(x.a, x.a)
+	n.Add(n, new(big.Int).Mul(x.b, x.b))
+	n.ModInverse(fpMod(n), fieldPrime)
+	return x.conj().mulScalar(n)
+}
+
+func (x fp2) exp(e *big.Int) fp2 {
+	r := fp2One()
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		r = r.mul(r)
+		if e.Bit(i) == 1 {
+			r = r.mul(x)
+		}
+	}
+	return r
+}
+
+// fp6 is c0 + c1·v + c2·v².
+type fp6 struct{ c0, c1, c2 fp2 }
+
+func fp6Zero() fp6 { return fp6{fp2Zero(), fp2Zero(), fp2Zero()} }
+func fp6One() fp6  { return fp6{fp2One(), fp2Zero(), fp2Zero()} }
+
+func (x fp6) equal(y fp6) bool { return x.c0.equal(y.c0) && x.c1.equal(y.c1) && x.c2.equal(y.c2) }
+
+func (x fp6) add(y fp6) fp6 { return fp6{x.c0.add(y.c0), x.c1.add(y.c1), x.c2.add(y.c2)} }
+
+func (x fp6) sub(y fp6) fp6 { return fp6{x.c0.sub(y.c0), x.c1.sub(y.c1), x.c2.sub(y.c2)} }
+
+func (x fp6) neg() fp6 { return fp6Zero().sub(x) }
+
+func (x fp6) mul(y fp6) fp6 {
+	t0, t1, t2 := x.c0.mul(y.c0), x.c1.mul(y.c1), x.c2.mul(y.c2)
+	return fp6{
+		t0.add(x.c1.add(x.c2).mul(y.c1.add(y.c2)).sub(t1).sub(t2).mulXi()),
+		x.c0.add(x.c1).mul(y.c0.add(y.c1)).sub(t0).sub(t1).add(t2.mulXi()),
+		x.c0.add(x.c2).mul(y.c0.add(y.c2)).sub(t0).sub(t2).add(t1),
+	}
+}
+
+// mulV multiplies by v, using v³ = ξ.
+func (x fp6) mulV() fp6 {
+	return fp6{x.c2.mulXi(), x.c0, x.c1}
+}
+
+func (x fp6) inv() fp6 {
+	a := x.c0.mul(x.c0).sub(x.c1.mul(x.c2).mulXi())
+	b := x.c2.mul(x.c2).mulXi().sub(x.c0.mul(x.c1))
+	c := x.c1.mul(x.c1).sub(x.c0.mul(x.c2))
+	f := fp2Inv(x.c0.mul(a).add(x.c2.mul(b).add(x.c1.mul(c)).mulXi()))
+	return fp6{a.mul(f), b.mul(f), c.mul(f)}
+}
+
+// fp12 is c0 + c1·w.
+type fp12 struct{ c0, c1 fp6 }
+
+func fp12One() fp12 { return fp12{fp6One(), fp6Zero()} }
+
+func (x fp12) equal(y fp12) bool { return x.c0.equal(y.c0) && x.c1.equal(y.c1) }
+
+func (x fp12) mul(y fp12) fp12 {
+	t0, t1 := x.c0.mul(y.c0), x.c1.mul(y.c1)
+	return fp12{
+		t0.add(t1.mulV()),
+		x.c0.add(x.c1).mul(y.c0.add(y.c1)).sub(t0).sub(t1),
+	}
+}
+
+// conj is x^(p⁶), as w^(p⁶) = -w.
+func (x fp12) conj() fp12 {
+	return fp12{x.c0, x.c1.neg()}
+}
+
+// frobenius2 is x^(p²). The coefficients of c0 are of w⁰, w² and w⁴; those
+// of c1 of w¹, w³ and w⁵.
+func (x fp12) frobenius2() fp12 {
+	return fp12{
+		fp6{x.c0.c0.mul(frob2[0]), x.c0.c1.mul(frob2[2]), x.c0.c2.mul(frob2[4])},
+		fp6{x.c1.c0.mul(frob2[1]), x.c1.c1.mul(frob2[3]), x.c1.c2.mul(frob2[5])},
+	}
+}
+
+func (x fp12) inv() fp12 {
+	t := x.c0.mul(x.c0).sub(x.c1.mul(x.c1).mulV()).inv()
+	return fp12{x.c0.mul(t), x.c1.mul(t).neg()}
+}
+
+func (x fp12) exp(e *big.Int) fp12 {
+	r := fp12One()
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		r = r.mul(r)
+		if e.Bit(i) == 1 {
+			r = r.mul(x)
+		}
+	}
+	return r
+}
+
+// g1 is an affine G1 point; inf marks the identity.
+type g1 struct {
+	x, y *big.Int
+	inf  bool
+}
+
+func (a g1) onCurve() bool {
+	if a.inf {
+		return true
+	}
+	lhs := fpMod(new(big.Int).Mul(a.y, a.y))
+	rhs := new(big.Int).Mul(a.x, a.x)
+	rhs.Mul(rhs, a.x).Add(rhs, big.NewInt(3))
+	return lhs.Cmp(fpMod(rhs)) == 0
+}
+
+func (a g1) neg() g1 {
+	if a.inf {
+		return a
+	}
+	return g1{x: a.x, y: fpMod(new(big.Int).Neg(a.y))}
+}
+
+func (a g1) add(b g1) g1 {
+	switch {
+	case a.inf:
+		return b
+	case b.inf:
+		return a
+	}
+	var lambda *big.Int
+	if a.x.Cmp(b.x) == 0 {
+		if a.y.Cmp(b.y) != 0 || a.y.Sign() == 0 {
+			return g1{inf: true}
+		}
+		num := new(big.Int).Mul(a.x, a.x)
+		num.Mul(num, big.NewInt(3))
+		lambda = num.Mul(num, new(big.Int).ModInverse(new(big.Int).Lsh(a.y, 1), fieldPrime))
+	} else {
+		num := new(big.Int).Sub(b.y, a.y)
+		den := fpMod(new(big.Int).Sub(b.x, a.x))
+		lambda = num.Mul(num, den.ModInverse(den, fieldPrime))
+	}
+	fpMod(lambda)
+	x := new(big.Int).Mul(lambda, lambda)
+	x = fpMod(x.Sub(x, a.x).Sub(x, b.x))
+	y := new(big.Int).Sub(a.x, x)
+	y = fpMod(y.Mul(y, lambda).Sub(y, a.y))
+	return g1{x: x, y: y}
+}
+
+func (a g1) mul(k *big.Int) g1 {
+	r := g1{inf: true}
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		r = r.add(r)
+		if k.Bit(i) == 1 {
+			r = r.add(a)
+		}
+	}
+	return r
+}
+
+// g2 is an affine point on the twist; inf marks the identity.
+type g2 struct {
+	x, y fp2
+	inf  bool
+}
+
+func (a g2) onCurve() bool {
+	if a.inf {
+		return true
+	}
+	return a.y.mul(a.y).equal(a.x.mul(a.x).mul(a.x).add(twistB))
+}
+
+// inSubgroup reports whether r·a is the identity; unlike G1, the twist has
+// points outside the group.
+func (a g2) inSubgroup() bool {
+	return a.mul(groupOrder).inf
+}
+
+func (a g2) neg() g2 {
+	if a.inf {
+		return a
+	}
+	return g2{x: a.x, y: a.y.neg()}
+}
+
+func (a g2) frobenius() g2 {
+	return g2{x: a.x.conj().mul(frobX), y: a.y.conj().mul(frobY), inf: a.inf}
+}
+
+// slope returns the slope of the line through a and b, the tangent if they
+// are equal, and false if it is vertical.
+func (a g2) slope(b g2) (fp2, bool) {
+	if a.x.equal(b.x) {
+		if !a.y.equal(b.y) || a.y.isZero() {
+			return fp2{}, false
+		}
+		num := a.x.mul(a.x).mulScalar(big.NewInt(3))
+		return num.mul(fp2Inv(a.y.add(a.y))), true
+	}
+	return b.y.sub(a.y).mul(fp2Inv(b.x.sub(a.x))), true
+}
+
+func (a g2) addWithSlope(b g2, lambda fp2) g2 {
+	x := lambda.mul(lambda).sub(a.x).sub(b.x)
+	y := lambda.mul(a.x.sub(x)).sub(a.y)
+	return g2{x: x, y: y}
+}
+
+func (a g2) add(b g2) g2 {
+	switch {
+	case a.inf:
+		return b
+	case b.inf:
+		return a
+	}
+	lambda, ok := a.slope(b)
+	if !ok {
+		return g2{inf: true}
+	}
+	return a.addWithSlope(b, lambda)
+}
+
+func (a g2) mul(k *big.Int) g2 {
+	r := g2{inf: true}
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		r = r.add(r)
+		if k.Bit(i) == 1 {
+			r = r.add(a)
+		}
+	}
+	return r
+}
+
+// lineStep returns t + q and the line through them evaluated at p. Points
+// of the twist map to the curve over Fp12 as (x, y) ↦ (x·w², y·w³), so the
+// line y - y_t = λ(x - x_t) there evaluates at p to
+//
+//	y_p - λ·x_p·w + (λ·x_t - y_t)·w³
+func lineStep(t, q g2, p g1) (g2, fp12) {
+	lambda, ok := t.slope(q)
+	if !ok {
+		// Vertical: x_p - x_t·w²
+		return g2{inf: true}, fp12{
+			fp6{fp2{new(big.Int).Set(p.x), new(big.Int)}, t.x.neg(), fp2Zero()},
+			fp6Zero(),
+		}
+	}
+	line := fp12{
+		fp6{fp2{new(big.Int).Set(p.y), new(big.Int)}, fp2Zero(), fp2Zero()},
+		fp6{lambda.mulScalar(p.x).neg(), lambda.mul(t.x).sub(t.y), fp2Zero()},
+	}
+	return t.addWithSlope(q, lambda), line
+}
+
+// millerLoop returns the product of the optimal ate Miller loops of the
+// pairs, before the final exponentiation. Pairs with the identity are
+// skipped, as they pair to 1.
+func millerLoop(ps []g1, qs []g2) fp12 {
+	f := fp12One()
+	ts := make([]g2, len(qs))
+	copy(ts, qs)
+	skip := func(i int) bool { return ps[i].inf || qs[i].inf }
+
+	for bit := ateLoop.BitLen() - 2; bit >= 0; bit-- {
+		f = f.mul(f)
+		for i := range ps {
+			if skip(i) {
+				continue
+			}
+			var line fp12
+			ts[i], line = lineStep(ts[i], ts[i], ps[i])
+			f = f.mul(line)
+			if ateLoop.Bit(bit) == 1 {
+				ts[i], line = lineStep(ts[i], qs[i], ps[i])
+				f = f.mul(line)
+			}
+		}
+	}
+	for i := range ps {
+		if skip(i) {
+			continue
+		}
+		q1 := qs[i].frobenius()
+		q2 := q1.frobenius().neg()
+		var line fp12
+		ts[i], line = lineStep(ts[i], q1, ps[i])
+		f = f.mul(line)
+		_, line = lineStep(ts[i], q2, ps[i])
+		f = f.mul(line)
+	}
+	return f
+}
+
+// finalExp raises f to (p¹² - 1)/r.
+func finalExp(f fp12) fp12 {
+	f = f.conj().mul(f.inv()) // f^(p⁶ - 1)
+	f = f.frobenius2().mul(f) // ^(p² + 1)
+	return f.exp(hardExp)
+}
+
+// pairingCheck reports whether the product of the pairings e(ps[i], qs[i])
+// is 1.
+func pairingCheck(ps []g1, qs []g2) bool {
+	return finalExp(millerLoop(ps, qs)).equal(fp12One())
+}
@@ -0,0 +1,95 @@
+package zkproof
+
+import (
+	"math/big"
+	"testing"
+)
+
+// The BN254 generators as published in EIP-197; snarkjs writes the G2
+// generator as vk_gamma_2 of every Groth16 key.
+var (
+	g1Gen = g1{x: big.NewInt(1), y: big.NewInt(2)}
+	g2Gen = g2{
+		x: fp2{
+			decimal("10857046999023057135944570762232829481370756359578518086990519993285655852781"),
+			decimal("11559732032986387107991004021392285783925812861821192530917403151452391805634"),
+		},
+		y: fp2{
+			decimal("8495653923123431417604973247489272438418190587263600148770280649306958101930"),
+			decimal("4082367875863433681332203403145435568316851327593401208105741076214120093531"),
+		},
+	}
+)
+
+func decimal(s string) *big.Int {
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic("bad decimal " + s)
+	}
+	return v
+}
+
+// pairing is the reduced pairing e(p, q).
+func pairing(p g1, q g2) fp12 {
+	return finalExp(millerLoop([]g1{p}, []g2{q}))
+}
+
+func TestGenerators(t *testing.T) {
+	if !g1Gen.onCurve() || !g1Gen.mul(groupOrder).inf {
+		t.Error("G1 generator is not a point of order r")
+	}
+	if !g2Gen.onCurve() || !g2Gen.inSubgroup() {
+		t.Error("G2 generator is not a point of order r on the twist")
+	}
+}
+
+// The Frobenius endomorphism acts on G2 as multiplication by p, which pins
+// the frobX and frobY constants.
+func TestFrobeniusIsMultiplicationByP(t *testing.T) {
+	q := g2Gen.mul(big.NewInt(7))
+	got, want := q.frobenius(), q.mul(fieldPrime)
+	if !got.x.equal(want.x) || !got.y.equal(want.y) {
+		t.Error("π(Q) differs from p·Q")
+	}
+}
+
+func TestPairingNonDegenerate(t *testing.T) {
+	e := pairing(g1Gen, g2Gen)
+	if e.equal(fp12One()) {
+		t.Fatal("e(G1, G2) is 1")
+	}
+	if !e.exp(groupOrder).equal(fp12One()) {
+		t.Error("e(G1, G2) is not an r-th root of unity")
+	}
+	if !pairingCheck([]g1{{inf: true}}, []g2{g2Gen}) || !pairingCheck([]g1{g1Gen}, []g2{{inf: true}}) {
+		t.Error("pairing with the identity is not 1")
+	}
+}
+
+func TestPairingBilinear(t *testing.T) {
+	a := decimal("9876543210123456789")
+	b := decimal("1234567890987654321012345")
+	ab := new(big.Int).Mul(a, b)
+
+	// e(a·P, b·Q) = e(P, Q)^(ab) = e(ab·P, Q)
+	if !pairing(g1Gen.mul(a), g2Gen.mul(b)).equal(pairing(g1Gen, g2Gen).exp(ab)) {
+		t.Error("e(aP, bQ) differs from e(P, Q)^(ab)")
+	}
+	if !pairingCheck([]g1{g1Gen.mul(a), g1Gen.mul(ab).neg()}, []g2{g2Gen.mul(b), g2Gen}) {
+		t.Error("e(aP, bQ)·e(-abP, Q) is not 1")
+	}
+	if pairingCheck([]g1{g1Gen.mul(a), g1Gen.mul(ab.Add(ab, big.NewInt(1))).neg()}, []g2{g2Gen.mul(b), g2Gen}) {
+		t.Error("e(aP, bQ)·e(-(ab+1)P, Q) is 1")
+	}
+
+	// e(P + P', Q) = e(P, Q)·e(P', Q)
+	p2 := g1Gen.mul(a)
+	if !pairingCheck([]g1{g1Gen, p2, g1Gen.add(p2).neg()}, []g2{g2Gen, g2Gen, g2Gen}) {
+		t.Error("pairing is not linear in G1")
+	}
+	// e(P, Q + Q') = e(P, Q)·e(P, Q')
+	q2 := g2Gen.mul(b)
+	if !pairingCheck([]g1{g1Gen, g1Gen, g1Gen.neg()}, []g2{g2Gen, q2, g2Gen.add(q2)}) {
+		t.Error("pairing is not linear in G2")
+	}
+}
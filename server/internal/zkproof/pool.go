@@ -0,0 +1,247 @@
+package zkproof
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrClosed is returned by Submit after Close.
+var ErrClosed = errors.New("proof pool is closed")
+
+// PoolConfig configures a Pool.
+type PoolConfig struct {
+	Prover    Prover
+	Workers   int // Proofs generated at once; defaults to runtime.NumCPU()
+	QueueSize int // Jobs waiting for a worker before Submit blocks; defaults to 4 per worker
+
+	// Progress is called as each job finishes, one call at a time. May be nil.
+	Progress func(Progress)
+}
+
+// Progress reports a finished job and the pool's totals so far.
+type Progress struct {
+	Job     Job
+	Err     error // Nil if the proof was generated
+	Elapsed time.Duration
+
+	Submitted int
+	Completed int
+	Failed    int // Including cancelled jobs
+}
+
+// Pending returns the number of submitted jobs not finished yet.
+func (p Progress) Pending() int {
+	return p.Submitted - p.Completed - p.Failed
+}
+
+// Pool generates proofs on a fixed set of workers, taking jobs from a
+// bounded queue in submission order.
+type Pool struct {
+	config PoolConfig
+	queue  chan *Task
+	wg     sync.WaitGroup
+
+	sendMu sync.RWMutex // Held for reading while submitting, for writing by Close
+	closed bool
+
+	mu    sync.Mutex // Guards stats and serializes Progress calls
+	stats Progress
+}
+
+// NewPool starts a pool's workers. Call Close to stop them.
+func NewPool(config PoolConfig) *Pool {
+	if config.Workers <= 0 {
+		config.Workers = runtime.NumCPU()
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 4 * config.Workers
+	}
+	p := &Pool{config: config, queue: make(chan *Task, config.QueueSize)}
+	for i := 0; i < config.Workers; i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+	return p
+}
+
+// Task is a submitted job.
+type Task struct {
+	Job Job
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	done    chan struct{}
+	proof   *Proof
+	err     error
+	elapsed time.Duration
+}
+
+// Cancel stops the job, or drops it if no worker has taken it yet.
+func (t *Task) Cancel() {
+	t.cancel()
+}
+
+// Done is closed when the job has finished.
+func (t *Task) Done() <-chan struct{} {
+	return t.done
+}
+
+// Wait returns the job's proof once it has finished, or ctx's error if ctx is
+// done first. The job keeps running; Cancel stops it.
+func (t *Task) Wait(ctx context.Context) (*Proof, error) {
+	select {
+	case <-t.done:
+		return t.proof, t.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Submit queues a job and returns without waiting for it, unless the queue is
+// full: then it blocks until a worker frees a place or ctx is done. The job
+// is cancelled with ctx.
+func (p *Pool) Submit(ctx context.Context, job Job) (*Task, error) {
+	p.sendMu.RLock()
+	defer p.sendMu.RUnlock()
+	if p.closed {
+		return nil, ErrClosed
+	}
+
+	t := &Task{Job: job, done: make(chan struct{})}
+	t.ctx, t.cancel = context.WithCancel(ctx)
+	// Counted first so a worker never reports the job before it is submitted
+	p.count(1)
+	select {
+	case p.queue <- t:
+		return t, nil
+	case <-ctx.Done():
+		t.cancel()
+		p.count(-1)
+		return nil, ctx.Err()
+	}
+}
+
+func (p *Pool) count(submitted int) {
+	p.mu.Lock()
+	p.stats.Submitted += submitted
+	p.mu.Unlock()
+}
+
+// Close stops accepting jobs and waits for the queued ones to finish. Cancel
+// them first to return sooner.
+func (p *Pool) Close() {
+	p.sendMu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.sendMu.Unlock()
+	p.wg.Wait()
+}
+
+// Stats returns the pool's totals so far.
+func (p *Pool) Stats() Progress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return Progress{Submitted: p.stats.Submitted, Completed: p.stats.Completed, Failed: p.stats.Failed}
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for t := range p.queue {
+		start := time.Now()
+		if err := t.ctx.Err(); err != nil {
+			t.err = err // Cancelled while queued
+		} else {
+			t.proof, t.err = p.config.Prover.Prove(t.ctx, t.Job)
+		}
+		t.elapsed = time.Since(start)
+		t.cancel()
+		p.finish(t)
+		close(t.done)
+	}
+}
+
+func (p *Pool) finish(t *Task) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if t.err != nil {
+		p.stats.Failed++
+	} else {
+		p.stats.Completed++
+	}
+	if p.config.Progress != nil {
+		progress := p.stats
+		progress.Job, progress.Err, progress.Elapsed = t.Job, t.err, t.elapsed
+		p.config.Progress(progress)
+	}
+}
+
+// Result is the outcome of a job of ProveAll.
+type Result struct {
+	Job   Job
+	Proof *Proof
+	Err   error
+}
+
+// BatchError aggregates the failures of ProveAll, keyed by job ID.
+type BatchError struct {
+	Errors map[string]error
+}
+
+func (e *BatchError) Error() string {
+	ids := make([]string, 0, len(e.Errors))
+	for id := range e.Errors {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = fmt.Sprintf("%s: %v", id, e.Errors[id])
+	}
+	return fmt.Sprintf("zkproof: %d proof(s) failed: %s", len(ids), strings.Join(parts, "; "))
+}
+
+// ProveAll generates the proofs of jobs and waits for them. Results are in
+// the order of jobs; jobs without an ID are named by their index. Cancelling
+// ctx cancels the jobs not finished yet. It returns a *BatchError if any job
+// failed; the other results are still set.
+func (p *Pool) ProveAll(ctx context.Context, jobs []Job) ([]Result, error) {
+	results := make([]Result, len(jobs))
+	tasks := make([]*Task, len(jobs))
+	var submitErr error // Fails the jobs not submitted yet
+	for i, job := range jobs {
+		if job.ID == "" {
+			job.ID = "#" + strconv.Itoa(i)
+		}
+		results[i].Job = job
+		if submitErr == nil {
+			tasks[i], submitErr = p.Submit(ctx, job)
+		}
+	}
+
+	failed := make(map[string]error)
+	for i, t := range tasks {
+		if t != nil {
+			<-t.done
+			results[i].Proof, results[i].Err = t.proof, t.err
+		} else {
+			results[i].Err = submitErr
+		}
+		if results[i].Err != nil {
+			failed[results[i].Job.ID] = results[i].Err
+		}
+	}
+	if len(failed) > 0 {
+		return results, &BatchError{Errors: failed}
+	}
+	return results, nil
+}
@@ -0,0 +1,31 @@
+package zkproof
+
+import (
+	"context"
+
+	"sol_privacy/internal/artifacts"
+)
+
+// Rapidsnark proves with rapidsnark's native prover, several times faster
+// than snarkjs and GPU-accelerated in builds that support it. Rapidsnark
+// takes a witness rather than inputs, so the witness is computed first with
+// the snarkjs CLI; both run on this machine.
+type Rapidsnark struct {
+	Artifacts *artifacts.Cache
+	Command   string // Rapidsnark's prover binary; defaults to "prover" on the PATH
+	SnarkJS   string // Computes witnesses; defaults to "snarkjs" on the PATH
+}
+
+// Prove computes the job's witness and proves it.
+func (r *Rapidsnark) Prove(ctx context.Context, job Job) (*Proof, error) {
+	witness, err := Witness(ctx, r.Artifacts, r.SnarkJS, job)
+	if err != nil {
+		return nil, err
+	}
+	return r.ProveWitness(ctx, job.Circuit, witness)
+}
+
+// ProveWitness runs rapidsnark for a witness.
+func (r *Rapidsnark) ProveWitness(ctx context.Context, circuit string, witness []byte) (*Proof, error) {
+	return proveWitness(ctx, r.Artifacts, circuit, witness, orDefault(r.Command, "prover"))
+}
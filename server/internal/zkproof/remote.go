@@ -0,0 +1,275 @@
+package zkproof
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"sol_privacy/internal/artifacts"
+	"sol_privacy/internal/base58"
+)
+
+// RemoteDomain separates remote prover keys from other uses of X25519 keys.
+const RemoteDomain = "shadowpay/zkproof-remote/v1"
+
+// MaxWitnessSize bounds the witnesses a prover service accepts, in bytes.
+const MaxWitnessSize = 256 << 20
+
+// ErrUnreadable is returned for sealed witnesses or proofs that do not
+// decrypt, which includes any sealed to another key or altered in transit.
+var ErrUnreadable = errors.New("sealed data cannot be decrypted")
+
+// RemoteRequest is a witness sent to a prover service.
+type RemoteRequest struct {
+	Circuit            string `json:"circuit"`
+	ZKeySHA256         string `json:"zkey_sha256"`          // Pin of the zkey the witness is for
+	EphemeralPublicKey string `json:"ephemeral_public_key"` // Base58 X25519
+	Witness            []byte `json:"witness"`              // Sealed
+}
+
+// RemoteResponse is a prover service's sealed proof.
+type RemoteResponse struct {
+	Proof []byte `json:"proof"` // Sealed Proof JSON
+}
+
+// Remote proves on a prover service, e.g. rapidsnark on a merchant's GPU
+// host, behind NewRemoteHandler. The witness is computed here, so the
+// inputs stay on this machine. The witness still reveals them to whoever
+// proves it, so it is sealed to the service's X25519 key with an ephemeral
+// key and AES-256-GCM: only the service can read it, whatever terminates
+// TLS in between. The proof comes back sealed under the same exchange.
+type Remote struct {
+	URL        string          // The service's prove endpoint
+	PublicKey  *ecdh.PublicKey // The service's key
+	Artifacts  *artifacts.Cache
+	SnarkJS    string       // Computes witnesses; defaults to "snarkjs" on the PATH
+	HTTPClient *http.Client // Defaults to one with a 5-minute timeout
+}
+
+// Prove computes the job's witness and proves it on the service.
+func (r *Remote) Prove(ctx context.Context, job Job) (*Proof, error) {
+	witness, err := Witness(ctx, r.Artifacts, r.SnarkJS, job)
+	if err != nil {
+		return nil, err
+	}
+	pin := r.Artifacts.Manifest().Circuits[job.Circuit].ZKey.SHA256
+
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := ephemeral.ECDH(r.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	request, response, err := remoteKeys(shared, ephemeral.PublicKey(), r.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	ad := remoteAD(job.Circuit, pin)
+	sealed, err := seal(request, witness, ad)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(RemoteRequest{
+		Circuit:            job.Circuit,
+		ZKeySHA256:         pin,
+		EphemeralPublicKey: base58.Encode(ephemeral.PublicKey().Bytes()),
+		Witness:            sealed,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", r.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := r.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Minute}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("remote prover: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&e)
+		return nil, fmt.Errorf("remote prover: %s: %s", resp.Status, e.Error)
+	}
+	var rr RemoteResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rr); err != nil {
+		return nil, fmt.Errorf("remote prover: %w", err)
+	}
+
+	plaintext, err := open(response, rr.Proof, ad)
+	if err != nil {
+		return nil, fmt.Errorf("remote prover: %w", err)
+	}
+	var proof Proof
+	if err := json.Unmarshal(plaintext, &proof); err != nil {
+		return nil, fmt.Errorf("remote prover: %w", err)
+	}
+	return &proof, nil
+}
+
+// RemoteConfig configures NewRemoteHandler.
+type RemoteConfig struct {
+	Key      *ecdh.PrivateKey // Clients seal witnesses to its public key
+	Manifest *artifacts.Manifest
+	Prover   WitnessProver
+	Workers  int // Proofs generated at once; defaults to 1, as GPU provers use the whole device
+}
+
+// NewRemoteHandler serves Remote clients: it opens each sealed witness,
+// proves it and returns the proof sealed to the client. Witnesses for a zkey
+// other than the manifest's are refused, as their proofs would not verify.
+func NewRemoteHandler(config RemoteConfig) http.Handler {
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
+	slots := make(chan struct{}, config.Workers)
+
+	fail := func(w http.ResponseWriter, status int, msg string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": msg})
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			fail(w, http.StatusMethodNotAllowed, "use POST")
+			return
+		}
+		var req RemoteRequest
+		// Base64 grows the witness by a third
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxWitnessSize/3*4+64<<10)).Decode(&req); err != nil {
+			fail(w, http.StatusBadRequest, "invalid request: "+err.Error())
+			return
+		}
+		circuit, ok := config.Manifest.Circuits[req.Circuit]
+		if !ok {
+			fail(w, http.StatusNotFound, fmt.Sprintf("%v: %s", artifacts.ErrUnknownCircuit, req.Circuit))
+			return
+		}
+		if req.ZKeySHA256 != circuit.ZKey.SHA256 {
+			fail(w, http.StatusConflict, "witness is for another zkey than "+circuit.ZKey.SHA256)
+			return
+		}
+		key, err := base58.Decode(req.EphemeralPublicKey)
+		if err != nil {
+			fail(w, http.StatusBadRequest, "ephemeral_public_key must be a base58 X25519 public key")
+			return
+		}
+		ephemeral, err := ecdh.X25519().NewPublicKey(key)
+		if err != nil {
+			fail(w, http.StatusBadRequest, "ephemeral_public_key must be a base58 X25519 public key")
+			return
+		}
+		shared, err := config.Key.ECDH(ephemeral)
+		if err != nil {
+			fail(w, http.StatusBadRequest, "invalid key exchange: "+err.Error())
+			return
+		}
+		request, response, err := remoteKeys(shared, ephemeral, config.Key.PublicKey())
+		if err != nil {
+			fail(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		ad := remoteAD(req.Circuit, req.ZKeySHA256)
+		witness, err := open(request, req.Witness, ad)
+		if err != nil {
+			fail(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		select {
+		case slots <- struct{}{}:
+			defer func() { <-slots }()
+		case <-r.Context().Done():
+			return
+		}
+		proof, err := config.Prover.ProveWitness(r.Context(), req.Circuit, witness)
+		if err != nil {
+			fail(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		plaintext, err := json.Marshal(proof)
+		if err != nil {
+			fail(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		sealed, err := seal(response, plaintext, ad)
+		if err != nil {
+			fail(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(RemoteResponse{Proof: sealed})
+	})
+}
+
+// remoteKeys derives the keys sealing the witness and the proof from the
+// exchange between the ephemeral and the service key:
+//
+//	SHA-256(domain || "/request" or "/response" || shared || ephemeral || service)
+func remoteKeys(shared []byte, ephemeral, service *ecdh.PublicKey) (request, response cipher.AEAD, err error) {
+	derive := func(label string) (cipher.AEAD, error) {
+		h := sha256.New()
+		h.Write([]byte(RemoteDomain + label))
+		h.Write(shared)
+		h.Write(ephemeral.Bytes())
+		h.Write(service.Bytes())
+		block, err := aes.NewCipher(h.Sum(nil))
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	}
+	if request, err = derive("/request"); err != nil {
+		return nil, nil, err
+	}
+	if response, err = derive("/response"); err != nil {
+		return nil, nil, err
+	}
+	return request, response, nil
+}
+
+// remoteAD binds sealed data to the circuit and zkey it is for.
+func remoteAD(circuit, zkeyPin string) []byte {
+	return []byte(circuit + "\x00" + zkeyPin)
+}
+
+// seal returns nonce || ciphertext.
+func seal(aead cipher.AEAD, plaintext, ad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, ad), nil
+}
+
+func open(aead cipher.AEAD, sealed, ad []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrUnreadable
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], ad)
+	if err != nil {
+		return nil, ErrUnreadable
+	}
+	return plaintext, nil
+}
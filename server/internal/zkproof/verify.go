@@ -0,0 +1,326 @@
+package zkproof
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"sol_privacy/internal/artifacts"
+	"sol_privacy/internal/jsonfile"
+)
+
+var (
+	// ErrMalformed is returned for proofs and keys that do not decode, or
+	// whose points are not on the curve or not in the group.
+	ErrMalformed = errors.New("malformed proof or verification key")
+	// ErrInputCount is returned when the number of public inputs is not the
+	// one the verification key expects.
+	ErrInputCount = errors.New("wrong number of public inputs")
+	// ErrInputMismatch is returned for proofs that verify, but for public
+	// inputs other than the expected ones.
+	ErrInputMismatch = errors.New("proof is for other public inputs")
+	// ErrInvalidProof is returned for proofs that do not verify.
+	ErrInvalidProof = errors.New("invalid proof")
+)
+
+// VerificationKey is a Groth16 verification key as exported by snarkjs
+// (zkey export verificationkey). Points are decimal coordinates: G1 as
+// [x, y, z] and G2 as [[x.c0, x.c1], [y.c0, y.c1], [z.c0, z.c1]], with z = 1,
+// or 0 for the identity.
+type VerificationKey struct {
+	Protocol string     `json:"protocol"`
+	Curve    string     `json:"curve"`
+	NPublic  int        `json:"nPublic"`
+	Alpha1   []string   `json:"vk_alpha_1"`
+	Beta2    [][]string `json:"vk_beta_2"`
+	Gamma2   [][]string `json:"vk_gamma_2"`
+	Delta2   [][]string `json:"vk_delta_2"`
+	IC       [][]string `json:"IC"`
+}
+
+// LoadVerificationKey reads a verification key file.
+func LoadVerificationKey(path string) (*VerificationKey, error) {
+	var vk VerificationKey
+	if err := jsonfile.Load(path, &vk); err != nil {
+		return nil, err
+	}
+	if vk.Protocol == "" {
+		return nil, fmt.Errorf("%s: %w: not a verification key", path, ErrMalformed)
+	}
+	return &vk, nil
+}
+
+// proofPoints is the proof.json snarkjs writes.
+type proofPoints struct {
+	Protocol string     `json:"protocol"`
+	Curve    string     `json:"curve"`
+	A        []string   `json:"pi_a"`
+	B        [][]string `json:"pi_b"`
+	C        []string   `json:"pi_c"`
+}
+
+// Mismatch is a public input the proof commits to differently than
+// expected.
+type Mismatch struct {
+	Index    int
+	Proof    string // As in the proof's public signals
+	Expected string
+}
+
+// VerifyError explains why Verify rejected a proof. It wraps one of
+// ErrMalformed, ErrInputCount, ErrInputMismatch or ErrInvalidProof.
+type VerifyError struct {
+	Err        error
+	Detail     string
+	Mismatches []Mismatch // Set for ErrInputMismatch, and ErrInvalidProof when the signals differ too
+}
+
+func (e *VerifyError) Error() string {
+	var b strings.Builder
+	b.WriteString("zkproof: ")
+	b.WriteString(e.Err.Error())
+	if e.Detail != "" {
+		b.WriteString(": ")
+		b.WriteString(e.Detail)
+	}
+	for _, m := range e.Mismatches {
+		fmt.Fprintf(&b, "; input %d is %s in the proof, expected %s", m.Index, m.Proof, m.Expected)
+	}
+	return b.String()
+}
+
+func (e *VerifyError) Unwrap() error {
+	return e.Err
+}
+
+// Verify checks a Groth16 proof against the public inputs settlement will
+// use and the circuit's verification key, so a bad proof fails here with a
+// reason rather than upstream. With nil publicInputs the proof's own public
+// signals are checked.
+//
+// When the inputs differ from the proof's public signals, the error lists
+// the differences: ErrInputMismatch if the proof is valid for its own
+// signals, so the inputs are what is wrong, and ErrInvalidProof if it is not
+// valid for either, e.g. because it was made with another circuit's zkey.
+func Verify(proof *Proof, publicInputs []string, vk *VerificationKey) error {
+	key, err := parseVerificationKey(vk)
+	if err != nil {
+		return &VerifyError{Err: ErrMalformed, Detail: "verification key: " + err.Error()}
+	}
+	var raw proofPoints
+	if err := json.Unmarshal(proof.Proof, &raw); err != nil {
+		return &VerifyError{Err: ErrMalformed, Detail: "proof: " + err.Error()}
+	}
+	if raw.Protocol != "" && raw.Protocol != "groth16" {
+		return &VerifyError{Err: ErrMalformed, Detail: fmt.Sprintf("proof: protocol %q is not groth16", raw.Protocol)}
+	}
+	var a, c g1
+	var b g2
+	if err := firstError(
+		parseField("pi_a", raw.A, parseG1, &a),
+		parseField("pi_b", raw.B, parseG2, &b),
+		parseField("pi_c", raw.C, parseG1, &c),
+	); err != nil {
+		return &VerifyError{Err: ErrMalformed, Detail: "proof: " + err.Error()}
+	}
+
+	if publicInputs == nil {
+		publicInputs = proof.PublicSignals
+	}
+	expected := len(key.ic) - 1
+	if len(publicInputs) != expected {
+		return &VerifyError{Err: ErrInputCount, Detail: fmt.Sprintf("got %d, the verification key expects %d", len(publicInputs), expected)}
+	}
+	inputs, err := parseInputs(publicInputs)
+	if err != nil {
+		return &VerifyError{Err: ErrMalformed, Detail: err.Error()}
+	}
+	if key.check(a, b, c, inputs) {
+		return nil
+	}
+
+	// Find out whether the proof or the inputs are to blame
+	var mismatches []Mismatch
+	if len(proof.PublicSignals) == len(publicInputs) {
+		for i, s := range proof.PublicSignals {
+			if v, ok := new(big.Int).SetString(s, 10); !ok || v.Cmp(inputs[i]) != 0 {
+				mismatches = append(mismatches, Mismatch{Index: i, Proof: s, Expected: publicInputs[i]})
+			}
+		}
+	}
+	if len(mismatches) > 0 {
+		if own, err := parseInputs(proof.PublicSignals); err == nil && key.check(a, b, c, own) {
+			return &VerifyError{Err: ErrInputMismatch, Mismatches: mismatches}
+		}
+	}
+	return &VerifyError{
+		Err:        ErrInvalidProof,
+		Detail:     "it was made for another circuit or zkey, or altered",
+		Mismatches: mismatches,
+	}
+}
+
+// VerifyCircuit checks a proof with the verification key of a circuit in
+// the cache, after checking the key against its pin.
+func VerifyCircuit(cache *artifacts.Cache, circuit string, proof *Proof, publicInputs []string) error {
+	path, err := cache.File(circuit, artifacts.KindVerificationKey)
+	if err != nil {
+		return err
+	}
+	vk, err := LoadVerificationKey(path)
+	if err != nil {
+		return err
+	}
+	return Verify(proof, publicInputs, vk)
+}
+
+type verificationKey struct {
+	alpha              g1
+	beta, gamma, delta g2
+	ic                 []g1
+}
+
+// check reports whether e(A, B) = e(α, β)·e(Σ inputs·IC, γ)·e(C, δ).
+func (k *verificationKey) check(a g1, b g2, c g1, inputs []*big.Int) bool {
+	x := k.ic[0]
+	for i, in := range inputs {
+		x = x.add(k.ic[i+1].mul(in))
+	}
+	return pairingCheck(
+		[]g1{a.neg(), k.alpha, x, c},
+		[]g2{b, k.beta, k.gamma, k.delta},
+	)
+}
+
+func parseVerificationKey(vk *VerificationKey) (*verificationKey, error) {
+	if vk.Protocol != "groth16" {
+		return nil, fmt.Errorf("protocol %q is not groth16", vk.Protocol)
+	}
+	if vk.Curve != "" && vk.Curve != "bn128" && vk.Curve != "bn254" {
+		return nil, fmt.Errorf("curve %q is not bn128", vk.Curve)
+	}
+	if len(vk.IC) == 0 || (vk.NPublic != 0 && vk.NPublic != len(vk.IC)-1) {
+		return nil, fmt.Errorf("has %d IC points for %d public inputs", len(vk.IC), vk.NPublic)
+	}
+	var k verificationKey
+	k.ic = make([]g1, len(vk.IC))
+	errs := []error{
+		parseField("vk_alpha_1", vk.Alpha1, parseG1, &k.alpha),
+		parseField("vk_beta_2", vk.Beta2, parseG2, &k.beta),
+		parseField("vk_gamma_2", vk.Gamma2, parseG2, &k.gamma),
+		parseField("vk_delta_2", vk.Delta2, parseG2, &k.delta),
+	}
+	for i, p := range vk.IC {
+		errs = append(errs, parseField(fmt.Sprintf("IC[%d]", i), p, parseG1, &k.ic[i]))
+	}
+	if err := firstError(errs...); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// parseField parses the point of a JSON field into p, naming the field in
+// errors.
+func parseField[C any, P any](name string, coords C, parse func(C) (P, error), p *P) error {
+	v, err := parse(coords)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	*p = v
+	return nil
+}
+
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseInputs decodes public inputs, which must be field elements; the
+// settlement verifier rejects any others.
+func parseInputs(signals []string) ([]*big.Int, error) {
+	inputs := make([]*big.Int, len(signals))
+	for i, s := range signals {
+		v, ok := new(big.Int).SetString(s, 10)
+		if !ok || v.Sign() < 0 || v.Cmp(groupOrder) >= 0 {
+			return nil, fmt.Errorf("public input %d (%s) is not a field element", i, s)
+		}
+		inputs[i] = v
+	}
+	return inputs, nil
+}
+
+func parseFp(s string) (*big.Int, error) {
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok || v.Sign() < 0 || v.Cmp(fieldPrime) >= 0 {
+		return nil, fmt.Errorf("%q is not a field element", s)
+	}
+	return v, nil
+}
+
+func parseG1(coords []string) (g1, error) {
+	if len(coords) != 3 && len(coords) != 2 {
+		return g1{}, fmt.Errorf("a G1 point has 3 coordinates, got %d", len(coords))
+	}
+	if len(coords) == 3 && coords[2] == "0" {
+		return g1{inf: true}, nil
+	}
+	if len(coords) == 3 && coords[2] != "1" {
+		return g1{}, errors.New("G1 point is not affine")
+	}
+	x, err := parseFp(coords[0])
+	if err != nil {
+		return g1{}, err
+	}
+	y, err := parseFp(coords[1])
+	if err != nil {
+		return g1{}, err
+	}
+	p := g1{x: x, y: y}
+	if !p.onCurve() {
+		return g1{}, errors.New("G1 point is not on the curve")
+	}
+	return p, nil
+}
+
+func parseG2(coords [][]string) (g2, error) {
+	if len(coords) != 3 && len(coords) != 2 {
+		return g2{}, fmt.Errorf("a G2 point has 3 coordinates, got %d", len(coords))
+	}
+	var xy [2]fp2
+	for i := range xy {
+		if len(coords[i]) != 2 {
+			return g2{}, errors.New("a G2 coordinate has 2 components")
+		}
+		a, err := parseFp(coords[i][0])
+		if err != nil {
+			return g2{}, err
+		}
+		b, err := parseFp(coords[i][1])
+		if err != nil {
+			return g2{}, err
+		}
+		xy[i] = fp2{a, b}
+	}
+	if len(coords) == 3 {
+		switch z := coords[2]; {
+		case len(z) == 2 && z[0] == "0" && z[1] == "0":
+			return g2{inf: true}, nil
+		case len(z) != 2 || z[0] != "1" || z[1] != "0":
+			return g2{}, errors.New("G2 point is not affine")
+		}
+	}
+	p := g2{x: xy[0], y: xy[1]}
+	if !p.onCurve() {
+		return g2{}, errors.New("G2 point is not on the curve")
+	}
+	if !p.inSubgroup() {
+		return g2{}, errors.New("G2 point is not in the group")
+	}
+	return p, nil
+}
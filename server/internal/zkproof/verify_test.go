@@ -0,0 +1,172 @@
+package zkproof
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// trapdoor is a Groth16 setup whose secrets are known, so proofs satisfying
+// the verification equation can be made for any public inputs without a
+// circuit. Keys and proofs are written in the JSON snarkjs exports.
+type trapdoor struct {
+	alpha, beta, gamma, delta *big.Int
+	ic                        []*big.Int // IC[i] = ic[i]·G1
+}
+
+func newTrapdoor(seed int64, nPublic int) *trapdoor {
+	s := func(k int64) *big.Int {
+		v := new(big.Int).Exp(big.NewInt(seed), big.NewInt(k+7), groupOrder)
+		return v.Add(v, big.NewInt(k))
+	}
+	td := &trapdoor{alpha: s(1), beta: s(2), gamma: s(3), delta: s(4)}
+	for i := 0; i <= nPublic; i++ {
+		td.ic = append(td.ic, s(int64(5+i)))
+	}
+	return td
+}
+
+func (td *trapdoor) key() *VerificationKey {
+	vk := &VerificationKey{
+		Protocol: "groth16",
+		Curve:    "bn128",
+		NPublic:  len(td.ic) - 1,
+		Alpha1:   g1JSON(g1Gen.mul(td.alpha)),
+		Beta2:    g2JSON(g2Gen.mul(td.beta)),
+		Gamma2:   g2JSON(g2Gen.mul(td.gamma)),
+		Delta2:   g2JSON(g2Gen.mul(td.delta)),
+	}
+	for _, k := range td.ic {
+		vk.IC = append(vk.IC, g1JSON(g1Gen.mul(k)))
+	}
+	return vk
+}
+
+// prove returns A = a·G1, B = b·G2 and the C for which
+// ab = αβ + γ·Σ xᵢ·icᵢ + δ·c.
+func (td *trapdoor) prove(t *testing.T, inputs ...int64) *Proof {
+	t.Helper()
+	a, b := big.NewInt(123456789), big.NewInt(987654321)
+	c := new(big.Int).Mul(a, b)
+	c.Sub(c, new(big.Int).Mul(td.alpha, td.beta))
+	sum := new(big.Int).Set(td.ic[0])
+	signals := make([]string, len(inputs))
+	for i, x := range inputs {
+		sum.Add(sum, new(big.Int).Mul(big.NewInt(x), td.ic[i+1]))
+		signals[i] = big.NewInt(x).String()
+	}
+	c.Sub(c, sum.Mul(sum, td.gamma))
+	c.Mul(c, new(big.Int).ModInverse(td.delta, groupOrder)).Mod(c, groupOrder)
+
+	return proofOf(t, g1Gen.mul(a), g2Gen.mul(b), g1Gen.mul(c), signals)
+}
+
+func proofOf(t *testing.T, a g1, b g2, c g1, signals []string) *Proof {
+	t.Helper()
+	raw, err := json.Marshal(proofPoints{
+		Protocol: "groth16",
+		Curve:    "bn128",
+		A:        g1JSON(a),
+		B:        g2JSON(b),
+		C:        g1JSON(c),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Proof{Proof: raw, PublicSignals: signals}
+}
+
+func g1JSON(p g1) []string {
+	if p.inf {
+		return []string{"0", "1", "0"}
+	}
+	return []string{p.x.String(), p.y.String(), "1"}
+}
+
+func g2JSON(p g2) [][]string {
+	if p.inf {
+		return [][]string{{"0", "0"}, {"1", "0"}, {"0", "0"}}
+	}
+	return [][]string{
+		{p.x.a.String(), p.x.b.String()},
+		{p.y.a.String(), p.y.b.String()},
+		{"1", "0"},
+	}
+}
+
+func TestVerifyAccepts(t *testing.T) {
+	td := newTrapdoor(3, 2)
+	proof := td.prove(t, 42, 7)
+	if err := Verify(proof, nil, td.key()); err != nil {
+		t.Errorf("own signals: %v", err)
+	}
+	if err := Verify(proof, []string{"42", "7"}, td.key()); err != nil {
+		t.Errorf("expected inputs: %v", err)
+	}
+}
+
+func TestVerifyRejectsOtherInputs(t *testing.T) {
+	td := newTrapdoor(3, 2)
+	err := Verify(td.prove(t, 42, 7), []string{"42", "8"}, td.key())
+	var verr *VerifyError
+	if !errors.As(err, &verr) || !errors.Is(err, ErrInputMismatch) {
+		t.Fatalf("got %v, expected ErrInputMismatch", err)
+	}
+	if len(verr.Mismatches) != 1 || verr.Mismatches[0].Index != 1 {
+		t.Errorf("mismatches %+v, expected only input 1", verr.Mismatches)
+	}
+}
+
+func TestVerifyRejectsAlteredProof(t *testing.T) {
+	td := newTrapdoor(3, 2)
+	var raw proofPoints
+	if err := json.Unmarshal(td.prove(t, 42, 7).Proof, &raw); err != nil {
+		t.Fatal(err)
+	}
+	c, err := parseG1(raw.C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := parseG1(raw.A)
+	b, _ := parseG2(raw.B)
+	altered := proofOf(t, a, b, c.add(g1Gen), []string{"42", "7"})
+	if err := Verify(altered, nil, td.key()); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("got %v, expected ErrInvalidProof", err)
+	}
+}
+
+func TestVerifyRejectsOtherKey(t *testing.T) {
+	proof := newTrapdoor(3, 2).prove(t, 42, 7)
+	if err := Verify(proof, nil, newTrapdoor(5, 2).key()); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("got %v, expected ErrInvalidProof", err)
+	}
+}
+
+func TestVerifyRejectsMalformed(t *testing.T) {
+	td := newTrapdoor(3, 2)
+	proof := td.prove(t, 42, 7)
+
+	if err := Verify(proof, []string{"42"}, td.key()); !errors.Is(err, ErrInputCount) {
+		t.Errorf("one input: got %v, expected ErrInputCount", err)
+	}
+	if err := Verify(proof, []string{"42", groupOrder.String()}, td.key()); !errors.Is(err, ErrMalformed) {
+		t.Errorf("input r: got %v, expected ErrMalformed", err)
+	}
+
+	offCurve := proofOf(t, g1{x: big.NewInt(1), y: big.NewInt(3)}, g2Gen, g1Gen, proof.PublicSignals)
+	if err := Verify(offCurve, nil, td.key()); !errors.Is(err, ErrMalformed) {
+		t.Errorf("pi_a off the curve: got %v, expected ErrMalformed", err)
+	}
+
+	vk := td.key()
+	vk.Protocol = "plonk"
+	if err := Verify(proof, nil, vk); !errors.Is(err, ErrMalformed) {
+		t.Errorf("plonk key: got %v, expected ErrMalformed", err)
+	}
+	vk = td.key()
+	vk.IC = vk.IC[:2]
+	if err := Verify(proof, nil, vk); !errors.Is(err, ErrMalformed) {
+		t.Errorf("IC shorter than nPublic: got %v, expected ErrMalformed", err)
+	}
+}
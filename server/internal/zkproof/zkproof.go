@@ -0,0 +1,214 @@
+// Package zkproof generates Groth16 proofs for the circuits of the artifacts
+// package, with snarkjs, rapidsnark or a remote prover service behind the
+// Prover interface; Open picks one from a URI. Proving is CPU-heavy, so a
+// Pool runs jobs on a bounded number of workers, reports progress as they
+// finish and cancels them with their context; batch payment preparation
+// submits its proofs to one pool. Verify checks proofs locally before they
+// are sent for settlement.
+package zkproof
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"sol_privacy/internal/artifacts"
+	"sol_privacy/internal/base58"
+)
+
+// Job is a proof to generate.
+type Job struct {
+	ID      string         // Names the job in progress reports and errors
+	Circuit string         // Circuit in the artifacts manifest
+	Input   map[string]any // Circuit input signals
+}
+
+// Proof is a Groth16 proof and the public signals it proves.
+type Proof struct {
+	Proof         json.RawMessage `json:"proof"`
+	PublicSignals []string        `json:"public_signals"`
+}
+
+// Prover generates the proof of a job. Implementations must stop when ctx is
+// done and be safe for concurrent use.
+type Prover interface {
+	Prove(ctx context.Context, job Job) (*Proof, error)
+}
+
+// ProverFunc adapts a function to a Prover.
+type ProverFunc func(ctx context.Context, job Job) (*Proof, error)
+
+// Prove calls f.
+func (f ProverFunc) Prove(ctx context.Context, job Job) (*Proof, error) {
+	return f(ctx, job)
+}
+
+// Open returns the prover for a URI, proving with the circuit files of cache:
+//
+//	snarkjs[:<command>]                  // The default for an empty URI
+//	rapidsnark[:<prover binary>]
+//	https://<host>/<path>#<base58 key>   // A prover service and its X25519 key
+func Open(uri string, cache *artifacts.Cache) (Prover, error) {
+	scheme, rest, _ := strings.Cut(uri, ":")
+	switch scheme {
+	case "", "snarkjs":
+		return &SnarkJS{Artifacts: cache, Command: rest}, nil
+	case "rapidsnark":
+		return &Rapidsnark{Artifacts: cache, Command: rest}, nil
+	case "http", "https":
+		endpoint, encoded, ok := strings.Cut(uri, "#")
+		if !ok {
+			return nil, fmt.Errorf("zkproof: %q: expected the service's key after #", uri)
+		}
+		b, err := base58.Decode(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("zkproof: %q: invalid service key: %w", uri, err)
+		}
+		key, err := ecdh.X25519().NewPublicKey(b)
+		if err != nil {
+			return nil, fmt.Errorf("zkproof: %q: invalid service key: %w", uri, err)
+		}
+		return &Remote{URL: endpoint, PublicKey: key, Artifacts: cache}, nil
+	}
+	return nil, fmt.Errorf("zkproof: %q: unsupported prover", uri)
+}
+
+// WitnessProver proves a circuit from a witness computed elsewhere, as the
+// remote prover service does.
+type WitnessProver interface {
+	ProveWitness(ctx context.Context, circuit string, witness []byte) (*Proof, error)
+}
+
+// SnarkJS proves with the snarkjs CLI, using the circuit files of a cache.
+// Each proof runs in its own process, which is killed when the job is
+// cancelled.
+type SnarkJS struct {
+	Artifacts *artifacts.Cache
+	Command   string // Defaults to "snarkjs" on the PATH
+}
+
+// Prove runs snarkjs groth16 fullprove for the job.
+func (s *SnarkJS) Prove(ctx context.Context, job Job) (*Proof, error) {
+	// Opening checks the files against their pins right before use
+	paths, err := s.Artifacts.Open(job.Circuit)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "zkproof-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	inputPath, err := writeInput(dir, job.Input)
+	if err != nil {
+		return nil, err
+	}
+	return prove(ctx, dir, orDefault(s.Command, "snarkjs"), "groth16", "fullprove", inputPath, paths.WASM, paths.ZKey)
+}
+
+// ProveWitness runs snarkjs groth16 prove for a witness.
+func (s *SnarkJS) ProveWitness(ctx context.Context, circuit string, witness []byte) (*Proof, error) {
+	return proveWitness(ctx, s.Artifacts, circuit, witness, orDefault(s.Command, "snarkjs"), "groth16", "prove")
+}
+
+// Witness computes the witness of a job with the circuit's WASM, through the
+// snarkjs CLI. The witness holds the job's secret inputs.
+func Witness(ctx context.Context, cache *artifacts.Cache, command string, job Job) ([]byte, error) {
+	paths, err := cache.Open(job.Circuit)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "zkproof-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	inputPath, err := writeInput(dir, job.Input)
+	if err != nil {
+		return nil, err
+	}
+	witnessPath := filepath.Join(dir, "witness.wtns")
+	if err := run(ctx, orDefault(command, "snarkjs"), "wtns", "calculate", paths.WASM, inputPath, witnessPath); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(witnessPath)
+}
+
+// proveWitness writes the witness and runs a prover command with the
+// circuit's zkey and the witness appended to args.
+func proveWitness(ctx context.Context, cache *artifacts.Cache, circuit string, witness []byte, command string, args ...string) (*Proof, error) {
+	paths, err := cache.Open(circuit)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "zkproof-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	witnessPath := filepath.Join(dir, "witness.wtns")
+	if err := os.WriteFile(witnessPath, witness, 0o600); err != nil {
+		return nil, err
+	}
+	return prove(ctx, dir, command, append(args, paths.ZKey, witnessPath)...)
+}
+
+func writeInput(dir string, input map[string]any) (string, error) {
+	b, err := json.Marshal(input)
+	if err != nil {
+		return "", fmt.Errorf("invalid input: %w", err)
+	}
+	path := filepath.Join(dir, "input.json")
+	return path, os.WriteFile(path, b, 0o600)
+}
+
+// prove runs a prover command that takes the proof and public signal files
+// to write as its last two arguments, as snarkjs and rapidsnark do.
+func prove(ctx context.Context, dir, command string, args ...string) (*Proof, error) {
+	proofPath := filepath.Join(dir, "proof.json")
+	publicPath := filepath.Join(dir, "public.json")
+	if err := run(ctx, command, append(args, proofPath, publicPath)...); err != nil {
+		return nil, err
+	}
+
+	var proof Proof
+	var err error
+	if proof.Proof, err = os.ReadFile(proofPath); err != nil {
+		return nil, fmt.Errorf("%s wrote no proof: %w", filepath.Base(command), err)
+	}
+	public, err := os.ReadFile(publicPath)
+	if err != nil {
+		return nil, fmt.Errorf("%s wrote no public signals: %w", filepath.Base(command), err)
+	}
+	if err := json.Unmarshal(public, &proof.PublicSignals); err != nil {
+		return nil, fmt.Errorf("%s public signals: %w", filepath.Base(command), err)
+	}
+	return &proof, nil
+}
+
+func run(ctx context.Context, command string, args ...string) error {
+	out, err := exec.CommandContext(ctx, command, args...).CombinedOutput()
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("%s: %w: %s", filepath.Base(command), err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
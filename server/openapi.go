@@ -0,0 +1,9 @@
+package shadowpay
+
+import _ "embed"
+
+// OpenAPI is the proxy API's OpenAPI document, openapi.yaml. The proxy checks
+// request bodies against its schemas.
+//
+//go:embed openapi.yaml
+var OpenAPI []byte
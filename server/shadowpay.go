@@ -4,12 +4,15 @@ import (
 	"context"
 
 	"sol_privacy/internal/authorization"
+	"sol_privacy/internal/capabilities"
 	"sol_privacy/internal/client"
 	"sol_privacy/internal/escrow"
 	"sol_privacy/internal/intent"
 	"sol_privacy/internal/keys"
 	"sol_privacy/internal/merchant"
+	"sol_privacy/internal/nullifier"
 	"sol_privacy/internal/payment"
+	"sol_privacy/internal/platform"
 	"sol_privacy/internal/pool"
 	"sol_privacy/internal/privacy"
 	"sol_privacy/internal/receipt"
@@ -21,7 +24,8 @@ import (
 
 // ShadowPay is the main SDK client for interacting with the ShadowPay API.
 type ShadowPay struct {
-	client *client.Client
+	client       *client.Client
+	capabilities *capabilities.Discoverer
 
 	// Services
 	Keys          *keys.Service
@@ -37,6 +41,8 @@ type ShadowPay struct {
 	Receipt       *receipt.Service
 	Token         *token.Service
 	Authorization *authorization.Service
+	Nullifiers    *nullifier.Service
+	Platform      *platform.Service
 }
 
 // New creates a new ShadowPay SDK client.
@@ -53,8 +59,9 @@ func New(apiKey string, opts ...client.Option) *ShadowPay {
 		return c.Do(req, result)
 	}
 
-	return &ShadowPay{
+	sp := &ShadowPay{
 		client:        c,
+		capabilities:  capabilities.New(doRequest, 0),
 		Keys:          keys.NewService(doRequest),
 		Escrow:        escrow.NewService(doRequest),
 		Payment:       payment.NewService(doRequest),
@@ -68,7 +75,41 @@ func New(apiKey string, opts ...client.Option) *ShadowPay {
 		Receipt:       receipt.NewService(doRequest),
 		Token:         token.NewService(doRequest),
 		Authorization: authorization.NewService(doRequest),
+		Nullifiers:    nullifier.NewService(doRequest),
+		Platform:      platform.NewService(doRequest),
 	}
+
+	// Services adapt to the API's features and x402 version
+	sp.Payment.SetCapabilities(sp.capabilities)
+	sp.Verify.SetCapabilities(sp.capabilities)
+	sp.ShadowID.SetCapabilities(sp.capabilities)
+	// Endpoints the upstream API reference does not document stay off until
+	// the API advertises them
+	sp.Receipt.SetCapabilities(sp.capabilities)
+	sp.Authorization.SetCapabilities(sp.capabilities)
+	sp.Nullifiers.SetCapabilities(sp.capabilities)
+	sp.Platform.SetCapabilities(sp.capabilities)
+	return sp
+}
+
+// Capabilities returns what the API supports: its version, x402 version,
+// payment schemes and optional features such as Umbra. They are discovered
+// on first use and cached for capabilities.DefaultTTL.
+func (s *ShadowPay) Capabilities(ctx context.Context) (*capabilities.Capabilities, error) {
+	return s.capabilities.Get(ctx)
+}
+
+// RefreshCapabilities discovers the API's capabilities again, e.g. after
+// the API was upgraded.
+func (s *ShadowPay) RefreshCapabilities(ctx context.Context) (*capabilities.Capabilities, error) {
+	return s.capabilities.Refresh(ctx)
+}
+
+// Supports reports whether the API offers a feature, one of the
+// types.Feature constants. It assumes the features the API has always had
+// while discovery fails, so it never fails itself.
+func (s *ShadowPay) Supports(ctx context.Context, feature string) bool {
+	return s.capabilities.Supports(ctx, feature)
 }
 
 // GetAPIKey returns the API key configured for this client.